@@ -0,0 +1,101 @@
+package dragoman
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	// BilingualMarkdown renders each paragraph of the translation below its
+	// original, which is quoted using a Markdown blockquote.
+	BilingualMarkdown BilingualFormat = "markdown"
+
+	// BilingualHTML renders each paragraph of the translation as an HTML
+	// paragraph, followed by a collapsed <details> element that contains the
+	// original.
+	BilingualHTML BilingualFormat = "html"
+)
+
+// BilingualFormat is the output format of [RenderBilingual].
+type BilingualFormat string
+
+// String returns the name of the format.
+func (f BilingualFormat) String() string {
+	return string(f)
+}
+
+var paragraphSeparator = regexp.MustCompile(`\n[ \t]*\n`)
+
+// RenderBilingual renders a document that interleaves the original chunks of a
+// translated document with their translations, for example to review a
+// translation or for language learning. Chunks whose original and translation
+// have the same number of paragraphs are interleaved paragraph by paragraph;
+// all other chunks are interleaved as a whole.
+func RenderBilingual(format BilingualFormat, pairs []ChunkPair) (string, error) {
+	var render func(ChunkPair) string
+	switch format {
+	case BilingualMarkdown:
+		render = renderBilingualMarkdown
+	case BilingualHTML:
+		render = renderBilingualHTML
+	default:
+		return "", fmt.Errorf("unknown bilingual format %q", format)
+	}
+
+	var blocks []string
+	for _, pair := range pairs {
+		for _, p := range paragraphPairs(pair) {
+			blocks = append(blocks, render(p))
+		}
+	}
+
+	return addNewline(strings.Join(blocks, "\n\n")), nil
+}
+
+// paragraphPairs splits a chunk pair into pairs of paragraphs if the original
+// and the translation have the same number of paragraphs.
+func paragraphPairs(pair ChunkPair) []ChunkPair {
+	sources := paragraphs(pair.Source)
+	translations := paragraphs(pair.Translation)
+	if len(sources) != len(translations) {
+		return []ChunkPair{{Source: strings.TrimSpace(pair.Source), Translation: strings.TrimSpace(pair.Translation)}}
+	}
+
+	out := make([]ChunkPair, len(sources))
+	for i := range sources {
+		out[i] = ChunkPair{Source: sources[i], Translation: translations[i]}
+	}
+	return out
+}
+
+func paragraphs(text string) []string {
+	var out []string
+	for _, p := range paragraphSeparator.Split(strings.TrimSpace(text), -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func renderBilingualMarkdown(pair ChunkPair) string {
+	lines := strings.Split(pair.Source, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n\n" + pair.Translation
+}
+
+func renderBilingualHTML(pair ChunkPair) string {
+	return fmt.Sprintf(
+		"<p>%s</p>\n<details><summary>Original</summary><p>%s</p></details>",
+		htmlText(pair.Translation),
+		htmlText(pair.Source),
+	)
+}
+
+func htmlText(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>\n")
+}
@@ -0,0 +1,65 @@
+package dragoman_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestRenderBilingual(t *testing.T) {
+	pairs := []dragoman.ChunkPair{
+		{Source: "# Hallo\n\nHallo <Welt>.\n", Translation: "# Hello\n\nHello <world>."},
+		{Source: "Eins.\n\nZwei.", Translation: "One. Two."},
+	}
+
+	tests := map[dragoman.BilingualFormat]string{
+		dragoman.BilingualMarkdown: heredoc.Doc(`
+			> # Hallo
+
+			# Hello
+
+			> Hallo <Welt>.
+
+			Hello <world>.
+
+			> Eins.
+			>
+			> Zwei.
+
+			One. Two.
+		`),
+		dragoman.BilingualHTML: heredoc.Doc(`
+			<p># Hello</p>
+			<details><summary>Original</summary><p># Hallo</p></details>
+
+			<p>Hello &lt;world&gt;.</p>
+			<details><summary>Original</summary><p>Hallo &lt;Welt&gt;.</p></details>
+
+			<p>One. Two.</p>
+			<details><summary>Original</summary><p>Eins.<br>
+			<br>
+			Zwei.</p></details>
+		`),
+	}
+
+	for format, want := range tests {
+		t.Run(format.String(), func(t *testing.T) {
+			got, err := dragoman.RenderBilingual(format, pairs)
+			if err != nil {
+				t.Fatalf("RenderBilingual() failed: %v", err)
+			}
+
+			if want != got {
+				t.Fatalf("RenderBilingual() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestRenderBilingual_unknownFormat(t *testing.T) {
+	if _, err := dragoman.RenderBilingual("pdf", nil); err == nil {
+		t.Fatalf("RenderBilingual() should fail for an unknown format")
+	}
+}
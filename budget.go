@@ -0,0 +1,99 @@
+package dragoman
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is the cause of a [*StoppedError] of a translation that
+// was stopped because the next chunk would exceed its [Budget].
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget limits the token usage and cost of one or more translations. Before
+// a chunk is sent to the model, the usage of the chunks that were translated
+// with the budget so far plus the estimated usage of the chunk is compared to
+// the limits. If a limit would be exceeded, the translation stops like it
+// would using [TranslateParams.Stop], with [ErrBudgetExceeded] as the cause.
+// Like [Estimate], the completion tokens of a chunk are approximated by the
+// token count of the chunk itself.
+//
+// A Budget must not be copied after it was used. It can be shared by several
+// translations, for example to limit the usage of a whole run.
+type Budget struct {
+	// MaxTokens is the maximum number of prompt and completion tokens. Zero
+	// means no limit.
+	MaxTokens int
+
+	// MaxCost is the maximum cost in USD, computed using Pricing. Zero means
+	// no limit.
+	MaxCost float64
+
+	// Pricing is the price of the model.
+	Pricing Pricing
+
+	// Tokens counts the tokens of the prompts and chunks. If it is nil, the
+	// chunks are not estimated, so that only the usage that was already spent
+	// is compared to the limits.
+	Tokens TokenCounter
+
+	mux  sync.Mutex
+	used Usage
+}
+
+// Used returns the usage of the chunks that were translated with the budget.
+func (b *Budget) Used() Usage {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.used
+}
+
+// Cost returns the cost in USD of the usage of the budget.
+func (b *Budget) Cost() float64 {
+	used := b.Used()
+	return b.Pricing.Cost(used.PromptTokens, used.CompletionTokens)
+}
+
+func (b *Budget) add(usage Usage) {
+	if b == nil {
+		return
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.used = b.used.Add(usage)
+}
+
+// check returns an error that wraps [ErrBudgetExceeded] if the translation of
+// a chunk with the given prompt would exceed the budget.
+func (b *Budget) check(chunk, prompt string) error {
+	if b == nil || b.MaxTokens <= 0 && b.MaxCost <= 0 {
+		return nil
+	}
+
+	var est ChunkEstimate
+	if b.Tokens != nil {
+		if prompt == "" {
+			prompt = chunk
+		}
+		var err error
+		if est.PromptTokens, err = b.Tokens(prompt); err != nil {
+			return fmt.Errorf("count prompt tokens: %w", err)
+		}
+		if est.CompletionTokens, err = b.Tokens(chunk); err != nil {
+			return fmt.Errorf("count completion tokens: %w", err)
+		}
+	}
+
+	used := b.Used()
+	if tokens := used.TotalTokens() + est.PromptTokens + est.CompletionTokens; b.MaxTokens > 0 && tokens > b.MaxTokens {
+		return fmt.Errorf("%w: %d tokens used, the next chunk needs about %d more (limit %d)", ErrBudgetExceeded, used.TotalTokens(), est.PromptTokens+est.CompletionTokens, b.MaxTokens)
+	}
+
+	spent := b.Pricing.Cost(used.PromptTokens, used.CompletionTokens)
+	next := b.Pricing.Cost(est.PromptTokens, est.CompletionTokens)
+	if b.MaxCost > 0 && spent+next > b.MaxCost {
+		return fmt.Errorf("%w: $%.4f spent, the next chunk costs about $%.4f more (limit $%.2f)", ErrBudgetExceeded, spent, next, b.MaxCost)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+func TestTranslator_Translate_budget(t *testing.T) {
+	source := heredoc.Doc(`
+		# One
+
+		Eins
+
+		# Two
+
+		Zwei
+
+		# Three
+
+		Drei
+	`)
+
+	var prompts int
+	model := dragoman.ModelFunc(func(ctx context.Context, prompt string) (string, error) {
+		prompts++
+		dragoman.ReportUsage(ctx, dragoman.Usage{Requests: 1, PromptTokens: 40, CompletionTokens: 10})
+		return "# Translated", nil
+	})
+
+	budget := &dragoman.Budget{
+		MaxTokens: 101,
+		Tokens: func(text string) (int, error) {
+			return 1, nil
+		},
+	}
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:    source,
+		SplitChunks: []string{"# "},
+		Budget:      budget,
+	})
+	if !errors.Is(err, dragoman.ErrBudgetExceeded) || !errors.Is(err, dragoman.ErrStopped) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrBudgetExceeded, err)
+	}
+
+	var stopped *dragoman.StoppedError
+	if !errors.As(err, &stopped) || stopped.Chunk != 3 {
+		t.Fatalf("Translate() should stop before chunk 3; got %v", err)
+	}
+
+	if want := "# Translated\n\n# Translated\n"; result != want {
+		t.Fatalf("Translate() should return the translated chunks %q; got %q", want, result)
+	}
+
+	if want := (dragoman.Usage{Requests: 2, PromptTokens: 80, CompletionTokens: 20}); budget.Used() != want {
+		t.Fatalf("Used() should return %+v; got %+v", want, budget.Used())
+	}
+
+	// The budget is shared with the next translation.
+	_, err = dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: "Hallo Welt!",
+		Budget:   budget,
+	})
+	if !errors.Is(err, dragoman.ErrBudgetExceeded) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrBudgetExceeded, err)
+	}
+
+	if prompts != 2 {
+		t.Fatalf("the model should be prompted 2 times; got %d", prompts)
+	}
+}
+
+func TestTranslator_Translate_budgetCost(t *testing.T) {
+	model := dragoman.ModelFunc(func(ctx context.Context, prompt string) (string, error) {
+		dragoman.ReportUsage(ctx, dragoman.Usage{Requests: 1, PromptTokens: 1000, CompletionTokens: 1000})
+		return "Hello", nil
+	})
+
+	budget := &dragoman.Budget{
+		MaxCost: 0.01,
+		Pricing: dragoman.Pricing{Prompt: 2, Completion: 8},
+		Tokens: func(text string) (int, error) {
+			return len(strings.Fields(text)), nil
+		},
+	}
+
+	translator := dragoman.NewTranslator(model)
+	if _, err := translator.Translate(context.Background(), dragoman.TranslateParams{Document: "Hallo", Budget: budget}); err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	if got := budget.Cost(); got != 0.01 {
+		t.Fatalf("Cost() should return 0.01; got %v", got)
+	}
+
+	_, err := translator.Translate(context.Background(), dragoman.TranslateParams{Document: "Hallo", Budget: budget})
+	if !errors.Is(err, dragoman.ErrBudgetExceeded) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrBudgetExceeded, err)
+	}
+}
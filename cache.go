@@ -0,0 +1,118 @@
+package dragoman
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	// NormalizeWhitespace collapses consecutive whitespace into a single space
+	// and trims leading and trailing whitespace before comparing segments.
+	NormalizeWhitespace Normalization = "whitespace"
+
+	// NormalizeCase compares segments case-insensitively.
+	NormalizeCase Normalization = "case"
+)
+
+// Normalization is a rule that is applied to a text segment before it is used
+// as a key in a [SegmentCache]. Segments that are equal after normalization
+// share the same translation.
+type Normalization string
+
+// String returns the name of the normalization rule.
+func (n Normalization) String() string {
+	return string(n)
+}
+
+func (n Normalization) apply(text string) string {
+	switch n {
+	case NormalizeWhitespace:
+		return strings.Join(strings.Fields(text), " ")
+	case NormalizeCase:
+		return strings.ToLower(text)
+	default:
+		return text
+	}
+}
+
+// SegmentCache caches translations of text segments keyed by their normalized
+// text, so that trivially different occurrences of the same segment (for
+// example "Hello world." and "Hello  world.") are translated only once. A
+// SegmentCache is safe for concurrent use.
+type SegmentCache struct {
+	rules []Normalization
+
+	mux     sync.RWMutex
+	entries map[string]string
+}
+
+// NewSegmentCache returns a new [*SegmentCache] that normalizes segments using
+// the provided rules. If no rules are provided, segments are only normalized
+// by [NormalizeWhitespace].
+func NewSegmentCache(rules ...Normalization) *SegmentCache {
+	if len(rules) == 0 {
+		rules = []Normalization{NormalizeWhitespace}
+	}
+	return &SegmentCache{
+		rules:   rules,
+		entries: make(map[string]string),
+	}
+}
+
+// Key returns the normalized cache key of the given text segment.
+func (c *SegmentCache) Key(text string) string {
+	for _, rule := range c.rules {
+		text = rule.apply(text)
+	}
+	return text
+}
+
+// Get returns the cached translation of the given text segment and whether the
+// cache contained a translation for it.
+func (c *SegmentCache) Get(text string) (string, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	translation, ok := c.entries[c.Key(text)]
+	return translation, ok
+}
+
+// Put caches the translation of the given text segment.
+func (c *SegmentCache) Put(text, translation string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.entries[c.Key(text)] = translation
+}
+
+// JSON returns the cached translations of the string values of the JSON object,
+// keyed by their key paths. Strip them from the object before it is translated
+// and apply them to the translation, so that strings that were already
+// translated, for example in another file of the same run, are not sent to the
+// model again.
+func (c *SegmentCache) JSON(doc map[string]any) JSONOverrides {
+	out := make(JSONOverrides)
+	for _, path := range allKeys(doc) {
+		value, ok := jsonValue(doc, path).(string)
+		if !ok || value == "" {
+			continue
+		}
+		if translation, ok := c.Get(value); ok {
+			out[strings.Join(path, ".")] = translation
+		}
+	}
+	return out
+}
+
+// PutJSON caches the string values of the translated JSON object as the
+// translations of the string values at the same key paths of the source
+// object.
+func (c *SegmentCache) PutJSON(source, translated map[string]any) {
+	for _, path := range allKeys(source) {
+		value, ok := jsonValue(source, path).(string)
+		if !ok || value == "" {
+			continue
+		}
+		if translation, ok := jsonValue(translated, path).(string); ok && translation != "" {
+			c.Put(value, translation)
+		}
+	}
+}
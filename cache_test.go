@@ -0,0 +1,71 @@
+package dragoman_test
+
+import (
+	"testing"
+
+	tcmp "github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestSegmentCache(t *testing.T) {
+	cache := dragoman.NewSegmentCache()
+	cache.Put("Hello world.", "Hallo Welt.")
+
+	if got, ok := cache.Get("  Hello   world. "); !ok || got != "Hallo Welt." {
+		t.Fatalf("Get(): got %q, %v; want %q, true", got, ok, "Hallo Welt.")
+	}
+
+	if _, ok := cache.Get("hello world."); ok {
+		t.Fatalf("Get() should be case-sensitive without %q rule", dragoman.NormalizeCase)
+	}
+}
+
+func TestSegmentCache_case(t *testing.T) {
+	cache := dragoman.NewSegmentCache(dragoman.NormalizeWhitespace, dragoman.NormalizeCase)
+	cache.Put("Hello world.", "Hallo Welt.")
+
+	if got, ok := cache.Get("HELLO  WORLD."); !ok || got != "Hallo Welt." {
+		t.Fatalf("Get(): got %q, %v; want %q, true", got, ok, "Hallo Welt.")
+	}
+}
+
+func TestSegmentCache_JSON(t *testing.T) {
+	cache := dragoman.NewSegmentCache()
+	cache.PutJSON(map[string]any{
+		"cancel": "Cancel",
+		"nav":    map[string]any{"home": "Home"},
+		"count":  3,
+	}, map[string]any{
+		"cancel": "Abbrechen",
+		"nav":    map[string]any{"home": "Startseite"},
+		"count":  3,
+	})
+
+	source := map[string]any{
+		"dialog": map[string]any{"cancel": "Cancel", "confirm": "Confirm"},
+		"links":  []any{"Home", "About"},
+	}
+
+	cached := cache.JSON(source)
+
+	want := dragoman.JSONOverrides{"dialog.cancel": "Abbrechen", "links.0": "Startseite"}
+	if !tcmp.Equal(want, cached) {
+		t.Fatalf("JSON(): got %v; want %v", cached, want)
+	}
+
+	stripped := cached.Strip(source)
+	if want := map[string]any{"dialog": map[string]any{"confirm": "Confirm"}, "links": []any{"Home", "About"}}; !tcmp.Equal(want, stripped) {
+		t.Fatalf("Strip(): got %v; want %v", stripped, want)
+	}
+
+	translated := map[string]any{"dialog": map[string]any{"confirm": "Bestätigen"}, "links": []any{"Home", "Über uns"}}
+	cached.Apply(source, translated)
+
+	wantTranslated := map[string]any{
+		"dialog": map[string]any{"cancel": "Abbrechen", "confirm": "Bestätigen"},
+		"links":  []any{"Startseite", "Über uns"},
+	}
+	if !tcmp.Equal(wantTranslated, translated) {
+		t.Fatalf("Apply(): got %v; want %v", translated, wantTranslated)
+	}
+}
@@ -0,0 +1,100 @@
+package dragoman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+)
+
+// carryOver collects the previously translated chunks of a document that are
+// carried over into the prompts of the following chunks.
+type carryOver struct {
+	window  int
+	summary bool
+	chunks  []string
+	notes   string
+}
+
+func newCarryOver(params TranslateParams) *carryOver {
+	return &carryOver{window: params.CarryOver, summary: params.CarrySummary}
+}
+
+func (c *carryOver) enabled() bool {
+	return c.window > 0 || c.summary
+}
+
+// section returns the section of the prompt that contains the carried-over
+// text, or an empty string if nothing was translated yet.
+func (c *carryOver) section() string {
+	if c.summary {
+		if c.notes == "" {
+			return ""
+		}
+		return "The document continues a text that was already translated. Keep terminology, names, pronouns and tone consistent with the following notes on the translation. Do not include them in the output:\n" +
+			"---<PREVIOUS_BEGIN>---\n" + c.notes + "\n---<PREVIOUS_END>---\n"
+	}
+
+	if len(c.chunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("The document continues the following previously translated text. Keep terminology, names, pronouns and tone consistent with it. Do not include it in the output:\n")
+	b.WriteString("---<PREVIOUS_BEGIN>---\n")
+	b.WriteString(strings.Join(c.chunks, "\n\n"))
+	b.WriteString("\n---<PREVIOUS_END>---\n")
+	return b.String()
+}
+
+// add carries over the translation of a chunk. If the carry-over is a
+// summary, the model updates the summary with the translation.
+func (c *carryOver) add(ctx context.Context, model Model, translation string) error {
+	translation = strings.TrimSpace(translation)
+	if translation == "" {
+		return nil
+	}
+
+	if !c.summary {
+		c.chunks = append(c.chunks, translation)
+		if len(c.chunks) > c.window {
+			c.chunks = c.chunks[len(c.chunks)-c.window:]
+		}
+		return nil
+	}
+
+	if model == nil {
+		return nil
+	}
+
+	notes, err := model.Chat(ctx, carrySummaryPrompt(c.notes, translation))
+	if err != nil {
+		return fmt.Errorf("summarize translated chunk: %w", err)
+	}
+	c.notes = trimDividers(notes)
+
+	return nil
+}
+
+func carrySummaryPrompt(notes, translation string) string {
+	if notes == "" {
+		notes = "(none)"
+	}
+
+	return heredoc.Docf(`
+		You are keeping notes for a translator who translates a long document chunk by chunk. Update the notes with the newly translated chunk. The notes must list the translations of recurring terms and names, who pronouns refer to, and the tone and form of address, so that the next chunks are translated consistently. Keep the notes short.
+
+		Current notes:
+		---<NOTES_BEGIN>---
+		%s
+		---<NOTES_END>---
+
+		Newly translated chunk:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		Output only the updated notes, no chat.
+	`, notes, translation)
+}
@@ -0,0 +1,103 @@
+package dragoman_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+var carrySource = heredoc.Doc(`
+	# Eins
+
+	Anna liest.
+
+	# Zwei
+
+	Sie lacht.
+
+	# Drei
+
+	Sie schläft.
+`)
+
+func TestTranslator_Translate_carryOver(t *testing.T) {
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		switch {
+		case strings.Contains(prompt, "Anna liest."):
+			return "# One\n\nAnna reads.", nil
+		case strings.Contains(prompt, "Sie lacht."):
+			return "# Two\n\nShe laughs.", nil
+		default:
+			return "# Three\n\nShe sleeps.", nil
+		}
+	})
+
+	if _, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:    carrySource,
+		SplitChunks: []string{"# "},
+		CarryOver:   1,
+	}); err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if len(prompts) != 3 {
+		t.Fatalf("expected 3 prompts; got %d", len(prompts))
+	}
+
+	if strings.Contains(prompts[0], "PREVIOUS_BEGIN") {
+		t.Fatalf("first prompt should not contain previous translations\n\n%s", prompts[0])
+	}
+
+	if !strings.Contains(prompts[1], "---<PREVIOUS_BEGIN>---\n# One\n\nAnna reads.\n---<PREVIOUS_END>---") {
+		t.Fatalf("second prompt should contain the translation of the first chunk\n\n%s", prompts[1])
+	}
+
+	if !strings.Contains(prompts[2], "She laughs.") || strings.Contains(prompts[2], "Anna reads.") {
+		t.Fatalf("third prompt should only contain the translation of the second chunk\n\n%s", prompts[2])
+	}
+}
+
+func TestTranslator_Translate_carrySummary(t *testing.T) {
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		if strings.Contains(prompt, "keeping notes") {
+			switch {
+			case strings.Contains(prompt, "Anna reads."):
+				return "Anna is female; 'sie' refers to Anna.", nil
+			default:
+				return "Anna is female; 'sie' refers to Anna. Informal tone.", nil
+			}
+		}
+
+		prompts = append(prompts, prompt)
+		switch {
+		case strings.Contains(prompt, "Anna liest."):
+			return "# One\n\nAnna reads.", nil
+		case strings.Contains(prompt, "Sie lacht."):
+			return "# Two\n\nShe laughs.", nil
+		default:
+			return "# Three\n\nShe sleeps.", nil
+		}
+	})
+
+	if _, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:     carrySource,
+		SplitChunks:  []string{"# "},
+		CarrySummary: true,
+	}); err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if !strings.Contains(prompts[1], "---<PREVIOUS_BEGIN>---\nAnna is female; 'sie' refers to Anna.\n---<PREVIOUS_END>---") {
+		t.Fatalf("second prompt should contain the summary of the first chunk\n\n%s", prompts[1])
+	}
+
+	if !strings.Contains(prompts[2], "Informal tone.") || strings.Contains(prompts[2], "She laughs.") {
+		t.Fatalf("third prompt should contain the updated summary instead of the translation\n\n%s", prompts[2])
+	}
+}
@@ -0,0 +1,19 @@
+// Package cli runs the dragoman command-line interface. Programs that build
+// their own dragoman executable, for example with additional providers that
+// register themselves using provider.Register, call [Main] from their main
+// function.
+package cli
+
+import (
+	"log"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/cli"
+)
+
+// Main parses the command-line arguments and runs the dragoman command-line
+// interface. It exits the process if the command fails.
+func Main() {
+	log.SetFlags(0)
+	cli.New(dragoman.Version()).Run()
+}
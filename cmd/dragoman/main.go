@@ -1,14 +1,7 @@
 package main
 
-import (
-	_ "embed"
-	"log"
-
-	"github.com/modernice/dragoman"
-	"github.com/modernice/dragoman/internal/cli"
-)
+import "github.com/modernice/dragoman/cli"
 
 func main() {
-	log.SetFlags(0)
-	cli.New(dragoman.Version()).Run()
+	cli.Main()
 }
@@ -0,0 +1,278 @@
+package dragoman
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Default thresholds and batch size of [CheckConsistency].
+const (
+	DefaultSourceSimilarity      = 0.95
+	DefaultTranslationSimilarity = 0.9
+	DefaultEmbedBatchSize        = 100
+)
+
+// ConsistencyEntry is a translated string that is checked by
+// [CheckConsistency].
+type ConsistencyEntry struct {
+	// File is the file of the string, if the strings come from several files.
+	File string `json:"file,omitempty"`
+
+	// Key identifies the string within its file, like the key path of a JSON
+	// document.
+	Key string `json:"key"`
+
+	// Source is the original string.
+	Source string `json:"source"`
+
+	// Translation is the translated string.
+	Translation string `json:"translation"`
+}
+
+// ConsistencyParams configure [CheckConsistency].
+type ConsistencyParams struct {
+	// Entries are the translated strings. They must all be translated into the
+	// same language.
+	Entries []ConsistencyEntry
+
+	// SourceSimilarity is the minimum cosine similarity of the embeddings of
+	// two source strings that are compared with each other. Identical sources
+	// are always compared. Defaults to [DefaultSourceSimilarity].
+	SourceSimilarity float64
+
+	// TranslationSimilarity is the minimum cosine similarity of the embeddings
+	// of two translations that are considered consistent, so that differences
+	// in punctuation or case are not reported. Defaults to
+	// [DefaultTranslationSimilarity].
+	TranslationSimilarity float64
+
+	// BatchSize is the maximum number of texts that are embedded in a single
+	// request. Defaults to [DefaultEmbedBatchSize].
+	BatchSize int
+}
+
+// Inconsistency is a group of strings with the same or near-identical sources
+// that were translated differently.
+type Inconsistency struct {
+	// Variants are the differing translations of the sources, each with the
+	// strings that were translated that way, ordered by the number of strings.
+	Variants []ConsistencyVariant `json:"variants"`
+}
+
+// ConsistencyVariant is one of the translations of an [Inconsistency].
+type ConsistencyVariant struct {
+	// Translation is the translation of the entries.
+	Translation string `json:"translation"`
+
+	// Entries are the strings that were translated as Translation.
+	Entries []ConsistencyEntry `json:"entries"`
+}
+
+// CheckConsistency reports the strings whose sources are the same or nearly
+// the same, but whose translations differ. The embeddings of the sources group
+// near-duplicate sources, like "Save" and "Save.", and the embeddings of the
+// translations decide whether the translations of a group differ in meaning,
+// not only in punctuation or case. Every distinct source and translation is
+// embedded once.
+func CheckConsistency(ctx context.Context, embedder Embedder, params ConsistencyParams) ([]Inconsistency, error) {
+	if params.SourceSimilarity <= 0 {
+		params.SourceSimilarity = DefaultSourceSimilarity
+	}
+	if params.TranslationSimilarity <= 0 {
+		params.TranslationSimilarity = DefaultTranslationSimilarity
+	}
+	if params.BatchSize <= 0 {
+		params.BatchSize = DefaultEmbedBatchSize
+	}
+
+	var (
+		sources      []string
+		translations []string
+		sourceIndex  = make(map[string]int)
+		targetIndex  = make(map[string]int)
+	)
+	for _, e := range params.Entries {
+		source, translation := strings.TrimSpace(e.Source), strings.TrimSpace(e.Translation)
+		if source == "" || translation == "" {
+			continue
+		}
+		if _, ok := sourceIndex[source]; !ok {
+			sourceIndex[source] = len(sources)
+			sources = append(sources, source)
+		}
+		if _, ok := targetIndex[translation]; !ok {
+			targetIndex[translation] = len(translations)
+			translations = append(translations, translation)
+		}
+	}
+
+	vectors, err := embedAll(ctx, embedder, append(append([]string{}, sources...), translations...), params.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	sourceVectors, translationVectors := vectors[:len(sources)], vectors[len(sources):]
+
+	groups := cluster(sourceVectors, params.SourceSimilarity)
+
+	byGroup := make(map[int][]ConsistencyEntry)
+	for _, e := range params.Entries {
+		i, ok := sourceIndex[strings.TrimSpace(e.Source)]
+		if !ok || strings.TrimSpace(e.Translation) == "" {
+			continue
+		}
+		byGroup[groups[i]] = append(byGroup[groups[i]], e)
+	}
+
+	var out []Inconsistency
+	for _, entries := range byGroup {
+		variants := consistencyVariants(entries)
+		if len(variants) < 2 {
+			continue
+		}
+
+		vecs := make([][]float32, len(variants))
+		for i, v := range variants {
+			vecs[i] = translationVectors[targetIndex[strings.TrimSpace(v.Translation)]]
+		}
+		if meanings := cluster(vecs, params.TranslationSimilarity); distinct(meanings) < 2 {
+			continue
+		}
+
+		out = append(out, Inconsistency{Variants: variants})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Variants[0].Entries[0].Source < out[j].Variants[0].Entries[0].Source
+	})
+
+	return out, nil
+}
+
+// embedAll embeds the texts in batches of the given size.
+func embedAll(ctx context.Context, embedder Embedder, texts []string, batchSize int) ([][]float32, error) {
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		vectors, err := embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("embed texts: %w", err)
+		}
+		if len(vectors) != end-start {
+			return nil, fmt.Errorf("embed texts: got %d embeddings for %d texts", len(vectors), end-start)
+		}
+
+		for _, v := range vectors {
+			out = append(out, normalize(v))
+		}
+	}
+	return out, nil
+}
+
+// cluster groups the vectors whose cosine similarity is at least the
+// threshold, transitively. It returns the group of each vector, which is the
+// index of the first vector of the group.
+func cluster(vectors [][]float32, threshold float64) []int {
+	parent := make([]int, len(vectors))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			if dot(vectors[i], vectors[j]) < threshold {
+				continue
+			}
+			a, b := find(i), find(j)
+			if a == b {
+				continue
+			}
+			if a < b {
+				parent[b] = a
+			} else {
+				parent[a] = b
+			}
+		}
+	}
+
+	groups := make([]int, len(vectors))
+	for i := range vectors {
+		groups[i] = find(i)
+	}
+	return groups
+}
+
+// consistencyVariants groups the entries by their translation, with the most
+// common translation first.
+func consistencyVariants(entries []ConsistencyEntry) []ConsistencyVariant {
+	var variants []ConsistencyVariant
+	index := make(map[string]int)
+	for _, e := range entries {
+		translation := strings.TrimSpace(e.Translation)
+		i, ok := index[translation]
+		if !ok {
+			i = len(variants)
+			index[translation] = i
+			variants = append(variants, ConsistencyVariant{Translation: translation})
+		}
+		variants[i].Entries = append(variants[i].Entries, e)
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return len(variants[i].Entries) > len(variants[j].Entries)
+	})
+
+	return variants
+}
+
+func distinct(groups []int) int {
+	seen := make(map[int]bool)
+	for _, g := range groups {
+		seen[g] = true
+	}
+	return len(seen)
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// dot returns the dot product of two vectors, which is their cosine
+// similarity if both are normalized.
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return float64(sum)
+}
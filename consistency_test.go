@@ -0,0 +1,100 @@
+package dragoman_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	vectors := map[string][]float32{
+		"Save":       {1, 0, 0, 0},
+		"Save.":      {0.99, 0.1, 0, 0},
+		"Open":       {0, 1, 0, 0},
+		"Speichern":  {0, 0, 1, 0},
+		"Speichern.": {0, 0, 0.99, 0.1},
+		"Sichern":    {0, 0, 0.5, 0.8},
+		"Öffnen":     {0, 0, 0, 1},
+	}
+
+	var requests int
+	embedder := dragoman.EmbedderFunc(func(_ context.Context, texts []string) ([][]float32, error) {
+		requests++
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			v, ok := vectors[text]
+			if !ok {
+				return nil, fmt.Errorf("unexpected text %q", text)
+			}
+			out[i] = v
+		}
+		return out, nil
+	})
+
+	entries := []dragoman.ConsistencyEntry{
+		{File: "a.json", Key: "save", Source: "Save", Translation: "Speichern"},
+		{File: "a.json", Key: "saveButton", Source: "Save.", Translation: "Speichern."},
+		{File: "b.json", Key: "save", Source: "Save", Translation: "Sichern"},
+		{File: "b.json", Key: "saveAgain", Source: " Save ", Translation: "Speichern"},
+		{File: "a.json", Key: "open", Source: "Open", Translation: "Öffnen"},
+		{File: "b.json", Key: "open", Source: "Open", Translation: "Öffnen"},
+		{File: "b.json", Key: "empty", Source: "Open", Translation: ""},
+	}
+
+	got, err := dragoman.CheckConsistency(context.Background(), embedder, dragoman.ConsistencyParams{
+		Entries:   entries,
+		BatchSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("CheckConsistency(): %v", err)
+	}
+
+	want := []dragoman.Inconsistency{{
+		Variants: []dragoman.ConsistencyVariant{
+			{Translation: "Speichern", Entries: []dragoman.ConsistencyEntry{entries[0], entries[3]}},
+			{Translation: "Speichern.", Entries: []dragoman.ConsistencyEntry{entries[1]}},
+			{Translation: "Sichern", Entries: []dragoman.ConsistencyEntry{entries[2]}},
+		},
+	}}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("CheckConsistency() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	// 3 sources and 4 translations in batches of 4
+	if requests != 2 {
+		t.Fatalf("CheckConsistency() should send 2 requests; got %d", requests)
+	}
+}
+
+func TestCheckConsistency_similarTranslations(t *testing.T) {
+	embedder := dragoman.EmbedderFunc(func(_ context.Context, texts []string) ([][]float32, error) {
+		vectors := map[string][]float32{
+			"Save":       {1, 0},
+			"Speichern":  {0, 1},
+			"Speichern.": {0.05, 1},
+		}
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			out[i] = vectors[text]
+		}
+		return out, nil
+	})
+
+	got, err := dragoman.CheckConsistency(context.Background(), embedder, dragoman.ConsistencyParams{
+		Entries: []dragoman.ConsistencyEntry{
+			{Key: "a", Source: "Save", Translation: "Speichern"},
+			{Key: "b", Source: "Save", Translation: "Speichern."},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CheckConsistency(): %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Fatalf("CheckConsistency() should not report translations that only differ in punctuation; got %+v", got)
+	}
+}
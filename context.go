@@ -0,0 +1,48 @@
+package dragoman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+)
+
+// SummarizeContext asks the model to condense a reference document, like a
+// brand guide or an existing translation, into a short summary of its
+// terminology, tone and style. The summary can then be passed as
+// [TranslateParams.Context] or [ImproveParams.Context] to keep prompts small
+// when the reference documents are large.
+func SummarizeContext(ctx context.Context, model Model, document string) (string, error) {
+	prompt := heredoc.Docf(`
+		Summarize the following reference document for a translator or editor. Focus on terminology, recurring phrases, brand names, tone of voice and style rules. Keep established translations of terms verbatim.
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		Output only the summary, no chat.
+	`, document)
+
+	response, err := model.Chat(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("llm error: %w", err)
+	}
+
+	return trimDividers(response), nil
+}
+
+func contextSection(docs []string) string {
+	if len(docs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Use the following reference material to match terminology, tone and style. Do not include it in the output:\n")
+	for _, doc := range docs {
+		b.WriteString("---<CONTEXT_BEGIN>---\n")
+		b.WriteString(strings.TrimSpace(doc))
+		b.WriteString("\n---<CONTEXT_END>---\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,321 @@
+package deepl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/modernice/dragoman/internal/backoff"
+	"github.com/modernice/dragoman/internal/logging"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a request to the DeepL API.
+	DefaultTimeout = time.Minute
+
+	// DefaultMaxRetries is the default number of times a failed request is
+	// retried if the DeepL API responds with a rate limit or server error.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryBackoff is the default base delay between retries. The delay
+	// doubles with every attempt up to 30 seconds and is randomized with
+	// jitter.
+	DefaultRetryBackoff = time.Second
+
+	// MaxTexts is the maximum number of texts that are sent to the DeepL API in
+	// a single request. Larger batches are split into multiple requests.
+	MaxTexts = 50
+
+	freeEndpoint = "https://api-free.deepl.com/v2/translate"
+	proEndpoint  = "https://api.deepl.com/v2/translate"
+)
+
+// Client translates texts using the DeepL API. It implements the
+// dragoman.Engine interface.
+type Client struct {
+	authKey      string
+	endpoint     string
+	formality    string
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+	verbose      bool
+	logger       *slog.Logger
+	client       *http.Client
+}
+
+// Option configures a [Client].
+type Option func(*Client)
+
+// Endpoint sets the URL of the translate endpoint of the DeepL API. By default,
+// the endpoint of the free API is used for authentication keys that end with
+// ":fx", and the endpoint of the pro API for all other keys.
+func Endpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.endpoint = endpoint
+	}
+}
+
+// Formality sets the formality of the translations ("more" or "less"). The
+// formality is only applied to target languages that support it.
+func Formality(formality string) Option {
+	return func(c *Client) {
+		c.formality = formality
+	}
+}
+
+// Timeout sets the timeout of a request to the DeepL API.
+func Timeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// MaxRetries sets the maximum number of times a failed request is retried if
+// the DeepL API responds with a rate limit (429) or server error (5xx). A value
+// of 0 disables retries.
+func MaxRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// RetryBackoff sets the base delay between retries of failed requests.
+func RetryBackoff(backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retryBackoff = backoff
+	}
+}
+
+// Verbose enables debug logs of API requests. The logs are written to the
+// output of the standard logger unless a [Logger] is configured.
+func Verbose(verbose bool) Option {
+	return func(c *Client) {
+		c.verbose = verbose
+	}
+}
+
+// Logger sets the logger that receives the debug records of API requests and
+// the warnings about retried requests. By default, nothing is logged.
+func Logger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// HTTPClient sets the HTTP client that is used for requests to the DeepL API.
+func HTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// New creates a new [*Client] with the given authentication key and options.
+func New(authKey string, opts ...Option) *Client {
+	c := Client{
+		authKey:      authKey,
+		timeout:      DefaultTimeout,
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+		client:       http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.endpoint == "" {
+		c.endpoint = proEndpoint
+		if strings.HasSuffix(authKey, ":fx") {
+			c.endpoint = freeEndpoint
+		}
+	}
+
+	switch {
+	case c.logger != nil:
+	case c.verbose:
+		c.logger = slog.New(logging.NewHandler(log.Writer(), "DeepL"))
+	default:
+		c.logger = logging.Discard()
+	}
+
+	return &c
+}
+
+// APIError is returned if the DeepL API responds with an error status, for
+// example because of an invalid authentication key or an exceeded quota.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *APIError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("deepl: %d %s", err.StatusCode, http.StatusText(err.StatusCode))
+	}
+	return fmt.Sprintf("deepl: %d %s: %s", err.StatusCode, http.StatusText(err.StatusCode), err.Message)
+}
+
+// IsAPIError reports whether the error was caused by the DeepL API, for
+// example because of an invalid authentication key, an exceeded quota or a
+// failed connection to the API.
+func IsAPIError(err error) bool {
+	var (
+		apiErr *APIError
+		urlErr *url.Error
+	)
+	return errors.As(err, &apiErr) || errors.As(err, &urlErr)
+}
+
+// TranslateTexts translates the given texts from the source language to the
+// target language. Languages can be provided as English names or as language
+// codes; an empty source language is detected by DeepL. The formatting of the
+// texts is preserved.
+func (c *Client) TranslateTexts(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	sourceLang, err := SourceLang(source)
+	if err != nil {
+		return nil, err
+	}
+
+	targetLang, err := TargetLang(target)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += MaxTexts {
+		end := start + MaxTexts
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		translated, err := c.withRetries(ctx, translateRequest{
+			Text:               texts[start:end],
+			SourceLang:         sourceLang,
+			TargetLang:         targetLang,
+			Formality:          c.formalityParam(),
+			PreserveFormatting: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(translated) != end-start {
+			return nil, fmt.Errorf("deepl: expected %d translations; got %d", end-start, len(translated))
+		}
+
+		out = append(out, translated...)
+	}
+
+	return out, nil
+}
+
+type translateRequest struct {
+	Text               []string `json:"text"`
+	SourceLang         string   `json:"source_lang,omitempty"`
+	TargetLang         string   `json:"target_lang"`
+	Formality          string   `json:"formality,omitempty"`
+	PreserveFormatting bool     `json:"preserve_formatting"`
+}
+
+type translateResponse struct {
+	Translations []struct {
+		Text string `json:"text"`
+	} `json:"translations"`
+}
+
+func (c *Client) formalityParam() string {
+	if c.formality == "" {
+		return ""
+	}
+	return "prefer_" + c.formality
+}
+
+func (c *Client) withRetries(ctx context.Context, req translateRequest) ([]string, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.translate(ctx, req)
+		if err == nil || attempt >= c.maxRetries || !isRetryable(err) {
+			return resp, err
+		}
+
+		delay := backoff.Delay(c.retryBackoff, attempt)
+		c.logger.WarnContext(ctx, "retry request", "error", err, "delay", delay, "attempt", attempt+1, "max_retries", c.maxRetries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) translate(ctx context.Context, req translateRequest) ([]string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	c.logger.DebugContext(ctx, "translate texts", "texts", len(req.Text), "target_lang", req.TargetLang)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "DeepL-Auth-Key "+c.authKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &msg)
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: msg.Message}
+	}
+
+	var result translateResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	out := make([]string, len(result.Translations))
+	for i, t := range result.Translations {
+		out[i] = t.Text
+	}
+
+	return out, nil
+}
+
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
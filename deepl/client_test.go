@@ -0,0 +1,81 @@
+package deepl_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/deepl"
+)
+
+func TestClient_TranslateTexts(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if got := r.Header.Get("Authorization"); got != "DeepL-Auth-Key secret" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+
+		var req struct {
+			Text       []string `json:"text"`
+			SourceLang string   `json:"source_lang"`
+			TargetLang string   `json:"target_lang"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		if req.SourceLang != "EN" || req.TargetLang != "DE" {
+			t.Errorf("unexpected languages %q -> %q", req.SourceLang, req.TargetLang)
+		}
+
+		var resp struct {
+			Translations []map[string]string `json:"translations"`
+		}
+		for _, text := range req.Text {
+			resp.Translations = append(resp.Translations, map[string]string{"text": strings.ToUpper(text)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client := deepl.New("secret", deepl.Endpoint(srv.URL))
+
+	texts := make([]string, deepl.MaxTexts+1)
+	want := make([]string, len(texts))
+	for i := range texts {
+		texts[i] = "text"
+		want[i] = "TEXT"
+	}
+
+	got, err := client.TranslateTexts(context.Background(), texts, "English", "German")
+	if err != nil {
+		t.Fatalf("TranslateTexts() failed: %v", err)
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("TranslateTexts() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if requests != 2 {
+		t.Fatalf("texts should be sent in batches of %d; got %d requests", deepl.MaxTexts, requests)
+	}
+}
+
+func TestClient_TranslateTexts_apiError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "Wrong endpoint"}`))
+	}))
+	defer srv.Close()
+
+	_, err := deepl.New("secret", deepl.Endpoint(srv.URL)).TranslateTexts(context.Background(), []string{"text"}, "", "German")
+	if !deepl.IsAPIError(err) {
+		t.Fatalf("TranslateTexts() should return an API error; got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package deepl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var languageCodes = map[string]string{
+	"arabic":               "AR",
+	"bulgarian":            "BG",
+	"chinese":              "ZH",
+	"czech":                "CS",
+	"danish":               "DA",
+	"dutch":                "NL",
+	"english":              "EN-US",
+	"american english":     "EN-US",
+	"british english":      "EN-GB",
+	"estonian":             "ET",
+	"finnish":              "FI",
+	"french":               "FR",
+	"german":               "DE",
+	"greek":                "EL",
+	"hungarian":            "HU",
+	"indonesian":           "ID",
+	"italian":              "IT",
+	"japanese":             "JA",
+	"korean":               "KO",
+	"latvian":              "LV",
+	"lithuanian":           "LT",
+	"norwegian":            "NB",
+	"polish":               "PL",
+	"portuguese":           "PT-PT",
+	"brazilian portuguese": "PT-BR",
+	"romanian":             "RO",
+	"russian":              "RU",
+	"slovak":               "SK",
+	"slovenian":            "SL",
+	"spanish":              "ES",
+	"swedish":              "SV",
+	"turkish":              "TR",
+	"ukrainian":            "UK",
+}
+
+// deprecatedTargets maps target language codes that DeepL no longer accepts
+// to their replacements.
+var deprecatedTargets = map[string]string{
+	"EN": "EN-US",
+	"PT": "PT-PT",
+}
+
+var codePattern = regexp.MustCompile(`^[A-Za-z]{2}(-[A-Za-z]{2,4})?$`)
+
+// TargetLang returns the DeepL code of the given target language. The language
+// can be provided as an English name (e.g. "German" or "British English") or as
+// a language code (e.g. "de" or "en-GB").
+func TargetLang(lang string) (string, error) {
+	code, err := languageCode(lang)
+	if err != nil {
+		return "", err
+	}
+
+	if replacement, ok := deprecatedTargets[code]; ok {
+		return replacement, nil
+	}
+
+	return code, nil
+}
+
+// SourceLang returns the DeepL code of the given source language. Source
+// languages have no regional variants, so "British English" and "en-GB" both
+// return "EN". An empty language returns an empty code, which lets DeepL detect
+// the source language.
+func SourceLang(lang string) (string, error) {
+	if lang == "" {
+		return "", nil
+	}
+
+	code, err := languageCode(lang)
+	if err != nil {
+		return "", err
+	}
+
+	base, _, _ := strings.Cut(code, "-")
+	return base, nil
+}
+
+func languageCode(lang string) (string, error) {
+	lang = strings.TrimSpace(lang)
+
+	if code, ok := languageCodes[strings.ToLower(lang)]; ok {
+		return code, nil
+	}
+
+	if codePattern.MatchString(lang) {
+		return strings.ToUpper(lang), nil
+	}
+
+	return "", fmt.Errorf("unsupported language %q", lang)
+}
@@ -0,0 +1,53 @@
+package deepl_test
+
+import (
+	"testing"
+
+	"github.com/modernice/dragoman/deepl"
+)
+
+func TestTargetLang(t *testing.T) {
+	tests := map[string]string{
+		"German":          "DE",
+		"british english": "EN-GB",
+		"English":         "EN-US",
+		"en":              "EN-US",
+		"pt-br":           "PT-BR",
+		"zh-Hans":         "ZH-HANS",
+	}
+
+	for lang, want := range tests {
+		got, err := deepl.TargetLang(lang)
+		if err != nil {
+			t.Errorf("TargetLang(%q) failed: %v", lang, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("TargetLang(%q) should return %q; got %q", lang, want, got)
+		}
+	}
+
+	if _, err := deepl.TargetLang("Klingon"); err == nil {
+		t.Errorf("TargetLang() should fail for unsupported languages")
+	}
+}
+
+func TestSourceLang(t *testing.T) {
+	tests := map[string]string{
+		"":                "",
+		"British English": "EN",
+		"de":              "DE",
+		"pt-BR":           "PT",
+	}
+
+	for lang, want := range tests {
+		got, err := deepl.SourceLang(lang)
+		if err != nil {
+			t.Errorf("SourceLang(%q) failed: %v", lang, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("SourceLang(%q) should return %q; got %q", lang, want, got)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package deepl
+
+import "github.com/modernice/dragoman/provider"
+
+func init() {
+	provider.Register("deepl", func(cfg provider.Config) (provider.Backend, error) {
+		return provider.Backend{Engine: New(cfg.Key, Timeout(cfg.Timeout), MaxRetries(cfg.MaxRetries), Verbose(cfg.Verbose))}, nil
+	})
+}
@@ -0,0 +1,90 @@
+package dragoman
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+)
+
+// detectSampleSize is the maximum number of characters of a text that are sent
+// to the model to detect its language.
+const detectSampleSize = 2000
+
+// ErrUndetectable is returned by [DetectLanguage] if the model could not
+// identify the language of a text.
+var ErrUndetectable = errors.New("language could not be detected")
+
+// Detection is the detected language of a text.
+type Detection struct {
+	// Language is the ISO 639-1 code of the language, like "de".
+	Language string `json:"language"`
+
+	// Name is the English name of the language, like "German".
+	Name string `json:"name"`
+
+	// Confidence is the confidence of the model in the detection, between 0
+	// and 1.
+	Confidence float64 `json:"confidence"`
+}
+
+// DetectLanguage asks the model to identify the language of the text, so that
+// documents whose language is unknown can be routed to the right translation.
+// Only the beginning of long texts is sent to the model. Texts without words,
+// like code or numbers, fail with [ErrUndetectable].
+func DetectLanguage(ctx context.Context, model Model, text string) (Detection, error) {
+	sample := strings.TrimSpace(text)
+	if runes := []rune(sample); len(runes) > detectSampleSize {
+		sample = string(runes[:detectSampleSize])
+	}
+	if sample == "" {
+		return Detection{}, ErrUndetectable
+	}
+
+	prompt := heredoc.Docf(`
+		Identify the language of the following text:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		Respond with a JSON object with the ISO 639-1 code of the language as "language", its English name as "name" and your confidence between 0 and 1 as "confidence", e.g. {"language": "de", "name": "German", "confidence": 0.98}.
+		If the text has no natural language, respond with {"language": "", "name": "", "confidence": 0}.
+
+		Output only the JSON object, no chat.
+	`, sample)
+
+	response, err := model.Chat(ctx, prompt)
+	if err != nil {
+		return Detection{}, fmt.Errorf("llm error: %w", err)
+	}
+
+	return parseDetection(response)
+}
+
+func parseDetection(response string) (Detection, error) {
+	text := trimDividers(response)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.Trim(text, "`\n ")
+
+	var d Detection
+	if err := json.Unmarshal([]byte(text), &d); err != nil {
+		return Detection{}, fmt.Errorf("parse detection %q: %w", firstLine(text), err)
+	}
+
+	d.Language = strings.ToLower(strings.TrimSpace(d.Language))
+	if d.Language == "" {
+		return Detection{}, ErrUndetectable
+	}
+
+	switch {
+	case d.Confidence < 0:
+		d.Confidence = 0
+	case d.Confidence > 1:
+		d.Confidence = 1
+	}
+
+	return d, nil
+}
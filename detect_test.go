@@ -0,0 +1,71 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modernice/dragoman"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]struct {
+		response string
+		want     dragoman.Detection
+		err      error
+	}{
+		"detected": {
+			response: `{"language": "DE", "name": "German", "confidence": 0.97}`,
+			want:     dragoman.Detection{Language: "de", Name: "German", Confidence: 0.97},
+		},
+		"code fence": {
+			response: "```json\n{\"language\": \"fr\", \"name\": \"French\", \"confidence\": 1.2}\n```",
+			want:     dragoman.Detection{Language: "fr", Name: "French", Confidence: 1},
+		},
+		"no language": {
+			response: `{"language": "", "name": "", "confidence": 0}`,
+			err:      dragoman.ErrUndetectable,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+				if !strings.Contains(prompt, "Guten Morgen") {
+					t.Errorf("prompt should contain the text; got\n\n%s", prompt)
+				}
+				return tt.response, nil
+			})
+
+			got, err := dragoman.DetectLanguage(context.Background(), model, "Guten Morgen!")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("DetectLanguage() should fail with %v; got %v", tt.err, err)
+			}
+			if got != tt.want {
+				t.Fatalf("DetectLanguage() should return %+v; got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage_invalidResponse(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		return "The text is German.", nil
+	})
+
+	if _, err := dragoman.DetectLanguage(context.Background(), model, "Guten Morgen!"); err == nil {
+		t.Fatalf("DetectLanguage() should fail for a response that is not JSON")
+	}
+}
+
+func TestDetectLanguage_empty(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		t.Fatal("the model should not be called for an empty text")
+		return "", nil
+	})
+
+	if _, err := dragoman.DetectLanguage(context.Background(), model, " \n "); !errors.Is(err, dragoman.ErrUndetectable) {
+		t.Fatalf("DetectLanguage() should fail with %v; got %v", dragoman.ErrUndetectable, err)
+	}
+}
@@ -0,0 +1,326 @@
+package dragoman
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// diffContext is the number of unchanged lines that are shown around the
+// changes of a diff.
+const diffContext = 3
+
+// JSONRenderDiff renders the changes between two JSON documents as a unified
+// diff of their indented representations with sorted keys, so that reviewers
+// can see which keys a merge, like an update of a translation, would add or
+// change. Added lines start with "+", removed lines with "-" and unchanged
+// context lines with a space. Each hunk starts with a "@@ -l,s +l,s @@" header.
+// An empty string is returned if the documents are equal.
+func JSONRenderDiff[TData []byte | map[string]any](before, after TData) (string, error) {
+	beforeLines, err := jsonLines(before)
+	if err != nil {
+		return "", fmt.Errorf("before: %w", err)
+	}
+
+	afterLines, err := jsonLines(after)
+	if err != nil {
+		return "", fmt.Errorf("after: %w", err)
+	}
+
+	return renderHunks(diffLines(beforeLines, afterLines)), nil
+}
+
+func jsonLines[TData []byte | map[string]any](data TData) ([]string, error) {
+	var doc map[string]any
+	switch data := any(data).(type) {
+	case []byte:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("unmarshal data: %w", err)
+		}
+	case map[string]any:
+		doc = data
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("marshal data: %w", err)
+	}
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+}
+
+// diffLine is a line of a diff. Op is '+' for added lines, '-' for removed
+// lines and ' ' for unchanged lines.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the shortest edit script that turns a into b, using the
+// algorithm of Myers.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+
+	var trace [][]int
+	for d := 0; d <= offset; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b)
+			}
+		}
+	}
+
+	return nil
+}
+
+func backtrackDiff(trace [][]int, a, b []string) []diffLine {
+	offset := len(a) + len(b)
+	x, y := len(a), len(b)
+
+	var lines []diffLine
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, diffLine{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+
+		if x == prevX {
+			lines = append(lines, diffLine{'+', b[y-1]})
+		} else {
+			lines = append(lines, diffLine{'-', a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		lines = append(lines, diffLine{' ', a[x-1]})
+		x, y = x-1, y-1
+	}
+
+	slices.Reverse(lines)
+	return lines
+}
+
+// renderHunks renders the changed lines of a diff together with their context
+// as unified diff hunks.
+func renderHunks(lines []diffLine) string {
+	// visible marks the lines that are changed or within the context of a
+	// changed line.
+	visible := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for j := i - diffContext; j <= i+diffContext; j++ {
+			if j >= 0 && j < len(lines) {
+				visible[j] = true
+			}
+		}
+	}
+
+	var out strings.Builder
+	var aLine, bLine int
+	for i := 0; i < len(lines); {
+		if !visible[i] {
+			aLine, bLine = advanceLines(lines[i], aLine, bLine)
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(lines) && visible[end] {
+			end++
+		}
+
+		aStart, bStart := aLine, bLine
+		for _, line := range lines[i:end] {
+			aLine, bLine = advanceLines(line, aLine, bLine)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aLine-aStart), hunkRange(bStart, bLine-bStart))
+		for _, line := range lines[i:end] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+
+		i = end
+	}
+
+	return out.String()
+}
+
+func advanceLines(line diffLine, aLine, bLine int) (int, int) {
+	if line.op != '+' {
+		aLine++
+	}
+	if line.op != '-' {
+		bLine++
+	}
+	return aLine, bLine
+}
+
+// hunkRange formats the range of a hunk. Like in GNU diff, the start of empty
+// ranges is the line before the range.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// DiffOp is the operation of a [DiffSpan].
+type DiffOp byte
+
+const (
+	// DiffEqual spans are unchanged.
+	DiffEqual DiffOp = ' '
+
+	// DiffInsert spans were added.
+	DiffInsert DiffOp = '+'
+
+	// DiffDelete spans were removed.
+	DiffDelete DiffOp = '-'
+)
+
+// DiffSpan is a span of text of a word-level diff.
+type DiffSpan struct {
+	Op   DiffOp
+	Text string
+}
+
+// WordDiff returns the word-level changes between two texts, for example
+// between a document and its improved version, so that reviewers only need to
+// read what was changed. Words, runs of whitespace and punctuation characters
+// are compared as a whole. Unchanged whitespace between two changes is part of
+// the changes, so that a rewritten phrase is a single deletion followed by a
+// single insertion. Concatenating the texts of the spans that are not
+// insertions returns the before text, and those that are not deletions the
+// after text.
+func WordDiff(before, after string) []DiffSpan {
+	// The texts are compared line by line first, so that only the words of
+	// changed lines are compared.
+	var lines []diffLine
+	var deletedLines, insertedLines strings.Builder
+	compareWords := func() {
+		lines = append(lines, diffLines(words(deletedLines.String()), words(insertedLines.String()))...)
+		deletedLines.Reset()
+		insertedLines.Reset()
+	}
+	for _, line := range diffLines(strings.SplitAfter(before, "\n"), strings.SplitAfter(after, "\n")) {
+		switch line.op {
+		case '-':
+			deletedLines.WriteString(line.text)
+		case '+':
+			insertedLines.WriteString(line.text)
+		default:
+			compareWords()
+			lines = append(lines, line)
+		}
+	}
+	compareWords()
+
+	// Unchanged whitespace between two changes joins the changes.
+	for i := 1; i < len(lines)-1; i++ {
+		if lines[i].op == ' ' && strings.TrimSpace(lines[i].text) == "" && lines[i-1].op != ' ' && lines[i+1].op != ' ' {
+			lines[i].op = '='
+		}
+	}
+
+	var spans []DiffSpan
+	var deleted, inserted strings.Builder
+	flush := func() {
+		if deleted.Len() > 0 {
+			spans = append(spans, DiffSpan{Op: DiffDelete, Text: deleted.String()})
+		}
+		if inserted.Len() > 0 {
+			spans = append(spans, DiffSpan{Op: DiffInsert, Text: inserted.String()})
+		}
+		deleted.Reset()
+		inserted.Reset()
+	}
+
+	for _, line := range lines {
+		switch line.op {
+		case '-':
+			deleted.WriteString(line.text)
+		case '+':
+			inserted.WriteString(line.text)
+		case '=':
+			deleted.WriteString(line.text)
+			inserted.WriteString(line.text)
+		default:
+			flush()
+			if n := len(spans); n > 0 && spans[n-1].Op == DiffEqual {
+				spans[n-1].Text += line.text
+			} else {
+				spans = append(spans, DiffSpan{Op: DiffEqual, Text: line.text})
+			}
+		}
+	}
+	flush()
+
+	return spans
+}
+
+// words splits a text into words, runs of whitespace and single punctuation
+// characters.
+func words(text string) []string {
+	var out []string
+	for text != "" {
+		r, size := utf8.DecodeRuneInString(text)
+		end := size
+		switch {
+		case unicode.IsSpace(r):
+			end = runLength(text, unicode.IsSpace)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			end = runLength(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) })
+		}
+		out = append(out, text[:end])
+		text = text[end:]
+	}
+	return out
+}
+
+// runLength returns the length in bytes of the prefix of the text whose runes
+// match fn.
+func runLength(text string, fn func(rune) bool) int {
+	for i, r := range text {
+		if !fn(r) {
+			return i
+		}
+	}
+	return len(text)
+}
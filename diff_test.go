@@ -0,0 +1,89 @@
+package dragoman_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestJSONRenderDiff(t *testing.T) {
+	before := []byte(`{
+		"a": "Eins",
+		"b": "Zwei",
+		"c": "Drei",
+		"d": "Vier",
+		"e": "Fünf",
+		"nav": {"home": "Startseite"}
+	}`)
+
+	after := []byte(`{
+		"a": "Eins",
+		"b": "Zwei",
+		"c": "Drei",
+		"d": "Vier",
+		"e": "Fünf",
+		"nav": {"about": "Über uns", "home": "Startseite"},
+		"title": "Willkommen"
+	}`)
+
+	diff, err := dragoman.JSONRenderDiff(before, after)
+	if err != nil {
+		t.Fatalf("JSONRenderDiff() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		@@ -5,6 +5,8 @@
+		   "d": "Vier",
+		   "e": "Fünf",
+		   "nav": {
+		+    "about": "Über uns",
+		     "home": "Startseite"
+		-  }
+		+  },
+		+  "title": "Willkommen"
+		 }
+	`)
+
+	if diff != want {
+		t.Fatalf("JSONRenderDiff() returned\n\n%s\n\nwant\n\n%s", diff, want)
+	}
+
+	if diff, err := dragoman.JSONRenderDiff(before, before); err != nil || diff != "" {
+		t.Fatalf("JSONRenderDiff() of equal documents should return an empty diff; got %q (%v)", diff, err)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	before := "The quick brown fox jumps over the dog.\n\nIt was fun."
+	after := "The fast fox jumps over the lazy dog.\n\nIt was fun."
+
+	got := dragoman.WordDiff(before, after)
+
+	want := []dragoman.DiffSpan{
+		{Op: dragoman.DiffEqual, Text: "The "},
+		{Op: dragoman.DiffDelete, Text: "quick brown "},
+		{Op: dragoman.DiffInsert, Text: "fast "},
+		{Op: dragoman.DiffEqual, Text: "fox jumps over the "},
+		{Op: dragoman.DiffInsert, Text: "lazy "},
+		{Op: dragoman.DiffEqual, Text: "dog.\n\nIt was fun."},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("WordDiff() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	var gotBefore, gotAfter strings.Builder
+	for _, span := range got {
+		if span.Op != dragoman.DiffInsert {
+			gotBefore.WriteString(span.Text)
+		}
+		if span.Op != dragoman.DiffDelete {
+			gotAfter.WriteString(span.Text)
+		}
+	}
+	if gotBefore.String() != before || gotAfter.String() != after {
+		t.Fatalf("spans should restore both texts; got %q and %q", gotBefore.String(), gotAfter.String())
+	}
+}
@@ -0,0 +1,18 @@
+package dragoman
+
+import "context"
+
+// Embedder embeds texts as vectors whose cosine similarity reflects how
+// similar the meanings of the texts are.
+type Embedder interface {
+	// Embed returns the embeddings of the texts, in the order of the texts.
+	Embed(context.Context, []string) ([][]float32, error)
+}
+
+// EmbedderFunc is a function that implements [Embedder].
+type EmbedderFunc func(context.Context, []string) ([][]float32, error)
+
+// Embed calls the function.
+func (fn EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return fn(ctx, texts)
+}
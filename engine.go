@@ -0,0 +1,83 @@
+package dragoman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Engine is a machine translation service, like DeepL, that translates texts
+// directly instead of following a prompt. Engines are cheap and deterministic,
+// which makes them a good fit for bulk strings, but they ignore the
+// instructions, preserved terms and context of the [TranslateParams].
+type Engine interface {
+	// TranslateTexts translates the given texts from the source language to the
+	// target language and returns the translations in the same order. The
+	// source language is empty if it should be detected by the engine.
+	TranslateTexts(ctx context.Context, texts []string, source, target string) ([]string, error)
+}
+
+// TranslateWith makes the [Translator] use the given [Engine] instead of its
+// [Model]. Chunks that are JSON objects are translated value by value, so that
+// the keys are never translated; all other chunks are translated as a whole.
+func TranslateWith(engine Engine) TranslatorOption {
+	return func(t *Translator) {
+		t.engine = engine
+	}
+}
+
+func (t *Translator) translateWithEngine(ctx context.Context, chunk string, params TranslateParams) (string, error) {
+	var doc map[string]any
+	if !strings.HasPrefix(strings.TrimSpace(chunk), "{") || json.Unmarshal([]byte(chunk), &doc) != nil {
+		translated, err := t.engine.TranslateTexts(ctx, []string{chunk}, params.Source, params.Target)
+		if err != nil {
+			return "", err
+		}
+		if len(translated) != 1 {
+			return "", fmt.Errorf("engine returned %d translations for 1 text", len(translated))
+		}
+		return translated[0], nil
+	}
+
+	var (
+		paths []JSONPath
+		texts []string
+	)
+	keys := allKeys(doc)
+	sort.Slice(keys, func(i, j int) bool {
+		return lessPath(keys[i], keys[j])
+	})
+	for _, path := range keys {
+		if text, ok := jsonValue(doc, path).(string); ok && text != "" {
+			paths = append(paths, path)
+			texts = append(texts, text)
+		}
+	}
+
+	if len(texts) > 0 {
+		translated, err := t.engine.TranslateTexts(ctx, texts, params.Source, params.Target)
+		if err != nil {
+			return "", err
+		}
+		if len(translated) != len(texts) {
+			return "", fmt.Errorf("engine returned %d translations for %d texts", len(translated), len(texts))
+		}
+
+		for i, path := range paths {
+			jsonSetValue(doc, path, translated[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("marshal translated JSON: %w", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,74 @@
+package dragoman_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+type upperEngine struct {
+	calls [][]string
+}
+
+func (e *upperEngine) TranslateTexts(_ context.Context, texts []string, _, _ string) ([]string, error) {
+	e.calls = append(e.calls, texts)
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = strings.ToUpper(text)
+	}
+	return out, nil
+}
+
+func TestTranslateWith(t *testing.T) {
+	engine := &upperEngine{}
+	translator := dragoman.NewTranslator(nil, dragoman.TranslateWith(engine))
+
+	t.Run("text", func(t *testing.T) {
+		result, err := translator.Translate(context.Background(), dragoman.TranslateParams{Document: "# Hello\n\nWorld"})
+		if err != nil {
+			t.Fatalf("Translate() failed: %v", err)
+		}
+
+		if want := "# HELLO\n\nWORLD\n"; result != want {
+			t.Fatalf("expected result to be %q; got %q", want, result)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		engine.calls = nil
+
+		result, err := translator.Translate(context.Background(), dragoman.TranslateParams{
+			Document: `{"title": "hello", "count": 3, "nav": {"home": "home", "items": ["a", "b"]}}`,
+			Validate: dragoman.ValidateJSON,
+		})
+		if err != nil {
+			t.Fatalf("Translate() failed: %v", err)
+		}
+
+		var got map[string]any
+		if err := json.Unmarshal([]byte(result), &got); err != nil {
+			t.Fatalf("result is not JSON: %v", err)
+		}
+
+		want := map[string]any{
+			"title": "HELLO",
+			"count": float64(3),
+			"nav": map[string]any{
+				"home":  "HOME",
+				"items": []any{"A", "B"},
+			},
+		}
+		if !cmp.Equal(want, got) {
+			t.Fatalf("result mismatch (-want +got):\n%s", cmp.Diff(want, got))
+		}
+
+		wantCalls := [][]string{{"home", "a", "b", "hello"}}
+		if !cmp.Equal(wantCalls, engine.calls) {
+			t.Fatalf("values should be translated in a single call (-want +got):\n%s", cmp.Diff(wantCalls, engine.calls))
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package dragoman
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ErrorAbort aborts the translation on the first chunk that fails to
+	// translate. It is the default action of an [ErrorPolicy].
+	ErrorAbort ErrorAction = "abort"
+
+	// ErrorSkip leaves chunks that fail to translate in the source language and
+	// continues with the next chunk.
+	ErrorSkip ErrorAction = "skip"
+
+	// ErrorRetry translates chunks that fail to translate again and aborts the
+	// translation if they still fail.
+	ErrorRetry ErrorAction = "retry"
+)
+
+// ErrorAction is the action of an [ErrorPolicy].
+type ErrorAction string
+
+// ErrorPolicy decides what happens when a chunk of a document fails to
+// translate, for example because the provider could not be reached or the
+// translation did not pass validation. Chunks that the model refused to
+// translate are handled by [TranslateParams.SkipRefused] instead, and canceled
+// translations always abort. The zero value aborts.
+type ErrorPolicy struct {
+	// Action is the action that is taken for a failed chunk.
+	Action ErrorAction
+
+	// Retries is the number of times a failed chunk is translated again if the
+	// Action is [ErrorRetry].
+	Retries int
+}
+
+// ParseErrorPolicy parses an error policy from its string representation:
+// "abort", "skip" or "retry N", where N is the number of retries (e.g.
+// "retry 3"). The number may also be separated by a colon, like "retry:3".
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch s {
+	case "", string(ErrorAbort):
+		return ErrorPolicy{Action: ErrorAbort}, nil
+	case string(ErrorSkip):
+		return ErrorPolicy{Action: ErrorSkip}, nil
+	}
+
+	action, count, ok := strings.Cut(strings.Replace(s, ":", " ", 1), " ")
+	if !ok || ErrorAction(action) != ErrorRetry {
+		return ErrorPolicy{}, fmt.Errorf("invalid error policy %q: expected 'abort', 'skip' or 'retry N'", s)
+	}
+
+	retries, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || retries < 1 {
+		return ErrorPolicy{}, fmt.Errorf("invalid error policy %q: the number of retries must be a positive integer", s)
+	}
+
+	return ErrorPolicy{Action: ErrorRetry, Retries: retries}, nil
+}
+
+// String returns the string representation of the policy, which can be parsed
+// using [ParseErrorPolicy].
+func (p ErrorPolicy) String() string {
+	switch p.Action {
+	case ErrorRetry:
+		return fmt.Sprintf("%s %d", ErrorRetry, p.Retries)
+	case "":
+		return string(ErrorAbort)
+	default:
+		return string(p.Action)
+	}
+}
+
+// retry reports whether a chunk that failed the given number of times should
+// be translated again.
+func (p ErrorPolicy) retry(failures int) bool {
+	return p.Action == ErrorRetry && failures <= p.Retries
+}
@@ -0,0 +1,140 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+func TestParseErrorPolicy(t *testing.T) {
+	tests := map[string]dragoman.ErrorPolicy{
+		"":        {Action: dragoman.ErrorAbort},
+		"abort":   {Action: dragoman.ErrorAbort},
+		"Skip":    {Action: dragoman.ErrorSkip},
+		"retry 3": {Action: dragoman.ErrorRetry, Retries: 3},
+		"retry:2": {Action: dragoman.ErrorRetry, Retries: 2},
+	}
+
+	for s, want := range tests {
+		got, err := dragoman.ParseErrorPolicy(s)
+		if err != nil {
+			t.Errorf("ParseErrorPolicy(%q): %v", s, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseErrorPolicy(%q) should return %+v; got %+v", s, want, got)
+		}
+	}
+
+	for _, s := range []string{"ignore", "retry", "retry 0", "retry many"} {
+		if _, err := dragoman.ParseErrorPolicy(s); err == nil {
+			t.Errorf("ParseErrorPolicy(%q) should fail", s)
+		}
+	}
+
+	if got := (dragoman.ErrorPolicy{Action: dragoman.ErrorRetry, Retries: 3}).String(); got != "retry 3" {
+		t.Errorf("String() should return %q; got %q", "retry 3", got)
+	}
+}
+
+func TestTranslator_Translate_errorPolicy(t *testing.T) {
+	source := heredoc.Doc(`
+		# Intro
+
+		Hallo Welt!
+
+		# Outro
+
+		Tschüss!
+	`)
+
+	errUnavailable := errors.New("service unavailable")
+
+	tests := map[string]struct {
+		policy   dragoman.ErrorPolicy
+		failures int
+		want     string
+		skipped  int
+		err      error
+	}{
+		"abort": {
+			policy:   dragoman.ErrorPolicy{},
+			failures: 1,
+			err:      errUnavailable,
+		},
+		"skip": {
+			policy:   dragoman.ErrorPolicy{Action: dragoman.ErrorSkip},
+			failures: 1,
+			want:     "# Intro\n\nHello world!\n\n# Outro\n\nTschüss!\n",
+			skipped:  1,
+		},
+		"retry": {
+			policy:   dragoman.ErrorPolicy{Action: dragoman.ErrorRetry, Retries: 2},
+			failures: 2,
+			want:     "# Intro\n\nHello world!\n\n# Outro\n\nBye!\n",
+		},
+		"retries exhausted": {
+			policy:   dragoman.ErrorPolicy{Action: dragoman.ErrorRetry, Retries: 2},
+			failures: 3,
+			err:      errUnavailable,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var failures int
+			model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+				if !strings.Contains(prompt, "Tschüss") {
+					return "# Intro\n\nHello world!", nil
+				}
+				if failures < tt.failures {
+					failures++
+					return "", errUnavailable
+				}
+				return "# Outro\n\nBye!", nil
+			})
+
+			var skipped []dragoman.SkippedChunk
+			result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+				Document:    source,
+				SplitChunks: []string{"# "},
+				ErrorPolicy: tt.policy,
+				OnSkip: func(chunk dragoman.SkippedChunk) {
+					skipped = append(skipped, chunk)
+				},
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Translate() should fail with %v; got %v", tt.err, err)
+			}
+
+			if result != tt.want {
+				t.Fatalf("Translate() should return %q; got %q", tt.want, result)
+			}
+
+			if len(skipped) != tt.skipped {
+				t.Fatalf("%d chunks should be skipped; got %+v", tt.skipped, skipped)
+			}
+		})
+	}
+}
+
+func TestTranslator_Translate_errorPolicy_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	model := dragoman.ModelFunc(func(ctx context.Context, _ string) (string, error) {
+		cancel()
+		return "", ctx.Err()
+	})
+
+	_, err := dragoman.NewTranslator(model).Translate(ctx, dragoman.TranslateParams{
+		Document:    "Hallo Welt!",
+		ErrorPolicy: dragoman.ErrorPolicy{Action: dragoman.ErrorSkip},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Translate() should fail with %v; got %v", context.Canceled, err)
+	}
+}
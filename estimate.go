@@ -0,0 +1,150 @@
+package dragoman
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TokenCounter returns the number of tokens of a text for a specific model.
+type TokenCounter func(text string) (int, error)
+
+// Pricing is the price of a model in USD per one million tokens.
+type Pricing struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Cost returns the cost in USD of the given number of prompt and completion
+// tokens.
+func (p Pricing) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1e6
+}
+
+// EstimateParams configures the estimation of a translation. It embeds the
+// [TranslateParams] of the translation to estimate.
+type EstimateParams struct {
+	TranslateParams
+
+	// Tokens counts the tokens of a text for the model that will be used. It is
+	// required.
+	Tokens TokenCounter
+
+	// Pricing is the price of the model. If it is zero, the estimate contains
+	// only token counts.
+	Pricing Pricing
+}
+
+// CostEstimate is the estimated token usage and cost of a translation, both in
+// total and per chunk.
+type CostEstimate struct {
+	ChunkEstimate
+
+	// Chunks contains the estimates of the individual chunks of the document.
+	Chunks []ChunkEstimate
+}
+
+// ChunkEstimate is the estimated token usage and cost of a single chunk.
+type ChunkEstimate struct {
+	PromptTokens     int
+	CompletionTokens int
+
+	// Cost is the estimated cost in USD.
+	Cost float64
+}
+
+// Estimate estimates the token usage and cost of a translation before any
+// request is made to the model. The prompt tokens are counted from the exact
+// prompts that would be sent to the model. The completion tokens are
+// approximated by the token count of the chunk itself, because a translation is
+// usually about as long as its source.
+func Estimate(params EstimateParams) (CostEstimate, error) {
+	return NewTranslator(nil).Estimate(params)
+}
+
+// Estimate estimates the token usage and cost of a translation using the
+// prompts of the [Translator]. See the package-level [Estimate] function for
+// details.
+func (t *Translator) Estimate(params EstimateParams) (CostEstimate, error) {
+	if params.Tokens == nil {
+		return CostEstimate{}, errors.New("missing token counter")
+	}
+
+	if params.Target == "" {
+		params.Target = "English"
+	}
+
+	return estimateChunks(params.chunks(), func(i int, chunk string) (string, error) {
+		if _, ok := params.Overrides[i+1]; ok {
+			return "", nil
+		}
+		return t.requestPrompt(chunk, params.TranslateParams)
+	}, params.Tokens, params.Pricing)
+}
+
+// ImproveEstimateParams configures the estimation of an improvement. It embeds
+// the [ImproveParams] of the improvement to estimate.
+type ImproveEstimateParams struct {
+	ImproveParams
+
+	// Tokens counts the tokens of a text for the model that will be used. It is
+	// required.
+	Tokens TokenCounter
+
+	// Pricing is the price of the model. If it is zero, the estimate contains
+	// only token counts.
+	Pricing Pricing
+}
+
+// Estimate estimates the token usage and cost of an improvement before any
+// request is made to the model. Like for translations, the completion tokens
+// are approximated by the token count of the chunk itself. Retries caused by
+// [ImproveParams.PreserveOutline] are not included.
+func (imp *Improver) Estimate(params ImproveEstimateParams) (CostEstimate, error) {
+	if params.Tokens == nil {
+		return CostEstimate{}, errors.New("missing token counter")
+	}
+
+	return estimateChunks(params.ImproveParams.chunks(), func(_ int, chunk string) (string, error) {
+		return imp.prompt(chunk, params.ImproveParams), nil
+	}, params.Tokens, params.Pricing)
+}
+
+// estimateChunks estimates the given chunks using their prompts. Chunks with an
+// empty prompt are not sent to the model and are estimated at zero.
+func estimateChunks(docChunks []string, prompt func(int, string) (string, error), tokens TokenCounter, pricing Pricing) (CostEstimate, error) {
+	var out CostEstimate
+	for i, chunk := range docChunks {
+		p, err := prompt(i, chunk)
+		if err != nil {
+			return out, err
+		}
+
+		if p == "" {
+			out.Chunks = append(out.Chunks, ChunkEstimate{})
+			continue
+		}
+
+		promptTokens, err := tokens(p)
+		if err != nil {
+			return out, fmt.Errorf("count prompt tokens: %w", err)
+		}
+
+		completionTokens, err := tokens(chunk)
+		if err != nil {
+			return out, fmt.Errorf("count completion tokens: %w", err)
+		}
+
+		est := ChunkEstimate{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			Cost:             pricing.Cost(promptTokens, completionTokens),
+		}
+
+		out.Chunks = append(out.Chunks, est)
+		out.PromptTokens += est.PromptTokens
+		out.CompletionTokens += est.CompletionTokens
+		out.Cost += est.Cost
+	}
+
+	return out, nil
+}
@@ -0,0 +1,112 @@
+package dragoman_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestEstimate(t *testing.T) {
+	source := heredoc.Doc(`
+		# First
+
+		Hello world.
+
+		# Second
+
+		Goodbye world.
+	`)
+
+	var prompts []string
+	est, err := dragoman.Estimate(dragoman.EstimateParams{
+		TranslateParams: dragoman.TranslateParams{
+			Document:    source,
+			Target:      "German",
+			SplitChunks: []string{"#"},
+		},
+		Tokens: func(text string) (int, error) {
+			prompts = append(prompts, text)
+			return len(strings.Fields(text)), nil
+		},
+		Pricing: dragoman.Pricing{Prompt: 1e6, Completion: 2e6},
+	})
+	if err != nil {
+		t.Fatalf("Estimate() failed: %v", err)
+	}
+
+	if len(est.Chunks) != 2 {
+		t.Fatalf("expected 2 chunk estimates; got %d", len(est.Chunks))
+	}
+
+	for i, chunk := range est.Chunks {
+		if !strings.Contains(prompts[i*2], "Translate the following document to German") {
+			t.Errorf("prompt tokens of chunk %d were not counted from the translation prompt", i)
+		}
+
+		if want := float64(chunk.PromptTokens + 2*chunk.CompletionTokens); math.Abs(chunk.Cost-want) > 1e-9 {
+			t.Errorf("cost of chunk %d should be %v; is %v", i, want, chunk.Cost)
+		}
+	}
+
+	want := []int{4, 4}
+	got := []int{est.Chunks[0].CompletionTokens, est.Chunks[1].CompletionTokens}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("completion tokens mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if est.PromptTokens != est.Chunks[0].PromptTokens+est.Chunks[1].PromptTokens {
+		t.Errorf("total prompt tokens should be the sum of all chunks; got %d", est.PromptTokens)
+	}
+}
+
+func TestEstimate_missingTokenCounter(t *testing.T) {
+	if _, err := dragoman.Estimate(dragoman.EstimateParams{}); err == nil {
+		t.Fatalf("Estimate() should fail without a token counter")
+	}
+}
+
+func TestImprover_Estimate(t *testing.T) {
+	source := heredoc.Doc(`
+		# First
+
+		Hello world.
+
+		# Second
+
+		Goodbye world.
+	`)
+
+	var prompts []string
+	est, err := dragoman.NewImprover(nil).Estimate(dragoman.ImproveEstimateParams{
+		ImproveParams: dragoman.ImproveParams{
+			Document:    source,
+			SplitChunks: []string{"#"},
+		},
+		Tokens: func(text string) (int, error) {
+			prompts = append(prompts, text)
+			return len(strings.Fields(text)), nil
+		},
+		Pricing: dragoman.Pricing{Prompt: 1e6, Completion: 1e6},
+	})
+	if err != nil {
+		t.Fatalf("Estimate() failed: %v", err)
+	}
+
+	if len(est.Chunks) != 2 {
+		t.Fatalf("expected 2 chunk estimates; got %d", len(est.Chunks))
+	}
+
+	for i := range est.Chunks {
+		if !strings.Contains(prompts[i*2], "Improve the following document") {
+			t.Errorf("prompt tokens of chunk %d were not counted from the improvement prompt", i)
+		}
+	}
+
+	if want := float64(est.PromptTokens + est.CompletionTokens); math.Abs(est.Cost-want) > 1e-9 {
+		t.Errorf("total cost should be %v; is %v", want, est.Cost)
+	}
+}
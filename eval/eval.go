@@ -0,0 +1,176 @@
+package eval
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ChrFOrder is the maximum character n-gram order used by [ChrF].
+	ChrFOrder = 6
+
+	// ChrFBeta is the recall weight used by [ChrF].
+	ChrFBeta = 2
+
+	// BLEUOrder is the maximum word n-gram order used by [BLEU].
+	BLEUOrder = 4
+)
+
+// ChrF computes the chrF score of a hypothesis against a reference
+// translation. The score is the F-score of character n-grams up to
+// [ChrFOrder], weighting recall [ChrFBeta] times as much as precision.
+// Whitespace is ignored. The score ranges from 0 to 100.
+func ChrF(hypothesis, reference string) float64 {
+	hyp := []rune(removeSpace(hypothesis))
+	ref := []rune(removeSpace(reference))
+
+	var precision, recall float64
+	var orders int
+	for n := 1; n <= ChrFOrder; n++ {
+		hypGrams := charNGrams(hyp, n)
+		refGrams := charNGrams(ref, n)
+		if len(hypGrams) == 0 && len(refGrams) == 0 {
+			break
+		}
+
+		matches := matchingNGrams(hypGrams, refGrams)
+		precision += ratio(matches, countNGrams(hypGrams))
+		recall += ratio(matches, countNGrams(refGrams))
+		orders++
+	}
+
+	if orders == 0 {
+		return 0
+	}
+
+	precision /= float64(orders)
+	recall /= float64(orders)
+
+	if precision == 0 && recall == 0 {
+		return 0
+	}
+
+	beta2 := float64(ChrFBeta * ChrFBeta)
+	return 100 * (1 + beta2) * precision * recall / (beta2*precision + recall)
+}
+
+// BLEU computes the BLEU score of a hypothesis against a reference translation
+// using word n-grams up to [BLEUOrder], a brevity penalty and add-one smoothing
+// for higher-order n-grams. Punctuation is split from words before comparison.
+// The score ranges from 0 to 100.
+func BLEU(hypothesis, reference string) float64 {
+	hyp := Tokenize(hypothesis)
+	ref := Tokenize(reference)
+
+	if len(hyp) == 0 || len(ref) == 0 {
+		return 0
+	}
+
+	var logPrecision float64
+	for n := 1; n <= BLEUOrder; n++ {
+		hypGrams := wordNGrams(hyp, n)
+		refGrams := wordNGrams(ref, n)
+
+		matches := float64(matchingNGrams(hypGrams, refGrams))
+		total := float64(countNGrams(hypGrams))
+		if n > 1 {
+			matches++
+			total++
+		}
+
+		if matches == 0 || total == 0 {
+			return 0
+		}
+		logPrecision += math.Log(matches / total)
+	}
+
+	brevity := 1.0
+	if len(hyp) < len(ref) {
+		brevity = math.Exp(1 - float64(len(ref))/float64(len(hyp)))
+	}
+
+	return 100 * brevity * math.Exp(logPrecision/BLEUOrder)
+}
+
+// Tokenize splits a text into words and punctuation marks.
+func Tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+	)
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func removeSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func charNGrams(runes []rune, n int) map[string]int {
+	grams := make(map[string]int)
+	for i := 0; i+n <= len(runes); i++ {
+		grams[string(runes[i:i+n])]++
+	}
+	return grams
+}
+
+func wordNGrams(words []string, n int) map[string]int {
+	grams := make(map[string]int)
+	for i := 0; i+n <= len(words); i++ {
+		grams[strings.Join(words[i:i+n], "\x00")]++
+	}
+	return grams
+}
+
+func matchingNGrams(hyp, ref map[string]int) int {
+	var matches int
+	for gram, count := range hyp {
+		if refCount := ref[gram]; refCount < count {
+			matches += refCount
+		} else {
+			matches += count
+		}
+	}
+	return matches
+}
+
+func countNGrams(grams map[string]int) int {
+	var total int
+	for _, count := range grams {
+		total += count
+	}
+	return total
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
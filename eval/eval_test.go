@@ -0,0 +1,67 @@
+package eval_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/eval"
+)
+
+func TestChrF(t *testing.T) {
+	tests := []struct {
+		name       string
+		hypothesis string
+		reference  string
+		want       float64
+	}{
+		{"identical", "Hallo Welt!", "Hallo Welt!", 100},
+		{"whitespace", "Hallo  Welt!", "Hallo Welt!", 100},
+		{"different", "abc", "xyz", 0},
+		{"empty", "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eval.ChrF(tt.hypothesis, tt.reference); !almostEqual(got, tt.want) {
+				t.Fatalf("ChrF(%q, %q): got %f; want %f", tt.hypothesis, tt.reference, got, tt.want)
+			}
+		})
+	}
+
+	similar := eval.ChrF("Hallo schöne Welt!", "Hallo Welt!")
+	if similar <= 0 || similar >= 100 {
+		t.Fatalf("ChrF() of similar texts should be between 0 and 100; got %f", similar)
+	}
+}
+
+func TestBLEU(t *testing.T) {
+	if got := eval.BLEU("the cat sat on the mat", "the cat sat on the mat"); !almostEqual(got, 100) {
+		t.Fatalf("BLEU() of identical texts should be 100; got %f", got)
+	}
+
+	if got := eval.BLEU("foo bar", "the cat sat on the mat"); got != 0 {
+		t.Fatalf("BLEU() of unrelated texts should be 0; got %f", got)
+	}
+
+	partial := eval.BLEU("the cat sat on a mat", "the cat sat on the mat")
+	if partial <= 0 || partial >= 100 {
+		t.Fatalf("BLEU() of similar texts should be between 0 and 100; got %f", partial)
+	}
+
+	short := eval.BLEU("the cat", "the cat sat on the mat")
+	if short >= partial {
+		t.Fatalf("BLEU() should penalize short hypotheses; got %f >= %f", short, partial)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	want := []string{"Hello", ",", "world", "!"}
+	if got := eval.Tokenize("Hello, world!"); !cmp.Equal(want, got) {
+		t.Fatalf("Tokenize(): got %v; want %v", got, want)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,410 @@
+package androidxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var formatSpecifier = regexp.MustCompile(`%(\d+\$)?[-#+ 0,(]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// File is a parsed Android resource file (e.g. "res/values/strings.xml"). It
+// keeps the original bytes of the file, so that writing it back only replaces
+// the texts that were set using [Entry.SetTranslation]. Comments, attributes
+// and formatting are preserved verbatim.
+type File struct {
+	// Entries are the translatable texts of the file. Resources that are marked
+	// with translatable="false" and items that reference other resources (e.g.
+	// "@string/app_name") are not included.
+	Entries []*Entry
+
+	data      []byte
+	elements  []element
+	closeTag  int
+	hasCloser bool
+}
+
+// Entry is a translatable text of a [File].
+type Entry struct {
+	// Key identifies the text within the file. It is the name of a <string>,
+	// the name of a <string-array> followed by the index of the item in
+	// brackets (e.g. "planets[2]"), or the name of a <plurals> followed by the
+	// quantity of the item (e.g. "songs#one").
+	Key string
+
+	// Text is the text to translate. Escaped quotes are unescaped and CDATA
+	// sections are unwrapped; inline markup like <b> or <xliff:g> is kept.
+	Text string
+
+	start, end int
+	cdata      bool
+	element    string
+	pending    bool
+	translated bool
+	inner      string
+}
+
+// element is a top-level resource of a file.
+type element struct {
+	name       string
+	start, end int
+}
+
+// Parse parses an Android resource file.
+func Parse(data []byte) (*File, error) {
+	f := File{data: data, closeTag: -1}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+
+	var (
+		stack    []string
+		resource string
+		skip     bool
+		items    int
+		elStart  int
+	)
+
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, tok.Name.Local)
+
+			if len(stack) == 1 {
+				if tok.Name.Local != "resources" {
+					return nil, fmt.Errorf("unexpected root element <%s>", tok.Name.Local)
+				}
+				continue
+			}
+
+			if len(stack) == 2 {
+				resource = attr(tok, "name")
+				skip = attr(tok, "translatable") == "false"
+				items = 0
+				elStart = start
+			}
+
+			switch {
+			case len(stack) == 2 && tok.Name.Local == "string":
+				if err := f.readEntry(dec, resource, resource, skip); err != nil {
+					return nil, err
+				}
+				stack = stack[:len(stack)-1]
+				f.elements = append(f.elements, element{name: resource, start: elStart, end: int(dec.InputOffset())})
+			case len(stack) == 3 && tok.Name.Local == "item" && (stack[1] == "string-array" || stack[1] == "plurals"):
+				key := resource + "[" + strconv.Itoa(items) + "]"
+				if stack[1] == "plurals" {
+					key = resource + "#" + attr(tok, "quantity")
+				}
+				items++
+				if err := f.readEntry(dec, key, resource, skip); err != nil {
+					return nil, err
+				}
+				stack = stack[:len(stack)-1]
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			switch len(stack) {
+			case 0:
+				f.closeTag = start
+				f.hasCloser = true
+			case 1:
+				f.elements = append(f.elements, element{name: resource, start: elStart, end: int(dec.InputOffset())})
+			}
+		}
+	}
+
+	if !f.hasCloser {
+		return nil, errors.New("missing <resources> root element")
+	}
+
+	return &f, nil
+}
+
+func (f *File) readEntry(dec *xml.Decoder, key, resource string, skip bool) error {
+	inner, start, end, err := innerXML(dec, f.data)
+	if err != nil {
+		return err
+	}
+
+	if skip || strings.TrimSpace(inner) == "" || strings.HasPrefix(strings.TrimSpace(inner), "@") {
+		return nil
+	}
+
+	e := &Entry{Key: key, start: start, end: end, element: resource, inner: inner}
+	if trimmed := strings.TrimSpace(inner); strings.HasPrefix(trimmed, "<![CDATA[") && strings.HasSuffix(trimmed, "]]>") {
+		e.cdata = true
+		e.Text = strings.TrimSuffix(strings.TrimPrefix(trimmed, "<![CDATA["), "]]>")
+	} else {
+		e.Text = unescapeQuotes(inner)
+	}
+
+	f.Entries = append(f.Entries, e)
+
+	return nil
+}
+
+// Merge adds the resources of the source file that are missing in the target
+// file to the end of the target file and returns the merged file. The texts of
+// the added resources are returned by [File.Untranslated] and still need to be
+// translated. Resources that are marked with translatable="false" are not
+// added. If target is nil, the merged file is a copy of the source file and
+// all of its texts are untranslated.
+func Merge(source, target *File) (*File, error) {
+	if target == nil {
+		merged, err := Parse(source.data)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range merged.Entries {
+			e.pending = true
+		}
+		return merged, nil
+	}
+
+	existing := make(map[string]bool, len(target.elements))
+	for _, el := range target.elements {
+		existing[el.name] = true
+	}
+
+	translatable := make(map[string]bool)
+	for _, e := range source.Entries {
+		translatable[e.element] = true
+	}
+
+	indent := elementIndent(target)
+	if indent == "" {
+		indent = elementIndent(source)
+	}
+
+	var (
+		added   bytes.Buffer
+		pending = make(map[string]bool)
+	)
+	for _, el := range source.elements {
+		if existing[el.name] || !translatable[el.name] {
+			continue
+		}
+		added.WriteString(indent)
+		added.Write(source.data[el.start:el.end])
+		added.WriteString("\n")
+		pending[el.name] = true
+	}
+
+	if added.Len() == 0 {
+		return Parse(target.data)
+	}
+
+	// Insert the resources on their own lines before </resources>.
+	insertAt := target.closeTag
+	lineStart := bytes.LastIndexByte(target.data[:insertAt], '\n') + 1
+	ownLine := strings.TrimSpace(string(target.data[lineStart:insertAt])) == ""
+	if ownLine {
+		insertAt = lineStart
+	}
+
+	var buf bytes.Buffer
+	buf.Write(target.data[:insertAt])
+	if !ownLine {
+		buf.WriteString("\n")
+	}
+	buf.Write(added.Bytes())
+	buf.Write(target.data[insertAt:])
+
+	merged, err := Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("parse merged file: %w", err)
+	}
+
+	for _, e := range merged.Entries {
+		e.pending = pending[e.element]
+	}
+
+	return merged, nil
+}
+
+// Untranslated returns the texts that were added by [Merge] and have not been
+// translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if e.pending && !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the resource file with the translations of all translated
+// texts.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(e.inner)
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the text. Quotes are escaped and CDATA
+// sections are restored. It returns an error if the translation is not
+// well-formed XML or if its format specifiers (e.g. "%1$s") or inline tags
+// differ from those of the original text.
+func (e *Entry) SetTranslation(text string) error {
+	want, err := placeholders(e.Text, e.cdata)
+	if err != nil {
+		return fmt.Errorf("text of %q: %w", e.Key, err)
+	}
+
+	got, err := placeholders(text, e.cdata)
+	if err != nil {
+		return fmt.Errorf("translation of %q: %w", e.Key, err)
+	}
+
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Key, want, got)
+	}
+
+	if e.cdata {
+		e.inner = "<![CDATA[" + text + "]]>"
+	} else {
+		e.inner = escapeQuotes(text)
+	}
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// placeholders returns the sorted format specifiers and inline tags of a text.
+func placeholders(text string, cdata bool) ([]string, error) {
+	out := formatSpecifier.FindAllString(text, -1)
+
+	if !cdata {
+		dec := xml.NewDecoder(strings.NewReader("<x>" + text + "</x>"))
+		dec.Strict = true
+		for {
+			tok, err := dec.Token()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid XML: %w", err)
+			}
+
+			if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "x" {
+				out = append(out, "<"+start.Name.Local+">")
+			}
+		}
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
+
+// unescapeQuotes removes the backslashes of escaped quotes.
+func unescapeQuotes(s string) string {
+	return strings.NewReplacer(`\'`, `'`, `\"`, `"`).Replace(s)
+}
+
+// escapeQuotes escapes the unescaped quotes of a text outside of tags, as
+// required by Android resource files.
+func escapeQuotes(s string) string {
+	var (
+		b     strings.Builder
+		inTag bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			b.WriteByte(c)
+			b.WriteByte(s[i+1])
+			i++
+			continue
+		case c == '<':
+			inTag = true
+		case c == '>':
+			inTag = false
+		case !inTag && (c == '\'' || c == '"'):
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// innerXML reads the remaining tokens of the current element and returns its
+// inner XML, the range of the inner XML, and an error if the element is not
+// well-formed.
+func innerXML(dec *xml.Decoder, data []byte) (string, int, int, error) {
+	innerStart := int(dec.InputOffset())
+	innerEnd := innerStart
+
+	for depth := 1; depth > 0; {
+		innerEnd = int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	// Self-closing elements have no inner XML.
+	if innerEnd < innerStart {
+		innerEnd = innerStart
+	}
+
+	return string(data[innerStart:innerEnd]), innerStart, innerEnd, nil
+}
+
+// elementIndent returns the indentation of the first resource of a file.
+func elementIndent(f *File) string {
+	if len(f.elements) == 0 {
+		return "    "
+	}
+	offset := f.elements[0].start
+	lineStart := bytes.LastIndexByte(f.data[:offset], '\n') + 1
+	indent := string(f.data[lineStart:offset])
+	if strings.TrimSpace(indent) != "" {
+		return ""
+	}
+	return indent
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
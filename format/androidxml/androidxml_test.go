@@ -0,0 +1,182 @@
+package androidxml_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/androidxml"
+)
+
+var source = heredoc.Doc(`
+	<?xml version="1.0" encoding="utf-8"?>
+	<resources xmlns:xliff="urn:oasis:names:tc:xliff:document:1.2">
+	    <!-- Branding -->
+	    <string name="app_name" translatable="false">Dragoman</string>
+	    <string name="welcome">Welcome, <xliff:g id="name">%1$s</xliff:g>!</string>
+	    <string name="hint">Don\'t forget to save.</string>
+	    <string name="html"><![CDATA[<b>Bold</b> text]]></string>
+	    <string-array name="planets">
+	        <item>Mercury</item>
+	        <item>@string/app_name</item>
+	        <item>Venus</item>
+	    </string-array>
+	    <plurals name="songs">
+	        <item quantity="one">%d song</item>
+	        <item quantity="other">%d songs</item>
+	    </plurals>
+	</resources>
+`)
+
+func TestParse(t *testing.T) {
+	f, err := androidxml.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+	}
+
+	want := map[string]string{
+		"welcome":     `Welcome, <xliff:g id="name">%1$s</xliff:g>!`,
+		"hint":        `Don't forget to save.`,
+		"html":        `<b>Bold</b> text`,
+		"planets[0]":  "Mercury",
+		"planets[2]":  "Venus",
+		"songs#one":   "%d song",
+		"songs#other": "%d songs",
+	}
+
+	got := make(map[string]string)
+	for _, e := range f.Entries {
+		got[e.Key] = e.Text
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	target := heredoc.Doc(`
+		<?xml version="1.0" encoding="utf-8"?>
+		<resources xmlns:xliff="urn:oasis:names:tc:xliff:document:1.2">
+		    <string name="hint">N\'oubliez pas d\'enregistrer.</string>
+		</resources>
+	`)
+
+	src, err := androidxml.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	tgt, err := androidxml.Parse([]byte(target))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := androidxml.Merge(src, tgt)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	translations := map[string]string{
+		"welcome":     `Bienvenue, <xliff:g id="name">%1$s</xliff:g> !`,
+		"html":        `Texte <b>gras</b>`,
+		"planets[0]":  "Mercure",
+		"planets[2]":  "Vénus",
+		"songs#one":   "%d chanson",
+		"songs#other": "%d chansons",
+	}
+
+	var keys []string
+	for _, e := range merged.Untranslated() {
+		keys = append(keys, e.Key)
+		if err := e.SetTranslation(translations[e.Key]); err != nil {
+			t.Fatalf("SetTranslation(): %v", err)
+		}
+	}
+
+	wantKeys := []string{"welcome", "html", "planets[0]", "planets[2]", "songs#one", "songs#other"}
+	if !cmp.Equal(wantKeys, keys) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(wantKeys, keys))
+	}
+
+	want := heredoc.Doc(`
+		<?xml version="1.0" encoding="utf-8"?>
+		<resources xmlns:xliff="urn:oasis:names:tc:xliff:document:1.2">
+		    <string name="hint">N\'oubliez pas d\'enregistrer.</string>
+		    <string name="welcome">Bienvenue, <xliff:g id="name">%1$s</xliff:g> !</string>
+		    <string name="html"><![CDATA[Texte <b>gras</b>]]></string>
+		    <string-array name="planets">
+		        <item>Mercure</item>
+		        <item>@string/app_name</item>
+		        <item>Vénus</item>
+		    </string-array>
+		    <plurals name="songs">
+		        <item quantity="one">%d chanson</item>
+		        <item quantity="other">%d chansons</item>
+		    </plurals>
+		</resources>
+	`)
+
+	if got := string(merged.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := androidxml.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := androidxml.Merge(f, nil)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	entries := make(map[string]*androidxml.Entry)
+	for _, e := range merged.Untranslated() {
+		entries[e.Key] = e
+	}
+
+	if err := entries["welcome"].SetTranslation("Bienvenue !"); err == nil {
+		t.Errorf("SetTranslation() should fail if a placeholder is missing")
+	}
+
+	if err := entries["songs#one"].SetTranslation("%s chanson"); err == nil {
+		t.Errorf("SetTranslation() should fail if a format specifier changes")
+	}
+
+	if err := entries["hint"].SetTranslation(`N'oubliez pas d'enregistrer "maintenant".`); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := `<string name="hint">N\'oubliez pas d\'enregistrer \"maintenant\".</string>`
+	if got := string(merged.Bytes()); !containsLine(got, want) {
+		t.Fatalf("Bytes() should contain %q; got\n\n%s", want, got)
+	}
+}
+
+func containsLine(s, line string) bool {
+	for _, l := range splitLines(s) {
+		if l == "    "+line {
+			return true
+		}
+	}
+	return false
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			lines = append(lines, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(lines, s[start:])
+}
@@ -0,0 +1,407 @@
+package applestrings
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var formatSpecifier = regexp.MustCompile(`%(\d+\$)?[-#+ 0']*(\d+|\*)?(\.(\d+|\*))?(hh|h|ll|l|q|L|z|t|j)?[@dDiuUxXoOfFeEgGcCsSpaA%]`)
+
+// File is a parsed strings file (e.g. "en.lproj/Localizable.strings"). It
+// keeps the original bytes of the file, so that writing it back only replaces
+// the values that were set using [Entry.SetTranslation]. Comments and
+// formatting are preserved verbatim. UTF-16 encoded files are written back in
+// the same encoding.
+type File struct {
+	// Entries are the key-value pairs of the file in the order of the file.
+	Entries []*Entry
+
+	data  []byte
+	order binary.ByteOrder
+}
+
+// Entry is a key-value pair of a [File].
+type Entry struct {
+	// Key is the key of the entry.
+	Key string
+
+	// Text is the unescaped value of the entry.
+	Text string
+
+	// block is the range of the entry including its preceding comment.
+	block      [2]int
+	start, end int
+	bare       bool
+	pending    bool
+	translated bool
+	raw        string
+}
+
+// Parse parses a strings file. The file may be encoded as UTF-8 or as UTF-16
+// with a byte order mark.
+func Parse(data []byte) (*File, error) {
+	f := File{}
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		f.order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		f.order = binary.BigEndian
+	}
+
+	if f.order != nil {
+		decoded, err := decodeUTF16(data[2:], f.order)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+
+	if !utf8.Valid(data) {
+		return nil, errors.New("file is not valid UTF-8 or UTF-16")
+	}
+
+	f.data = data
+	p := parser{data: data}
+
+	for {
+		blockStart := p.pos
+		if err := p.skipSpaceAndComments(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(data) {
+			break
+		}
+
+		key, _, _, err := p.readToken()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+
+		value, start, end, err := p.readToken()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(';'); err != nil {
+			return nil, err
+		}
+
+		f.Entries = append(f.Entries, &Entry{
+			Key:   key,
+			Text:  value,
+			block: [2]int{blockStart, p.pos},
+			start: start,
+			end:   end,
+			bare:  data[start-1] != '"',
+		})
+
+		// The block of the next entry starts on the next line.
+		if i := bytes.IndexByte(data[p.pos:], '\n'); i >= 0 && strings.TrimSpace(string(data[p.pos:p.pos+i])) == "" {
+			p.pos += i + 1
+		}
+	}
+
+	return &f, nil
+}
+
+// Merge appends the entries of the source file that are missing in the target
+// file to the end of the target file, together with their comments, and
+// returns the merged file. The values of the added entries are returned by
+// [File.Untranslated] and still need to be translated. If target is nil, the
+// merged file is a copy of the source file and all of its values are
+// untranslated.
+func Merge(source, target *File) (*File, error) {
+	if target == nil {
+		merged := *source
+		merged.Entries = make([]*Entry, len(source.Entries))
+		for i, e := range source.Entries {
+			clone := *e
+			clone.pending = true
+			merged.Entries[i] = &clone
+		}
+		return &merged, nil
+	}
+
+	existing := make(map[string]bool, len(target.Entries))
+	for _, e := range target.Entries {
+		existing[e.Key] = true
+	}
+
+	var (
+		added   bytes.Buffer
+		pending = make(map[string]bool)
+	)
+	for _, e := range source.Entries {
+		if existing[e.Key] {
+			continue
+		}
+		added.WriteString("\n")
+		added.WriteString(strings.TrimSpace(string(source.data[e.block[0]:e.block[1]])))
+		added.WriteString("\n")
+		pending[e.Key] = true
+	}
+
+	if added.Len() == 0 {
+		return target, nil
+	}
+
+	data := append([]byte(nil), target.data...)
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = nil
+		added.Next(1)
+	} else if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, added.Bytes()...)
+
+	merged, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse merged file: %w", err)
+	}
+	merged.order = target.order
+
+	for _, e := range merged.Entries {
+		e.pending = pending[e.Key]
+	}
+
+	return merged, nil
+}
+
+// Untranslated returns the entries that were added by [Merge] and have not
+// been translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if e.pending && !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the strings file with the translations of all translated
+// entries, in the encoding of the original file.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(e.raw)
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+
+	if f.order == nil {
+		return buf.Bytes()
+	}
+
+	return encodeUTF16(buf.String(), f.order)
+}
+
+// SetTranslation sets the translation of the entry. It returns an error if
+// the format specifiers (e.g. "%@" or "%1$ld") of the translation differ from
+// those of the original value.
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text), placeholders(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Key, want, got)
+	}
+
+	e.raw = escape(text)
+	if e.bare {
+		e.raw = `"` + e.raw + `"`
+	}
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// placeholders returns the sorted format specifiers of a text.
+func placeholders(text string) []string {
+	out := formatSpecifier.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func (p *parser) skipSpaceAndComments() error {
+	for p.pos < len(p.data) {
+		switch {
+		case isSpace(p.data[p.pos]):
+			p.pos++
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 4
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end + 1
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *parser) expect(c byte) error {
+	if err := p.skipSpaceAndComments(); err != nil {
+		return err
+	}
+	if p.pos >= len(p.data) || p.data[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// readToken reads a quoted string or an unquoted word and returns its
+// unescaped value and the range of its raw value without the quotes.
+func (p *parser) readToken() (string, int, int, error) {
+	if err := p.skipSpaceAndComments(); err != nil {
+		return "", 0, 0, err
+	}
+
+	if p.pos >= len(p.data) {
+		return "", 0, 0, p.errorf("unexpected end of file")
+	}
+
+	if p.data[p.pos] != '"' {
+		start := p.pos
+		for p.pos < len(p.data) && isWordChar(p.data[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", 0, 0, p.errorf("unexpected character %q", p.data[p.pos])
+		}
+		return string(p.data[start:p.pos]), start, p.pos, nil
+	}
+
+	start := p.pos + 1
+	for i := start; i < len(p.data); i++ {
+		switch p.data[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := unescape(string(p.data[start:i]))
+			if err != nil {
+				return "", 0, 0, p.errorf("%v", err)
+			}
+			p.pos = i + 1
+			return value, start, i, nil
+		}
+	}
+
+	return "", 0, 0, p.errorf("unterminated string")
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := bytes.Count(p.data[:p.pos], []byte("\n")) + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '$' || c == '/' || c == ':' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'U', 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid escape sequence %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\t", `\t`,
+		"\r", `\r`,
+	).Replace(s)
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, errors.New("invalid UTF-16 data")
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+func encodeUTF16(s string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 2+len(units)*2)
+	order.PutUint16(out, 0xFEFF)
+	for i, u := range units {
+		order.PutUint16(out[2+i*2:], u)
+	}
+	return out
+}
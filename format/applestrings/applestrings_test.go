@@ -0,0 +1,157 @@
+package applestrings_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/applestrings"
+)
+
+var source = heredoc.Doc(`
+	/* Title of the welcome screen */
+	"welcome.title" = "Welcome, %@!";
+
+	// Number of unread messages
+	"inbox.unread" = "You have %ld unread \"messages\".";
+
+	"multiline" = "First line\nSecond line";
+	legacy_key = "Legacy";
+`)
+
+func TestParse(t *testing.T) {
+	f, err := applestrings.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+	}
+
+	want := map[string]string{
+		"welcome.title": "Welcome, %@!",
+		"inbox.unread":  `You have %ld unread "messages".`,
+		"multiline":     "First line\nSecond line",
+		"legacy_key":    "Legacy",
+	}
+
+	got := make(map[string]string)
+	for _, e := range f.Entries {
+		got[e.Key] = e.Text
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestParse_utf16(t *testing.T) {
+	data := encodeUTF16LE("\"greeting\" = \"Grüß dich\";\n")
+
+	f, err := applestrings.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if len(f.Entries) != 1 || f.Entries[0].Text != "Grüß dich" {
+		t.Fatalf("unexpected entries: %+v", f.Entries)
+	}
+
+	merged, err := applestrings.Merge(f, nil)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	if err := merged.Untranslated()[0].SetTranslation("Hello there"); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := encodeUTF16LE("\"greeting\" = \"Hello there\";\n")
+	if got := merged.Bytes(); !cmp.Equal(want, got) {
+		t.Fatalf("Bytes() should be encoded as UTF-16\n\nwant: %v\n\ngot: %v", want, got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	target := heredoc.Doc(`
+		/* Title of the welcome screen */
+		"welcome.title" = "Willkommen, %@!";
+	`)
+
+	src, err := applestrings.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	tgt, err := applestrings.Parse([]byte(target))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := applestrings.Merge(src, tgt)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	translations := map[string]string{
+		"inbox.unread": `Du hast %ld ungelesene "Nachrichten".`,
+		"multiline":    "Erste Zeile\nZweite Zeile",
+		"legacy_key":   "Alt",
+	}
+
+	var keys []string
+	for _, e := range merged.Untranslated() {
+		keys = append(keys, e.Key)
+		if err := e.SetTranslation(translations[e.Key]); err != nil {
+			t.Fatalf("SetTranslation(): %v", err)
+		}
+	}
+
+	wantKeys := []string{"inbox.unread", "multiline", "legacy_key"}
+	if !cmp.Equal(wantKeys, keys) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(wantKeys, keys))
+	}
+
+	want := heredoc.Doc(`
+		/* Title of the welcome screen */
+		"welcome.title" = "Willkommen, %@!";
+
+		// Number of unread messages
+		"inbox.unread" = "Du hast %ld ungelesene \"Nachrichten\".";
+
+		"multiline" = "Erste Zeile\nZweite Zeile";
+
+		legacy_key = "Alt";
+	`)
+
+	if got := string(merged.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := applestrings.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if err := f.Entries[0].SetTranslation("Willkommen!"); err == nil {
+		t.Errorf("SetTranslation() should fail if a placeholder is missing")
+	}
+
+	if err := f.Entries[1].SetTranslation("Du hast %d ungelesene Nachrichten."); err == nil {
+		t.Errorf("SetTranslation() should fail if a format specifier changes")
+	}
+}
+
+func encodeUTF16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := []byte{0xFF, 0xFE}
+	for _, u := range units {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
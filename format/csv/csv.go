@@ -0,0 +1,415 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholder matches printf-style format specifiers (e.g. "%s", "%1$d") and
+// brace placeholders (e.g. "{name}", "{{count}}").
+var placeholder = regexp.MustCompile(`\{\{?[^{}\s]+\}\}?|%(\d+\$)?[-#+0]*\d*(\.\d+)?[sdfiuxXoeEgGc@%]`)
+
+// Delimiter returns the delimiter of a file with the given path: a tab for
+// ".tsv" and ".tab" files and a comma otherwise.
+func Delimiter(path string) byte {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		return '\t'
+	default:
+		return ','
+	}
+}
+
+// File is a parsed CSV or TSV file. The first row is the header that names
+// the columns. The file keeps its original bytes, so that writing it back only
+// replaces the cells that were set using [Entry.SetTranslation]. Delimiters,
+// quoting, line endings and all other cells are preserved verbatim.
+type File struct {
+	// Header are the names of the columns.
+	Header []string
+
+	// Entries are the cells of the translated columns. They are set by
+	// [Merge].
+	Entries []*Entry
+
+	delimiter byte
+	data      []byte
+	rows      []row
+}
+
+// Entry is a cell of a translated column of a [File].
+type Entry struct {
+	// Row is the 1-based number of the row of the cell, not counting the
+	// header.
+	Row int
+
+	// Column is the name of the column of the cell.
+	Column string
+
+	// Text is the unquoted text of the cell.
+	Text string
+
+	start, end int
+	prefix     string
+	quoted     bool
+	pending    bool
+	translated bool
+	raw        string
+	delimiter  byte
+}
+
+type row struct {
+	start, end int
+	fields     []field
+}
+
+type field struct {
+	start, end int
+	quoted     bool
+	value      string
+}
+
+// Parse parses a CSV file whose fields are separated by the given delimiter.
+// Fields may be quoted with double quotes, in which case they may contain
+// delimiters, line breaks and quotes that are escaped by doubling them. Empty
+// lines are skipped.
+func Parse(data []byte, delimiter byte) (*File, error) {
+	if delimiter == '"' || delimiter == '\n' || delimiter == '\r' {
+		return nil, fmt.Errorf("invalid delimiter %q", delimiter)
+	}
+
+	p := parser{data: data, delimiter: delimiter}
+	p.pos = len(bom(data))
+
+	f := File{delimiter: delimiter, data: data}
+	for p.pos < len(data) {
+		r, err := p.row()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line(r.start), err)
+		}
+		if r.end == r.start {
+			continue
+		}
+		f.rows = append(f.rows, r)
+	}
+
+	if len(f.rows) > 0 {
+		for _, field := range f.rows[0].fields {
+			f.Header = append(f.Header, field.value)
+		}
+	}
+
+	return &f, nil
+}
+
+// Columns returns the 0-based indexes of the columns that are selected by the
+// given names or 1-based numbers. If no columns are selected, all columns are
+// returned.
+func (f *File) Columns(selectors []string) ([]int, error) {
+	if len(selectors) == 0 {
+		columns := make([]int, len(f.Header))
+		for i := range columns {
+			columns[i] = i
+		}
+		return columns, nil
+	}
+
+	var columns []int
+	for _, sel := range selectors {
+		index := -1
+		for i, name := range f.Header {
+			if name == sel {
+				index = i
+				break
+			}
+		}
+
+		if n, err := strconv.Atoi(sel); index < 0 && err == nil {
+			if n < 1 || n > len(f.Header) {
+				return nil, fmt.Errorf("column %d out of range (1-%d)", n, len(f.Header))
+			}
+			index = n - 1
+		}
+
+		if index < 0 {
+			return nil, fmt.Errorf("unknown column %q", sel)
+		}
+		columns = append(columns, index)
+	}
+
+	sort.Ints(columns)
+	return columns, nil
+}
+
+// Merge returns the file that results from translating the given columns of
+// the source file, whose untranslated cells are returned by
+// [File.Untranslated]. If target is nil, the merged file is a copy of the
+// source file and all non-empty cells of the columns are untranslated.
+// Otherwise, the merged file is the target file, in which the cells of the
+// columns are untranslated if they are empty in the target file but not in
+// the source file. Rows of the source file that the target file does not have
+// yet are appended to the target file. Rows are matched by their position.
+func Merge(source, target *File, columns []int) (*File, error) {
+	if target == nil {
+		merged, err := Parse(source.data, source.delimiter)
+		if err != nil {
+			return nil, err
+		}
+		merged.addEntries(source, columns, 0)
+		return merged, nil
+	}
+
+	data := target.data
+	if len(source.rows) > len(target.rows) {
+		var buf bytes.Buffer
+		buf.Write(data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			buf.WriteString(newline(data))
+		}
+		for _, r := range source.rows[len(target.rows):] {
+			buf.Write(source.data[r.start:r.end])
+			buf.WriteString(newline(data))
+		}
+		data = buf.Bytes()
+	}
+
+	merged, err := Parse(data, target.delimiter)
+	if err != nil {
+		return nil, fmt.Errorf("parse merged file: %w", err)
+	}
+	merged.addEntries(source, columns, len(target.rows))
+
+	return merged, nil
+}
+
+// addEntries adds the cells of the columns to the entries of the merged file.
+// The first existing rows are the rows of the target file, whose cells are
+// only untranslated if they are empty.
+func (f *File) addEntries(source *File, columns []int, existing int) {
+	for i := 1; i < len(f.rows) && i < len(source.rows); i++ {
+		r := f.rows[i]
+		for _, col := range columns {
+			text := source.rows[i].cell(col)
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			e := &Entry{
+				Row:       i,
+				Text:      text,
+				pending:   true,
+				delimiter: f.delimiter,
+			}
+			if col < len(source.Header) {
+				e.Column = source.Header[col]
+			}
+
+			if col < len(r.fields) {
+				cell := r.fields[col]
+				if i < existing && strings.TrimSpace(cell.value) != "" {
+					continue
+				}
+				e.start, e.end, e.quoted = cell.start, cell.end, cell.quoted
+			} else {
+				// The row is shorter than the column, so the missing fields
+				// are inserted at the end of the row.
+				e.start, e.end = r.end, r.end
+				e.prefix = strings.Repeat(string(f.delimiter), col-len(r.fields)+1)
+			}
+
+			f.Entries = append(f.Entries, e)
+		}
+	}
+}
+
+// Untranslated returns the cells that were added by [Merge] and have not been
+// translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if e.pending && !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the file with the translations of all translated cells.
+func (f *File) Bytes() []byte {
+	entries := make([]*Entry, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		if e.translated {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].start < entries[j].start })
+
+	var buf bytes.Buffer
+	pos := 0
+	for i, e := range entries {
+		buf.Write(f.data[pos:e.start])
+		// Cells that are inserted at the end of the same short row share
+		// their position; only the delimiters that are still missing are
+		// inserted.
+		prefix := e.prefix
+		if i > 0 && e.prefix != "" && entries[i-1].start == e.start {
+			prefix = prefix[len(entries[i-1].prefix):]
+		}
+		buf.WriteString(prefix)
+		buf.WriteString(e.raw)
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the cell. The translation is quoted
+// if the cell was quoted or if the translation contains delimiters, quotes or
+// line breaks. It returns an error if the format specifiers (e.g. "%s") or
+// brace placeholders (e.g. "{name}") of the translation differ from those of
+// the original text.
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text), placeholders(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of row %d, column %q changes the placeholders %v to %v", e.Row, e.Column, want, got)
+	}
+
+	e.raw = text
+	if e.quoted || strings.ContainsAny(text, string(e.delimiter)+"\"\r\n") {
+		e.raw = `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+	}
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// placeholders returns the sorted placeholders of a text.
+func placeholders(text string) []string {
+	out := placeholder.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+func (r row) cell(col int) string {
+	if col < len(r.fields) {
+		return r.fields[col].value
+	}
+	return ""
+}
+
+type parser struct {
+	data      []byte
+	pos       int
+	delimiter byte
+}
+
+// row parses the row at the current position. The end of the row excludes
+// the line break.
+func (p *parser) row() (row, error) {
+	r := row{start: p.pos}
+
+	if p.lineBreak() {
+		r.end = r.start
+		return r, nil
+	}
+
+	for {
+		f, err := p.field()
+		if err != nil {
+			return r, err
+		}
+		r.fields = append(r.fields, f)
+
+		if p.pos < len(p.data) && p.data[p.pos] == p.delimiter {
+			p.pos++
+			continue
+		}
+
+		r.end = p.pos
+		p.lineBreak()
+		return r, nil
+	}
+}
+
+func (p *parser) field() (field, error) {
+	f := field{start: p.pos}
+
+	if p.pos < len(p.data) && p.data[p.pos] == '"' {
+		f.quoted = true
+
+		var value strings.Builder
+		for i := p.pos + 1; ; i++ {
+			if i >= len(p.data) {
+				return f, errors.New("unterminated quoted field")
+			}
+			if p.data[i] != '"' {
+				value.WriteByte(p.data[i])
+				continue
+			}
+			if i+1 < len(p.data) && p.data[i+1] == '"' {
+				value.WriteByte('"')
+				i++
+				continue
+			}
+
+			p.pos = i + 1
+			if p.pos < len(p.data) && p.data[p.pos] != p.delimiter && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				return f, fmt.Errorf("unexpected %q after quoted field", p.data[p.pos])
+			}
+			f.end = p.pos
+			f.value = value.String()
+			return f, nil
+		}
+	}
+
+	for p.pos < len(p.data) && p.data[p.pos] != p.delimiter && p.data[p.pos] != '\n' && !p.atCRLF() {
+		p.pos++
+	}
+	f.end = p.pos
+	f.value = string(p.data[f.start:f.end])
+
+	return f, nil
+}
+
+// lineBreak skips a line break at the current position and reports whether
+// there was one.
+func (p *parser) lineBreak() bool {
+	switch {
+	case p.atCRLF():
+		p.pos += 2
+		return true
+	case p.pos < len(p.data) && p.data[p.pos] == '\n':
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) atCRLF() bool {
+	return p.pos+1 < len(p.data) && p.data[p.pos] == '\r' && p.data[p.pos+1] == '\n'
+}
+
+func (p *parser) line(pos int) int {
+	return bytes.Count(p.data[:pos], []byte("\n")) + 1
+}
+
+func bom(data []byte) []byte {
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		return data[:3]
+	}
+	return nil
+}
+
+func newline(data []byte) string {
+	if bytes.Contains(data, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
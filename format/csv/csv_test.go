@@ -0,0 +1,150 @@
+package csv_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/csv"
+)
+
+var source = heredoc.Doc(`
+	id,title,description,price
+	1,Chair,"A chair, made of wood.",10
+	2,"Table","The ""classic"" table
+	for {count} people.",20
+	3,Lamp,,5
+`)
+
+func TestParse(t *testing.T) {
+	f, err := csv.Parse([]byte(source), ',')
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if want := []string{"id", "title", "description", "price"}; !cmp.Equal(want, f.Header) {
+		t.Fatalf("Header mismatch (-want +got):\n%s", cmp.Diff(want, f.Header))
+	}
+
+	columns, err := f.Columns([]string{"description", "2"})
+	if err != nil {
+		t.Fatalf("Columns(): %v", err)
+	}
+	if want := []int{1, 2}; !cmp.Equal(want, columns) {
+		t.Fatalf("Columns() should return %v; got %v", want, columns)
+	}
+
+	if _, err := f.Columns([]string{"name"}); err == nil {
+		t.Fatalf("Columns() should fail for unknown columns")
+	}
+
+	merged, err := csv.Merge(f, nil, columns)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	want := []string{"Chair", "A chair, made of wood.", "Table", "The \"classic\" table\nfor {count} people.", "Lamp"}
+	var got []string
+	for _, e := range merged.Untranslated() {
+		got = append(got, e.Text)
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if got := string(merged.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := csv.Parse([]byte(source), ',')
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := csv.Merge(f, nil, []int{1, 2})
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	translations := []string{"Stuhl", "Ein Stuhl aus Holz.", "Tisch", "Der \"klassische\" Tisch für {count} Personen.", "Lampe"}
+	for i, e := range merged.Untranslated() {
+		if err := e.SetTranslation(translations[i]); err != nil {
+			t.Fatalf("SetTranslation(%q): %v", translations[i], err)
+		}
+	}
+
+	want := heredoc.Doc(`
+		id,title,description,price
+		1,Stuhl,"Ein Stuhl aus Holz.",10
+		2,"Tisch","Der ""klassische"" Tisch für {count} Personen.",20
+		3,Lampe,,5
+	`)
+
+	if got := string(merged.Bytes()); got != want {
+		t.Fatalf("Bytes() returned\n\n%s\n\nwant\n\n%s", got, want)
+	}
+
+	if err := merged.Entries[3].SetTranslation("Der Tisch für viele Personen."); err == nil {
+		t.Fatalf("SetTranslation() should fail if a placeholder is missing")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	src, err := csv.Parse([]byte(heredoc.Doc(`
+		key	text	note
+		greeting	Hello	informal
+		farewell	Goodbye
+		thanks	Thank you	polite
+		welcome	Welcome, %s!
+	`)), '\t')
+	if err != nil {
+		t.Fatalf("Parse(source): %v", err)
+	}
+
+	target, err := csv.Parse([]byte(heredoc.Doc(`
+		key	text	note
+		greeting	Hallo	informell
+		farewell
+		thanks	Danke
+	`)), '\t')
+	if err != nil {
+		t.Fatalf("Parse(target): %v", err)
+	}
+
+	merged, err := csv.Merge(src, target, []int{1, 2})
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	translations := map[string]string{
+		"Goodbye":      "Auf Wiedersehen",
+		"polite":       "höflich",
+		"Welcome, %s!": "Willkommen, %s!",
+	}
+
+	var pending []string
+	for _, e := range merged.Untranslated() {
+		pending = append(pending, e.Column+": "+e.Text)
+		if err := e.SetTranslation(translations[e.Text]); err != nil {
+			t.Fatalf("SetTranslation(): %v", err)
+		}
+	}
+
+	if want := []string{"text: Goodbye", "note: polite", "text: Welcome, %s!"}; !cmp.Equal(want, pending) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(want, pending))
+	}
+
+	want := heredoc.Doc(`
+		key	text	note
+		greeting	Hallo	informell
+		farewell	Auf Wiedersehen
+		thanks	Danke	höflich
+		welcome	Willkommen, %s!
+	`)
+
+	if got := string(merged.Bytes()); got != want {
+		t.Fatalf("Bytes() returned\n\n%q\n\nwant\n\n%q", got, want)
+	}
+}
@@ -0,0 +1,385 @@
+// Package gotemplate extracts the translatable text of Go templates
+// (text/template and html/template) and of string literals in Go source files
+// that are passed to translation functions like i18n.T.
+package gotemplate
+
+import (
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// verbatim matches the parts of a template that are never translated and
+	// separate its texts: scripts, styles and comments, block-level HTML tags
+	// and blank lines.
+	verbatim = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<!--.*?-->|</?(address|article|aside|blockquote|body|br|button|dd|div|dl|dt|fieldset|figcaption|figure|footer|form|h[1-6]|head|header|hr|html|label|li|link|main|meta|nav|ol|option|p|section|select|table|tbody|td|textarea|tfoot|th|thead|title|tr|ul)\b[^>]*>|\n[ \t]*\r?\n`)
+
+	// tag matches HTML tags and entities, which do not count as text.
+	tag = regexp.MustCompile(`<[^>]*>|&#?\w+;`)
+
+	// action matches the actions of a template.
+	action = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+	// verb matches the verbs of format strings (e.g. "%s" or "%[1]d").
+	verb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[vTtbcdoOqxXUeEfFgGsp%]`)
+
+	// control matches actions that change the control flow of a template or
+	// define templates. Texts are never translated across these actions.
+	control = regexp.MustCompile(`^\{\{-?\s*(/\*|(if|else|end|range|with|define|block|template|break|continue)\b)`)
+)
+
+// File is a parsed Go template or Go source file. It keeps the original bytes
+// of the file, so that writing it back only replaces the texts that were set
+// using [Entry.SetTranslation].
+type File struct {
+	// Entries are the translatable texts of the file in the order of the file.
+	Entries []*Entry
+
+	data []byte
+}
+
+// Entry is a translatable text of a [File].
+type Entry struct {
+	// Text is the text of the entry. Texts of templates keep the actions that
+	// they contain (e.g. "Hello, {{.Name}}!"); texts of Go source files are
+	// the unquoted string literals.
+	Text string
+
+	// Line is the line number of the text in the file.
+	Line int
+
+	start, end int
+	literal    bool
+	raw        bool
+	translated bool
+}
+
+// Parse parses a Go template. Actions that print values (e.g. "{{.Name}}")
+// stay part of the text around them, so that the text can be translated as a
+// whole; actions that change the control flow (e.g. "{{if .LoggedIn}}") and
+// comments separate texts. Texts are also separated by blank lines and
+// block-level HTML tags, and the contents of scripts, styles and HTML comments
+// are never translated. Leading and trailing whitespace is not part of the
+// texts, and texts without letters are skipped.
+func Parse(data []byte) (*File, error) {
+	type span struct{ start, end int }
+
+	var (
+		regions = verbatim.FindAllIndex(data, -1)
+		f       = File{data: data}
+		run     []span
+		pos     int
+		next    int
+	)
+
+	flush := func() {
+		if len(run) > 0 {
+			f.add(run[0].start, run[len(run)-1].end)
+		}
+		run = run[:0]
+	}
+
+	for pos < len(data) {
+		start := bytes.Index(data[pos:], []byte("{{"))
+		if start < 0 {
+			start = len(data)
+		} else {
+			start += pos
+		}
+
+		// Split the text before the action at the verbatim regions.
+		for pos < start {
+			for next < len(regions) && regions[next][1] <= pos {
+				next++
+			}
+			if next < len(regions) && regions[next][0] <= pos {
+				flush()
+				pos = regions[next][1]
+				continue
+			}
+
+			end := start
+			if next < len(regions) && regions[next][0] < end {
+				end = regions[next][0]
+			}
+			run = append(run, span{start: pos, end: end})
+			pos = end
+		}
+
+		if start >= len(data) {
+			break
+		}
+
+		end, err := actionEnd(data, start)
+		if err != nil {
+			return nil, err
+		}
+		pos = end
+
+		if control.Match(data[start:end]) || inRegion(regions, start) {
+			flush()
+			continue
+		}
+		run = append(run, span{start: start, end: end})
+	}
+	flush()
+
+	return &f, nil
+}
+
+// add adds the text between start and end as an entry if it contains letters
+// outside of actions and HTML tags.
+func (f *File) add(start, end int) {
+	for start < end && isSpace(f.data[start]) {
+		start++
+	}
+	for end > start && isSpace(f.data[end-1]) {
+		end--
+	}
+
+	text := string(f.data[start:end])
+	if !hasLetters(tag.ReplaceAllString(action.ReplaceAllString(text, "\x00"), "")) {
+		return
+	}
+
+	f.Entries = append(f.Entries, &Entry{
+		Text:  text,
+		Line:  lineNumber(f.data, start),
+		start: start,
+		end:   end,
+	})
+}
+
+// ParseSource parses a Go source file and extracts the string literals that are
+// passed as the first argument to one of the given functions, like
+// `i18n.T("Hello, world!")`. Functions are named like they are called, with
+// their package or receiver name if any (e.g. "i18n.T" or "T"). Literals that
+// are part of a larger expression are not extracted.
+func ParseSource(data []byte, funcs []string) (*File, error) {
+	names := make(map[string]bool, len(funcs))
+	for _, name := range funcs {
+		names[name] = true
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(data))
+
+	var (
+		errs    scanner.ErrorList
+		s       scanner.Scanner
+		f       = File{data: data}
+		name    string
+		call    bool
+		pending *Entry
+	)
+	s.Init(file, data, func(pos token.Position, msg string) {
+		errs.Add(pos, msg)
+	}, 0)
+
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		if pending != nil && (tok == token.COMMA || tok == token.RPAREN) {
+			f.Entries = append(f.Entries, pending)
+		}
+		pending = nil
+
+		switch tok {
+		case token.IDENT:
+			if strings.HasSuffix(name, ".") {
+				name += lit
+			} else {
+				name = lit
+			}
+			call = false
+			continue
+		case token.PERIOD:
+			if name != "" {
+				name += "."
+			}
+			call = false
+			continue
+		case token.LPAREN:
+			call = calls(names, name)
+		case token.STRING:
+			if call {
+				entry, err := literalEntry(data, file.Offset(pos), lit)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", file.Line(pos), err)
+				}
+				entry.Line = file.Line(pos)
+				pending = entry
+			}
+			call = false
+		default:
+			call = false
+		}
+		name = ""
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// calls reports whether a call of the named function extracts its string
+// literal. Names also match calls on values of other packages or fields, like
+// "ctx.i18n.T" for "i18n.T".
+func calls(names map[string]bool, name string) bool {
+	for n := name; n != ""; {
+		if names[n] {
+			return true
+		}
+		_, n, _ = strings.Cut(n, ".")
+	}
+	return false
+}
+
+func literalEntry(data []byte, start int, lit string) (*Entry, error) {
+	text, err := strconv.Unquote(lit)
+	if err != nil {
+		return nil, fmt.Errorf("unquote %s: %w", lit, err)
+	}
+
+	end := start + len(lit)
+	raw := strings.HasPrefix(lit, "`")
+	if raw {
+		// Carriage returns are removed from the literals of raw strings, so
+		// the end of the literal has to be looked up in the source.
+		end = start + 1 + bytes.IndexByte(data[start+1:], '`') + 1
+	}
+
+	return &Entry{
+		Text:    text,
+		start:   start,
+		end:     end,
+		literal: true,
+		raw:     raw,
+	}, nil
+}
+
+// Bytes returns the file with the translations of all translated entries.
+// Translated string literals are quoted again; raw string literals stay raw
+// strings unless the translation contains a backquote.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(e.quoted())
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+func (e *Entry) quoted() string {
+	if !e.literal {
+		return e.Text
+	}
+	if e.raw && !strings.ContainsAny(e.Text, "`\r") {
+		return "`" + e.Text + "`"
+	}
+	return strconv.Quote(e.Text)
+}
+
+// SetTranslation sets the translation of the entry. It returns an error if
+// the translation does not keep the actions (e.g. "{{.Name}}") of the
+// original text, or, for string literals, its format verbs (e.g. "%s").
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text, e.literal), placeholders(text, e.literal)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Text, want, got)
+	}
+
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// placeholders returns the sorted actions of a text, and also its format verbs
+// if the text is a string literal.
+func placeholders(text string, literal bool) []string {
+	out := action.FindAllString(text, -1)
+	if literal {
+		out = append(out, verb.FindAllString(action.ReplaceAllString(text, ""), -1)...)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// actionEnd returns the end of the action that starts at pos. Delimiters in
+// strings and comments do not end the action.
+func actionEnd(data []byte, pos int) (int, error) {
+	i := pos + 2
+	if j := bytes.Index(data[i:], []byte("/*")); j >= 0 && len(bytes.TrimLeft(data[i:i+j], "- ")) == 0 {
+		k := bytes.Index(data[i+j:], []byte("*/"))
+		if k < 0 {
+			return 0, fmt.Errorf("line %d: unterminated comment", lineNumber(data, pos))
+		}
+		i += j + k + 2
+	}
+
+	for i < len(data) {
+		switch c := data[i]; c {
+		case '"', '\'', '`':
+			end := i + 1
+			for end < len(data) && data[end] != c {
+				if data[end] == '\\' && c != '`' {
+					end++
+				}
+				end++
+			}
+			if end >= len(data) {
+				return 0, fmt.Errorf("line %d: unterminated quoted string in action", lineNumber(data, pos))
+			}
+			i = end + 1
+		case '}':
+			if i+1 < len(data) && data[i+1] == '}' {
+				return i + 2, nil
+			}
+			i++
+		default:
+			i++
+		}
+	}
+
+	return 0, fmt.Errorf("line %d: unclosed action", lineNumber(data, pos))
+}
+
+func inRegion(regions [][]int, pos int) bool {
+	for _, r := range regions {
+		if r[0] <= pos && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLetters(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func lineNumber(data []byte, pos int) int {
+	return bytes.Count(data[:pos], []byte("\n")) + 1
+}
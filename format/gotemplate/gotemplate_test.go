@@ -0,0 +1,155 @@
+package gotemplate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/gotemplate"
+)
+
+var template = heredoc.Doc(`
+	{{define "content"}}
+	<div class="{{.Class}}">
+	  <h1>Welcome, {{.User.Name}}!</h1>
+	  {{/* Shown to guests only. */}}
+	  {{if not .LoggedIn}}
+	  <p>Please <a href="{{.LoginURL}}">log in</a> to continue.</p>
+	  {{else}}
+	  <p>You have {{len .Messages}} new messages.</p>
+	  {{end}}
+	  <script>var label = "{{.Label}}";</script>
+	  <!-- Do not translate this comment. -->
+	</div>
+
+	Thanks for visiting!
+	{{end}}
+`)
+
+func TestParse(t *testing.T) {
+	f, err := gotemplate.Parse([]byte(template))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != template {
+		t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+	}
+
+	want := []string{
+		"Welcome, {{.User.Name}}!",
+		`Please <a href="{{.LoginURL}}">log in</a> to continue.`,
+		"You have {{len .Messages}} new messages.",
+		"Thanks for visiting!",
+	}
+
+	if got := texts(f); !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if f.Entries[0].Line != 3 {
+		t.Fatalf("Line of the first entry should be %d; got %d", 3, f.Entries[0].Line)
+	}
+}
+
+func TestParse_quotedDelimiters(t *testing.T) {
+	f, err := gotemplate.Parse([]byte(`{{if eq .Mode "}}"}}Hello {{printf "%s}}" .Name}}{{end}}`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if want := []string{`Hello {{printf "%s}}" .Name}}`}; !cmp.Equal(want, texts(f)) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, texts(f)))
+	}
+
+	if _, err := gotemplate.Parse([]byte("Hello {{.Name")); err == nil {
+		t.Fatalf("Parse() should fail for an unclosed action")
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := gotemplate.Parse([]byte(template))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if err := f.Entries[0].SetTranslation("Willkommen, {{.User.Name}}!"); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	if err := f.Entries[2].SetTranslation("Du hast {{.Count}} neue Nachrichten."); err == nil {
+		t.Fatalf("SetTranslation() should fail if the actions change")
+	}
+
+	want := strings.Replace(template, "Welcome, {{.User.Name}}!", "Willkommen, {{.User.Name}}!", 1)
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+var source = heredoc.Doc(`
+	package main
+
+	func greet(name string) string {
+		fmt.Println("not translated")
+		title := i18n.T("Welcome")
+		msg := app.i18n.T("Hello, %s!", name)
+		raw := i18n.T(` + "`Multi\nline`" + `)
+		joined := i18n.T("not" + "translated")
+		return T("unknown function")
+	}
+`)
+
+func TestParseSource(t *testing.T) {
+	f, err := gotemplate.ParseSource([]byte(source), []string{"i18n.T"})
+	if err != nil {
+		t.Fatalf("ParseSource(): %v", err)
+	}
+
+	want := []string{"Welcome", "Hello, %s!", "Multi\nline"}
+	if got := texts(f); !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	for i, translation := range []string{`"Willkommen"`, "Hallo, %s!", "Mehrere\nZeilen"} {
+		if err := f.Entries[i].SetTranslation(translation); err != nil {
+			t.Fatalf("SetTranslation(%q): %v", translation, err)
+		}
+	}
+
+	want = []string{
+		`title := i18n.T("\"Willkommen\"")`,
+		`msg := app.i18n.T("Hallo, %s!", name)`,
+		"raw := i18n.T(`Mehrere\nZeilen`)",
+	}
+	got := string(f.Bytes())
+	for _, line := range want {
+		if !strings.Contains(got, line) {
+			t.Fatalf("Bytes() should contain %q; got\n\n%s", line, got)
+		}
+	}
+}
+
+func TestParseSource_formatVerbs(t *testing.T) {
+	f, err := gotemplate.ParseSource([]byte(`package main; var s = T("%d of %s")`), []string{"T"})
+	if err != nil {
+		t.Fatalf("ParseSource(): %v", err)
+	}
+
+	if err := f.Entries[0].SetTranslation("%s von %d"); err != nil {
+		t.Fatalf("SetTranslation() should allow reordered verbs; got %v", err)
+	}
+
+	if err := f.Entries[0].SetTranslation("%d von %v"); err == nil {
+		t.Fatalf("SetTranslation() should fail if the verbs change")
+	}
+}
+
+func texts(f *gotemplate.File) []string {
+	var out []string
+	for _, e := range f.Entries {
+		out = append(out, e.Text)
+	}
+	return out
+}
@@ -0,0 +1,445 @@
+// Package icu parses ICU MessageFormat messages, like
+// "{count, plural, one {# item} other {# items}}", into their syntax skeleton
+// and the literal texts of their plural and select branches, so that only the
+// texts are translated.
+package icu
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Message is a parsed ICU MessageFormat message.
+type Message []Node
+
+// Node is a part of a [Message]: either text or a plural, selectordinal or
+// select argument.
+type Node struct {
+	// Text is the raw text of the node, including apostrophe quoting and
+	// simple arguments like "{name}" or "{count, number}". It is empty if the
+	// node is an argument.
+	Text string
+
+	// Arg is the plural, selectordinal or select argument of the node, or nil
+	// if the node is text.
+	Arg *Argument
+}
+
+// Argument is a plural, selectordinal or select argument of a [Message].
+type Argument struct {
+	// Name is the name of the argument, e.g. "count".
+	Name string
+
+	// Type is "plural", "selectordinal" or "select".
+	Type string
+
+	// Offset is the offset of a plural argument, e.g. "offset:1", or an
+	// empty string.
+	Offset string
+
+	// Branches are the branches of the argument in the order of the message.
+	Branches []Branch
+}
+
+// Branch is a branch of an [Argument].
+type Branch struct {
+	// Selector is the selector of the branch, e.g. "one", "=0" or "female".
+	Selector string
+
+	// Message is the message of the branch.
+	Message Message
+}
+
+// Parse parses an ICU MessageFormat message.
+func Parse(text string) (Message, error) {
+	p := parser{text: text}
+	msg, err := p.message(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.text) {
+		return nil, p.errorf("unexpected %q", p.text[p.pos])
+	}
+	return msg, nil
+}
+
+// IsComplex reports whether the message has plural, selectordinal or select
+// arguments.
+func (m Message) IsComplex() bool {
+	for _, n := range m {
+		if n.Arg != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the message in ICU MessageFormat syntax.
+func (m Message) String() string {
+	var b strings.Builder
+	for _, n := range m {
+		if n.Arg == nil {
+			b.WriteString(n.Text)
+			continue
+		}
+
+		b.WriteString("{" + n.Arg.Name + ", " + n.Arg.Type + ",")
+		if n.Arg.Offset != "" {
+			b.WriteString(" " + n.Arg.Offset)
+		}
+		for _, branch := range n.Arg.Branches {
+			b.WriteString(" " + branch.Selector + " {" + branch.Message.String() + "}")
+		}
+		b.WriteString("}")
+	}
+	return b.String()
+}
+
+// Segments returns the translatable texts of the message by their keys. The
+// key of a text in a branch is the name of the argument and the selector of
+// the branch, like "count:one", joined by "/" for nested arguments. If a
+// message has several texts beside its arguments, their keys end with "#"
+// and the 1-based number of the text, like "#1" for the first text of the
+// message itself. Texts without letters are not translated.
+func (m Message) Segments() map[string]string {
+	out := make(map[string]string)
+	m.walk("", func(key string, n *Node) {
+		out[key] = n.Text
+	})
+	return out
+}
+
+// SetSegments returns a copy of the message whose texts are replaced with
+// the given texts, keyed like the texts of [Message.Segments]. It returns an
+// error if a text is missing.
+func (m Message) SetSegments(texts map[string]string) (Message, error) {
+	out := m.clone()
+
+	var missing []string
+	out.walk("", func(key string, n *Node) {
+		text, ok := texts[key]
+		if !ok {
+			missing = append(missing, key)
+			return
+		}
+		n.Text = text
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing texts %s", strings.Join(missing, ", "))
+	}
+
+	return out, nil
+}
+
+// walk calls fn for the translatable text nodes of the message and of its
+// branches.
+func (m Message) walk(prefix string, fn func(key string, n *Node)) {
+	var texts []int
+	for i, n := range m {
+		if n.Arg == nil && strings.IndexFunc(n.Text, unicode.IsLetter) >= 0 {
+			texts = append(texts, i)
+		}
+	}
+
+	for i, idx := range texts {
+		key := prefix
+		if len(texts) > 1 || prefix == "" {
+			key += "#" + strconv.Itoa(i+1)
+		}
+		fn(key, &m[idx])
+	}
+
+	for _, n := range m {
+		if n.Arg == nil {
+			continue
+		}
+		for _, branch := range n.Arg.Branches {
+			key := n.Arg.Name + ":" + branch.Selector
+			if prefix != "" {
+				key = prefix + "/" + key
+			}
+			branch.Message.walk(key, fn)
+		}
+	}
+}
+
+func (m Message) clone() Message {
+	out := make(Message, len(m))
+	for i, n := range m {
+		out[i] = n
+		if n.Arg == nil {
+			continue
+		}
+		arg := *n.Arg
+		arg.Branches = make([]Branch, len(n.Arg.Branches))
+		for j, branch := range n.Arg.Branches {
+			arg.Branches[j] = Branch{Selector: branch.Selector, Message: branch.Message.clone()}
+		}
+		out[i].Arg = &arg
+	}
+	return out
+}
+
+// Expand adds the plural categories of the given language that are missing in
+// the plural and selectordinal arguments of the message, so that the
+// translation can use every grammatical form of the language. The language is
+// an ISO 639-1 code or an English language name, like "pl" or "Polish". New
+// branches are copies of the "other" branch and are inserted before it. It
+// reports whether branches were added; messages of unknown languages are not
+// changed.
+func (m Message) Expand(language string) bool {
+	lang, ok := languageCode(language)
+	if !ok {
+		return false
+	}
+
+	added := false
+	for _, n := range m {
+		if n.Arg == nil {
+			continue
+		}
+
+		for _, branch := range n.Arg.Branches {
+			if branch.Message.Expand(language) {
+				added = true
+			}
+		}
+
+		var categories []string
+		switch n.Arg.Type {
+		case "plural":
+			categories = cardinal[lang]
+		case "selectordinal":
+			categories = ordinal[lang]
+		default:
+			continue
+		}
+
+		other := -1
+		existing := make(map[string]bool)
+		for i, branch := range n.Arg.Branches {
+			existing[branch.Selector] = true
+			if branch.Selector == "other" {
+				other = i
+			}
+		}
+		if other < 0 {
+			continue
+		}
+
+		var missing []Branch
+		for _, category := range categories {
+			if !existing[category] {
+				missing = append(missing, Branch{Selector: category, Message: n.Arg.Branches[other].Message.clone()})
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		branches := append([]Branch(nil), n.Arg.Branches[:other]...)
+		branches = append(branches, missing...)
+		n.Arg.Branches = append(branches, n.Arg.Branches[other:]...)
+		added = true
+	}
+
+	return added
+}
+
+// errInvalid is the cause of parse errors.
+var errInvalid = errors.New("invalid message")
+
+type parser struct {
+	text string
+	pos  int
+}
+
+// message parses a message up to the end of the text or, if nested is true,
+// up to the closing brace of the branch.
+func (p *parser) message(nested bool) (Message, error) {
+	var msg Message
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			msg = append(msg, Node{Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.text) {
+		switch c := p.text[p.pos]; c {
+		case '}':
+			if !nested {
+				return nil, p.errorf("unexpected '}'")
+			}
+			flush()
+			return msg, nil
+		case '\'':
+			text.WriteString(p.quoted())
+		case '{':
+			start := p.pos
+			arg, err := p.argument()
+			if err != nil {
+				return nil, err
+			}
+			if arg == nil {
+				text.WriteString(p.text[start:p.pos])
+				continue
+			}
+			flush()
+			msg = append(msg, Node{Arg: arg})
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	if nested {
+		return nil, p.errorf("unterminated branch")
+	}
+	flush()
+	return msg, nil
+}
+
+// quoted returns the raw text of the apostrophe at the current position: a
+// doubled apostrophe, a quoted literal like "'{'", or a single apostrophe.
+func (p *parser) quoted() string {
+	start := p.pos
+	p.pos++
+	if p.pos >= len(p.text) {
+		return "'"
+	}
+
+	switch p.text[p.pos] {
+	case '\'':
+		p.pos++
+	case '{', '}', '#', '|':
+		end := strings.IndexByte(p.text[p.pos:], '\'')
+		if end < 0 {
+			p.pos = len(p.text)
+		} else {
+			p.pos += end + 1
+		}
+	}
+
+	return p.text[start:p.pos]
+}
+
+// argument parses the argument at the current position. It returns nil for
+// simple arguments, like "{name}" or "{count, number}", which are part of the
+// text.
+func (p *parser) argument() (*Argument, error) {
+	p.pos++
+	name := p.word()
+	if name == "" {
+		return nil, p.errorf("missing argument name")
+	}
+
+	p.space()
+	if p.consume('}') {
+		return nil, nil
+	}
+	if !p.consume(',') {
+		return nil, p.errorf("expected ',' or '}' after argument %q", name)
+	}
+
+	p.space()
+	typ := p.word()
+	p.space()
+
+	switch typ {
+	case "plural", "selectordinal", "select":
+	default:
+		// The style of simple arguments is skipped up to the closing brace
+		// of the argument.
+		depth := 1
+		for p.pos < len(p.text) {
+			switch p.text[p.pos] {
+			case '\'':
+				p.quoted()
+				continue
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			p.pos++
+			if depth == 0 {
+				return nil, nil
+			}
+		}
+		return nil, p.errorf("unterminated argument %q", name)
+	}
+
+	if !p.consume(',') {
+		return nil, p.errorf("expected ',' after %s argument %q", typ, name)
+	}
+
+	arg := &Argument{Name: name, Type: typ}
+	for {
+		p.space()
+		if p.consume('}') {
+			break
+		}
+
+		selector := p.word()
+		if selector == "" {
+			return nil, p.errorf("missing selector of argument %q", name)
+		}
+
+		if typ != "select" && strings.HasPrefix(selector, "offset:") {
+			arg.Offset = selector
+			continue
+		}
+
+		p.space()
+		if !p.consume('{') {
+			return nil, p.errorf("expected '{' after selector %q", selector)
+		}
+
+		msg, err := p.message(true)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+
+		arg.Branches = append(arg.Branches, Branch{Selector: selector, Message: msg})
+	}
+
+	if len(arg.Branches) == 0 {
+		return nil, p.errorf("argument %q has no branches", name)
+	}
+
+	return arg, nil
+}
+
+// word returns the characters up to the next whitespace, comma or brace.
+func (p *parser) word() string {
+	start := p.pos
+	for p.pos < len(p.text) && !strings.ContainsRune(",{} \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+	return p.text[start:p.pos]
+}
+
+func (p *parser) space() {
+	for p.pos < len(p.text) && strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.pos < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", errInvalid, p.pos, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,238 @@
+package icu_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/icu"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		text     string
+		complex  bool
+		segments map[string]string
+	}{
+		"text": {
+			text:     "Hello, {name}!",
+			segments: map[string]string{"#1": "Hello, {name}!"},
+		},
+		"plural": {
+			text:    "{count, plural, =0 {No items} one {# item} other {# items}}",
+			complex: true,
+			segments: map[string]string{
+				"count:=0":    "No items",
+				"count:one":   "# item",
+				"count:other": "# items",
+			},
+		},
+		"text around argument": {
+			text:    "You have {count, plural, offset:1 one {# new message} other {# new messages}} from {sender, number}.",
+			complex: true,
+			segments: map[string]string{
+				"#1":          "You have ",
+				"#2":          " from {sender, number}.",
+				"count:one":   "# new message",
+				"count:other": "# new messages",
+			},
+		},
+		"nested select": {
+			text:    "{gender, select, female {{count, plural, one {She has one cat} other {She has # cats}}} other {{count, plural, one {They have one cat} other {They have # cats}}}}",
+			complex: true,
+			segments: map[string]string{
+				"gender:female/count:one":   "She has one cat",
+				"gender:female/count:other": "She has # cats",
+				"gender:other/count:one":    "They have one cat",
+				"gender:other/count:other":  "They have # cats",
+			},
+		},
+		"quoted braces": {
+			text:     "Use '{name}' for the {kind, select, user {user's name} other {name}}",
+			complex:  true,
+			segments: map[string]string{"#1": "Use '{name}' for the ", "kind:user": "user's name", "kind:other": "name"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg, err := icu.Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			if msg.IsComplex() != tt.complex {
+				t.Fatalf("IsComplex() should return %v", tt.complex)
+			}
+
+			if got := msg.String(); got != tt.text {
+				t.Fatalf("String() should return %q; got %q", tt.text, got)
+			}
+
+			if got := msg.Segments(); !cmp.Equal(tt.segments, got) {
+				t.Fatalf("Segments() mismatch (-want +got):\n%s", cmp.Diff(tt.segments, got))
+			}
+		})
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	tests := map[string]string{
+		"unclosed argument":   "{count, plural, one {# item}",
+		"unclosed branch":     "{count, plural, one {# item",
+		"missing selector":    "{count, plural, {# items}}",
+		"missing branches":    "{count, plural,}",
+		"unexpected brace":    "Hello}",
+		"missing branch text": "{count, plural, one other {# items}}",
+	}
+
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := icu.Parse(text); err == nil {
+				t.Fatalf("Parse(%q) should fail", text)
+			}
+		})
+	}
+}
+
+func TestMessage_SetSegments(t *testing.T) {
+	msg, err := icu.Parse("{count, plural, one {# item} other {# items}} left")
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	got, err := msg.SetSegments(map[string]string{"#1": " übrig", "count:one": "# Artikel", "count:other": "# Artikel"})
+	if err != nil {
+		t.Fatalf("SetSegments(): %v", err)
+	}
+
+	if want := "{count, plural, one {# Artikel} other {# Artikel}} übrig"; got.String() != want {
+		t.Fatalf("SetSegments() should return %q; got %q", want, got.String())
+	}
+
+	if want := "{count, plural, one {# item} other {# items}} left"; msg.String() != want {
+		t.Fatalf("SetSegments() should not change the message; got %q", msg.String())
+	}
+
+	if _, err := msg.SetSegments(map[string]string{"count:one": "# Artikel"}); err == nil {
+		t.Fatalf("SetSegments() should fail for missing texts")
+	}
+}
+
+func TestMessage_Expand(t *testing.T) {
+	tests := map[string]struct {
+		language string
+		want     string
+	}{
+		"code": {
+			language: "pl",
+			want:     "{count, plural, =0 {none} one {# file} few {# files} many {# files} other {# files}}",
+		},
+		"name": {
+			language: "Russian",
+			want:     "{count, plural, =0 {none} one {# file} few {# files} many {# files} other {# files}}",
+		},
+		"region": {
+			language: "pt-BR",
+			want:     "{count, plural, =0 {none} one {# file} many {# files} other {# files}}",
+		},
+		"fewer categories": {
+			language: "Japanese",
+			want:     "{count, plural, =0 {none} one {# file} other {# files}}",
+		},
+		"unknown language": {
+			language: "Klingon",
+			want:     "{count, plural, =0 {none} one {# file} other {# files}}",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg, err := icu.Parse("{count, plural, =0 {none} one {# file} other {# files}}")
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			added := msg.Expand(tt.language)
+			if got := msg.String(); got != tt.want {
+				t.Fatalf("Expand(%q) should result in %q; got %q", tt.language, tt.want, got)
+			}
+
+			if wantAdded := tt.want != "{count, plural, =0 {none} one {# file} other {# files}}"; added != wantAdded {
+				t.Fatalf("Expand(%q) should return %v", tt.language, wantAdded)
+			}
+		})
+	}
+}
+
+func TestMessage_Expand_selectordinal(t *testing.T) {
+	msg, err := icu.Parse("{place, selectordinal, one {#st} other {#th}} place")
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	msg.Expand("English")
+
+	if want := "{place, selectordinal, one {#st} two {#th} few {#th} other {#th}} place"; msg.String() != want {
+		t.Fatalf("Expand() should result in %q; got %q", want, msg.String())
+	}
+}
+
+func TestPluralCategories(t *testing.T) {
+	tests := []struct {
+		language string
+		ordinals bool
+		want     []string
+	}{
+		{language: "ru", want: []string{"one", "few", "many", "other"}},
+		{language: "Brazilian Portuguese", want: []string{"one", "many", "other"}},
+		{language: "ja", want: []string{"other"}},
+		{language: "English", ordinals: true, want: []string{"one", "two", "few", "other"}},
+		{language: "de", ordinals: true, want: []string{"other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			got, ok := icu.PluralCategories(tt.language, tt.ordinals)
+			if !ok {
+				t.Fatalf("PluralCategories(%q) should know the language", tt.language)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected categories (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, ok := icu.PluralCategories("Klingon", false); ok {
+		t.Fatalf("PluralCategories() should not know Klingon")
+	}
+}
+
+func TestGettextCategories(t *testing.T) {
+	tests := []struct {
+		language string
+		nplurals int
+		want     []string
+	}{
+		{language: "Russian", nplurals: 3, want: []string{"one", "few", "many"}},
+		{language: "cs", nplurals: 3, want: []string{"one", "few", "other"}},
+		{language: "fr", nplurals: 2, want: []string{"one", "other"}},
+		{language: "ar", nplurals: 6, want: []string{"zero", "one", "two", "few", "many", "other"}},
+		{language: "ja", nplurals: 1, want: []string{"other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			got, ok := icu.GettextCategories(tt.language, tt.nplurals)
+			if !ok {
+				t.Fatalf("GettextCategories(%q, %d) should know the plural forms", tt.language, tt.nplurals)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected categories (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, ok := icu.GettextCategories("cy", 4); ok {
+		t.Fatalf("GettextCategories() should not guess the plural forms of a mismatching catalog")
+	}
+}
@@ -0,0 +1,235 @@
+package icu
+
+import "strings"
+
+// cardinal are the CLDR plural categories of cardinal numbers by language.
+var cardinal = map[string][]string{
+	"af": {"one", "other"},
+	"ar": {"zero", "one", "two", "few", "many", "other"},
+	"az": {"one", "other"},
+	"be": {"one", "few", "many", "other"},
+	"bg": {"one", "other"},
+	"bn": {"one", "other"},
+	"bs": {"one", "few", "other"},
+	"ca": {"one", "many", "other"},
+	"cs": {"one", "few", "many", "other"},
+	"cy": {"zero", "one", "two", "few", "many", "other"},
+	"da": {"one", "other"},
+	"de": {"one", "other"},
+	"el": {"one", "other"},
+	"en": {"one", "other"},
+	"es": {"one", "many", "other"},
+	"et": {"one", "other"},
+	"eu": {"one", "other"},
+	"fa": {"one", "other"},
+	"fi": {"one", "other"},
+	"fr": {"one", "many", "other"},
+	"ga": {"one", "two", "few", "many", "other"},
+	"gl": {"one", "other"},
+	"he": {"one", "two", "other"},
+	"hi": {"one", "other"},
+	"hr": {"one", "few", "other"},
+	"hu": {"one", "other"},
+	"id": {"other"},
+	"is": {"one", "other"},
+	"it": {"one", "many", "other"},
+	"ja": {"other"},
+	"ka": {"one", "other"},
+	"kk": {"one", "other"},
+	"km": {"other"},
+	"ko": {"other"},
+	"lo": {"other"},
+	"lt": {"one", "few", "many", "other"},
+	"lv": {"zero", "one", "other"},
+	"mk": {"one", "other"},
+	"ms": {"other"},
+	"mt": {"one", "two", "few", "many", "other"},
+	"my": {"other"},
+	"nb": {"one", "other"},
+	"nl": {"one", "other"},
+	"no": {"one", "other"},
+	"pl": {"one", "few", "many", "other"},
+	"pt": {"one", "many", "other"},
+	"ro": {"one", "few", "other"},
+	"ru": {"one", "few", "many", "other"},
+	"sk": {"one", "few", "many", "other"},
+	"sl": {"one", "two", "few", "other"},
+	"sq": {"one", "other"},
+	"sr": {"one", "few", "other"},
+	"sv": {"one", "other"},
+	"sw": {"one", "other"},
+	"ta": {"one", "other"},
+	"th": {"other"},
+	"tr": {"one", "other"},
+	"uk": {"one", "few", "many", "other"},
+	"ur": {"one", "other"},
+	"uz": {"one", "other"},
+	"vi": {"other"},
+	"zh": {"other"},
+}
+
+// ordinal are the CLDR plural categories of ordinal numbers by language.
+// Languages that are not listed only use "other".
+var ordinal = map[string][]string{
+	"ca": {"one", "two", "few", "other"},
+	"cy": {"zero", "one", "two", "few", "many", "other"},
+	"en": {"one", "two", "few", "other"},
+	"fr": {"one", "other"},
+	"hu": {"one", "other"},
+	"it": {"many", "other"},
+	"sv": {"one", "other"},
+}
+
+// gettextOmitted are the CLDR plural categories that the plural forms of
+// gettext catalogs commonly lack, because they are only used for fractions,
+// like "other" in Russian, or for compact numbers, like "many" in French.
+var gettextOmitted = map[string]string{
+	"be": "other",
+	"ca": "many",
+	"cs": "many",
+	"es": "many",
+	"fr": "many",
+	"it": "many",
+	"lt": "many",
+	"pl": "other",
+	"pt": "many",
+	"ru": "other",
+	"sk": "many",
+	"uk": "other",
+}
+
+// languages maps English language names to their ISO 639-1 codes.
+var languages = map[string]string{
+	"afrikaans":   "af",
+	"arabic":      "ar",
+	"azerbaijani": "az",
+	"belarusian":  "be",
+	"bulgarian":   "bg",
+	"bengali":     "bn",
+	"bosnian":     "bs",
+	"catalan":     "ca",
+	"czech":       "cs",
+	"welsh":       "cy",
+	"danish":      "da",
+	"german":      "de",
+	"greek":       "el",
+	"english":     "en",
+	"spanish":     "es",
+	"estonian":    "et",
+	"basque":      "eu",
+	"persian":     "fa",
+	"finnish":     "fi",
+	"french":      "fr",
+	"irish":       "ga",
+	"galician":    "gl",
+	"hebrew":      "he",
+	"hindi":       "hi",
+	"croatian":    "hr",
+	"hungarian":   "hu",
+	"indonesian":  "id",
+	"icelandic":   "is",
+	"italian":     "it",
+	"japanese":    "ja",
+	"georgian":    "ka",
+	"kazakh":      "kk",
+	"khmer":       "km",
+	"korean":      "ko",
+	"lao":         "lo",
+	"lithuanian":  "lt",
+	"latvian":     "lv",
+	"macedonian":  "mk",
+	"malay":       "ms",
+	"maltese":     "mt",
+	"burmese":     "my",
+	"dutch":       "nl",
+	"norwegian":   "no",
+	"polish":      "pl",
+	"portuguese":  "pt",
+	"romanian":    "ro",
+	"russian":     "ru",
+	"slovak":      "sk",
+	"slovenian":   "sl",
+	"albanian":    "sq",
+	"serbian":     "sr",
+	"swedish":     "sv",
+	"swahili":     "sw",
+	"tamil":       "ta",
+	"thai":        "th",
+	"turkish":     "tr",
+	"ukrainian":   "uk",
+	"urdu":        "ur",
+	"uzbek":       "uz",
+	"vietnamese":  "vi",
+	"chinese":     "zh",
+}
+
+// languageCode returns the ISO 639-1 code of a language given by its code,
+// like "pt" or "pt-BR", or by its English name, like "Portuguese" or
+// "Brazilian Portuguese".
+func languageCode(language string) (string, bool) {
+	lang := strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if _, ok := cardinal[lang]; ok {
+		return lang, true
+	}
+
+	if code, ok := languages[lang]; ok {
+		return code, true
+	}
+	for _, word := range strings.Fields(lang) {
+		if code, ok := languages[strings.Trim(word, "()")]; ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// PluralCategories returns the CLDR plural categories of cardinal numbers of
+// the given language, or of ordinal numbers if ordinals is true. The language
+// is an ISO 639-1 code or an English language name, like "pl" or "Polish". It
+// reports false for unknown languages.
+func PluralCategories(language string, ordinals bool) ([]string, bool) {
+	lang, ok := languageCode(language)
+	if !ok {
+		return nil, false
+	}
+	if !ordinals {
+		return cardinal[lang], true
+	}
+	if categories, ok := ordinal[lang]; ok {
+		return categories, true
+	}
+	return []string{"other"}, true
+}
+
+// GettextCategories returns the CLDR plural categories of the msgstr[n]
+// translations of a gettext catalog with nplurals plural forms in the given
+// language, in the order of their indices, like "one", "few" and "many" for
+// Russian. It reports false for unknown languages and if the number of forms
+// does not match the categories of the language.
+func GettextCategories(language string, nplurals int) ([]string, bool) {
+	lang, ok := languageCode(language)
+	if !ok {
+		return nil, false
+	}
+
+	categories := cardinal[lang]
+	if len(categories) == nplurals {
+		return categories, true
+	}
+
+	omitted, ok := gettextOmitted[lang]
+	if !ok || len(categories) != nplurals+1 {
+		return nil, false
+	}
+
+	out := make([]string, 0, nplurals)
+	for _, category := range categories {
+		if category != omitted {
+			out = append(out, category)
+		}
+	}
+	return out, true
+}
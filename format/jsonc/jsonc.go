@@ -0,0 +1,460 @@
+// Package jsonc parses and writes the string values of JSON documents with
+// comments (JSONC) and of JSON5 documents, without losing their comments.
+package jsonc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// placeholder matches printf-style format specifiers (e.g. "%s", "%1$d") and
+// brace placeholders (e.g. "{name}", "{{count}}").
+var placeholder = regexp.MustCompile(`\{\{?[^{}\s]+\}\}?|%(\d+\$)?[-#+0]*\d*(\.\d+)?[sdfiuxXoeEgGc@%]`)
+
+// literal matches the literals of JSON5 documents that are not strings, like
+// numbers (including hexadecimal numbers, Infinity and NaN), booleans and null.
+var literal = regexp.MustCompile(`^([-+]?([0-9.]|0[xX]|Infinity|NaN)[0-9a-zA-Z.+-]*|true|false|null)$`)
+
+// File is a parsed JSONC or JSON5 document, i.e. a JSON document that may
+// contain comments, trailing commas, single-quoted strings and unquoted keys.
+// The file keeps its original bytes, so that writing it back only replaces the
+// string values that were set using [Entry.SetTranslation]. Comments, keys
+// and formatting are preserved verbatim.
+type File struct {
+	// Entries are the non-empty string values of the document in the order
+	// of the document.
+	Entries []*Entry
+
+	data []byte
+}
+
+// Entry is a string value of a [File].
+type Entry struct {
+	// Path is the key path of the value. Array elements are identified by
+	// their index.
+	Path []string
+
+	// Text is the unescaped value.
+	Text string
+
+	start, end int
+	quote      byte
+	raw        string
+	translated bool
+}
+
+// Key returns the key path of the entry, joined by dots.
+func (e *Entry) Key() string {
+	return strings.Join(e.Path, ".")
+}
+
+// Parse parses a JSONC or JSON5 document.
+func Parse(data []byte) (*File, error) {
+	p := parser{data: data}
+	if bytes.HasPrefix(data, []byte("\ufeff")) {
+		p.pos = len("\ufeff")
+	}
+
+	if err := p.value(nil); err != nil {
+		return nil, err
+	}
+
+	if err := p.space(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected %q after the document", p.data[p.pos])
+	}
+
+	return &File{Entries: p.entries, data: data}, nil
+}
+
+// Merge sets the translations of the entries of the source to the values of
+// the target at the same key paths. The returned file is the source, so that
+// the layout and the comments of the source are kept; values of the target at
+// key paths that do not exist in the source are dropped. If target is nil,
+// no entry is translated.
+func Merge(source, target *File) *File {
+	if target == nil {
+		return source
+	}
+
+	existing := make(map[string]string, len(target.Entries))
+	for _, e := range target.Entries {
+		existing[strings.Join(e.Path, "\x00")] = e.Text
+	}
+
+	for _, e := range source.Entries {
+		if text, ok := existing[strings.Join(e.Path, "\x00")]; ok {
+			e.set(text)
+		}
+	}
+
+	return source
+}
+
+// Untranslated returns the entries that have not been translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the document with the translations of all translated entries.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(e.raw)
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the entry. The translation is quoted
+// like the original value. It returns an error if the format specifiers (e.g.
+// "%s") or brace placeholders (e.g. "{name}") of the translation differ from
+// those of the original text.
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text), placeholders(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Key(), want, got)
+	}
+
+	e.set(text)
+
+	return nil
+}
+
+func (e *Entry) set(text string) {
+	e.raw = quote(text, e.quote)
+	e.Text = text
+	e.translated = true
+}
+
+// placeholders returns the sorted placeholders of a text.
+func placeholders(text string) []string {
+	out := placeholder.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+// quote returns the text as a string literal with the given quote character.
+// Non-ASCII characters are written as they are.
+func quote(text string, q byte) string {
+	var b strings.Builder
+	b.WriteByte(q)
+	for _, r := range text {
+		switch r {
+		case rune(q), '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\u2028', '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(q)
+	return b.String()
+}
+
+type parser struct {
+	data    []byte
+	pos     int
+	entries []*Entry
+}
+
+func (p *parser) value(path []string) error {
+	if err := p.space(); err != nil {
+		return err
+	}
+	if p.pos >= len(p.data) {
+		return p.errorf("unexpected end of document")
+	}
+
+	switch c := p.data[p.pos]; c {
+	case '{':
+		return p.object(path)
+	case '[':
+		return p.array(path)
+	case '"', '\'':
+		start := p.pos
+		text, err := p.string()
+		if err != nil {
+			return err
+		}
+		if text != "" {
+			p.entries = append(p.entries, &Entry{
+				Path:  append([]string(nil), path...),
+				Text:  text,
+				start: start,
+				end:   p.pos,
+				quote: c,
+			})
+		}
+		return nil
+	default:
+		start := p.pos
+		token := p.token()
+		if !literal.MatchString(token) {
+			p.pos = start
+			return p.errorf("invalid value %q", token)
+		}
+		return nil
+	}
+}
+
+func (p *parser) object(path []string) error {
+	p.pos++
+	for {
+		if err := p.space(); err != nil {
+			return err
+		}
+		if p.consume('}') {
+			return nil
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return err
+		}
+
+		if err := p.space(); err != nil {
+			return err
+		}
+		if !p.consume(':') {
+			return p.errorf("expected ':' after key %q", key)
+		}
+
+		if err := p.value(append(path[:len(path):len(path)], key)); err != nil {
+			return err
+		}
+
+		if err := p.space(); err != nil {
+			return err
+		}
+		if p.consume(',') {
+			continue
+		}
+		if p.consume('}') {
+			return nil
+		}
+		return p.errorf("expected ',' or '}'")
+	}
+}
+
+func (p *parser) array(path []string) error {
+	p.pos++
+	for i := 0; ; i++ {
+		if err := p.space(); err != nil {
+			return err
+		}
+		if p.consume(']') {
+			return nil
+		}
+
+		if err := p.value(append(path[:len(path):len(path)], strconv.Itoa(i))); err != nil {
+			return err
+		}
+
+		if err := p.space(); err != nil {
+			return err
+		}
+		if p.consume(',') {
+			continue
+		}
+		if p.consume(']') {
+			return nil
+		}
+		return p.errorf("expected ',' or ']'")
+	}
+}
+
+// key parses a quoted key or an unquoted JSON5 identifier.
+func (p *parser) key() (string, error) {
+	if p.pos < len(p.data) && (p.data[p.pos] == '"' || p.data[p.pos] == '\'') {
+		return p.string()
+	}
+
+	key := p.token()
+	if key == "" {
+		return "", p.errorf("expected key")
+	}
+	return key, nil
+}
+
+// token returns the characters up to the next delimiter.
+func (p *parser) token() string {
+	start := p.pos
+	for p.pos < len(p.data) && !strings.ContainsRune(",:[]{}\"'/ \t\r\n", rune(p.data[p.pos])) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// string parses the string literal at the current position.
+func (p *parser) string() (string, error) {
+	start := p.pos
+	q := p.data[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == q:
+			p.pos++
+			return b.String(), nil
+		case c == '\n' || c == '\r':
+			p.pos = start
+			return "", p.errorf("unterminated string")
+		case c == '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			b.WriteRune(r)
+			p.pos += size
+		}
+	}
+
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// escape parses the escape sequence at the current position.
+func (p *parser) escape(b *strings.Builder) error {
+	p.pos++
+	if p.pos >= len(p.data) {
+		return p.errorf("unterminated string")
+	}
+
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'v':
+		b.WriteByte('\v')
+	case '0':
+		b.WriteByte(0)
+	case '\r':
+		// Line continuation of JSON5 strings.
+		p.consume('\n')
+	case '\n':
+	case 'x':
+		n, err := p.hex(2)
+		if err != nil {
+			return err
+		}
+		b.WriteRune(rune(n))
+	case 'u':
+		n, err := p.hex(4)
+		if err != nil {
+			return err
+		}
+		r := rune(n)
+		if utf16.IsSurrogate(r) && bytes.HasPrefix(p.data[p.pos:], []byte(`\u`)) {
+			p.pos += 2
+			low, err := p.hex(4)
+			if err != nil {
+				return err
+			}
+			r = utf16.DecodeRune(r, rune(low))
+		}
+		b.WriteRune(r)
+	default:
+		p.pos--
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		b.WriteRune(r)
+		p.pos += size
+	}
+
+	return nil
+}
+
+func (p *parser) hex(digits int) (uint64, error) {
+	if p.pos+digits > len(p.data) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	n, err := strconv.ParseUint(string(p.data[p.pos:p.pos+digits]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	p.pos += digits
+	return n, nil
+}
+
+// space skips whitespace and comments.
+func (p *parser) space() error {
+	for p.pos < len(p.data) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(p.data[p.pos])):
+			p.pos++
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+				return nil
+			}
+			p.pos += end + 1
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := bytes.Count(p.data[:p.pos], []byte("\n")) + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,142 @@
+package jsonc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/jsonc"
+)
+
+var source = heredoc.Doc(`
+	// Texts of the welcome screen
+	{
+		"welcome": {
+			"title": "Welcome, {name}!", // shown after login
+			/* The number of unread messages. */
+			unread: 'You have %d "unread" messages.',
+		},
+		"tags": ["New", "Sale",],
+		"count": 3,
+		"hex": 0xFF,
+		"enabled": true,
+		"empty": "",
+		"escaped": "Tab\tand é",
+	}
+`)
+
+func TestParse(t *testing.T) {
+	f, err := jsonc.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original document; got\n\n%s", got)
+	}
+
+	got := make(map[string]string)
+	for _, e := range f.Entries {
+		got[e.Key()] = e.Text
+	}
+
+	want := map[string]string{
+		"welcome.title":  "Welcome, {name}!",
+		"welcome.unread": `You have %d "unread" messages.`,
+		"tags.0":         "New",
+		"tags.1":         "Sale",
+		"escaped":        "Tab\tand é",
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Parse() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	tests := map[string]string{
+		"unterminated string":  `{"a": "b}`,
+		"unterminated comment": `{"a": "b"} /* comment`,
+		"missing colon":        `{"a" "b"}`,
+		"invalid value":        `{"a": hello}`,
+		"trailing content":     `{"a": "b"} "c"`,
+	}
+
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := jsonc.Parse([]byte(doc)); err == nil {
+				t.Fatalf("Parse() should fail for %s", doc)
+			}
+		})
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := jsonc.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	translations := map[string]string{
+		"welcome.title":  "Willkommen, {name}!",
+		"welcome.unread": "Du hast %d 'ungelesene' Nachrichten.",
+		"tags.0":         "Neu",
+		"tags.1":         "Angebot",
+		"escaped":        "Tab\tund é",
+	}
+	for _, e := range f.Entries {
+		if err := e.SetTranslation(translations[e.Key()]); err != nil {
+			t.Fatalf("SetTranslation(): %v", err)
+		}
+	}
+
+	want := heredoc.Doc(`
+		// Texts of the welcome screen
+		{
+			"welcome": {
+				"title": "Willkommen, {name}!", // shown after login
+				/* The number of unread messages. */
+				unread: 'Du hast %d \'ungelesene\' Nachrichten.',
+			},
+			"tags": ["Neu", "Angebot",],
+			"count": 3,
+			"hex": 0xFF,
+			"enabled": true,
+			"empty": "",
+			"escaped": "Tab\tund é",
+		}
+	`)
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if err := f.Entries[0].SetTranslation("Willkommen!"); err == nil || !strings.Contains(err.Error(), "placeholders") {
+		t.Fatalf("SetTranslation() should reject a translation without the placeholders of the source; got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	src, err := jsonc.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	target, err := jsonc.Parse([]byte(`{"welcome": {"title": "Willkommen, {name}!"}, "tags": ["Neu"], "removed": "Alt"}`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	f := jsonc.Merge(src, target)
+
+	var untranslated []string
+	for _, e := range f.Untranslated() {
+		untranslated = append(untranslated, e.Key())
+	}
+	if want := []string{"welcome.unread", "tags.1", "escaped"}; !cmp.Equal(want, untranslated) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(want, untranslated))
+	}
+
+	if got := string(f.Bytes()); !strings.Contains(got, `"title": "Willkommen, {name}!", // shown after login`) || strings.Contains(got, "removed") {
+		t.Fatalf("Bytes() should keep the layout of the source with the existing translations; got\n\n%s", got)
+	}
+}
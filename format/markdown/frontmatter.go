@@ -0,0 +1,219 @@
+package markdown
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FrontMatterField is a top-level field of the YAML or TOML front matter of a
+// Markdown document.
+type FrontMatterField struct {
+	// Range is the range of the raw value of the field, including its quotes.
+	Range
+
+	// Key is the key of the field.
+	Key string
+
+	// Text is the unquoted value of the field.
+	Text string
+
+	quote byte
+	toml  bool
+}
+
+// SplitFrontMatter splits a Markdown document into its YAML ("---") or TOML
+// ("+++") front matter and its body. The front matter includes its delimiters
+// and the blank lines that follow it. If the document has no front matter,
+// front is nil and body is the whole document.
+func SplitFrontMatter(source []byte) (front, body []byte) {
+	lines := splitLines(source)
+	end, ok := frontMatterEnd(source, lines)
+	if !ok {
+		return nil, source
+	}
+
+	for end < len(lines) && strings.TrimSpace(string(source[lines[end].Start:lines[end].End])) == "" {
+		end++
+	}
+
+	pos := len(source)
+	if end < len(lines) {
+		pos = lines[end].Start
+	}
+
+	return source[:pos], source[pos:]
+}
+
+// FrontMatterFields returns the fields of the front matter of the provided
+// Markdown document that have one of the given keys, in the order in which
+// they appear. Only top-level fields with single-line string values are
+// returned; nested fields, lists, multi-line strings and TOML tables are
+// skipped.
+func FrontMatterFields(source []byte, keys []string) []FrontMatterField {
+	lines := splitLines(source)
+	end, ok := frontMatterEnd(source, lines)
+	if !ok || len(keys) == 0 {
+		return nil
+	}
+
+	toml := string(source[lines[0].Start:lines[0].End]) == "+++"
+	separator := ":"
+	if toml {
+		separator = "="
+	}
+
+	var fields []FrontMatterField
+	for _, line := range lines[1 : end-1] {
+		text := string(source[line.Start:line.End])
+
+		// Fields that follow a TOML table header belong to the table.
+		if toml && strings.HasPrefix(text, "[") {
+			break
+		}
+
+		if text == "" || text[0] == ' ' || text[0] == '\t' || text[0] == '#' {
+			continue
+		}
+
+		key, rest, ok := strings.Cut(text, separator)
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if !slices.Contains(keys, key) {
+			continue
+		}
+
+		start := len(text) - len(strings.TrimLeft(rest, " \t"))
+		field, ok := parseValue(text[start:], toml)
+		if !ok {
+			continue
+		}
+
+		field.Key = key
+		field.Start += line.Start + start
+		field.End += line.Start + start
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// Value returns the given text encoded as the value of the field. The text is
+// quoted like the original value unless it must be quoted differently.
+func (f FrontMatterField) Value(text string) string {
+	switch {
+	case f.quote == '\'' && f.toml && !strings.ContainsAny(text, "'\r\n"):
+		return "'" + text + "'"
+	case f.quote == '\'' && !f.toml && !strings.ContainsAny(text, "\r\n"):
+		return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+	case f.quote == 0 && !f.toml && isPlainScalar(text):
+		return text
+	default:
+		return doubleQuote(text)
+	}
+}
+
+// parseValue parses the raw value of a field. The range of the returned field
+// is relative to the value.
+func parseValue(value string, toml bool) (FrontMatterField, bool) {
+	if value == "" {
+		return FrontMatterField{}, false
+	}
+
+	field := FrontMatterField{toml: toml}
+
+	switch quote := value[0]; quote {
+	case '"', '\'':
+		if strings.HasPrefix(value, strings.Repeat(string(quote), 3)) {
+			// multi-line strings
+			return field, false
+		}
+
+		end := closingQuote(value, quote, toml)
+		if end < 0 {
+			return field, false
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return field, false
+		}
+
+		raw := value[:end+1]
+		switch {
+		case quote == '"':
+			text, err := strconv.Unquote(raw)
+			if err != nil {
+				return field, false
+			}
+			field.Text = text
+		case toml:
+			field.Text = raw[1 : len(raw)-1]
+		default:
+			field.Text = strings.ReplaceAll(raw[1:len(raw)-1], "''", "'")
+		}
+
+		field.quote = quote
+		field.Range = Range{Start: 0, End: len(raw)}
+		return field, true
+	}
+
+	// TOML strings are always quoted, and plain YAML values that start with an
+	// indicator are block scalars, flow collections, anchors or tags.
+	if toml || strings.ContainsRune("|>[{&*!%@`", rune(value[0])) {
+		return field, false
+	}
+
+	end := len(value)
+	if i := strings.Index(value, " #"); i >= 0 {
+		end = i
+	}
+	text := strings.TrimRight(value[:end], " \t")
+	if text == "" {
+		return field, false
+	}
+
+	field.Text = text
+	field.Range = Range{Start: 0, End: len(text)}
+	return field, true
+}
+
+// closingQuote returns the index of the quote that closes the string that
+// starts at the beginning of value, or -1 if the string is not closed.
+func closingQuote(value string, quote byte, toml bool) int {
+	for i := 1; i < len(value); i++ {
+		switch {
+		case quote == '"' && value[i] == '\\':
+			i++
+		case value[i] != quote:
+		case quote == '\'' && !toml && i+1 < len(value) && value[i+1] == '\'':
+			// escaped single quote in YAML
+			i++
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// isPlainScalar reports whether the text can be written as a plain YAML
+// scalar without quotes.
+func isPlainScalar(text string) bool {
+	if text == "" || text != strings.TrimSpace(text) || strings.ContainsAny(text, "\r\n") {
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(text[0])) {
+		return false
+	}
+	return !strings.Contains(text, ": ") && !strings.Contains(text, " #") && !strings.HasSuffix(text, ":")
+}
+
+func doubleQuote(text string) string {
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(text) + `"`
+}
@@ -0,0 +1,362 @@
+package markdown
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Range is a byte range [Start, End) within a Markdown document that contains
+// translatable prose.
+type Range struct {
+	Start int
+	End   int
+}
+
+// Ranges returns the ranges of the provided Markdown document that contain
+// prose, namely headings, paragraphs, list items, block quotes, table cells and
+// link texts. Front matter, fenced code blocks, inline code, HTML tags, link
+// destinations and URLs are skipped. The returned ranges are sorted and do not
+// overlap.
+func Ranges(source []byte) []Range {
+	var (
+		ranges      []Range
+		fence       string
+		block       = Range{Start: -1}
+		inParagraph bool
+	)
+
+	flush := func() {
+		if block.Start >= 0 {
+			ranges = append(ranges, inlineRanges(source, block.Start, block.End)...)
+		}
+		block = Range{Start: -1}
+		inParagraph = false
+	}
+
+	lines := splitLines(source)
+
+	start := 0
+	if end, ok := frontMatterEnd(source, lines); ok {
+		start = end
+	}
+
+	for _, line := range lines[start:] {
+		text := string(source[line.Start:line.End])
+		trimmed := strings.TrimSpace(text)
+		indent := len(text) - len(strings.TrimLeft(text, " \t"))
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		if indent < 4 && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			flush()
+			fence = trimmed[:3]
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if indent >= 4 && !inParagraph {
+			// indented code block
+			flush()
+			continue
+		}
+
+		if isThematicBreak(trimmed) || isTableDelimiter(trimmed) || isReferenceDefinition(trimmed) || strings.HasPrefix(trimmed, "<") {
+			flush()
+			continue
+		}
+
+		contentStart := line.Start + indent
+		if offset, ok := blockMarker(trimmed); ok {
+			flush()
+			contentStart += offset
+			ranges = append(ranges, inlineRanges(source, contentStart, line.End)...)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			flush()
+			ranges = append(ranges, tableRanges(source, contentStart, line.End)...)
+			continue
+		}
+
+		if !inParagraph {
+			block.Start = contentStart
+			inParagraph = true
+		}
+		block.End = line.End
+	}
+	flush()
+
+	return ranges
+}
+
+// Replace replaces the provided ranges of the source document with the given
+// texts and returns the resulting document. The i-th text replaces the i-th
+// range; ranges without a corresponding text are left unchanged.
+func Replace(source []byte, ranges []Range, texts []string) []byte {
+	type replacement struct {
+		Range
+		text string
+	}
+
+	replacements := make([]replacement, 0, len(ranges))
+	for i, r := range ranges {
+		if i >= len(texts) {
+			break
+		}
+		replacements = append(replacements, replacement{Range: r, text: texts[i]})
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].Start < replacements[j].Start
+	})
+
+	var buf bytes.Buffer
+	var pos int
+	for _, r := range replacements {
+		buf.Write(source[pos:r.Start])
+		buf.WriteString(r.text)
+		pos = r.End
+	}
+	buf.Write(source[pos:])
+
+	return buf.Bytes()
+}
+
+func splitLines(source []byte) []Range {
+	var lines []Range
+	start := 0
+	for i, b := range source {
+		if b == '\n' {
+			end := i
+			if end > start && source[end-1] == '\r' {
+				end--
+			}
+			lines = append(lines, Range{Start: start, End: end})
+			start = i + 1
+		}
+	}
+	if start < len(source) {
+		lines = append(lines, Range{Start: start, End: len(source)})
+	}
+	return lines
+}
+
+func frontMatterEnd(source []byte, lines []Range) (int, bool) {
+	if len(lines) == 0 {
+		return 0, false
+	}
+
+	delim := string(source[lines[0].Start:lines[0].End])
+	if delim != "---" && delim != "+++" {
+		return 0, false
+	}
+
+	for i, line := range lines[1:] {
+		if string(source[line.Start:line.End]) == delim {
+			return i + 2, true
+		}
+	}
+
+	return 0, false
+}
+
+func blockMarker(line string) (int, bool) {
+	offset := 0
+	for {
+		switch {
+		case strings.HasPrefix(line[offset:], "#"):
+			n := len(line[offset:]) - len(strings.TrimLeft(line[offset:], "#"))
+			if n > 6 || (offset+n < len(line) && line[offset+n] != ' ') {
+				return offset, offset > 0
+			}
+			offset += n
+		case strings.HasPrefix(line[offset:], ">"):
+			offset++
+		case strings.HasPrefix(line[offset:], "- "), strings.HasPrefix(line[offset:], "* "), strings.HasPrefix(line[offset:], "+ "):
+			offset += 2
+		case orderedListMarker(line[offset:]) > 0:
+			offset += orderedListMarker(line[offset:])
+		case offset > 0 && (strings.HasPrefix(line[offset:], "[ ] ") || strings.HasPrefix(line[offset:], "[x] ")):
+			offset += 4
+		default:
+			return offset, offset > 0
+		}
+
+		rest := strings.TrimLeft(line[offset:], " ")
+		offset = len(line) - len(rest)
+	}
+}
+
+func orderedListMarker(line string) int {
+	var digits int
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || digits > 9 || digits+1 >= len(line) {
+		return 0
+	}
+	if (line[digits] == '.' || line[digits] == ')') && line[digits+1] == ' ' {
+		return digits + 2
+	}
+	return 0
+}
+
+func isThematicBreak(line string) bool {
+	compact := strings.ReplaceAll(line, " ", "")
+	if len(compact) < 3 {
+		return false
+	}
+	for _, c := range []string{"-", "*", "_"} {
+		if strings.Trim(compact, c) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func isTableDelimiter(line string) bool {
+	return strings.Contains(line, "-") && strings.Trim(line, "|-: ") == ""
+}
+
+func isReferenceDefinition(line string) bool {
+	if !strings.HasPrefix(line, "[") {
+		return false
+	}
+	end := strings.Index(line, "]:")
+	return end > 0 && !strings.Contains(line[:end], "](")
+}
+
+func tableRanges(source []byte, start, end int) []Range {
+	var ranges []Range
+	cellStart := start
+	for i := start; i < end; i++ {
+		if source[i] == '|' && (i == start || source[i-1] != '\\') {
+			ranges = append(ranges, inlineRanges(source, cellStart, i)...)
+			cellStart = i + 1
+		}
+	}
+	return append(ranges, inlineRanges(source, cellStart, end)...)
+}
+
+// inlineRanges returns the prose ranges within [start, end), skipping inline
+// code, HTML tags, link destinations and URLs.
+func inlineRanges(source []byte, start, end int) []Range {
+	var ranges []Range
+
+	segStart := start
+	emit := func(segEnd int) {
+		if r, ok := trimRange(source, segStart, segEnd); ok {
+			ranges = append(ranges, r)
+		}
+	}
+
+	for i := start; i < end; {
+		rest := source[i:end]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1:
+			i += 2
+			continue
+		case rest[0] == '`':
+			ticks := len(rest) - len(bytes.TrimLeft(rest, "`"))
+			closing := bytes.Index(rest[ticks:], rest[:ticks])
+			if closing < 0 {
+				i += ticks
+				continue
+			}
+			emit(i)
+			i += ticks + closing + ticks
+			segStart = i
+			continue
+		case rest[0] == '<':
+			closing := bytes.IndexByte(rest, '>')
+			if closing < 0 {
+				break
+			}
+			emit(i)
+			i += closing + 1
+			segStart = i
+			continue
+		case bytes.HasPrefix(rest, []byte("![")):
+			emit(i)
+			i += 2
+			segStart = i
+			continue
+		case rest[0] == '[':
+			emit(i)
+			i++
+			segStart = i
+			continue
+		case bytes.HasPrefix(rest, []byte("](")) || bytes.HasPrefix(rest, []byte("][")):
+			closer := byte(')')
+			if rest[1] == '[' {
+				closer = ']'
+			}
+			closing := bytes.IndexByte(rest[2:], closer)
+			emit(i)
+			if closing < 0 {
+				i = end
+			} else {
+				i += 2 + closing + 1
+			}
+			segStart = i
+			continue
+		case rest[0] == ']':
+			emit(i)
+			i++
+			segStart = i
+			continue
+		case bytes.HasPrefix(rest, []byte("http://")) || bytes.HasPrefix(rest, []byte("https://")):
+			emit(i)
+			n := bytes.IndexFunc(rest, unicode.IsSpace)
+			if n < 0 {
+				n = len(rest)
+			}
+			i += n
+			segStart = i
+			continue
+		}
+
+		_, size := utf8.DecodeRune(rest)
+		i += size
+	}
+	emit(end)
+
+	return ranges
+}
+
+func trimRange(source []byte, start, end int) (Range, bool) {
+	for start < end {
+		r, size := utf8.DecodeRune(source[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+
+	for end > start {
+		r, size := utf8.DecodeLastRune(source[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+
+	if bytes.IndexFunc(source[start:end], unicode.IsLetter) < 0 {
+		return Range{}, false
+	}
+
+	return Range{Start: start, End: end}, true
+}
@@ -0,0 +1,199 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/markdown"
+)
+
+func TestRanges(t *testing.T) {
+	source := heredoc.Doc(`
+		---
+		title: Hello
+		---
+
+		# Getting started
+
+		Install the package with ` + "`go get`" + ` and
+		read the [documentation](https://example.com/docs).
+
+		- First item
+		- [x] Second item
+
+		> Quoted text
+
+		` + "```go" + `
+		fmt.Println("Hello")
+		` + "```" + `
+
+		| Name | Description |
+		| ---- | ----------- |
+		| foo  | Does things |
+
+		Visit https://example.com for more.
+
+		[docs]: https://example.com/docs
+	`)
+
+	want := []string{
+		"Getting started",
+		"Install the package with",
+		"and\nread the",
+		"documentation",
+		"First item",
+		"Second item",
+		"Quoted text",
+		"Name",
+		"Description",
+		"foo",
+		"Does things",
+		"Visit",
+		"for more.",
+	}
+
+	got := texts(source, markdown.Ranges([]byte(source)))
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Ranges() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestReplace(t *testing.T) {
+	source := heredoc.Doc(`
+		# Hello
+
+		Read the [docs](https://example.com).
+	`)
+
+	ranges := markdown.Ranges([]byte(source))
+	result := markdown.Replace([]byte(source), ranges, []string{"Hallo", "Lies die", "Doku"})
+
+	want := heredoc.Doc(`
+		# Hallo
+
+		Lies die [Doku](https://example.com).
+	`)
+
+	if got := string(result); got != want {
+		t.Fatalf("Replace(): got %q; want %q", got, want)
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	source := heredoc.Doc(`
+		---
+		title: Hello
+		---
+
+		# Hello
+	`)
+
+	front, body := markdown.SplitFrontMatter([]byte(source))
+
+	if want := "---\ntitle: Hello\n---\n\n"; string(front) != want {
+		t.Fatalf("front matter should be %q; got %q", want, front)
+	}
+	if want := "# Hello\n"; string(body) != want {
+		t.Fatalf("body should be %q; got %q", want, body)
+	}
+
+	if front, body := markdown.SplitFrontMatter([]byte("# Hello\n")); front != nil || string(body) != "# Hello\n" {
+		t.Fatalf("document without front matter should only have a body; got %q and %q", front, body)
+	}
+}
+
+func TestFrontMatterFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		fields []string
+		texts  []string
+		want   string
+	}{
+		{
+			name: "yaml",
+			source: heredoc.Doc(`
+				---
+				title: Getting started # comment
+				description: "Learn how to \"install\" it."
+				summary: 'It''s easy.'
+				slug: getting-started
+				tags:
+				  - title: nested
+				---
+				Body
+			`),
+			fields: []string{"Getting started", `Learn how to "install" it.`, "It's easy."},
+			texts:  []string{"Erste Schritte: Installation", "Lerne, wie du es \"installierst\".", "Es ist einfach."},
+			want: heredoc.Doc(`
+				---
+				title: "Erste Schritte: Installation" # comment
+				description: "Lerne, wie du es \"installierst\"."
+				summary: 'Es ist einfach.'
+				slug: getting-started
+				tags:
+				  - title: nested
+				---
+				Body
+			`),
+		},
+		{
+			name: "toml",
+			source: heredoc.Doc(`
+				+++
+				title = "Getting started"
+				summary = 'Easy'
+				[params]
+				description = "Nested"
+				+++
+				Body
+			`),
+			fields: []string{"Getting started", "Easy"},
+			texts:  []string{"Erste Schritte", "Einfach, oder?"},
+			want: heredoc.Doc(`
+				+++
+				title = "Erste Schritte"
+				summary = 'Einfach, oder?'
+				[params]
+				description = "Nested"
+				+++
+				Body
+			`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := markdown.FrontMatterFields([]byte(tt.source), []string{"title", "description", "summary"})
+			var got []string
+			for _, f := range fields {
+				got = append(got, f.Text)
+			}
+			if !cmp.Equal(tt.fields, got) {
+				t.Fatalf("FrontMatterFields() mismatch (-want +got):\n%s", cmp.Diff(tt.fields, got))
+			}
+
+			ranges := make([]markdown.Range, len(fields))
+			values := make([]string, len(fields))
+			for i, f := range fields {
+				ranges[i] = f.Range
+				values[i] = f.Value(tt.texts[i])
+			}
+
+			if got := string(markdown.Replace([]byte(tt.source), ranges, values)); got != tt.want {
+				t.Fatalf("Replace() returned\n\n%s\n\nwant\n\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func texts(source string, ranges []markdown.Range) []string {
+	out := make([]string, len(ranges))
+	for i, r := range ranges {
+		out[i] = strings.TrimSpace(source[r.Start:r.End])
+	}
+	return out
+}
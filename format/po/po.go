@@ -0,0 +1,350 @@
+package po
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	npluralsExpr   = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+	pluralFormExpr = regexp.MustCompile(`^msgstr\[(\d+)\]$`)
+)
+
+// maxPluralForms is the maximum number of plural forms of a message.
+const maxPluralForms = 100
+
+// File is a parsed gettext PO or POT catalog. It keeps the original lines of
+// every entry, so that writing the catalog back only changes the translations
+// that were set using [Entry.SetTranslation]. Comments, flags, references and
+// obsolete entries are preserved verbatim.
+type File struct {
+	Entries []*Entry
+
+	// separators contains the blank lines that precede each entry and the
+	// lines that follow the last entry.
+	separators [][]string
+}
+
+// Entry is a single message of a [File].
+type Entry struct {
+	// Context is the message context (msgctxt) of the entry.
+	Context string
+
+	// ID is the untranslated message (msgid).
+	ID string
+
+	// IDPlural is the untranslated plural message (msgid_plural). It is empty
+	// for messages without plural forms.
+	IDPlural string
+
+	// Str contains the translations of the message (msgstr). Messages without
+	// plural forms have a single translation, messages with plural forms have
+	// one translation per plural form, where Str[N] is the translation of
+	// msgstr[N].
+	Str []string
+
+	// Obsolete reports whether the entry is commented out using "#~".
+	Obsolete bool
+
+	lines   []string
+	strLine int
+}
+
+// Parse parses a PO or POT catalog.
+func Parse(data []byte) (*File, error) {
+	var (
+		f       File
+		entry   *Entry
+		blanks  []string
+		field   *string
+		lineNum int
+	)
+
+	flush := func() {
+		if entry == nil {
+			return
+		}
+		f.Entries = append(f.Entries, entry)
+		f.separators = append(f.separators, blanks)
+		entry, blanks, field = nil, nil, nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		lineNum++
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			flush()
+			blanks = append(blanks, line)
+			continue
+		}
+
+		// Comments and the msgctxt or msgid of a message that follow the
+		// msgstr of an entry start the next entry, even if there is no blank
+		// line in between.
+		if entry != nil && (entry.strLine >= 0 || entry.Obsolete) && startsEntry(trimmed) &&
+			!(entry.Obsolete && strings.HasPrefix(trimmed, "#~")) {
+			flush()
+		}
+
+		if entry == nil {
+			entry = &Entry{strLine: -1}
+		}
+		entry.lines = append(entry.lines, line)
+
+		if strings.HasPrefix(trimmed, "#~") {
+			entry.Obsolete = true
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, `"`) {
+			if field == nil {
+				return nil, fmt.Errorf("line %d: unexpected string continuation", lineNum)
+			}
+			s, err := unquote(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			*field += s
+			continue
+		}
+
+		keyword, value, ok := strings.Cut(trimmed, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid line %q", lineNum, line)
+		}
+
+		s, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			entry.Context = s
+			field = &entry.Context
+		case keyword == "msgid":
+			entry.ID = s
+			field = &entry.ID
+		case keyword == "msgid_plural":
+			entry.IDPlural = s
+			field = &entry.IDPlural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			index := 0
+			if keyword != "msgstr" {
+				m := pluralFormExpr.FindStringSubmatch(keyword)
+				if m == nil {
+					return nil, fmt.Errorf("line %d: invalid keyword %q", lineNum, keyword)
+				}
+				if index, err = strconv.Atoi(m[1]); err != nil || index >= maxPluralForms {
+					return nil, fmt.Errorf("line %d: invalid plural form %q", lineNum, keyword)
+				}
+			}
+
+			if entry.strLine < 0 {
+				entry.strLine = len(entry.lines) - 1
+			}
+			for len(entry.Str) <= index {
+				entry.Str = append(entry.Str, "")
+			}
+			entry.Str[index] = s
+			field = &entry.Str[index]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNum, keyword)
+		}
+	}
+	flush()
+	f.separators = append(f.separators, blanks)
+
+	return &f, nil
+}
+
+// startsEntry reports whether the trimmed line may only appear at the start of
+// an entry.
+func startsEntry(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "msgctxt ") || strings.HasPrefix(line, "msgid ")
+}
+
+// Bytes returns the catalog in PO format.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, entry := range f.Entries {
+		for _, line := range f.separators[i] {
+			buf.WriteString(line + "\n")
+		}
+		for _, line := range entry.lines {
+			buf.WriteString(line + "\n")
+		}
+	}
+	for _, line := range f.separators[len(f.separators)-1] {
+		buf.WriteString(line + "\n")
+	}
+	return buf.Bytes()
+}
+
+// Header returns the header entry of the catalog, which is the entry with an
+// empty msgid, or nil if the catalog has no header.
+func (f *File) Header() *Entry {
+	for _, entry := range f.Entries {
+		if entry.ID == "" && entry.Context == "" && !entry.Obsolete && entry.strLine >= 0 {
+			return entry
+		}
+	}
+	return nil
+}
+
+// NPlurals returns the number of plural forms declared by the Plural-Forms
+// header of the catalog. It returns 2 if the header is missing.
+func (f *File) NPlurals() int {
+	if header := f.Header(); header != nil && len(header.Str) > 0 {
+		if m := npluralsExpr.FindStringSubmatch(header.Str[0]); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 2
+}
+
+// Untranslated returns the entries that have no translation yet, excluding the
+// header and obsolete entries.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, entry := range f.Entries {
+		if entry.ID == "" || entry.Obsolete || entry.Translated() {
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// Translated reports whether the entry has a non-empty translation for every
+// plural form.
+func (e *Entry) Translated() bool {
+	if len(e.Str) == 0 {
+		return false
+	}
+	for _, s := range e.Str {
+		if s == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// SetTranslation replaces the translations (msgstr) of the entry. Messages with
+// plural forms expect one translation per plural form.
+func (e *Entry) SetTranslation(strs ...string) {
+	if e.strLine >= 0 {
+		e.lines = e.lines[:e.strLine]
+	} else {
+		e.strLine = len(e.lines)
+	}
+
+	e.Str = strs
+	if e.IDPlural == "" {
+		if len(strs) > 0 {
+			e.lines = append(e.lines, formatField("msgstr", strs[0])...)
+		}
+		return
+	}
+
+	for i, s := range strs {
+		e.lines = append(e.lines, formatField(fmt.Sprintf("msgstr[%d]", i), s)...)
+	}
+}
+
+func formatField(keyword, value string) []string {
+	if !strings.Contains(strings.TrimSuffix(value, "\n"), "\n") {
+		return []string{keyword + " " + quote(value)}
+	}
+
+	lines := []string{keyword + ` ""`}
+	for _, part := range strings.SplitAfter(value, "\n") {
+		if part != "" {
+			lines = append(lines, quote(part))
+		}
+	}
+	return lines
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+
+	var b strings.Builder
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid escape sequence at end of string")
+		}
+
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '"', '\\', '\'', '?':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
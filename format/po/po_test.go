@@ -0,0 +1,198 @@
+package po_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman/format/po"
+)
+
+func TestParse(t *testing.T) {
+	source := heredoc.Doc(`
+		msgid ""
+		msgstr ""
+		"Language: de\n"
+		"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+		#: main.go:12
+		#, c-format
+		msgid "Hello, %s!"
+		msgstr "Hallo, %s!"
+
+		#. A comment for translators
+		msgctxt "menu"
+		msgid "Open"
+		msgstr ""
+
+		msgid "One file"
+		msgid_plural "%d files"
+		msgstr[0] ""
+		msgstr[1] ""
+
+		msgid ""
+		"A \"quoted\"\n"
+		"multiline message"
+		msgstr ""
+
+		#~ msgid "Old"
+		#~ msgstr "Alt"
+	`)
+
+	f, err := po.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original catalog; got\n\n%s", got)
+	}
+
+	if n := f.NPlurals(); n != 2 {
+		t.Fatalf("NPlurals(): got %d; want %d", n, 2)
+	}
+
+	untranslated := f.Untranslated()
+	if len(untranslated) != 3 {
+		t.Fatalf("Untranslated() should return 3 entries; got %d", len(untranslated))
+	}
+
+	if untranslated[0].Context != "menu" || untranslated[0].ID != "Open" {
+		t.Fatalf("unexpected entry %+v", untranslated[0])
+	}
+
+	if untranslated[1].IDPlural != "%d files" {
+		t.Fatalf("unexpected entry %+v", untranslated[1])
+	}
+
+	if want := "A \"quoted\"\nmultiline message"; untranslated[2].ID != want {
+		t.Fatalf("ID: got %q; want %q", untranslated[2].ID, want)
+	}
+
+	untranslated[0].SetTranslation("Öffnen")
+	untranslated[1].SetTranslation("Eine Datei", "%d Dateien")
+	untranslated[2].SetTranslation("Eine \"zitierte\"\nmehrzeilige Nachricht")
+
+	want := heredoc.Doc(`
+		msgid ""
+		msgstr ""
+		"Language: de\n"
+		"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+		#: main.go:12
+		#, c-format
+		msgid "Hello, %s!"
+		msgstr "Hallo, %s!"
+
+		#. A comment for translators
+		msgctxt "menu"
+		msgid "Open"
+		msgstr "Öffnen"
+
+		msgid "One file"
+		msgid_plural "%d files"
+		msgstr[0] "Eine Datei"
+		msgstr[1] "%d Dateien"
+
+		msgid ""
+		"A \"quoted\"\n"
+		"multiline message"
+		msgstr ""
+		"Eine \"zitierte\"\n"
+		"mehrzeilige Nachricht"
+
+		#~ msgid "Old"
+		#~ msgstr "Alt"
+	`)
+
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes(): got\n\n%s\n\nwant\n\n%s", got, want)
+	}
+}
+
+func TestParse_withoutBlankLines(t *testing.T) {
+	source := heredoc.Doc(`
+		msgid "Open"
+		msgstr ""
+		#: main.go:20
+		msgctxt "menu"
+		msgid "Close"
+		msgstr "Schließen"
+		msgid "Save"
+		msgstr ""
+		#~ msgid "Old"
+		#~ msgstr "Alt"
+		msgid "Quit"
+		msgstr ""
+	`)
+
+	f, err := po.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	var ids []string
+	for _, entry := range f.Entries {
+		ids = append(ids, entry.ID)
+	}
+	if want := []string{"Open", "Close", "Save", "", "Quit"}; strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("Parse() should return the entries %q; got %q", want, ids)
+	}
+	if entry := f.Entries[1]; entry.Context != "menu" || entry.Str[0] != "Schließen" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if !f.Entries[3].Obsolete {
+		t.Fatalf("entry should be obsolete: %+v", f.Entries[3])
+	}
+
+	untranslated := f.Untranslated()
+	if len(untranslated) != 3 {
+		t.Fatalf("Untranslated() should return 3 entries; got %d", len(untranslated))
+	}
+	for i, s := range []string{"Öffnen", "Speichern", "Beenden"} {
+		untranslated[i].SetTranslation(s)
+	}
+
+	want := heredoc.Doc(`
+		msgid "Open"
+		msgstr "Öffnen"
+		#: main.go:20
+		msgctxt "menu"
+		msgid "Close"
+		msgstr "Schließen"
+		msgid "Save"
+		msgstr "Speichern"
+		#~ msgid "Old"
+		#~ msgstr "Alt"
+		msgid "Quit"
+		msgstr "Beenden"
+	`)
+
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes(): got\n\n%s\n\nwant\n\n%s", got, want)
+	}
+}
+
+func TestParse_pluralFormIndex(t *testing.T) {
+	source := heredoc.Doc(`
+		msgid "One file"
+		msgid_plural "%d files"
+		msgstr[1] "%d Dateien"
+		msgstr[0] "Eine Datei"
+	`)
+
+	f, err := po.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got, want := f.Entries[0].Str, []string{"Eine Datei", "%d Dateien"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Str should be %q; got %q", want, got)
+	}
+
+	for _, keyword := range []string{"msgstr[x]", "msgstr[1", "msgstr[100]"} {
+		if _, err := po.Parse([]byte("msgid \"a\"\n" + keyword + " \"b\"\n")); err == nil {
+			t.Errorf("Parse() should fail for %q", keyword)
+		}
+	}
+}
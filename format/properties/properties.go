@@ -0,0 +1,434 @@
+package properties
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// placeholder matches MessageFormat arguments (e.g. "{0}" or "{1,number}") and
+// format specifiers (e.g. "%s" or "%1$d").
+var placeholder = regexp.MustCompile(`\{\d+(,[^{}]*)?\}|%(\d+\$)?[-#+0,(]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// encoding is the character encoding of a properties file.
+type encoding int
+
+const (
+	// ascii files only contain ASCII characters; other characters are written
+	// as Unicode escapes (e.g. `\u00fc`), like native2ascii does.
+	ascii encoding = iota
+
+	// latin1 files are encoded as ISO-8859-1, the traditional encoding of
+	// properties files. Characters outside of ISO-8859-1 are written as
+	// Unicode escapes.
+	latin1
+
+	// utf8Encoding files contain UTF-8 encoded characters, which are written
+	// as they are.
+	utf8Encoding
+)
+
+// File is a parsed Java properties file (e.g. "messages_de.properties"). It
+// keeps the original bytes of the file, so that writing it back only replaces
+// the values that were set using [Entry.SetTranslation]. Comments, separators
+// and formatting are preserved verbatim. Files that are not valid UTF-8 are
+// read and written as ISO-8859-1.
+type File struct {
+	// Entries are the key-value pairs of the file in the order of the file.
+	Entries []*Entry
+
+	data     []byte
+	encoding encoding
+}
+
+// Entry is a key-value pair of a [File].
+type Entry struct {
+	// Key is the unescaped key of the entry.
+	Key string
+
+	// Text is the unescaped value of the entry.
+	Text string
+
+	// block is the range of the entry including its preceding comments.
+	block      [2]int
+	start, end int
+	pending    bool
+	translated bool
+}
+
+// Parse parses a properties file. Escape sequences of keys and values,
+// including Unicode escapes, are unescaped, and values that are continued on
+// the next lines are joined.
+func Parse(data []byte) (*File, error) {
+	f := File{encoding: ascii}
+
+	switch {
+	case !utf8.Valid(data):
+		f.encoding = latin1
+		data = decodeLatin1(data)
+	case hasNonASCII(data):
+		f.encoding = utf8Encoding
+	}
+	f.data = data
+
+	blockStart := 0
+	for pos := 0; pos < len(data); {
+		eol, next := endOfLine(data, pos)
+
+		start := pos
+		for start < eol && isSpace(data[start]) {
+			start++
+		}
+
+		if start == eol || data[start] == '#' || data[start] == '!' {
+			pos = next
+			continue
+		}
+
+		// Lines that end with an odd number of backslashes are continued on
+		// the next line.
+		end := eol
+		for continued(data[start:end]) && next < len(data) {
+			end, next = endOfLine(data, next)
+		}
+
+		keyEnd := start
+		for keyEnd < end {
+			c := data[keyEnd]
+			if c == '\\' {
+				keyEnd += 2
+				continue
+			}
+			if c == '=' || c == ':' || isSpace(c) {
+				break
+			}
+			keyEnd++
+		}
+		if keyEnd > end {
+			keyEnd = end
+		}
+
+		valueStart := keyEnd
+		for valueStart < end && isSpace(data[valueStart]) {
+			valueStart++
+		}
+		if valueStart < end && (data[valueStart] == '=' || data[valueStart] == ':') {
+			valueStart++
+			for valueStart < end && isSpace(data[valueStart]) {
+				valueStart++
+			}
+		}
+
+		key, err := unescape(string(data[start:keyEnd]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: key: %w", lineNumber(data, start), err)
+		}
+
+		value, err := unescape(string(data[valueStart:end]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: value of %q: %w", lineNumber(data, start), key, err)
+		}
+
+		f.Entries = append(f.Entries, &Entry{
+			Key:   key,
+			Text:  value,
+			block: [2]int{blockStart, next},
+			start: valueStart,
+			end:   end,
+		})
+
+		pos = next
+		blockStart = next
+	}
+
+	return &f, nil
+}
+
+// Merge appends the entries of the source file that are missing in the target
+// file to the end of the target file, together with their comments, and
+// returns the merged file. The values of the added entries are returned by
+// [File.Untranslated] and still need to be translated. If target is nil, the
+// merged file is a copy of the source file and all of its values are
+// untranslated.
+func Merge(source, target *File) (*File, error) {
+	if target == nil {
+		merged := *source
+		merged.Entries = make([]*Entry, len(source.Entries))
+		for i, e := range source.Entries {
+			clone := *e
+			clone.pending = true
+			merged.Entries[i] = &clone
+		}
+		return &merged, nil
+	}
+
+	existing := make(map[string]bool, len(target.Entries))
+	for _, e := range target.Entries {
+		existing[e.Key] = true
+	}
+
+	var (
+		added   bytes.Buffer
+		pending = make(map[string]bool)
+	)
+	for _, e := range source.Entries {
+		if existing[e.Key] {
+			continue
+		}
+		added.WriteString("\n")
+		added.WriteString(strings.TrimSpace(string(source.data[e.block[0]:e.block[1]])))
+		added.WriteString("\n")
+		pending[e.Key] = true
+	}
+
+	if added.Len() == 0 {
+		return target, nil
+	}
+
+	data := append([]byte(nil), target.data...)
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = nil
+		added.Next(1)
+	} else if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, added.Bytes()...)
+
+	merged, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse merged file: %w", err)
+	}
+	merged.encoding = target.encoding
+
+	for _, e := range merged.Entries {
+		e.pending = pending[e.Key]
+	}
+
+	return merged, nil
+}
+
+// Untranslated returns the entries that were added by [Merge] and have not
+// been translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if e.pending && !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the properties file with the translations of all translated
+// entries, in the encoding of the original file. Translations that contain
+// characters that the encoding cannot represent use Unicode escapes.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(escape(e.Text, f.encoding))
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+
+	if f.encoding == latin1 {
+		return encodeLatin1(buf.String())
+	}
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the entry. It returns an error if
+// the placeholders (e.g. "{0}" or "%s") of the translation differ from those
+// of the original value.
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text), placeholders(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Key, want, got)
+	}
+
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// placeholders returns the sorted placeholders of a text.
+func placeholders(text string) []string {
+	out := placeholder.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+// endOfLine returns the end of the line that starts at pos and the start of the
+// next line. Lines end with "\n", "\r" or "\r\n".
+func endOfLine(data []byte, pos int) (int, int) {
+	for i := pos; i < len(data); i++ {
+		switch data[i] {
+		case '\n':
+			return i, i + 1
+		case '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i, i + 2
+			}
+			return i, i + 1
+		}
+	}
+	return len(data), len(data)
+}
+
+// continued reports whether the line ends with an odd number of backslashes.
+func continued(line []byte) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i >= len(s) {
+			break
+		}
+
+		switch c := s[i]; c {
+		case '\r', '\n':
+			// A continued line: skip the line terminator and the leading
+			// whitespace of the next line.
+			if c == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			for i+1 < len(s) && isSpace(s[i+1]) {
+				i++
+			}
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", errors.New("incomplete unicode escape")
+			}
+			r1, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+5])
+			}
+			i += 4
+
+			// Characters outside of the Basic Multilingual Plane are
+			// written as surrogate pairs.
+			if utf16.IsSurrogate(rune(r1)) && i+6 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+				if r2, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil {
+					if r := utf16.DecodeRune(rune(r1), rune(r2)); r != utf8.RuneError {
+						b.WriteRune(r)
+						i += 6
+						continue
+					}
+				}
+			}
+			b.WriteRune(rune(r1))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// escape escapes a value. Characters above the limit of the encoding are
+// written as Unicode escapes.
+func escape(s string, enc encoding) string {
+	limit := rune(utf8.MaxRune)
+	switch enc {
+	case ascii:
+		limit = 0x7F
+	case latin1:
+		limit = 0xFF
+	}
+
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == ' ' && i == 0:
+			// Leading spaces of values are ignored unless they are escaped.
+			b.WriteString(`\ `)
+		case r > limit:
+			for _, u := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04x`, u)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+func hasNonASCII(data []byte) bool {
+	for _, c := range data {
+		if c >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+func lineNumber(data []byte, pos int) int {
+	return bytes.Count(data[:pos], []byte("\n")) + 1
+}
+
+func decodeLatin1(data []byte) []byte {
+	var buf bytes.Buffer
+	for _, c := range data {
+		buf.WriteRune(rune(c))
+	}
+	return buf.Bytes()
+}
+
+func encodeLatin1(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		// Translations are already escaped, but comments that were merged
+		// from a UTF-8 source file may contain other characters.
+		if r > 0xFF {
+			out = append(out, escape(string(r), latin1)...)
+			continue
+		}
+		out = append(out, byte(r))
+	}
+	return out
+}
@@ -0,0 +1,176 @@
+package properties_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/properties"
+)
+
+var source = heredoc.Doc(`
+	# Title of the welcome screen
+	welcome.title = Welcome, {0}!
+
+	! Number of unread messages
+	inbox.unread: You have %d unread messages.
+	multiline = First line \
+	            continued
+	escaped\ key=Tab\there
+	unicode=Café
+`)
+
+func TestParse(t *testing.T) {
+	f, err := properties.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+	}
+
+	want := map[string]string{
+		"welcome.title": "Welcome, {0}!",
+		"inbox.unread":  "You have %d unread messages.",
+		"multiline":     "First line continued",
+		"escaped key":   "Tab\there",
+		"unicode":       "Café",
+	}
+
+	got := make(map[string]string)
+	for _, e := range f.Entries {
+		got[e.Key] = e.Text
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestParse_unicodeEscapes(t *testing.T) {
+	f, err := properties.Parse([]byte(`greeting=Gr\u00fc\u00DFe \ud83d\udc4b`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if want := "Grüße 👋"; f.Entries[0].Text != want {
+		t.Fatalf("Text should be %q; got %q", want, f.Entries[0].Text)
+	}
+
+	if _, err := properties.Parse([]byte(`greeting=\u00`)); err == nil {
+		t.Fatalf("Parse() should fail for an incomplete unicode escape")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	target := heredoc.Doc(`
+		# Title of the welcome screen
+		welcome.title = Willkommen, {0}!
+	`)
+
+	src, err := properties.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	tgt, err := properties.Parse([]byte(target))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := properties.Merge(src, tgt)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	translations := map[string]string{
+		"inbox.unread": "Du hast %d ungelesene Nachrichten.",
+		"multiline":    "Erste Zeile\nZweite Zeile",
+		"escaped key":  " Tabulator\thier",
+		"unicode":      "Grüße 👋",
+	}
+
+	var keys []string
+	for _, e := range merged.Untranslated() {
+		keys = append(keys, e.Key)
+		if err := e.SetTranslation(translations[e.Key]); err != nil {
+			t.Fatalf("SetTranslation(): %v", err)
+		}
+	}
+
+	wantKeys := []string{"inbox.unread", "multiline", "escaped key", "unicode"}
+	if !cmp.Equal(wantKeys, keys) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(wantKeys, keys))
+	}
+
+	want := heredoc.Doc(`
+		# Title of the welcome screen
+		welcome.title = Willkommen, {0}!
+
+		! Number of unread messages
+		inbox.unread: Du hast %d ungelesene Nachrichten.
+
+		multiline = Erste Zeile\nZweite Zeile
+
+		escaped\ key=\ Tabulator\thier
+
+		unicode=Gr\u00fc\u00dfe \ud83d\udc4b
+	`)
+
+	if got := string(merged.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestFile_Bytes_encoding(t *testing.T) {
+	tests := map[string]struct {
+		source []byte
+		want   []byte
+	}{
+		"utf-8": {
+			source: []byte("greeting=Grüß dich\n"),
+			want:   []byte("greeting=Hallo, schöne Welt 👋\n"),
+		},
+		"iso-8859-1": {
+			source: []byte("greeting=Gr\xfc\xdf dich\n"),
+			want:   []byte("greeting=Hallo, sch\xf6ne Welt \\ud83d\\udc4b\n"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := properties.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			if f.Entries[0].Text != "Grüß dich" {
+				t.Fatalf("Text should be %q; got %q", "Grüß dich", f.Entries[0].Text)
+			}
+
+			if err := f.Entries[0].SetTranslation("Hallo, schöne Welt 👋"); err != nil {
+				t.Fatalf("SetTranslation(): %v", err)
+			}
+
+			if got := f.Bytes(); !cmp.Equal(tt.want, got) {
+				t.Fatalf("Bytes() should return %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := properties.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if err := f.Entries[0].SetTranslation("Willkommen!"); err == nil {
+		t.Errorf("SetTranslation() should fail if a placeholder is missing")
+	}
+
+	if err := f.Entries[1].SetTranslation("Du hast %s ungelesene Nachrichten."); err == nil {
+		t.Errorf("SetTranslation() should fail if a format specifier changes")
+	}
+}
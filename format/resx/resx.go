@@ -0,0 +1,327 @@
+package resx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholder matches composite format items (e.g. "{0}", "{1:N2}" or
+// "{0,-10}").
+var placeholder = regexp.MustCompile(`\{\d+(,-?\d+)?(:[^{}]*)?\}`)
+
+// File is a parsed .NET resource file (e.g. "Resources.de.resx"). It keeps the
+// original bytes of the file, so that writing it back only replaces the values
+// that were set using [Entry.SetTranslation]. The schema, the resource
+// headers, comments and formatting are preserved verbatim.
+type File struct {
+	// Entries are the string resources of the file. Resources with a type or
+	// MIME type, like images, and the metadata of the Windows Forms designer
+	// (names that start with ">>" or "$this.") are not included.
+	Entries []*Entry
+
+	data     []byte
+	elements []element
+	closeTag int
+}
+
+// Entry is a string resource of a [File].
+type Entry struct {
+	// Key is the name of the resource.
+	Key string
+
+	// Text is the unescaped value of the resource.
+	Text string
+
+	// Comment is the comment of the resource for translators, if any.
+	Comment string
+
+	start, end int
+	pending    bool
+	translated bool
+}
+
+// element is a <data> element of a file.
+type element struct {
+	name       string
+	start, end int
+}
+
+// Parse parses a .NET resource file.
+func Parse(data []byte) (*File, error) {
+	f := File{data: data, closeTag: -1}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+
+	var (
+		depth   int
+		entry   *Entry
+		elStart int
+		skip    bool
+	)
+
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+
+			switch {
+			case depth == 1:
+				if tok.Name.Local != "root" {
+					return nil, fmt.Errorf("unexpected root element <%s>", tok.Name.Local)
+				}
+			case depth == 2 && tok.Name.Local == "data":
+				name := attr(tok, "name")
+				entry = &Entry{Key: name}
+				elStart = start
+				skip = name == "" || attr(tok, "type") != "" || attr(tok, "mimetype") != "" || strings.HasPrefix(name, ">>") || strings.HasPrefix(name, "$this.")
+			case depth == 3 && entry != nil && (tok.Name.Local == "value" || tok.Name.Local == "comment"):
+				text, innerStart, innerEnd, ok, err := innerText(dec)
+				if err != nil {
+					return nil, err
+				}
+				depth--
+
+				if tok.Name.Local == "comment" {
+					entry.Comment = text
+					continue
+				}
+				if !ok {
+					// Values with markup are not string resources.
+					skip = true
+					continue
+				}
+				entry.Text = text
+				entry.start, entry.end = innerStart, innerEnd
+				if innerStart == innerEnd {
+					// An empty <value/> element has no inner text that
+					// could be replaced.
+					skip = true
+				}
+			}
+		case xml.EndElement:
+			depth--
+
+			switch {
+			case depth == 0:
+				f.closeTag = start
+			case depth == 1 && entry != nil:
+				f.elements = append(f.elements, element{name: entry.Key, start: elStart, end: int(dec.InputOffset())})
+				if !skip && strings.TrimSpace(entry.Text) != "" {
+					f.Entries = append(f.Entries, entry)
+				}
+				entry = nil
+			}
+		}
+	}
+
+	if f.closeTag < 0 {
+		return nil, errors.New("missing <root> element")
+	}
+
+	return &f, nil
+}
+
+// Merge adds the string resources of the source file that are missing in the
+// target file to the end of the target file and returns the merged file. The
+// values of the added resources are returned by [File.Untranslated] and still
+// need to be translated. If target is nil, the merged file is a copy of the
+// source file and all of its values are untranslated.
+func Merge(source, target *File) (*File, error) {
+	if target == nil {
+		merged, err := Parse(source.data)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range merged.Entries {
+			e.pending = true
+		}
+		return merged, nil
+	}
+
+	existing := make(map[string]bool, len(target.elements))
+	for _, el := range target.elements {
+		existing[el.name] = true
+	}
+
+	translatable := make(map[string]bool, len(source.Entries))
+	for _, e := range source.Entries {
+		translatable[e.Key] = true
+	}
+
+	indent := elementIndent(target)
+	if indent == "" {
+		indent = elementIndent(source)
+	}
+
+	var (
+		added   bytes.Buffer
+		pending = make(map[string]bool)
+	)
+	for _, el := range source.elements {
+		if existing[el.name] || !translatable[el.name] {
+			continue
+		}
+		added.WriteString(indent)
+		added.Write(source.data[el.start:el.end])
+		added.WriteString("\n")
+		pending[el.name] = true
+	}
+
+	if added.Len() == 0 {
+		return Parse(target.data)
+	}
+
+	// Insert the resources on their own lines before </root>.
+	insertAt := target.closeTag
+	lineStart := bytes.LastIndexByte(target.data[:insertAt], '\n') + 1
+	ownLine := strings.TrimSpace(string(target.data[lineStart:insertAt])) == ""
+	if ownLine {
+		insertAt = lineStart
+	}
+
+	var buf bytes.Buffer
+	buf.Write(target.data[:insertAt])
+	if !ownLine {
+		buf.WriteString("\n")
+	}
+	buf.Write(added.Bytes())
+	buf.Write(target.data[insertAt:])
+
+	merged, err := Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("parse merged file: %w", err)
+	}
+
+	for _, e := range merged.Entries {
+		e.pending = pending[e.Key]
+	}
+
+	return merged, nil
+}
+
+// Untranslated returns the resources that were added by [Merge] and have not
+// been translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if e.pending && !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the resource file with the translations of all translated
+// resources.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		xml.EscapeText(&buf, []byte(e.Text))
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the resource. It returns an error if
+// the format items (e.g. "{0}" or "{1:N2}") of the translation differ from
+// those of the original value.
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text), placeholders(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Key, want, got)
+	}
+
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// placeholders returns the sorted format items of a text.
+func placeholders(text string) []string {
+	out := placeholder.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+// innerText reads the remaining tokens of the current element and returns its
+// unescaped text and the range of its inner XML. It reports false if the
+// element contains other elements.
+func innerText(dec *xml.Decoder) (string, int, int, bool, error) {
+	innerStart := int(dec.InputOffset())
+	innerEnd := innerStart
+
+	var (
+		text strings.Builder
+		ok   = true
+	)
+	for depth := 1; depth > 0; {
+		innerEnd = int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return "", 0, 0, false, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+			ok = false
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			text.Write(tok)
+		}
+	}
+
+	// Self-closing elements have no inner XML.
+	if innerEnd < innerStart {
+		innerEnd = innerStart
+	}
+
+	return text.String(), innerStart, innerEnd, ok, nil
+}
+
+// elementIndent returns the indentation of the first <data> element of a file.
+func elementIndent(f *File) string {
+	if len(f.elements) == 0 {
+		return "  "
+	}
+	offset := f.elements[0].start
+	lineStart := bytes.LastIndexByte(f.data[:offset], '\n') + 1
+	indent := string(f.data[lineStart:offset])
+	if strings.TrimSpace(indent) != "" {
+		return ""
+	}
+	return indent
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package resx_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/resx"
+)
+
+var source = heredoc.Doc(`
+	<?xml version="1.0" encoding="utf-8"?>
+	<root>
+	  <resheader name="resmimetype">
+	    <value>text/microsoft-resx</value>
+	  </resheader>
+	  <data name="Welcome" xml:space="preserve">
+	    <value>Welcome, {0}!</value>
+	    <comment>Title of the welcome screen</comment>
+	  </data>
+	  <data name="Unread" xml:space="preserve">
+	    <value>You have {0:N0} unread messages &amp; {1} drafts.</value>
+	  </data>
+	  <data name="Logo" type="System.Drawing.Bitmap, System.Drawing" mimetype="application/x-microsoft.net.object.bytearray.base64">
+	    <value>iVBORw0KGgo=</value>
+	  </data>
+	  <data name="&gt;&gt;button1.Name" xml:space="preserve">
+	    <value>button1</value>
+	  </data>
+	  <data name="Empty" xml:space="preserve">
+	    <value />
+	  </data>
+	</root>
+`)
+
+func TestParse(t *testing.T) {
+	f, err := resx.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+	}
+
+	want := map[string]string{
+		"Welcome": "Welcome, {0}!",
+		"Unread":  "You have {0:N0} unread messages & {1} drafts.",
+	}
+
+	got := make(map[string]string)
+	for _, e := range f.Entries {
+		got[e.Key] = e.Text
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if want := "Title of the welcome screen"; f.Entries[0].Comment != want {
+		t.Fatalf("Comment should be %q; got %q", want, f.Entries[0].Comment)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	if _, err := resx.Parse([]byte(`<resources></resources>`)); err == nil {
+		t.Errorf("Parse() should fail for an unexpected root element")
+	}
+
+	if _, err := resx.Parse([]byte(`<root><data name="a"><value>a</value></root>`)); err == nil {
+		t.Errorf("Parse() should fail for invalid XML")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	target := heredoc.Doc(`
+		<?xml version="1.0" encoding="utf-8"?>
+		<root>
+		  <resheader name="resmimetype">
+		    <value>text/microsoft-resx</value>
+		  </resheader>
+		  <data name="Welcome" xml:space="preserve">
+		    <value>Willkommen, {0}!</value>
+		  </data>
+		</root>
+	`)
+
+	src, err := resx.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	tgt, err := resx.Parse([]byte(target))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := resx.Merge(src, tgt)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	untranslated := merged.Untranslated()
+	if len(untranslated) != 1 || untranslated[0].Key != "Unread" {
+		t.Fatalf("Untranslated() should return the Unread resource; got %v", untranslated)
+	}
+
+	if err := untranslated[0].SetTranslation("Du hast {0:N0} ungelesene Nachrichten & {1} Entwürfe."); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := heredoc.Doc(`
+		<?xml version="1.0" encoding="utf-8"?>
+		<root>
+		  <resheader name="resmimetype">
+		    <value>text/microsoft-resx</value>
+		  </resheader>
+		  <data name="Welcome" xml:space="preserve">
+		    <value>Willkommen, {0}!</value>
+		  </data>
+		  <data name="Unread" xml:space="preserve">
+		    <value>Du hast {0:N0} ungelesene Nachrichten &amp; {1} Entwürfe.</value>
+		  </data>
+		</root>
+	`)
+
+	if got := string(merged.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestMerge_nilTarget(t *testing.T) {
+	src, err := resx.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	merged, err := resx.Merge(src, nil)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	if got := len(merged.Untranslated()); got != 2 {
+		t.Fatalf("Untranslated() should return 2 resources; got %d", got)
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := resx.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if err := f.Entries[0].SetTranslation("Willkommen!"); err == nil {
+		t.Errorf("SetTranslation() should fail if a placeholder is missing")
+	}
+
+	if err := f.Entries[1].SetTranslation("Du hast {0:N2} ungelesene Nachrichten und {1} Entwürfe."); err == nil {
+		t.Errorf("SetTranslation() should fail if a format string changes")
+	}
+}
@@ -0,0 +1,243 @@
+// Package subtitle parses and writes the cue texts of SubRip (.srt) and WebVTT
+// (.vtt) subtitle files.
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// tag matches the styling tags of cues, like "<i>", "<c.yellow>", "<v Roger>"
+// or the "{\an8}" positioning tags of SubRip files.
+var tag = regexp.MustCompile(`<[^>\n]+>|\{\\[^}\n]*\}`)
+
+// File is a parsed subtitle file. It keeps the original bytes of the file, so
+// that writing it back only replaces the texts of the cues that were set
+// using [Cue.SetTranslation]. Indexes, identifiers, timings, cue settings and
+// the header, styles and notes of WebVTT files are preserved verbatim.
+type File struct {
+	// Cues are the cues of the file in the order of the file.
+	Cues []*Cue
+
+	// MaxLineLength is the maximum number of characters of a line of a
+	// translated cue, not counting styling tags. Longer lines are wrapped at
+	// spaces when the file is written. Zero disables wrapping.
+	MaxLineLength int
+
+	data    []byte
+	newline string
+}
+
+// Cue is a subtitle of a [File].
+type Cue struct {
+	// ID is the index of a SubRip cue or the optional identifier of a WebVTT
+	// cue.
+	ID string
+
+	// Timing is the timing line of the cue (e.g.
+	// "00:00:01,000 --> 00:00:03,500"), including the cue settings of WebVTT
+	// cues.
+	Timing string
+
+	// Text is the text of the cue. Lines are separated by "\n".
+	Text string
+
+	start, end int
+	translated bool
+}
+
+// Parse parses a SubRip or WebVTT file. WebVTT files are recognized by their
+// "WEBVTT" header; blocks of WebVTT files that are not cues, like notes and
+// styles, are skipped.
+func Parse(data []byte) (*File, error) {
+	f := File{data: data, newline: "\n"}
+	if bytes.Contains(data, []byte("\r\n")) {
+		f.newline = "\r\n"
+	}
+
+	body := bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	vtt := bytes.HasPrefix(body, []byte("WEBVTT"))
+
+	var block []line
+	flush := func() error {
+		defer func() { block = block[:0] }()
+		if len(block) == 0 {
+			return nil
+		}
+
+		timing := -1
+		for i := 0; i < len(block) && i < 2; i++ {
+			if strings.Contains(block[i].text, "-->") {
+				timing = i
+				break
+			}
+		}
+
+		if timing < 0 {
+			if vtt {
+				return nil
+			}
+			return fmt.Errorf("line %d: missing timing of cue", block[0].number)
+		}
+
+		cue := Cue{Timing: block[timing].text}
+		if timing > 0 {
+			cue.ID = block[0].text
+		}
+
+		text := block[timing+1:]
+		if len(text) == 0 {
+			return nil
+		}
+
+		lines := make([]string, len(text))
+		for i, l := range text {
+			lines[i] = l.text
+		}
+		cue.Text = strings.Join(lines, "\n")
+		cue.start, cue.end = text[0].start, text[len(text)-1].end
+
+		f.Cues = append(f.Cues, &cue)
+		return nil
+	}
+
+	for _, l := range lines(data) {
+		if strings.TrimSpace(l.text) == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		block = append(block, l)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// Bytes returns the subtitle file with the translations of all translated
+// cues. Lines of translated cues that are longer than the MaxLineLength of the
+// file are wrapped.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, c := range f.Cues {
+		if !c.translated {
+			continue
+		}
+
+		text := c.Text
+		if f.MaxLineLength > 0 {
+			text = wrap(text, f.MaxLineLength)
+		}
+
+		buf.Write(f.data[pos:c.start])
+		buf.WriteString(strings.ReplaceAll(text, "\n", f.newline))
+		pos = c.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the cue. It returns an error if the
+// translation does not keep the styling tags of the original text. Empty
+// lines are removed from the translation, because they would end the cue.
+func (c *Cue) SetTranslation(text string) error {
+	want, got := tags(c.Text), tags(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of cue %q changes the tags %v to %v", c.Timing, want, got)
+	}
+
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, strings.TrimRight(l, " \t"))
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("translation of cue %q is empty", c.Timing)
+	}
+
+	c.Text = strings.Join(lines, "\n")
+	c.translated = true
+
+	return nil
+}
+
+// wrap wraps the lines of a text that are longer than limit at spaces. Words
+// that are longer than the limit are not split.
+func wrap(text string, limit int) string {
+	var out []string
+	for _, l := range strings.Split(text, "\n") {
+		if length(l) <= limit {
+			out = append(out, l)
+			continue
+		}
+
+		var current string
+		for _, word := range strings.Fields(l) {
+			if current != "" && length(current)+1+length(word) > limit {
+				out = append(out, current)
+				current = ""
+			}
+			if current != "" {
+				current += " "
+			}
+			current += word
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
+
+// length returns the number of visible characters of a line.
+func length(line string) int {
+	return utf8.RuneCountInString(tag.ReplaceAllString(line, ""))
+}
+
+// tags returns the sorted styling tags of a text.
+func tags(text string) []string {
+	out := tag.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+type line struct {
+	text       string
+	number     int
+	start, end int
+}
+
+// lines splits data into lines. The text of a line excludes its terminator and
+// a byte order mark at the start of the file.
+func lines(data []byte) []line {
+	var out []line
+	start := 0
+	if bytes.HasPrefix(data, []byte("\xEF\xBB\xBF")) {
+		start = 3
+	}
+
+	for number := 1; start < len(data); number++ {
+		end := bytes.IndexByte(data[start:], '\n')
+		next := start + end + 1
+		if end < 0 {
+			end = len(data)
+			next = len(data)
+		} else {
+			end += start
+		}
+		if end > start && data[end-1] == '\r' {
+			end--
+		}
+
+		out = append(out, line{text: string(data[start:end]), number: number, start: start, end: end})
+		start = next
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package subtitle_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/subtitle"
+)
+
+var srt = heredoc.Doc(`
+	1
+	00:00:01,000 --> 00:00:03,500
+	<i>Hello, world!</i>
+
+	2
+	00:00:04,000 --> 00:00:06,000
+	{\an8}How are you?
+	- Fine, thanks.
+`)
+
+var vtt = heredoc.Doc(`
+	WEBVTT
+
+	NOTE This is a comment --> not a cue.
+
+	STYLE
+	::cue { color: yellow; }
+
+	intro
+	00:01.000 --> 00:03.500 align:start
+	<v Roger>Good morning!
+
+	00:04.000 --> 00:06.000
+	See you later.
+`)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		source string
+		want   []subtitle.Cue
+	}{
+		"srt": {
+			source: srt,
+			want: []subtitle.Cue{
+				{ID: "1", Timing: "00:00:01,000 --> 00:00:03,500", Text: "<i>Hello, world!</i>"},
+				{ID: "2", Timing: "00:00:04,000 --> 00:00:06,000", Text: "{\\an8}How are you?\n- Fine, thanks."},
+			},
+		},
+		"vtt": {
+			source: vtt,
+			want: []subtitle.Cue{
+				{ID: "intro", Timing: "00:01.000 --> 00:03.500 align:start", Text: "<v Roger>Good morning!"},
+				{Timing: "00:04.000 --> 00:06.000", Text: "See you later."},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := subtitle.Parse([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			if got := string(f.Bytes()); got != tt.source {
+				t.Fatalf("Bytes() should return the original file; got\n\n%s", got)
+			}
+
+			var got []subtitle.Cue
+			for _, c := range f.Cues {
+				got = append(got, subtitle.Cue{ID: c.ID, Timing: c.Timing, Text: c.Text})
+			}
+
+			if !cmp.Equal(tt.want, got, cmp.AllowUnexported(subtitle.Cue{})) {
+				t.Fatalf("Cues mismatch (-want +got):\n%s", cmp.Diff(tt.want, got, cmp.AllowUnexported(subtitle.Cue{})))
+			}
+		})
+	}
+}
+
+func TestParse_missingTiming(t *testing.T) {
+	if _, err := subtitle.Parse([]byte("1\nHello, world!\n")); err == nil {
+		t.Fatalf("Parse() should fail for a SubRip cue without timing")
+	}
+}
+
+func TestCue_SetTranslation(t *testing.T) {
+	source := strings.ReplaceAll(srt, "\n", "\r\n")
+	f, err := subtitle.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	f.MaxLineLength = 20
+
+	if err := f.Cues[0].SetTranslation("Hallo, Welt!"); err == nil {
+		t.Fatalf("SetTranslation() should fail if the tags change")
+	}
+
+	if err := f.Cues[0].SetTranslation("<i>Hallo, wunderschöne Welt da draußen!</i>"); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	if err := f.Cues[1].SetTranslation("{\\an8}Wie geht's?\n\n- Gut, danke."); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := strings.ReplaceAll(heredoc.Doc(`
+		1
+		00:00:01,000 --> 00:00:03,500
+		<i>Hallo, wunderschöne
+		Welt da draußen!</i>
+
+		2
+		00:00:04,000 --> 00:00:06,000
+		{\an8}Wie geht's?
+		- Gut, danke.
+	`), "\n", "\r\n")
+
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
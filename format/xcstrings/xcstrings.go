@@ -0,0 +1,377 @@
+package xcstrings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var formatSpecifier = regexp.MustCompile(`%#@[^@]+@|%(\d+\$)?[-#+ 0']*(\d+|\*)?(\.(\d+|\*))?(hh|h|ll|l|q|L|z|t|j)?[@dDiuUxXoOfFeEgGcCsSpaA%]`)
+
+// Catalog is a parsed string catalog (".xcstrings"). The order of all keys is
+// preserved, and the catalog is written back in the formatting of Xcode, so
+// that translating a catalog only adds the new localizations to the file.
+type Catalog struct {
+	// SourceLanguage is the language of the keys of the catalog (e.g. "en").
+	SourceLanguage string
+
+	root    *node
+	newline bool
+	pending []*localization
+}
+
+// Entry is a text of a string catalog that needs to be translated into a
+// locale.
+type Entry struct {
+	// Key is the key of the string in the catalog.
+	Key string
+
+	// Variation is the path of the variation of the string, for example
+	// "plural.one" or "device.iphone". It is empty for strings without
+	// variations.
+	Variation string
+
+	// Text is the text in the source language.
+	Text string
+
+	unit       *node
+	translated bool
+}
+
+// localization is a pending localization of a string.
+type localization struct {
+	key     string
+	locale  string
+	value   *node
+	entries []*Entry
+}
+
+// node is a JSON value that preserves the order of object keys.
+type node struct {
+	// kind is '{' for objects, '[' for arrays and 0 for all other values.
+	kind   byte
+	keys   []string
+	values []*node
+	raw    string
+}
+
+// Parse parses a string catalog.
+func Parse(data []byte) (*Catalog, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	root, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("decode JSON: %w", err)
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("decode JSON: unexpected data after catalog")
+	}
+
+	if root.kind != '{' || root.get("strings") == nil {
+		return nil, errors.New("missing \"strings\" of string catalog")
+	}
+
+	c := Catalog{
+		root:    root,
+		newline: bytes.HasSuffix(data, []byte("\n")),
+	}
+
+	if lang := root.get("sourceLanguage"); lang != nil {
+		json.Unmarshal([]byte(lang.raw), &c.SourceLanguage)
+	}
+
+	return &c, nil
+}
+
+// Untranslated returns the texts of the strings that have no localization for
+// the given locale yet. Strings that are marked with "shouldTranslate": false
+// are skipped. If the source localization of a string has variations (e.g.
+// plurals), the localization is created with the same variations, and every
+// variation is returned as an [Entry]. A localization is only added to the
+// catalog when all of its entries were translated.
+func (c *Catalog) Untranslated(locale string) []*Entry {
+	strs := c.root.get("strings")
+
+	var out []*Entry
+	for i, key := range strs.keys {
+		str := strs.values[i]
+		if str.kind != '{' {
+			continue
+		}
+
+		if should := str.get("shouldTranslate"); should != nil && should.raw == "false" {
+			continue
+		}
+
+		if locs := str.get("localizations"); locs != nil && locs.get(locale) != nil {
+			continue
+		}
+
+		if loc := c.pendingLocalization(key, locale); loc != nil {
+			for _, e := range loc.entries {
+				if !e.translated {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+
+		var source *node
+		if locs := str.get("localizations"); locs != nil {
+			source = locs.get(c.SourceLanguage)
+		}
+		if source == nil {
+			source = &node{kind: '{', keys: []string{"stringUnit"}, values: []*node{{
+				kind:   '{',
+				keys:   []string{"state", "value"},
+				values: []*node{stringNode("translated"), stringNode(key)},
+			}}}
+		}
+
+		loc := &localization{key: key, locale: locale, value: source.clone()}
+		collectUnits(loc, loc.value, nil)
+		if len(loc.entries) == 0 {
+			continue
+		}
+
+		c.pending = append(c.pending, loc)
+		out = append(out, loc.entries...)
+	}
+
+	return out
+}
+
+func (c *Catalog) pendingLocalization(key, locale string) *localization {
+	for _, loc := range c.pending {
+		if loc.key == key && loc.locale == locale {
+			return loc
+		}
+	}
+	return nil
+}
+
+// collectUnits adds an entry for every string unit below the given node.
+func collectUnits(loc *localization, n *node, path []string) {
+	if n.kind != '{' {
+		return
+	}
+
+	for i, key := range n.keys {
+		value := n.values[i]
+		if key != "stringUnit" {
+			next := path
+			if key != "variations" && key != "substitutions" {
+				next = append(append([]string(nil), path...), key)
+			}
+			collectUnits(loc, value, next)
+			continue
+		}
+
+		var text string
+		if v := value.get("value"); v != nil {
+			json.Unmarshal([]byte(v.raw), &text)
+		}
+
+		loc.entries = append(loc.entries, &Entry{
+			Key:       loc.key,
+			Variation: strings.Join(path, "."),
+			Text:      text,
+			unit:      value,
+		})
+	}
+}
+
+// SetTranslation sets the translation of the entry. It returns an error if the
+// format specifiers (e.g. "%@" or "%lld") of the translation differ from those
+// of the source text.
+func (e *Entry) SetTranslation(text string) error {
+	want, got := placeholders(e.Text), placeholders(text)
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("translation of %q changes the placeholders %v to %v", e.Key, want, got)
+	}
+
+	e.unit.set("state", stringNode("translated"))
+	e.unit.set("value", stringNode(text))
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// Bytes returns the string catalog with all localizations whose entries were
+// translated, formatted like Xcode formats string catalogs.
+func (c *Catalog) Bytes() []byte {
+	root := c.root.clone()
+	strs := root.get("strings")
+
+	for _, loc := range c.pending {
+		if !loc.done() {
+			continue
+		}
+
+		str := strs.get(loc.key)
+		locs := str.get("localizations")
+		if locs == nil {
+			locs = &node{kind: '{'}
+			str.insertSorted("localizations", locs)
+		}
+		locs.insertSorted(loc.locale, loc.value)
+	}
+
+	var buf bytes.Buffer
+	root.write(&buf, "")
+	if c.newline {
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+func (loc *localization) done() bool {
+	for _, e := range loc.entries {
+		if !e.translated {
+			return false
+		}
+	}
+	return true
+}
+
+// placeholders returns the sorted format specifiers of a text.
+func placeholders(text string) []string {
+	out := formatSpecifier.FindAllString(text, -1)
+	sort.Strings(out)
+	return out
+}
+
+func decode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		n := &node{kind: byte(tok)}
+		for dec.More() {
+			if n.kind == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return stringNode(tok), nil
+	case json.Number:
+		return &node{raw: tok.String()}, nil
+	case bool:
+		return &node{raw: fmt.Sprint(tok)}, nil
+	default:
+		return &node{raw: "null"}, nil
+	}
+}
+
+func stringNode(s string) *node {
+	return &node{raw: quote(s)}
+}
+
+// quote encodes a string like Xcode: HTML characters and slashes are not
+// escaped.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (n *node) get(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+func (n *node) set(key string, value *node) {
+	for i, k := range n.keys {
+		if k == key {
+			n.values[i] = value
+			return
+		}
+	}
+	n.insertSorted(key, value)
+}
+
+// insertSorted inserts a key before the first key that sorts after it, so
+// that the keys of objects that were sorted by Xcode stay sorted.
+func (n *node) insertSorted(key string, value *node) {
+	i := 0
+	for i < len(n.keys) && n.keys[i] < key {
+		i++
+	}
+	n.keys = append(n.keys[:i], append([]string{key}, n.keys[i:]...)...)
+	n.values = append(n.values[:i], append([]*node{value}, n.values[i:]...)...)
+}
+
+func (n *node) clone() *node {
+	out := &node{kind: n.kind, raw: n.raw, keys: append([]string(nil), n.keys...)}
+	for _, v := range n.values {
+		out.values = append(out.values, v.clone())
+	}
+	return out
+}
+
+// write writes the node in the formatting of Xcode: two spaces of
+// indentation, " : " between keys and values, and empty objects and arrays
+// spanning two lines.
+func (n *node) write(buf *bytes.Buffer, indent string) {
+	if n.kind == 0 {
+		buf.WriteString(n.raw)
+		return
+	}
+
+	closer := byte('}')
+	if n.kind == '[' {
+		closer = ']'
+	}
+
+	buf.WriteByte(n.kind)
+	buf.WriteByte('\n')
+	if len(n.values) == 0 {
+		buf.WriteByte('\n')
+	}
+	for i, value := range n.values {
+		buf.WriteString(indent + "  ")
+		if n.kind == '{' {
+			buf.WriteString(quote(n.keys[i]))
+			buf.WriteString(" : ")
+		}
+		value.write(buf, indent+"  ")
+		if i < len(n.values)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(indent)
+	buf.WriteByte(closer)
+}
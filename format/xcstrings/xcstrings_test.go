@@ -0,0 +1,211 @@
+package xcstrings_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/xcstrings"
+)
+
+var catalog = heredoc.Doc(`
+	{
+	  "sourceLanguage" : "en",
+	  "strings" : {
+	    "%lld items" : {
+	      "localizations" : {
+	        "en" : {
+	          "variations" : {
+	            "plural" : {
+	              "one" : {
+	                "stringUnit" : {
+	                  "state" : "translated",
+	                  "value" : "%lld item"
+	                }
+	              },
+	              "other" : {
+	                "stringUnit" : {
+	                  "state" : "translated",
+	                  "value" : "%lld items"
+	                }
+	              }
+	            }
+	          }
+	        }
+	      }
+	    },
+	    "Dragoman" : {
+	      "shouldTranslate" : false
+	    },
+	    "Hello, %@!" : {
+
+	    },
+	    "See https://example.com/<docs>" : {
+	      "localizations" : {
+	        "de" : {
+	          "stringUnit" : {
+	            "state" : "translated",
+	            "value" : "Siehe https://example.com/<docs>"
+	          }
+	        }
+	      }
+	    }
+	  },
+	  "version" : "1.0"
+	}
+`)
+
+func TestParse(t *testing.T) {
+	c, err := xcstrings.Parse([]byte(catalog))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if c.SourceLanguage != "en" {
+		t.Fatalf("SourceLanguage should be %q; is %q", "en", c.SourceLanguage)
+	}
+
+	if got := string(c.Bytes()); got != catalog {
+		t.Fatalf("Bytes() should return the original catalog (-want +got):\n%s", cmp.Diff(catalog, got))
+	}
+}
+
+func TestCatalog_Untranslated(t *testing.T) {
+	c, err := xcstrings.Parse([]byte(catalog))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	translations := map[string]string{
+		"%lld items/plural.one":   "%lld Element",
+		"%lld items/plural.other": "%lld Elemente",
+		"Hello, %@!/":             "Hallo, %@!",
+	}
+
+	var keys []string
+	for _, e := range c.Untranslated("de") {
+		key := e.Key + "/" + e.Variation
+		keys = append(keys, key)
+		if err := e.SetTranslation(translations[key]); err != nil {
+			t.Fatalf("SetTranslation(): %v", err)
+		}
+	}
+
+	wantKeys := []string{"%lld items/plural.one", "%lld items/plural.other", "Hello, %@!/"}
+	if !cmp.Equal(wantKeys, keys) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(wantKeys, keys))
+	}
+
+	if untranslated := c.Untranslated("de"); len(untranslated) != 0 {
+		t.Fatalf("Untranslated() should return no entries after translating; got %d", len(untranslated))
+	}
+
+	want := heredoc.Doc(`
+		{
+		  "sourceLanguage" : "en",
+		  "strings" : {
+		    "%lld items" : {
+		      "localizations" : {
+		        "de" : {
+		          "variations" : {
+		            "plural" : {
+		              "one" : {
+		                "stringUnit" : {
+		                  "state" : "translated",
+		                  "value" : "%lld Element"
+		                }
+		              },
+		              "other" : {
+		                "stringUnit" : {
+		                  "state" : "translated",
+		                  "value" : "%lld Elemente"
+		                }
+		              }
+		            }
+		          }
+		        },
+		        "en" : {
+		          "variations" : {
+		            "plural" : {
+		              "one" : {
+		                "stringUnit" : {
+		                  "state" : "translated",
+		                  "value" : "%lld item"
+		                }
+		              },
+		              "other" : {
+		                "stringUnit" : {
+		                  "state" : "translated",
+		                  "value" : "%lld items"
+		                }
+		              }
+		            }
+		          }
+		        }
+		      }
+		    },
+		    "Dragoman" : {
+		      "shouldTranslate" : false
+		    },
+		    "Hello, %@!" : {
+		      "localizations" : {
+		        "de" : {
+		          "stringUnit" : {
+		            "state" : "translated",
+		            "value" : "Hallo, %@!"
+		          }
+		        }
+		      }
+		    },
+		    "See https://example.com/<docs>" : {
+		      "localizations" : {
+		        "de" : {
+		          "stringUnit" : {
+		            "state" : "translated",
+		            "value" : "Siehe https://example.com/<docs>"
+		          }
+		        }
+		      }
+		    }
+		  },
+		  "version" : "1.0"
+		}
+	`)
+
+	if got := string(c.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestCatalog_Bytes_partial(t *testing.T) {
+	c, err := xcstrings.Parse([]byte(catalog))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	entries := c.Untranslated("fr")
+	if err := entries[0].SetTranslation("%lld élément"); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	if got := string(c.Bytes()); got != catalog {
+		t.Fatalf("Bytes() should not add incomplete localizations (-want +got):\n%s", cmp.Diff(catalog, got))
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	c, err := xcstrings.Parse([]byte(catalog))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	entries := c.Untranslated("de")
+
+	if err := entries[0].SetTranslation("%d Element"); err == nil {
+		t.Errorf("SetTranslation() should fail if a format specifier changes")
+	}
+
+	if err := entries[2].SetTranslation("Hallo!"); err == nil {
+		t.Errorf("SetTranslation() should fail if a placeholder is missing")
+	}
+}
@@ -0,0 +1,354 @@
+package xliff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StateTranslated is the state that is set on translated units.
+const StateTranslated = "translated"
+
+var stateAttr = regexp.MustCompile(`\sstate\s*=\s*("[^"]*"|'[^']*')`)
+
+// inlineTags are the inline elements of XLIFF 1.2 and 2.0 that mark
+// placeholders and formatting within a text. They must be kept intact by a
+// translation.
+var inlineTags = map[string]bool{
+	"ph": true, "g": true, "x": true, "bx": true, "ex": true, "bpt": true,
+	"ept": true, "it": true, "sub": true, "mrk": true, "pc": true, "sc": true,
+	"ec": true, "sm": true, "em": true,
+}
+
+// File is a parsed XLIFF 1.2 or 2.0 document. It keeps the original bytes of
+// the document, so that writing it back only inserts or replaces the targets
+// that were set using [Unit.SetTranslation]. Everything else, including
+// comments, notes and formatting, is preserved verbatim.
+type File struct {
+	// Version is the XLIFF version of the document ("1.2" or "2.0").
+	Version string
+
+	// Units are the translatable units of the document.
+	Units []*Unit
+
+	data []byte
+}
+
+// Unit is a translatable text of a [File]. In XLIFF 1.2 documents, a Unit is a
+// <trans-unit> element; in XLIFF 2.0 documents, it is a <segment> of a <unit>.
+type Unit struct {
+	// ID is the id of the unit. The ids of XLIFF 2.0 segments are appended to
+	// the id of their unit, separated by a slash.
+	ID string
+
+	// Source is the inner XML of the <source> element, including inline
+	// placeholder tags.
+	Source string
+
+	// Target is the inner XML of the <target> element, or an empty string if
+	// the unit has no target.
+	Target string
+
+	version     string
+	translated  bool
+	indent      string
+	insertAt    int
+	targetStart int
+	targetEnd   int
+	stateStart  int
+	stateEnd    int
+}
+
+// Parse parses an XLIFF 1.2 or 2.0 document.
+func Parse(data []byte) (*File, error) {
+	f := File{data: data}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+
+	var (
+		stack   []string
+		unitID  string
+		skip    bool
+		current *Unit
+	)
+
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, tok.Name.Local)
+
+			switch {
+			case tok.Name.Local == "xliff":
+				f.Version = attr(tok, "version")
+			case tok.Name.Local == "trans-unit" && f.Version != "2.0":
+				skip = attr(tok, "translate") == "no"
+				if !skip {
+					current = f.newUnit(attr(tok, "id"), -1, -1)
+				}
+			case tok.Name.Local == "unit" && f.Version == "2.0":
+				unitID = attr(tok, "id")
+				skip = attr(tok, "translate") == "no"
+			case tok.Name.Local == "segment" && f.Version == "2.0" && !skip:
+				id := unitID
+				if segmentID := attr(tok, "id"); segmentID != "" {
+					id += "/" + segmentID
+				}
+				current = f.newUnit(id, start, int(dec.InputOffset()))
+			case current != nil && (parent == "trans-unit" || parent == "segment"):
+				switch tok.Name.Local {
+				case "source":
+					inner, end, err := innerXML(dec, data)
+					if err != nil {
+						return nil, err
+					}
+					stack = stack[:len(stack)-1]
+					current.Source = inner
+					current.indent = lineIndent(data, start)
+					current.insertAt = end
+				case "seg-source":
+					if err := dec.Skip(); err != nil {
+						return nil, fmt.Errorf("decode XML: %w", err)
+					}
+					stack = stack[:len(stack)-1]
+					current.insertAt = int(dec.InputOffset())
+				case "target":
+					inner, end, err := innerXML(dec, data)
+					if err != nil {
+						return nil, err
+					}
+					stack = stack[:len(stack)-1]
+					current.Target = inner
+					current.targetStart = start
+					current.targetEnd = end
+				}
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			switch tok.Name.Local {
+			case "trans-unit", "segment":
+				current = nil
+			case "unit":
+				skip = false
+			}
+		}
+	}
+
+	if f.Version == "" {
+		return nil, errors.New("missing <xliff> root element")
+	}
+
+	return &f, nil
+}
+
+func (f *File) newUnit(id string, tagStart, tagEnd int) *Unit {
+	u := &Unit{
+		ID:          id,
+		version:     f.Version,
+		targetStart: -1,
+		targetEnd:   -1,
+		stateStart:  tagStart,
+		stateEnd:    tagEnd,
+	}
+	f.Units = append(f.Units, u)
+	return u
+}
+
+// Bytes returns the XLIFF document with the targets of all translated units.
+func (f *File) Bytes() []byte {
+	type edit struct {
+		start, end int
+		text       string
+	}
+
+	var edits []edit
+	for _, u := range f.Units {
+		if !u.translated {
+			continue
+		}
+
+		target := u.targetElement()
+		if u.targetStart >= 0 {
+			edits = append(edits, edit{u.targetStart, u.targetEnd, target})
+		} else {
+			if u.indent != "" {
+				target = "\n" + u.indent + target
+			}
+			edits = append(edits, edit{u.insertAt, u.insertAt, target})
+		}
+
+		if u.version == "2.0" && u.stateStart >= 0 {
+			tag := string(f.data[u.stateStart:u.stateEnd])
+			edits = append(edits, edit{u.stateStart, u.stateEnd, withState(tag)})
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range edits {
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(e.text)
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+
+	return buf.Bytes()
+}
+
+// Untranslated returns the units that have no target or an empty target.
+func (f *File) Untranslated() []*Unit {
+	var out []*Unit
+	for _, u := range f.Units {
+		if !u.Translated() {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// Translated reports whether the unit has a non-empty target.
+func (u *Unit) Translated() bool {
+	return strings.TrimSpace(u.Target) != ""
+}
+
+// SetTranslation sets the target of the unit to the given inner XML and marks
+// the unit as translated. It returns an error if the target is not well-formed
+// or if its inline placeholder tags differ from those of the source.
+func (u *Unit) SetTranslation(target string) error {
+	want, err := placeholders(u.Source)
+	if err != nil {
+		return fmt.Errorf("source of unit %q: %w", u.ID, err)
+	}
+
+	got, err := placeholders(target)
+	if err != nil {
+		return fmt.Errorf("target of unit %q: %w", u.ID, err)
+	}
+
+	if strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Errorf("target of unit %q changes the placeholders %v to %v", u.ID, want, got)
+	}
+
+	u.Target = target
+	u.translated = true
+
+	return nil
+}
+
+func (u *Unit) targetElement() string {
+	if u.version == "2.0" {
+		return "<target>" + u.Target + "</target>"
+	}
+	return `<target state="` + StateTranslated + `">` + u.Target + "</target>"
+}
+
+// placeholders returns the sorted inline tags of an inner XML text, identified
+// by their name and id.
+func placeholders(inner string) ([]string, error) {
+	dec := xml.NewDecoder(strings.NewReader("<x>" + inner + "</x>"))
+	dec.Strict = true
+
+	var out []string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		if start, ok := tok.(xml.StartElement); ok && inlineTags[start.Name.Local] {
+			out = append(out, start.Name.Local+"#"+attr(start, "id"))
+		}
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
+
+// innerXML reads the remaining tokens of the current element and returns its
+// inner XML and the offset after its end tag.
+func innerXML(dec *xml.Decoder, data []byte) (string, int, error) {
+	innerStart := int(dec.InputOffset())
+	innerEnd := innerStart
+
+	for depth := 1; depth > 0; {
+		innerEnd = int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return "", 0, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	end := int(dec.InputOffset())
+
+	// Self-closing elements have no inner XML.
+	if innerEnd < innerStart {
+		innerEnd = innerStart
+	}
+
+	return string(data[innerStart:innerEnd]), end, nil
+}
+
+func withState(tag string) string {
+	if stateAttr.MatchString(tag) {
+		return stateAttr.ReplaceAllString(tag, ` state="`+StateTranslated+`"`)
+	}
+
+	end := len(tag) - 1
+	if strings.HasSuffix(tag, "/>") {
+		end--
+	}
+
+	return tag[:end] + ` state="` + StateTranslated + `"` + tag[end:]
+}
+
+// lineIndent returns the indentation of the line that contains the given
+// offset, or an empty string if the offset is not preceded only by whitespace
+// on its line.
+func lineIndent(data []byte, offset int) string {
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	indent := string(data[lineStart:offset])
+	if strings.TrimSpace(indent) != "" {
+		return ""
+	}
+	return indent
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package xliff_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/xliff"
+)
+
+func TestParse_v12(t *testing.T) {
+	source := heredoc.Doc(`
+		<?xml version="1.0" encoding="UTF-8"?>
+		<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+		  <file source-language="en" target-language="de" datatype="plaintext" original="messages">
+		    <body>
+		      <trans-unit id="greeting">
+		        <source>Hello, <ph id="1">{name}</ph>!</source>
+		      </trans-unit>
+		      <trans-unit id="bye">
+		        <source>Goodbye</source>
+		        <target state="final">Auf Wiedersehen</target>
+		      </trans-unit>
+		      <trans-unit id="empty">
+		        <source>Save <g id="1">now</g></source>
+		        <target/>
+		      </trans-unit>
+		      <trans-unit id="brand" translate="no">
+		        <source>Dragoman</source>
+		      </trans-unit>
+		    </body>
+		  </file>
+		</xliff>
+	`)
+
+	f, err := xliff.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if f.Version != "1.2" {
+		t.Fatalf("Version should be %q; is %q", "1.2", f.Version)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original document; got\n\n%s", got)
+	}
+
+	untranslated := f.Untranslated()
+	wantSources := []string{`Hello, <ph id="1">{name}</ph>!`, `Save <g id="1">now</g>`}
+	if got := sources(untranslated); !cmp.Equal(wantSources, got) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(wantSources, got))
+	}
+
+	if err := untranslated[0].SetTranslation(`Hallo, <ph id="1">{name}</ph>!`); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+	if err := untranslated[1].SetTranslation(`<g id="1">Jetzt</g> speichern`); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := heredoc.Doc(`
+		<?xml version="1.0" encoding="UTF-8"?>
+		<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+		  <file source-language="en" target-language="de" datatype="plaintext" original="messages">
+		    <body>
+		      <trans-unit id="greeting">
+		        <source>Hello, <ph id="1">{name}</ph>!</source>
+		        <target state="translated">Hallo, <ph id="1">{name}</ph>!</target>
+		      </trans-unit>
+		      <trans-unit id="bye">
+		        <source>Goodbye</source>
+		        <target state="final">Auf Wiedersehen</target>
+		      </trans-unit>
+		      <trans-unit id="empty">
+		        <source>Save <g id="1">now</g></source>
+		        <target state="translated"><g id="1">Jetzt</g> speichern</target>
+		      </trans-unit>
+		      <trans-unit id="brand" translate="no">
+		        <source>Dragoman</source>
+		      </trans-unit>
+		    </body>
+		  </file>
+		</xliff>
+	`)
+
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestParse_v20(t *testing.T) {
+	source := heredoc.Doc(`
+		<?xml version="1.0" encoding="UTF-8"?>
+		<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="fr">
+		  <file id="f1">
+		    <unit id="u1">
+		      <segment id="s1" state="initial">
+		        <source>Click <pc id="1">here</pc></source>
+		      </segment>
+		    </unit>
+		    <unit id="u2">
+		      <segment>
+		        <source>Done</source>
+		        <target>Terminé</target>
+		      </segment>
+		    </unit>
+		  </file>
+		</xliff>
+	`)
+
+	f, err := xliff.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	untranslated := f.Untranslated()
+	if len(untranslated) != 1 || untranslated[0].ID != "u1/s1" {
+		t.Fatalf("Untranslated() should return unit %q; got %v", "u1/s1", untranslated)
+	}
+
+	if err := untranslated[0].SetTranslation(`Cliquez <pc id="1">ici</pc>`); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := heredoc.Doc(`
+		<?xml version="1.0" encoding="UTF-8"?>
+		<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="fr">
+		  <file id="f1">
+		    <unit id="u1">
+		      <segment id="s1" state="translated">
+		        <source>Click <pc id="1">here</pc></source>
+		        <target>Cliquez <pc id="1">ici</pc></target>
+		      </segment>
+		    </unit>
+		    <unit id="u2">
+		      <segment>
+		        <source>Done</source>
+		        <target>Terminé</target>
+		      </segment>
+		    </unit>
+		  </file>
+		</xliff>
+	`)
+
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestUnit_SetTranslation_placeholders(t *testing.T) {
+	f, err := xliff.Parse([]byte(`<xliff version="1.2"><file><body><trans-unit id="a"><source>Hi <ph id="1"/></source></trans-unit></body></file></xliff>`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	unit := f.Units[0]
+
+	if err := unit.SetTranslation("Hallo"); err == nil {
+		t.Fatalf("SetTranslation() should fail if a placeholder is missing")
+	}
+
+	if err := unit.SetTranslation(`Hallo <ph id="1">`); err == nil {
+		t.Fatalf("SetTranslation() should fail if the target is not well-formed")
+	}
+
+	if unit.Translated() {
+		t.Fatalf("unit should not be translated after failed SetTranslation() calls")
+	}
+
+	if err := unit.SetTranslation(`Hallo <ph id="1"/>`); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+
+	want := `<xliff version="1.2"><file><body><trans-unit id="a"><source>Hi <ph id="1"/></source><target state="translated">Hallo <ph id="1"/></target></trans-unit></body></file></xliff>`
+	if got := string(f.Bytes()); got != want {
+		t.Fatalf("Bytes(): got %q; want %q", got, want)
+	}
+}
+
+func sources(units []*xliff.Unit) []string {
+	out := make([]string, len(units))
+	for i, u := range units {
+		out[i] = u.Source
+	}
+	return out
+}
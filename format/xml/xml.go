@@ -0,0 +1,466 @@
+// Package xml parses and writes the texts of arbitrary XML documents, like
+// DITA topics or DocBook chapters, whose translatable elements and attributes
+// are selected by paths. Only the selected texts are replaced when the
+// document is written, so namespaces, comments, CDATA sections, entity
+// references and formatting are preserved verbatim.
+package xml
+
+import (
+	"bytes"
+	stdxml "encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// entityRef matches entity references like "&product;".
+var entityRef = regexp.MustCompile(`&([A-Za-z_:][A-Za-z0-9_.:-]*);`)
+
+// predefined are the entities that every XML document knows.
+var predefined = map[string]bool{"amp": true, "lt": true, "gt": true, "quot": true, "apos": true}
+
+// Selectors select the translatable texts of a document.
+type Selectors struct {
+	// Paths select the elements whose content is translated, as element names
+	// separated by slashes, e.g. "product/description". A path matches the
+	// elements whose innermost ancestors have the given names; a leading
+	// slash anchors it at the root element. "*" matches any element. Names
+	// match with or without their namespace prefix, e.g. "dita:p" or "p".
+	Paths []string
+
+	// Attributes select the translatable attributes as a path followed by "@"
+	// and the name of the attribute, e.g. "item@label". An empty path, like in
+	// "@title", matches any element.
+	Attributes []string
+}
+
+// File is a parsed XML document. It keeps the original bytes of the document,
+// so that writing it back only replaces the texts that were set using
+// [Entry.SetTranslation].
+type File struct {
+	// Entries are the selected texts of the document in the order of the
+	// document. Elements within selected elements are part of the text of the
+	// outermost selected element.
+	Entries []*Entry
+
+	data      []byte
+	selectors Selectors
+}
+
+// Entry is a selected text of a [File].
+type Entry struct {
+	// Key identifies the text within the document, like
+	// "/catalog/product[2]/description" for the second <product> of the
+	// root element or "/catalog/item[1]@label" for an attribute.
+	Key string
+
+	// Text is the inner XML of an element without its surrounding
+	// whitespace, or the value of an attribute, as written in the document.
+	// Inline markup, entity references and character references are kept. The
+	// content of an element that consists of a single CDATA section is
+	// unwrapped.
+	Text string
+
+	start, end int
+	attr       byte
+	cdata      bool
+	pending    bool
+	translated bool
+}
+
+// frame is an open element of the document.
+type frame struct {
+	key      string
+	names    []string
+	children map[string]int
+	selected bool
+}
+
+// Parse parses an XML document and selects its texts.
+func Parse(data []byte, selectors Selectors) (*File, error) {
+	f := File{data: data, selectors: selectors}
+
+	dec := stdxml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+	dec.Entity = entities(data)
+
+	var (
+		stack  []*frame
+		starts []int
+	)
+
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode XML: %w", err)
+		}
+
+		switch tok.(type) {
+		case stdxml.StartElement:
+			end := int(dec.InputOffset())
+			tag := data[start:end]
+			name := tagName(tag)
+
+			parent := &frame{children: make(map[string]int)}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			parent.children[name]++
+
+			fr := &frame{
+				key:      parent.key + "/" + name,
+				names:    append(slices.Clone(parent.names), name),
+				children: make(map[string]int),
+				selected: parent.selected,
+			}
+			if len(stack) > 0 {
+				fr.key += "[" + strconv.Itoa(parent.children[name]) + "]"
+			}
+
+			if !fr.selected {
+				for _, a := range rawAttrs(tag) {
+					if selectors.matchAttr(fr.names, a.name) && strings.TrimSpace(string(tag[a.start:a.end])) != "" {
+						f.Entries = append(f.Entries, &Entry{
+							Key:   fr.key + "@" + a.name,
+							Text:  string(tag[a.start:a.end]),
+							start: start + a.start,
+							end:   start + a.end,
+							attr:  a.quote,
+						})
+					}
+				}
+				if selectors.matchPath(fr.names) {
+					fr.selected = true
+					// The entry of the element is added when it is closed,
+					// after the entries of its attributes.
+					f.Entries = append(f.Entries, &Entry{Key: fr.key, start: -1})
+				}
+			}
+
+			stack = append(stack, fr)
+			starts = append(starts, end)
+		case stdxml.EndElement:
+			fr := stack[len(stack)-1]
+			innerStart := starts[len(starts)-1]
+			stack, starts = stack[:len(stack)-1], starts[:len(starts)-1]
+
+			if fr.selected && (len(stack) == 0 || !stack[len(stack)-1].selected) {
+				f.closeElement(fr.key, innerStart, start)
+			}
+		}
+	}
+
+	f.Entries = slices.DeleteFunc(f.Entries, func(e *Entry) bool {
+		return e.start < 0
+	})
+
+	return &f, nil
+}
+
+// closeElement sets the text of the entry of a selected element to its inner
+// XML, or removes the entry if the element has no text.
+func (f *File) closeElement(key string, innerStart, innerEnd int) {
+	idx := slices.IndexFunc(f.Entries, func(e *Entry) bool {
+		return e.Key == key && e.start < 0
+	})
+	if idx < 0 || innerEnd <= innerStart {
+		return
+	}
+	e := f.Entries[idx]
+
+	inner := string(f.data[innerStart:innerEnd])
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		return
+	}
+	e.start = innerStart + strings.Index(inner, trimmed)
+	e.end = e.start + len(trimmed)
+	e.Text = trimmed
+
+	if text, ok := strings.CutPrefix(trimmed, "<![CDATA["); ok && strings.Index(text, "]]>") == len(text)-3 {
+		e.Text = strings.TrimSuffix(text, "]]>")
+		e.start += len("<![CDATA[")
+		e.end -= len("]]>")
+		e.cdata = true
+	}
+}
+
+// Merge returns a copy of the source document whose selected texts are the
+// texts of the target document with the same keys. The texts that the target
+// does not have are returned by [File.Untranslated] and still need to be
+// translated. If target is nil, all texts are untranslated.
+func Merge(source, target *File) (*File, error) {
+	merged, err := Parse(source.data, source.selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]*Entry)
+	if target != nil {
+		for _, e := range target.Entries {
+			existing[e.Key] = e
+		}
+	}
+
+	for _, e := range merged.Entries {
+		t, ok := existing[e.Key]
+		if !ok || t.attr != 0 != (e.attr != 0) {
+			e.pending = true
+			continue
+		}
+		if err := e.SetTranslation(t.Text); err != nil {
+			e.pending = true
+		}
+	}
+
+	return merged, nil
+}
+
+// Untranslated returns the texts that were not taken from the target by
+// [Merge] and have not been translated yet.
+func (f *File) Untranslated() []*Entry {
+	var out []*Entry
+	for _, e := range f.Entries {
+		if e.pending && !e.translated {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Bytes returns the document with the translations of all translated texts.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range f.Entries {
+		if !e.translated {
+			continue
+		}
+		buf.Write(f.data[pos:e.start])
+		buf.WriteString(e.Text)
+		pos = e.end
+	}
+	buf.Write(f.data[pos:])
+	return buf.Bytes()
+}
+
+// SetTranslation sets the translation of the text. Bare ampersands, and in
+// attributes also angle brackets and the quotes of the attribute, are
+// escaped. It returns an error if the translation is not well-formed or
+// changes the elements or the custom entity references of the text.
+func (e *Entry) SetTranslation(text string) error {
+	switch {
+	case e.cdata:
+		if strings.Contains(text, "]]>") {
+			return fmt.Errorf("translation of %q ends its CDATA section", e.Key)
+		}
+	case e.attr != 0:
+		text = escapeAttr(text, e.attr)
+	default:
+		text = escapeAmpersands(text)
+	}
+
+	if !e.cdata {
+		want, err := markup(e.Text)
+		if err != nil {
+			return fmt.Errorf("text of %q: %w", e.Key, err)
+		}
+		got, err := markup(text)
+		if err != nil {
+			return fmt.Errorf("translation of %q is not well-formed: %w", e.Key, err)
+		}
+		if !slices.Equal(want, got) {
+			return fmt.Errorf("translation of %q changes the markup %v to %v", e.Key, want, got)
+		}
+	}
+
+	e.Text = text
+	e.translated = true
+
+	return nil
+}
+
+// markup returns the sorted element names and custom entity references of an
+// XML fragment.
+func markup(fragment string) ([]string, error) {
+	dec := stdxml.NewDecoder(strings.NewReader("<x>" + fragment + "</x>"))
+	dec.Strict = true
+	dec.Entity = entities([]byte(fragment))
+
+	var out []string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if el, ok := tok.(stdxml.StartElement); ok {
+			out = append(out, "<"+el.Name.Local+">")
+		}
+	}
+	out = out[1:]
+
+	for _, m := range entityRef.FindAllStringSubmatch(fragment, -1) {
+		if !predefined[m[1]] {
+			out = append(out, m[0])
+		}
+	}
+
+	slices.Sort(out)
+	return out, nil
+}
+
+// entities returns the custom entities that are referenced in the data, so
+// that the decoder accepts entities that are declared in a DTD.
+func entities(data []byte) map[string]string {
+	out := make(map[string]string)
+	for _, m := range entityRef.FindAllSubmatch(data, -1) {
+		if name := string(m[1]); !predefined[name] {
+			out[name] = "&" + name + ";"
+		}
+	}
+	return out
+}
+
+// escapeAmpersands escapes the ampersands of a text that do not start an
+// entity or character reference.
+func escapeAmpersands(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '&' && !isReference(text[i:]) {
+			b.WriteString("&amp;")
+			continue
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// escapeAttr escapes a text for an attribute value that is quoted with the
+// given quote.
+func escapeAttr(text string, quote byte) string {
+	text = strings.NewReplacer("<", "&lt;", string(quote), map[byte]string{'"': "&quot;", '\'': "&apos;"}[quote]).Replace(escapeAmpersands(text))
+	return text
+}
+
+var reference = regexp.MustCompile(`^&(?:[A-Za-z_:][A-Za-z0-9_.:-]*|#[0-9]+|#x[0-9A-Fa-f]+);`)
+
+func isReference(text string) bool {
+	return reference.MatchString(text)
+}
+
+// rawAttr is an attribute of a raw start tag. start and end are the offsets of
+// its value within the tag.
+type rawAttr struct {
+	name       string
+	start, end int
+	quote      byte
+}
+
+// tagName returns the qualified name of a raw start tag.
+func tagName(tag []byte) string {
+	end := bytes.IndexAny(tag[1:], " \t\r\n/>") + 1
+	return string(tag[1:end])
+}
+
+// rawAttrs returns the attributes of a raw start tag.
+func rawAttrs(tag []byte) []rawAttr {
+	var out []rawAttr
+	i := 1 + len(tagName(tag))
+	for i < len(tag) {
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if i >= len(tag) || tag[i] == '/' || tag[i] == '>' {
+			break
+		}
+
+		nameStart := i
+		for i < len(tag) && tag[i] != '=' && !isSpace(tag[i]) {
+			i++
+		}
+		name := string(tag[nameStart:i])
+
+		for i < len(tag) && (isSpace(tag[i]) || tag[i] == '=') {
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+
+		quote := tag[i]
+		end := bytes.IndexByte(tag[i+1:], quote)
+		if end < 0 {
+			break
+		}
+		out = append(out, rawAttr{name: name, start: i + 1, end: i + 1 + end, quote: quote})
+		i += end + 2
+	}
+	return out
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// matchPath reports whether the element with the given ancestors, ending
+// with the element itself, is selected by one of the paths.
+func (s Selectors) matchPath(names []string) bool {
+	for _, path := range s.Paths {
+		if matchNames(path, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAttr reports whether the attribute of the element with the given
+// ancestors is selected.
+func (s Selectors) matchAttr(names []string, attr string) bool {
+	for _, selector := range s.Attributes {
+		path, name, ok := strings.Cut(selector, "@")
+		if !ok || !matchName(name, attr) {
+			continue
+		}
+		if path == "" || matchNames(path, names) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchNames(path string, names []string) bool {
+	anchored := strings.HasPrefix(path, "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > len(names) || anchored && len(segments) != len(names) {
+		return false
+	}
+
+	names = names[len(names)-len(segments):]
+	for i, segment := range segments {
+		if segment != "*" && !matchName(segment, names[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchName reports whether a name of a selector matches a qualified name of
+// the document. Names without a prefix match names with any prefix.
+func matchName(selector, name string) bool {
+	if selector == name {
+		return true
+	}
+	_, local, ok := strings.Cut(name, ":")
+	return ok && !strings.Contains(selector, ":") && selector == local
+}
@@ -0,0 +1,234 @@
+package xml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/format/xml"
+)
+
+var source = heredoc.Doc(`
+	<?xml version="1.0" encoding="utf-8"?>
+	<!DOCTYPE catalog SYSTEM "catalog.dtd">
+	<catalog xmlns:d="urn:docs">
+	  <!-- Products -->
+	  <product id="p1">
+	    <name>Lamp</name>
+	    <d:description>
+	      A <b>bright</b> lamp by &company; &amp; friends.
+	    </d:description>
+	    <item label="Red &amp; blue" sku="1"/>
+	  </product>
+	  <product id="p2">
+	    <d:description><![CDATA[Use <lamp> & enjoy]]></d:description>
+	    <item label='Green' sku="2"/>
+	    <item label="" sku="3"/>
+	  </product>
+	  <description>   </description>
+	</catalog>
+`)
+
+var selectors = xml.Selectors{
+	Paths:      []string{"product/description"},
+	Attributes: []string{"item@label"},
+}
+
+func TestParse(t *testing.T) {
+	f, err := xml.Parse([]byte(source), selectors)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if got := string(f.Bytes()); got != source {
+		t.Fatalf("Bytes() should return the original document; got\n\n%s", got)
+	}
+
+	want := map[string]string{
+		"/catalog/product[1]/d:description[1]": "A <b>bright</b> lamp by &company; &amp; friends.",
+		"/catalog/product[1]/item[1]@label":    "Red &amp; blue",
+		"/catalog/product[2]/d:description[1]": "Use <lamp> & enjoy",
+		"/catalog/product[2]/item[1]@label":    "Green",
+	}
+
+	got := make(map[string]string)
+	for _, e := range f.Entries {
+		got[e.Key] = e.Text
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Entries mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestParse_selectors(t *testing.T) {
+	tests := map[string]struct {
+		selectors xml.Selectors
+		want      []string
+	}{
+		"anchored": {
+			selectors: xml.Selectors{Paths: []string{"/catalog/product/name"}},
+			want:      []string{"/catalog/product[1]/name[1]"},
+		},
+		"anchored mismatch": {
+			selectors: xml.Selectors{Paths: []string{"/product/name"}},
+		},
+		"wildcard": {
+			selectors: xml.Selectors{Paths: []string{"product/*"}},
+			want: []string{
+				"/catalog/product[1]/name[1]",
+				"/catalog/product[1]/d:description[1]",
+				"/catalog/product[2]/d:description[1]",
+			},
+		},
+		"prefixed": {
+			selectors: xml.Selectors{Paths: []string{"d:description"}},
+			want: []string{
+				"/catalog/product[1]/d:description[1]",
+				"/catalog/product[2]/d:description[1]",
+			},
+		},
+		"outermost element": {
+			selectors: xml.Selectors{Paths: []string{"product", "b"}, Attributes: []string{"@label"}},
+			want:      []string{"/catalog/product[1]", "/catalog/product[2]"},
+		},
+		"any attribute": {
+			selectors: xml.Selectors{Attributes: []string{"@id"}},
+			want:      []string{"/catalog/product[1]@id", "/catalog/product[2]@id"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := xml.Parse([]byte(source), tt.selectors)
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			var got []string
+			for _, e := range f.Entries {
+				got = append(got, e.Key)
+			}
+
+			if !cmp.Equal(tt.want, got) {
+				t.Fatalf("Keys mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestEntry_SetTranslation(t *testing.T) {
+	f, err := xml.Parse([]byte(source), selectors)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	translations := []string{
+		"Eine <b>helle</b> Lampe von &company; & Freunden.",
+		`Rot & "blau"`,
+		"<lamp> benutzen & genießen",
+		"Grün's",
+	}
+	for i, text := range translations {
+		if err := f.Entries[i].SetTranslation(text); err != nil {
+			t.Fatalf("SetTranslation(%q): %v", text, err)
+		}
+	}
+
+	got := string(f.Bytes())
+	for _, want := range []string{
+		"<d:description>\n      Eine <b>helle</b> Lampe von &company; &amp; Freunden.\n    </d:description>",
+		`<item label="Rot &amp; &quot;blau&quot;" sku="1"/>`,
+		"<d:description><![CDATA[<lamp> benutzen & genießen]]></d:description>",
+		`<item label='Grün&apos;s' sku="2"/>`,
+		`<catalog xmlns:d="urn:docs">`,
+		"<!-- Products -->",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Bytes() should contain %q; got\n\n%s", want, got)
+		}
+	}
+}
+
+func TestEntry_SetTranslation_invalid(t *testing.T) {
+	tests := map[string]string{
+		"not well-formed":  "Eine <b>helle Lampe von &company;.",
+		"missing element":  "Eine helle Lampe von &company;.",
+		"added element":    "Eine <b>helle</b> <i>Lampe</i> von &company;.",
+		"missing entity":   "Eine <b>helle</b> Lampe von ACME.",
+		"unknown entities": "Eine <b>helle</b> Lampe von &company; &acme;.",
+	}
+
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := xml.Parse([]byte(source), selectors)
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			if err := f.Entries[0].SetTranslation(text); err == nil {
+				t.Fatalf("SetTranslation(%q) should fail", text)
+			}
+
+			if got := string(f.Bytes()); got != source {
+				t.Fatalf("Bytes() should return the original document; got\n\n%s", got)
+			}
+		})
+	}
+
+	f, err := xml.Parse([]byte(source), selectors)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	if err := f.Entries[2].SetTranslation("x ]]> y"); err == nil {
+		t.Fatal("SetTranslation() should fail for a translation that ends the CDATA section")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	sourceFile, err := xml.Parse([]byte(source), selectors)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	target := strings.NewReplacer(
+		"A <b>bright</b> lamp by &company; &amp; friends.", "Eine <b>helle</b> Lampe von &company;.",
+		`label="Red &amp; blue"`, `label="Rot"`,
+		"<product id=\"p2\">", "<other>",
+		"</product>\n  <description>", "</other>\n  <description>",
+	).Replace(source)
+
+	targetFile, err := xml.Parse([]byte(target), selectors)
+	if err != nil {
+		t.Fatalf("Parse(target): %v", err)
+	}
+
+	f, err := xml.Merge(sourceFile, targetFile)
+	if err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+
+	var pending []string
+	for _, e := range f.Untranslated() {
+		pending = append(pending, e.Key)
+	}
+	want := []string{"/catalog/product[2]/d:description[1]", "/catalog/product[2]/item[1]@label"}
+	if !cmp.Equal(want, pending) {
+		t.Fatalf("Untranslated() mismatch (-want +got):\n%s", cmp.Diff(want, pending))
+	}
+
+	got := string(f.Bytes())
+	for _, want := range []string{"Eine <b>helle</b> Lampe von &company;.", `label="Rot"`, `<product id="p2">`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Bytes() should contain %q; got\n\n%s", want, got)
+		}
+	}
+
+	if err := f.Untranslated()[0].SetTranslation("Genießen"); err != nil {
+		t.Fatalf("SetTranslation(): %v", err)
+	}
+	if len(f.Untranslated()) != 1 {
+		t.Fatalf("Untranslated() should return 1 entry; got %d", len(f.Untranslated()))
+	}
+}
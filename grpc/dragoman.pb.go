@@ -0,0 +1,596 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: dragoman.proto
+
+package grpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TranslateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The document to translate.
+	Document string `protobuf:"bytes,1,opt,name=document,proto3" json:"document,omitempty"`
+	// Language of the document. Detected by the model if empty.
+	Source string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	// Language to translate the document to. Defaults to English.
+	Target string `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	// Terms that must not be translated.
+	Preserve []string `protobuf:"bytes,4,rep,name=preserve,proto3" json:"preserve,omitempty"`
+	// Additional instructions that are included in the prompt.
+	Instructions []string `protobuf:"bytes,5,rep,name=instructions,proto3" json:"instructions,omitempty"`
+	// Read-only reference documents, like brand guides or glossaries.
+	Context []string `protobuf:"bytes,6,rep,name=context,proto3" json:"context,omitempty"`
+	// Prefixes of the lines at which the document is split into chunks.
+	SplitChunks []string `protobuf:"bytes,7,rep,name=split_chunks,json=splitChunks,proto3" json:"split_chunks,omitempty"`
+	// Formality of the translation ("formal" or "informal").
+	Formality string `protobuf:"bytes,8,opt,name=formality,proto3" json:"formality,omitempty"`
+}
+
+func (x *TranslateRequest) Reset() {
+	*x = TranslateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dragoman_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TranslateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranslateRequest) ProtoMessage() {}
+
+func (x *TranslateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dragoman_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranslateRequest.ProtoReflect.Descriptor instead.
+func (*TranslateRequest) Descriptor() ([]byte, []int) {
+	return file_dragoman_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TranslateRequest) GetDocument() string {
+	if x != nil {
+		return x.Document
+	}
+	return ""
+}
+
+func (x *TranslateRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TranslateRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *TranslateRequest) GetPreserve() []string {
+	if x != nil {
+		return x.Preserve
+	}
+	return nil
+}
+
+func (x *TranslateRequest) GetInstructions() []string {
+	if x != nil {
+		return x.Instructions
+	}
+	return nil
+}
+
+func (x *TranslateRequest) GetContext() []string {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *TranslateRequest) GetSplitChunks() []string {
+	if x != nil {
+		return x.SplitChunks
+	}
+	return nil
+}
+
+func (x *TranslateRequest) GetFormality() string {
+	if x != nil {
+		return x.Formality
+	}
+	return ""
+}
+
+type TranslateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The translated document.
+	Translation string `protobuf:"bytes,1,opt,name=translation,proto3" json:"translation,omitempty"`
+}
+
+func (x *TranslateResponse) Reset() {
+	*x = TranslateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dragoman_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TranslateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranslateResponse) ProtoMessage() {}
+
+func (x *TranslateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dragoman_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranslateResponse.ProtoReflect.Descriptor instead.
+func (*TranslateResponse) Descriptor() ([]byte, []int) {
+	return file_dragoman_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TranslateResponse) GetTranslation() string {
+	if x != nil {
+		return x.Translation
+	}
+	return ""
+}
+
+type TranslateChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 1-based number of the chunk.
+	Chunk int32 `protobuf:"varint,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	// Number of chunks of the document.
+	Chunks int32 `protobuf:"varint,2,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// The translation of the chunk.
+	Translation string `protobuf:"bytes,3,opt,name=translation,proto3" json:"translation,omitempty"`
+}
+
+func (x *TranslateChunk) Reset() {
+	*x = TranslateChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dragoman_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TranslateChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranslateChunk) ProtoMessage() {}
+
+func (x *TranslateChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_dragoman_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranslateChunk.ProtoReflect.Descriptor instead.
+func (*TranslateChunk) Descriptor() ([]byte, []int) {
+	return file_dragoman_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TranslateChunk) GetChunk() int32 {
+	if x != nil {
+		return x.Chunk
+	}
+	return 0
+}
+
+func (x *TranslateChunk) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *TranslateChunk) GetTranslation() string {
+	if x != nil {
+		return x.Translation
+	}
+	return ""
+}
+
+type ImproveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The document to improve.
+	Document string `protobuf:"bytes,1,opt,name=document,proto3" json:"document,omitempty"`
+	// Language the improved document is written in.
+	Language string `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	// Formality of the improved document ("formal" or "informal").
+	Formality string `protobuf:"bytes,3,opt,name=formality,proto3" json:"formality,omitempty"`
+	// SEO keywords that are used in the improved document.
+	Keywords []string `protobuf:"bytes,4,rep,name=keywords,proto3" json:"keywords,omitempty"`
+	// Additional instructions that are included in the prompt.
+	Instructions []string `protobuf:"bytes,5,rep,name=instructions,proto3" json:"instructions,omitempty"`
+	// Read-only reference documents, like brand guides or glossaries.
+	Context []string `protobuf:"bytes,6,rep,name=context,proto3" json:"context,omitempty"`
+	// Prefixes of the lines at which the document is split into chunks.
+	SplitChunks []string `protobuf:"bytes,7,rep,name=split_chunks,json=splitChunks,proto3" json:"split_chunks,omitempty"`
+	// Keep the headings of the document unchanged.
+	PreserveOutline bool `protobuf:"varint,8,opt,name=preserve_outline,json=preserveOutline,proto3" json:"preserve_outline,omitempty"`
+}
+
+func (x *ImproveRequest) Reset() {
+	*x = ImproveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dragoman_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImproveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImproveRequest) ProtoMessage() {}
+
+func (x *ImproveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dragoman_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImproveRequest.ProtoReflect.Descriptor instead.
+func (*ImproveRequest) Descriptor() ([]byte, []int) {
+	return file_dragoman_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ImproveRequest) GetDocument() string {
+	if x != nil {
+		return x.Document
+	}
+	return ""
+}
+
+func (x *ImproveRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ImproveRequest) GetFormality() string {
+	if x != nil {
+		return x.Formality
+	}
+	return ""
+}
+
+func (x *ImproveRequest) GetKeywords() []string {
+	if x != nil {
+		return x.Keywords
+	}
+	return nil
+}
+
+func (x *ImproveRequest) GetInstructions() []string {
+	if x != nil {
+		return x.Instructions
+	}
+	return nil
+}
+
+func (x *ImproveRequest) GetContext() []string {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *ImproveRequest) GetSplitChunks() []string {
+	if x != nil {
+		return x.SplitChunks
+	}
+	return nil
+}
+
+func (x *ImproveRequest) GetPreserveOutline() bool {
+	if x != nil {
+		return x.PreserveOutline
+	}
+	return false
+}
+
+type ImproveResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The improved document.
+	Document string `protobuf:"bytes,1,opt,name=document,proto3" json:"document,omitempty"`
+}
+
+func (x *ImproveResponse) Reset() {
+	*x = ImproveResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dragoman_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImproveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImproveResponse) ProtoMessage() {}
+
+func (x *ImproveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dragoman_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImproveResponse.ProtoReflect.Descriptor instead.
+func (*ImproveResponse) Descriptor() ([]byte, []int) {
+	return file_dragoman_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ImproveResponse) GetDocument() string {
+	if x != nil {
+		return x.Document
+	}
+	return ""
+}
+
+var File_dragoman_proto protoreflect.FileDescriptor
+
+var file_dragoman_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x64, 0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0b, 0x64, 0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x22, 0xf9, 0x01,
+	0x0a, 0x10, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x6e,
+	0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x70, 0x6c, 0x69,
+	0x74, 0x5f, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b,
+	0x73, 0x70, 0x6c, 0x69, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x66,
+	0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x22, 0x35, 0x0a, 0x11, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x20,
+	0x0a, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x60, 0x0a, 0x0e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x43, 0x68, 0x75,
+	0x6e, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x68, 0x75, 0x6e,
+	0x6b, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x73,
+	0x12, 0x20, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0x8e, 0x02, 0x0a, 0x0e, 0x49, 0x6d, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x6b,
+	0x65, 0x79, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x6b,
+	0x65, 0x79, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x72,
+	0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x69,
+	0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x78, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x5f, 0x63,
+	0x68, 0x75, 0x6e, 0x6b, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x70, 0x6c,
+	0x69, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x65, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x5f, 0x6f, 0x75, 0x74, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0f, 0x70, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x4f, 0x75, 0x74, 0x6c,
+	0x69, 0x6e, 0x65, 0x22, 0x2d, 0x0a, 0x0f, 0x49, 0x6d, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65,
+	0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65,
+	0x6e, 0x74, 0x32, 0xed, 0x01, 0x0a, 0x08, 0x44, 0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x12,
+	0x4a, 0x0a, 0x09, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x1d, 0x2e, 0x64,
+	0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x64, 0x72,
+	0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6c,
+	0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0f, 0x54,
+	0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1d,
+	0x2e, 0x64, 0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e,
+	0x64, 0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x6c, 0x61, 0x74, 0x65, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x07,
+	0x49, 0x6d, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x12, 0x1b, 0x2e, 0x64, 0x72, 0x61, 0x67, 0x6f, 0x6d,
+	0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x64, 0x72, 0x61, 0x67, 0x6f, 0x6d, 0x61, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x49, 0x6d, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x24, 0x5a, 0x22, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x6e, 0x69, 0x63, 0x65, 0x2f, 0x64, 0x72, 0x61, 0x67, 0x6f,
+	0x6d, 0x61, 0x6e, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_dragoman_proto_rawDescOnce sync.Once
+	file_dragoman_proto_rawDescData = file_dragoman_proto_rawDesc
+)
+
+func file_dragoman_proto_rawDescGZIP() []byte {
+	file_dragoman_proto_rawDescOnce.Do(func() {
+		file_dragoman_proto_rawDescData = protoimpl.X.CompressGZIP(file_dragoman_proto_rawDescData)
+	})
+	return file_dragoman_proto_rawDescData
+}
+
+var file_dragoman_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_dragoman_proto_goTypes = []interface{}{
+	(*TranslateRequest)(nil),  // 0: dragoman.v1.TranslateRequest
+	(*TranslateResponse)(nil), // 1: dragoman.v1.TranslateResponse
+	(*TranslateChunk)(nil),    // 2: dragoman.v1.TranslateChunk
+	(*ImproveRequest)(nil),    // 3: dragoman.v1.ImproveRequest
+	(*ImproveResponse)(nil),   // 4: dragoman.v1.ImproveResponse
+}
+var file_dragoman_proto_depIdxs = []int32{
+	0, // 0: dragoman.v1.Dragoman.Translate:input_type -> dragoman.v1.TranslateRequest
+	0, // 1: dragoman.v1.Dragoman.TranslateStream:input_type -> dragoman.v1.TranslateRequest
+	3, // 2: dragoman.v1.Dragoman.Improve:input_type -> dragoman.v1.ImproveRequest
+	1, // 3: dragoman.v1.Dragoman.Translate:output_type -> dragoman.v1.TranslateResponse
+	2, // 4: dragoman.v1.Dragoman.TranslateStream:output_type -> dragoman.v1.TranslateChunk
+	4, // 5: dragoman.v1.Dragoman.Improve:output_type -> dragoman.v1.ImproveResponse
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_dragoman_proto_init() }
+func file_dragoman_proto_init() {
+	if File_dragoman_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_dragoman_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TranslateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dragoman_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TranslateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dragoman_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TranslateChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dragoman_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImproveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dragoman_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImproveResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_dragoman_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dragoman_proto_goTypes,
+		DependencyIndexes: file_dragoman_proto_depIdxs,
+		MessageInfos:      file_dragoman_proto_msgTypes,
+	}.Build()
+	File_dragoman_proto = out.File
+	file_dragoman_proto_rawDesc = nil
+	file_dragoman_proto_goTypes = nil
+	file_dragoman_proto_depIdxs = nil
+}
@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: dragoman.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Dragoman_Translate_FullMethodName       = "/dragoman.v1.Dragoman/Translate"
+	Dragoman_TranslateStream_FullMethodName = "/dragoman.v1.Dragoman/TranslateStream"
+	Dragoman_Improve_FullMethodName         = "/dragoman.v1.Dragoman/Improve"
+)
+
+// DragomanClient is the client API for Dragoman service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DragomanClient interface {
+	// Translate translates a document and returns the whole translation.
+	Translate(ctx context.Context, in *TranslateRequest, opts ...grpc.CallOption) (*TranslateResponse, error)
+	// TranslateStream translates a document and streams the translation of each
+	// chunk as soon as it is done.
+	TranslateStream(ctx context.Context, in *TranslateRequest, opts ...grpc.CallOption) (Dragoman_TranslateStreamClient, error)
+	// Improve improves a document and returns the improved document.
+	Improve(ctx context.Context, in *ImproveRequest, opts ...grpc.CallOption) (*ImproveResponse, error)
+}
+
+type dragomanClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDragomanClient(cc grpc.ClientConnInterface) DragomanClient {
+	return &dragomanClient{cc}
+}
+
+func (c *dragomanClient) Translate(ctx context.Context, in *TranslateRequest, opts ...grpc.CallOption) (*TranslateResponse, error) {
+	out := new(TranslateResponse)
+	err := c.cc.Invoke(ctx, Dragoman_Translate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dragomanClient) TranslateStream(ctx context.Context, in *TranslateRequest, opts ...grpc.CallOption) (Dragoman_TranslateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Dragoman_ServiceDesc.Streams[0], Dragoman_TranslateStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dragomanTranslateStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Dragoman_TranslateStreamClient interface {
+	Recv() (*TranslateChunk, error)
+	grpc.ClientStream
+}
+
+type dragomanTranslateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *dragomanTranslateStreamClient) Recv() (*TranslateChunk, error) {
+	m := new(TranslateChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dragomanClient) Improve(ctx context.Context, in *ImproveRequest, opts ...grpc.CallOption) (*ImproveResponse, error) {
+	out := new(ImproveResponse)
+	err := c.cc.Invoke(ctx, Dragoman_Improve_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DragomanServer is the server API for Dragoman service.
+// All implementations must embed UnimplementedDragomanServer
+// for forward compatibility
+type DragomanServer interface {
+	// Translate translates a document and returns the whole translation.
+	Translate(context.Context, *TranslateRequest) (*TranslateResponse, error)
+	// TranslateStream translates a document and streams the translation of each
+	// chunk as soon as it is done.
+	TranslateStream(*TranslateRequest, Dragoman_TranslateStreamServer) error
+	// Improve improves a document and returns the improved document.
+	Improve(context.Context, *ImproveRequest) (*ImproveResponse, error)
+	mustEmbedUnimplementedDragomanServer()
+}
+
+// UnimplementedDragomanServer must be embedded to have forward compatible implementations.
+type UnimplementedDragomanServer struct {
+}
+
+func (UnimplementedDragomanServer) Translate(context.Context, *TranslateRequest) (*TranslateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Translate not implemented")
+}
+func (UnimplementedDragomanServer) TranslateStream(*TranslateRequest, Dragoman_TranslateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method TranslateStream not implemented")
+}
+func (UnimplementedDragomanServer) Improve(context.Context, *ImproveRequest) (*ImproveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Improve not implemented")
+}
+func (UnimplementedDragomanServer) mustEmbedUnimplementedDragomanServer() {}
+
+// UnsafeDragomanServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DragomanServer will
+// result in compilation errors.
+type UnsafeDragomanServer interface {
+	mustEmbedUnimplementedDragomanServer()
+}
+
+func RegisterDragomanServer(s grpc.ServiceRegistrar, srv DragomanServer) {
+	s.RegisterService(&Dragoman_ServiceDesc, srv)
+}
+
+func _Dragoman_Translate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TranslateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DragomanServer).Translate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Dragoman_Translate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DragomanServer).Translate(ctx, req.(*TranslateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dragoman_TranslateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TranslateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DragomanServer).TranslateStream(m, &dragomanTranslateStreamServer{stream})
+}
+
+type Dragoman_TranslateStreamServer interface {
+	Send(*TranslateChunk) error
+	grpc.ServerStream
+}
+
+type dragomanTranslateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *dragomanTranslateStreamServer) Send(m *TranslateChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Dragoman_Improve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImproveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DragomanServer).Improve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Dragoman_Improve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DragomanServer).Improve(ctx, req.(*ImproveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Dragoman_ServiceDesc is the grpc.ServiceDesc for Dragoman service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Dragoman_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dragoman.v1.Dragoman",
+	HandlerType: (*DragomanServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Translate",
+			Handler:    _Dragoman_Translate_Handler,
+		},
+		{
+			MethodName: "Improve",
+			Handler:    _Dragoman_Improve_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TranslateStream",
+			Handler:       _Dragoman_TranslateStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "dragoman.proto",
+}
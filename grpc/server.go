@@ -0,0 +1,150 @@
+// Package grpc exposes dragoman as a gRPC service, so that backend services can
+// run a long-running dragoman daemon instead of invoking the CLI. The service
+// is defined in dragoman.proto, from which clients can be generated for any
+// language. The message types and the client and server stubs of this package
+// are generated from it with protoc-gen-go and protoc-gen-go-grpc.
+//
+// [Server] implements the service. It is registered on a
+// [google.golang.org/grpc.Server], which provides TLS, authentication,
+// interceptors and the rest of the gRPC stack:
+//
+//	s := grpc.NewServer(grpc.Creds(creds))
+//	dragomangrpc.RegisterDragomanServer(s, dragomangrpc.NewServer(translator, improver))
+//	s.Serve(lis)
+//
+// Importing this package registers the gzip compressor, so clients may send
+// gzip-compressed messages.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative dragoman.proto
+
+import (
+	"context"
+	"errors"
+
+	"github.com/modernice/dragoman"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Dragoman gRPC service.
+type Server struct {
+	UnimplementedDragomanServer
+
+	translator *dragoman.Translator
+	improver   *dragoman.Improver
+}
+
+// NewServer returns a [Server] that translates documents using the given
+// translator and improves documents using the given improver.
+func NewServer(translator *dragoman.Translator, improver *dragoman.Improver) *Server {
+	return &Server{translator: translator, improver: improver}
+}
+
+// Translate translates a document and returns the whole translation.
+func (s *Server) Translate(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+	params, err := translateParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	translation, err := s.translator.Translate(ctx, params)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &TranslateResponse{Translation: translation}, nil
+}
+
+// TranslateStream translates a document and sends the translation of each
+// chunk as soon as it is done.
+func (s *Server) TranslateStream(req *TranslateRequest, stream Dragoman_TranslateStreamServer) error {
+	params, err := translateParams(req)
+	if err != nil {
+		return err
+	}
+
+	// The translator cannot be stopped from OnChunkDone, so a failed send
+	// cancels the context of the translation instead.
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	var sendErr error
+	params.OnChunkDone = func(p dragoman.ChunkProgress) {
+		if sendErr != nil {
+			return
+		}
+
+		chunk := &TranslateChunk{Chunk: int32(p.Chunk), Chunks: int32(p.Chunks), Translation: p.Translation}
+		if sendErr = stream.Send(chunk); sendErr != nil {
+			cancel()
+		}
+	}
+
+	_, err = s.translator.TranslateChunks(ctx, params)
+	if sendErr != nil {
+		return sendErr
+	}
+	return statusError(err)
+}
+
+// Improve improves a document and returns the improved document.
+func (s *Server) Improve(ctx context.Context, req *ImproveRequest) (*ImproveResponse, error) {
+	formality, err := formality(req.GetFormality())
+	if err != nil {
+		return nil, err
+	}
+
+	improved, err := s.improver.Improve(ctx, dragoman.ImproveParams{
+		Document:        req.GetDocument(),
+		SplitChunks:     req.GetSplitChunks(),
+		Formality:       formality,
+		Keywords:        req.GetKeywords(),
+		Instructions:    req.GetInstructions(),
+		Language:        req.GetLanguage(),
+		Context:         req.GetContext(),
+		PreserveOutline: req.GetPreserveOutline(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &ImproveResponse{Document: improved}, nil
+}
+
+func translateParams(req *TranslateRequest) (dragoman.TranslateParams, error) {
+	formality, err := formality(req.GetFormality())
+	if err != nil {
+		return dragoman.TranslateParams{}, err
+	}
+
+	return dragoman.TranslateParams{
+		Document:     req.GetDocument(),
+		Source:       req.GetSource(),
+		Target:       req.GetTarget(),
+		Preserve:     req.GetPreserve(),
+		Instructions: req.GetInstructions(),
+		Context:      req.GetContext(),
+		SplitChunks:  req.GetSplitChunks(),
+		Formality:    formality,
+	}, nil
+}
+
+func formality(value string) (dragoman.Formality, error) {
+	switch f := dragoman.Formality(value); f {
+	case dragoman.FormalityUnspecified, dragoman.FormalityFormal, dragoman.FormalityInformal:
+		return f, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "invalid formality %q", value)
+	}
+}
+
+// statusError reports canceled and timed out calls with their gRPC status
+// codes. Other errors are reported as [codes.Unknown] by the gRPC server.
+func statusError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+	dragomangrpc "github.com/modernice/dragoman/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestServer_Translate(t *testing.T) {
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		if !strings.Contains(prompt, "to German") || !strings.Contains(prompt, "Use formal language") {
+			t.Errorf("prompt should contain the target language and formality; got\n\n%s", prompt)
+		}
+		return "Hallo, Welt!", nil
+	})
+	client := newClient(t, model)
+
+	resp, err := client.Translate(context.Background(), &dragomangrpc.TranslateRequest{
+		Document:  "Hello, world!",
+		Target:    "German",
+		Formality: string(dragoman.FormalityFormal),
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	if want := "Hallo, Welt!\n"; resp.GetTranslation() != want {
+		t.Fatalf("Translation should be %q; got %q", want, resp.GetTranslation())
+	}
+}
+
+func TestServer_TranslateStream(t *testing.T) {
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		if strings.Contains(prompt, "# Second") {
+			return "# Zweiter", nil
+		}
+		return "# Erster", nil
+	})
+	client := newClient(t, model)
+
+	stream, err := client.TranslateStream(context.Background(), &dragomangrpc.TranslateRequest{
+		Document:    "# First\n\n# Second\n",
+		Target:      "German",
+		SplitChunks: []string{"#"},
+	})
+	if err != nil {
+		t.Fatalf("TranslateStream(): %v", err)
+	}
+
+	var chunks []*dragomangrpc.TranslateChunk
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv(): %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	want := []*dragomangrpc.TranslateChunk{
+		{Chunk: 1, Chunks: 2, Translation: "# Erster"},
+		{Chunk: 2, Chunks: 2, Translation: "# Zweiter"},
+	}
+	if !cmp.Equal(want, chunks, protocmp.Transform()) {
+		t.Fatalf("TranslateStream returned unexpected chunks (-want +got):\n%s", cmp.Diff(want, chunks, protocmp.Transform()))
+	}
+}
+
+func TestServer_Improve(t *testing.T) {
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		if !strings.Contains(prompt, "dragoman") {
+			t.Errorf("prompt should contain the keywords; got\n\n%s", prompt)
+		}
+		return "An improved document.", nil
+	})
+	client := newClient(t, model)
+
+	resp, err := client.Improve(context.Background(), &dragomangrpc.ImproveRequest{
+		Document:  "A document.",
+		Keywords:  []string{"dragoman"},
+		Formality: string(dragoman.FormalityFormal),
+	})
+	if err != nil {
+		t.Fatalf("Improve(): %v", err)
+	}
+
+	if want := "An improved document.\n"; resp.GetDocument() != want {
+		t.Fatalf("Document should be %q; got %q", want, resp.GetDocument())
+	}
+}
+
+func TestServer_gzip(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		return "Hallo", nil
+	})
+	client := newClient(t, model)
+
+	resp, err := client.Translate(context.Background(), &dragomangrpc.TranslateRequest{Document: "Hello"}, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		t.Fatalf("Translate() with gzip compression: %v", err)
+	}
+
+	if want := "Hallo\n"; resp.GetTranslation() != want {
+		t.Fatalf("Translation should be %q; got %q", want, resp.GetTranslation())
+	}
+}
+
+func TestServer_errors(t *testing.T) {
+	model := dragoman.ModelFunc(func(ctx context.Context, _ string) (string, error) {
+		if _, ok := ctx.Deadline(); ok {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "", errors.New("model unavailable")
+	})
+	client := newClient(t, model)
+
+	canceled, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		call    func() error
+		code    codes.Code
+		message string
+	}{
+		{"model error", func() error {
+			_, err := client.Translate(context.Background(), &dragomangrpc.TranslateRequest{Document: "Hello"})
+			return err
+		}, codes.Unknown, "model unavailable"},
+		{"invalid formality", func() error {
+			_, err := client.Improve(context.Background(), &dragomangrpc.ImproveRequest{Document: "Hello", Formality: "casual"})
+			return err
+		}, codes.InvalidArgument, "invalid formality"},
+		{"deadline exceeded", func() error {
+			_, err := client.Translate(canceled, &dragomangrpc.TranslateRequest{Document: "Hello"})
+			return err
+		}, codes.DeadlineExceeded, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			if st.Code() != tt.code {
+				t.Fatalf("status should be %s; got %s (%s)", tt.code, st.Code(), st.Message())
+			}
+			if !strings.Contains(st.Message(), tt.message) {
+				t.Fatalf("message should contain %q; got %q", tt.message, st.Message())
+			}
+		})
+	}
+}
+
+// newClient serves the service in memory and returns a client of it.
+func newClient(t *testing.T, model dragoman.Model) dragomangrpc.DragomanClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	dragomangrpc.RegisterDragomanServer(server, dragomangrpc.NewServer(dragoman.NewTranslator(model), dragoman.NewImprover(model)))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return dragomangrpc.NewDragomanClient(conn)
+}
@@ -0,0 +1,522 @@
+package dragoman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultHTMLAttributes are the attributes whose values are translated if no
+// attributes are configured for [ParseHTML].
+var DefaultHTMLAttributes = []string{"alt", "title", "placeholder", "aria-label"}
+
+// htmlLanguageAttributes are the attributes that declare the language and
+// direction of the text of an element.
+var htmlLanguageAttributes = []string{"lang", "dir"}
+
+// HTMLUpdate describes the changes between a new version of an HTML source
+// document and an existing translation of that document. It is created by
+// [HTMLDiff] and knows which text nodes of the new source can reuse their
+// existing translation and which text nodes still need to be translated. After
+// translating the pending texts, [HTMLUpdate.Render] splices them into the new
+// source document to produce the updated translation.
+type HTMLUpdate struct {
+	doc     *html.Node
+	nodes   []*html.Node
+	reuse   map[int]string
+	pending map[int]string
+}
+
+type htmlSegment struct {
+	node *html.Node
+	path string
+	text string
+}
+
+// HTMLDiff compares the new version of an HTML source document against an
+// existing translation of the previous version and returns an [*HTMLUpdate]
+// that describes which text nodes must be translated. If the previous version
+// of the source document is provided, text nodes whose text changed are
+// detected as well; otherwise only added text nodes are detected. The
+// translated document must have the same structure as the previous source
+// document.
+//
+// Elements of the new source that match an element of the translation keep
+// the lang and dir attributes and the translated [DefaultHTMLAttributes] of
+// the translation. If the previous version is provided, translated attributes
+// whose source value changed are not kept.
+func HTMLDiff(previous, source, translated []byte) (*HTMLUpdate, error) {
+	sourceDoc, err := html.Parse(bytes.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("parse source: %w", err)
+	}
+
+	translatedDoc, err := html.Parse(bytes.NewReader(translated))
+	if err != nil {
+		return nil, fmt.Errorf("parse translation: %w", err)
+	}
+
+	sourceSegments := htmlSegments(sourceDoc)
+	translatedSegments := htmlSegments(translatedDoc)
+
+	compareText := previous != nil
+	baseSegments := translatedSegments
+	var previousDoc *html.Node
+	if compareText {
+		previousDoc, err = html.Parse(bytes.NewReader(previous))
+		if err != nil {
+			return nil, fmt.Errorf("parse previous source: %w", err)
+		}
+
+		baseSegments = htmlSegments(previousDoc)
+		if len(baseSegments) != len(translatedSegments) {
+			return nil, fmt.Errorf("translation has %d text nodes but previous source has %d", len(translatedSegments), len(baseSegments))
+		}
+	}
+
+	keepHTMLAttributes(previousDoc, sourceDoc, translatedDoc)
+
+	equal := func(a, b htmlSegment) bool {
+		if a.path != b.path {
+			return false
+		}
+		return !compareText || a.text == b.text
+	}
+
+	update := &HTMLUpdate{
+		doc:     sourceDoc,
+		nodes:   make([]*html.Node, len(sourceSegments)),
+		reuse:   make(map[int]string),
+		pending: make(map[int]string),
+	}
+
+	matches := lcsMatches(baseSegments, sourceSegments, equal)
+	for i, seg := range sourceSegments {
+		update.nodes[i] = seg.node
+		if j, ok := matches[i]; ok {
+			update.reuse[i] = translatedSegments[j].text
+			continue
+		}
+		update.pending[i] = seg.text
+	}
+
+	return update, nil
+}
+
+// Pending returns the texts that need to be translated, keyed by an opaque
+// identifier. The same identifiers must be used for the translations that are
+// passed to [HTMLUpdate.Render].
+func (u *HTMLUpdate) Pending() map[string]string {
+	out := make(map[string]string, len(u.pending))
+	for i, text := range u.pending {
+		out[strconv.Itoa(i)] = text
+	}
+	return out
+}
+
+// Render returns the updated translation. Text nodes that did not change are
+// taken from the existing translation, pending text nodes are replaced by the
+// provided translations. Render returns an error if a translation for a pending
+// text node is missing.
+func (u *HTMLUpdate) Render(translations map[string]string) ([]byte, error) {
+	for i, node := range u.nodes {
+		text, ok := u.reuse[i]
+		if !ok {
+			if text, ok = translations[strconv.Itoa(i)]; !ok {
+				return nil, fmt.Errorf("missing translation for text %q", u.pending[i])
+			}
+		}
+		node.Data = replaceTrimmed(node.Data, text)
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, u.doc); err != nil {
+		return nil, fmt.Errorf("render html: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// keepHTMLAttributes copies the lang and dir attributes and the translated
+// attributes of the elements of the translation to the matching elements of
+// the source. Elements match if they have the same path in both documents. If
+// the previous version of the source is not nil, translated attributes are only
+// copied if their value did not change since the previous version.
+func keepHTMLAttributes(previous, source, translated *html.Node) {
+	sourceElements := htmlElements(source)
+	translatedElements := htmlElements(translated)
+
+	var previousElements []htmlSegment
+	if previous != nil {
+		previousElements = htmlElements(previous)
+		if len(previousElements) != len(translatedElements) {
+			previousElements = nil
+		}
+	}
+
+	equal := func(a, b htmlSegment) bool { return a.path == b.path }
+	for i, j := range lcsMatches(translatedElements, sourceElements, equal) {
+		node, from := sourceElements[i].node, translatedElements[j].node
+
+		for _, key := range htmlLanguageAttributes {
+			if val, ok := htmlAttr(from, key); ok {
+				setHTMLAttr(node, key, val)
+			}
+		}
+
+		for _, key := range DefaultHTMLAttributes {
+			val, ok := htmlAttr(node, key)
+			if !ok {
+				continue
+			}
+			if previousElements != nil {
+				if prev, ok := htmlAttr(previousElements[j].node, key); !ok || prev != val {
+					continue
+				}
+			}
+			if translatedVal, ok := htmlAttr(from, key); ok {
+				setHTMLAttr(node, key, translatedVal)
+			}
+		}
+	}
+}
+
+// htmlElements returns the elements of the document whose texts are
+// translated. The path of each element includes the element itself.
+func htmlElements(doc *html.Node) []htmlSegment {
+	var elements []htmlSegment
+
+	var walk func(*html.Node, []string)
+	walk = func(n *html.Node, path []string) {
+		if n.Type == html.ElementNode {
+			if !translatableElement(n) {
+				return
+			}
+			path = append(path, n.Data)
+			elements = append(elements, htmlSegment{node: n, path: strings.Join(path, ">")})
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path)
+		}
+	}
+	walk(doc, nil)
+
+	return elements
+}
+
+func htmlAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func setHTMLAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func htmlSegments(doc *html.Node) []htmlSegment {
+	var segments []htmlSegment
+
+	var walk func(*html.Node, []string)
+	walk = func(n *html.Node, path []string) {
+		switch n.Type {
+		case html.ElementNode:
+			if !translatableElement(n) {
+				return
+			}
+			path = append(path, n.Data)
+		case html.TextNode:
+			if text := strings.TrimSpace(n.Data); text != "" {
+				segments = append(segments, htmlSegment{
+					node: n,
+					path: strings.Join(path, ">"),
+					text: text,
+				})
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path)
+		}
+	}
+	walk(doc, nil)
+
+	return segments
+}
+
+// translatableElement reports whether the texts of the element and its
+// children are translated. Scripts, styles and elements that opt out using
+// translate="no" are not.
+func translatableElement(n *html.Node) bool {
+	if n.Data == "script" || n.Data == "style" {
+		return false
+	}
+	for _, a := range n.Attr {
+		if a.Key == "translate" && strings.EqualFold(a.Val, "no") {
+			return false
+		}
+	}
+	return true
+}
+
+// HTMLDocument is an HTML document whose translatable texts are extracted, so
+// that only the texts, and not the markup, are sent to the model. It is created
+// by [ParseHTML]. After translating the texts, [HTMLDocument.Render] reinserts
+// them into the document.
+type HTMLDocument struct {
+	nodes []*html.Node
+	texts []htmlField
+}
+
+// htmlField is a text node, or an attribute of an element if attr is not
+// negative.
+type htmlField struct {
+	node *html.Node
+	attr int
+	text string
+}
+
+// ParseHTML parses an HTML document and extracts the text nodes and the values
+// of the given attributes, or of [DefaultHTMLAttributes] if no attributes are
+// given. Texts without letters, scripts, styles and elements with
+// translate="no" are skipped. Documents without an <html> element are parsed
+// as fragments, so that no <html>, <head> and <body> elements are added to
+// them.
+func ParseHTML(source []byte, attributes ...string) (*HTMLDocument, error) {
+	if len(attributes) == 0 {
+		attributes = DefaultHTMLAttributes
+	}
+
+	var nodes []*html.Node
+	if isHTMLFragment(source) {
+		var err error
+		nodes, err = html.ParseFragment(bytes.NewReader(source), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+		if err != nil {
+			return nil, fmt.Errorf("parse html: %w", err)
+		}
+	} else {
+		doc, err := html.Parse(bytes.NewReader(source))
+		if err != nil {
+			return nil, fmt.Errorf("parse html: %w", err)
+		}
+		nodes = []*html.Node{doc}
+	}
+
+	d := &HTMLDocument{nodes: nodes}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.ElementNode:
+			if !translatableElement(n) {
+				return
+			}
+			for i, a := range n.Attr {
+				if a.Namespace == "" && containsFold(attributes, a.Key) && hasLetter(a.Val) {
+					d.texts = append(d.texts, htmlField{node: n, attr: i, text: strings.TrimSpace(a.Val)})
+				}
+			}
+		case html.TextNode:
+			if hasLetter(n.Data) {
+				d.texts = append(d.texts, htmlField{node: n, attr: -1, text: strings.TrimSpace(n.Data)})
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	return d, nil
+}
+
+// Texts returns the texts of the document, keyed by an opaque identifier. The
+// same identifiers must be used for the translations that are passed to
+// [HTMLDocument.Render].
+func (d *HTMLDocument) Texts() map[string]string {
+	out := make(map[string]string, len(d.texts))
+	for i, t := range d.texts {
+		out[strconv.Itoa(i)] = t.text
+	}
+	return out
+}
+
+// Render returns the document with its texts replaced by the given
+// translations. Texts without a translation are kept as they are. The
+// whitespace around the texts is preserved.
+func (d *HTMLDocument) Render(translations map[string]string) ([]byte, error) {
+	for i, t := range d.texts {
+		text, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+		if t.attr < 0 {
+			t.node.Data = replaceTrimmed(t.node.Data, text)
+		} else {
+			t.node.Attr[t.attr].Val = replaceTrimmed(t.node.Attr[t.attr].Val, text)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, n := range d.nodes {
+		if err := html.Render(&buf, n); err != nil {
+			return nil, fmt.Errorf("render html: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// HTMLParams configures the translation of an HTML document by
+// [Translator.TranslateHTML]. The Document of the embedded [TranslateParams] is
+// the HTML document.
+type HTMLParams struct {
+	TranslateParams
+
+	// Attributes are the attributes whose values are translated. Defaults to
+	// [DefaultHTMLAttributes].
+	Attributes []string
+
+	// Batch translates all texts of the document as a single JSON object of
+	// key-value pairs instead of translating every text on its own. Batches
+	// need fewer requests and give the model the context of the surrounding
+	// texts, but very large pages may exceed the context of the model.
+	Batch bool
+}
+
+// TranslateHTML translates the text nodes and the selected attributes of an
+// HTML document and reinserts them into the document, so that the markup of the
+// document is never sent to the model and cannot be changed by it.
+func (t *Translator) TranslateHTML(ctx context.Context, params HTMLParams) (string, error) {
+	doc, err := ParseHTML([]byte(params.Document), params.Attributes...)
+	if err != nil {
+		return "", err
+	}
+
+	texts := doc.Texts()
+	translations := make(map[string]string, len(texts))
+
+	if params.Batch && len(texts) > 0 {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(texts); err != nil {
+			return "", fmt.Errorf("marshal texts: %w", err)
+		}
+
+		p := params.TranslateParams
+		p.Document = buf.String()
+		p.Validate = ValidateAll(p.Validate, ValidateJSON)
+		result, err := t.Translate(ctx, p)
+		if err != nil {
+			return "", err
+		}
+
+		if err := json.Unmarshal([]byte(result), &translations); err != nil {
+			return "", fmt.Errorf("unmarshal translated texts: %w", err)
+		}
+	} else {
+		for i := range doc.texts {
+			id := strconv.Itoa(i)
+			p := params.TranslateParams
+			p.Document = texts[id]
+			result, err := t.Translate(ctx, p)
+			if err != nil {
+				return "", fmt.Errorf("translate text %q: %w", texts[id], err)
+			}
+			translations[id] = strings.TrimSpace(result)
+		}
+	}
+
+	out, err := doc.Render(translations)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func isHTMLFragment(source []byte) bool {
+	lower := bytes.ToLower(source)
+	return !bytes.Contains(lower, []byte("<html")) && !bytes.Contains(lower, []byte("<!doctype"))
+}
+
+func hasLetter(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// lcsMatches computes the longest common subsequence of a and b and returns a
+// map of the matched indices of b to the matched indices of a.
+func lcsMatches[T any](a, b []T, equal func(T, T) bool) map[int]int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if equal(a[i], b[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	matches := make(map[int]int)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case equal(a[i], b[j]):
+			matches[j] = i
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return matches
+}
+
+func replaceTrimmed(original, text string) string {
+	trimmed := strings.TrimSpace(original)
+	if trimmed == "" {
+		return original
+	}
+	start := strings.Index(original, trimmed)
+	return original[:start] + text + original[start+len(trimmed):]
+}
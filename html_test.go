@@ -0,0 +1,195 @@
+package dragoman_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tcmp "github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestHTMLDiff(t *testing.T) {
+	previous := `<html><body><h1>Hello</h1><p>First paragraph.</p><p>Second paragraph.</p></body></html>`
+	source := `<html><body><h1>Hello</h1><p>First paragraph, changed.</p><p>Second paragraph.</p><p>Third paragraph.</p></body></html>`
+	translated := `<html><body><h1>Hallo</h1><p>Erster Absatz.</p><p>Zweiter Absatz.</p></body></html>`
+
+	update, err := dragoman.HTMLDiff([]byte(previous), []byte(source), []byte(translated))
+	if err != nil {
+		t.Fatalf("HTMLDiff(): %v", err)
+	}
+
+	wantPending := map[string]string{
+		"1": "First paragraph, changed.",
+		"3": "Third paragraph.",
+	}
+
+	if pending := update.Pending(); !tcmp.Equal(wantPending, pending) {
+		t.Fatalf("Pending(): got %v; want %v", pending, wantPending)
+	}
+
+	result, err := update.Render(map[string]string{
+		"1": "Erster Absatz, geändert.",
+		"3": "Dritter Absatz.",
+	})
+	if err != nil {
+		t.Fatalf("Render(): %v", err)
+	}
+
+	want := `<html><head></head><body><h1>Hallo</h1><p>Erster Absatz, geändert.</p><p>Zweiter Absatz.</p><p>Dritter Absatz.</p></body></html>`
+	if got := strings.TrimSpace(string(result)); got != want {
+		t.Fatalf("Render(): got %q; want %q", got, want)
+	}
+}
+
+func TestHTMLDiff_withoutPrevious(t *testing.T) {
+	source := `<html><body><h1>Hello</h1><p>First paragraph.</p><ul><li>Item</li></ul></body></html>`
+	translated := `<html><body><h1>Hallo</h1><p>Erster Absatz.</p></body></html>`
+
+	update, err := dragoman.HTMLDiff(nil, []byte(source), []byte(translated))
+	if err != nil {
+		t.Fatalf("HTMLDiff(): %v", err)
+	}
+
+	wantPending := map[string]string{"2": "Item"}
+
+	if pending := update.Pending(); !tcmp.Equal(wantPending, pending) {
+		t.Fatalf("Pending(): got %v; want %v", pending, wantPending)
+	}
+}
+
+func TestHTMLDiff_attributes(t *testing.T) {
+	previous := `<html lang="en"><body><h1 title="Greeting">Hello</h1><img src="a.png" alt="First image"><img src="b.png" alt="Second image"></body></html>`
+	source := `<html lang="en"><body><h1 title="Greeting">Hello</h1><img src="a.png" alt="First image"><img src="b.png" alt="Changed image"><p>New paragraph.</p></body></html>`
+	translated := `<html lang="de"><body><h1 title="Begrüßung">Hallo</h1><img src="a.png" alt="Erstes Bild"><img src="b.png" alt="Zweites Bild"></body></html>`
+
+	update, err := dragoman.HTMLDiff([]byte(previous), []byte(source), []byte(translated))
+	if err != nil {
+		t.Fatalf("HTMLDiff(): %v", err)
+	}
+
+	result, err := update.Render(map[string]string{"1": "Neuer Absatz."})
+	if err != nil {
+		t.Fatalf("Render(): %v", err)
+	}
+
+	want := `<html lang="de"><head></head><body><h1 title="Begrüßung">Hallo</h1><img src="a.png" alt="Erstes Bild"/><img src="b.png" alt="Changed image"/><p>Neuer Absatz.</p></body></html>`
+	if got := strings.TrimSpace(string(result)); got != want {
+		t.Fatalf("Render(): got %q; want %q", got, want)
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	source := `<div class="card">
+  <img src="logo.png" alt="Our logo">
+  <p translate="no">Dragoman</p>
+  <p> Hello, <b>World</b>! </p>
+  <input placeholder="Your name" data-id="name">
+  <span>42</span>
+</div>`
+
+	doc, err := dragoman.ParseHTML([]byte(source))
+	if err != nil {
+		t.Fatalf("ParseHTML(): %v", err)
+	}
+
+	wantTexts := map[string]string{
+		"0": "Our logo",
+		"1": "Hello,",
+		"2": "World",
+		"3": "Your name",
+	}
+	if texts := doc.Texts(); !tcmp.Equal(wantTexts, texts) {
+		t.Fatalf("Texts(): got %v; want %v", texts, wantTexts)
+	}
+
+	result, err := doc.Render(map[string]string{
+		"0": "Unser Logo",
+		"1": "Hallo,",
+		"2": "Welt",
+	})
+	if err != nil {
+		t.Fatalf("Render(): %v", err)
+	}
+
+	want := `<div class="card">
+  <img src="logo.png" alt="Unser Logo"/>
+  <p translate="no">Dragoman</p>
+  <p> Hallo, <b>Welt</b>! </p>
+  <input placeholder="Your name" data-id="name"/>
+  <span>42</span>
+</div>`
+	if got := string(result); got != want {
+		t.Fatalf("Render(): got\n\n%s\n\nwant\n\n%s", got, want)
+	}
+}
+
+func TestParseHTML_attributes(t *testing.T) {
+	source := `<html><head><meta name="description" content="A translator"></head><body><a href="/" title="Home">Start</a></body></html>`
+
+	doc, err := dragoman.ParseHTML([]byte(source), "content")
+	if err != nil {
+		t.Fatalf("ParseHTML(): %v", err)
+	}
+
+	wantTexts := map[string]string{"0": "A translator", "1": "Start"}
+	if texts := doc.Texts(); !tcmp.Equal(wantTexts, texts) {
+		t.Fatalf("Texts(): got %v; want %v", texts, wantTexts)
+	}
+}
+
+func TestTranslator_TranslateHTML(t *testing.T) {
+	source := `<p>Hello</p><img src="a.png" alt="Image">`
+	want := `<p>DE Hello</p><img src="a.png" alt="DE Image"/>`
+
+	t.Run("texts", func(t *testing.T) {
+		var prompts []string
+		model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			if strings.Contains(prompt, "<p>") || strings.Contains(prompt, "<img") {
+				t.Errorf("prompt should not contain markup; got\n\n%s", prompt)
+			}
+			doc := prompt[strings.Index(prompt, "---<DOC_BEGIN>---")+len("---<DOC_BEGIN>---"):]
+			doc = doc[:strings.Index(doc, "---<DOC_END>---")]
+			return "DE " + strings.TrimSpace(doc), nil
+		})
+
+		result, err := dragoman.NewTranslator(model).TranslateHTML(context.Background(), dragoman.HTMLParams{
+			TranslateParams: dragoman.TranslateParams{Document: source, Target: "German"},
+		})
+		if err != nil {
+			t.Fatalf("TranslateHTML(): %v", err)
+		}
+		if len(prompts) != 2 {
+			t.Fatalf("TranslateHTML() should send a prompt per text; got %d prompts", len(prompts))
+		}
+		if result != want {
+			t.Fatalf("TranslateHTML(): got %q; want %q", result, want)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		var prompts int
+		model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+			prompts++
+			if !strings.Contains(prompt, `"0": "Hello"`) || !strings.Contains(prompt, `"1": "Image"`) {
+				t.Errorf("prompt should contain the texts as JSON; got\n\n%s", prompt)
+			}
+			return `{"0": "DE Hello", "1": "DE Image"}`, nil
+		})
+
+		result, err := dragoman.NewTranslator(model).TranslateHTML(context.Background(), dragoman.HTMLParams{
+			TranslateParams: dragoman.TranslateParams{Document: source, Target: "German"},
+			Batch:           true,
+		})
+		if err != nil {
+			t.Fatalf("TranslateHTML(): %v", err)
+		}
+		if prompts != 1 {
+			t.Fatalf("TranslateHTML() should send a single prompt; got %d prompts", prompts)
+		}
+		if result != want {
+			t.Fatalf("TranslateHTML(): got %q; want %q", result, want)
+		}
+	})
+}
@@ -0,0 +1,361 @@
+package dragoman
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/modernice/dragoman/format/icu"
+)
+
+// i18nextInstruction is added to the prompt of chunks whose i18next plural
+// forms were grouped into objects.
+const i18nextInstruction = `Some values are objects of the plural forms of a key, like "item#plural" for the forms of cardinal numbers or "item#ordinal" for the forms of ordinal numbers. Their keys are CLDR plural categories, like "one" or "few". Translate each text in the grammatical form of its category in the target language and keep all keys.`
+
+var (
+	// i18nextPluralKey matches the keys of the plural forms of i18next v4,
+	// like "item_one" or "place_ordinal_few".
+	i18nextPluralKey = regexp.MustCompile(`^(.+?)_(ordinal_)?(zero|one|two|few|many|other)$`)
+
+	// i18nextPatterns match the interpolations, like "{{count}}", and the
+	// nesting references, like "$t(common.save)", of i18next.
+	i18nextPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\{\{[^{}]*\}\}`),
+		regexp.MustCompile(`\$t\([^()]*\)`),
+	}
+)
+
+// The kinds of i18next plural families.
+const (
+	i18nextCardinal = "plural"
+	i18nextOrdinal  = "ordinal"
+
+	// i18nextLegacy are the "key" and "key_plural" pairs of i18next v3, which
+	// are translated together but not expanded, because i18next v3 uses
+	// numbered keys for the additional forms.
+	i18nextLegacy = "legacy"
+)
+
+// i18nextFamily are the plural forms of a key of an i18next object, like
+// "item_one" and "item_other".
+type i18nextFamily struct {
+	base string
+	kind string
+
+	// forms maps the plural categories to the keys of the forms.
+	forms map[string]string
+}
+
+// key returns the key of the object of the forms in a split chunk.
+func (f i18nextFamily) key() string {
+	if f.kind == i18nextOrdinal {
+		return f.base + "#ordinal"
+	}
+	return f.base + "#plural"
+}
+
+// formKey returns the key of the form of the given category.
+func (f i18nextFamily) formKey(category string) string {
+	if key, ok := f.forms[category]; ok {
+		return key
+	}
+	if f.kind == i18nextOrdinal {
+		return f.base + "_ordinal_" + category
+	}
+	return f.base + "_" + category
+}
+
+// expand adds the keys of the categories of the language that are missing in
+// the family. Legacy families and families without an "other" form are not
+// expanded.
+func (f i18nextFamily) expand(language string) []string {
+	if _, ok := f.forms["other"]; !ok || f.kind == i18nextLegacy {
+		return nil
+	}
+
+	categories, ok := icu.PluralCategories(language, f.kind == i18nextOrdinal)
+	if !ok {
+		return nil
+	}
+
+	var added []string
+	for _, category := range categories {
+		if _, ok := f.forms[category]; !ok {
+			added = append(added, category)
+		}
+	}
+	return added
+}
+
+// i18nextFamilies returns the plural families of the string values of an
+// object, sorted by their keys.
+func i18nextFamilies(obj map[string]any) []i18nextFamily {
+	families := make(map[string]*i18nextFamily)
+	add := func(base, kind, category, key string) {
+		id := kind + "\x00" + base
+		f, ok := families[id]
+		if !ok {
+			f = &i18nextFamily{base: base, kind: kind, forms: make(map[string]string)}
+			families[id] = f
+		}
+		f.forms[category] = key
+	}
+
+	for key, value := range obj {
+		if _, ok := value.(string); !ok {
+			continue
+		}
+
+		if base, ok := strings.CutSuffix(key, "_plural"); ok && base != "" {
+			if _, ok := obj[base].(string); ok {
+				add(base, i18nextLegacy, "one", base)
+				add(base, i18nextLegacy, "other", key)
+			}
+			continue
+		}
+
+		if m := i18nextPluralKey.FindStringSubmatch(key); m != nil {
+			kind := i18nextCardinal
+			if m[2] != "" {
+				kind = i18nextOrdinal
+			}
+			add(m[1], kind, m[3], key)
+		}
+	}
+
+	out := make([]i18nextFamily, 0, len(families))
+	for _, f := range families {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].key() < out[j].key()
+	})
+
+	return out
+}
+
+// i18nextObjects calls fn for every object of the JSON document, including
+// the document itself, with the path of the object.
+func i18nextObjects(path JSONPath, obj map[string]any, fn func(JSONPath, map[string]any)) {
+	fn(path, obj)
+	for key, value := range obj {
+		if child, ok := value.(map[string]any); ok {
+			i18nextObjects(append(slices.Clone(path), key), child, fn)
+		}
+	}
+}
+
+// i18nextChunk is a JSON chunk of an i18next resource whose plural forms were
+// grouped into one object per key, so that the forms of a key are translated
+// together.
+type i18nextChunk struct {
+	document string
+	groups   []i18nextGroup
+}
+
+type i18nextGroup struct {
+	parent JSONPath
+	family i18nextFamily
+
+	// categories are the plural categories of the object of the forms,
+	// including the categories that were added for the target language.
+	categories []string
+}
+
+// splitI18next groups the plural forms of the i18next keys of a JSON chunk
+// into objects. The forms of cardinal and ordinal plurals are expanded to the
+// plural categories of the target language. It reports false if the chunk is
+// not a JSON object.
+func splitI18next(chunk, target string) (i18nextChunk, bool) {
+	var doc map[string]any
+	if json.Unmarshal([]byte(chunk), &doc) != nil {
+		return i18nextChunk{}, false
+	}
+
+	var out i18nextChunk
+	i18nextObjects(nil, doc, func(path JSONPath, obj map[string]any) {
+		for _, f := range i18nextFamilies(obj) {
+			if _, exists := obj[f.key()]; exists {
+				continue
+			}
+
+			g := i18nextGroup{parent: path, family: f}
+			forms := make(map[string]any, len(f.forms))
+			for category, key := range f.forms {
+				forms[category] = obj[key]
+				g.categories = append(g.categories, category)
+				delete(obj, key)
+			}
+			for _, category := range f.expand(target) {
+				forms[category] = forms["other"]
+				g.categories = append(g.categories, category)
+			}
+			obj[f.key()] = forms
+
+			out.groups = append(out.groups, g)
+		}
+	})
+
+	document, err := indentJSON(doc)
+	if err != nil {
+		return i18nextChunk{}, false
+	}
+	out.document = document
+
+	return out, true
+}
+
+// source returns the chunk with the grouped plural forms.
+func (c i18nextChunk) source() string {
+	return c.document
+}
+
+// join splits the objects of plural forms of the translated chunk back into
+// keys. It returns an error that wraps [ErrInvalidTranslation] if forms are
+// missing.
+func (c i18nextChunk) join(translated string) (string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(translated), &doc); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+
+	for _, g := range c.groups {
+		name := strings.Join(append(slices.Clone(g.parent), g.family.key()), ".")
+
+		parent, ok := jsonValue(doc, g.parent).(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("%w: missing plural forms %q", ErrInvalidTranslation, name)
+		}
+		forms, ok := parent[g.family.key()].(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("%w: missing plural forms %q", ErrInvalidTranslation, name)
+		}
+
+		for category := range forms {
+			if !slices.Contains(g.categories, category) {
+				return "", fmt.Errorf("%w: unexpected plural form %q of %q", ErrInvalidTranslation, category, name)
+			}
+		}
+		for _, category := range g.categories {
+			if _, ok := forms[category]; !ok {
+				return "", fmt.Errorf("%w: missing plural form %q of %q", ErrInvalidTranslation, category, name)
+			}
+		}
+
+		delete(parent, g.family.key())
+		for category, text := range forms {
+			if _, ok := text.(string); !ok {
+				return "", fmt.Errorf("%w: plural form %q of %q is not a string", ErrInvalidTranslation, category, name)
+			}
+			parent[g.family.formKey(category)] = text
+		}
+	}
+
+	return indentJSON(doc)
+}
+
+// params returns the params of the translation of the chunk, which never
+// translate the interpolations and nesting references of i18next, instruct
+// the model about the plural forms and validate that the forms can be split
+// back into keys.
+func (c i18nextChunk) params(params TranslateParams) TranslateParams {
+	params.PreservePatterns = append(slices.Clone(params.PreservePatterns), i18nextPatterns...)
+	if len(c.groups) == 0 {
+		return params
+	}
+
+	params.Instructions = append(slices.Clone(params.Instructions), i18nextInstruction)
+	params.Validate = ValidateAll(params.Validate, func(_, translated string) error {
+		_, err := c.join(translated)
+		return err
+	})
+	return params
+}
+
+// I18nextExpand returns a copy of the i18next resource whose cardinal and
+// ordinal plural forms are expanded to the plural categories of the given
+// language, like "item_few" and "item_many" next to "item_one" and
+// "item_other" for Russian. The added forms are copies of the "other" form.
+// Diffing the translation against the expanded source finds the plural forms
+// that the translation lacks.
+func I18nextExpand(doc map[string]any, language string) map[string]any {
+	out := jsonCopy(doc)
+	i18nextObjects(nil, out, func(_ JSONPath, obj map[string]any) {
+		for _, f := range i18nextFamilies(obj) {
+			for _, category := range f.expand(language) {
+				obj[f.formKey(category)] = obj[f.forms["other"]]
+			}
+		}
+	})
+	return out
+}
+
+// I18nextFamilies returns the paths together with the paths of all plural
+// forms of the i18next resource that belong to the same keys, so that the
+// forms of a key are always translated together. Paths of forms that only
+// exist in the expanded resource of [I18nextExpand] are replaced by the forms
+// of their key. The paths are returned in sorted order.
+func I18nextFamilies(doc map[string]any, paths []JSONPath) []JSONPath {
+	seen := make(map[string]bool)
+	var out []JSONPath
+	add := func(path JSONPath) {
+		id := strings.Join(path, "\x00")
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, path)
+		}
+	}
+
+	for _, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+
+		parentPath, key := path[:len(path)-1], path[len(path)-1]
+		parent, ok := jsonValue(doc, parentPath).(map[string]any)
+		if !ok {
+			add(path)
+			continue
+		}
+
+		found := false
+		for _, f := range i18nextFamilies(parent) {
+			if !f.contains(key) {
+				continue
+			}
+			found = true
+			for _, formKey := range f.forms {
+				add(append(slices.Clone(parentPath), formKey))
+			}
+		}
+
+		if _, exists := parent[key]; exists || !found {
+			add(path)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return lessPath(out[i], out[j])
+	})
+
+	return out
+}
+
+// contains reports whether the key is one of the forms of the family, or a
+// form of a category that the family lacks.
+func (f i18nextFamily) contains(key string) bool {
+	for _, formKey := range f.forms {
+		if formKey == key {
+			return true
+		}
+	}
+
+	if f.kind == i18nextLegacy {
+		return false
+	}
+	m := i18nextPluralKey.FindStringSubmatch(key)
+	return m != nil && m[1] == f.base && (m[2] != "") == (f.kind == i18nextOrdinal)
+}
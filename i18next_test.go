@@ -0,0 +1,162 @@
+package dragoman_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestTranslator_Translate_i18next(t *testing.T) {
+	source := `{
+		"cart": {"item_one": "{{count}} item", "item_other": "{{count}} items"},
+		"file": "file",
+		"file_plural": "files",
+		"save": "$t(common.save) now"
+	}`
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		doc := strings.Split(strings.Split(prompt, "---<DOC_BEGIN>---\n")[1], "\n---<DOC_END>---")[0]
+
+		var m map[string]any
+		if err := json.Unmarshal([]byte(doc), &m); err != nil {
+			t.Fatalf("chunk is not a JSON object: %v\n%s", err, doc)
+		}
+		upper(m)
+		b, _ := json.Marshal(m)
+		return string(b), nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: source,
+		Target:   "Russian",
+		I18next:  true,
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(result), &got); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+
+	want := map[string]any{
+		"cart": map[string]any{
+			"item_one":   "{{count}} ITEM",
+			"item_few":   "{{count}} ITEMS",
+			"item_many":  "{{count}} ITEMS",
+			"item_other": "{{count}} ITEMS",
+		},
+		"file":        "FILE",
+		"file_plural": "FILES",
+		"save":        "$t(common.save) NOW",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Translate() mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(prompts) != 1 {
+		t.Fatalf("model should be called once; got %d calls", len(prompts))
+	}
+	for _, text := range []string{`"item#plural"`, `"file#plural"`, `"few"`, "CLDR plural categories"} {
+		if !strings.Contains(prompts[0], text) {
+			t.Fatalf("prompt should contain %q; got\n\n%s", text, prompts[0])
+		}
+	}
+	for _, text := range []string{"$t(", "{{count}}", "item_one"} {
+		if strings.Contains(prompts[0], text) {
+			t.Fatalf("prompt should not contain %q; got\n\n%s", text, prompts[0])
+		}
+	}
+}
+
+func TestTranslator_Translate_i18next_retry(t *testing.T) {
+	responses := []string{
+		`{"item#plural": {"one": "Datei"}}`,
+		`{"item#plural": {"one": "Datei", "other": "Dateien"}}`,
+	}
+
+	var calls int
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		calls++
+		return responses[calls-1], nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:          `{"item_one": "file", "item_other": "files"}`,
+		Target:            "German",
+		I18next:           true,
+		ValidationRetries: 1,
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	want := "{\n  \"item_one\": \"Datei\",\n  \"item_other\": \"Dateien\"\n}\n"
+	if result != want {
+		t.Fatalf("Translate() should return %q; got %q", want, result)
+	}
+
+	if calls != 2 {
+		t.Fatalf("translation with missing plural forms should be retried; got %d calls", calls)
+	}
+}
+
+func TestI18nextExpand(t *testing.T) {
+	doc := map[string]any{
+		"item_one":            "item",
+		"item_other":          "items",
+		"place_ordinal_one":   "{{count}}st",
+		"place_ordinal_other": "{{count}}th",
+		"title":               "Title",
+	}
+
+	got := dragoman.I18nextExpand(doc, "pl")
+
+	want := map[string]any{
+		"item_one":            "item",
+		"item_few":            "items",
+		"item_many":           "items",
+		"item_other":          "items",
+		"place_ordinal_one":   "{{count}}st",
+		"place_ordinal_other": "{{count}}th",
+		"title":               "Title",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("I18nextExpand() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, ok := doc["item_few"]; ok {
+		t.Fatalf("I18nextExpand() should not modify the document")
+	}
+}
+
+func TestI18nextFamilies(t *testing.T) {
+	doc := map[string]any{
+		"cart": map[string]any{
+			"item_one":   "item",
+			"item_other": "items",
+			"total":      "Total",
+		},
+	}
+
+	got := dragoman.I18nextFamilies(doc, []dragoman.JSONPath{
+		{"cart", "item_few"},
+		{"cart", "total"},
+	})
+
+	want := []dragoman.JSONPath{
+		{"cart", "item_one"},
+		{"cart", "item_other"},
+		{"cart", "total"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("I18nextFamilies() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,127 @@
+package dragoman
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modernice/dragoman/format/icu"
+)
+
+// icuInstruction is added to the prompt of chunks whose ICU MessageFormat
+// messages were split into their texts.
+const icuInstruction = `Some values are objects of the texts of ICU MessageFormat messages. Their keys name the plural or select branch of a text, like "count:one" for the plural category "one" of the argument "count", or "#1" for a text outside of the branches. Translate each text in the grammatical form of its branch and keep the keys. Keep "#" and placeholders like "{name}" unchanged.`
+
+// icuChunk is a JSON chunk whose ICU MessageFormat messages with plural,
+// selectordinal or select arguments were replaced with objects of their
+// translatable texts, so that the syntax of the messages is never translated.
+type icuChunk struct {
+	document string
+	messages []icuMessage
+}
+
+type icuMessage struct {
+	path    JSONPath
+	message icu.Message
+}
+
+// splitICU replaces the ICU MessageFormat messages of a JSON chunk with objects
+// of their texts. Plural arguments are expanded to the plural categories of
+// the target language. It reports false if the chunk is not a JSON object or
+// has no such messages.
+func splitICU(chunk, target string) (icuChunk, bool) {
+	var doc map[string]any
+	if json.Unmarshal([]byte(chunk), &doc) != nil {
+		return icuChunk{}, false
+	}
+
+	var out icuChunk
+	for _, path := range allKeys(doc) {
+		text, ok := jsonValue(doc, path).(string)
+		if !ok {
+			continue
+		}
+
+		msg, err := icu.Parse(text)
+		if err != nil || !msg.IsComplex() {
+			continue
+		}
+		msg.Expand(target)
+
+		segments := msg.Segments()
+		if len(segments) == 0 {
+			continue
+		}
+
+		obj := make(map[string]any, len(segments))
+		for key, text := range segments {
+			obj[key] = text
+		}
+		jsonSetValue(doc, path, obj)
+		out.messages = append(out.messages, icuMessage{path: path, message: msg})
+	}
+
+	if len(out.messages) == 0 {
+		return icuChunk{}, false
+	}
+
+	document, err := indentJSON(doc)
+	if err != nil {
+		return icuChunk{}, false
+	}
+	out.document = document
+
+	return out, true
+}
+
+// join replaces the objects of texts of the translated chunk with the
+// translated ICU MessageFormat messages. It returns an error that wraps
+// [ErrInvalidTranslation] if texts are missing.
+func (c icuChunk) join(translated string) (string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(translated), &doc); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+
+	for _, m := range c.messages {
+		key := strings.Join(m.path, ".")
+
+		obj, ok := jsonValue(doc, m.path).(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("%w: missing texts of ICU message %q", ErrInvalidTranslation, key)
+		}
+
+		texts := make(map[string]string, len(obj))
+		for k, v := range obj {
+			if texts[k], ok = v.(string); !ok {
+				return "", fmt.Errorf("%w: text %q of ICU message %q is not a string", ErrInvalidTranslation, k, key)
+			}
+		}
+
+		msg, err := m.message.SetSegments(texts)
+		if err != nil {
+			return "", fmt.Errorf("%w: ICU message %q: %v", ErrInvalidTranslation, key, err)
+		}
+		jsonSetValue(doc, m.path, msg.String())
+	}
+
+	return indentJSON(doc)
+}
+
+// source returns the chunk with the texts of its messages.
+func (c icuChunk) source() string {
+	return c.document
+}
+
+// params returns the params of the translation of the chunk, which instruct
+// the model about the texts of the messages and validate that the texts can be
+// joined back into messages.
+func (c icuChunk) params(params TranslateParams) TranslateParams {
+	params.Instructions = append(slices.Clone(params.Instructions), icuInstruction)
+	params.Validate = ValidateAll(params.Validate, func(_, translated string) error {
+		_, err := c.join(translated)
+		return err
+	})
+	return params
+}
@@ -0,0 +1,88 @@
+package dragoman_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestTranslator_Translate_icu(t *testing.T) {
+	source := `{"files": "{count, plural, one {# file} other {# files}} selected", "title": "Files"}`
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return `{
+			"files": {"#1": " wybrano", "count:one": "# plik", "count:few": "# pliki", "count:many": "# plików", "count:other": "# pliku"},
+			"title": "Pliki"
+		}`, nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: source,
+		Target:   "Polish",
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(result), &got); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+
+	want := map[string]any{
+		"files": "{count, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}} wybrano",
+		"title": "Pliki",
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Translate() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if len(prompts) != 1 {
+		t.Fatalf("model should be called once; got %d calls", len(prompts))
+	}
+	for _, text := range []string{`"count:few": "# files"`, `"count:many": "# files"`, "ICU MessageFormat"} {
+		if !strings.Contains(prompts[0], text) {
+			t.Fatalf("prompt should contain %q; got\n\n%s", text, prompts[0])
+		}
+	}
+	if strings.Contains(prompts[0], "plural,") {
+		t.Fatalf("prompt should not contain the syntax of the message; got\n\n%s", prompts[0])
+	}
+}
+
+func TestTranslator_Translate_icu_retry(t *testing.T) {
+	responses := []string{
+		`{"files": {"count:one": "# Datei"}}`,
+		`{"files": {"count:one": "# Datei", "count:other": "# Dateien"}}`,
+	}
+
+	var calls int
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		calls++
+		return responses[calls-1], nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:          `{"files": "{count, plural, one {# file} other {# files}}"}`,
+		Target:            "German",
+		ValidationRetries: 1,
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	want := "{\n  \"files\": \"{count, plural, one {# Datei} other {# Dateien}}\"\n}\n"
+	if result != want {
+		t.Fatalf("Translate() should return %q; got %q", want, result)
+	}
+
+	if calls != 2 {
+		t.Fatalf("translation with missing texts should be retried; got %d calls", calls)
+	}
+}
@@ -2,11 +2,16 @@ package dragoman
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/modernice/dragoman/internal/chunks"
+	"github.com/modernice/dragoman/internal/logging"
 )
 
 // Improver enhances the content of a document by making it more engaging,
@@ -17,14 +22,32 @@ import (
 // segment of the document separately when necessary, allowing for large
 // documents to be handled effectively.
 type Improver struct {
-	model Model
+	model  Model
+	logger *slog.Logger
 }
 
-// NewImprover creates a new instance of [Improver] using the provided [Model].
-func NewImprover(svc Model) *Improver {
-	return &Improver{
-		model: svc,
+// ImproverOption configures an [Improver].
+type ImproverOption func(*Improver)
+
+// ImproverLogger makes the [Improver] log structured records of its progress
+// to the given logger, like [TranslatorLogger]. By default, nothing is logged.
+func ImproverLogger(logger *slog.Logger) ImproverOption {
+	return func(imp *Improver) {
+		imp.logger = logger
+	}
+}
+
+// NewImprover creates a new instance of [Improver] using the provided [Model]
+// and options.
+func NewImprover(svc Model, opts ...ImproverOption) *Improver {
+	imp := &Improver{
+		model:  svc,
+		logger: logging.Discard(),
+	}
+	for _, opt := range opts {
+		opt(imp)
 	}
+	return imp
 }
 
 // ImproveParams configures the enhancement of a document by specifying its
@@ -41,6 +64,12 @@ type ImproveParams struct {
 	// context window.
 	SplitChunks []string
 
+	// SplitHeadingLevels are the levels of the Markdown headings (e.g. 2 for
+	// "## ") before which the document is split into chunks. Unlike
+	// SplitChunks, headings in fenced code blocks are ignored. If it is set, it
+	// takes precedence over SplitChunks.
+	SplitHeadingLevels []int
+
 	// Formality specifies the formality (formal address) to use in the improved document.
 	Formality Formality
 
@@ -52,8 +81,98 @@ type ImproveParams struct {
 
 	// Language is the language the improved document should be written in.
 	Language string
+
+	// Context are read-only reference documents, like brand guides, that are
+	// included in the prompt so that the improved document matches their
+	// terminology, tone and style.
+	Context []string
+
+	// PreserveOutline constrains the model to keep the exact headings of the
+	// document in their original order. No sections may be added, removed,
+	// renamed or reordered. If the improved document does not match the outline
+	// of the original document, the chunk is improved once more before an
+	// [ErrOutlineChanged] error is returned.
+	PreserveOutline bool
+
+	// Sections are the titles of the Markdown headings of the sections that
+	// are improved, like "Conclusion". If it is set, each of these sections is
+	// improved on its own and split into chunks like a document, and the rest
+	// of the document is kept unchanged. A section ends before the next
+	// heading of the same or a higher level; sections within another selected
+	// section are improved with it. Titles are matched case-insensitively.
+	Sections []string
+
+	// PreservePatterns are regular expressions of texts, like placeholders,
+	// that are masked before the document is improved and restored verbatim,
+	// like the PreservePatterns of the [TranslateParams]. A chunk whose
+	// improvement lost a masked text is improved once more before an error
+	// that wraps [ErrInvalidTranslation] is returned.
+	PreservePatterns []*regexp.Regexp
+}
+
+// chunks returns the chunks of the document of the params, or of its selected
+// sections.
+func (p ImproveParams) chunks() []string {
+	if len(p.Sections) > 0 {
+		// Missing sections are reported by Improve.
+		sections, _ := p.sections()
+
+		var out []string
+		for _, section := range sections {
+			out = append(out, p.section(section).chunks()...)
+		}
+		return out
+	}
+
+	if len(p.SplitHeadingLevels) > 0 {
+		return chunks.Headings(p.Document, p.SplitHeadingLevels)
+	}
+	return chunks.Chunks(p.Document, p.SplitChunks)
+}
+
+// sections returns the sections of the document that are selected by the
+// Sections of the params, without the sections that are part of another
+// selected section. It returns an error that wraps [ErrSectionNotFound] if a
+// section does not exist.
+func (p ImproveParams) sections() ([]chunks.Section, error) {
+	found := chunks.Sections(p.Document, p.Sections)
+	for _, title := range p.Sections {
+		if !slices.ContainsFunc(found, func(s chunks.Section) bool {
+			return strings.EqualFold(s.Title, strings.TrimSpace(title))
+		}) {
+			return nil, fmt.Errorf("%w: %q", ErrSectionNotFound, title)
+		}
+	}
+
+	var sections []chunks.Section
+	end := 0
+	for _, section := range found {
+		if section.Start < end {
+			continue
+		}
+		sections = append(sections, section)
+		end = section.End
+	}
+
+	return sections, nil
+}
+
+// section returns the params that improve a section of the document.
+func (p ImproveParams) section(section chunks.Section) ImproveParams {
+	p.Document = strings.TrimRight(p.Document[section.Start:section.End], " \t\r\n")
+	p.Sections = nil
+	return p
 }
 
+// ErrSectionNotFound is returned by [Improver.Improve] if a section of
+// [ImproveParams.Sections] does not exist in the document.
+var ErrSectionNotFound = errors.New("section not found")
+
+// ErrOutlineChanged is returned by [Improver.Improve] if
+// [ImproveParams.PreserveOutline] is set and the model changed the heading
+// outline of the document.
+var ErrOutlineChanged = errors.New("outline of the document was changed")
+
 // Improve enhances the content of a document based on specified parameters to
 // increase engagement, clarity, and search engine optimization. It splits the
 // document into manageable chunks if necessary, processes each chunk
@@ -61,31 +180,127 @@ type ImproveParams struct {
 // formality, keywords, and additional instructions, and then reassembles the
 // improved chunks into a cohesive output.
 func (imp *Improver) Improve(ctx context.Context, params ImproveParams) (string, error) {
-	docChunks := []string{params.Document}
-
-	if len(params.SplitChunks) > 0 {
-		docChunks = chunks.Chunks(params.Document, params.SplitChunks)
+	if len(params.Sections) > 0 {
+		return imp.improveSections(ctx, params)
 	}
 
+	docChunks := params.chunks()
+
 	var result []string
 
-	for _, chunk := range docChunks {
-		translated, err := imp.improveChunk(ctx, chunk, params)
+	for i, chunk := range docChunks {
+		logger := imp.logger.With("chunk", i+1, "chunks", len(docChunks))
+		logger.DebugContext(ctx, "improve chunk")
+
+		improved, err := imp.improveChunk(ctx, chunk, params)
 		if err != nil {
 			return "", err
 		}
-		result = append(result, translated)
+
+		if params.PreserveOutline && !slices.Equal(outline(chunk), outline(improved)) {
+			logger.WarnContext(ctx, "retry changed outline")
+			if improved, err = imp.improveChunk(ctx, chunk, params); err != nil {
+				return "", err
+			}
+
+			if !slices.Equal(outline(chunk), outline(improved)) {
+				return "", ErrOutlineChanged
+			}
+		}
+
+		result = append(result, improved)
+		logger.DebugContext(ctx, "chunk done")
 	}
 
 	return addNewline(strings.Join(result, "\n\n")), nil
 }
 
+// improveSections improves the sections of the document that are selected by
+// the Sections of the params and splices them back into the document, which is
+// otherwise kept unchanged.
+func (imp *Improver) improveSections(ctx context.Context, params ImproveParams) (string, error) {
+	sections, err := params.sections()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, section := range sections {
+		imp.logger.DebugContext(ctx, "improve section", "section", section.Title)
+
+		sectionParams := params.section(section)
+		improved, err := imp.Improve(ctx, sectionParams)
+		if err != nil {
+			return "", fmt.Errorf("section %q: %w", section.Title, err)
+		}
+
+		b.WriteString(params.Document[pos:section.Start])
+		b.WriteString(strings.TrimSpace(improved))
+		b.WriteString(params.Document[section.Start+len(sectionParams.Document) : section.End])
+		pos = section.End
+	}
+	b.WriteString(params.Document[pos:])
+
+	return b.String(), nil
+}
+
 func (imp *Improver) improveChunk(ctx context.Context, chunk string, params ImproveParams) (string, error) {
+	masked := mask(chunk, params.PreservePatterns)
+	for attempt := 0; ; attempt++ {
+		response, err := imp.chat(ctx, masked.text, params)
+		if err != nil {
+			return "", fmt.Errorf("llm error: %w", err)
+		}
+
+		improved, err := masked.restore(trimDividers(response))
+		if err != nil {
+			if attempt < 1 {
+				imp.logger.WarnContext(ctx, "retry invalid improvement", "error", err)
+				continue
+			}
+			return "", fmt.Errorf("validate chunk: %w", err)
+		}
+
+		return improved, nil
+	}
+}
+
+// chat sends the instructions and the chunk to the model, as a system and a
+// user message if the model is a [MessageModel], and as a single prompt
+// otherwise.
+func (imp *Improver) chat(ctx context.Context, chunk string, params ImproveParams) (string, error) {
+	model, ok := imp.model.(MessageModel)
+	if !ok {
+		return imp.model.Chat(ctx, imp.prompt(chunk, params))
+	}
+
+	return model.ChatMessages(ctx, []Message{
+		{Role: RoleSystem, Content: imp.instructions(chunk, params)},
+		{Role: RoleUser, Content: improveDocument(chunk)},
+	})
+}
+
+// prompt returns the single prompt for improving a chunk.
+func (imp *Improver) prompt(chunk string, params ImproveParams) string {
+	return imp.instructions(chunk, params) + "\n\n" + improveDocument(chunk)
+}
+
+// instructions returns the instructions of the prompt for improving a chunk.
+func (imp *Improver) instructions(chunk string, params ImproveParams) string {
 	optimizeKeywords := "Identify and utilize keywords naturally derived from the document's content."
 	if len(params.Keywords) > 0 {
 		optimizeKeywords = fmt.Sprintf("Incorporate the following keywords effectively throughout the document: %s", strings.Join(mapSlice(params.Keywords, quote), ", "))
 	}
 
+	sections := "You may introduce new sections or headings and reorganize existing content. Ensure these changes enhance the document’s overall message and readability while using the predefined formatting elements mentioned."
+	if params.PreserveOutline {
+		sections = "Keep the exact headings of the document in their original order. Do not add, remove, rename or reorder any headings or sections."
+		if headings := outline(chunk); len(headings) > 0 {
+			sections += fmt.Sprintf(" The document must contain exactly these headings:\n\t- %s", strings.Join(headings, "\n\t- "))
+		}
+	}
+
 	prompt := strings.TrimSpace(heredoc.Docf(`
 		Task: Improve the document provided below. The objective is to enhance the content to be more engaging, informative, and optimized for search engine visibility.
 
@@ -96,9 +311,9 @@ func (imp *Improver) improveChunk(ctx context.Context, chunk string, params Impr
 		2. Content Optimization:
 			- Engagement: Increase the text's appeal and readability by refining dense or uninviting sentences. Adjust titles and headings to be more compelling and clear.
 			- SEO: Optimize the text for search engines. Incorporate provided keywords effectively throughout the document. %s
-		3. You may introduce new sections or headings and reorganize existing content. Ensure these changes enhance the document’s overall message and readability while using the predefined formatting elements mentioned.
+		3. %s
 		4. Return only the revised document text. Exclude any additional commentary or discussion about the changes made.
-	`, optimizeKeywords))
+	`, optimizeKeywords, sections))
 
 	language := "5. Write in the same language as the original document."
 	if params.Language != "" {
@@ -116,18 +331,45 @@ func (imp *Improver) improveChunk(ctx context.Context, chunk string, params Impr
 		additionalInstructions = append(additionalInstructions, fmt.Sprintf("%d. %s", len(additionalInstructions)+6, params.Formality.instruction()))
 	}
 
+	if len(params.PreservePatterns) > 0 {
+		additionalInstructions = append(additionalInstructions, fmt.Sprintf("%d. Keep tokens like ⟦0⟧ exactly as they are.", len(additionalInstructions)+6))
+	}
+
 	if len(additionalInstructions) > 0 {
 		prompt += "\n" + strings.Join(additionalInstructions, "\n")
 	}
 
-	prompt += fmt.Sprintf("\n\nImprove the following document:\n---<DOC_BEGIN>---\n%s\n---<DOC_END>---", chunk)
-
-	response, err := imp.model.Chat(ctx, prompt)
-	if err != nil {
-		return "", fmt.Errorf("llm error: %w", err)
+	if section := contextSection(params.Context); section != "" {
+		prompt += "\n\n" + strings.TrimSpace(section)
 	}
 
-	return trimDividers(response), nil
+	return prompt
+}
+
+// improveDocument returns the part of the prompt that contains the chunk to
+// improve.
+func improveDocument(chunk string) string {
+	return fmt.Sprintf("Improve the following document:\n---<DOC_BEGIN>---\n%s\n---<DOC_END>---", chunk)
+}
+
+// outline returns the Markdown headings of a document in their original order,
+// ignoring lines within fenced code blocks.
+func outline(doc string) []string {
+	var (
+		headings []string
+		inFence  bool
+	)
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.HasPrefix(trimmed, "#") {
+			headings = append(headings, trimmed)
+		}
+	}
+	return headings
 }
 
 func quote(s string) string {
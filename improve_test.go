@@ -0,0 +1,280 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+func TestImprover_Improve_preserveOutline(t *testing.T) {
+	source := heredoc.Doc(`
+		# Title
+
+		Introduction.
+
+		## Section
+
+		Content.
+	`)
+
+	improved := heredoc.Doc(`
+		# Title
+
+		A better introduction.
+
+		## Section
+
+		Better content.
+	`)
+
+	changed := heredoc.Doc(`
+		# A Better Title
+
+		A better introduction.
+	`)
+
+	responses := []string{changed, improved}
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		resp := responses[0]
+		responses = responses[1:]
+		return resp, nil
+	})
+
+	result, err := dragoman.NewImprover(model).Improve(context.Background(), dragoman.ImproveParams{
+		Document:        source,
+		PreserveOutline: true,
+	})
+	if err != nil {
+		t.Fatalf("Improve(): %v", err)
+	}
+
+	if result != improved {
+		t.Fatalf("Improve(): got %q; want %q", result, improved)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 prompts; got %d", len(prompts))
+	}
+
+	if !strings.Contains(prompts[0], "- # Title\n\t- ## Section") {
+		t.Fatalf("prompt should list the outline of the document:\n\n%s", prompts[0])
+	}
+}
+
+func TestImprover_Improve_preserveOutline_error(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		return "# Another Title\n", nil
+	})
+
+	_, err := dragoman.NewImprover(model).Improve(context.Background(), dragoman.ImproveParams{
+		Document:        "# Title\n\nContent.\n",
+		PreserveOutline: true,
+	})
+	if !errors.Is(err, dragoman.ErrOutlineChanged) {
+		t.Fatalf("Improve() should fail with %q; got %v", dragoman.ErrOutlineChanged, err)
+	}
+}
+
+func TestImprover_Improve_sections(t *testing.T) {
+	source := heredoc.Doc(`
+		# Title
+
+		Introduction.
+
+		## Section 1
+
+		Content.
+
+		## Section 2
+
+		More content.
+
+		### Subsection
+
+		Even more content.
+
+		## Conclusion
+
+		Last words.
+	`)
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		switch {
+		case strings.Contains(prompt, "## Section 2"):
+			return "## Section 2\n\nBetter content.\n\n### Subsection\n\nEven better content.", nil
+		case strings.Contains(prompt, "## Conclusion"):
+			return "## Conclusion\n\nBetter last words.\n", nil
+		default:
+			return "", errors.New("unexpected prompt")
+		}
+	})
+
+	result, err := dragoman.NewImprover(model).Improve(context.Background(), dragoman.ImproveParams{
+		Document: source,
+		Sections: []string{"section 2", "Subsection", "Conclusion"},
+	})
+	if err != nil {
+		t.Fatalf("Improve(): %v", err)
+	}
+
+	want := heredoc.Doc(`
+		# Title
+
+		Introduction.
+
+		## Section 1
+
+		Content.
+
+		## Section 2
+
+		Better content.
+
+		### Subsection
+
+		Even better content.
+
+		## Conclusion
+
+		Better last words.
+	`)
+	if result != want {
+		t.Fatalf("Improve(): got %q; want %q", result, want)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 prompts; got %d", len(prompts))
+	}
+
+	if strings.Contains(prompts[0], "Introduction.") || strings.Contains(prompts[0], "## Conclusion") {
+		t.Fatalf("prompt should only contain the section:\n\n%s", prompts[0])
+	}
+}
+
+func TestImprover_Improve_sections_notFound(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		t.Fatal("model should not be called")
+		return "", nil
+	})
+
+	_, err := dragoman.NewImprover(model).Improve(context.Background(), dragoman.ImproveParams{
+		Document: "# Title\n\nContent.\n",
+		Sections: []string{"Conclusion"},
+	})
+	if !errors.Is(err, dragoman.ErrSectionNotFound) {
+		t.Fatalf("Improve() should fail with %q; got %v", dragoman.ErrSectionNotFound, err)
+	}
+}
+
+type improveMessageModel struct {
+	dragoman.Model
+	messages []dragoman.Message
+}
+
+func (m *improveMessageModel) ChatMessages(_ context.Context, msgs []dragoman.Message) (string, error) {
+	m.messages = msgs
+	return "Improved.", nil
+}
+
+func TestImprover_Improve_messageModel(t *testing.T) {
+	model := &improveMessageModel{}
+
+	result, err := dragoman.NewImprover(model).Improve(context.Background(), dragoman.ImproveParams{
+		Document: "Hello.",
+		Language: "English",
+	})
+	if err != nil {
+		t.Fatalf("Improve() failed: %v", err)
+	}
+	if strings.TrimSpace(result) != "Improved." {
+		t.Fatalf("Improve() should return %q; got %q", "Improved.", result)
+	}
+
+	if len(model.messages) != 2 || model.messages[0].Role != dragoman.RoleSystem || model.messages[1].Role != dragoman.RoleUser {
+		t.Fatalf("ChatMessages() should be called with a system and a user message; got %v", model.messages)
+	}
+	if system := model.messages[0].Content; !strings.Contains(system, "Write in the following language: English") || strings.Contains(system, "Hello.") {
+		t.Errorf("system message should contain the instructions but not the document; got\n\n%s", system)
+	}
+	if want := "Improve the following document:\n---<DOC_BEGIN>---\nHello.\n---<DOC_END>---"; model.messages[1].Content != want {
+		t.Errorf("user message should be %q; got %q", want, model.messages[1].Content)
+	}
+}
+
+func TestImprover_ImproveJSON(t *testing.T) {
+	source := heredoc.Doc(`
+		{
+		  "title": "Hello {name}",
+		  "buttons": ["Buy now", "Buy now"],
+		  "date": "2024-06-01",
+		  "count": 12345678901234567890,
+		  "nested": {
+		    "cta": "Click here"
+		  }
+		}
+	`)
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		_, doc, _ := strings.Cut(prompt, "---<DOC_BEGIN>---\n")
+		doc, _, _ = strings.Cut(doc, "\n---<DOC_END>---")
+		return "Better: " + doc, nil
+	})
+
+	result, err := dragoman.NewImprover(model).ImproveJSON(context.Background(), dragoman.ImproveParams{Document: source})
+	if err != nil {
+		t.Fatalf("ImproveJSON() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		{
+		  "title": "Better: Hello {name}",
+		  "buttons": [
+		    "Better: Buy now",
+		    "Better: Buy now"
+		  ],
+		  "date": "2024-06-01",
+		  "count": 12345678901234567890,
+		  "nested": {
+		    "cta": "Better: Click here"
+		  }
+		}
+	`)
+	if result != want {
+		t.Fatalf("ImproveJSON() should return\n\n%s\n\ngot\n\n%s", want, result)
+	}
+
+	if len(prompts) != 3 {
+		t.Fatalf("the model should be prompted once per distinct string; got %d prompts", len(prompts))
+	}
+	for _, prompt := range prompts {
+		if strings.Contains(prompt, "{name}") {
+			t.Fatalf("placeholders should be masked; got prompt\n\n%s", prompt)
+		}
+	}
+}
+
+func TestImprover_ImproveJSON_lostPlaceholder(t *testing.T) {
+	var calls int
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		calls++
+		return "Hello!", nil
+	})
+
+	_, err := dragoman.NewImprover(model).ImproveJSON(context.Background(), dragoman.ImproveParams{Document: `{"title": "Hello {name}"}`})
+	if !errors.Is(err, dragoman.ErrInvalidTranslation) {
+		t.Fatalf("ImproveJSON() should fail with %v; got %v", dragoman.ErrInvalidTranslation, err)
+	}
+	if calls != 2 {
+		t.Fatalf("the value should be improved twice; got %d calls", calls)
+	}
+}
@@ -0,0 +1,120 @@
+package dragoman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// jsonValueInstruction is added to the instructions of every string value
+// that is improved by [Improver.ImproveJSON].
+const jsonValueInstruction = "The document is a single text value of a JSON file, like a product description or a button label. Keep it about as long as the original and do not add headings, lists or other formatting that the original does not have."
+
+// ImproveJSON improves every string value of the JSON document of the params
+// independently, like the marketing copy of a CMS export. The keys, the values
+// that are not strings and the order and indentation of the keys are
+// preserved. Strings without letters, like numbers or dates, are kept, and
+// identical strings are improved only once. Placeholders that match
+// [DefaultPlaceholderPatterns] are masked and restored verbatim, unless the
+// PreservePatterns of the params are set. SplitChunks, SplitHeadingLevels,
+// PreserveOutline and Sections are ignored.
+func (imp *Improver) ImproveJSON(ctx context.Context, params ImproveParams) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(params.Document))
+	dec.UseNumber()
+
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return "", fmt.Errorf("decode JSON document: %w", err)
+	}
+
+	if params.PreservePatterns == nil {
+		for _, pattern := range DefaultPlaceholderPatterns {
+			params.PreservePatterns = append(params.PreservePatterns, regexp.MustCompile(pattern))
+		}
+	}
+
+	params.SplitChunks = nil
+	params.SplitHeadingLevels = nil
+	params.PreserveOutline = false
+	params.Sections = nil
+	params.Instructions = append(append([]string{}, params.Instructions...), jsonValueInstruction)
+
+	improved := make(map[string]string)
+	var walk func(key string, value any) (any, error)
+	walk = func(key string, value any) (any, error) {
+		switch v := value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				child := k
+				if key != "" {
+					child = key + "." + k
+				}
+				out, err := walk(child, v[k])
+				if err != nil {
+					return nil, err
+				}
+				v[k] = out
+			}
+			return v, nil
+		case []any:
+			for i, elem := range v {
+				out, err := walk(fmt.Sprintf("%s[%d]", key, i), elem)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = out
+			}
+			return v, nil
+		case string:
+			if strings.IndexFunc(v, unicode.IsLetter) < 0 {
+				return v, nil
+			}
+			if out, ok := improved[v]; ok {
+				return out, nil
+			}
+
+			imp.logger.DebugContext(ctx, "improve value", "key", key)
+
+			p := params
+			p.Document = strings.TrimSpace(v)
+			out, err := imp.Improve(ctx, p)
+			if err != nil {
+				return nil, fmt.Errorf("improve %q: %w", key, err)
+			}
+			out = strings.TrimSpace(out)
+			improved[v] = out
+			return out, nil
+		default:
+			return v, nil
+		}
+	}
+
+	if _, err := walk("", doc); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("encode JSON document: %w", err)
+	}
+
+	out, err := JSONOrder(buf.Bytes(), []byte(params.Document))
+	if err != nil {
+		return "", err
+	}
+
+	return addNewline(string(out)), nil
+}
@@ -0,0 +1,131 @@
+// Package atomicfile replaces files atomically and locks them against
+// concurrent writers, so that output files are never left half-written if a
+// process is killed and concurrent invocations do not clobber each other's
+// output.
+package atomicfile
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pollInterval is the interval at which a lock that is held by another process
+// is tried again.
+const pollInterval = 100 * time.Millisecond
+
+// WriteFile writes data to the file at the given path like [os.WriteFile], but
+// atomically: the data is written to a temporary file in the directory of the
+// path, synced to disk and renamed to the path. Readers see either the old or
+// the new content of the file, never a partial write. An existing file keeps
+// its permissions; new files are created with perm. If the path is a symbolic
+// link, the file it points to is replaced.
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Syncing the directory persists the rename. Not every platform supports
+	// it, so errors are ignored.
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
+
+// Lock acquires an exclusive advisory lock of the file at the given path by
+// locking the lock file path+".lock". If another process holds the lock, Lock
+// calls waiting, if it is not nil, and waits until the lock is released or ctx
+// is canceled. The returned function releases the lock and removes the lock
+// file. Locks are released by the operating system if the process exits.
+//
+// On platforms without file locking, Lock does not wait for other processes.
+func Lock(ctx context.Context, path string, waiting func()) (unlock func() error, err error) {
+	lockPath := path + ".lock"
+	notified := false
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open lock file: %w", err)
+		}
+
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock %q: %w", path, err)
+		}
+
+		// The process that held the lock before may have removed the lock
+		// file after it was opened here, in which case another process can
+		// already hold the lock of a new lock file.
+		if ok && isCurrent(f, lockPath) {
+			return func() error {
+				os.Remove(lockPath)
+				err := unlockFile(f)
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+				return err
+			}, nil
+		}
+		f.Close()
+
+		if ok {
+			continue
+		}
+
+		if !notified && waiting != nil {
+			waiting()
+			notified = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// isCurrent reports whether the open file is still the file at the path.
+func isCurrent(f *os.File, path string) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	return err == nil && os.SameFile(info, current)
+}
@@ -0,0 +1,99 @@
+package atomicfile_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/modernice/dragoman/internal/atomicfile"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "de.json")
+
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if err := atomicfile.WriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if string(b) != "new" {
+		t.Fatalf("file should contain %q; got %q", "new", b)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat file: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Fatalf("file should keep its permissions %v; got %v", fs.FileMode(0600), info.Mode().Perm())
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("WriteFile() should not leave temporary files; got %d files", len(entries))
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "de.json")
+
+	unlock, err := atomicfile.Lock(context.Background(), path, nil)
+	if err != nil {
+		t.Fatalf("Lock(): %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var waited bool
+	if _, err := atomicfile.Lock(ctx, path, func() { waited = true }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Lock() of a locked file should wait until the context is canceled; got %v", err)
+	}
+	if !waited {
+		t.Fatalf("Lock() of a locked file should report that it waits")
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		unlock, err := atomicfile.Lock(context.Background(), path, nil)
+		if err == nil {
+			err = unlock()
+		}
+		acquired <- err
+	}()
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("Lock() after unlock: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Lock() should acquire the lock once it is released")
+	}
+
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unlock should remove the lock file; got %v", err)
+	}
+}
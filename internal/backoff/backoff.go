@@ -0,0 +1,35 @@
+// Package backoff computes the delays between retries of failed requests to
+// the APIs of the providers.
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Max is the maximum delay that is returned by [Delay], so that many retries
+// do not wait for minutes.
+const Max = 30 * time.Second
+
+// Delay returns the exponential backoff delay for the given attempt with up to
+// 50% of random jitter added. The delay doubles with every attempt, starting
+// at base, and never exceeds [Max]. Delay returns 0 if base is not positive.
+func Delay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 0; i < attempt && delay < Max; i++ {
+		delay *= 2
+	}
+	if delay >= Max {
+		return Max
+	}
+
+	delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	if delay > Max {
+		return Max
+	}
+	return delay
+}
@@ -0,0 +1,34 @@
+package backoff_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/modernice/dragoman/internal/backoff"
+)
+
+func TestDelay(t *testing.T) {
+	if got := backoff.Delay(time.Second, 0); got < time.Second || got > 1500*time.Millisecond {
+		t.Errorf("Delay() of the first attempt should be between 1s and 1.5s; got %v", got)
+	}
+	if got := backoff.Delay(time.Second, 2); got < 4*time.Second || got > 6*time.Second {
+		t.Errorf("Delay() of the third attempt should be between 4s and 6s; got %v", got)
+	}
+
+	for _, attempt := range []int{10, 63, 64, 1000, math.MaxInt} {
+		if got := backoff.Delay(time.Second, attempt); got <= 0 || got > backoff.Max {
+			t.Errorf("Delay() of attempt %d should be limited to %v; got %v", attempt, backoff.Max, got)
+		}
+	}
+
+	if got := backoff.Delay(time.Duration(math.MaxInt64), 3); got != backoff.Max {
+		t.Errorf("Delay() of a huge base delay should return %v; got %v", backoff.Max, got)
+	}
+
+	for _, base := range []time.Duration{0, -time.Second} {
+		if got := backoff.Delay(base, 3); got != 0 {
+			t.Errorf("Delay() with base delay %v should return 0; got %v", base, got)
+		}
+	}
+}
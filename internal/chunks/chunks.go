@@ -1,6 +1,7 @@
 package chunks
 
 import (
+	"slices"
 	"strings"
 )
 
@@ -12,6 +13,150 @@ func Chunks(source string, splitPrefixes []string) []string {
 		return []string{source}
 	}
 
+	return split(source, func(line string) bool {
+		for _, prefix := range splitPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Headings splits a Markdown document before its ATX headings (e.g. "## Title")
+// of the given levels. Unlike [Chunks], lines in fenced code blocks are never
+// split, so that comments like "# install" in shell snippets do not start a
+// new chunk. If no levels are provided, it returns the entire document as a
+// single segment. Each segment is trimmed of leading and trailing whitespace.
+func Headings(source string, levels []int) []string {
+	if len(levels) == 0 {
+		return []string{source}
+	}
+
+	var headings headingScanner
+	return split(source, func(line string) bool {
+		level, _ := headings.scan(line)
+		return slices.Contains(levels, level)
+	})
+}
+
+// Section is a section of a Markdown document: an ATX heading and the lines
+// up to the next heading of the same or a higher level.
+type Section struct {
+	// Title is the text of the heading of the section.
+	Title string
+
+	// Level is the level of the heading of the section.
+	Level int
+
+	// Start and End are the byte offsets of the section in the document. The
+	// section includes the whitespace that precedes the next heading.
+	Start, End int
+}
+
+// Sections returns the sections of a Markdown document whose headings have
+// one of the given titles, in the order of the document. Titles are matched
+// case-insensitively. Sections may contain other returned sections. Headings
+// in fenced code blocks are ignored.
+func Sections(source string, titles []string) []Section {
+	var (
+		headings headingScanner
+		sections []Section
+		open     []Section
+		offset   int
+	)
+
+	closeSections := func(level int) {
+		for len(open) > 0 && open[len(open)-1].Level >= level {
+			section := open[len(open)-1]
+			section.End = offset
+			sections = append(sections, section)
+			open = open[:len(open)-1]
+		}
+	}
+
+	for _, line := range strings.SplitAfter(source, "\n") {
+		level, title := headings.scan(strings.TrimSuffix(line, "\n"))
+		if level > 0 {
+			closeSections(level)
+			if slices.ContainsFunc(titles, func(t string) bool {
+				return strings.EqualFold(strings.TrimSpace(t), title)
+			}) {
+				open = append(open, Section{Title: title, Level: level, Start: offset})
+			}
+		}
+		offset += len(line)
+	}
+	closeSections(1)
+
+	slices.SortStableFunc(sections, func(a, b Section) int {
+		return a.Start - b.Start
+	})
+
+	return sections
+}
+
+// headingScanner finds the ATX headings of a Markdown document, skipping
+// fenced code blocks. Its scan method must be called for every line in order.
+type headingScanner struct {
+	fence string
+}
+
+// scan returns the level and the text of the heading of the line, or 0 if the
+// line is not a heading.
+func (s *headingScanner) scan(line string) (int, string) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, ""
+	}
+
+	if marker := fenceMarker(trimmed); marker != "" {
+		switch {
+		case s.fence == "":
+			s.fence = marker
+		case strings.HasPrefix(marker, s.fence) && strings.TrimSpace(trimmed[len(marker):]) == "":
+			s.fence = ""
+		}
+		return 0, ""
+	}
+	if s.fence != "" {
+		return 0, ""
+	}
+
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+
+	// Closing sequences like "## Title ##" are not part of the text.
+	title := strings.TrimSpace(rest)
+	if t := strings.TrimRight(title, "#"); t != title && (t == "" || strings.HasSuffix(t, " ")) {
+		title = strings.TrimSpace(t)
+	}
+
+	return level, title
+}
+
+// fenceMarker returns the backticks or tildes that open or close a fenced
+// code block at the beginning of the line, or an empty string.
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// split splits the source before the lines for which isBoundary returns true.
+// The first line never starts a new segment. isBoundary is called for every
+// line in order.
+func split(source string, isBoundary func(line string) bool) []string {
 	lines := strings.Split(source, "\n")
 
 	var chunks []string
@@ -27,16 +172,8 @@ func Chunks(source string, splitPrefixes []string) []string {
 	}
 
 	for _, line := range lines {
-		if len(currentChunk) == 0 {
-			currentChunk = append(currentChunk, line)
-			continue
-		}
-
-		for _, prefix := range splitPrefixes {
-			if strings.HasPrefix(line, prefix) {
-				appendChunk()
-				break
-			}
+		if isBoundary(line) && len(currentChunk) > 0 {
+			appendChunk()
 		}
 
 		currentChunk = append(currentChunk, line)
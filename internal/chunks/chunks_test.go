@@ -109,6 +109,71 @@ func TestChunks(t *testing.T) {
 	}
 }
 
+func TestHeadings(t *testing.T) {
+	source := strings.TrimSpace(heredoc.Doc(`
+		# Title
+
+		Introduction.
+
+		## Install
+
+		` + "```sh" + `
+		# install the CLI
+		go install ./cmd/dragoman
+		` + "```" + `
+
+		### Homebrew
+
+		Use brew.
+
+		##Not a heading
+
+		## Usage
+
+		Run it.
+	`))
+
+	tests := []struct {
+		name     string
+		levels   []int
+		expected []string
+	}{
+		{
+			name:     "no levels",
+			expected: []string{source},
+		},
+		{
+			name:   "level 2",
+			levels: []int{2},
+			expected: []string{
+				"# Title\n\nIntroduction.",
+				"## Install\n\n```sh\n# install the CLI\ngo install ./cmd/dragoman\n```\n\n### Homebrew\n\nUse brew.\n\n##Not a heading",
+				"## Usage\n\nRun it.",
+			},
+		},
+		{
+			name:   "levels 2 and 3",
+			levels: []int{2, 3},
+			expected: []string{
+				"# Title\n\nIntroduction.",
+				"## Install\n\n```sh\n# install the CLI\ngo install ./cmd/dragoman\n```",
+				"### Homebrew\n\nUse brew.\n\n##Not a heading",
+				"## Usage\n\nRun it.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := chunks.Headings(source, tt.levels)
+
+			if !cmp.Equal(tt.expected, chunks) {
+				t.Errorf("unexpected chunks (-want +got):\n%s", cmp.Diff(tt.expected, chunks))
+			}
+		})
+	}
+}
+
 func takeLines(s string, n int) string {
 	lines := strings.Split(s, "\n")
 	if n >= len(lines) {
@@ -128,3 +193,31 @@ func skipLines(s string, n int) string {
 func skipAndTakeLines(s string, skip, take int) string {
 	return takeLines(skipLines(s, skip), take)
 }
+
+func TestSections(t *testing.T) {
+	source := "# Title\n\nIntroduction.\n\n" +
+		"## Section 2\n\n```sh\n# Conclusion\n```\n\n### Subsection\n\nMore content.\n\n" +
+		"## Section 3 ##\n\nContent.\n\n" +
+		"## Conclusion\n\nLast words.\n"
+
+	sections := chunks.Sections(source, []string{"section 2", "Subsection", "Section 3", "conclusion", "Missing"})
+
+	var got []string
+	for _, s := range sections {
+		got = append(got, source[s.Start:s.End])
+	}
+
+	want := []string{
+		"## Section 2\n\n```sh\n# Conclusion\n```\n\n### Subsection\n\nMore content.\n\n",
+		"### Subsection\n\nMore content.\n\n",
+		"## Section 3 ##\n\nContent.\n\n",
+		"## Conclusion\n\nLast words.\n",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected sections (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if sections[2].Title != "Section 3" || sections[2].Level != 2 {
+		t.Errorf("unexpected section %+v", sections[2])
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/applestrings"
+	"github.com/modernice/dragoman/format/xcstrings"
+)
+
+var localeCode = regexp.MustCompile(`^[a-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// translateAppleStrings translates the entries of a strings file. If the
+// output file already exists, the entries that are missing in it are
+// translated and appended; existing translations are kept. Translations that
+// do not keep the format specifiers of their source are discarded.
+func (app *App) translateAppleStrings(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	src, err := applestrings.Parse(source)
+	app.fatalIfErrorf(err, "failed to parse strings file")
+
+	var target *applestrings.File
+	if existing := app.readExistingOut(); existing != nil {
+		target, err = applestrings.Parse(existing)
+		app.fatalIfErrorf(err, "failed to parse strings file %q", options.Translate.Out)
+	}
+
+	f, err := applestrings.Merge(src, target)
+	app.fatalIfErrorf(err, "failed to merge strings files")
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+// translateStringCatalog adds the localizations of the target locale (--to)
+// to the strings of a string catalog that have none yet. If the output file
+// already exists, it is used as the catalog, so that translating a catalog in
+// place only adds the missing localizations. Localizations whose translations
+// do not keep the format specifiers of their source are not added.
+func (app *App) translateStringCatalog(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	locale := app.params.TargetLang
+	if !localeCode.MatchString(locale) {
+		app.fatalf(exitConfig, "string catalogs require a locale code like 'de' or 'pt-BR' for --to; got %q", locale)
+	}
+
+	doc := source
+	if existing := app.readExistingOut(); existing != nil {
+		doc = existing
+	}
+
+	c, err := xcstrings.Parse(doc)
+	app.fatalIfErrorf(err, "failed to parse string catalog")
+
+	if locale == c.SourceLanguage {
+		app.fatalf(exitConfig, "%q is the source language of the string catalog", locale)
+	}
+
+	entries := c.Untranslated(locale)
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(c.Bytes()))
+}
+
+// readExistingOut returns the content of the output file, or nil if there is
+// no output file yet.
+func (app *App) readExistingOut() []byte {
+	if options.Translate.Out == "" {
+		return nil
+	}
+
+	existing, err := os.ReadFile(options.Translate.Out)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	app.fatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
+
+	return existing
+}
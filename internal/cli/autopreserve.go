@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// autoPreserve asks the model for the product names, trademarks and other
+// proper nouns of the source and adds them to the preserved terms, if
+// --auto-preserve is set. Only the values of JSON documents are sent to the
+// model, and the sensitive texts of --scrub are masked.
+func (app *App) autoPreserve(ctx context.Context, model dragoman.Model, source []byte) {
+	if !options.Translate.AutoPreserve || options.Translate.Estimate || options.Translate.PrintPrompts {
+		return
+	}
+	app.requireModel("--auto-preserve")
+
+	text := string(source)
+	var doc map[string]any
+	if isJSONFile(sourceFile()) && json.Unmarshal(source, &doc) == nil {
+		var values []string
+		for _, leaf := range sortedLeaves(jsonLeaves(doc)) {
+			values = append(values, leaf.text)
+		}
+		text = strings.Join(values, "\n")
+	}
+	for _, pattern := range app.scrubPatterns() {
+		text = pattern.ReplaceAllString(text, "***")
+	}
+
+	terms, err := dragoman.DetectTerms(ctx, model, text)
+	app.fatalIfErrorf(err, "failed to detect the terms to preserve")
+
+	params := &options.Translate.Params
+	var added []string
+	for _, term := range terms {
+		if !slices.Contains(params.Preserve, term) {
+			params.Preserve = append(params.Preserve, term)
+			added = append(added, term)
+		}
+	}
+
+	if options.Verbose {
+		app.progress.clear()
+		fmt.Fprintf(os.Stderr, "Preserving %d detected terms: %s\n", len(added), strings.Join(added, ", "))
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/modernice/dragoman/openai"
+)
+
+// batchSubmit collects the prompts of all pending translations of the sync
+// targets without calling the model and submits them as a single job to the
+// OpenAI Batch API.
+func (app *App) batchSubmit() {
+	app.requireOpenAI("batch")
+	app.submitting = true
+	app.sync(options.Batch.Submit.Targets, false)
+
+	if len(app.prompts) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to translate.")
+		return
+	}
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	id, err := app.model().SubmitBatch(ctx, app.prompts)
+	app.fatalIfErrorf(err, "failed to submit batch")
+
+	fmt.Fprintln(os.Stdout, id)
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Submitted %d prompts. Run 'dragoman batch collect %s' once the batch has completed.\n", len(app.prompts), id)
+	}
+}
+
+// batchCollect retrieves the results of a completed batch job and translates
+// the sync targets again, answering the prompts from the batch results instead
+// of the model. The source and output files must not change between submitting
+// and collecting a batch, otherwise the prompts cannot be matched.
+func (app *App) batchCollect() {
+	app.requireOpenAI("batch")
+	ctx, cancel := app.context()
+	defer cancel()
+
+	results, err := app.model().CollectBatch(ctx, options.Batch.Collect.ID)
+	if errors.Is(err, openai.ErrBatchNotCompleted) {
+		app.fatalf(exitFailure, "%v", err)
+	}
+	app.fatalIfErrorf(err, "failed to collect batch")
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Collected %d responses.\n", results.Len())
+	}
+
+	app.replay = results
+	app.sync(options.Batch.Collect.Targets, false)
+}
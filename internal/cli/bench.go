@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/openai"
+)
+
+// benchWorkload is the built-in document of the bench command. It is short
+// enough to be cheap, but contains the usual building blocks of a document:
+// headings, prose, a list, inline code and a link.
+const benchWorkload = `# Getting Started
+
+Welcome to the documentation of our product. This guide explains how to
+install the command-line tool, how to configure your first project and where
+to find help if something goes wrong.
+
+## Installation
+
+Download the latest release for your operating system and add the binary to
+your PATH. You can verify the installation by running ` + "`tool --version`" + `.
+
+## Configuration
+
+Create a configuration file in the root directory of your project. The most
+important settings are:
+
+- the languages your content is written in,
+- the directories that contain your documents,
+- the files that should be ignored.
+
+If you have any questions, please visit our [support page](https://example.com/support).
+`
+
+// benchmark is a backend that is compared by the bench command.
+type benchmark struct {
+	name       string
+	model      string
+	deepl      bool
+	translator *dragoman.Translator
+}
+
+// benchResult is the measurement of a benchmark for a single target language.
+type benchResult struct {
+	runs             int
+	total, min, max  time.Duration
+	promptTokens     int
+	completionTokens int
+}
+
+// bench translates a small standardized workload with each backend and reports
+// the latency, throughput and cost of the translations, so that users can
+// choose the fastest or cheapest backend for their language pairs. Output
+// tokens are counted with the OpenAI tokenizer for every backend, so that the
+// throughput of DeepL and OpenAI models is comparable.
+func (app *App) bench() {
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the bench command")
+	}
+
+	opts := &options.Bench
+	if opts.Runs < 1 {
+		app.fatalf(exitConfig, "--runs must be at least 1")
+	}
+
+	workload := benchWorkload
+	if opts.Workload != "" {
+		data, err := os.ReadFile(opts.Workload)
+		app.fatalIfErrorf(err, "failed to read workload %q", opts.Workload)
+		workload = string(data)
+	}
+
+	backends := opts.Backends
+	if len(backends) == 0 {
+		backends = []string{options.Provider}
+		if !app.usesDeepL() {
+			backends[0] += ":" + options.OpenAIModel
+		}
+	}
+
+	app.languageName("--from", opts.From)
+	for _, target := range opts.To {
+		app.languageName("--to", target)
+	}
+
+	benchmarks := make([]benchmark, len(backends))
+	for i, backend := range backends {
+		benchmarks[i] = app.benchmark(backend)
+	}
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "BACKEND\tTARGET\tRUNS\tAVG LATENCY\tMIN\tMAX\tTOKENS/S\tCOST/RUN\t")
+
+	for _, b := range benchmarks {
+		for _, target := range opts.To {
+			params := dragoman.TranslateParams{
+				Document: workload,
+				Source:   opts.From,
+				Target:   target,
+			}
+
+			res, err := app.runBenchmark(ctx, b, params)
+			if err != nil {
+				if ctx.Err() != nil {
+					app.fatalf(exitFailure, "benchmark interrupted")
+				}
+				app.warn("benchmark of %s (%s) failed: %v", b.name, target, err)
+				fmt.Fprintf(w, "%s\t%s\t%d\tfailed\t\t\t\t\t\n", b.name, target, res.runs)
+				continue
+			}
+
+			avg := res.total / time.Duration(res.runs)
+			throughput := float64(res.completionTokens) / res.total.Seconds()
+
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%.1f\t%s\t\n",
+				b.name, target, res.runs,
+				benchDuration(avg), benchDuration(res.min), benchDuration(res.max),
+				throughput, benchCost(b, res),
+			)
+		}
+	}
+
+	w.Flush()
+}
+
+// benchmark creates the translator of the given backend ("deepl",
+// "openai:<model>" or "compat:<model>").
+func (app *App) benchmark(backend string) benchmark {
+	provider, model, _ := strings.Cut(backend, ":")
+
+	switch provider {
+	case "deepl":
+		if model != "" {
+			app.fatalf(exitConfig, "invalid backend %q: DeepL has no models", backend)
+		}
+		return benchmark{
+			name:       backend,
+			model:      options.OpenAIModel,
+			deepl:      true,
+			translator: dragoman.NewTranslator(nil, dragoman.TranslateWith(app.deepl())),
+		}
+	case "openai":
+		if model == "" {
+			model = options.OpenAIModel
+		}
+		return benchmark{
+			name:       "openai:" + model,
+			model:      model,
+			translator: dragoman.NewTranslator(openai.New(options.OpenAIKey, app.openaiOptions(model)...)),
+		}
+	case "compat":
+		if model == "" {
+			model = options.OpenAIModel
+		}
+		opts := append(app.openaiOptions(model), app.compatOptions()...)
+		return benchmark{
+			name:       "compat:" + model,
+			model:      model,
+			translator: dragoman.NewTranslator(openai.New(options.CompatKey, opts...)),
+		}
+	default:
+		app.fatalf(exitConfig, "invalid backend %q: expected 'deepl', 'openai:<model>' or 'compat:<model>'", backend)
+		return benchmark{}
+	}
+}
+
+// runBenchmark translates the workload --runs times with the given backend
+// and measures each translation.
+func (app *App) runBenchmark(ctx context.Context, b benchmark, params dragoman.TranslateParams) (benchResult, error) {
+	var res benchResult
+
+	tokens := func(text string) (int, error) {
+		return openai.PromptTokens(b.model, text)
+	}
+
+	if !b.deepl {
+		est, err := b.translator.Estimate(dragoman.EstimateParams{TranslateParams: params, Tokens: tokens})
+		if err != nil {
+			return res, fmt.Errorf("count prompt tokens: %w", err)
+		}
+		res.promptTokens = est.PromptTokens * options.Bench.Runs
+	}
+
+	for i := 0; i < options.Bench.Runs; i++ {
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Translating workload to %s with %s (%d/%d) ...\n", params.Target, b.name, i+1, options.Bench.Runs)
+		}
+
+		start := time.Now()
+		result, err := b.translator.Translate(ctx, params)
+		elapsed := time.Since(start)
+		if err != nil {
+			return res, err
+		}
+
+		completion, err := tokens(result)
+		if err != nil {
+			return res, fmt.Errorf("count output tokens: %w", err)
+		}
+
+		res.runs++
+		res.total += elapsed
+		res.completionTokens += completion
+		if res.min == 0 || elapsed < res.min {
+			res.min = elapsed
+		}
+		if elapsed > res.max {
+			res.max = elapsed
+		}
+	}
+
+	return res, nil
+}
+
+// benchCost returns the average cost of a run, or "unknown" if the backend
+// has no token pricing.
+func benchCost(b benchmark, res benchResult) string {
+	if b.deepl {
+		return "unknown"
+	}
+
+	prompt, completion, ok := openai.ModelPricing(b.model)
+	if !ok {
+		return "unknown"
+	}
+
+	pricing := dragoman.Pricing{Prompt: prompt, Completion: completion}
+	return fmt.Sprintf("$%.4f", pricing.Cost(res.promptTokens, res.completionTokens)/float64(res.runs))
+}
+
+func benchDuration(d time.Duration) string {
+	return d.Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/openai"
+)
+
+// budget returns the budget of --max-cost and --max-tokens-total, which is
+// shared by all translations of the run, or nil if neither is set.
+func (app *App) budget() *dragoman.Budget {
+	if options.MaxCost <= 0 && options.MaxTokensTotal <= 0 {
+		return nil
+	}
+
+	if app.spending == nil {
+		app.requireModel("--max-cost and --max-tokens-total")
+		if options.MaxCost > 0 {
+			if _, _, priced := openai.ModelPricing(options.OpenAIModel); !priced || options.Provider != "openai" {
+				app.fatalf(exitConfig, "no pricing available for model %q; use --max-tokens-total instead of --max-cost", options.OpenAIModel)
+			}
+		}
+
+		app.spending = &dragoman.Budget{
+			MaxTokens: options.MaxTokensTotal,
+			MaxCost:   options.MaxCost,
+			Pricing:   modelPricing(),
+			Tokens: func(text string) (int, error) {
+				return openai.PromptTokens(options.OpenAIModel, text)
+			},
+		}
+	}
+
+	return app.spending
+}
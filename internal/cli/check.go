@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/androidxml"
+	"github.com/modernice/dragoman/format/applestrings"
+	"github.com/modernice/dragoman/format/csv"
+	"github.com/modernice/dragoman/format/jsonc"
+	"github.com/modernice/dragoman/format/po"
+	"github.com/modernice/dragoman/format/properties"
+	"github.com/modernice/dragoman/format/resx"
+	"github.com/modernice/dragoman/format/xcstrings"
+	"github.com/modernice/dragoman/format/xliff"
+	"github.com/modernice/dragoman/format/xml"
+)
+
+// finding is the result of a command that runs in check-only mode. It reports
+// whether the output of the command is up to date and, if it is not, why and
+// which texts still need to be translated.
+type finding struct {
+	Command string   `json:"command"`
+	Source  string   `json:"source,omitempty"`
+	Out     string   `json:"out"`
+	Status  string   `json:"status"`
+	Reason  string   `json:"reason,omitempty"`
+	Pending []string `json:"pending,omitempty"`
+	Stale   []string `json:"stale,omitempty"`
+}
+
+// checkTranslate reports the pending work of the translate command without
+// calling the model or writing any files.
+func (app *App) checkTranslate(source []byte) {
+	f := finding{
+		Command: "translate",
+		Source:  options.Translate.SourcePath,
+		Out:     options.Translate.Out,
+	}
+
+	target, err := os.ReadFile(options.Translate.Out)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		app.fatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
+	}
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		f.Reason = "output file does not exist"
+	case selectsXML():
+		src, err := xml.Parse(source, xmlSelectors())
+		app.fatalIfErrorf(err, "failed to parse XML document %q", options.Translate.SourcePath)
+		doc, err := xml.Parse(target, xmlSelectors())
+		app.fatalIfErrorf(err, "failed to parse XML document %q", options.Translate.Out)
+		merged, err := xml.Merge(src, doc)
+		app.fatalIfErrorf(err, "failed to merge XML documents")
+		for _, entry := range merged.Untranslated() {
+			f.Pending = append(f.Pending, entry.Key)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated texts"
+		}
+	case isJSONCFile(sourceFile()) || isJSONFile(sourceFile()) && isJSONCDocument(source):
+		src, err := jsonc.Parse(source)
+		app.fatalIfErrorf(err, "failed to parse JSONC document %q", options.Translate.SourcePath)
+		doc, err := jsonc.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse JSONC document %q", options.Translate.Out)
+		for _, entry := range jsonc.Merge(src, doc).Untranslated() {
+			f.Pending = append(f.Pending, entry.Key())
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "missing values"
+		}
+	case isPOFile(sourceFile()):
+		catalog, err := po.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse PO file %q", options.Translate.Out)
+		for _, entry := range catalog.Untranslated() {
+			f.Pending = append(f.Pending, entry.ID)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated messages"
+		}
+	case isXLIFFFile(sourceFile()):
+		doc, err := xliff.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse XLIFF file %q", options.Translate.Out)
+		for _, unit := range doc.Untranslated() {
+			f.Pending = append(f.Pending, unit.ID)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated units"
+		}
+	case isAndroidXMLFile(sourceFile()):
+		src, err := androidxml.Parse(source)
+		app.fatalIfErrorf(err, "failed to parse Android resource file %q", options.Translate.SourcePath)
+		doc, err := androidxml.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse Android resource file %q", options.Translate.Out)
+		merged, err := androidxml.Merge(src, doc)
+		app.fatalIfErrorf(err, "failed to merge Android resource files")
+		for _, entry := range merged.Untranslated() {
+			f.Pending = append(f.Pending, entry.Key)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated strings"
+		}
+	case isAppleStringsFile(sourceFile()):
+		src, err := applestrings.Parse(source)
+		app.fatalIfErrorf(err, "failed to parse strings file %q", options.Translate.SourcePath)
+		doc, err := applestrings.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse strings file %q", options.Translate.Out)
+		merged, err := applestrings.Merge(src, doc)
+		app.fatalIfErrorf(err, "failed to merge strings files")
+		for _, entry := range merged.Untranslated() {
+			f.Pending = append(f.Pending, entry.Key)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated strings"
+		}
+	case isPropertiesFile(sourceFile()):
+		src, err := properties.Parse(source)
+		app.fatalIfErrorf(err, "failed to parse properties file %q", options.Translate.SourcePath)
+		doc, err := properties.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse properties file %q", options.Translate.Out)
+		merged, err := properties.Merge(src, doc)
+		app.fatalIfErrorf(err, "failed to merge properties files")
+		for _, entry := range merged.Untranslated() {
+			f.Pending = append(f.Pending, entry.Key)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated strings"
+		}
+	case isResxFile(sourceFile()):
+		src, err := resx.Parse(source)
+		app.fatalIfErrorf(err, "failed to parse resource file %q", options.Translate.SourcePath)
+		doc, err := resx.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse resource file %q", options.Translate.Out)
+		merged, err := resx.Merge(src, doc)
+		app.fatalIfErrorf(err, "failed to merge resource files")
+		for _, entry := range merged.Untranslated() {
+			f.Pending = append(f.Pending, entry.Key)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated strings"
+		}
+	case isStringCatalogFile(sourceFile()):
+		catalog, err := xcstrings.Parse(target)
+		app.fatalIfErrorf(err, "failed to parse string catalog %q", options.Translate.Out)
+		for _, entry := range catalog.Untranslated(options.Translate.Params.TargetLang) {
+			f.Pending = append(f.Pending, entry.Key)
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "missing localizations"
+		}
+	case isCSVFile(sourceFile()) && options.Translate.Update:
+		src, columns := app.parseCSV(source)
+		doc, err := csv.Parse(target, csv.Delimiter(options.Translate.Out))
+		app.fatalIfErrorf(err, "failed to parse CSV file %q", options.Translate.Out)
+		merged, err := csv.Merge(src, doc, columns)
+		app.fatalIfErrorf(err, "failed to merge CSV files")
+		for _, entry := range merged.Untranslated() {
+			f.Pending = append(f.Pending, fmt.Sprintf("row %d/%s", entry.Row, entry.Column))
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated cells"
+		}
+	case options.Translate.Update && isHTMLFile(options.Translate.Out):
+		var previous []byte
+		if options.Translate.Previous != "" {
+			previous, err = os.ReadFile(options.Translate.Previous)
+			app.fatalIfErrorf(err, "failed to read previous source file %q", options.Translate.Previous)
+		}
+
+		update, err := dragoman.HTMLDiff(previous, source, target)
+		app.fatalIfErrorf(err, "failed to diff source and target")
+
+		pending := update.Pending()
+		ids := make([]string, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		sortIDs(ids)
+		for _, id := range ids {
+			f.Pending = append(f.Pending, pending[id])
+		}
+		if len(f.Pending) > 0 {
+			f.Reason = "untranslated text nodes"
+		}
+	case options.Translate.Update:
+		target = app.nestKeys(target, fmt.Sprintf("target file %q", options.Translate.Out))
+		paths, err := dragoman.JSONDiff(source, target)
+		app.fatalIfErrorf(err, "failed to diff source and target")
+		if options.Translate.Since != "" || options.Translate.Provenance || len(options.Translate.ForceKeys) > 0 || options.Translate.I18next {
+			var sourceMap, targetMap map[string]any
+			app.fatalIfErrorf(json.Unmarshal(source, &sourceMap), "failed to unmarshal source as JSON")
+			app.fatalIfErrorf(json.Unmarshal(target, &targetMap), "failed to unmarshal target file %q", options.Translate.Out)
+			app.loadProvenance()
+			paths, err = dragoman.JSONDiff(app.i18nextExpand(sourceMap), targetMap)
+			app.fatalIfErrorf(err, "failed to diff source and target")
+			paths = app.changedSince(paths, sourceMap)
+			paths = app.changedProvenance(paths, sourceMap, targetMap)
+			paths = app.forcedKeys(paths, sourceMap)
+		}
+		for _, path := range paths {
+			f.Pending = append(f.Pending, strings.Join(path, "."))
+		}
+		sortIDs(f.Pending)
+		if options.Translate.Prune {
+			f.Stale = app.staleKeys(source, target)
+		}
+		switch {
+		case len(f.Pending) > 0 && len(f.Stale) > 0:
+			f.Reason = "missing and stale fields"
+		case len(f.Pending) > 0:
+			f.Reason = "missing fields"
+		case len(f.Stale) > 0:
+			f.Reason = "stale fields"
+		}
+	default:
+		if isOutdated(options.Translate.SourcePath, options.Translate.Out) {
+			f.Reason = "output file is older than source file"
+		}
+	}
+
+	app.report(f)
+}
+
+// checkImprove reports whether the output of the improve command is up to
+// date without calling the model or writing any files.
+func (app *App) checkImprove() {
+	f := finding{
+		Command: "improve",
+		Source:  options.Improve.SourcePath,
+		Out:     options.Improve.Out,
+	}
+
+	if _, err := os.Stat(options.Improve.Out); errors.Is(err, fs.ErrNotExist) {
+		f.Reason = "output file does not exist"
+	} else if isOutdated(options.Improve.SourcePath, options.Improve.Out) {
+		f.Reason = "output file is older than source file"
+	}
+
+	app.report(f)
+}
+
+// report prints the finding as JSON to stdout and records whether there is
+// pending work, so that the command exits with exitValidation.
+func (app *App) report(f finding) {
+	f.Status = "ok"
+	if f.Reason != "" {
+		f.Status = "pending"
+	}
+
+	out, err := jsonMarshal(f)
+	app.fatalIfErrorf(err, "failed to marshal findings")
+	fmt.Fprint(os.Stdout, string(out))
+
+	if f.Status != "ok" {
+		app.pending = true
+	}
+}
+
+// checkWritable fails early if the output file cannot be written, for example
+// because of its file permissions or a read-only file system, so that no API
+// requests are made for a result that cannot be saved.
+func (app *App) checkWritable(path string) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		f.Close()
+		return
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		app.fatalf(exitFailure, "output file %q is not writable: %v", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".dragoman-*")
+	if err != nil {
+		app.fatalf(exitFailure, "cannot create output file %q: %v", path, err)
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+}
+
+func isOutdated(source, out string) bool {
+	if source == "" {
+		return false
+	}
+
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return false
+	}
+
+	outInfo, err := os.Stat(out)
+	if err != nil {
+		return true
+	}
+
+	return sourceInfo.ModTime().After(outInfo.ModTime())
+}
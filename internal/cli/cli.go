@@ -9,50 +9,324 @@ import (
 	"io"
 	"io/fs"
 	"os"
-	"os/signal"
-	"syscall"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/androidxml"
+	"github.com/modernice/dragoman/format/markdown"
+	"github.com/modernice/dragoman/format/po"
+	"github.com/modernice/dragoman/format/xliff"
 	"github.com/modernice/dragoman/internal/chunks"
+	"github.com/modernice/dragoman/internal/provenance"
 	"github.com/modernice/dragoman/openai"
+	"github.com/modernice/dragoman/provider"
 )
 
+type translationOptions struct {
+	SourceLang   string   `name:"from" short:"f" help:"Source language" env:"DRAGOMAN_SOURCE_LANG" default:"auto"`
+	TargetLang   string   `name:"to" short:"t" help:"Target language" env:"DRAGOMAN_TARGET_LANG" default:"English"`
+	Preserve     []string `short:"p" help:"Preserve the specified terms/words" env:"DRAGOMAN_PRESERVE"`
+	Instructions []string `name:"instruct" short:"i" help:"Additional instructions for the prompt" env:"DRAGOMAN_INSTRUCT"`
+	Context      []string `name:"context" help:"Reference files (e.g. brand guides or existing translations) to include in the prompt" type:"path" env:"DRAGOMAN_CONTEXT"`
+	SystemPrompt string   `name:"system" help:"System message that steers the style of the translation" env:"DRAGOMAN_SYSTEM"`
+	Examples     []string `name:"example" help:"Example translation as 'source=translation' that is shown to the model before each chunk (can be repeated)" env:"DRAGOMAN_EXAMPLE" sep:"none"`
+
+	Formality  dragoman.Formality `name:"formality" help:"Formality of the translation ('formal' or 'informal')" env:"DRAGOMAN_FORMALITY" enum:",formal,informal" default:""`
+	Validate   bool               `help:"Validate the structure of translated JSON documents" env:"DRAGOMAN_VALIDATE" default:"true" negatable:""`
+	PromptFile string             `name:"prompt-file" help:"Go text/template file that replaces the built-in translation prompt" type:"existingfile" env:"DRAGOMAN_PROMPT_FILE"`
+
+	OnRefusal      string `name:"on-refusal" help:"What to do if the model refuses to translate a chunk ('skip' leaves it untranslated, 'fail' aborts)" env:"DRAGOMAN_ON_REFUSAL" enum:"skip,fail" default:"skip"`
+	RefusalRetries int    `name:"refusal-retries" help:"Number of times a refused chunk is translated again, asking the model for a faithful localization" env:"DRAGOMAN_REFUSAL_RETRIES"`
+	OnError        string `name:"on-error" help:"What to do if a chunk fails to translate ('abort', 'skip' leaves it untranslated, 'retry N' translates it again up to N times)" env:"DRAGOMAN_ON_ERROR" default:"abort"`
+
+	ChunkTotalTimeout time.Duration `name:"chunk-total-timeout" help:"Maximum time to translate each chunk, including its retries; a chunk that exceeds it fails like other failed chunks (see --on-error)" env:"DRAGOMAN_CHUNK_TOTAL_TIMEOUT"`
+	RunTimeout        time.Duration `name:"run-timeout" help:"Maximum time to translate each document; the translation aborts if it is exceeded" env:"DRAGOMAN_RUN_TIMEOUT"`
+
+	Scrub            []string `name:"scrub" help:"Kinds of sensitive texts ('secrets' like API keys and tokens, 'emails') that are kept away from the model (see --scrub-action)" env:"DRAGOMAN_SCRUB"`
+	ScrubAction      string   `name:"scrub-action" help:"What to do with the sensitive texts of --scrub ('mask' masks them before translation and restores them, 'abort' fails before anything is sent)" env:"DRAGOMAN_SCRUB_ACTION" enum:"mask,abort" default:"mask"`
+	PreservePatterns []string `name:"preserve-patterns" help:"Regular expressions of texts, like placeholders, that are masked before translation and restored verbatim" env:"DRAGOMAN_PRESERVE_PATTERNS" sep:"none"`
+	MaxLengthRatio   float64  `name:"max-length-ratio" help:"Maximum length of translations as a multiple of the length of their source (e.g. 1.2); longer translations are translated again with a stricter prompt" env:"DRAGOMAN_MAX_LENGTH_RATIO"`
+
+	CheckPlaceholders   bool     `name:"check-placeholders" help:"Translate chunks again whose translation lost placeholders like '{name}' or '%s', and fail if they are still missing" env:"DRAGOMAN_CHECK_PLACEHOLDERS"`
+	PlaceholderPatterns []string `name:"placeholder-patterns" help:"Regular expressions of the placeholders checked by --check-placeholders (defaults to '{name}', '{{.Var}}' and printf-style specifiers)" env:"DRAGOMAN_PLACEHOLDER_PATTERNS" sep:"none"`
+
+	CarryOver    int  `name:"carry-over" help:"Number of previously translated chunks to include in the prompt of each chunk for consistency" env:"DRAGOMAN_CARRY_OVER"`
+	CarrySummary bool `name:"carry-summary" help:"Include a rolling summary of the previously translated chunks in each prompt instead (one extra request per chunk)" env:"DRAGOMAN_CARRY_SUMMARY"`
+}
+
+// improveOptions configure the improvement of documents.
+type improveOptions struct {
+	SplitChunks     []string           `name:"split-chunks" help:"Chunk source file at lines that start with one of the provided prefixes" env:"DRAGOMAN_SPLIT_CHUNKS"`
+	SplitHeadings   []int              `name:"split-headings" help:"Chunk Markdown source files before the headings of the given levels, ignoring code blocks (e.g. '2,3')" env:"DRAGOMAN_SPLIT_HEADINGS"`
+	Formality       dragoman.Formality `name:"formality" help:"Formality of the text" env:"DRAGOMAN_FORMALITY"`
+	Instructions    []string           `name:"instruct" short:"i" help:"Additional instructions for the prompt" env:"DRAGOMAN_INSTRUCT"`
+	Context         []string           `name:"context" help:"Reference files (e.g. brand guides) to include in the prompt" type:"path" env:"DRAGOMAN_CONTEXT"`
+	Keywords        []string           `name:"keywords" help:"Keywords to optimize for" env:"DRAGOMAN_KEYWORDS"`
+	Language        string             `name:"language" short:"l" help:"Write the text in the given language" env:"DRAGOMAN_LANGUAGE"`
+	PreserveOutline bool               `name:"preserve-outline" help:"Keep the exact headings of the document in their original order" env:"DRAGOMAN_PRESERVE_OUTLINE"`
+	Sections        []string           `name:"sections" help:"Only improve the Markdown sections with the given headings (e.g. 'Installation,Conclusion') and keep the rest of the document unchanged" env:"DRAGOMAN_SECTIONS"`
+}
+
+// syncOptions select the targets of the sync and batch commands.
+type syncOptions struct {
+	Config   string   `short:"f" help:"Configuration file" type:"path" env:"DRAGOMAN_CONFIG" default:"dragoman.yaml"`
+	Discover bool     `help:"Discover the locale files of the project and translate the source locale to all other locales, even if the configuration file declares targets" env:"DRAGOMAN_SYNC_DISCOVER"`
+	To       []string `help:"Discover the locale files of the project and translate them to the given locales (e.g. 'fr')" env:"DRAGOMAN_SYNC_TO"`
+	From     string   `help:"Source locale of the discovered locale files (defaults to 'en')" env:"DRAGOMAN_SYNC_FROM"`
+}
+
+// translateCommand contains the options of the translate command.
+type translateCommand struct {
+	SourcePath   string                   `arg:"source" name:"source" optional:"" help:"Source file" type:"path" env:"DRAGOMAN_SOURCE"`
+	Clipboard    bool                     `short:"c" help:"Read the source from the clipboard and copy the result back to the clipboard" env:"DRAGOMAN_CLIPBOARD"`
+	Format       string                   `name:"source-format" help:"Translate the source like a file of the given format, e.g. when it is read from stdin ('json', 'jsonc', 'json5', 'md', 'html', 'po', 'xliff', 'csv', 'tsv', 'strings', 'properties', 'resx', 'gotmpl', 'go', 'srt', 'vtt' or 'txt')" env:"DRAGOMAN_SOURCE_FORMAT" enum:",json,jsonc,json5,md,html,po,xliff,csv,tsv,strings,properties,resx,gotmpl,go,srt,vtt,txt" default:""`
+	Params       translationOptions       `embed:""`
+	AutoPreserve bool                     `name:"auto-preserve" help:"Ask the model for the product names, trademarks and other proper nouns of the source before translating and preserve them" env:"DRAGOMAN_AUTO_PRESERVE"`
+	Out          string                   `short:"o" help:"Output file" type:"path" env:"DRAGOMAN_OUT"`
+	Config       string                   `help:"Configuration file whose profile of the target language is applied, if it exists" type:"path" env:"DRAGOMAN_CONFIG" default:"dragoman.yaml"`
+	Update       bool                     `short:"u" help:"Only translate missing fields in output file (requires JSON, HTML or CSV files)" env:"DRAGOMAN_UPDATE"`
+	KeyStyle     string                   `name:"key-style" help:"Write the keys of JSON documents as dot-separated keys ('flat') or nested objects ('nested'), diffing and merging flat and nested files by their nested keys" env:"DRAGOMAN_KEY_STYLE" enum:",flat,nested" default:""`
+	Prune        bool                     `help:"Remove keys from the output file that no longer exist in the source file (requires --update and JSON files)" env:"DRAGOMAN_PRUNE"`
+	Since        string                   `help:"Also translate the keys of JSON files again whose source values changed since the given git revision (requires --update)" env:"DRAGOMAN_SINCE"`
+	ForceKeys    []string                 `name:"force-keys" help:"Also translate the keys of JSON files again that match the given key paths (e.g. 'checkout.*'), even if they are already translated (requires --update)" env:"DRAGOMAN_FORCE_KEYS"`
+	Provenance   bool                     `help:"Record the model, prompt, time and source of every translated key in a sidecar file next to the output file (e.g. 'de.json.dragoman'), and translate keys again whose source changed (requires JSON files)" env:"DRAGOMAN_PROVENANCE"`
+	Previous     string                   `help:"Previous version of the source file, used to detect changed texts when updating HTML files" type:"path" env:"DRAGOMAN_PREVIOUS"`
+	SplitChunks  []string                 `name:"split-chunks" help:"Chunk source file at lines that start with one of the provided prefixes" env:"DRAGOMAN_SPLIT_CHUNKS"`
+	SplitLevels  []int                    `name:"split-headings" help:"Chunk Markdown source files before the headings of the given levels, ignoring code blocks (e.g. '2,3')" env:"DRAGOMAN_SPLIT_HEADINGS"`
+	PackTokens   int                      `name:"pack-tokens" help:"Pack the values of JSON documents into chunks of at most the given number of tokens instead of sending the whole document in one prompt (0 disables packing)" env:"DRAGOMAN_PACK_TOKENS"`
+	I18next      bool                     `name:"i18next" help:"Translate the plural forms of i18next JSON files together, generate the plural forms of the target language and keep $t() nesting references" env:"DRAGOMAN_I18NEXT"`
+	Prose        bool                     `help:"Only translate the prose of Markdown files, skipping code and URLs" env:"DRAGOMAN_PROSE"`
+	FrontMatter  []string                 `name:"frontmatter-fields" help:"Front-matter fields of Markdown files to translate; all other fields are kept verbatim" env:"DRAGOMAN_FRONTMATTER_FIELDS" default:"title,description"`
+	Normalize    []dragoman.Normalization `help:"Normalization rules for reusing translations of repeated segments ('whitespace', 'case')" env:"DRAGOMAN_NORMALIZE" default:"whitespace"`
+	Dry          bool                     `help:"Write the result to stdout" env:"DRAGOMAN_DRY_RUN"`
+	StreamOut    bool                     `name:"stream-out" help:"Write every translated chunk to '<out>.partial' as soon as it is done and move it to <out> when the translation is complete" env:"DRAGOMAN_STREAM_OUT"`
+	Resume       bool                     `help:"Continue an interrupted translation at its first untranslated chunk instead of translating the document again" env:"DRAGOMAN_RESUME"`
+	Diff         bool                     `help:"Print the changes to the output file as a diff instead of writing it (requires --update)" env:"DRAGOMAN_DIFF"`
+	Dedupe       bool                     `help:"Translate repeated strings of JSON documents only once" env:"DRAGOMAN_DEDUPE"`
+	Estimate     bool                     `help:"Print the estimated token usage and cost without translating" env:"DRAGOMAN_ESTIMATE"`
+	PrintPrompts bool                     `name:"print-prompts" help:"Print the prompts that would be sent to the model, after chunking, masking and the assembly of the instructions, without translating" env:"DRAGOMAN_PRINT_PROMPTS"`
+	Bilingual    dragoman.BilingualFormat `help:"Interleave the source and the translation paragraph by paragraph ('markdown' or 'html')" env:"DRAGOMAN_BILINGUAL" enum:",markdown,html" default:""`
+	Overrides    string                   `help:"YAML or JSON file that maps chunk numbers or JSON key paths to fixed translations" type:"existingfile" env:"DRAGOMAN_OVERRIDES"`
+	IncludeKeys  []string                 `name:"include-keys" help:"Only translate the values of JSON documents at matching key paths (e.g. 'errors.*', '**.title')" env:"DRAGOMAN_INCLUDE_KEYS"`
+	ExcludeKeys  []string                 `name:"exclude-keys" help:"Copy the values of JSON documents at matching key paths verbatim instead of translating them" env:"DRAGOMAN_EXCLUDE_KEYS"`
+	HTMLAttrs    []string                 `name:"html-attributes" help:"Attributes of HTML elements whose values are translated" env:"DRAGOMAN_HTML_ATTRIBUTES" default:"alt,title,placeholder,aria-label"`
+	GoFuncs      []string                 `name:"go-funcs" help:"Functions whose string literals are translated in Go source files (e.g. 'i18n.T' or 'T')" env:"DRAGOMAN_GO_FUNCS" default:"i18n.T"`
+	LineLength   int                      `name:"max-line-length" help:"Maximum number of characters of a line of translated subtitles; longer lines are wrapped (0 for no limit)" env:"DRAGOMAN_MAX_LINE_LENGTH" default:"42"`
+	Columns      []string                 `help:"Columns of CSV and TSV files to translate, by name or 1-based number (defaults to all columns)" env:"DRAGOMAN_COLUMNS"`
+	XMLPaths     []string                 `name:"xml-path" help:"Elements of XML documents whose content is translated, as slash-separated paths (e.g. 'product/description')" env:"DRAGOMAN_XML_PATHS"`
+	XMLAttrs     []string                 `name:"xml-attr" help:"Attributes of XML documents whose values are translated, as a path and the attribute name (e.g. 'item@label')" env:"DRAGOMAN_XML_ATTRS"`
+	Structured   bool                     `name:"structured-output" help:"Ask OpenAI chat models to respond with the keys of translated JSON documents using function calling" env:"DRAGOMAN_STRUCTURED_OUTPUT" default:"true" negatable:""`
+}
+
+// clone returns a copy of the options that shares no slices with c.
+func (c translateCommand) clone() translateCommand {
+	c.Params = c.Params.clone()
+	c.ForceKeys = slices.Clone(c.ForceKeys)
+	c.SplitChunks = slices.Clone(c.SplitChunks)
+	c.SplitLevels = slices.Clone(c.SplitLevels)
+	c.FrontMatter = slices.Clone(c.FrontMatter)
+	c.Normalize = slices.Clone(c.Normalize)
+	c.IncludeKeys = slices.Clone(c.IncludeKeys)
+	c.ExcludeKeys = slices.Clone(c.ExcludeKeys)
+	c.HTMLAttrs = slices.Clone(c.HTMLAttrs)
+	c.GoFuncs = slices.Clone(c.GoFuncs)
+	c.Columns = slices.Clone(c.Columns)
+	c.XMLPaths = slices.Clone(c.XMLPaths)
+	c.XMLAttrs = slices.Clone(c.XMLAttrs)
+	return c
+}
+
+// clone returns a copy of the options that shares no slices with o.
+func (o translationOptions) clone() translationOptions {
+	o.Preserve = slices.Clone(o.Preserve)
+	o.Instructions = slices.Clone(o.Instructions)
+	o.Context = slices.Clone(o.Context)
+	o.Examples = slices.Clone(o.Examples)
+	o.Scrub = slices.Clone(o.Scrub)
+	o.PreservePatterns = slices.Clone(o.PreservePatterns)
+	o.PlaceholderPatterns = slices.Clone(o.PlaceholderPatterns)
+	return o
+}
+
 type cliOptions struct {
-	Translate struct {
-		SourcePath   string   `arg:"source" name:"source" optional:"" help:"Source file" type:"path" env:"DRAGOMAN_SOURCE"`
-		SourceLang   string   `name:"from" short:"f" help:"Source language" env:"DRAGOMAN_SOURCE_LANG" default:"auto"`
-		TargetLang   string   `name:"to" short:"t" help:"Target language" env:"DRAGOMAN_TARGET_LANG" default:"English"`
-		Preserve     []string `short:"p" help:"Preserve the specified terms/words" env:"DRAGOMAN_PRESERVE"`
-		Instructions []string `name:"instruct" short:"i" help:"Additional instructions for the prompt" env:"DRAGOMAN_INSTRUCT"`
-		Out          string   `short:"o" help:"Output file" type:"path" env:"DRAGOMAN_OUT"`
-		Update       bool     `short:"u" help:"Only translate missing fields in output file (requires JSON files)" env:"DRAGOMAN_UPDATE"`
-		SplitChunks  []string `name:"split-chunks" help:"Chunk source file at lines that start with one of the provided prefixes" env:"DRAGOMAN_SPLIT_CHUNKS"`
-		Dry          bool     `help:"Write the result to stdout" env:"DRAGOMAN_DRY_RUN"`
-	} `cmd:"translate" default:"withargs"`
+	Translate translateCommand `cmd:"translate" default:"withargs"`
 
 	Improve struct {
-		SourcePath   string             `arg:"source" name:"source" optional:"" help:"Source file" type:"path" env:"DRAGOMAN_SOURCE"`
-		Out          string             `short:"o" help:"Output file" type:"path" env:"DRAGOMAN_OUT"`
-		SplitChunks  []string           `name:"split-chunks" help:"Chunk source file at lines that start with one of the provided prefixes" env:"DRAGOMAN_SPLIT_CHUNKS"`
-		Formality    dragoman.Formality `name:"formality" help:"Formality of the text" env:"DRAGOMAN_FORMALITY"`
-		Instructions []string           `name:"instruct" short:"i" help:"Additional instructions for the prompt" env:"DRAGOMAN_INSTRUCT"`
-		Keywords     []string           `name:"keywords" help:"Keywords to optimize for" env:"DRAGOMAN_KEYWORDS"`
-		Language     string             `name:"language" short:"l" help:"Write the text in the given language" env:"DRAGOMAN_LANGUAGE"`
-		Dry          bool               `help:"Write the result to stdout" env:"DRAGOMAN_DRY_RUN"`
+		SourcePath  string         `arg:"source" name:"source" optional:"" help:"Source file" type:"path" env:"DRAGOMAN_SOURCE"`
+		Clipboard   bool           `short:"c" help:"Read the source from the clipboard and copy the result back to the clipboard" env:"DRAGOMAN_CLIPBOARD"`
+		Out         string         `short:"o" help:"Output file" type:"path" env:"DRAGOMAN_OUT"`
+		Params      improveOptions `embed:""`
+		Dry         bool           `help:"Write the result to stdout" env:"DRAGOMAN_DRY_RUN"`
+		ShowChanges bool           `name:"show-changes" help:"Print a word-level diff of the changes instead of the improved document to stdout" env:"DRAGOMAN_SHOW_CHANGES"`
+		Changes     string         `name:"changes-format" help:"Format of --show-changes ('words' or 'critic' for CriticMarkup)" env:"DRAGOMAN_CHANGES_FORMAT" enum:"words,critic" default:"words"`
 	} `cmd:"improve"`
 
-	OpenAIKey            string  `name:"openai-key" help:"OpenAI API key" env:"OPENAI_KEY"`
-	OpenAIModel          string  `name:"openai-model" help:"OpenAI model" env:"OPENAI_MODEL" default:"gpt-3.5-turbo"`
-	OpenAITemperature    float32 `name:"temperature" help:"OpenAI temperature" env:"OPENAI_TEMPERATURE" default:"0.3"`
-	OpenAITopP           float32 `name:"top-p" help:"OpenAI top_p" env:"OPENAI_TOP_P" default:"0.3"`
-	OpenAIResponseFormat string  `name:"format" help:"OpenAI response format ('text' or 'json_object')" env:"OPENAI_RESPONSE_FORMAT" default:"text"`
-	OpenAIChunkTimeout   string  `name:"chunk-timeout" help:"Timeout for each token chunk" env:"OPENAI_CHUNK_TIMEOUT"`
+	ImproveDir struct {
+		Dir       string         `arg:"" name:"dir" help:"Directory of the documents" type:"existingdir"`
+		Params    improveOptions `embed:""`
+		Manifest  string         `help:"Manifest of the last improvements (defaults to '.dragoman-improve.json' in <dir>)" type:"path" env:"DRAGOMAN_MANIFEST"`
+		Ext       []string       `help:"File extensions of the documents" env:"DRAGOMAN_EXT" default:".md,.mdx"`
+		Limit     int            `short:"n" help:"Maximum number of documents to improve (0 for no limit)" env:"DRAGOMAN_LIMIT" default:"10"`
+		MaxTokens int            `name:"max-tokens" help:"Estimated token budget of the run (0 for no limit)" env:"DRAGOMAN_MAX_TOKENS"`
+		MinAge    time.Duration  `name:"min-age" help:"Do not improve unchanged documents again before the given duration has passed" env:"DRAGOMAN_MIN_AGE"`
+		Dry       bool           `help:"Only list the documents that would be improved" env:"DRAGOMAN_DRY_RUN"`
+	} `cmd:"improve-dir" help:"Gradually improve the documents of a directory, a few at a time"`
+
+	Content struct {
+		Dir          string             `arg:"" name:"dir" help:"Content directory of the site" type:"existingdir"`
+		From         string             `name:"from" short:"f" help:"Language tag of the source files" env:"DRAGOMAN_SOURCE_LANG" default:"en"`
+		To           []string           `name:"to" short:"t" help:"Language tags of the translations (e.g. 'de,fr')" env:"DRAGOMAN_TARGET_LANG" required:""`
+		Layout       string             `help:"Where the translations are written ('suffix' for 'about.de.md' next to 'about.md', 'dir' for 'de/about.md' next to 'en/about.md')" env:"DRAGOMAN_LAYOUT" enum:"suffix,dir" default:"suffix"`
+		Ext          []string           `help:"File extensions of the content files" env:"DRAGOMAN_EXT" default:".md,.markdown"`
+		FrontMatter  []string           `name:"frontmatter-fields" help:"Front-matter fields to translate; all other fields are kept verbatim" env:"DRAGOMAN_FRONTMATTER_FIELDS" default:"title,description,summary"`
+		Preserve     []string           `short:"p" help:"Preserve the specified terms/words" env:"DRAGOMAN_PRESERVE"`
+		Instructions []string           `name:"instruct" short:"i" help:"Additional instructions for the prompt" env:"DRAGOMAN_INSTRUCT"`
+		Formality    dragoman.Formality `name:"formality" help:"Formality of the translations ('formal' or 'informal')" env:"DRAGOMAN_FORMALITY" enum:",formal,informal" default:""`
+		SplitLevels  []int              `name:"split-headings" help:"Chunk the files before the headings of the given levels, ignoring code blocks (e.g. '2,3')" env:"DRAGOMAN_SPLIT_HEADINGS"`
+		Prose        bool               `help:"Only translate the prose of the files, skipping code and URLs" env:"DRAGOMAN_PROSE"`
+		Force        bool               `help:"Translate files again whose translations are newer than their sources" env:"DRAGOMAN_FORCE"`
+		Dry          bool               `help:"Only list the translations that would be written" env:"DRAGOMAN_DRY_RUN"`
+	} `cmd:"content" help:"Translate the Markdown content of a static site (e.g. Hugo or Jekyll) into a copy per language"`
+
+	Eval struct {
+		Pairs  []string           `arg:"" name:"pairs" help:"Source files and their reference translations, separated by '=' (e.g. en.json=de.json)"`
+		Params translationOptions `embed:""`
+	} `cmd:"eval" help:"Evaluate translations against human reference translations"`
+
+	Score struct {
+		SourcePath   string   `name:"source" help:"Source JSON file" type:"existingfile" env:"DRAGOMAN_SOURCE" required:""`
+		TargetPath   string   `name:"target" help:"Translated JSON file" type:"existingfile" env:"DRAGOMAN_TARGET" required:""`
+		SourceLang   string   `name:"from" short:"f" help:"Source language (inferred by the model if empty)" env:"DRAGOMAN_SOURCE_LANG"`
+		TargetLang   string   `name:"to" short:"t" help:"Target language (inferred by the model if empty)" env:"DRAGOMAN_TARGET_LANG"`
+		Instructions []string `name:"instruct" short:"i" help:"Additional instructions for the reviewer, like required terminology" env:"DRAGOMAN_INSTRUCT"`
+		BatchSize    int      `name:"batch-size" help:"Maximum number of strings rated per request" env:"DRAGOMAN_SCORE_BATCH_SIZE" default:"40"`
+		CSV          bool     `name:"csv" help:"Write the report as CSV instead of JSON" env:"DRAGOMAN_CSV"`
+		Out          string   `short:"o" help:"Report file (defaults to stdout)" type:"path" env:"DRAGOMAN_OUT"`
+	} `cmd:"score" help:"Rate the accuracy, fluency and terminology of each translated string"`
+
+	Consistency struct {
+		Pairs                 []string `arg:"" name:"pairs" help:"Source JSON files and their translations into the same language, separated by '=' (e.g. en/app.json=de/app.json)"`
+		SourceSimilarity      float64  `name:"source-similarity" help:"Minimum similarity of source strings that are compared with each other (0-1)" env:"DRAGOMAN_SOURCE_SIMILARITY" default:"0.95"`
+		TranslationSimilarity float64  `name:"translation-similarity" help:"Minimum similarity of translations that are considered consistent (0-1)" env:"DRAGOMAN_TRANSLATION_SIMILARITY" default:"0.9"`
+		EmbeddingModel        string   `name:"embedding-model" help:"Model that embeds the strings" env:"DRAGOMAN_EMBEDDING_MODEL" default:"text-embedding-3-small"`
+		BatchSize             int      `name:"batch-size" help:"Maximum number of strings embedded per request" env:"DRAGOMAN_EMBED_BATCH_SIZE" default:"100"`
+		JSON                  bool     `name:"json" help:"Write the report as JSON" env:"DRAGOMAN_JSON"`
+		Out                   string   `short:"o" help:"Report file (defaults to stdout)" type:"path" env:"DRAGOMAN_OUT"`
+	} `cmd:"consistency" help:"Report source strings that were translated differently across keys and files"`
+
+	Crawl struct {
+		URL       string             `arg:"" name:"url" help:"Sitemap (e.g. 'https://example.com/sitemap.xml') or base URL of the website"`
+		Out       string             `short:"o" help:"Directory of the translated pages" type:"path" env:"DRAGOMAN_OUT" required:""`
+		Params    translationOptions `embed:""`
+		Limit     int                `short:"n" help:"Maximum number of pages to translate (0 for no limit)" env:"DRAGOMAN_LIMIT" default:"100"`
+		HTMLAttrs []string           `name:"html-attributes" help:"Attributes of HTML elements whose values are translated" env:"DRAGOMAN_HTML_ATTRIBUTES" default:"alt,title,placeholder,aria-label"`
+		Dry       bool               `help:"Only list the discovered pages and their files" env:"DRAGOMAN_DRY_RUN"`
+	} `cmd:"crawl" help:"Translate the pages of a website into a mirrored directory tree"`
+
+	Bench struct {
+		Backends []string `arg:"" name:"backends" optional:"" help:"Backends to compare, either 'deepl', 'openai:<model>' or 'compat:<model>' (defaults to the configured provider and model)"`
+		From     string   `name:"from" short:"f" help:"Source language of the workload" env:"DRAGOMAN_SOURCE_LANG" default:"English"`
+		To       []string `name:"to" short:"t" help:"Target languages to benchmark" env:"DRAGOMAN_TARGET_LANG" default:"German"`
+		Runs     int      `short:"n" help:"Number of translations per backend and target language" env:"DRAGOMAN_BENCH_RUNS" default:"3"`
+		Workload string   `help:"Document to translate instead of the built-in workload" type:"existingfile" env:"DRAGOMAN_BENCH_WORKLOAD"`
+	} `cmd:"bench" help:"Compare the latency, throughput and cost of providers and models"`
+
+	Compare struct {
+		SourcePath  string             `arg:"" name:"source" help:"Source file" type:"existingfile"`
+		Models      []string           `name:"models" help:"Models to compare, either '<model>' of the configured provider, 'openai:<model>', 'compat:<model>' or 'deepl'" env:"DRAGOMAN_COMPARE_MODELS" required:""`
+		Params      translationOptions `embed:""`
+		SplitChunks []string           `name:"split-chunks" help:"Chunk source file at lines that start with one of the provided prefixes" env:"DRAGOMAN_SPLIT_CHUNKS"`
+		SplitLevels []int              `name:"split-headings" help:"Chunk Markdown source files before the headings of the given levels, ignoring code blocks (e.g. '2,3')" env:"DRAGOMAN_SPLIT_HEADINGS"`
+		JSON        bool               `name:"json" help:"Write the report as JSON" env:"DRAGOMAN_JSON" xor:"compare-format"`
+		Markdown    bool               `name:"markdown" help:"Write the report as a Markdown table" env:"DRAGOMAN_MARKDOWN" xor:"compare-format"`
+		Out         string             `short:"o" help:"Report file (defaults to stdout)" type:"path" env:"DRAGOMAN_OUT"`
+	} `cmd:"compare" help:"Translate a document with several models and show the translations side by side"`
+
+	Sync struct {
+		Targets syncOptions `embed:""`
+		Dry     bool        `help:"Write the results to stdout" env:"DRAGOMAN_DRY_RUN"`
+		Jobs    int         `short:"j" help:"Number of targets that are translated concurrently, each in its own process" env:"DRAGOMAN_JOBS" default:"1"`
+		Target  int         `name:"sync-target" hidden:"" default:"-1"`
+		Summary string      `name:"sync-summary" hidden:""`
+	} `cmd:"sync" help:"Translate all targets declared in the configuration file"`
+
+	Batch struct {
+		Submit struct {
+			Targets syncOptions `embed:""`
+		} `cmd:"submit" help:"Submit the pending translations of all targets as a batch job"`
+
+		Collect struct {
+			ID      string      `arg:"" name:"id" help:"ID of the batch job"`
+			Targets syncOptions `embed:""`
+		} `cmd:"collect" help:"Write the results of a completed batch job to the targets"`
+	} `cmd:"batch" help:"Translate the targets of the configuration file using the OpenAI Batch API"`
+
+	Detect struct {
+		SourcePath string `arg:"source" name:"source" optional:"" help:"Source file (defaults to stdin)" type:"path" env:"DRAGOMAN_SOURCE"`
+		JSON       bool   `name:"json" help:"Print the detection as a JSON object with the language code, name and confidence" env:"DRAGOMAN_JSON"`
+	} `cmd:"detect" help:"Detect the language of a document and print its ISO 639-1 code and the confidence of the detection"`
+
+	Serve struct {
+		Addr    string `help:"Address the gRPC server listens on" env:"DRAGOMAN_ADDR" default:":50051"`
+		TLSCert string `name:"tls-cert" help:"TLS certificate of the server (serves plaintext HTTP/2 if omitted)" type:"existingfile" env:"DRAGOMAN_TLS_CERT"`
+		TLSKey  string `name:"tls-key" help:"Private key of the TLS certificate" type:"existingfile" env:"DRAGOMAN_TLS_KEY"`
+		Token   string `help:"Require clients to send this bearer token in the 'authorization' metadata" env:"DRAGOMAN_SERVE_TOKEN"`
+	} `cmd:"serve" help:"Run a gRPC server that translates and improves documents"`
 
-	Timeout time.Duration `short:"T" help:"Timeout for API requests" env:"DRAGOMAN_TIMEOUT" default:"3m"`
-	Verbose bool          `short:"v" help:"Verbose output"`
-	Stream  bool          `short:"s" help:"Stream output to stdout"`
+	Provider    string `help:"Translation provider ('openai', 'deepl', 'compat' for OpenAI-compatible APIs, 'vertex' for Gemini on Vertex AI, or a provider that is registered by the executable)" env:"DRAGOMAN_PROVIDER" default:"openai"`
+	ProviderKey string `name:"provider-key" help:"API key of a provider that is registered by the executable" env:"DRAGOMAN_PROVIDER_KEY"`
+	DeepLKey    string `name:"deepl-key" help:"DeepL authentication key" env:"DEEPL_KEY"`
+
+	BaseURL   string `name:"base-url" help:"Base URL of the OpenAI-compatible API of the 'compat' provider (e.g. 'https://openrouter.ai/api/v1')" env:"DRAGOMAN_BASE_URL"`
+	CompatKey string `name:"compat-key" help:"API key of the 'compat' provider" env:"COMPAT_KEY"`
+	Model     string `help:"Model of the provider (takes precedence over --openai-model)" env:"DRAGOMAN_MODEL"`
+
+	OpenAIKey            string   `name:"openai-key" help:"OpenAI API key" env:"OPENAI_KEY"`
+	OpenAIModel          string   `name:"openai-model" help:"OpenAI model" env:"OPENAI_MODEL" default:"gpt-3.5-turbo"`
+	OpenAITemperature    float32  `name:"temperature" help:"OpenAI temperature" env:"OPENAI_TEMPERATURE" default:"0.3"`
+	OpenAITopP           float32  `name:"top-p" help:"OpenAI top_p" env:"OPENAI_TOP_P" default:"0.3"`
+	OpenAISeed           *int     `name:"seed" help:"Seed for best-effort deterministic sampling of chat models" env:"OPENAI_SEED"`
+	OpenAIStop           []string `name:"stop" help:"Sequence where the model stops generating, e.g. to cut off trailing commentary (can be repeated up to 4 times)" env:"OPENAI_STOP" sep:"none"`
+	OpenAIFrequency      float32  `name:"frequency-penalty" help:"OpenAI frequency_penalty between -2 and 2" env:"OPENAI_FREQUENCY_PENALTY"`
+	OpenAIPresence       float32  `name:"presence-penalty" help:"OpenAI presence_penalty between -2 and 2" env:"OPENAI_PRESENCE_PENALTY"`
+	OpenAIResponseFormat string   `name:"format" help:"OpenAI response format ('text' or 'json_object')" env:"OPENAI_RESPONSE_FORMAT" default:"text"`
+	OpenAIChunkTimeout   string   `name:"chunk-timeout" help:"Timeout for each token chunk" env:"OPENAI_CHUNK_TIMEOUT"`
+	OpenAIContextWindow  int      `name:"context-window" help:"Context window of the model in tokens, for models whose context window is unknown (e.g. fine-tunes or models of the 'compat' provider)" env:"OPENAI_CONTEXT_WINDOW"`
+	OpenAIOrg            string   `name:"openai-org" help:"OpenAI organization ID" env:"OPENAI_ORG"`
+	OpenAIProject        string   `name:"openai-project" help:"OpenAI project ID" env:"OPENAI_PROJECT"`
+
+	Headers map[string]string `name:"header" help:"Custom HTTP header of the requests to the model as 'Name=Value', e.g. for API gateways (can be repeated)" env:"DRAGOMAN_HEADERS"`
+
+	Record string `help:"Write every prompt and response of the model to a JSON file in the given directory, so that the run can be replayed with --replay" type:"path" env:"DRAGOMAN_RECORD" xor:"recording"`
+	Replay string `help:"Answer the prompts with the responses recorded by --record in the given directory instead of calling the API" type:"existingdir" env:"DRAGOMAN_REPLAY" xor:"recording"`
+
+	ContextLimit int `name:"context-limit" help:"Summarize context files that are longer than the given number of characters (0 disables summarization)" env:"DRAGOMAN_CONTEXT_LIMIT" default:"8000"`
+
+	CheckOnly bool `name:"check-only" help:"Report pending work as JSON without calling the model or writing any files (exits with status 2 if there is pending work)" env:"DRAGOMAN_CHECK_ONLY"`
+
+	Strict bool `help:"Treat warnings, like discarded translations with mismatched placeholders, as failures" env:"DRAGOMAN_STRICT"`
+
+	Timeout  time.Duration `short:"T" help:"Timeout for API requests" env:"DRAGOMAN_TIMEOUT" default:"3m"`
+	Retries  int           `help:"Maximum number of retries for rate-limited or failed API requests" env:"DRAGOMAN_RETRIES" default:"3"`
+	RPM      int           `name:"rpm" help:"Maximum number of requests per minute to the model (0 for no limit)" env:"DRAGOMAN_RPM"`
+	TPM      int           `name:"tpm" help:"Maximum number of estimated tokens per minute sent to the model (0 for no limit)" env:"DRAGOMAN_TPM"`
+	Verbose  bool          `short:"v" help:"Verbose output"`
+	Progress string        `help:"Show a progress bar on stderr ('auto' shows it if stderr is a terminal)" env:"DRAGOMAN_PROGRESS" enum:"auto,always,never" default:"auto"`
+	Stream   bool          `short:"s" help:"Stream output to stdout"`
+	Report   bool          `help:"Print the number of requests, the token usage, the estimated cost and the wall time of the run to stderr" env:"DRAGOMAN_REPORT"`
+
+	MaxCost        float64 `name:"max-cost" help:"Estimated cost budget of the run in USD; translations stop before a chunk that would exceed it (0 for no limit)" env:"DRAGOMAN_MAX_COST"`
+	MaxTokensTotal int     `name:"max-tokens-total" help:"Token budget of the run; translations stop before a chunk that would exceed it (0 for no limit)" env:"DRAGOMAN_MAX_TOKENS_TOTAL"`
+
+	OnSuccess []string `name:"on-success" help:"Shell command or webhook URL that receives a JSON description of the run if the command succeeds (can be repeated)" env:"DRAGOMAN_ON_SUCCESS" sep:"none"`
+	OnFailure []string `name:"on-failure" help:"Shell command or webhook URL that receives a JSON description of the run if the command fails (can be repeated)" env:"DRAGOMAN_ON_FAILURE" sep:"none"`
 }
 
 var options cliOptions
@@ -66,15 +340,47 @@ var options cliOptions
 // respecting user-defined timeouts and verbosity settings. It also gracefully
 // handles termination signals to ensure proper cleanup during unexpected exits.
 type App struct {
-	version string
-	kong    *kong.Context
+	version        string
+	kong           *kong.Context
+	cache          *dragoman.SegmentCache
+	caches         map[string]*dragoman.SegmentCache
+	jsonOverrides  dragoman.JSONOverrides
+	chunkOverrides map[int]string
+	refs           []string
+	crlf           bool
+	params         *translationOptions
+	estimate       dragoman.CostEstimate
+	submitting     bool
+	prompts        []string
+	replay         dragoman.Model
+	pending        bool
+	failed         bool
+	stop           <-chan struct{}
+	spending       *dragoman.Budget
+	backend        *provider.Backend
+	sidecar        *provenance.Sidecar
+	provenanceKeys []string
+	warnings       int
+	skipped        []dragoman.SkippedChunk
+	skipReport     []string
+	progress       *progress
+	rateLimit      openai.Option
+	diffBase       []byte
+	jsonLayouts    [][]byte
+	usage          dragoman.Usage
+	started        time.Time
+	reported       bool
+	unlocks        []func() error
+	written        []string
+	languages      []string
+	hooked         bool
 }
 
 // New creates a new instance of App with the provided version and sets up its
 // command-line interface context. It returns a pointer to the created App.
 func New(version string) *App {
 	app := App{version: version}
-	app.kong = kong.Parse(
+	parser := kong.Must(
 		&options,
 		kong.Name("dragoman"),
 		kong.Description("Dragoman is a translator for structured text, powered by AI language models."),
@@ -83,6 +389,20 @@ func New(version string) *App {
 			return kong.DefaultHelpPrinter(opts, ctx)
 		}),
 	)
+
+	ctx, err := parser.Parse(os.Args[1:])
+	if err != nil {
+		parser.Errorf("%s", err)
+		parser.Exit(exitConfig)
+	}
+	app.kong = ctx
+	app.progress = newProgress()
+	app.checkProvider()
+
+	if options.Model != "" {
+		options.OpenAIModel = options.Model
+	}
+
 	return &app
 }
 
@@ -91,239 +411,1395 @@ func New(version string) *App {
 // corresponding function, and handles default behavior if no specific command
 // is recognized.
 func (app *App) Run() {
+	app.started = time.Now()
+
 	switch app.kong.Command() {
-	case "translate <source>":
+	case "translate", "translate <source>":
 		app.translate()
-	case "improve <source>":
+	case "improve", "improve <source>":
 		app.improve()
+	case "improve-dir <dir>":
+		app.improveDir()
+	case "content <dir>":
+		app.content()
+	case "eval <pairs>":
+		app.eval()
+	case "score":
+		app.score()
+	case "consistency <pairs>":
+		app.consistency()
+	case "crawl <url>":
+		app.crawl()
+	case "bench", "bench <backends>":
+		app.bench()
+	case "compare <source>":
+		app.compare()
+	case "sync":
+		app.sync(options.Sync.Targets, options.Sync.Dry)
+	case "batch submit":
+		app.batchSubmit()
+	case "batch collect <id>":
+		app.batchCollect()
+	case "detect", "detect <source>":
+		app.detect()
+	case "serve":
+		app.serve()
 	default:
 		app.kong.PrintUsage(false)
 	}
+
+	app.printSkipped()
+	app.printReport()
+	app.exit()
 }
 
-func (app *App) translate() {
-	if options.Translate.Update && options.Translate.Out == "" {
-		app.kong.Fatalf("you must provide the <out> file when using --update")
+// model creates the OpenAI client according to the command-line options and
+// the given additional options.
+func (app *App) model(extra ...openai.Option) *openai.Client {
+	if app.usesRegistered() {
+		app.fatalf(exitConfig, "%s is not supported by the %q provider; use the 'openai' or 'compat' provider", commandName(app.kong.Command()), options.Provider)
 	}
-
-	if options.Translate.Out == "" {
-		options.Translate.Dry = true
+	opts := append(app.openaiOptions(options.OpenAIModel), extra...)
+	if options.Stream {
+		opts = append(opts, openai.Stream(os.Stdout))
 	}
+	if app.usesCompat() {
+		return openai.New(options.CompatKey, append(opts, app.compatOptions()...)...)
+	}
+	return openai.New(options.OpenAIKey, opts...)
+}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
-
+// openaiOptions returns the options of an OpenAI client for the given model
+// according to the command-line options. Streaming is not included.
+func (app *App) openaiOptions(model string) []openai.Option {
 	opts := []openai.Option{
-		openai.Model(options.OpenAIModel),
+		openai.Model(model),
 		openai.ResponseFormat(options.OpenAIResponseFormat),
 		openai.Temperature(options.OpenAITemperature),
 		openai.TopP(options.OpenAITopP),
+		openai.Stop(options.OpenAIStop),
+		openai.FrequencyPenalty(options.OpenAIFrequency),
+		openai.PresencePenalty(options.OpenAIPresence),
+		openai.ContextWindow(options.OpenAIContextWindow),
 		openai.Timeout(options.Timeout),
+		openai.MaxRetries(options.Retries),
 		openai.Verbose(options.Verbose),
 	}
 
-	if options.Stream {
-		opts = append(opts, openai.Stream(os.Stdout))
+	if len(options.OpenAIStop) > 4 {
+		app.fatalf(exitConfig, "--stop can be used at most 4 times")
+	}
+	for flag, penalty := range map[string]float32{"--frequency-penalty": options.OpenAIFrequency, "--presence-penalty": options.OpenAIPresence} {
+		if penalty < -2 || penalty > 2 {
+			app.fatalf(exitConfig, "%s must be between -2 and 2", flag)
+		}
+	}
+
+	if options.OpenAISeed != nil {
+		opts = append(opts, openai.Seed(*options.OpenAISeed))
+	}
+
+	if options.RPM > 0 || options.TPM > 0 {
+		// All clients of a run share the same rate limit.
+		if app.rateLimit == nil {
+			app.rateLimit = openai.RateLimit(options.RPM, options.TPM)
+		}
+		opts = append(opts, app.rateLimit)
+	}
+
+	switch {
+	case options.Record != "":
+		opts = append(opts, openai.Record(options.Record))
+	case options.Replay != "":
+		opts = append(opts, openai.Replay(options.Replay))
 	}
 
 	if options.OpenAIChunkTimeout != "" {
 		chunkTimeout, err := time.ParseDuration(options.OpenAIChunkTimeout)
 		if err != nil {
-			app.kong.Fatalf("invalid chunk timeout: %v", err)
+			app.fatalf(exitConfig, "invalid chunk timeout: %v", err)
 		}
 		opts = append(opts, openai.ChunkTimeout(chunkTimeout))
 	}
 
-	model := openai.New(options.OpenAIKey, opts...)
-	translator := dragoman.NewTranslator(model)
+	if options.OpenAIOrg != "" {
+		opts = append(opts, openai.Organization(options.OpenAIOrg))
+	}
+	if options.OpenAIProject != "" {
+		opts = append(opts, openai.Project(options.OpenAIProject))
+	}
+	for key, value := range options.Headers {
+		opts = append(opts, openai.Header(key, value))
+	}
+
+	return opts
+}
 
-	var (
-		source []byte
-		err    error
-	)
-	if options.Translate.SourcePath == "" {
-		source, err = readAll(os.Stdin)
-		if errors.Is(err, errEmptyStdin) {
-			app.kong.Fatalf("you must either provide the <source> file or provide the source text via stdin")
-		} else {
-			app.kong.FatalIfErrorf(err, "failed to read source from stdin")
+func (app *App) translate() {
+	if options.Translate.Update && options.Translate.Out == "" {
+		app.fatalf(exitConfig, "you must provide the <out> file when using --update")
+	}
+
+	if options.Translate.Prune && (!options.Translate.Update || !isJSONFile(sourceFile()) || !isJSONFile(options.Translate.Out)) {
+		app.fatalf(exitConfig, "--prune requires --update and JSON files")
+	}
+
+	if options.Translate.Since != "" && (!options.Translate.Update || options.Translate.SourcePath == "" || !isJSONFile(options.Translate.SourcePath) || !isJSONFile(options.Translate.Out)) {
+		app.fatalf(exitConfig, "--since requires --update, a source file and JSON files")
+	}
+
+	if options.Translate.PackTokens < 0 {
+		app.fatalf(exitConfig, "--pack-tokens must not be negative")
+	}
+
+	if options.Translate.PackTokens > 0 && (options.Translate.Prose || !options.Translate.Update && !isJSONFile(sourceFile())) {
+		app.fatalf(exitConfig, "--pack-tokens requires JSON files")
+	}
+
+	if options.Translate.I18next && (options.Translate.Prose || options.Translate.Dedupe || !options.Translate.Update && !isJSONFile(sourceFile())) {
+		app.fatalf(exitConfig, "--i18next requires JSON files and cannot be used with --prose or --dedupe")
+	}
+
+	if len(options.Translate.ForceKeys) > 0 && (!options.Translate.Update || !isJSONFile(sourceFile()) || !isJSONFile(options.Translate.Out)) {
+		app.fatalf(exitConfig, "--force-keys requires --update and JSON files")
+	}
+
+	if options.Translate.Provenance && (options.Translate.Out == "" || options.Translate.Prose || !isJSONFile(sourceFile()) || !isJSONFile(options.Translate.Out)) {
+		app.fatalf(exitConfig, "--provenance requires an output file and JSON files")
+	}
+
+	if options.Translate.KeyStyle != "" && (!isJSONFile(sourceFile()) || options.Translate.StreamOut || options.Translate.Prose) {
+		app.fatalf(exitConfig, "--key-style requires JSON files and cannot be used with --stream-out or --prose")
+	}
+
+	if options.Translate.Out == "" && !options.Translate.Clipboard {
+		options.Translate.Dry = true
+	}
+
+	app.checkDiff()
+	app.checkStreamOut()
+	app.checkResume()
+	app.applyLanguageProfile()
+
+	if options.Translate.Estimate {
+		app.requireModel("--estimate")
+	}
+
+	if options.Translate.PrintPrompts {
+		app.requireModel("--print-prompts")
+	}
+
+	if options.Translate.Bilingual != "" {
+		if options.Translate.Update || options.Translate.Prose {
+			app.fatalf(exitConfig, "--bilingual cannot be used with --update or --prose")
+		}
+		if path := sourceFile(); isJSONFile(path) || isJSONCFile(path) || isPOFile(path) || isXLIFFFile(path) || isAndroidXMLFile(path) || selectsXML() || isAppleStringsFile(path) || isStringCatalogFile(path) || isPropertiesFile(path) || isResxFile(path) || isGoTemplateFile(path) || isGoFile(path) || isSubtitleFile(path) || isCSVFile(path) {
+			app.fatalf(exitConfig, "--bilingual cannot be used for JSON, PO, XLIFF, Android, Apple, Java or .NET resource, Go, subtitle or CSV files")
+		}
+	}
+
+	app.checkHeadingLevels(options.Translate.SplitLevels)
+
+	if len(options.Translate.Columns) > 0 && !isCSVFile(sourceFile()) {
+		app.fatalf(exitConfig, "--columns can only be used for CSV and TSV files")
+	}
+
+	app.readOverrides()
+	app.checkKeyPatterns()
+
+	source := app.readSource(options.Translate.SourcePath, options.Translate.Clipboard)
+	source = app.nestKeys(source, "source")
+
+	if options.CheckOnly {
+		if options.Translate.Out == "" {
+			app.fatalf(exitConfig, "you must provide the <out> file when using --check-only")
+		}
+		app.checkTranslate(source)
+		return
+	}
+
+	if !options.Translate.Dry && !options.Translate.Estimate && !options.Translate.PrintPrompts && options.Translate.Out != "" {
+		app.checkWritable(options.Translate.Out)
+	}
+
+	ctx, cancel := app.stoppableContext()
+	defer cancel()
+
+	if !options.Translate.Dry && !options.Translate.Estimate && !options.Translate.PrintPrompts && options.Translate.Out != "" {
+		app.lockOutput(ctx, options.Translate.Out)
+		defer app.releaseLocks()
+	}
+
+	model := app.translationModel()
+	if app.replay != nil {
+		model = app.replay
+	}
+	translator := app.translator(model, &options.Translate.Params)
+	app.cache = app.segmentCache()
+	app.useParams(ctx, model, &options.Translate.Params)
+	app.checkSecrets(source)
+	app.autoPreserve(ctx, model, source)
+
+	var err error
+
+	if selectsXML() {
+		app.translateXML(ctx, translator, source)
+		return
+	}
+
+	if isJSONCFile(sourceFile()) || isJSONFile(sourceFile()) && isJSONCDocument(source) {
+		app.translateJSONC(ctx, translator, source)
+		return
+	}
+
+	if isPOFile(sourceFile()) {
+		app.translatePO(ctx, translator, source)
+		return
+	}
+
+	if isXLIFFFile(sourceFile()) {
+		app.translateXLIFF(ctx, translator, source)
+		return
+	}
+
+	if isAndroidXMLFile(sourceFile()) {
+		app.translateAndroidXML(ctx, translator, source)
+		return
+	}
+
+	if isAppleStringsFile(sourceFile()) {
+		app.translateAppleStrings(ctx, translator, source)
+		return
+	}
+
+	if isPropertiesFile(sourceFile()) {
+		app.translateProperties(ctx, translator, source)
+		return
+	}
+
+	if isResxFile(sourceFile()) {
+		app.translateResx(ctx, translator, source)
+		return
+	}
+
+	if isGoTemplateFile(sourceFile()) || isGoFile(sourceFile()) {
+		app.translateGoTemplate(ctx, translator, source)
+		return
+	}
+
+	if isSubtitleFile(sourceFile()) {
+		app.translateSubtitles(ctx, translator, source)
+		return
+	}
+
+	if isStringCatalogFile(sourceFile()) {
+		app.translateStringCatalog(ctx, translator, source)
+		return
+	}
+
+	if isCSVFile(sourceFile()) {
+		app.translateCSV(ctx, translator, source)
+		return
+	}
+
+	if options.Translate.Update && isHTMLFile(options.Translate.Out) {
+		if app.updateHTML(ctx, translator, source) {
+			return
 		}
-	} else {
-		source, err = os.ReadFile(options.Translate.SourcePath)
-		app.kong.FatalIfErrorf(err, "failed to read source file %q", options.Translate.SourcePath)
 	}
 
+	if isHTMLFile(sourceFile()) && options.Translate.Bilingual == "" {
+		app.translateHTML(ctx, translator, source)
+		return
+	}
+
+	if (options.Translate.Update || isJSONFile(sourceFile())) && !options.Translate.Prose {
+		app.jsonLayouts = [][]byte{source}
+	}
+
+	app.loadProvenance()
+	original := source
+
 	var (
 		sourceMap      map[string]any
 		originalOutMap map[string]any
 	)
-	if options.Translate.Update {
+	if options.Translate.Update && !isHTMLFile(options.Translate.Out) {
 		err = json.Unmarshal(source, &sourceMap)
-		app.kong.FatalIfErrorf(err, "failed to unmarshal source as JSON")
+		app.fatalIfErrorf(err, "failed to unmarshal source as JSON")
 
 		outFile, err := os.ReadFile(options.Translate.Out)
 		if err != nil && !errors.Is(err, fs.ErrNotExist) {
-			app.kong.FatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
+			app.fatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
 		} else if err == nil {
-			err = json.Unmarshal(outFile, &originalOutMap)
-			app.kong.FatalIfErrorf(err, "failed to unmarshal target file %q", options.Translate.Out)
+			err = json.Unmarshal(app.nestKeys(outFile, fmt.Sprintf("target file %q", options.Translate.Out)), &originalOutMap)
+			app.fatalIfErrorf(err, "failed to unmarshal target file %q", options.Translate.Out)
 		} else {
 			originalOutMap = map[string]any{}
+			outFile = []byte("{}")
 		}
+		app.diffBase = outFile
+		app.jsonLayouts = [][]byte{outFile, source}
+
+		expected := app.i18nextExpand(sourceMap)
+		pruned := app.pruneJSON(originalOutMap, expected)
+		pinned := app.pinOverrides(sourceMap, originalOutMap)
 
-		paths, err := dragoman.JSONDiff(sourceMap, originalOutMap)
-		app.kong.FatalIfErrorf(err, "failed to diff source and target")
+		paths, err := dragoman.JSONDiff(expected, originalOutMap)
+		app.fatalIfErrorf(err, "failed to diff source and target")
+		paths = app.changedSince(paths, sourceMap)
+		paths = app.changedProvenance(paths, sourceMap, originalOutMap)
+		paths = app.forcedKeys(paths, sourceMap)
+		paths = app.i18nextFamilies(paths, sourceMap)
+
+		paths, excluded := partitionPaths(paths)
+		if len(excluded) > 0 {
+			excludedMap, err := dragoman.JSONExtract(sourceMap, excluded)
+			app.fatalIfErrorf(err, "failed to extract excluded fields from source")
+			dragoman.JSONMerge(originalOutMap, excludedMap)
+		}
 
 		if len(paths) == 0 {
 			if options.Verbose {
 				fmt.Fprintf(os.Stderr, "No fields missing in output file %q.\n", options.Translate.Out)
 			}
+			if pinned || pruned || len(excluded) > 0 {
+				marshaled, err := jsonMarshal(originalOutMap)
+				app.fatalIfErrorf(err, "failed to marshal result map")
+				app.outputTranslation(string(marshaled))
+				app.recordProvenance(original, string(marshaled))
+			}
 			return
 		}
 
 		sourceMap, err := dragoman.JSONExtract(source, paths)
 		if err != nil {
-			app.kong.FatalIfErrorf(err, "failed to extract missing fields from source")
+			app.fatalIfErrorf(err, "failed to extract missing fields from source")
 		}
 
 		if source, err = jsonMarshal(sourceMap); err != nil {
-			app.kong.FatalIfErrorf(err, "failed to marshal source map")
+			app.fatalIfErrorf(err, "failed to marshal source map")
+		}
+		app.translatedKeys(source)
+	}
+
+	var unfiltered map[string]any
+	if len(keyPatterns()) > 0 && !options.Translate.Update {
+		if source, unfiltered = app.filterJSON(source); source == nil {
+			if options.Verbose {
+				fmt.Fprintln(os.Stderr, "No fields selected by --include-keys and --exclude-keys.")
+			}
+			app.outputTranslation(app.unfilterJSON("{}", unfiltered))
+			return
 		}
 	}
 
-	if options.Translate.SourceLang == "auto" {
-		options.Translate.SourceLang = ""
+	var overridden map[string]any
+	if len(app.jsonOverrides) > 0 && !options.Translate.Update {
+		source, overridden = app.stripOverrides(source)
+	}
+
+	if !options.Translate.Update {
+		app.translatedKeys(source)
 	}
 
-	result, err := translator.Translate(
-		ctx,
-		dragoman.TranslateParams{
-			Document:     string(source),
-			Source:       options.Translate.SourceLang,
-			Target:       options.Translate.TargetLang,
-			Preserve:     options.Translate.Preserve,
-			Instructions: options.Translate.Instructions,
-			SplitChunks:  options.Translate.SplitChunks,
-		},
+	var (
+		dups         []dragoman.JSONDuplicate
+		cached       dragoman.JSONOverrides
+		cachedSource map[string]any
 	)
-	app.kong.FatalIfErrorf(err, "failed to translate document")
+	dedupe := options.Translate.Dedupe && !options.Translate.Prose && (options.Translate.Update || isJSONFile(sourceFile()))
+	if dedupe {
+		source, dups = app.dedupeJSON(source)
+		source, cachedSource, cached = app.stripCached(source)
+	}
 
-	if options.Translate.Dry {
-		fmt.Fprintf(os.Stdout, "%s\n", result)
-		return
+	// The body of Markdown documents is translated without its front matter,
+	// whose selected fields are translated separately. Batch jobs translate
+	// the document as a whole.
+	var frontMatter []byte
+	if isMarkdownFile(sourceFile()) && !app.planning() {
+		frontMatter, source = markdown.SplitFrontMatter(source)
 	}
 
-	if options.Translate.Update {
-		var resultMap map[string]any
-		if err := json.Unmarshal([]byte(result), &resultMap); err != nil {
-			app.kong.FatalIfErrorf(err, "failed to unmarshal result as JSON")
+	var translatedFront string
+	if frontMatter != nil {
+		translatedFront = app.translateFrontMatter(ctx, translator, frontMatter)
+	}
+
+	var result string
+	if frontMatter != nil && len(bytes.TrimSpace(source)) == 0 {
+		result = string(source)
+	} else if source == nil {
+		// All strings were already translated in this run.
+		result = "{}"
+	} else if options.Translate.Prose {
+		result = app.translateProse(ctx, translator, source)
+	} else {
+		params := app.translateParams(string(source), options.Translate.SplitChunks)
+		params.SplitHeadingLevels = options.Translate.SplitLevels
+		params.Overrides = app.chunkOverrides
+		if options.Translate.Update || isJSONFile(sourceFile()) {
+			app.validateJSON(&params)
+			app.packJSON(&params)
+			if options.Translate.I18next {
+				params.I18next = true
+				params.ValidationRetries = options.Retries
+			}
+			translator = app.structuredTranslator(translator, source)
+		}
+
+		tracked := app.trackJob(&params)
+
+		switch {
+		case app.planning():
+			app.plan(translator, params)
+		case options.Translate.Bilingual != "":
+			result, err = app.translateBilingual(ctx, translator, params, tracked)
+		case options.Translate.StreamOut:
+			stream := app.openStreamOut(&params, translatedFront)
+			_, err = translator.Translate(ctx, params)
+			if err != nil {
+				app.abortStreamOut(stream)
+				app.failJob(tracked)
+			}
+			app.fatalIfErrorf(err, "failed to translate document")
+			app.finishStreamOut(stream)
+			app.finishJob(tracked)
+			return
+		default:
+			result, err = translator.Translate(ctx, params)
+			if err != nil && !keepsPartial(err) {
+				app.failJob(tracked)
+				app.fatalIfErrorf(err, "failed to translate document")
+			}
 		}
-		dragoman.JSONMerge(originalOutMap, resultMap)
 
-		marshaled, err := jsonMarshal(originalOutMap)
 		if err != nil {
-			app.kong.FatalIfErrorf(err, "failed to marshal result map")
+			// The stopped translation is reported after its partial result
+			// was written.
+			result += stopMarker(err)
+			defer app.reportStopped(tracked, err)
+		} else {
+			app.finishJob(tracked)
+		}
+
+		if dedupe && !app.planning() {
+			app.cacheJSON(source, result)
 		}
-		result = string(marshaled)
 	}
 
-	f, err := os.Create(options.Translate.Out)
-	if err != nil {
-		app.kong.FatalIfErrorf(err, "failed to create output file %q", options.Translate.Out)
+	if app.planning() {
+		app.outputTranslation(result)
 		return
 	}
-	defer f.Close()
 
-	if _, err = fmt.Fprint(f, result); err != nil {
-		app.kong.FatalIfErrorf(err, "failed to write to output file %q", options.Translate.Out)
-		return
+	if frontMatter != nil {
+		result = translatedFront + result
 	}
 
-	if err = f.Close(); err != nil {
-		app.kong.FatalIfErrorf(err, "failed to close output file %q", options.Translate.Out)
-		return
+	if cached != nil {
+		result = app.applyCached(result, cachedSource, cached)
 	}
-}
 
-func (app *App) improve() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
+	if len(dups) > 0 {
+		result = app.restoreDuplicates(result, dups)
+	}
 
-	opts := []openai.Option{
-		openai.Model(options.OpenAIModel),
-		openai.ResponseFormat(options.OpenAIResponseFormat),
-		openai.Temperature(options.OpenAITemperature),
-		openai.TopP(options.OpenAITopP),
-		openai.Timeout(options.Timeout),
-		openai.Verbose(options.Verbose),
+	if overridden != nil {
+		result = app.applyOverrides(result, overridden)
 	}
 
-	if options.Stream {
-		opts = append(opts, openai.Stream(os.Stdout))
+	if unfiltered != nil {
+		result = app.unfilterJSON(result, unfiltered)
 	}
 
-	model := openai.New(options.OpenAIKey, opts...)
-	improver := dragoman.NewImprover(model)
+	if options.Translate.Dry && !options.Translate.Diff {
+		app.printResult(app.layoutJSON(app.styleKeys(result)))
+		return
+	}
 
-	var (
-		source []byte
-		err    error
-	)
-	if options.Improve.SourcePath == "" {
-		source, err = readAll(os.Stdin)
-		if errors.Is(err, errEmptyStdin) {
-			app.kong.Fatalf("you must either provide the <source> file or provide the source text via stdin")
-		} else {
-			app.kong.FatalIfErrorf(err, "failed to read source from stdin")
+	if options.Translate.Update {
+		var resultMap map[string]any
+		if err := json.Unmarshal([]byte(result), &resultMap); err != nil {
+			app.fatalIfErrorf(err, "failed to unmarshal result as JSON")
 		}
-	} else {
-		source, err = os.ReadFile(options.Improve.SourcePath)
-		app.kong.FatalIfErrorf(err, "failed to read source file %q", options.Improve.SourcePath)
-	}
+		// Skipped keys stay missing, so that the next run tries them again.
+		dragoman.JSONDelete(resultMap, app.skippedPaths(0))
+		dragoman.JSONMerge(originalOutMap, resultMap)
 
-	result, err := improver.Improve(ctx, dragoman.ImproveParams{
-		Document:     string(source),
-		SplitChunks:  options.Improve.SplitChunks,
-		Formality:    options.Improve.Formality,
-		Instructions: options.Improve.Instructions,
-		Keywords:     options.Improve.Keywords,
-		Language:     options.Improve.Language,
-	})
-	if err != nil {
-		app.kong.FatalIfErrorf(err, "failed to improve document")
+		marshaled, err := jsonMarshal(originalOutMap)
+		if err != nil {
+			app.fatalIfErrorf(err, "failed to marshal result map")
+		}
+		result = string(marshaled)
 	}
 
-	if options.Improve.Dry {
-		fmt.Fprintf(os.Stdout, "%s\n", result)
-		return
+	app.outputTranslation(result)
+	app.recordProvenance(original, result)
+}
+
+// outputTranslation prints the estimate or the translation in dry-run mode,
+// copies it to the clipboard if no output file was provided, or writes it to
+// the output file. Nothing is written while a batch job is being submitted.
+func (app *App) outputTranslation(result string) {
+	if !app.planning() {
+		result = app.layoutJSON(app.styleKeys(result))
 	}
 
-	f, err := os.Create(options.Improve.Out)
-	if err != nil {
-		app.kong.FatalIfErrorf(err, "failed to create output file %q", options.Improve.Out)
-		return
+	switch {
+	case options.Translate.Estimate:
+		app.printEstimate()
+	case options.Translate.PrintPrompts:
+		// The prompts were printed while they were planned.
+	case app.submitting:
+	case options.Translate.Diff:
+		app.printDiff(result)
+	case options.Translate.Dry:
+		app.printResult(result)
+	case options.Translate.Out == "":
+		app.copyResult(result)
+	default:
+		app.writeResult(options.Translate.Out, result)
 	}
-	defer f.Close()
+}
 
-	if _, err = fmt.Fprint(f, result); err != nil {
-		app.kong.FatalIfErrorf(err, "failed to write to output file %q", options.Improve.Out)
-		return
+// useParams sets the translation options of the current command and reads the
+// context files.
+func (app *App) useParams(ctx context.Context, model dragoman.Model, params *translationOptions) {
+	if params.SourceLang == "auto" {
+		params.SourceLang = ""
+	}
+	app.params = params
+	app.languageName("--from", params.SourceLang)
+	app.languageName("--to", params.TargetLang)
+	if params.TargetLang != "" && !slices.Contains(app.languages, params.TargetLang) {
+		app.languages = append(app.languages, params.TargetLang)
 	}
 
-	if err = f.Close(); err != nil {
-		app.kong.FatalIfErrorf(err, "failed to close output file %q", options.Improve.Out)
+	if app.usesDeepL() || app.registeredEngine() != nil {
+		if len(params.Context) > 0 || len(params.Instructions) > 0 || len(params.Preserve) > 0 {
+			app.warn("%s ignores context files, instructions and preserved terms", engineName())
+		}
 		return
 	}
 
-	if options.Improve.Dry {
-		fmt.Fprintf(os.Stdout, "%s\n", result)
+	app.refs = app.readContext(ctx, model, params.Context)
+}
+
+// translator creates the translator for the given translation options. If a
+// prompt file was provided, it replaces the built-in prompt. If DeepL is the
+// provider, the model is not used.
+func (app *App) translator(model dragoman.Model, params *translationOptions) *dragoman.Translator {
+	if app.usesDeepL() {
+		if params.PromptFile != "" {
+			app.fatalf(exitConfig, "--prompt-file requires the 'openai' provider")
+		}
+		return dragoman.NewTranslator(nil, dragoman.TranslateWith(app.deepl(deeplFormality(params.Formality)...)), dragoman.TranslatorLogger(app.logger()))
 	}
 
-	if options.Improve.Out != "" {
-		if err := os.WriteFile(options.Improve.Out, []byte(result), 0644); err != nil {
-			app.kong.FatalIfErrorf(err, "failed to write output to %q", options.Improve.Out)
+	if engine := app.registeredEngine(); engine != nil {
+		if params.PromptFile != "" {
+			app.fatalf(exitConfig, "--prompt-file cannot be used with the %q provider", options.Provider)
 		}
+		return dragoman.NewTranslator(model, dragoman.TranslateWith(engine), dragoman.TranslatorLogger(app.logger()))
+	}
+
+	if params.PromptFile == "" {
+		return dragoman.NewTranslator(model, dragoman.TranslatorLogger(app.logger()))
+	}
+
+	text, err := os.ReadFile(params.PromptFile)
+	app.fatalIfErrorf(err, "failed to read prompt file %q", params.PromptFile)
+
+	tmpl, err := dragoman.ParsePromptTemplate(string(text))
+	if err != nil {
+		app.fatalf(exitConfig, "invalid prompt file %q: %v", params.PromptFile, err)
+	}
+
+	return dragoman.NewTranslator(model, dragoman.PromptTemplate(tmpl), dragoman.TranslatorLogger(app.logger()))
+}
+
+// checkHeadingLevels fails if one of the levels of --split-headings is not the
+// level of a Markdown heading.
+func (app *App) checkHeadingLevels(levels []int) {
+	for _, level := range levels {
+		if level < 1 || level > 6 {
+			app.fatalf(exitConfig, "invalid --split-headings level %d: levels must be between 1 and 6", level)
+		}
+	}
+}
+
+// translateParams returns the parameters for translating the given document
+// according to the command-line options.
+func (app *App) translateParams(doc string, splitChunks []string) dragoman.TranslateParams {
+	params := dragoman.TranslateParams{
+		Document:     doc,
+		Source:       app.languageName("--from", app.params.SourceLang),
+		Target:       app.languageName("--to", app.params.TargetLang),
+		Preserve:     app.params.Preserve,
+		Instructions: app.params.Instructions,
+		SystemPrompt: app.params.SystemPrompt,
+		Examples:     app.examples(),
+		Formality:    app.params.Formality,
+		Context:      app.refs,
+		SplitChunks:  splitChunks,
+
+		RefusalRetries: app.params.RefusalRetries,
+		SkipRefused:    app.params.OnRefusal == "skip",
+		ErrorPolicy:    app.errorPolicy(),
+		ChunkTimeout:   app.params.ChunkTotalTimeout,
+		RunTimeout:     app.params.RunTimeout,
+		Stop:           app.stop,
+		Budget:         app.budget(),
+		OnSkip:         app.skip,
+		OnChunkStart:   app.progress.chunkStart,
+		OnChunkDone:    app.progress.chunkDone,
+	}
+
+	for _, pattern := range app.params.PreservePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			app.fatalf(exitConfig, "invalid --preserve-patterns %q: %v", pattern, err)
+		}
+		params.PreservePatterns = append(params.PreservePatterns, re)
+		params.ValidationRetries = options.Retries
+	}
+	if patterns := app.scrubPatterns(); len(patterns) > 0 {
+		params.PreservePatterns = append(params.PreservePatterns, patterns...)
+		params.ValidationRetries = options.Retries
+	}
+
+	if ratio := app.params.MaxLengthRatio; ratio != 0 {
+		if ratio < 0 {
+			app.fatalf(exitConfig, "--max-length-ratio must be positive")
+		}
+		params.LengthConstraint = dragoman.LengthConstraint{MaxRatio: ratio, Retries: options.Retries}
+		params.OnOverlong = app.overlong
+	}
+
+	if app.params.CheckPlaceholders {
+		validate, err := dragoman.ValidatePlaceholders(app.params.PlaceholderPatterns)
+		if err != nil {
+			app.fatalf(exitConfig, "invalid --placeholder-patterns: %v", err)
+		}
+		params.Validate = validate
+		params.ValidationRetries = options.Retries
+	}
+
+	// The prompts of batch jobs are collected before anything is translated,
+	// so they cannot contain previous translations.
+	if !app.planning() && app.replay == nil {
+		params.CarryOver = app.params.CarryOver
+		params.CarrySummary = app.params.CarrySummary
+	}
+
+	return params
+}
+
+// overlong warns about a translation that still exceeds --max-length-ratio
+// after all retries.
+func (app *App) overlong(text dragoman.OverlongText) {
+	app.warn("translation of %q is longer than %d characters: %q", text.Source, text.Limit, text.Translation)
+}
+
+// examples returns the example translations of the --example flags.
+func (app *App) examples() []dragoman.Example {
+	examples := make([]dragoman.Example, 0, len(app.params.Examples))
+	for _, example := range app.params.Examples {
+		source, target, ok := strings.Cut(example, "=")
+		if !ok || strings.TrimSpace(source) == "" || strings.TrimSpace(target) == "" {
+			app.fatalf(exitConfig, "invalid --example %q: expected 'source=translation'", example)
+		}
+		examples = append(examples, dragoman.Example{Source: source, Target: target})
+	}
+	return examples
+}
+
+// validateJSON enables the structural validation of translated JSON documents
+// unless it was disabled using --no-validate. The structure is validated
+// before the placeholders are checked.
+func (app *App) validateJSON(params *dragoman.TranslateParams) {
+	if !app.params.Validate {
+		return
+	}
+	params.Validate = dragoman.ValidateAll(dragoman.ValidateJSON, params.Validate)
+	params.ValidationRetries = options.Retries
+}
+
+// packJSON packs the values of the JSON document into chunks of at most
+// --pack-tokens tokens, which are merged back into one document after they
+// were translated.
+func (app *App) packJSON(params *dragoman.TranslateParams) {
+	if options.Translate.PackTokens <= 0 {
+		return
+	}
+	params.Chunker = dragoman.JSONPacker(options.Translate.PackTokens, func(text string) (int, error) {
+		return openai.PromptTokens(options.OpenAIModel, text)
+	})
+	params.Join = dragoman.JoinJSON
+}
+
+// structuredTranslator returns a translator whose OpenAI client asks the model
+// to follow the JSON schema of the JSON source, so that changed keys and
+// invalid JSON are less likely. The given translator is returned if structured
+// output is disabled or cannot be used, like for DeepL, batch jobs, documents
+// that are split into chunks or whose root is an array, and i18next
+// resources, whose plural forms are regrouped before their translation.
+func (app *App) structuredTranslator(translator *dragoman.Translator, source []byte) *dragoman.Translator {
+	if !options.Translate.Structured || app.usesDeepL() || app.usesRegistered() || app.replay != nil || app.planning() || len(options.Translate.SplitChunks) > 0 || len(options.Translate.SplitLevels) > 0 || options.Translate.PackTokens > 0 || options.Translate.I18next {
+		return translator
+	}
+
+	// The parameters of the function that the model is forced to call must be
+	// an object, so documents whose root is an array are translated without
+	// structured output.
+	schema, err := dragoman.JSONSchema(source)
+	if err != nil || schema["type"] != "object" {
+		return translator
+	}
+
+	return app.translator(app.model(openai.StructuredOutput(schema)), &options.Translate.Params)
+}
+
+// updateHTML translates only the added or changed text nodes of an HTML source
+// file and splices them into the existing output file. It reports false if the
+// output file does not exist yet, in which case the whole document must be
+// translated.
+func (app *App) updateHTML(ctx context.Context, translator *dragoman.Translator, source []byte) bool {
+	translated, err := os.ReadFile(options.Translate.Out)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	app.fatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
+
+	var previous []byte
+	if options.Translate.Previous != "" {
+		previous, err = os.ReadFile(options.Translate.Previous)
+		app.fatalIfErrorf(err, "failed to read previous source file %q", options.Translate.Previous)
+	}
+
+	update, err := dragoman.HTMLDiff(previous, source, translated)
+	app.fatalIfErrorf(err, "failed to diff source and target")
+
+	pending := update.Pending()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d text nodes need to be translated.\n", len(pending))
+	}
+
+	translations, err := app.translateTexts(ctx, translator, pending)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	result, err := update.Render(translations)
+	app.fatalIfErrorf(err, "failed to render updated HTML")
+
+	app.outputTranslation(string(result))
+
+	return true
+}
+
+// translatePO translates the messages of a gettext catalog that have no
+// translation yet. If the output file already exists, its untranslated
+// messages are translated and merged into it; otherwise the source catalog is
+// used as the template.
+func (app *App) translatePO(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	catalog := source
+	if options.Translate.Out != "" {
+		existing, err := os.ReadFile(options.Translate.Out)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			app.fatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
+		}
+		if err == nil {
+			catalog = existing
+		}
+	}
+
+	f, err := po.Parse(catalog)
+	app.fatalIfErrorf(err, "failed to parse PO file")
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d messages need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string)
+	plurals := make(map[string]*po.Entry)
+	for i, entry := range entries {
+		if entry.IDPlural != "" {
+			plurals[strconv.Itoa(i)] = entry
+			continue
+		}
+		texts[strconv.Itoa(i)] = entry.ID
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	forms, err := app.translatePlurals(ctx, translator, plurals)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	var categories []string
+	if len(forms) > 0 {
+		categories = app.pluralCategories(f.NPlurals())
+	}
+	for i, entry := range entries {
+		id := strconv.Itoa(i)
+		if entry.IDPlural != "" {
+			if translated, ok := forms[id]; ok {
+				entry.SetTranslation(pluralForms(translated, categories, f.NPlurals())...)
+			}
+			continue
+		}
+		if translated, ok := translations[id]; ok {
+			entry.SetTranslation(translated)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+// translateXLIFF translates the units of an XLIFF document that have no target
+// yet. If the output file already exists, its untranslated units are
+// translated and merged into it; otherwise the source document is used.
+// Translations that do not keep the inline placeholders of their source are
+// discarded.
+func (app *App) translateXLIFF(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	doc := source
+	if options.Translate.Out != "" {
+		existing, err := os.ReadFile(options.Translate.Out)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			app.fatalIfErrorf(err, "failed to read target file %q", options.Translate.Out)
+		}
+		if err == nil {
+			doc = existing
+		}
+	}
+
+	f, err := xliff.Parse(doc)
+	app.fatalIfErrorf(err, "failed to parse XLIFF file")
+
+	units := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d units need to be translated.\n", len(units))
+	}
+
+	texts := make(map[string]string, len(units))
+	for i, unit := range units {
+		texts[strconv.Itoa(i)] = unit.Source
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, unit := range units {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := unit.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+// translateAndroidXML translates the strings, string arrays and plurals of an
+// Android resource file. If the output file already exists, the resources that
+// are missing in it are translated and inserted; existing translations are
+// kept. Translations that do not keep the format specifiers and inline tags of
+// their source are discarded.
+func (app *App) translateAndroidXML(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	src, err := androidxml.Parse(source)
+	app.fatalIfErrorf(err, "failed to parse Android resource file")
+
+	var target *androidxml.File
+	if existing := app.readExistingOut(); existing != nil {
+		target, err = androidxml.Parse(existing)
+		app.fatalIfErrorf(err, "failed to parse Android resource file %q", options.Translate.Out)
+	}
+
+	f, err := androidxml.Merge(src, target)
+	app.fatalIfErrorf(err, "failed to merge Android resource files")
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+// translateProse translates only the prose of a Markdown document and leaves
+// code, front matter and URLs untouched.
+func (app *App) translateProse(ctx context.Context, translator *dragoman.Translator, source []byte) string {
+	ranges := markdown.Ranges(source)
+
+	texts := make(map[string]string, len(ranges))
+	for i, r := range ranges {
+		texts[strconv.Itoa(i)] = string(source[r.Start:r.End])
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	replacements := make([]string, len(ranges))
+	for i, r := range ranges {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			translated = string(source[r.Start:r.End])
+		}
+		replacements[i] = translated
+	}
+
+	return string(markdown.Replace(source, ranges, replacements))
+}
+
+// translateBilingual translates the document and renders the source and the
+// translation interleaved in the format of the --bilingual option. If the
+// translation was stopped, the translated chunks are rendered and the error
+// of the stopped translation is returned.
+func (app *App) translateBilingual(ctx context.Context, translator *dragoman.Translator, params dragoman.TranslateParams, tracked *job) (string, error) {
+	pairs, stopErr := translator.TranslateChunks(ctx, params)
+	if stopErr != nil && !keepsPartial(stopErr) {
+		app.failJob(tracked)
+		app.fatalIfErrorf(stopErr, "failed to translate document")
+	}
+
+	result, err := dragoman.RenderBilingual(options.Translate.Bilingual, pairs)
+	app.fatalIfErrorf(err, "failed to render bilingual document")
+
+	return result, stopErr
+}
+
+// translateTexts translates a set of independent texts in a single prompt by
+// encoding them as a JSON object. Texts that are equal after normalization are
+// translated only once, and texts that have already been translated during the
+// run are taken from the segment cache.
+func (app *App) translateTexts(ctx context.Context, translator *dragoman.Translator, texts map[string]string) (map[string]string, error) {
+	ids := make([]string, 0, len(texts))
+	for id := range texts {
+		ids = append(ids, id)
+	}
+	sortIDs(ids)
+
+	out := make(map[string]string, len(texts))
+	pending := make(map[string]string)
+	var keys []string
+	for _, id := range ids {
+		text := texts[id]
+		if translated, ok := app.cache.Get(text); ok {
+			out[id] = translated
+			continue
+		}
+
+		key := app.cache.Key(text)
+		if _, ok := pending[key]; !ok {
+			pending[key] = text
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return out, nil
+	}
+
+	unique := make(map[string]string, len(keys))
+	for i, key := range keys {
+		unique[strconv.Itoa(i)] = pending[key]
+	}
+
+	translations, err := app.translateJSONTexts(ctx, translator, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		if translated, ok := translations[strconv.Itoa(i)]; ok {
+			app.cache.Put(pending[key], translated)
+		}
+	}
+
+	for id, text := range texts {
+		if translated, ok := app.cache.Get(text); ok {
+			out[id] = translated
+		} else if !app.planning() {
+			app.warn("the model returned no translation for %q", text)
+		}
+	}
+
+	return out, nil
+}
+
+func (app *App) translateJSONTexts(ctx context.Context, translator *dragoman.Translator, texts map[string]string) (map[string]string, error) {
+	translations := make(map[string]string)
+
+	doc, err := jsonMarshal(texts)
+	if err != nil {
+		return nil, fmt.Errorf("marshal texts: %w", err)
+	}
+
+	params := app.translateParams(string(doc), nil)
+	app.validateJSON(&params)
+
+	if app.planning() {
+		app.plan(translator, params)
+		return texts, nil
+	}
+
+	skipped := len(app.skipped)
+
+	result, err := translator.Translate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(result), &translations); err != nil {
+		return nil, fmt.Errorf("unmarshal result as JSON: %w", err)
+	}
+
+	for _, path := range app.skippedPaths(skipped) {
+		delete(translations, strings.Join(path, "."))
+	}
+
+	return translations, nil
+}
+
+// dedupeJSON removes repeated strings from the JSON source, so that each unique
+// string is translated only once.
+func (app *App) dedupeJSON(source []byte) ([]byte, []dragoman.JSONDuplicate) {
+	var doc map[string]any
+	err := json.Unmarshal(source, &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal source as JSON")
+
+	deduped, dups := dragoman.JSONDeduplicate(doc)
+	if len(dups) == 0 {
+		return source, nil
+	}
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Skipping %d duplicate strings.\n", len(dups))
+	}
+
+	out, err := jsonMarshal(deduped)
+	app.fatalIfErrorf(err, "failed to marshal deduplicated source")
+
+	return out, dups
+}
+
+// segmentCache returns the segment cache of the target language, which is
+// shared by all translations to that language in the same run.
+func (app *App) segmentCache() *dragoman.SegmentCache {
+	target := strings.ToLower(options.Translate.Params.TargetLang)
+	if cache, ok := app.caches[target]; ok {
+		return cache
+	}
+	if app.caches == nil {
+		app.caches = make(map[string]*dragoman.SegmentCache)
+	}
+	cache := dragoman.NewSegmentCache(options.Translate.Normalize...)
+	app.caches[target] = cache
+	return cache
+}
+
+// stripCached removes the strings of the JSON source whose translation is in
+// the segment cache, because they were already translated in this run. It
+// returns the source without them, the unstripped source and the cached
+// translations. The returned source is nil if no string is left to translate.
+func (app *App) stripCached(source []byte) ([]byte, map[string]any, dragoman.JSONOverrides) {
+	var doc map[string]any
+	err := json.Unmarshal(source, &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal source as JSON")
+
+	cached := app.cache.JSON(doc)
+	if len(cached) == 0 {
+		return source, nil, nil
+	}
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Reusing the translations of %d strings.\n", len(cached))
+	}
+
+	stripped := cached.Strip(doc)
+	if pending, err := dragoman.JSONDiff(stripped, map[string]any{}); err == nil && len(pending) == 0 {
+		return nil, doc, cached
+	}
+
+	out, err := jsonMarshal(stripped)
+	app.fatalIfErrorf(err, "failed to marshal source")
+
+	return out, doc, cached
+}
+
+// cacheJSON adds the translated strings of the JSON result to the segment
+// cache, so that later files of the run reuse them.
+func (app *App) cacheJSON(source []byte, result string) {
+	var sourceDoc, resultDoc map[string]any
+	if json.Unmarshal(source, &sourceDoc) != nil || json.Unmarshal([]byte(result), &resultDoc) != nil {
+		return
+	}
+	// Skipped strings were not translated and must not be reused.
+	dragoman.JSONDelete(resultDoc, app.skippedPaths(0))
+	app.cache.PutJSON(sourceDoc, resultDoc)
+}
+
+// applyCached sets the strings of the translated JSON document that were
+// stripped by stripCached to their cached translations.
+func (app *App) applyCached(result string, source map[string]any, cached dragoman.JSONOverrides) string {
+	var doc map[string]any
+	err := json.Unmarshal([]byte(result), &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal result as JSON")
+
+	cached.Apply(source, doc)
+
+	out, err := jsonMarshal(doc)
+	app.fatalIfErrorf(err, "failed to marshal result")
+
+	return string(out)
+}
+
+// restoreDuplicates copies the translations of deduplicated strings to all of
+// their occurrences.
+func (app *App) restoreDuplicates(result string, dups []dragoman.JSONDuplicate) string {
+	var doc map[string]any
+	err := json.Unmarshal([]byte(result), &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal result as JSON")
+
+	err = dragoman.JSONRestoreDuplicates(doc, dups)
+	app.fatalIfErrorf(err, "failed to restore duplicate strings")
+
+	out, err := jsonMarshal(doc)
+	app.fatalIfErrorf(err, "failed to marshal result")
+
+	return string(out)
+}
+
+// sortIDs sorts text identifiers numerically if possible, so that texts are
+// sent to the model in document order.
+func sortIDs(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+}
+
+func isPOFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".po" || ext == ".pot"
+}
+
+func isXLIFFFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".xlf" || ext == ".xliff"
+}
+
+func isAndroidXMLFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".xml"
+}
+
+// selectsXML reports whether the source is translated as a generic XML
+// document, whose texts are selected by --xml-path and --xml-attr.
+func selectsXML() bool {
+	return len(options.Translate.XMLPaths) > 0 || len(options.Translate.XMLAttrs) > 0
+}
+
+func isAppleStringsFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".strings"
+}
+
+func isStringCatalogFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".xcstrings"
+}
+
+func isPropertiesFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".properties"
+}
+
+func isResxFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".resx"
+}
+
+func isGoTemplateFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tmpl", ".gotmpl", ".gohtml":
+		return true
+	default:
+		return false
+	}
+}
+
+func isGoFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".go"
+}
+
+func isSubtitleFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".srt" || ext == ".vtt"
+}
+
+func isCSVFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv", ".tab":
+		return true
+	default:
+		return false
+	}
+}
+
+func isMarkdownFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".mdx", ".markdown":
+		return true
+	default:
+		return false
+	}
+}
+
+func isJSONFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".json"
+}
+
+func isJSONCFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".jsonc" || ext == ".json5"
+}
+
+func isHTMLFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".html" || ext == ".htm"
+}
+
+func (app *App) improve() {
+	app.requireModel("improve")
+	app.checkHeadingLevels(options.Improve.Params.SplitHeadings)
+
+	if options.CheckOnly {
+		if options.Improve.Out == "" {
+			app.fatalf(exitConfig, "you must provide the <out> file when using --check-only")
+		}
+		app.checkImprove()
+		return
+	}
+
+	source := app.readSource(options.Improve.SourcePath, options.Improve.Clipboard)
+
+	if !options.Improve.Dry && options.Improve.Out != "" {
+		app.checkWritable(options.Improve.Out)
+	}
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	if !options.Improve.Dry && options.Improve.Out != "" {
+		app.lockOutput(ctx, options.Improve.Out)
+		defer app.releaseLocks()
+	}
+
+	if app.usesRegistered() {
+		app.requireModel("improve")
+	}
+	model := app.translationModel()
+	improver := dragoman.NewImprover(model, dragoman.ImproverLogger(app.logger()))
+	refs := app.readContext(ctx, model, options.Improve.Params.Context)
+
+	params := improveParams(string(source), &options.Improve.Params, refs)
+
+	var (
+		result string
+		err    error
+	)
+	if isJSONFile(options.Improve.SourcePath) {
+		if len(params.Sections) > 0 || params.PreserveOutline {
+			app.fatalf(exitConfig, "--sections and --preserve-outline cannot be used for JSON files")
+		}
+		result, err = improver.ImproveJSON(ctx, params)
+	} else {
+		result, err = improver.Improve(ctx, params)
+	}
+	if err != nil {
+		app.fatalIfErrorf(err, "failed to improve document")
+	}
+
+	if options.Improve.ShowChanges {
+		app.printChanges(string(source), result)
+	}
+
+	if options.Improve.Dry || (options.Improve.Out == "" && !options.Improve.Clipboard) {
+		if !options.Improve.ShowChanges {
+			app.printResult(result)
+		}
+		return
+	}
+
+	if options.Improve.Out == "" {
+		app.copyResult(result)
+		return
+	}
+
+	app.writeResult(options.Improve.Out, result)
+}
+
+// improveParams returns the parameters for improving the given document
+// according to the command-line options.
+func improveParams(doc string, opts *improveOptions, refs []string) dragoman.ImproveParams {
+	return dragoman.ImproveParams{
+		Document:           doc,
+		SplitChunks:        opts.SplitChunks,
+		SplitHeadingLevels: opts.SplitHeadings,
+		Formality:          opts.Formality,
+		Instructions:       opts.Instructions,
+		Context:            refs,
+		Keywords:           opts.Keywords,
+		Language:           opts.Language,
+		PreserveOutline:    opts.PreserveOutline,
+		Sections:           opts.Sections,
+	}
+}
+
+// readContext reads the provided context files. Files that exceed the context
+// limit are summarized by the model before they are included in prompts.
+func (app *App) readContext(ctx context.Context, model dragoman.Model, paths []string) []string {
+	docs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		app.fatalIfErrorf(err, "failed to read context file %q", path)
+
+		doc := string(content)
+		if options.ContextLimit > 0 && len(doc) > options.ContextLimit && !app.planning() && app.replay == nil {
+			if options.Verbose {
+				fmt.Fprintf(os.Stderr, "Summarizing context file %q ...\n", path)
+			}
+			doc, err = dragoman.SummarizeContext(ctx, model, doc)
+			app.fatalIfErrorf(err, "failed to summarize context file %q", path)
+		}
+
+		docs = append(docs, doc)
 	}
+	return docs
 }
 
 var errEmptyStdin = errors.New("stdin is empty")
@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/openai"
+)
+
+// comparison is the report of the compare command.
+type comparison struct {
+	Source string          `json:"source"`
+	Target string          `json:"target"`
+	Models []comparedModel `json:"models"`
+	Rows   []comparisonRow `json:"rows"`
+}
+
+// comparedModel is the measurement of a model of the compare command.
+type comparedModel struct {
+	Name             string   `json:"name"`
+	DurationSeconds  float64  `json:"durationSeconds"`
+	PromptTokens     int      `json:"promptTokens"`
+	CompletionTokens int      `json:"completionTokens"`
+	Cost             *float64 `json:"cost,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// comparisonRow is a key of a JSON document or a chunk of another document
+// together with its translations by model name.
+type comparisonRow struct {
+	Key          string            `json:"key"`
+	Source       string            `json:"source"`
+	Translations map[string]string `json:"translations"`
+}
+
+// compare translates the source with each of the given models and reports the
+// translations side by side, per key for JSON documents and per chunk for
+// other documents, so that teams can choose a model for their language pair.
+func (app *App) compare() {
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the compare command")
+	}
+
+	opts := &options.Compare
+	if opts.Params.PromptFile != "" {
+		app.fatalf(exitConfig, "--prompt-file is not supported by the compare command")
+	}
+	app.checkHeadingLevels(opts.SplitLevels)
+
+	benchmarks := make([]benchmark, len(opts.Models))
+	for i, name := range opts.Models {
+		benchmarks[i] = app.benchmark(compareBackend(name))
+	}
+
+	source, err := os.ReadFile(opts.SourcePath)
+	app.fatalIfErrorf(err, "failed to read source file %q", opts.SourcePath)
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	app.useParams(ctx, app.model(), &opts.Params)
+
+	params := app.translateParams(string(source), opts.SplitChunks)
+	params.SplitHeadingLevels = opts.SplitLevels
+	isJSON := isJSONFile(opts.SourcePath)
+	if isJSON {
+		app.validateJSON(&params)
+	}
+
+	report := comparison{Source: opts.SourcePath, Target: params.Target}
+	results := make([][]dragoman.ChunkPair, len(benchmarks))
+	for i, b := range benchmarks {
+		model, pairs := app.compareModel(ctx, b, params)
+		report.Models = append(report.Models, model)
+		results[i] = pairs
+	}
+
+	if isJSON {
+		report.Rows = compareKeys(string(source), benchmarks, results)
+	} else {
+		report.Rows = compareChunks(benchmarks, results)
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case opts.JSON:
+		out, err := jsonMarshal(report)
+		app.fatalIfErrorf(err, "failed to marshal report")
+		buf.Write(out)
+	case opts.Markdown:
+		writeComparisonMarkdown(&buf, report)
+	default:
+		writeComparisonText(&buf, report)
+	}
+
+	if opts.Out == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	app.writeResult(opts.Out, buf.String())
+}
+
+// compareBackend returns the backend of a model of the --models flag. Models
+// without a provider use the configured provider, or OpenAI if the configured
+// provider is DeepL.
+func compareBackend(name string) string {
+	if name == "deepl" || strings.Contains(name, ":") {
+		return name
+	}
+	provider := options.Provider
+	if provider == "deepl" {
+		provider = "openai"
+	}
+	return provider + ":" + name
+}
+
+// compareModel translates the document with a model and measures the
+// translation. Failed translations are reported as warnings and in the report,
+// and make the command exit with exitFailure.
+func (app *App) compareModel(ctx context.Context, b benchmark, params dragoman.TranslateParams) (comparedModel, []dragoman.ChunkPair) {
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Translating %q with %s ...\n", options.Compare.SourcePath, b.name)
+	}
+
+	var usage dragoman.Usage
+	start := time.Now()
+	pairs, err := b.translator.TranslateChunks(dragoman.TrackUsage(ctx, &usage), params)
+	model := comparedModel{
+		Name:             b.name,
+		DurationSeconds:  time.Since(start).Seconds(),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			app.fatalf(exitFailure, "comparison interrupted")
+		}
+		app.warn("translation with %s failed: %v", b.name, err)
+		app.failed = true
+		model.Error = err.Error()
+		return model, nil
+	}
+
+	if prompt, completion, ok := openai.ModelPricing(b.model); ok && !b.deepl {
+		cost := dragoman.Pricing{Prompt: prompt, Completion: completion}.Cost(usage.PromptTokens, usage.CompletionTokens)
+		model.Cost = &cost
+	}
+
+	return model, pairs
+}
+
+// compareKeys returns a row for every string value of a JSON source.
+func compareKeys(source string, benchmarks []benchmark, results [][]dragoman.ChunkPair) []comparisonRow {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(source), &doc); err != nil {
+		return nil
+	}
+	values := make(map[string]string)
+	collectStrings(doc, "", values)
+
+	translations := make([]map[string]string, len(results))
+	for i, pairs := range results {
+		translations[i] = make(map[string]string)
+		for _, pair := range pairs {
+			var chunk map[string]any
+			if json.Unmarshal([]byte(pair.Translation), &chunk) == nil {
+				collectStrings(chunk, "", translations[i])
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([]comparisonRow, len(keys))
+	for i, key := range keys {
+		rows[i] = comparisonRow{Key: key, Source: values[key], Translations: make(map[string]string)}
+		for j, b := range benchmarks {
+			if text, ok := translations[j][key]; ok {
+				rows[i].Translations[b.name] = text
+			}
+		}
+	}
+
+	return rows
+}
+
+// compareChunks returns a row for every chunk of the document.
+func compareChunks(benchmarks []benchmark, results [][]dragoman.ChunkPair) []comparisonRow {
+	var rows []comparisonRow
+	for i, pairs := range results {
+		for j, pair := range pairs {
+			if j == len(rows) {
+				rows = append(rows, comparisonRow{
+					Key:          fmt.Sprintf("chunk %d", j+1),
+					Source:       pair.Source,
+					Translations: make(map[string]string),
+				})
+			}
+			rows[j].Translations[benchmarks[i].name] = pair.Translation
+		}
+	}
+	return rows
+}
+
+// writeComparisonText writes the report as aligned columns. Line breaks of
+// the texts are shown as "↵", so that every row fits on a single line.
+func writeComparisonText(w io.Writer, report comparison) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprint(tw, "KEY\tSOURCE")
+	for _, model := range report.Models {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(model.Name))
+	}
+	fmt.Fprintln(tw)
+
+	oneLine := strings.NewReplacer("\r\n", " ↵ ", "\n", " ↵ ", "\t", " ")
+	for _, row := range report.Rows {
+		fmt.Fprintf(tw, "%s\t%s", row.Key, oneLine.Replace(row.Source))
+		for _, model := range report.Models {
+			fmt.Fprintf(tw, "\t%s", oneLine.Replace(row.Translations[model.Name]))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	writeComparisonSummary(w, report)
+}
+
+// writeComparisonMarkdown writes the report as a Markdown table.
+func writeComparisonMarkdown(w io.Writer, report comparison) {
+	cell := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+	fmt.Fprint(w, "| Key | Source |")
+	for _, model := range report.Models {
+		fmt.Fprintf(w, " %s |", cell.Replace(model.Name))
+	}
+	fmt.Fprint(w, "\n| --- | --- |")
+	for range report.Models {
+		fmt.Fprint(w, " --- |")
+	}
+	fmt.Fprintln(w)
+
+	for _, row := range report.Rows {
+		fmt.Fprintf(w, "| %s | %s |", cell.Replace(row.Key), cell.Replace(row.Source))
+		for _, model := range report.Models {
+			fmt.Fprintf(w, " %s |", cell.Replace(row.Translations[model.Name]))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "\n```")
+	writeComparisonSummary(w, report)
+	fmt.Fprintln(w, "```")
+}
+
+// writeComparisonSummary writes the time, tokens and cost of each model.
+func writeComparisonSummary(w io.Writer, report comparison) {
+	failed := slices.ContainsFunc(report.Models, func(m comparedModel) bool {
+		return m.Error != ""
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "MODEL\tTIME\tTOKENS\tCOST")
+	if failed {
+		fmt.Fprint(tw, "\tERROR")
+	}
+	fmt.Fprintln(tw)
+
+	for _, model := range report.Models {
+		cost := "unknown"
+		if model.Cost != nil {
+			cost = fmt.Sprintf("$%.4f", *model.Cost)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s",
+			model.Name,
+			benchDuration(time.Duration(model.DurationSeconds*float64(time.Second))),
+			model.PromptTokens+model.CompletionTokens,
+			cost,
+		)
+		if failed {
+			fmt.Fprintf(tw, "\t%s", model.Error)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"github.com/modernice/dragoman/openai"
+)
+
+// usesCompat reports whether an OpenAI-compatible API, like OpenRouter or a
+// local vLLM server, is the translation provider.
+func (app *App) usesCompat() bool {
+	return options.Provider == "compat"
+}
+
+// compatOptions returns the options that point an OpenAI client to the API of
+// the 'compat' provider.
+func (app *App) compatOptions() []openai.Option {
+	if options.BaseURL == "" {
+		app.fatalf(exitConfig, "you must provide the base URL of the 'compat' provider using --base-url or DRAGOMAN_BASE_URL")
+	}
+	return []openai.Option{openai.BaseURL(options.BaseURL)}
+}
+
+// requireOpenAI exits if the command needs the OpenAI API but another provider
+// was selected.
+func (app *App) requireOpenAI(feature string) {
+	if options.Provider != "openai" {
+		app.fatalf(exitConfig, "%s requires the 'openai' provider", feature)
+	}
+}
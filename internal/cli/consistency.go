@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/atomicfile"
+	"github.com/modernice/dragoman/openai"
+)
+
+// consistency embeds the strings of the translated JSON documents and reports
+// the sources that are the same or nearly the same, but were translated
+// differently. The command exits with exitValidation if it finds any.
+func (app *App) consistency() {
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the consistency command")
+	}
+	app.requireModel("consistency")
+
+	opts := options.Consistency
+	for _, similarity := range []float64{opts.SourceSimilarity, opts.TranslationSimilarity} {
+		if similarity <= 0 || similarity > 1 {
+			app.fatalf(exitConfig, "similarities must be between 0 and 1")
+		}
+	}
+
+	var entries []dragoman.ConsistencyEntry
+	for _, pair := range opts.Pairs {
+		sourcePath, targetPath, ok := strings.Cut(pair, "=")
+		if !ok {
+			app.fatalf(exitConfig, "invalid pair %q: expected <source>=<translation>", pair)
+		}
+		if !isJSONFile(sourcePath) || !isJSONFile(targetPath) {
+			app.fatalf(exitConfig, "consistency requires JSON source and target files")
+		}
+
+		source, target := app.readStrings(sourcePath), app.readStrings(targetPath)
+		keys := make([]string, 0, len(source))
+		for key := range source {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if translation, ok := target[key]; ok {
+				entries = append(entries, dragoman.ConsistencyEntry{File: targetPath, Key: key, Source: source[key], Translation: translation})
+			}
+		}
+	}
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Embedding %d strings ...\n", len(entries))
+	}
+
+	found, err := dragoman.CheckConsistency(ctx, app.model(openai.EmbeddingModel(opts.EmbeddingModel)), dragoman.ConsistencyParams{
+		Entries:               entries,
+		SourceSimilarity:      opts.SourceSimilarity,
+		TranslationSimilarity: opts.TranslationSimilarity,
+		BatchSize:             opts.BatchSize,
+	})
+	app.fatalIfErrorf(err, "failed to check consistency")
+
+	var (
+		report bytes.Buffer
+		w      io.Writer = os.Stdout
+	)
+	if opts.Out != "" {
+		w = &report
+	}
+
+	if opts.JSON {
+		if found == nil {
+			found = []dragoman.Inconsistency{}
+		}
+		var b []byte
+		if b, err = jsonMarshal(found); err == nil {
+			_, err = w.Write(b)
+		}
+	} else {
+		err = writeInconsistencies(w, found)
+	}
+	app.fatalIfErrorf(err, "failed to write report")
+
+	if opts.Out != "" {
+		err = atomicfile.WriteFile(opts.Out, report.Bytes(), 0644)
+		app.fatalIfErrorf(err, "failed to write report file %q", opts.Out)
+		app.written = append(app.written, opts.Out)
+	}
+
+	if len(found) > 0 {
+		app.pending = true
+	}
+}
+
+// writeInconsistencies writes the inconsistencies as text: the sources of
+// every inconsistency, followed by its translations and the keys that use
+// them.
+func writeInconsistencies(w io.Writer, found []dragoman.Inconsistency) error {
+	if len(found) == 0 {
+		_, err := fmt.Fprintln(w, "No inconsistent translations found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, inc := range found {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+
+		var sources []string
+		seen := make(map[string]bool)
+		for _, v := range inc.Variants {
+			for _, e := range v.Entries {
+				if source := strings.TrimSpace(e.Source); !seen[source] {
+					seen[source] = true
+					sources = append(sources, fmt.Sprintf("%q", source))
+				}
+			}
+		}
+		fmt.Fprintf(tw, "%s is translated in %d ways:\n", strings.Join(sources, ", "), len(inc.Variants))
+
+		for _, v := range inc.Variants {
+			keys := make([]string, len(v.Entries))
+			for j, e := range v.Entries {
+				keys[j] = e.File + ":" + e.Key
+			}
+			fmt.Fprintf(tw, "  %q\t%s\n", v.Translation, strings.Join(keys, ", "))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/modernice/dragoman/lang"
+)
+
+// contentFile is a content file of a static site and one of its translations.
+type contentFile struct {
+	source string
+	out    string
+	lang   string
+}
+
+// content translates the Markdown files of the content directory of a static
+// site generator, like Hugo or Jekyll, into a copy per language. The body and
+// the selected front-matter fields of each file are translated like a call to
+// the translate command. Translations that are newer than their source are
+// skipped unless --force is set.
+func (app *App) content() {
+	opts := &options.Content
+	app.checkHeadingLevels(opts.SplitLevels)
+
+	for _, l := range append([]string{opts.From}, opts.To...) {
+		if !isLocaleTag(l) {
+			app.fatalf(exitConfig, "invalid language %q: languages must be tags like 'de' or 'pt-BR'", l)
+		}
+	}
+
+	files, err := contentFiles(opts.Dir, opts.Layout, opts.From, opts.To, opts.Ext)
+	app.fatalIfErrorf(err, "failed to read content directory %q", opts.Dir)
+
+	var queue []contentFile
+	for _, f := range files {
+		if !opts.Force && upToDate(f.source, f.out) {
+			if options.Verbose {
+				fmt.Fprintf(os.Stderr, "Skipping %q because it is newer than %q.\n", f.out, f.source)
+			}
+			continue
+		}
+		queue = append(queue, f)
+	}
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d of %d translations are out of date.\n", len(queue), len(files))
+	}
+
+	if opts.Dry {
+		for _, f := range queue {
+			fmt.Fprintln(os.Stdout, f.out)
+		}
+		return
+	}
+
+	defaults := options.Translate
+	for i, f := range queue {
+		app.progress.setFile(i+1, len(queue))
+
+		target := defaults.clone()
+		target.SourcePath = f.source
+		target.Out = f.out
+		target.SplitLevels = slices.Clone(opts.SplitLevels)
+		target.Prose = opts.Prose
+		target.FrontMatter = slices.Clone(opts.FrontMatter)
+		target.Params.SourceLang = opts.From
+		target.Params.TargetLang = f.lang
+		target.Params.Preserve = slices.Clone(opts.Preserve)
+		target.Params.Instructions = slices.Clone(opts.Instructions)
+		target.Params.Formality = opts.Formality
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Translating %q to %q ...\n", f.source, f.out)
+		}
+
+		app.fatalIfErrorf(os.MkdirAll(filepath.Dir(f.out), 0755), "failed to create directory for %q", f.out)
+
+		app.crlf = false
+		switch code := app.translateTarget(target); code {
+		case exitOK:
+		case exitConfig, exitProvider:
+			app.kong.Exit(code)
+		default:
+			app.failed = true
+		}
+	}
+}
+
+// contentFiles returns the source files below dir with one of the given
+// extensions and their translations into each of the languages. With the "suffix" layout, the translations are written next to their
+// sources, like "about.de.md" for "about.md" or "about.en.md". Files that
+// already have a language suffix other than from are translations themselves.
+// With the "dir" layout, the sources are read from the directory of the
+// source language, like "content/en/about.md", and written to the directory of
+// each language, like "content/de/about.md". Hidden directories are skipped.
+func contentFiles(dir, layout, from string, to, exts []string) ([]contentFile, error) {
+	root := dir
+	if layout == "dir" {
+		root = filepath.Join(dir, from)
+	}
+
+	var files []contentFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !hasExt(path, exts) {
+			return nil
+		}
+
+		if layout == "dir" {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			for _, l := range to {
+				files = append(files, contentFile{source: path, out: filepath.Join(dir, l, rel), lang: l})
+			}
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		stem := strings.TrimSuffix(path, ext)
+		if suffix := filepath.Ext(stem); suffix != "" && isLocaleTag(suffix[1:]) {
+			if !strings.EqualFold(suffix[1:], from) {
+				return nil
+			}
+			stem = strings.TrimSuffix(stem, suffix)
+		}
+		for _, l := range to {
+			files = append(files, contentFile{source: path, out: stem + "." + l + ext, lang: l})
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// upToDate reports whether the translation exists and was modified after its
+// source.
+func upToDate(source, out string) bool {
+	src, err := os.Stat(source)
+	if err != nil {
+		return false
+	}
+	dst, err := os.Stat(out)
+	if err != nil {
+		return false
+	}
+	return !dst.ModTime().Before(src.ModTime())
+}
+
+// isLocaleTag reports whether s is the tag of a known language, like "de" or
+// "pt-BR", as opposed to a language name.
+func isLocaleTag(s string) bool {
+	l, err := lang.Parse(s)
+	return err == nil && strings.EqualFold(l.Tag, strings.ReplaceAll(s, "_", "-"))
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/atomicfile"
+	"github.com/modernice/dragoman/internal/crawl"
+)
+
+// crawlMapFile is the name of the file in the output directory of the crawl
+// command that maps the URLs of the translated pages to their files.
+const crawlMapFile = "urls.json"
+
+// crawl discovers the pages of a website using its sitemap or its links,
+// translates every page using the HTML pipeline and writes the translated pages
+// to a mirrored directory tree, together with a map of the URLs of the pages to
+// their files. Links between the translated pages point to their local files,
+// so that the tree can be previewed in a browser. Pages that fail to translate
+// are skipped with a warning.
+func (app *App) crawl() {
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the crawl command")
+	}
+
+	opts := &options.Crawl
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	crawler := crawl.Crawler{
+		Client: &http.Client{Timeout: options.Timeout},
+		Limit:  opts.Limit,
+	}
+
+	pages, err := crawler.Discover(ctx, opts.URL)
+	app.fatalIfErrorf(err, "failed to discover the pages of %q", opts.URL)
+
+	if len(pages) == 0 {
+		app.fatalf(exitFailure, "no pages found at %q", opts.URL)
+	}
+
+	paths := make(map[string]string, len(pages))
+	for _, page := range pages {
+		paths[page.URL.String()] = crawl.LocalPath(page.URL)
+	}
+
+	if opts.Dry {
+		for _, page := range pages {
+			fmt.Fprintf(os.Stdout, "%s\t%s\n", page.URL, paths[page.URL.String()])
+		}
+		return
+	}
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Translating %d pages of %q ...\n", len(pages), opts.URL)
+	}
+
+	model := app.model()
+	translator := app.translator(model, &opts.Params)
+	app.useParams(ctx, model, &opts.Params)
+
+	written := make(map[string]string, len(pages))
+	for _, page := range pages {
+		if ctx.Err() != nil {
+			break
+		}
+
+		body := page.Body
+		if body == nil {
+			var mediaType string
+			if body, mediaType, err = crawler.Fetch(ctx, page.URL.String()); err != nil {
+				app.warn("skipped %s: %v", page.URL, err)
+				continue
+			}
+			if mediaType != "" && mediaType != "text/html" {
+				app.warn("skipped %s: not an HTML page (%s)", page.URL, mediaType)
+				continue
+			}
+		}
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Translating %s ...\n", page.URL)
+		}
+
+		translated, err := translator.TranslateHTML(ctx, dragoman.HTMLParams{
+			TranslateParams: app.translateParams(string(body), nil),
+			Attributes:      opts.HTMLAttrs,
+			Batch:           true,
+		})
+		if err != nil {
+			app.warn("skipped %s: %v", page.URL, err)
+			continue
+		}
+
+		out, err := crawl.Rewrite([]byte(translated), page.URL, paths)
+		if err != nil {
+			app.warn("skipped %s: %v", page.URL, err)
+			continue
+		}
+
+		path := filepath.Join(opts.Out, filepath.FromSlash(paths[page.URL.String()]))
+		app.fatalIfErrorf(os.MkdirAll(filepath.Dir(path), 0755), "failed to create directory for %q", path)
+		app.fatalIfErrorf(atomicfile.WriteFile(path, out, 0644), "failed to write %q", path)
+		app.written = append(app.written, path)
+
+		written[page.URL.String()] = paths[page.URL.String()]
+	}
+
+	b, err := jsonMarshal(written)
+	app.fatalIfErrorf(err, "failed to marshal URL map")
+	app.fatalIfErrorf(os.MkdirAll(opts.Out, 0755), "failed to create directory %q", opts.Out)
+	app.fatalIfErrorf(atomicfile.WriteFile(filepath.Join(opts.Out, crawlMapFile), b, 0644), "failed to write URL map")
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Translated %d of %d pages into %q.\n", len(written), len(pages), opts.Out)
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/csv"
+)
+
+// translateCSV translates the selected columns of a CSV or TSV file cell by
+// cell. Using --update, only the cells that are empty in the existing output
+// file are translated.
+func (app *App) translateCSV(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	f, columns := app.parseCSV(source)
+
+	var target *csv.File
+	if options.Translate.Update {
+		if existing := app.readExistingOut(); existing != nil {
+			var err error
+			target, err = csv.Parse(existing, csv.Delimiter(options.Translate.Out))
+			app.fatalIfErrorf(err, "failed to parse CSV file %q", options.Translate.Out)
+		}
+	}
+
+	merged, err := csv.Merge(f, target, columns)
+	app.fatalIfErrorf(err, "failed to merge CSV files")
+
+	entries := merged.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d cells need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(merged.Bytes()))
+}
+
+// parseCSV parses the CSV source file and returns it together with the
+// columns that are selected by --columns.
+func (app *App) parseCSV(source []byte) (*csv.File, []int) {
+	f, err := csv.Parse(source, csv.Delimiter(sourceFile()))
+	app.fatalIfErrorf(err, "failed to parse CSV file %q", options.Translate.SourcePath)
+
+	columns, err := f.Columns(options.Translate.Columns)
+	if err != nil {
+		app.fatalf(exitConfig, "invalid --columns: %v", err)
+	}
+
+	return f, columns
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/deepl"
+)
+
+// usesDeepL reports whether DeepL is the translation provider.
+func (app *App) usesDeepL() bool {
+	return options.Provider == "deepl"
+}
+
+// deepl creates the DeepL client according to the command-line options and
+// the given additional options.
+func (app *App) deepl(extra ...deepl.Option) *deepl.Client {
+	if options.DeepLKey == "" {
+		app.fatalf(exitConfig, "you must provide the DeepL authentication key using --deepl-key or DEEPL_KEY")
+	}
+
+	return deepl.New(options.DeepLKey, append([]deepl.Option{
+		deepl.Timeout(options.Timeout),
+		deepl.MaxRetries(options.Retries),
+		deepl.Verbose(options.Verbose),
+	}, extra...)...)
+}
+
+// deeplFormality returns the DeepL option for the given formality.
+func deeplFormality(formality dragoman.Formality) []deepl.Option {
+	switch formality {
+	case dragoman.FormalityFormal:
+		return []deepl.Option{deepl.Formality("more")}
+	case dragoman.FormalityInformal:
+		return []deepl.Option{deepl.Formality("less")}
+	default:
+		return nil
+	}
+}
+
+// requireModel exits if the command needs a language model but DeepL or a
+// registered provider without a model was selected as the provider.
+func (app *App) requireModel(feature string) {
+	if app.usesDeepL() || app.usesRegistered() && app.registered().Model == nil {
+		app.fatalf(exitConfig, "%s requires the 'openai' or 'compat' provider", feature)
+	}
+}
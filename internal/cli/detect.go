@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/modernice/dragoman"
+)
+
+// detect prints the language of the source document, so that pipelines can
+// route documents whose language is unknown.
+func (app *App) detect() {
+	app.requireModel("detect")
+
+	source := app.readSource(options.Detect.SourcePath, false)
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	detection, err := dragoman.DetectLanguage(ctx, app.model(), string(source))
+	if errors.Is(err, dragoman.ErrUndetectable) {
+		app.fatalf(exitValidation, "the language of the document could not be detected")
+	}
+	app.fatalIfErrorf(err, "failed to detect language")
+
+	if options.Detect.JSON {
+		b, err := json.Marshal(detection)
+		app.fatalIfErrorf(err, "failed to marshal detection")
+		fmt.Fprintln(os.Stdout, string(b))
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%s %.2f\n", detection.Language, detection.Confidence)
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// ANSI escape codes of the colored diff output.
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// checkDiff exits if --diff is used for anything else than updating a JSON
+// file. --diff implies --dry.
+func (app *App) checkDiff() {
+	if !options.Translate.Diff {
+		return
+	}
+
+	if !options.Translate.Update || !isJSONFile(options.Translate.Out) {
+		app.fatalf(exitConfig, "--diff requires --update and a JSON output file")
+	}
+	options.Translate.Dry = true
+}
+
+// printDiff prints the changes that the result would make to the output file
+// as a unified diff. The diff is colored if stdout is a terminal.
+func (app *App) printDiff(result string) {
+	diff, err := dragoman.JSONRenderDiff(app.diffBase, []byte(result))
+	app.fatalIfErrorf(err, "failed to diff output file")
+
+	if diff == "" {
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "No changes to output file %q.\n", options.Translate.Out)
+		}
+		return
+	}
+
+	color := isTerminal(os.Stdout)
+
+	var out strings.Builder
+	header := fmt.Sprintf("--- %s\n+++ %s\n", options.Translate.Out, options.Translate.Out)
+	if color {
+		header = colorBold + strings.ReplaceAll(header, "\n", colorReset+"\n"+colorBold)
+		header = strings.TrimSuffix(header, colorBold)
+	}
+	out.WriteString(header)
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		if !color {
+			out.WriteString(line)
+			continue
+		}
+
+		var code string
+		switch line[0] {
+		case '+':
+			code = colorGreen
+		case '-':
+			code = colorRed
+		case '@':
+			code = colorCyan
+		}
+		if code == "" {
+			out.WriteString(line)
+			continue
+		}
+		out.WriteString(code + strings.TrimSuffix(line, "\n") + colorReset + "\n")
+	}
+
+	fmt.Fprint(os.Stdout, out.String())
+}
+
+// printChanges prints the word-level changes between the source and the
+// improved document to stdout, either inline like `git diff --word-diff`,
+// colored if stdout is a terminal, or as CriticMarkup.
+func (app *App) printChanges(source, improved string) {
+	spans := dragoman.WordDiff(source, improved)
+	critic := options.Improve.Changes == "critic"
+	color := !critic && isTerminal(os.Stdout)
+
+	var out strings.Builder
+	for i, span := range spans {
+		switch {
+		case span.Op == dragoman.DiffEqual:
+			out.WriteString(span.Text)
+		case critic && span.Op == dragoman.DiffDelete && i+1 < len(spans) && spans[i+1].Op == dragoman.DiffInsert:
+			out.WriteString("{~~" + span.Text + "~>")
+		case critic && span.Op == dragoman.DiffInsert && i > 0 && spans[i-1].Op == dragoman.DiffDelete:
+			out.WriteString(span.Text + "~~}")
+		case critic && span.Op == dragoman.DiffDelete:
+			out.WriteString("{--" + span.Text + "--}")
+		case critic:
+			out.WriteString("{++" + span.Text + "++}")
+		case color && span.Op == dragoman.DiffDelete:
+			out.WriteString(colorRed + span.Text + colorReset)
+		case color:
+			out.WriteString(colorGreen + span.Text + colorReset)
+		case span.Op == dragoman.DiffDelete:
+			out.WriteString("[-" + span.Text + "-]")
+		default:
+			out.WriteString("{+" + span.Text + "+}")
+		}
+	}
+
+	if options.Verbose && (len(spans) == 0 || len(spans) == 1 && spans[0].Op == dragoman.DiffEqual) {
+		fmt.Fprintln(os.Stderr, "The model did not change the document.")
+	}
+
+	fmt.Fprintf(os.Stdout, "%s\n", app.lineEndings(out.String()))
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/openai"
+)
+
+// planning reports whether the prompts of the current run are only collected
+// for an estimate, a batch job or --print-prompts instead of being sent to the
+// model.
+func (app *App) planning() bool {
+	return options.Translate.Estimate || options.Translate.PrintPrompts || app.submitting
+}
+
+// plan records the given translation for an estimate or a batch job.
+func (app *App) plan(translator *dragoman.Translator, params dragoman.TranslateParams) {
+	if options.Translate.Estimate {
+		app.addEstimate(translator, params)
+	}
+	if options.Translate.PrintPrompts {
+		app.printPrompts(translator, params)
+	}
+	if app.submitting {
+		prompts, err := translator.Prompts(params)
+		app.fatalIfErrorf(err, "failed to build prompts")
+		app.prompts = append(app.prompts, prompts...)
+	}
+}
+
+// printPrompts prints the conversations that would be sent to the model for
+// the chunks of the given translation.
+func (app *App) printPrompts(translator *dragoman.Translator, params dragoman.TranslateParams) {
+	conversations, err := translator.PromptMessages(params)
+	app.fatalIfErrorf(err, "failed to build prompts")
+
+	source := sourceFile()
+	if source == "" {
+		source = "stdin"
+	}
+
+	for i, msgs := range conversations {
+		fmt.Fprintf(os.Stdout, "=== %s: prompt %d of %d ===\n", source, i+1, len(conversations))
+		for _, msg := range msgs {
+			fmt.Fprintf(os.Stdout, "--- %s ---\n%s\n", msg.Role, strings.TrimRight(msg.Content, "\n"))
+		}
+		fmt.Fprintln(os.Stdout)
+	}
+}
+
+// addEstimate estimates the token usage and cost of the given translation and
+// adds it to the estimate of the current run instead of calling the model.
+func (app *App) addEstimate(translator *dragoman.Translator, params dragoman.TranslateParams) {
+	est, err := translator.Estimate(dragoman.EstimateParams{
+		TranslateParams: params,
+		Tokens: func(text string) (int, error) {
+			return openai.PromptTokens(options.OpenAIModel, text)
+		},
+		Pricing: modelPricing(),
+	})
+	app.fatalIfErrorf(err, "failed to estimate translation")
+
+	app.estimate.Chunks = append(app.estimate.Chunks, est.Chunks...)
+	app.estimate.PromptTokens += est.PromptTokens
+	app.estimate.CompletionTokens += est.CompletionTokens
+	app.estimate.Cost += est.Cost
+}
+
+// printEstimate prints the per-chunk and total estimate of the current run.
+func (app *App) printEstimate() {
+	_, _, priced := openai.ModelPricing(options.OpenAIModel)
+
+	cost := func(est dragoman.ChunkEstimate) string {
+		if !priced {
+			return "unknown"
+		}
+		return fmt.Sprintf("$%.4f", est.Cost)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "CHUNK\tPROMPT TOKENS\tCOMPLETION TOKENS\tCOST\t")
+	for i, chunk := range app.estimate.Chunks {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t\n", i+1, chunk.PromptTokens, chunk.CompletionTokens, cost(chunk))
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%s\t\n", app.estimate.PromptTokens, app.estimate.CompletionTokens, cost(app.estimate.ChunkEstimate))
+	w.Flush()
+
+	if !priced {
+		fmt.Fprintf(os.Stderr, "No pricing available for model %q.\n", options.OpenAIModel)
+	}
+}
+
+func modelPricing() dragoman.Pricing {
+	prompt, completion, _ := openai.ModelPricing(options.OpenAIModel)
+	return dragoman.Pricing{Prompt: prompt, Completion: completion}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/modernice/dragoman/eval"
+)
+
+// eval translates each source file with the current configuration and
+// compares the result against the human reference translation using the chrF
+// and BLEU metrics.
+func (app *App) eval() {
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the eval command")
+	}
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	model := app.model()
+	translator := app.translator(model, &options.Eval.Params)
+	app.useParams(ctx, model, &options.Eval.Params)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tREFERENCE\tCHRF\tBLEU")
+
+	var totalChrF, totalBLEU float64
+	for _, pair := range options.Eval.Pairs {
+		sourcePath, referencePath, ok := strings.Cut(pair, "=")
+		if !ok {
+			app.fatalf(exitConfig, "invalid pair %q: expected <source>=<reference>", pair)
+		}
+
+		source, err := os.ReadFile(sourcePath)
+		app.fatalIfErrorf(err, "failed to read source file %q", sourcePath)
+
+		reference, err := os.ReadFile(referencePath)
+		app.fatalIfErrorf(err, "failed to read reference file %q", referencePath)
+
+		params := app.translateParams(string(source), nil)
+		if isJSONFile(sourcePath) {
+			app.validateJSON(&params)
+		}
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Translating %q ...\n", sourcePath)
+		}
+
+		result, err := translator.Translate(ctx, params)
+		app.fatalIfErrorf(err, "failed to translate %q", sourcePath)
+
+		hypothesis, ref := evalText(result), evalText(string(reference))
+		chrF, bleu := eval.ChrF(hypothesis, ref), eval.BLEU(hypothesis, ref)
+		totalChrF += chrF
+		totalBLEU += bleu
+
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\n", sourcePath, referencePath, chrF, bleu)
+	}
+
+	n := float64(len(options.Eval.Pairs))
+	fmt.Fprintf(w, "AVERAGE\t\t%.2f\t%.2f\n", totalChrF/n, totalBLEU/n)
+
+	w.Flush()
+}
+
+// evalText returns the text that is compared by the metrics. For JSON
+// documents, only the string values are compared (ordered by their key path),
+// so that keys and syntax don't inflate the scores.
+func evalText(doc string) string {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(doc), &data); err != nil {
+		return doc
+	}
+
+	values := make(map[string]string)
+	collectStrings(data, "", values)
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	lines := make([]string, len(paths))
+	for i, path := range paths {
+		lines[i] = values[path]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func collectStrings(data map[string]any, prefix string, out map[string]string) {
+	for key, value := range data {
+		path := prefix + key
+		switch value := value.(type) {
+		case string:
+			out[path] = value
+		case map[string]any:
+			collectStrings(value, path+".", out)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/deepl"
+	"github.com/modernice/dragoman/internal/logging"
+	"github.com/modernice/dragoman/openai"
+	"github.com/modernice/dragoman/vertex"
+)
+
+// Exit codes of the CLI.
+const (
+	exitOK = iota
+
+	// exitFailure is returned if a translation failed or if only some of the
+	// targets of a sync could be translated.
+	exitFailure
+
+	// exitValidation is returned if a check found pending work, if a
+	// translation failed validation, or if warnings occurred in strict mode.
+	exitValidation
+
+	// exitConfig is returned for invalid flags, arguments and configuration
+	// files.
+	exitConfig
+
+	// exitProvider is returned if the model provider rejected a request or
+	// could not be reached, for example because of an invalid API key or a
+	// content filter.
+	exitProvider
+)
+
+// fatalf prints the error message and exits with the given exit code.
+func (app *App) fatalf(code int, format string, args ...any) {
+	app.progress.clear()
+	app.releaseLocks()
+	app.kong.Errorf(format, args...)
+	// The report of failed runs shows the usage of the requests that succeeded.
+	if app.usage.Requests > 0 {
+		app.printReport()
+	}
+	app.runHooks(code, fmt.Sprintf(format, args...))
+	app.kong.Exit(code)
+}
+
+// fatalIfErrorf prints the error and exits with the exit code that matches the
+// cause of the error. If args are provided, the first argument is used as a
+// format string for a message that is prefixed to the error.
+func (app *App) fatalIfErrorf(err error, args ...any) {
+	if err == nil {
+		return
+	}
+
+	msg := err.Error()
+	if len(args) > 0 {
+		msg = fmt.Sprintf(args[0].(string), args[1:]...) + ": " + msg
+	}
+
+	app.fatalf(exitCode(err), "%s", msg)
+}
+
+// warn prints a warning. Warnings do not change the exit code unless the
+// --strict flag is set, in which case the command exits with exitValidation.
+func (app *App) warn(format string, args ...any) {
+	app.progress.clear()
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+	app.warnings++
+}
+
+// logger returns the logger of translators and improvers, which writes the
+// records of their progress to stderr if --verbose is set.
+func (app *App) logger() *slog.Logger {
+	if !options.Verbose {
+		return logging.Discard()
+	}
+	return slog.New(logging.NewHandler(log.Writer(), "Dragoman"))
+}
+
+// exit exits with the exit code that results from the findings, warnings and
+// failures of the command. It returns if the command succeeded.
+func (app *App) exit() {
+	code := exitOK
+	if app.failed {
+		code = exitFailure
+	}
+	if app.pending || (options.Strict && app.warnings > 0) {
+		code = exitValidation
+	}
+
+	app.runHooks(code, "")
+
+	if code != exitOK {
+		app.kong.Exit(code)
+	}
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, dragoman.ErrInvalidTranslation):
+		return exitValidation
+	case errors.Is(err, dragoman.ErrSectionNotFound):
+		return exitConfig
+	case errors.Is(err, dragoman.ErrRefused), openai.IsAPIError(err), deepl.IsAPIError(err), vertex.IsAPIError(err):
+		return exitProvider
+	default:
+		return exitFailure
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/modernice/dragoman"
+)
+
+// keyPatterns returns the patterns of the --include-keys and --exclude-keys
+// flags in the syntax of [dragoman.JSONFilter].
+func keyPatterns() []string {
+	patterns := append([]string(nil), options.Translate.IncludeKeys...)
+	for _, key := range options.Translate.ExcludeKeys {
+		patterns = append(patterns, "!"+key)
+	}
+	return patterns
+}
+
+// checkKeyPatterns exits if --include-keys or --exclude-keys is used for a
+// document that is not JSON.
+func (app *App) checkKeyPatterns() {
+	if len(keyPatterns()) == 0 {
+		return
+	}
+
+	if options.Translate.Prose || !(isJSONFile(sourceFile()) || options.Translate.Update && !isHTMLFile(options.Translate.Out) && !isCSVFile(sourceFile())) {
+		app.fatalf(exitConfig, "--include-keys and --exclude-keys can only be used for JSON files")
+	}
+}
+
+// filterJSON removes the values that are not selected by --include-keys and
+// --exclude-keys from the JSON source. It returns the filtered source and the
+// parsed original source, which is needed to copy the excluded values to the
+// result. The filtered source is nil if no value is selected.
+func (app *App) filterJSON(source []byte) ([]byte, map[string]any) {
+	var doc map[string]any
+	err := json.Unmarshal(source, &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal source as JSON")
+
+	filtered, err := dragoman.JSONFilter(doc, keyPatterns())
+	app.fatalIfErrorf(err, "failed to filter source")
+
+	if len(filtered) == 0 {
+		return nil, doc
+	}
+
+	out, err := jsonMarshal(filtered)
+	app.fatalIfErrorf(err, "failed to marshal source")
+
+	return out, doc
+}
+
+// unfilterJSON merges the translated values into the original source, so that
+// the values that were excluded from the translation are copied verbatim.
+func (app *App) unfilterJSON(result string, source map[string]any) string {
+	var doc map[string]any
+	err := json.Unmarshal([]byte(result), &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal result as JSON")
+
+	dragoman.JSONMerge(source, doc)
+
+	out, err := jsonMarshal(source)
+	app.fatalIfErrorf(err, "failed to marshal result")
+
+	return string(out)
+}
+
+// partitionPaths splits the missing paths of an updated JSON document into
+// the paths that are selected by --include-keys and --exclude-keys and the
+// paths that are excluded.
+func partitionPaths(paths []dragoman.JSONPath) (selected, excluded []dragoman.JSONPath) {
+	patterns := keyPatterns()
+	for _, path := range paths {
+		if dragoman.MatchJSONPath(path, patterns) {
+			selected = append(selected, path)
+		} else {
+			excluded = append(excluded, path)
+		}
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// forcedKeys adds the key paths of the source document that match the
+// patterns of --force-keys to the given paths, so that their existing
+// translations are replaced. Values with an override keep their fixed
+// translation.
+func (app *App) forcedKeys(paths []dragoman.JSONPath, source map[string]any) []dragoman.JSONPath {
+	if len(options.Translate.ForceKeys) == 0 {
+		return paths
+	}
+
+	all, err := dragoman.JSONDiff(source, map[string]any{})
+	app.fatalIfErrorf(err, "failed to read the keys of the source")
+
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		seen[strings.Join(path, "\x00")] = true
+	}
+
+	for _, path := range all {
+		if !dragoman.MatchJSONPath(path, options.Translate.ForceKeys) {
+			continue
+		}
+
+		key := strings.Join(path, "\x00")
+		if _, pinned := app.jsonOverrides[strings.Join(path, ".")]; pinned || seen[key] {
+			continue
+		}
+		seen[key] = true
+		paths = append(paths, path)
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Forcing the translation of %q.\n", strings.Join(path, "."))
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/markdown"
+)
+
+// translateFrontMatter translates the fields of the front matter of a Markdown
+// document that were selected by --frontmatter-fields. All other fields are
+// left untouched.
+func (app *App) translateFrontMatter(ctx context.Context, translator *dragoman.Translator, front []byte) string {
+	fields := markdown.FrontMatterFields(front, options.Translate.FrontMatter)
+	if len(fields) == 0 {
+		return string(front)
+	}
+
+	texts := make(map[string]string, len(fields))
+	for i, f := range fields {
+		texts[strconv.Itoa(i)] = f.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate front matter")
+
+	ranges := make([]markdown.Range, len(fields))
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			translated = f.Text
+		}
+		ranges[i] = f.Range
+		values[i] = f.Value(translated)
+	}
+
+	return string(markdown.Replace(front, ranges, values))
+}
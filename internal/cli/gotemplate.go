@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/gotemplate"
+)
+
+// translateGoTemplate translates the texts of a Go template, keeping its
+// actions, or the string literals of a Go source file that are passed to one
+// of the functions of --go-funcs. Translations that do not keep the actions or
+// format verbs of their source are discarded.
+func (app *App) translateGoTemplate(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	var (
+		f   *gotemplate.File
+		err error
+	)
+	if isGoFile(sourceFile()) {
+		f, err = gotemplate.ParseSource(source, options.Translate.GoFuncs)
+		app.fatalIfErrorf(err, "failed to parse Go source file")
+	} else {
+		f, err = gotemplate.Parse(source)
+		app.fatalIfErrorf(err, "failed to parse template")
+	}
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(f.Entries))
+	}
+
+	texts := make(map[string]string, len(f.Entries))
+	for i, entry := range f.Entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range f.Entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
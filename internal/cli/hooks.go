@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// hookPayload describes a finished run to the --on-success and --on-failure
+// hooks.
+type hookPayload struct {
+	// Text summarizes the run, so that the payload can be posted to chat
+	// webhooks, like the incoming webhooks of Slack, as it is.
+	Text     string    `json:"text"`
+	Command  string    `json:"command"`
+	Status   string    `json:"status"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+	Files    []string  `json:"files"`
+	From     string    `json:"from,omitempty"`
+	To       []string  `json:"to,omitempty"`
+	Usage    hookUsage `json:"usage"`
+	Warnings int       `json:"warnings"`
+	Started  time.Time `json:"started"`
+	Duration float64   `json:"durationSeconds"`
+}
+
+type hookUsage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// runHooks runs the --on-success hooks if the command exits with exitOK, and
+// the --on-failure hooks otherwise. message is the error of a failed command.
+// Failed hooks are reported as warnings but do not change the exit code.
+func (app *App) runHooks(code int, message string) {
+	if app.hooked {
+		return
+	}
+	app.hooked = true
+
+	status, hooks := "success", options.OnSuccess
+	if code != exitOK {
+		status, hooks = "failure", options.OnFailure
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload := hookPayload{
+		Command:  commandName(app.kong.Command()),
+		Status:   status,
+		ExitCode: code,
+		Error:    message,
+		Files:    app.written,
+		To:       app.languages,
+		Usage: hookUsage{
+			Requests:         app.usage.Requests,
+			PromptTokens:     app.usage.PromptTokens,
+			CompletionTokens: app.usage.CompletionTokens,
+			TotalTokens:      app.usage.TotalTokens(),
+		},
+		Warnings: app.warnings,
+		Started:  app.started,
+		Duration: time.Since(app.started).Seconds(),
+	}
+	if payload.Files == nil {
+		payload.Files = []string{}
+	}
+	if app.params != nil {
+		payload.From = app.params.SourceLang
+	}
+	payload.Text = hookText(payload)
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		app.warn("failed to marshal hook payload: %v", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Running %s hook %q ...\n", status, hook)
+		}
+		if err := runHook(hook, status, b); err != nil {
+			app.warn("%s hook %q failed: %v", status, hook, err)
+		}
+	}
+}
+
+// runHook posts the payload to the hook if it is an http or https URL, or runs
+// the hook as a shell command that reads the payload from stdin otherwise.
+// Hooks are canceled after the --timeout.
+func runHook(hook, status string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+
+	if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with %s", resp.Status)
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	// The output of hooks must not mix with results that are written to
+	// stdout.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "DRAGOMAN_HOOK_STATUS="+status)
+
+	return cmd.Run()
+}
+
+// hookText returns the summary of the run of a hook payload.
+func hookText(p hookPayload) string {
+	verb := "succeeded"
+	if p.Status != "success" {
+		verb = "failed"
+	}
+
+	text := fmt.Sprintf("dragoman %s %s after %s", p.Command, verb, time.Duration(p.Duration*float64(time.Second)).Round(time.Second))
+	switch len(p.Files) {
+	case 0:
+	case 1:
+		text += ", wrote " + p.Files[0]
+	default:
+		text += fmt.Sprintf(", wrote %d files", len(p.Files))
+	}
+	if len(p.To) > 0 {
+		text += " (" + strings.Join(p.To, ", ") + ")"
+	}
+	if p.Usage.Requests > 0 {
+		text += fmt.Sprintf(", used %d tokens", p.Usage.TotalTokens)
+	}
+	if p.Error != "" {
+		text += ": " + p.Error
+	}
+	return text
+}
+
+// commandName returns the name of a command without its arguments, e.g.
+// "batch collect" for "batch collect <id>".
+func commandName(command string) string {
+	var words []string
+	for _, word := range strings.Fields(command) {
+		if !strings.HasPrefix(word, "<") {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/modernice/dragoman"
+)
+
+// translateHTML translates the text nodes and the attributes of an HTML file
+// that are selected by --html-attributes as a single batch of key-value pairs
+// and reinserts them into the document, so that the model never sees the
+// markup of the page.
+func (app *App) translateHTML(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	doc, err := dragoman.ParseHTML(source, options.Translate.HTMLAttrs...)
+	app.fatalIfErrorf(err, "failed to parse HTML file")
+
+	texts := doc.Texts()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d texts need to be translated.\n", len(texts))
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	result, err := doc.Render(translations)
+	app.fatalIfErrorf(err, "failed to render translated HTML")
+
+	app.outputTranslation(string(result))
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"github.com/modernice/dragoman"
+)
+
+// i18nextExpand returns the source document with the plural forms of the
+// target language if --i18next is set, so that the forms that the target
+// language needs but the source lacks are diffed and never pruned.
+func (app *App) i18nextExpand(source map[string]any) map[string]any {
+	if !options.Translate.I18next {
+		return source
+	}
+	return dragoman.I18nextExpand(source, app.languageName("--to", options.Translate.Params.TargetLang))
+}
+
+// i18nextFamilies adds the other plural forms of the keys of the paths if
+// --i18next is set, so that the forms of a key are always translated together.
+func (app *App) i18nextFamilies(paths []dragoman.JSONPath, source map[string]any) []dragoman.JSONPath {
+	if !options.Translate.I18next {
+		return paths
+	}
+	return dragoman.I18nextFamilies(source, paths)
+}
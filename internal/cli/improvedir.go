@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/manifest"
+	"github.com/modernice/dragoman/openai"
+)
+
+// improveDir improves the documents of a directory that are due for an
+// improvement. Changed and never improved documents come first, followed by
+// the documents that were improved the longest time ago. Each run improves at
+// most --limit documents within the estimated token and cost budget, and the
+// manifest is updated after every document, so that an interrupted run does not
+// lose progress.
+func (app *App) improveDir() {
+	app.requireModel("improve-dir")
+
+	opts := &options.ImproveDir
+	app.checkHeadingLevels(opts.Params.SplitHeadings)
+
+	if options.MaxCost > 0 {
+		if _, _, priced := openai.ModelPricing(options.OpenAIModel); !priced {
+			app.fatalf(exitConfig, "no pricing available for model %q; use --max-tokens instead of --max-cost", options.OpenAIModel)
+		}
+	}
+
+	manifestPath := opts.Manifest
+	if manifestPath == "" {
+		manifestPath = filepath.Join(opts.Dir, manifest.DefaultFile)
+	}
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	// The manifest is locked for the whole run, so that concurrent runs do not
+	// improve the same documents.
+	if !opts.Dry {
+		app.lockOutput(ctx, manifestPath)
+		defer app.releaseLocks()
+	}
+
+	m, err := manifest.Load(manifestPath)
+	app.fatalIfErrorf(err, "failed to load manifest")
+
+	hashes, err := documentHashes(opts.Dir, opts.Ext)
+	app.fatalIfErrorf(err, "failed to read documents in %q", opts.Dir)
+
+	paths := make([]string, 0, len(hashes))
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	m.Prune(paths)
+
+	queue := m.Queue(hashes, time.Now(), opts.MinAge)
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d of %d documents are due for an improvement.\n", len(queue), len(hashes))
+	}
+
+	model := app.model()
+	improver := dragoman.NewImprover(model, dragoman.ImproverLogger(app.logger()))
+	refs := app.readContext(ctx, model, opts.Params.Context)
+
+	var (
+		improved int
+		tokens   int
+		cost     float64
+	)
+	for _, rel := range queue {
+		if opts.Limit > 0 && improved >= opts.Limit {
+			break
+		}
+
+		path := filepath.Join(opts.Dir, filepath.FromSlash(rel))
+
+		app.crlf = false
+		source := app.readSource(path, false)
+		params := improveParams(string(source), &opts.Params, refs)
+
+		if opts.MaxTokens > 0 || options.MaxCost > 0 {
+			est, err := improver.Estimate(dragoman.ImproveEstimateParams{
+				ImproveParams: params,
+				Tokens: func(text string) (int, error) {
+					return openai.PromptTokens(options.OpenAIModel, text)
+				},
+				Pricing: modelPricing(),
+			})
+			app.fatalIfErrorf(err, "failed to estimate improvement of %q", path)
+
+			total := est.PromptTokens + est.CompletionTokens
+			if (opts.MaxTokens > 0 && tokens+total > opts.MaxTokens) || (options.MaxCost > 0 && cost+est.Cost > options.MaxCost) {
+				if options.Verbose {
+					fmt.Fprintf(os.Stderr, "Skipping %q because it exceeds the remaining budget.\n", path)
+				}
+				continue
+			}
+
+			tokens += total
+			cost += est.Cost
+		}
+
+		improved++
+
+		if opts.Dry {
+			fmt.Fprintln(os.Stdout, path)
+			continue
+		}
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Improving %q ...\n", path)
+		}
+
+		result, err := improver.Improve(ctx, params)
+		if errors.Is(err, dragoman.ErrOutlineChanged) {
+			app.warn("skipping %q: %v", path, err)
+			continue
+		}
+		app.fatalIfErrorf(err, "failed to improve %q", path)
+
+		result = app.lineEndings(result)
+		app.crlf = false
+		app.writeResult(path, result)
+
+		m.Improved(rel, []byte(result), time.Now())
+		err = m.Save(manifestPath)
+		app.fatalIfErrorf(err, "failed to save manifest")
+	}
+
+	if opts.Dry {
+		return
+	}
+
+	err = m.Save(manifestPath)
+	app.fatalIfErrorf(err, "failed to save manifest")
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Improved %d documents.\n", improved)
+	}
+}
+
+// documentHashes returns the hashes of the documents with one of the given
+// extensions below dir, keyed by their slash-separated paths relative to dir.
+// Hidden directories are skipped.
+func documentHashes(dir string, exts []string) (map[string]string, error) {
+	hashes := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !hasExt(path, exts) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(rel)] = manifest.Hash(content)
+
+		return nil
+	})
+
+	return hashes, err
+}
+
+func hasExt(path string, exts []string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range exts {
+		if strings.EqualFold(ext, "."+strings.TrimPrefix(e, ".")) {
+			return true
+		}
+	}
+	return false
+}
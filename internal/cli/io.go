@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modernice/dragoman/internal/atomicfile"
+	"github.com/modernice/dragoman/internal/clipboard"
+)
+
+// readSource reads the source document from the clipboard, from the given
+// path, or from stdin if the path is empty. Windows line endings are
+// normalized to "\n" so that chunking and prompt construction work the same on
+// every platform. The original line endings are restored when the result is
+// written.
+func (app *App) readSource(path string, fromClipboard bool) []byte {
+	var (
+		source []byte
+		err    error
+	)
+	if fromClipboard {
+		var text string
+		text, err = clipboard.Read()
+		app.fatalIfErrorf(err, "failed to read source from clipboard")
+		source = []byte(strings.TrimSpace(text))
+	} else if path == "" {
+		source, err = readAll(os.Stdin)
+		if errors.Is(err, errEmptyStdin) {
+			app.fatalf(exitConfig, "you must either provide the <source> file or provide the source text via stdin")
+		} else {
+			app.fatalIfErrorf(err, "failed to read source from stdin")
+		}
+	} else {
+		source, err = os.ReadFile(path)
+		app.fatalIfErrorf(err, "failed to read source file %q", path)
+	}
+
+	if strings.Contains(string(source), "\r\n") {
+		app.crlf = true
+		source = []byte(strings.ReplaceAll(string(source), "\r\n", "\n"))
+	}
+
+	return source
+}
+
+// sourceFile returns the path whose extension selects how the source of the
+// translate command is translated. If --source-format is set, it takes
+// precedence over the extension of the source file, so that documents from
+// stdin or the clipboard are translated like files of that format.
+func sourceFile() string {
+	if format := options.Translate.Format; format != "" {
+		return "source." + format
+	}
+	return options.Translate.SourcePath
+}
+
+// printResult writes the result to stdout.
+func (app *App) printResult(result string) {
+	fmt.Fprintf(os.Stdout, "%s\n", app.lineEndings(result))
+}
+
+// copyResult copies the result to the clipboard.
+func (app *App) copyResult(result string) {
+	err := clipboard.Write(app.lineEndings(result))
+	app.fatalIfErrorf(err, "failed to copy result to clipboard")
+
+	if options.Verbose {
+		fmt.Fprintln(os.Stderr, "Copied result to clipboard.")
+	}
+}
+
+// writeResult writes the result to the output file at the given path. The file
+// is replaced atomically, so that it is never left half-written.
+func (app *App) writeResult(path, result string) {
+	err := atomicfile.WriteFile(path, []byte(app.lineEndings(result)), 0644)
+	app.fatalIfErrorf(err, "failed to write output file %q", path)
+	app.written = append(app.written, path)
+}
+
+// lockOutput locks the output file at the given path until the command
+// finishes, so that concurrent invocations that write the same file, like
+// parallel CI jobs, wait for each other instead of overwriting each other's
+// output.
+func (app *App) lockOutput(ctx context.Context, path string) {
+	unlock, err := atomicfile.Lock(ctx, path, func() {
+		app.progress.clear()
+		fmt.Fprintf(os.Stderr, "Waiting for another dragoman process to finish writing %q ...\n", path)
+	})
+	app.fatalIfErrorf(err, "failed to lock output file %q", path)
+	app.unlocks = append(app.unlocks, unlock)
+}
+
+// releaseLocks releases the locks of the output files.
+func (app *App) releaseLocks() {
+	for _, unlock := range app.unlocks {
+		unlock()
+	}
+	app.unlocks = nil
+}
+
+// lineEndings restores the line endings of the source document.
+func (app *App) lineEndings(text string) string {
+	if !app.crlf {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+}
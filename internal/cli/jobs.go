@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/config"
+)
+
+// jobSummary is written by the process of a single target of a parallel sync
+// to the file of the hidden --sync-summary flag.
+type jobSummary struct {
+	Usage    dragoman.Usage `json:"usage"`
+	Written  int            `json:"written"`
+	Warnings int            `json:"warnings"`
+}
+
+// jobResult is the outcome of the process of a single target.
+type jobResult struct {
+	index    int
+	target   config.Target
+	code     int
+	summary  jobSummary
+	duration time.Duration
+	stdout   []byte
+	stderr   []byte
+	err      error
+}
+
+func (r jobResult) status() string {
+	switch {
+	case r.err != nil || r.code != exitOK && r.code != exitValidation:
+		return "failed"
+	case r.summary.Written == 0 && r.summary.Usage.Requests == 0:
+		return "skipped"
+	default:
+		return "done"
+	}
+}
+
+// parallel reports whether the targets of the configuration are translated
+// by a pool of worker processes. Pending work is checked sequentially, and
+// the budget of a run cannot be shared between processes.
+func (app *App) parallel(cfg *config.Config) bool {
+	if options.Sync.Jobs <= 1 || options.Sync.Target >= 0 || len(cfg.Targets) < 2 || options.CheckOnly {
+		return false
+	}
+	if options.MaxCost > 0 || options.MaxTokensTotal > 0 {
+		app.fatalf(exitConfig, "--max-cost and --max-tokens-total cannot be used with --jobs")
+	}
+	return true
+}
+
+// syncParallel translates the targets of the configuration using a pool of
+// --jobs worker processes. Every target is translated by running the
+// executable again with the same arguments and the hidden --sync-target flag.
+// A status line is printed when a target is done, the output of its process
+// is printed in one piece, and a summary table is printed at the end.
+func (app *App) syncParallel(cfg *config.Config) {
+	exe, err := os.Executable()
+	if err != nil {
+		app.fatalf(exitFailure, "failed to find the executable: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "dragoman-sync-*")
+	if err != nil {
+		app.fatalf(exitFailure, "failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The worker processes receive interrupts as well and stop gracefully,
+	// so the parent waits for them instead of exiting.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+		}
+	}()
+
+	jobs := make(chan int)
+	results := make(chan jobResult)
+	for w := 0; w < options.Sync.Jobs; w++ {
+		go func() {
+			for i := range jobs {
+				results <- runJob(exe, dir, i, cfg.Targets[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range cfg.Targets {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	all := make([]jobResult, len(cfg.Targets))
+	worst := exitOK
+	for n := 1; n <= len(cfg.Targets); n++ {
+		r := <-results
+		all[r.index] = r
+
+		os.Stdout.Write(r.stdout)
+		os.Stderr.Write(r.stderr)
+		if r.err != nil {
+			fmt.Fprintf(os.Stderr, "failed to run %s: %v\n", targetName(r.target), r.err)
+		}
+
+		line := fmt.Sprintf("[%d/%d] %s %s (%s", n, len(cfg.Targets), r.status(), targetName(r.target), r.duration.Round(time.Millisecond))
+		if tokens := r.summary.Usage.TotalTokens(); tokens > 0 {
+			line += fmt.Sprintf(", %d tokens", tokens)
+		}
+		fmt.Fprintln(os.Stderr, line+")")
+
+		app.usage = app.usage.Add(r.summary.Usage)
+		app.warnings += r.summary.Warnings
+		switch {
+		case r.status() != "failed":
+		case r.code == exitConfig || r.code == exitProvider:
+			worst = r.code
+		default:
+			app.failed = true
+		}
+	}
+
+	printJobSummary(all, time.Since(app.started))
+
+	if worst != exitOK {
+		app.printReport()
+		app.runHooks(worst, "")
+		app.kong.Exit(worst)
+	}
+}
+
+// runJob translates a single target in a new process of the executable.
+func runJob(exe, dir string, index int, target config.Target) jobResult {
+	summary := filepath.Join(dir, strconv.Itoa(index)+".json")
+
+	args := append(append([]string{}, os.Args[1:]...), "--sync-target", strconv.Itoa(index), "--sync-summary", summary)
+	cmd := exec.Command(exe, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := jobResult{index: index, target: target}
+
+	start := time.Now()
+	err := cmd.Run()
+	result.duration = time.Since(start)
+	result.stdout, result.stderr = stdout.Bytes(), stderr.Bytes()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.code = exitErr.ExitCode()
+	case err != nil:
+		result.err = err
+		return result
+	}
+
+	if b, err := os.ReadFile(summary); err == nil {
+		if err := json.Unmarshal(b, &result.summary); err != nil {
+			result.err = fmt.Errorf("read summary: %w", err)
+		}
+	}
+
+	return result
+}
+
+// writeJobSummary writes the summary of the target that was translated by a
+// worker process of a parallel sync.
+func (app *App) writeJobSummary() {
+	path := options.Sync.Summary
+	if path == "" {
+		return
+	}
+
+	b, err := json.Marshal(jobSummary{
+		Usage:    app.usage,
+		Written:  len(app.written),
+		Warnings: app.warnings,
+	})
+	if err == nil {
+		err = os.WriteFile(path, b, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write summary: %v\n", err)
+	}
+}
+
+func printJobSummary(results []jobResult, elapsed time.Duration) {
+	var done, skipped, failed, tokens int
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nFILE\tSTATUS\tTOKENS\tDURATION")
+	for _, r := range results {
+		switch r.status() {
+		case "done":
+			done++
+		case "skipped":
+			skipped++
+		default:
+			failed++
+		}
+		tokens += r.summary.Usage.TotalTokens()
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", targetName(r.target), r.status(), r.summary.Usage.TotalTokens(), r.duration.Round(time.Millisecond))
+	}
+	w.Flush()
+
+	fmt.Fprintf(os.Stderr, "\n%d files: %d done, %d skipped, %d failed, %d tokens in %s\n", len(results), done, skipped, failed, tokens, elapsed.Round(time.Millisecond))
+}
+
+func targetName(target config.Target) string {
+	if target.Out != "" {
+		return target.Out
+	}
+	return target.Source
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/jsonc"
+)
+
+// translateJSONC translates the string values of a JSON document with comments
+// or of a JSON5 document. Comments, keys and formatting of the source are
+// kept. If the output file already exists, its values are reused for the key
+// paths of the source, and only the missing values are translated.
+// Translations that do not keep the placeholders of their source are
+// discarded.
+func (app *App) translateJSONC(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	src, err := jsonc.Parse(source)
+	app.fatalIfErrorf(err, "failed to parse JSONC document")
+
+	var target *jsonc.File
+	if existing := app.readExistingOut(); existing != nil {
+		target, err = jsonc.Parse(existing)
+		app.fatalIfErrorf(err, "failed to parse JSONC document %q", options.Translate.Out)
+	}
+
+	f := jsonc.Merge(src, target)
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+// isJSONCDocument reports whether the source of a JSON file is not valid JSON
+// but a valid JSON document with comments or trailing commas.
+func isJSONCDocument(source []byte) bool {
+	if json.Valid(source) {
+		return false
+	}
+	_, err := jsonc.Parse(source)
+	return err == nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/modernice/dragoman"
+)
+
+// nestKeys returns the JSON document with its dot-separated keys replaced with
+// nested objects if --key-style is set, so that flat and nested documents can
+// be diffed and merged. The name identifies the document in errors.
+func (app *App) nestKeys(doc []byte, name string) []byte {
+	if options.Translate.KeyStyle == "" {
+		return doc
+	}
+
+	var m map[string]any
+	app.fatalIfErrorf(json.Unmarshal(doc, &m), "failed to unmarshal %s as JSON", name)
+
+	nested, err := dragoman.JSONUnflatten(m)
+	app.fatalIfErrorf(err, "failed to nest the keys of %s", name)
+
+	out, err := jsonMarshal(nested)
+	app.fatalIfErrorf(err, "failed to marshal %s", name)
+
+	return out
+}
+
+// styleKeys returns the translated JSON document with the keys of --key-style:
+// dot-separated keys for 'flat' and nested objects for 'nested'.
+func (app *App) styleKeys(result string) string {
+	if options.Translate.KeyStyle == "" {
+		return result
+	}
+
+	var m map[string]any
+	app.fatalIfErrorf(json.Unmarshal([]byte(result), &m), "failed to unmarshal result as JSON")
+
+	nested, err := dragoman.JSONUnflatten(m)
+	app.fatalIfErrorf(err, "failed to nest the keys of the result")
+
+	styled := nested
+	if options.Translate.KeyStyle == "flat" {
+		styled = dragoman.JSONFlatten(nested)
+	}
+
+	out, err := jsonMarshal(styled)
+	app.fatalIfErrorf(err, "failed to marshal result")
+
+	return string(out)
+}
+
+// layoutJSON orders the keys of a translated JSON document like the existing
+// output file and the source, and indents it like them, so that updates do not
+// reorder the output file. Results that are not JSON are returned unchanged.
+func (app *App) layoutJSON(result string) string {
+	if len(app.jsonLayouts) == 0 {
+		return result
+	}
+
+	out, err := dragoman.JSONOrder([]byte(result), app.jsonLayouts...)
+	if err != nil {
+		return result
+	}
+
+	return string(out)
+}
@@ -0,0 +1,24 @@
+package cli
+
+import "github.com/modernice/dragoman/lang"
+
+// languageName validates the language of the given flag and returns its
+// English name, which is what the prompts of the models expect, e.g. "German"
+// for "de" or "Brazilian Portuguese" for "pt-BR". DeepL gets the language as
+// given, because it expects its own codes. An empty language stays empty.
+// Unknown languages exit with exitConfig and the closest known languages.
+func (app *App) languageName(flag, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	l, err := lang.Parse(value)
+	if err != nil {
+		app.fatalf(exitConfig, "invalid %s: %v", flag, err)
+	}
+
+	if app.usesDeepL() {
+		return value
+	}
+	return l.Name
+}
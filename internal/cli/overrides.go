@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readOverrides reads the override file of the translate command. The keys of
+// the file are JSON key paths if a JSON document is translated, and chunk
+// numbers otherwise.
+func (app *App) readOverrides() {
+	app.jsonOverrides, app.chunkOverrides = nil, nil
+
+	path := options.Translate.Overrides
+	if path == "" {
+		return
+	}
+
+	if isJSONCFile(sourceFile()) || isPOFile(sourceFile()) || isXLIFFFile(sourceFile()) || isAndroidXMLFile(sourceFile()) || selectsXML() || isAppleStringsFile(sourceFile()) || isStringCatalogFile(sourceFile()) || isPropertiesFile(sourceFile()) || isResxFile(sourceFile()) || isGoTemplateFile(sourceFile()) || isGoFile(sourceFile()) || isSubtitleFile(sourceFile()) || isCSVFile(sourceFile()) || isHTMLFile(sourceFile()) && options.Translate.Bilingual == "" || isHTMLFile(options.Translate.Out) && options.Translate.Update || options.Translate.Prose {
+		app.fatalf(exitConfig, "--overrides cannot be used for JSONC, PO, XLIFF, Android, Apple, Java or .NET resource, Go, subtitle, CSV or HTML files or with --prose")
+	}
+
+	data, err := os.ReadFile(path)
+	app.fatalIfErrorf(err, "failed to read override file %q", path)
+
+	var overrides map[string]string
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		app.fatalf(exitConfig, "invalid override file %q: %v", path, err)
+	}
+
+	if options.Translate.Update || isJSONFile(sourceFile()) {
+		app.jsonOverrides = overrides
+		return
+	}
+
+	app.chunkOverrides = make(map[int]string, len(overrides))
+	for key, translation := range overrides {
+		n, err := strconv.Atoi(key)
+		if err != nil || n < 1 {
+			app.fatalf(exitConfig, "invalid override file %q: %q is not a chunk number", path, key)
+		}
+		app.chunkOverrides[n] = translation
+	}
+}
+
+// pinOverrides sets the overridden values of the existing output document and
+// reports whether any value was changed.
+func (app *App) pinOverrides(source, out map[string]any) bool {
+	if len(app.jsonOverrides) == 0 {
+		return false
+	}
+
+	before, err := json.Marshal(out)
+	app.fatalIfErrorf(err, "failed to marshal target file")
+
+	app.jsonOverrides.Apply(source, out)
+
+	after, err := json.Marshal(out)
+	app.fatalIfErrorf(err, "failed to marshal target file")
+
+	return !bytes.Equal(before, after)
+}
+
+// stripOverrides removes the overridden values from the JSON source, so that
+// they are not sent to the model. It returns the stripped source and the
+// parsed original source, which is needed to apply the overrides to the
+// result.
+func (app *App) stripOverrides(source []byte) ([]byte, map[string]any) {
+	var doc map[string]any
+	err := json.Unmarshal(source, &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal source as JSON")
+
+	out, err := jsonMarshal(app.jsonOverrides.Strip(doc))
+	app.fatalIfErrorf(err, "failed to marshal source")
+
+	return out, doc
+}
+
+// applyOverrides sets the overridden values of the translated JSON document to
+// their fixed translations.
+func (app *App) applyOverrides(result string, source map[string]any) string {
+	var doc map[string]any
+	err := json.Unmarshal([]byte(result), &doc)
+	app.fatalIfErrorf(err, "failed to unmarshal result as JSON")
+
+	app.jsonOverrides.Apply(source, doc)
+
+	out, err := jsonMarshal(doc)
+	app.fatalIfErrorf(err, "failed to marshal result")
+
+	return string(out)
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/icu"
+	"github.com/modernice/dragoman/format/po"
+)
+
+// translatePlurals translates the messages of a gettext catalog that have
+// plural forms, keyed by their ids. The singular and the plural of a message
+// are translated together as the plural forms of an i18next key, so that the
+// model writes every plural form of the target language. The translations are
+// returned by id and CLDR plural category.
+func (app *App) translatePlurals(ctx context.Context, translator *dragoman.Translator, entries map[string]*po.Entry) (map[string]map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	doc := make(map[string]map[string]string, len(entries))
+	for id, entry := range entries {
+		doc[id] = map[string]string{"msg_one": entry.ID, "msg_other": entry.IDPlural}
+	}
+	source, err := jsonMarshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal plural messages: %w", err)
+	}
+
+	params := app.translateParams(string(source), nil)
+	app.validateJSON(&params)
+	params.I18next = true
+	params.ValidationRetries = options.Retries
+
+	if app.planning() {
+		app.plan(translator, params)
+		return nil, nil
+	}
+
+	skipped := len(app.skipped)
+
+	result, err := translator.Translate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var translated map[string]map[string]string
+	if err := json.Unmarshal([]byte(result), &translated); err != nil {
+		return nil, fmt.Errorf("unmarshal result as JSON: %w", err)
+	}
+
+	for _, path := range app.skippedPaths(skipped) {
+		delete(translated, path[0])
+	}
+
+	out := make(map[string]map[string]string, len(translated))
+	for id, forms := range translated {
+		out[id] = make(map[string]string, len(forms))
+		for key, text := range forms {
+			if category, ok := strings.CutPrefix(key, "msg_"); ok {
+				out[id][category] = text
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// pluralCategories returns the CLDR plural categories of the msgstr[n]
+// translations of a catalog with nplurals plural forms in the target language,
+// or nil if they are unknown.
+func (app *App) pluralCategories(nplurals int) []string {
+	target := app.languageName("--to", app.params.TargetLang)
+	categories, ok := icu.GettextCategories(target, nplurals)
+	if !ok && nplurals > 2 {
+		app.warn("the %d plural forms of the catalog are unknown for %s; only the first two are translated", nplurals, target)
+	}
+	return categories
+}
+
+// pluralForms returns the msgstr[n] translations of a message from the
+// translations of its plural categories. If the categories of the forms are
+// unknown, only the singular and the plural are set and the other forms are
+// left empty, so that they stay untranslated.
+func pluralForms(forms map[string]string, categories []string, nplurals int) []string {
+	if categories == nil {
+		categories = []string{"one", "other"}
+		if nplurals == 1 {
+			categories = []string{"other"}
+		}
+	}
+
+	strs := make([]string, nplurals)
+	for i, category := range categories {
+		if i < nplurals {
+			strs[i] = forms[category]
+		}
+	}
+	return strs
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranslate_poPlurals(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		prompt := req.Messages[len(req.Messages)-1].Content
+		doc := strings.Split(strings.Split(prompt, "---<DOC_BEGIN>---\n")[1], "\n---<DOC_END>---")[0]
+
+		// Messages are translated to "RU <msgid>", plural forms to "файл:<category>".
+		var m map[string]any
+		if err := json.Unmarshal([]byte(doc), &m); err != nil {
+			t.Errorf("chunk is not a JSON object: %v\n%s", err, doc)
+		}
+		for key, v := range m {
+			switch v := v.(type) {
+			case string:
+				m[key] = "RU " + v
+			case map[string]any:
+				forms, _ := v["msg#plural"].(map[string]any)
+				for category := range forms {
+					forms[category] = "файл:" + category
+				}
+			}
+		}
+		response, _ := json.Marshal(m)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"index": 0, "delta": map[string]any{"content": string(response)}, "finish_reason": "stop"}},
+		})
+		fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", chunk)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "messages.pot")
+	out := filepath.Join(dir, "ru.po")
+	catalog := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "Title"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+msgstr[2] ""
+`
+	if err := os.WriteFile(source, []byte(catalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{
+		"dragoman", "translate", source,
+		"--out", out,
+		"--provider", "compat",
+		"--base-url", srv.URL + "/v1",
+		"--model", "m",
+		"--to", "Russian",
+		"--config", filepath.Join(dir, "dragoman.yaml"),
+	}
+
+	New("test").Run()
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output file: %v", err)
+	}
+
+	for _, want := range []string{
+		`msgstr "RU Title"`,
+		`msgstr[0] "файл:one"`,
+		`msgstr[1] "файл:few"`,
+		`msgstr[2] "файл:many"`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("output should contain %q; got\n\n%s", want, b)
+		}
+	}
+}
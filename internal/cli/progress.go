@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/openai"
+)
+
+// progressWidth is the number of characters of the progress bar.
+const progressWidth = 20
+
+// progress renders a single-line progress bar on stderr that shows the chunk
+// that is being translated, the tokens used so far and the estimated time
+// until the current document is translated. In multi-file runs, the line is
+// prefixed with the number of the current file.
+type progress struct {
+	w       io.Writer
+	file    int
+	files   int
+	tokens  int
+	started time.Time
+	active  bool
+}
+
+// newProgress returns the progress bar of the run, or nil if progress should
+// not be shown. In 'auto' mode, progress is only shown if stderr is a terminal
+// and neither --stream nor --verbose write to the terminal at the same time.
+func newProgress() *progress {
+	switch options.Progress {
+	case "never":
+		return nil
+	case "auto":
+		if options.Stream || options.Verbose || !isTerminal(os.Stderr) {
+			return nil
+		}
+	}
+	return &progress{w: os.Stderr}
+}
+
+// setFile sets the number of the file that is translated next in a
+// multi-file run.
+func (p *progress) setFile(file, files int) {
+	if p == nil {
+		return
+	}
+	p.file, p.files = file, files
+}
+
+// chunkStart is called before a chunk is translated.
+func (p *progress) chunkStart(c dragoman.ChunkProgress) {
+	if p == nil {
+		return
+	}
+
+	if c.Chunk == 1 || p.started.IsZero() {
+		p.started = time.Now()
+	}
+
+	p.tokens += countTokens(c.Prompt)
+	p.render(c.Chunk-1, c.Chunks)
+}
+
+// chunkDone is called after a chunk was translated.
+func (p *progress) chunkDone(c dragoman.ChunkProgress) {
+	if p == nil {
+		return
+	}
+
+	if c.Prompt != "" {
+		p.tokens += countTokens(c.Translation)
+	}
+	p.render(c.Chunk, c.Chunks)
+
+	if c.Chunk == c.Chunks {
+		fmt.Fprintln(p.w)
+		p.active = false
+		p.started = time.Time{}
+	}
+}
+
+// clear removes the progress bar from the terminal, so that a message can be
+// printed. The bar is rendered again when the next chunk starts or finishes.
+func (p *progress) clear() {
+	if p == nil || !p.active {
+		return
+	}
+	fmt.Fprint(p.w, "\r\033[K")
+}
+
+func (p *progress) render(done, total int) {
+	var b strings.Builder
+	b.WriteString("\r\033[K")
+
+	if p.files > 1 {
+		fmt.Fprintf(&b, "[file %d/%d] ", p.file, p.files)
+	}
+
+	current := done + 1
+	if current > total {
+		current = total
+	}
+
+	filled := progressWidth * done / total
+	fmt.Fprintf(&b, "chunk %d/%d [%s%s] %d tokens",
+		current, total,
+		strings.Repeat("#", filled), strings.Repeat("-", progressWidth-filled),
+		p.tokens,
+	)
+
+	if done > 0 && done < total {
+		elapsed := time.Since(p.started)
+		eta := elapsed / time.Duration(done) * time.Duration(total-done)
+		fmt.Fprintf(&b, " ETA %s", eta.Round(time.Second))
+	}
+
+	fmt.Fprint(p.w, b.String())
+	p.active = true
+}
+
+// countTokens returns the number of tokens of a text for the current model.
+// Errors are ignored because the token count is only informational.
+func countTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	n, _ := openai.PromptTokens(options.OpenAIModel, text)
+	return n
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
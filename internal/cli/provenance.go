@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/provenance"
+)
+
+// loadProvenance loads the sidecar file of the output file if --provenance is
+// set.
+func (app *App) loadProvenance() {
+	app.sidecar, app.provenanceKeys = nil, nil
+	if !options.Translate.Provenance {
+		return
+	}
+
+	sidecar, err := provenance.Load(provenance.Path(options.Translate.Out))
+	app.fatalIfErrorf(err, "failed to load the provenance of %q", options.Translate.Out)
+	app.sidecar = sidecar
+}
+
+// changedProvenance adds the key paths of the source document whose values
+// changed since they were translated, according to the sidecar file of
+// --provenance, to the given paths, so that their existing translations are
+// replaced. Translations that were edited since they were translated are kept
+// and reported instead. Values with an override keep their fixed translation.
+func (app *App) changedProvenance(paths []dragoman.JSONPath, source, target map[string]any) []dragoman.JSONPath {
+	if app.sidecar == nil {
+		return paths
+	}
+
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		seen[strings.Join(path, "\x00")] = true
+	}
+
+	translations := jsonLeaves(target)
+	for _, leaf := range sortedLeaves(jsonLeaves(source)) {
+		key := strings.Join(leaf.path, ".")
+		translation, ok := translations[key]
+		if !ok || seen[strings.Join(leaf.path, "\x00")] || !app.sidecar.Stale(key, leaf.text) {
+			continue
+		}
+		if _, pinned := app.jsonOverrides[key]; pinned {
+			continue
+		}
+		if app.sidecar.Edited(key, translation.text) {
+			app.warn("the source of %q changed since it was translated, but its translation was edited and is kept", key)
+			continue
+		}
+		paths = append(paths, leaf.path)
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Changed since its translation: %q.\n", key)
+		}
+	}
+
+	return paths
+}
+
+// translatedKeys remembers the keys of the JSON document that is sent to the
+// model, so that their provenance is recorded.
+func (app *App) translatedKeys(doc []byte) {
+	if app.sidecar == nil || doc == nil {
+		return
+	}
+
+	var m map[string]any
+	app.fatalIfErrorf(json.Unmarshal(doc, &m), "failed to unmarshal source as JSON")
+	for key := range jsonLeaves(m) {
+		app.provenanceKeys = append(app.provenanceKeys, key)
+	}
+}
+
+// recordProvenance records the provenance of the translated keys in the
+// sidecar file of the output file and removes the keys that are no longer in
+// the output file.
+func (app *App) recordProvenance(source []byte, result string) {
+	if app.sidecar == nil || app.planning() || app.submitting || options.Translate.Dry || options.Translate.Diff {
+		return
+	}
+
+	var sourceMap, resultMap map[string]any
+	app.fatalIfErrorf(json.Unmarshal(source, &sourceMap), "failed to unmarshal source as JSON")
+	app.fatalIfErrorf(json.Unmarshal([]byte(result), &resultMap), "failed to unmarshal result as JSON")
+	sources, translations := jsonLeaves(sourceMap), jsonLeaves(resultMap)
+
+	skipped := make(map[string]bool)
+	for _, path := range app.skippedPaths(0) {
+		skipped[strings.Join(path, ".")] = true
+	}
+
+	entry := provenance.Entry{
+		Model:      app.provenanceModel(),
+		Prompt:     app.promptHash(),
+		Translated: time.Now().UTC(),
+	}
+	for _, key := range app.provenanceKeys {
+		source, ok := sources[key]
+		translation, translated := translations[key]
+		if !ok || !translated || skipped[key] {
+			continue
+		}
+		app.sidecar.Record(key, source.text, translation.text, entry)
+	}
+
+	keys := make([]string, 0, len(translations))
+	for key := range translations {
+		keys = append(keys, key)
+	}
+	app.sidecar.Prune(keys)
+
+	err := app.sidecar.Save(provenance.Path(options.Translate.Out))
+	app.fatalIfErrorf(err, "failed to write the provenance of %q", options.Translate.Out)
+}
+
+// provenanceModel returns the model or provider that translates the keys.
+func (app *App) provenanceModel() string {
+	if app.usesDeepL() {
+		return "deepl"
+	}
+	if app.usesRegistered() {
+		if options.Model == "" {
+			return options.Provider
+		}
+		return options.Provider + ":" + options.Model
+	}
+	return options.OpenAIModel
+}
+
+// promptHash returns the hash of the settings that make up the prompt of the
+// translation, so that keys that were translated with different languages,
+// instructions or context can be told apart.
+func (app *App) promptHash() string {
+	params := app.translateParams("", nil)
+
+	var template []byte
+	if path := app.params.PromptFile; path != "" {
+		var err error
+		template, err = os.ReadFile(path)
+		app.fatalIfErrorf(err, "failed to read prompt file %q", path)
+	}
+
+	b, err := json.Marshal(struct {
+		Source       string
+		Target       string
+		Preserve     []string
+		Instructions []string
+		Formality    dragoman.Formality
+		Context      []string
+		SystemPrompt string
+		Examples     []dragoman.Example
+		Template     string
+	}{
+		Source:       params.Source,
+		Target:       params.Target,
+		Preserve:     params.Preserve,
+		Instructions: params.Instructions,
+		Formality:    params.Formality,
+		Context:      params.Context,
+		SystemPrompt: params.SystemPrompt,
+		Examples:     params.Examples,
+		Template:     string(template),
+	})
+	app.fatalIfErrorf(err, "failed to marshal prompt settings")
+
+	return provenance.Hash(string(b))
+}
+
+// jsonLeaf is a value of a JSON document that is not an object.
+type jsonLeaf struct {
+	path dragoman.JSONPath
+	text string
+}
+
+// jsonLeaves returns the values of a JSON document that are not objects,
+// keyed by their dot-separated key paths. Values that are not strings are
+// represented by their JSON encoding.
+func jsonLeaves(doc map[string]any) map[string]jsonLeaf {
+	out := make(map[string]jsonLeaf)
+	var walk func(dragoman.JSONPath, map[string]any)
+	walk = func(prefix dragoman.JSONPath, doc map[string]any) {
+		for k, v := range doc {
+			path := append(append(dragoman.JSONPath{}, prefix...), k)
+			if m, ok := v.(map[string]any); ok && len(m) > 0 {
+				walk(path, m)
+				continue
+			}
+
+			text, ok := v.(string)
+			if !ok {
+				b, _ := json.Marshal(v)
+				text = string(b)
+			}
+			out[strings.Join(path, ".")] = jsonLeaf{path: path, text: text}
+		}
+	}
+	walk(nil, doc)
+	return out
+}
+
+// sortedLeaves returns the leaves sorted by their key paths.
+func sortedLeaves(leaves map[string]jsonLeaf) []jsonLeaf {
+	keys := make([]string, 0, len(leaves))
+	for key := range leaves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]jsonLeaf, len(keys))
+	for i, key := range keys {
+		out[i] = leaves[key]
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/provider"
+)
+
+// checkProvider exits if the provider of --provider is not registered.
+func (app *App) checkProvider() {
+	if !provider.Registered(options.Provider) {
+		app.fatalf(exitConfig, "unknown provider %q (available: %s)", options.Provider, strings.Join(provider.Names(), ", "))
+	}
+}
+
+// usesRegistered reports whether the provider is one that was registered by
+// the executable, like a third-party provider, instead of one of the built-in
+// providers, which the CLI configures using all of its flags.
+func (app *App) usesRegistered() bool {
+	switch options.Provider {
+	case "openai", "deepl", "compat":
+		return false
+	default:
+		return true
+	}
+}
+
+// registered creates the backend of the registered provider, once per run.
+func (app *App) registered() provider.Backend {
+	if app.backend != nil {
+		return *app.backend
+	}
+
+	backend, err := provider.New(options.Provider, provider.Config{
+		Model:      options.Model,
+		Key:        options.ProviderKey,
+		BaseURL:    options.BaseURL,
+		Timeout:    options.Timeout,
+		MaxRetries: options.Retries,
+		Verbose:    options.Verbose,
+	})
+	if err != nil {
+		app.fatalf(exitConfig, "failed to create provider: %v", err)
+	}
+	app.backend = &backend
+
+	return backend
+}
+
+// registeredEngine returns the machine translation engine of the registered
+// provider, or nil if the provider is a built-in provider or a language model.
+func (app *App) registeredEngine() dragoman.Engine {
+	if !app.usesRegistered() {
+		return nil
+	}
+	return app.registered().Engine
+}
+
+// translationModel returns the model that translates and improves documents:
+// the model of the registered provider, or the OpenAI client of the built-in
+// providers.
+func (app *App) translationModel() dragoman.Model {
+	if app.usesRegistered() {
+		return app.registered().Model
+	}
+	return app.model()
+}
+
+// engineName returns the name of the machine translation engine of the
+// provider for messages.
+func engineName() string {
+	if options.Provider == "deepl" {
+		return "DeepL"
+	}
+	return options.Provider
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// pruneJSON removes the keys of the existing output document that no longer
+// exist in the source document if --prune is set, and reports whether any key
+// was removed. The removed keys are listed in verbose mode.
+func (app *App) pruneJSON(out, source map[string]any) bool {
+	if !options.Translate.Prune {
+		return false
+	}
+
+	pruned := dragoman.JSONPrune(out, source)
+	if options.Verbose {
+		for _, path := range pruned {
+			fmt.Fprintf(os.Stderr, "Pruned %q.\n", strings.Join(path, "."))
+		}
+	}
+
+	return len(pruned) > 0
+}
+
+// staleKeys returns the key paths of the target document that --prune would
+// remove.
+func (app *App) staleKeys(source, target []byte) []string {
+	var sourceMap, targetMap map[string]any
+	app.fatalIfErrorf(json.Unmarshal(source, &sourceMap), "failed to unmarshal source as JSON")
+	app.fatalIfErrorf(json.Unmarshal(target, &targetMap), "failed to unmarshal target file %q", options.Translate.Out)
+
+	var stale []string
+	for _, path := range dragoman.JSONPrune(targetMap, app.i18nextExpand(sourceMap)) {
+		stale = append(stale, strings.Join(path, "."))
+	}
+	return stale
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// skip records a chunk that was left untranslated because the model refused
+// to translate it or because it failed and --on-error is 'skip', and reports it
+// as a warning together with the keys of the chunk if it is a JSON object.
+func (app *App) skip(chunk dragoman.SkippedChunk) {
+	app.skipped = append(app.skipped, chunk)
+
+	msg := fmt.Sprintf("chunk %d", chunk.Chunk)
+	if paths := chunkPaths(chunk.Source); len(paths) > 0 {
+		keys := make([]string, len(paths))
+		for i, path := range paths {
+			keys[i] = strings.Join(path, ".")
+		}
+		msg += fmt.Sprintf(" (keys: %s)", strings.Join(keys, ", "))
+	}
+	app.warn("skipped %s: %s", msg, chunk.Reason)
+
+	if path := options.Translate.SourcePath; path != "" {
+		msg = fmt.Sprintf("%s: %s", path, msg)
+	}
+	app.skipReport = append(app.skipReport, msg)
+}
+
+// printSkipped lists the chunks that were skipped during the run on stderr, so
+// that they are not lost between the output of long runs.
+func (app *App) printSkipped() {
+	if len(app.skipReport) == 0 {
+		return
+	}
+	app.progress.clear()
+
+	fmt.Fprintf(os.Stderr, "%d chunks were skipped and left untranslated:\n", len(app.skipReport))
+	for _, msg := range app.skipReport {
+		fmt.Fprintf(os.Stderr, "  %s\n", msg)
+	}
+}
+
+// errorPolicy returns the error policy of the --on-error flag.
+func (app *App) errorPolicy() dragoman.ErrorPolicy {
+	policy, err := dragoman.ParseErrorPolicy(app.params.OnError)
+	if err != nil {
+		app.fatalf(exitConfig, "invalid --on-error: %v", err)
+	}
+	return policy
+}
+
+// skippedPaths returns the key paths of the values of the JSON chunks that
+// were skipped since the given number of skipped chunks. Chunks of packed
+// documents can share a parent key, so only the paths of the values of the
+// chunk itself are returned.
+func (app *App) skippedPaths(since int) []dragoman.JSONPath {
+	var paths []dragoman.JSONPath
+	for _, chunk := range app.skipped[since:] {
+		paths = append(paths, chunkPaths(chunk.Source)...)
+	}
+	return paths
+}
+
+// chunkPaths returns the sorted key paths of the values of a chunk, or nil if
+// the chunk is not a JSON object.
+func chunkPaths(chunk string) []dragoman.JSONPath {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(chunk), &doc); err != nil {
+		return nil
+	}
+
+	leaves := sortedLeaves(jsonLeaves(doc))
+	paths := make([]dragoman.JSONPath, len(leaves))
+	for i, leaf := range leaves {
+		paths[i] = leaf.path
+	}
+
+	return paths
+}
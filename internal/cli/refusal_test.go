@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTranslate_packedUpdate_skippedChunk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		prompt := req.Messages[len(req.Messages)-1].Content
+		doc := strings.Split(strings.Split(prompt, "---<DOC_BEGIN>---\n")[1], "\n---<DOC_END>---")[0]
+
+		response := "I'm sorry, but I can't assist with that."
+		if !strings.Contains(doc, `"home"`) {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(doc), &m); err != nil {
+				t.Errorf("chunk is not a JSON object: %v\n%s", err, doc)
+			}
+			prefix(m)
+			b, _ := json.Marshal(m)
+			response = string(b)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"index": 0, "delta": map[string]any{"content": response}, "finish_reason": "stop"}},
+		})
+		fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", chunk)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "en.json")
+	out := filepath.Join(dir, "ru.json")
+	if err := os.WriteFile(source, []byte(`{"nav": {"home": "Home", "about": "About", "contact": "Contact"}, "title": "Title"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{
+		"dragoman", "translate", source,
+		"--out", out,
+		"--update",
+		"--pack-tokens", "12",
+		"--provider", "compat",
+		"--base-url", srv.URL + "/v1",
+		"--model", "m",
+		"--to", "Russian",
+		"--config", filepath.Join(dir, "dragoman.yaml"),
+	}
+
+	app := New("test")
+	app.Run()
+
+	if len(app.skipped) != 1 {
+		t.Fatalf("expected 1 skipped chunk; got %d", len(app.skipped))
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output file: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("output is not a JSON object: %v", err)
+	}
+
+	want := map[string]any{
+		"nav":   map[string]any{"about": "DE About", "contact": "DE Contact"},
+		"title": "DE Title",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected output (-want +got):\n%s", diff)
+	}
+
+	if len(app.skipReport) != 1 || !strings.HasSuffix(app.skipReport[0], "(keys: nav.home)") {
+		t.Fatalf("expected the skipped chunk to be reported with its key paths; got %q", app.skipReport)
+	}
+}
+
+func prefix(m map[string]any) {
+	for k, v := range m {
+		switch v := v.(type) {
+		case string:
+			m[k] = "DE " + v
+		case map[string]any:
+			prefix(v)
+		}
+	}
+}
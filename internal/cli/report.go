@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/openai"
+)
+
+// context returns the context of a command, which is canceled when the command
+// is interrupted and tracks the usage of the models for --report.
+func (app *App) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return dragoman.TrackUsage(ctx, &app.usage), cancel
+}
+
+// printReport prints the number of requests, the token usage, the estimated
+// cost and the wall time of the run to stderr if --report is set.
+func (app *App) printReport() {
+	if !options.Report || app.reported {
+		return
+	}
+	app.reported = true
+	app.progress.clear()
+
+	// Only the models of OpenAI have known prices.
+	cost := "unknown"
+	if _, _, priced := openai.ModelPricing(options.OpenAIModel); priced && options.Provider == "openai" {
+		cost = fmt.Sprintf("$%.4f", modelPricing().Cost(app.usage.PromptTokens, app.usage.CompletionTokens))
+	}
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Requests:\t%d\n", app.usage.Requests)
+	fmt.Fprintf(w, "Tokens:\t%d (%d prompt, %d completion)\n", app.usage.TotalTokens(), app.usage.PromptTokens, app.usage.CompletionTokens)
+	fmt.Fprintf(w, "Estimated cost:\t%s\n", cost)
+	fmt.Fprintf(w, "Wall time:\t%s\n", time.Since(app.started).Round(time.Millisecond))
+	w.Flush()
+}
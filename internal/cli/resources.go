@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/properties"
+	"github.com/modernice/dragoman/format/resx"
+)
+
+// translateProperties translates the values of a Java properties file. If the
+// output file already exists, the entries that are missing in it are
+// translated and appended; existing translations are kept. Translations that
+// do not keep the placeholders of their source are discarded.
+func (app *App) translateProperties(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	src, err := properties.Parse(source)
+	app.fatalIfErrorf(err, "failed to parse properties file")
+
+	var target *properties.File
+	if existing := app.readExistingOut(); existing != nil {
+		target, err = properties.Parse(existing)
+		app.fatalIfErrorf(err, "failed to parse properties file %q", options.Translate.Out)
+	}
+
+	f, err := properties.Merge(src, target)
+	app.fatalIfErrorf(err, "failed to merge properties files")
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+// translateResx translates the string resources of a .NET resource file. If
+// the output file already exists, the resources that are missing in it are
+// translated and inserted; existing translations are kept. Translations that
+// do not keep the format items of their source are discarded.
+func (app *App) translateResx(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	src, err := resx.Parse(source)
+	app.fatalIfErrorf(err, "failed to parse resource file")
+
+	var target *resx.File
+	if existing := app.readExistingOut(); existing != nil {
+		target, err = resx.Parse(existing)
+		app.fatalIfErrorf(err, "failed to parse resource file %q", options.Translate.Out)
+	}
+
+	f, err := resx.Merge(src, target)
+	app.fatalIfErrorf(err, "failed to merge resource files")
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d strings need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/jobstate"
+)
+
+// job records the progress of a multi-chunk translation in the state file next
+// to the output file, so that an interrupted translation can be resumed using
+// --resume.
+type job struct {
+	path        string
+	out         string
+	fingerprint string
+	done        map[int]bool
+	skipped     map[int]bool
+	failed      bool
+}
+
+// checkResume exits if --resume is used for a translation that is not recorded
+// in the state file.
+func (app *App) checkResume() {
+	if !options.Translate.Resume {
+		return
+	}
+
+	if options.Translate.Out == "" || options.Translate.Dry || options.Translate.Clipboard || app.planning() {
+		app.fatalf(exitConfig, "--resume requires the <out> file and cannot be used with --dry, --clipboard or --estimate")
+	}
+
+	path := sourceFile()
+	if options.Translate.Prose || isJSONCFile(path) || isPOFile(path) || isXLIFFFile(path) || isAndroidXMLFile(path) || selectsXML() || isAppleStringsFile(path) || isStringCatalogFile(path) || isPropertiesFile(path) || isResxFile(path) || isGoTemplateFile(path) || isGoFile(path) || isSubtitleFile(path) || isCSVFile(path) || isHTMLFile(path) && options.Translate.Bilingual == "" {
+		app.fatalf(exitConfig, "--resume cannot be used with --prose or for JSONC, PO, XLIFF, Android, Apple, Java or .NET resource, Go, subtitle, CSV or HTML files")
+	}
+}
+
+// trackJob records every chunk that the params translate in the state file. If
+// --resume is set, the chunks of an interrupted translation of the same source
+// with the same settings are added to the chunk overrides of the params, so
+// that they are not translated again. trackJob returns nil if the translation
+// is not written to an output file.
+func (app *App) trackJob(params *dragoman.TranslateParams) *job {
+	if options.Translate.Out == "" || options.Translate.Dry || app.planning() {
+		return nil
+	}
+
+	fingerprint, err := jobstate.Fingerprint(struct {
+		Provider     string
+		Model        string
+		PromptFile   string
+		Document     string
+		Source       string
+		Target       string
+		Preserve     []string
+		Patterns     []string
+		Instructions []string
+		SystemPrompt string
+		Examples     []dragoman.Example
+		Formality    dragoman.Formality
+		Context      []string
+		SplitChunks  []string
+		SplitLevels  []int    `json:",omitempty"`
+		Scrub        []string `json:",omitempty"`
+		CarryOver    int
+		CarrySummary bool
+	}{
+		Provider:     options.Provider,
+		Model:        options.OpenAIModel,
+		PromptFile:   app.params.PromptFile,
+		Document:     params.Document,
+		Source:       params.Source,
+		Target:       params.Target,
+		Preserve:     params.Preserve,
+		Patterns:     app.params.PreservePatterns,
+		Instructions: params.Instructions,
+		SystemPrompt: params.SystemPrompt,
+		Examples:     params.Examples,
+		Formality:    params.Formality,
+		Context:      params.Context,
+		SplitChunks:  params.SplitChunks,
+		SplitLevels:  params.SplitHeadingLevels,
+		Scrub:        app.scrubKinds(),
+		CarryOver:    params.CarryOver,
+		CarrySummary: params.CarrySummary,
+	})
+	app.fatalIfErrorf(err, "failed to fingerprint translation")
+
+	j := &job{
+		path:        filepath.Join(filepath.Dir(options.Translate.Out), jobstate.DefaultFile),
+		out:         filepath.Base(options.Translate.Out),
+		fingerprint: fingerprint,
+		done:        make(map[int]bool),
+		skipped:     make(map[int]bool),
+	}
+
+	if options.Translate.Resume {
+		app.resumeJob(j, params)
+	}
+
+	onSkip := params.OnSkip
+	params.OnSkip = func(chunk dragoman.SkippedChunk) {
+		j.skipped[chunk.Chunk] = true
+		if onSkip != nil {
+			onSkip(chunk)
+		}
+	}
+
+	done := params.OnChunkDone
+	params.OnChunkDone = func(progress dragoman.ChunkProgress) {
+		app.jobChunkDone(j, progress)
+		if done != nil {
+			done(progress)
+		}
+	}
+
+	return j
+}
+
+// resumeJob adds the translated chunks of the recorded job to the chunk
+// overrides of the params. Overrides from the --overrides file take
+// precedence.
+func (app *App) resumeJob(j *job, params *dragoman.TranslateParams) {
+	state, err := jobstate.Load(j.path)
+	app.fatalIfErrorf(err, "failed to load translation state")
+
+	translations, ok := state.Resume(j.out, j.fingerprint)
+	if !ok {
+		if _, exists := state.Jobs[j.out]; exists {
+			app.warn("the source or the settings changed since the translation of %q was interrupted; starting over", options.Translate.Out)
+		} else if options.Verbose {
+			fmt.Fprintf(os.Stderr, "No interrupted translation of %q to resume.\n", options.Translate.Out)
+		}
+		return
+	}
+
+	overrides := make(map[int]string, len(params.Overrides)+len(translations))
+	for chunk, translation := range translations {
+		overrides[chunk] = translation
+		j.done[chunk] = true
+	}
+	for chunk, translation := range params.Overrides {
+		overrides[chunk] = translation
+	}
+	params.Overrides = overrides
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Resuming the translation of %q at chunk %d.\n", options.Translate.Out, j.next())
+	}
+}
+
+// jobChunkDone records the translation of a chunk in the state file. Skipped
+// chunks are not recorded, so that they are translated again when the job is
+// resumed. Documents that consist of a single chunk are not recorded at all.
+func (app *App) jobChunkDone(j *job, progress dragoman.ChunkProgress) {
+	if j.failed || progress.Chunks < 2 || j.skipped[progress.Chunk] {
+		return
+	}
+
+	// The state file is loaded again for every chunk so that translations
+	// to other output files of the same directory are not lost.
+	err := jobstate.Update(context.Background(), j.path, func(state *jobstate.State) {
+		state.ChunkDone(j.out, j.fingerprint, progress.Chunk, progress.Chunks, progress.Translation, time.Now())
+	})
+	if err != nil {
+		j.failed = true
+		app.warn("failed to record the progress of the translation: %v", err)
+		return
+	}
+
+	j.done[progress.Chunk] = true
+}
+
+// finishJob removes the completed job from the state file.
+func (app *App) finishJob(j *job) {
+	if j == nil {
+		return
+	}
+
+	state, err := jobstate.Load(j.path)
+	if err == nil {
+		if _, ok := state.Jobs[j.out]; !ok {
+			return
+		}
+		err = jobstate.Update(context.Background(), j.path, func(state *jobstate.State) {
+			state.Done(j.out)
+		})
+	}
+	if err != nil {
+		app.warn("failed to remove the completed translation from %q: %v", j.path, err)
+	}
+}
+
+// failJob reports how a failed translation can be resumed.
+func (app *App) failJob(j *job) {
+	if j == nil || j.failed || len(j.done) == 0 {
+		return
+	}
+	app.progress.clear()
+	fmt.Fprintf(os.Stderr, "Run the command again with --resume to continue the translation at chunk %d.\n", j.next())
+}
+
+// next returns the number of the first chunk that is not translated yet.
+func (j *job) next() int {
+	chunk := 1
+	for j.done[chunk] {
+		chunk++
+	}
+	return chunk
+}
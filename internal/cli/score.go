@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/atomicfile"
+)
+
+// scoreRecord is a string of the report of the score command.
+type scoreRecord struct {
+	dragoman.Score
+	Overall float64 `json:"overall"`
+}
+
+// score asks the model to rate each string of the translated JSON document and
+// writes a report of the scores, lowest overall score first, so that human
+// reviewers can start with the strings that most likely need work.
+func (app *App) score() {
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the score command")
+	}
+	app.requireModel("score")
+
+	if !isJSONFile(options.Score.SourcePath) || !isJSONFile(options.Score.TargetPath) {
+		app.fatalf(exitConfig, "score requires JSON source and target files")
+	}
+
+	source := app.readStrings(options.Score.SourcePath)
+	target := app.readStrings(options.Score.TargetPath)
+
+	ctx, cancel := app.context()
+	defer cancel()
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Rating the translations of %q ...\n", options.Score.TargetPath)
+	}
+
+	scores, err := dragoman.ScoreTranslations(ctx, app.model(), dragoman.ScoreParams{
+		Source:       source,
+		Target:       target,
+		SourceLang:   app.languageName("--from", options.Score.SourceLang),
+		TargetLang:   app.languageName("--to", options.Score.TargetLang),
+		Instructions: options.Score.Instructions,
+		BatchSize:    options.Score.BatchSize,
+	})
+	app.fatalIfErrorf(err, "failed to score translations")
+
+	records := make([]scoreRecord, len(scores))
+	for i, s := range scores {
+		records[i] = scoreRecord{Score: s, Overall: s.Overall()}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Overall < records[j].Overall
+	})
+
+	var (
+		report bytes.Buffer
+		w      io.Writer = os.Stdout
+	)
+	if options.Score.Out != "" {
+		w = &report
+	}
+
+	if options.Score.CSV {
+		err = writeScoresCSV(w, records)
+	} else {
+		var b []byte
+		if b, err = jsonMarshal(records); err == nil {
+			_, err = w.Write(b)
+		}
+	}
+	app.fatalIfErrorf(err, "failed to write report")
+
+	if options.Score.Out != "" {
+		err = atomicfile.WriteFile(options.Score.Out, report.Bytes(), 0644)
+		app.fatalIfErrorf(err, "failed to write report file %q", options.Score.Out)
+		app.written = append(app.written, options.Score.Out)
+	}
+}
+
+// readStrings reads a JSON document and returns its string values by their
+// key path.
+func (app *App) readStrings(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	app.fatalIfErrorf(err, "failed to read file %q", path)
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		app.fatalf(exitConfig, "invalid JSON file %q: %v", path, err)
+	}
+
+	values := make(map[string]string)
+	collectStrings(doc, "", values)
+
+	return values
+}
+
+func writeScoresCSV(w io.Writer, records []scoreRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"key", "overall", "accuracy", "fluency", "terminology", "comment", "source", "translation"})
+	for _, r := range records {
+		cw.Write([]string{
+			r.Key,
+			strconv.FormatFloat(r.Overall, 'f', 2, 64),
+			strconv.Itoa(r.Accuracy),
+			strconv.Itoa(r.Fluency),
+			strconv.Itoa(r.Terminology),
+			r.Comment,
+			r.Source,
+			r.Translation,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// scrubPatterns returns the patterns of the sensitive texts of the --scrub
+// flag that are masked before they are sent to the model. It returns nil if
+// --scrub-action is 'abort'.
+func (app *App) scrubPatterns() []*regexp.Regexp {
+	patterns, err := dragoman.ScrubPatterns(app.scrubKinds()...)
+	if err != nil {
+		app.fatalf(exitConfig, "invalid --scrub: %v", err)
+	}
+	return patterns
+}
+
+// checkSecrets aborts the command if --scrub-action is 'abort' and the source
+// contains sensitive texts of the --scrub flag. The texts are reported
+// redacted.
+func (app *App) checkSecrets(source []byte) {
+	if len(app.params.Scrub) == 0 || app.params.ScrubAction != "abort" {
+		return
+	}
+
+	secrets, err := dragoman.FindSecrets(string(source), app.params.Scrub...)
+	if err != nil {
+		app.fatalf(exitConfig, "invalid --scrub: %v", err)
+	}
+	if len(secrets) == 0 {
+		return
+	}
+
+	app.progress.clear()
+	fmt.Fprintf(os.Stderr, "%d sensitive texts were found in the source:\n", len(secrets))
+	for _, secret := range secrets {
+		fmt.Fprintf(os.Stderr, "  line %d: %s (%s)\n", secret.Line, secret.Redacted(), strings.TrimSuffix(secret.Kind, "s"))
+	}
+	app.fatalf(exitValidation, "the source was not sent to the model because it contains sensitive texts (use --scrub-action mask to mask them instead)")
+}
+
+// scrubKinds returns the kinds of sensitive texts that are masked before
+// translation.
+func (app *App) scrubKinds() []string {
+	if app.params.ScrubAction == "abort" {
+		return nil
+	}
+	return app.params.Scrub
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/modernice/dragoman"
+	dragomangrpc "github.com/modernice/dragoman/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// shutdownTimeout is the time that running calls are given to complete when
+// the server is stopped.
+const shutdownTimeout = 30 * time.Second
+
+// serve runs the gRPC server until it is interrupted.
+func (app *App) serve() {
+	app.requireModel("serve")
+
+	if options.CheckOnly {
+		app.fatalf(exitConfig, "--check-only is not supported by the serve command")
+	}
+
+	if (options.Serve.TLSCert == "") != (options.Serve.TLSKey == "") {
+		app.fatalf(exitConfig, "--tls-cert and --tls-key must be provided together")
+	}
+
+	var opts []grpc.ServerOption
+	if options.Serve.TLSCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(options.Serve.TLSCert, options.Serve.TLSKey)
+		app.fatalIfErrorf(err, "failed to load TLS certificate")
+		opts = append(opts, grpc.Creds(creds))
+	}
+	if options.Serve.Token != "" {
+		opts = append(opts, tokenAuth(options.Serve.Token)...)
+	}
+
+	lis, err := net.Listen("tcp", options.Serve.Addr)
+	app.fatalIfErrorf(err, "failed to listen on %s", options.Serve.Addr)
+
+	model := app.model()
+	server := grpc.NewServer(opts...)
+	dragomangrpc.RegisterDragomanServer(server, dragomangrpc.NewServer(
+		dragoman.NewTranslator(model, dragoman.TranslatorLogger(app.logger())),
+		dragoman.NewImprover(model, dragoman.ImproverLogger(app.logger())),
+	))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+
+		// GracefulStop waits for all running calls to complete, so calls that
+		// take longer than the timeout are canceled by Stop.
+		timer := time.AfterFunc(shutdownTimeout, server.Stop)
+		defer timer.Stop()
+		server.GracefulStop()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving %s on %s\n", dragomangrpc.Dragoman_ServiceDesc.ServiceName, lis.Addr())
+
+	// Serve returns nil once the server is stopped.
+	app.fatalIfErrorf(server.Serve(lis), "failed to serve")
+
+	<-stopped
+}
+
+// tokenAuth returns the interceptors that reject calls without the given
+// bearer token in their "authorization" metadata.
+func tokenAuth(token string) []grpc.ServerOption {
+	authorize := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, value := range md.Get("authorization") {
+			got, ok := strings.CutPrefix(value, "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+	}
+
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+}
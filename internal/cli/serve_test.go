@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/modernice/dragoman"
+	dragomangrpc "github.com/modernice/dragoman/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestTokenAuth(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		return "Hallo", nil
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(tokenAuth("secret")...)
+	dragomangrpc.RegisterDragomanServer(server, dragomangrpc.NewServer(dragoman.NewTranslator(model), dragoman.NewImprover(model)))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	defer conn.Close()
+	client := dragomangrpc.NewDragomanClient(conn)
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          codes.Code
+	}{
+		{"valid token", "Bearer secret", codes.OK},
+		{"invalid token", "Bearer wrong", codes.Unauthenticated},
+		{"missing scheme", "secret", codes.Unauthenticated},
+		{"missing token", "", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.authorization)
+			}
+
+			_, err := client.Translate(ctx, &dragomangrpc.TranslateRequest{Document: "Hello"})
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("Translate() should return %s; got %s", tt.code, code)
+			}
+
+			stream, err := client.TranslateStream(ctx, &dragomangrpc.TranslateRequest{Document: "Hello"})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("TranslateStream() should return %s; got %s", tt.code, code)
+			}
+		})
+	}
+}
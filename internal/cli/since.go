@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// changedSince adds the key paths of the source document whose values were
+// added or changed since the git revision of --since to the given paths, so
+// that their existing translations are replaced. Values with an override
+// keep their fixed translation.
+func (app *App) changedSince(paths []dragoman.JSONPath, source map[string]any) []dragoman.JSONPath {
+	if options.Translate.Since == "" {
+		return paths
+	}
+
+	previous := app.sourceAt(options.Translate.Since)
+
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		seen[strings.Join(path, "\x00")] = true
+	}
+
+	for _, path := range dragoman.JSONChanges(previous, source) {
+		key := strings.Join(path, "\x00")
+		if _, pinned := app.jsonOverrides[strings.Join(path, ".")]; pinned || seen[key] {
+			continue
+		}
+		seen[key] = true
+		paths = append(paths, path)
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Changed since %s: %q.\n", options.Translate.Since, strings.Join(path, "."))
+		}
+	}
+
+	return paths
+}
+
+// sourceAt returns the source file at the given git revision. A source file
+// that did not exist at the revision is an empty document.
+func (app *App) sourceAt(rev string) map[string]any {
+	path, err := filepath.Abs(options.Translate.SourcePath)
+	app.fatalIfErrorf(err, "failed to resolve source file %q", options.Translate.SourcePath)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "show", rev+":./"+filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "exists on disk, but not in") || strings.Contains(msg, "does not exist in") {
+			return map[string]any{}
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		app.fatalf(exitConfig, "failed to read source file %q at %q: %s", options.Translate.SourcePath, rev, msg)
+	}
+
+	var previous map[string]any
+	err = json.Unmarshal(app.nestKeys(stdout.Bytes(), fmt.Sprintf("source file %q at %q", options.Translate.SourcePath, rev)), &previous)
+	app.fatalIfErrorf(err, "failed to unmarshal source file %q at %q as JSON", options.Translate.SourcePath, rev)
+
+	return previous
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/modernice/dragoman"
+)
+
+// stoppableContext returns the context of a translation that is stopped
+// gracefully when the command is interrupted: app.stop is closed, so that the
+// chunk that is being translated is finished and the chunks that were
+// translated so far are kept. A second interrupt cancels the context.
+func (app *App) stoppableContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := make(chan struct{})
+	app.stop = stop
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-ctx.Done():
+			return
+		}
+		app.progress.clear()
+		fmt.Fprintln(os.Stderr, "Stopping after the current chunk. Interrupt again to abort immediately.")
+		close(stop)
+
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return dragoman.TrackUsage(ctx, &app.usage), func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// keepsPartial reports whether the translation was stopped after some of its
+// chunks were translated and the partial result can be written. Partial JSON
+// documents are not valid, so their translated chunks are only kept in the
+// state file.
+func keepsPartial(err error) bool {
+	var stopped *dragoman.StoppedError
+	return errors.As(err, &stopped) && stopped.Chunk > 1 && !options.Translate.Update && !isJSONFile(sourceFile())
+}
+
+// stopMarker returns the marker that is appended to the partial result of a
+// stopped translation.
+func stopMarker(err error) string {
+	var stopped *dragoman.StoppedError
+	errors.As(err, &stopped)
+	return fmt.Sprintf("\n<!-- dragoman: translation stopped before chunk %d of %d -->\n", stopped.Chunk, stopped.Chunks)
+}
+
+// reportStopped reports where the translation was stopped and how it can be
+// continued. The command exits with exitFailure.
+func (app *App) reportStopped(j *job, err error) {
+	var stopped *dragoman.StoppedError
+	errors.As(err, &stopped)
+
+	reason := ""
+	if stopped.Cause != nil {
+		reason = fmt.Sprintf(" (%v)", stopped.Cause)
+	}
+
+	app.progress.clear()
+	fmt.Fprintf(os.Stderr, "The translation was stopped before chunk %d of %d%s; the translated chunks were kept.\n", stopped.Chunk, stopped.Chunks, reason)
+	app.failJob(j)
+	app.failed = true
+}
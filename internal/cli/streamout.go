@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modernice/dragoman"
+)
+
+// streamOut writes the translated chunks of a document to a partial file next
+// to the output file as soon as they are done. Once the whole document is
+// translated, the partial file replaces the output file, so that the output
+// file is never left half-written, while the partial file keeps the progress
+// of a translation that failed or was interrupted.
+type streamOut struct {
+	path    string
+	file    *os.File
+	written int
+	last    string
+	err     error
+}
+
+// checkStreamOut exits if --stream-out is used for a translation whose result
+// is not the concatenation of its translated chunks.
+func (app *App) checkStreamOut() {
+	if !options.Translate.StreamOut {
+		return
+	}
+
+	if options.Translate.Out == "" || options.Translate.Dry || options.Translate.Clipboard || app.planning() {
+		app.fatalf(exitConfig, "--stream-out requires the <out> file and cannot be used with --dry, --clipboard or --estimate")
+	}
+
+	path := sourceFile()
+	if options.Translate.Update || options.Translate.Prose || options.Translate.Bilingual != "" || isJSONFile(path) || isJSONCFile(path) || isPOFile(path) || isXLIFFFile(path) || isAndroidXMLFile(path) || selectsXML() || isAppleStringsFile(path) || isStringCatalogFile(path) || isPropertiesFile(path) || isResxFile(path) || isGoTemplateFile(path) || isGoFile(path) || isSubtitleFile(path) || isCSVFile(path) || isHTMLFile(path) && options.Translate.Bilingual == "" {
+		app.fatalf(exitConfig, "--stream-out cannot be used with --update, --prose or --bilingual or for JSON, PO, XLIFF, Android, Apple, Java or .NET resource, Go, subtitle, CSV or HTML files")
+	}
+}
+
+// openStreamOut creates the partial file of the output file, writes the given
+// prefix, like the translated front matter of a Markdown document, to it and
+// makes the params write every translated chunk to it.
+func (app *App) openStreamOut(params *dragoman.TranslateParams, prefix string) *streamOut {
+	path := options.Translate.Out + ".partial"
+	f, err := os.Create(path)
+	app.fatalIfErrorf(err, "failed to create partial output file %q", path)
+
+	s := &streamOut{path: path, file: f}
+	s.write(app.lineEndings(prefix))
+
+	done := params.OnChunkDone
+	params.OnChunkDone = func(progress dragoman.ChunkProgress) {
+		s.chunkDone(app, progress)
+		if done != nil {
+			done(progress)
+		}
+	}
+
+	return s
+}
+
+// chunkDone writes the translation of a chunk to the partial file. Chunks are
+// separated by a blank line, like the result of [dragoman.Translator.Translate].
+func (s *streamOut) chunkDone(app *App, progress dragoman.ChunkProgress) {
+	if s.written > 0 {
+		s.write(app.lineEndings("\n\n"))
+	}
+	s.write(app.lineEndings(progress.Translation))
+	s.written++
+
+	// Synced chunks survive a crash of the process.
+	if s.err == nil {
+		s.err = s.file.Sync()
+	}
+}
+
+func (s *streamOut) write(text string) {
+	if s.err != nil || text == "" {
+		return
+	}
+	_, s.err = s.file.WriteString(text)
+	s.last = text
+}
+
+// finish completes the partial file and moves it to the output file.
+func (app *App) finishStreamOut(s *streamOut) {
+	if !strings.HasSuffix(s.last, "\n") {
+		s.write(app.lineEndings("\n"))
+	}
+	if s.err == nil {
+		s.err = s.file.Close()
+	}
+	app.fatalIfErrorf(s.err, "failed to write partial output file %q", s.path)
+
+	err := os.Rename(s.path, options.Translate.Out)
+	app.fatalIfErrorf(err, "failed to move %q to output file %q", s.path, options.Translate.Out)
+}
+
+// abortStreamOut closes the partial file of a failed translation and reports
+// where the translated chunks were kept.
+func (app *App) abortStreamOut(s *streamOut) {
+	s.file.Close()
+	if s.written > 0 {
+		fmt.Fprintf(os.Stderr, "The translation of %d chunks was kept in %q.\n", s.written, s.path)
+		return
+	}
+	os.Remove(s.path)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/subtitle"
+)
+
+// translateSubtitles translates the cue texts of a SubRip or WebVTT file.
+// Indexes, timings and styling tags are kept, and lines of translated cues
+// that exceed --max-line-length are wrapped. Translations that do not keep the
+// styling tags of their cue are discarded.
+func (app *App) translateSubtitles(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	f, err := subtitle.Parse(source)
+	app.fatalIfErrorf(err, "failed to parse subtitle file")
+	f.MaxLineLength = options.Translate.LineLength
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d cues need to be translated.\n", len(f.Cues))
+	}
+
+	texts := make(map[string]string, len(f.Cues))
+	for i, cue := range f.Cues {
+		texts[strconv.Itoa(i)] = cue.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, cue := range f.Cues {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := cue.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/config"
+)
+
+// sync translates every target that is declared in the configuration file.
+// Each target is translated like a call to the translate command, using the
+// project defaults of the configuration merged with the settings of the
+// target.
+func (app *App) sync(opts syncOptions, dry bool) {
+	cfg := app.syncConfig(opts)
+
+	if cfg.Model != "" {
+		options.Model = cfg.Model
+		options.OpenAIModel = cfg.Model
+	}
+
+	if cfg.Provider != "" {
+		options.Provider = cfg.Provider
+		app.checkProvider()
+	}
+
+	if cfg.BaseURL != "" {
+		options.BaseURL = cfg.BaseURL
+	}
+
+	if app.parallel(cfg) {
+		app.syncParallel(cfg)
+		return
+	}
+
+	targets := cfg.Targets
+	if i := options.Sync.Target; i >= 0 {
+		if i >= len(targets) {
+			app.fatalf(exitConfig, "target %d does not exist", i)
+		}
+		targets = targets[i : i+1]
+		// The parent process runs the hooks and prints the report.
+		app.hooked, app.reported = true, true
+	}
+
+	defaults := options.Translate
+	for i, target := range targets {
+		target = cfg.Resolve(target)
+		app.progress.setFile(i+1, len(targets))
+
+		if options.Verbose {
+			fmt.Fprintf(os.Stderr, "Translating %q to %q ...\n", target.Source, target.Out)
+		}
+
+		app.crlf = false
+		switch code := app.translateTarget(targetOptions(defaults, target, dry)); code {
+		case exitOK:
+		case exitConfig, exitProvider:
+			app.writeJobSummary()
+			app.kong.Exit(code)
+		default:
+			app.failed = true
+		}
+	}
+
+	app.writeJobSummary()
+}
+
+// targetOptions returns the options of the translate command for a target of
+// the sync command. The options are a copy of defaults merged with the
+// settings of the target that shares no slices with either of them.
+func targetOptions(defaults translateCommand, target config.Target, dry bool) translateCommand {
+	opts := defaults.clone()
+	opts.SourcePath = target.Source
+	opts.Out = target.Out
+	opts.Update = target.Update
+	opts.Prune = target.Prune
+	opts.SplitChunks = slices.Clone(target.SplitChunks)
+	opts.SplitLevels = slices.Clone(target.SplitHeadings)
+	opts.Prose = target.Prose
+	opts.Overrides = target.Overrides
+	opts.Dedupe = target.Dedupe
+	opts.Provenance = target.Provenance
+	opts.Dry = dry
+	opts.Config = ""
+	opts.Params.SourceLang = target.From
+	opts.Params.Preserve = slices.Clone(target.Preserve)
+	opts.Params.Instructions = append(slices.Clone(target.Instructions), target.GlossaryInstructions()...)
+	opts.Params.Context = slices.Clone(target.Context)
+	if target.To != "" {
+		opts.Params.TargetLang = target.To
+	}
+	if target.Formality != "" {
+		opts.Params.Formality = dragoman.Formality(target.Formality)
+	}
+	return opts
+}
+
+// translateTarget runs the translate command with the given options and
+// returns its exit code instead of exiting, so that a failed target of the
+// sync and content commands does not abort the remaining targets. The options
+// replace those of the translate command only until the translation is done.
+func (app *App) translateTarget(opts translateCommand) int {
+	defaults := options.Translate
+	options.Translate = opts
+	defer func() { options.Translate = defaults }()
+
+	exit := app.kong.Exit
+	defer func() { app.kong.Exit = exit }()
+
+	// The translation runs in its own goroutine, which is stopped with
+	// runtime.Goexit when the translation exits, like testing.T.FailNow stops
+	// a test. The deferred calls of the translation still run.
+	codes := make(chan int, 1)
+	app.kong.Exit = func(code int) {
+		codes <- code
+		runtime.Goexit()
+	}
+	go func() {
+		app.translate()
+		codes <- exitOK
+	}()
+
+	return <-codes
+}
+
+// applyLanguageProfile applies the profile of the target language of the
+// translate command that is declared in the configuration file, if the file
+// exists. The settings of the profile are prepended to the preserved terms,
+// instructions and context of the flags, and its formality is used unless
+// --formality is set. The sync command applies the profiles when it resolves
+// its targets instead.
+func (app *App) applyLanguageProfile() {
+	path := options.Translate.Config
+	if path == "" {
+		return
+	}
+
+	cfg, err := config.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		app.fatalf(exitConfig, "failed to load configuration: %v", err)
+	}
+
+	params := &options.Translate.Params
+	profile, ok := cfg.Profile(params.TargetLang)
+	if !ok {
+		return
+	}
+
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "Applying the %q profile of %q.\n", params.TargetLang, path)
+	}
+
+	if params.Formality == "" {
+		params.Formality = dragoman.Formality(profile.Formality)
+	}
+	params.Preserve = append(append([]string{}, profile.Preserve...), params.Preserve...)
+	params.Instructions = append(append(append([]string{}, profile.Instructions...), profile.GlossaryInstructions()...), params.Instructions...)
+	params.Context = append(append([]string{}, profile.Context...), params.Context...)
+}
+
+// syncConfig loads the configuration file and replaces its targets with the
+// locale files that are discovered in the project if target locales or
+// --discover are provided, or if the configuration declares no targets. The
+// configuration file is optional when discovering locale files.
+func (app *App) syncConfig(opts syncOptions) *config.Config {
+	cfg, err := config.Load(opts.Config)
+	if errors.Is(err, fs.ErrNotExist) {
+		cfg, err = &config.Config{}, nil
+	}
+	if err != nil {
+		app.fatalf(exitConfig, "failed to load configuration: %v", err)
+	}
+
+	explicit := opts.Discover || len(opts.To) > 0
+	if explicit || len(cfg.Targets) == 0 {
+		root := filepath.Dir(opts.Config)
+		cfg.Targets, err = config.Discover(root, opts.From, opts.To)
+		if errors.Is(err, config.ErrNoLocales) && !explicit {
+			app.fatalf(exitConfig, "no targets declared in %q and no locale files found in %q", opts.Config, root)
+		}
+		if err != nil {
+			app.fatalf(exitConfig, "failed to discover locale files in %q: %v", root, err)
+		}
+	}
+
+	if len(cfg.Targets) == 0 {
+		app.fatalf(exitConfig, "no locale files to translate in %q", filepath.Dir(opts.Config))
+	}
+
+	return cfg
+}
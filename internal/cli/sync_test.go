@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSync_failedTarget(t *testing.T) {
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		var prompt []string
+		for _, msg := range req.Messages {
+			prompt = append(prompt, msg.Content)
+		}
+		prompts = append(prompts, strings.Join(prompt, "\n"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"index": 0, "delta": map[string]any{"content": "Bonjour"}, "finish_reason": "stop"}},
+		})
+		fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", chunk)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "en.md"), []byte("Hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf(`provider: compat
+base_url: %s/v1
+model: m
+targets:
+  - source: missing.md
+    out: de.md
+    to: German
+    instructions: [Address the reader informally.]
+  - source: en.md
+    out: fr.md
+    to: French
+`, srv.URL)
+	if err := os.WriteFile(filepath.Join(dir, "dragoman.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"dragoman", "sync", "--config", filepath.Join(dir, "dragoman.yaml")}
+
+	code := exitOK
+	app := New("test")
+	app.kong.Exit = func(c int) { code = c }
+	app.Run()
+
+	if code != exitFailure {
+		t.Fatalf("sync should exit with %d if a target failed; got %d", exitFailure, code)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "fr.md"))
+	if err != nil {
+		t.Fatalf("the target after the failed target should be translated: %v", err)
+	}
+	if strings.TrimSpace(string(b)) != "Bonjour" {
+		t.Fatalf("unexpected translation %q", b)
+	}
+
+	if len(prompts) != 1 {
+		t.Fatalf("expected 1 prompt; got %d", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "French") || strings.Contains(prompts[0], "informally") {
+		t.Fatalf("the prompt should only use the settings of its own target; got\n\n%s", prompts[0])
+	}
+	if options.Translate.SourcePath != "" || options.Translate.Params.TargetLang == "French" {
+		t.Fatalf("the options of the last target should not remain in the options of the translate command")
+	}
+}
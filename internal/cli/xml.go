@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/format/xml"
+)
+
+// translateXML translates the elements and attributes of an XML document that
+// are selected by --xml-path and --xml-attr. The rest of the document,
+// including namespaces, comments, CDATA sections and entity references, is
+// written back unchanged. If the output file already exists, only the texts
+// that are missing in it are translated; existing translations are kept.
+// Translations that change the inline markup of a text are discarded.
+func (app *App) translateXML(ctx context.Context, translator *dragoman.Translator, source []byte) {
+	src, err := xml.Parse(source, xmlSelectors())
+	app.fatalIfErrorf(err, "failed to parse XML document")
+
+	var target *xml.File
+	if existing := app.readExistingOut(); existing != nil {
+		target, err = xml.Parse(existing, xmlSelectors())
+		app.fatalIfErrorf(err, "failed to parse XML document %q", options.Translate.Out)
+	}
+
+	f, err := xml.Merge(src, target)
+	app.fatalIfErrorf(err, "failed to merge XML documents")
+
+	entries := f.Untranslated()
+	if options.Verbose {
+		fmt.Fprintf(os.Stderr, "%d texts need to be translated.\n", len(entries))
+	}
+
+	texts := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		texts[strconv.Itoa(i)] = entry.Text
+	}
+
+	translations, err := app.translateTexts(ctx, translator, texts)
+	app.fatalIfErrorf(err, "failed to translate document")
+
+	for i, entry := range entries {
+		translated, ok := translations[strconv.Itoa(i)]
+		if !ok {
+			continue
+		}
+
+		if err := entry.SetTranslation(translated); err != nil {
+			app.warn("discarding translation: %v", err)
+		}
+	}
+
+	app.outputTranslation(string(f.Bytes()))
+}
+
+func xmlSelectors() xml.Selectors {
+	return xml.Selectors{
+		Paths:      options.Translate.XMLPaths,
+		Attributes: options.Translate.XMLAttrs,
+	}
+}
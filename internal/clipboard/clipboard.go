@@ -0,0 +1,69 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnavailable is returned if no clipboard utility is available on the
+// system.
+var ErrUnavailable = errors.New("no clipboard utility available")
+
+// lookPath finds the executables of the clipboard utilities. It is replaced
+// by tests.
+var lookPath = exec.LookPath
+
+type command struct {
+	name string
+	args []string
+}
+
+// Read returns the current text content of the system clipboard.
+func Read() (string, error) {
+	cmd, err := find(pasteCommands)
+	if err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command(cmd.name, cmd.args...)
+	c.Stderr = &stderr
+
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", cmd.name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(out), nil
+}
+
+// Write replaces the content of the system clipboard with the given text.
+func Write(text string) error {
+	cmd, err := find(copyCommands)
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command(cmd.name, cmd.args...)
+	c.Stdin = strings.NewReader(text)
+	c.Stderr = &stderr
+
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+func find(cmds []command) (command, error) {
+	for _, cmd := range cmds {
+		if _, err := lookPath(cmd.name); err == nil {
+			return cmd, nil
+		}
+	}
+	return command{}, ErrUnavailable
+}
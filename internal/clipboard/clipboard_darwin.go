@@ -0,0 +1,6 @@
+package clipboard
+
+var (
+	pasteCommands = []command{{name: "pbpaste"}}
+	copyCommands  = []command{{name: "pbcopy"}}
+)
@@ -0,0 +1,70 @@
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	cmds := []command{
+		{name: "wl-copy"},
+		{name: "xclip", args: []string{"-selection", "clipboard", "-in"}},
+		{name: "xsel", args: []string{"--clipboard", "--input"}},
+	}
+
+	tests := []struct {
+		name      string
+		installed []string
+		want      string
+	}{
+		{"first available", []string{"wl-copy", "xclip", "xsel"}, "wl-copy"},
+		{"skips missing", []string{"xsel", "xclip"}, "xclip"},
+		{"last resort", []string{"xsel"}, "xsel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubLookPath(t, tt.installed...)
+
+			cmd, err := find(cmds)
+			if err != nil {
+				t.Fatalf("find(): %v", err)
+			}
+			if cmd.name != tt.want {
+				t.Fatalf("find() should return %q; got %q", tt.want, cmd.name)
+			}
+		})
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	stubLookPath(t)
+
+	if _, err := find(copyCommands); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("find() should return %v; got %v", ErrUnavailable, err)
+	}
+	if _, err := Read(); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Read() should return %v; got %v", ErrUnavailable, err)
+	}
+	if err := Write("Hello"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Write() should return %v; got %v", ErrUnavailable, err)
+	}
+}
+
+// stubLookPath makes lookPath only find the given executables.
+func stubLookPath(t *testing.T, installed ...string) {
+	t.Helper()
+
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+
+	lookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !darwin && !windows
+
+package clipboard
+
+var (
+	pasteCommands = []command{
+		{name: "wl-paste", args: []string{"--no-newline"}},
+		{name: "xclip", args: []string{"-selection", "clipboard", "-out"}},
+		{name: "xsel", args: []string{"--clipboard", "--output"}},
+	}
+
+	copyCommands = []command{
+		{name: "wl-copy"},
+		{name: "xclip", args: []string{"-selection", "clipboard", "-in"}},
+		{name: "xsel", args: []string{"--clipboard", "--input"}},
+	}
+)
@@ -0,0 +1,13 @@
+package clipboard
+
+var (
+	pasteCommands = []command{{
+		name: "powershell.exe",
+		args: []string{"-NoProfile", "-NonInteractive", "-Command", "[Console]::OutputEncoding = [Text.Encoding]::UTF8; Get-Clipboard -Raw"},
+	}}
+
+	copyCommands = []command{{
+		name: "powershell.exe",
+		args: []string{"-NoProfile", "-NonInteractive", "-Command", "[Console]::InputEncoding = [Text.Encoding]::UTF8; $input | Out-String | Set-Clipboard"},
+	}}
+)
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/modernice/dragoman/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the name of the project configuration file.
+const DefaultFile = "dragoman.yaml"
+
+// Config is a project configuration that declares the defaults for all
+// translations of a project and the set of translation targets that are
+// translated by `dragoman sync`.
+type Config struct {
+	// Provider is the provider of the translations, either "openai", "deepl",
+	// "compat" for OpenAI-compatible APIs, or a provider that is registered by
+	// the executable.
+	Provider string `yaml:"provider"`
+
+	// BaseURL is the base URL of the API of the "compat" provider or of a
+	// registered provider.
+	BaseURL string `yaml:"base_url"`
+
+	// Model is the language model to use.
+	Model string `yaml:"model"`
+
+	// Defaults are the translation settings that apply to every target.
+	Defaults `yaml:",inline"`
+
+	// Languages maps target languages, as they are passed to --to or declared
+	// as the "to" of targets, to the settings that apply to all translations
+	// to the language.
+	Languages map[string]Profile `yaml:"languages"`
+
+	// Targets are the translations of the project.
+	Targets []Target `yaml:"targets"`
+}
+
+// Defaults are translation settings that can be declared both for the whole
+// project and for individual targets.
+type Defaults struct {
+	// From is the source language.
+	From string `yaml:"from"`
+
+	// To is the target language.
+	To string `yaml:"to"`
+
+	// Formality is the formality of the translation, either "formal" or
+	// "informal".
+	Formality string `yaml:"formality"`
+
+	// Preserve are terms that must not be translated.
+	Preserve []string `yaml:"preserve"`
+
+	// Instructions are additional instructions for the prompt.
+	Instructions []string `yaml:"instructions"`
+
+	// Glossary maps terms of the source language to their required
+	// translations.
+	Glossary map[string]string `yaml:"glossary"`
+
+	// Context are reference files to include in the prompt.
+	Context []string `yaml:"context"`
+
+	// Dedupe translates repeated strings of JSON documents only once. Strings
+	// that were translated for a target are reused by the following targets
+	// of the same language.
+	Dedupe bool `yaml:"dedupe"`
+
+	// Provenance records the model, prompt, time and source of every
+	// machine-translated key of JSON output files in a sidecar file next to
+	// the output file.
+	Provenance bool `yaml:"provenance"`
+}
+
+// Profile are the translation settings of a target language, like the
+// handling of brand names in Japanese translations.
+type Profile struct {
+	// Formality is the formality of the translation, either "formal" or
+	// "informal".
+	Formality string `yaml:"formality"`
+
+	// Preserve are terms that must not be translated.
+	Preserve []string `yaml:"preserve"`
+
+	// Instructions are additional instructions for the prompt.
+	Instructions []string `yaml:"instructions"`
+
+	// Glossary maps terms of the source language to their required
+	// translations.
+	Glossary map[string]string `yaml:"glossary"`
+
+	// Context are reference files to include in the prompt.
+	Context []string `yaml:"context"`
+}
+
+// Target is a single translation of a source file into an output file.
+type Target struct {
+	Defaults `yaml:",inline"`
+
+	// Source is the source file.
+	Source string `yaml:"source"`
+
+	// Out is the output file.
+	Out string `yaml:"out"`
+
+	// Update only translates the fields of the source file that are missing in
+	// the output file.
+	Update bool `yaml:"update"`
+
+	// Prune removes the keys of the output file that no longer exist in the
+	// source file when the output file is updated.
+	Prune bool `yaml:"prune"`
+
+	// SplitChunks are the line prefixes at which the source file is split
+	// into chunks.
+	SplitChunks []string `yaml:"split-chunks"`
+
+	// SplitHeadings are the levels of the Markdown headings before which the
+	// source file is split into chunks.
+	SplitHeadings []int `yaml:"split-headings"`
+
+	// Prose only translates the prose of Markdown files.
+	Prose bool `yaml:"prose"`
+
+	// Overrides is a file that maps chunk numbers or JSON key paths to fixed
+	// translations.
+	Overrides string `yaml:"overrides"`
+}
+
+// Load reads the configuration file at the given path. Relative paths of the
+// configuration are resolved against the directory of the file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %q: %w", path, err)
+	}
+
+	cfg.resolvePaths(filepath.Dir(path))
+
+	return cfg, nil
+}
+
+// Parse parses a configuration in YAML format.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Provider {
+	case "", "openai", "deepl", "compat":
+	default:
+		if !provider.Registered(cfg.Provider) {
+			return nil, fmt.Errorf("unsupported provider %q", cfg.Provider)
+		}
+	}
+
+	switch cfg.Provider {
+	case "", "openai", "deepl":
+		if cfg.BaseURL != "" {
+			return nil, fmt.Errorf("base_url requires the %q provider or a registered provider", "compat")
+		}
+	}
+
+	if err := checkFormality(cfg.Formality); err != nil {
+		return nil, err
+	}
+
+	for lang, profile := range cfg.Languages {
+		if err := checkFormality(profile.Formality); err != nil {
+			return nil, fmt.Errorf("language %q: %w", lang, err)
+		}
+	}
+
+	for i, target := range cfg.Targets {
+		if target.Source == "" {
+			return nil, fmt.Errorf("target #%d: missing source", i+1)
+		}
+		if target.Out == "" {
+			return nil, fmt.Errorf("target #%d: missing out", i+1)
+		}
+		if err := checkFormality(target.Formality); err != nil {
+			return nil, fmt.Errorf("target #%d: %w", i+1, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// Resolve returns the settings of the target merged with the profile of its
+// target language and the project defaults. Target settings take precedence
+// over the profile, which takes precedence over the defaults; lists are
+// concatenated in the same order and glossary entries of the target override
+// entries of the profile and the project.
+func (cfg *Config) Resolve(target Target) Target {
+	if target.From == "" {
+		target.From = cfg.From
+	}
+	if target.To == "" {
+		target.To = cfg.To
+	}
+	target.Dedupe = target.Dedupe || cfg.Dedupe
+	target.Provenance = target.Provenance || cfg.Provenance
+
+	profile, _ := cfg.Profile(target.To)
+
+	if target.Formality == "" {
+		target.Formality = profile.Formality
+	}
+	if target.Formality == "" {
+		target.Formality = cfg.Formality
+	}
+
+	target.Preserve = concat(cfg.Preserve, profile.Preserve, target.Preserve)
+	target.Instructions = concat(cfg.Instructions, profile.Instructions, target.Instructions)
+	target.Context = concat(cfg.Context, profile.Context, target.Context)
+
+	glossary := make(map[string]string, len(cfg.Glossary)+len(profile.Glossary)+len(target.Glossary))
+	for _, g := range []map[string]string{cfg.Glossary, profile.Glossary, target.Glossary} {
+		for term, translation := range g {
+			glossary[term] = translation
+		}
+	}
+	target.Glossary = glossary
+
+	return target
+}
+
+// Profile returns the profile of the given target language. Languages are
+// matched case-insensitively.
+func (cfg *Config) Profile(lang string) (Profile, bool) {
+	if lang == "" {
+		return Profile{}, false
+	}
+	for name, profile := range cfg.Languages {
+		if strings.EqualFold(name, lang) {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+// GlossaryInstructions returns prompt instructions that require the terms of
+// the glossary to be translated as declared. The instructions are sorted by
+// term.
+func (d Defaults) GlossaryInstructions() []string {
+	return glossaryInstructions(d.Glossary)
+}
+
+// GlossaryInstructions returns prompt instructions that require the terms of
+// the glossary of the profile to be translated as declared. The instructions
+// are sorted by term.
+func (p Profile) GlossaryInstructions() []string {
+	return glossaryInstructions(p.Glossary)
+}
+
+func glossaryInstructions(glossary map[string]string) []string {
+	terms := make([]string, 0, len(glossary))
+	for term := range glossary {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	out := make([]string, len(terms))
+	for i, term := range terms {
+		out[i] = fmt.Sprintf("Translate %q as %q.", term, glossary[term])
+	}
+	return out
+}
+
+func concat(lists ...[]string) []string {
+	out := []string{}
+	for _, list := range lists {
+		out = append(out, list...)
+	}
+	return out
+}
+
+func checkFormality(formality string) error {
+	switch formality {
+	case "", "formal", "informal":
+		return nil
+	default:
+		return fmt.Errorf("unsupported formality %q", formality)
+	}
+}
+
+func (cfg *Config) resolvePaths(dir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	for i, path := range cfg.Context {
+		cfg.Context[i] = resolve(path)
+	}
+
+	for _, profile := range cfg.Languages {
+		for i, path := range profile.Context {
+			profile.Context[i] = resolve(path)
+		}
+	}
+
+	for i := range cfg.Targets {
+		target := &cfg.Targets[i]
+		target.Source = resolve(target.Source)
+		target.Out = resolve(target.Out)
+		target.Overrides = resolve(target.Overrides)
+		for j, path := range target.Context {
+			target.Context[j] = resolve(path)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/internal/config"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.DefaultFile)
+
+	if err := os.WriteFile(path, []byte(heredoc.Doc(`
+		provider: openai
+		model: gpt-4o
+		from: English
+		preserve: [Dragoman]
+		dedupe: true
+		glossary:
+		  invoice: Rechnung
+		  account: Konto
+		targets:
+		  - source: locales/en.json
+		    out: locales/de.json
+		    to: German
+		    update: true
+		    prune: true
+		    overrides: overrides.yaml
+		    glossary:
+		      account: Benutzerkonto
+	`)), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+
+	if cfg.Model != "gpt-4o" {
+		t.Fatalf("Model should be %q; is %q", "gpt-4o", cfg.Model)
+	}
+
+	target := cfg.Resolve(cfg.Targets[0])
+
+	want := config.Target{
+		Defaults: config.Defaults{
+			From:         "English",
+			To:           "German",
+			Preserve:     []string{"Dragoman"},
+			Instructions: []string{},
+			Context:      []string{},
+			Dedupe:       true,
+			Glossary: map[string]string{
+				"invoice": "Rechnung",
+				"account": "Benutzerkonto",
+			},
+		},
+		Source:    filepath.Join(dir, "locales/en.json"),
+		Out:       filepath.Join(dir, "locales/de.json"),
+		Overrides: filepath.Join(dir, "overrides.yaml"),
+		Update:    true,
+		Prune:     true,
+	}
+
+	if !cmp.Equal(want, target) {
+		t.Fatalf("Resolve() mismatch (-want +got):\n%s", cmp.Diff(want, target))
+	}
+
+	wantInstructions := []string{
+		`Translate "account" as "Benutzerkonto".`,
+		`Translate "invoice" as "Rechnung".`,
+	}
+
+	if got := target.GlossaryInstructions(); !cmp.Equal(wantInstructions, got) {
+		t.Fatalf("GlossaryInstructions() mismatch (-want +got):\n%s", cmp.Diff(wantInstructions, got))
+	}
+}
+
+func TestConfig_Resolve_languages(t *testing.T) {
+	cfg, err := config.Parse([]byte(heredoc.Doc(`
+		preserve: [Dragoman]
+		glossary:
+		  invoice: Rechnung
+		languages:
+		  german:
+		    formality: informal
+		    instructions: [Use short sentences.]
+		    glossary:
+		      invoice: Faktura
+		      account: Konto
+		  Japanese:
+		    preserve: [Modernice]
+		targets:
+		  - source: en.json
+		    out: de.json
+		    to: German
+		    instructions: [Keep it friendly.]
+		  - source: en.json
+		    out: ja.json
+		    to: Japanese
+		    formality: formal
+	`)))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	german := cfg.Resolve(cfg.Targets[0])
+	wantGerman := config.Defaults{
+		To:           "German",
+		Formality:    "informal",
+		Preserve:     []string{"Dragoman"},
+		Instructions: []string{"Use short sentences.", "Keep it friendly."},
+		Context:      []string{},
+		Glossary:     map[string]string{"invoice": "Faktura", "account": "Konto"},
+	}
+	if !cmp.Equal(wantGerman, german.Defaults) {
+		t.Fatalf("Resolve() mismatch (-want +got):\n%s", cmp.Diff(wantGerman, german.Defaults))
+	}
+
+	japanese := cfg.Resolve(cfg.Targets[1])
+	wantJapanese := config.Defaults{
+		To:           "Japanese",
+		Formality:    "formal",
+		Preserve:     []string{"Dragoman", "Modernice"},
+		Instructions: []string{},
+		Context:      []string{},
+		Glossary:     map[string]string{"invoice": "Rechnung"},
+	}
+	if !cmp.Equal(wantJapanese, japanese.Defaults) {
+		t.Fatalf("Resolve() mismatch (-want +got):\n%s", cmp.Diff(wantJapanese, japanese.Defaults))
+	}
+
+	if _, ok := cfg.Profile("French"); ok {
+		t.Fatalf("Profile() should not return a profile for an undeclared language")
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":        "targets: [{source: a, out: b}]\nunknown: true\n",
+		"unsupported provider": "provider: foo\ntargets: [{source: a, out: b}]\n",
+		"base url of openai":   "provider: openai\nbase_url: http://localhost:8000/v1\ntargets: [{source: a, out: b}]\n",
+		"missing out":          "targets: [{source: a}]\n",
+		"invalid formality":    "languages: {German: {formality: casual}}\ntargets: [{source: a, out: b}]\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := config.Parse([]byte(data)); err == nil {
+				t.Fatalf("Parse() should fail")
+			}
+		})
+	}
+}
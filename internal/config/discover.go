@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/modernice/dragoman/lang"
+)
+
+// LocaleDirs are the names of the directories that are searched for locale
+// files by [Discover].
+var LocaleDirs = []string{"locales", "locale", "i18n", "lang", "translations"}
+
+var localeExts = map[string]bool{".json": true, ".yml": true, ".yaml": true}
+
+// ErrNoLocales is returned by [Discover] if no known locale directory layout
+// was found.
+var ErrNoLocales = errors.New("no locale files found")
+
+// Discover detects common locale directory layouts below the given root
+// directory and returns a target for every namespace and target language.
+// Supported layouts are a file per language (e.g. "locales/en.json" or
+// "lang/en.yml"), a directory per language that contains a file per
+// namespace, also in nested directories (e.g. "lang/en/common.json" or
+// "lang/en/admin/users.json"), and a file per namespace and language (e.g.
+// "i18n/common.en.yaml"). Only file and directory names that are language
+// tags, like "en", "pt-BR" or "zh_Hant", are considered locales.
+//
+// If from is empty, "en" is used as the source locale if it exists, or the
+// only locale if there is exactly one. If to is empty, the source locale is
+// translated to every other locale that was found. JSON targets are updated,
+// so that only missing keys are translated.
+func Discover(root, from string, to []string) ([]Target, error) {
+	for _, name := range LocaleDirs {
+		dir := filepath.Join(root, name)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		locales, err := findLocales(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(locales) == 0 {
+			continue
+		}
+
+		source, err := sourceLocale(locales, from)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+
+		if len(to) == 0 {
+			to = otherLocales(locales, source)
+		}
+
+		var targets []Target
+		for _, lang := range to {
+			for _, path := range locales[source] {
+				out := localePath(dir, path, source, lang)
+				targets = append(targets, Target{
+					Defaults: Defaults{From: source, To: lang},
+					Source:   path,
+					Out:      out,
+					Update:   strings.EqualFold(filepath.Ext(path), ".json"),
+				})
+			}
+		}
+
+		return targets, nil
+	}
+
+	return nil, ErrNoLocales
+}
+
+// findLocales returns the locale files of a locale directory, grouped by their
+// language.
+func findLocales(dir string) (map[string][]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read locale directory: %w", err)
+	}
+
+	locales := make(map[string][]string)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		if !entry.IsDir() {
+			if lang, ok := fileLocale(entry.Name()); ok {
+				locales[lang] = append(locales[lang], path)
+			}
+			continue
+		}
+
+		if !isLocale(entry.Name()) {
+			continue
+		}
+
+		if err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && localeExts[strings.ToLower(filepath.Ext(file))] {
+				locales[entry.Name()] = append(locales[entry.Name()], file)
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("read locale directory: %w", err)
+		}
+	}
+
+	for _, files := range locales {
+		sort.Strings(files)
+	}
+
+	return locales, nil
+}
+
+// fileLocale returns the locale of a locale file that is named after its
+// language (e.g. "en.json") or after its namespace and language (e.g.
+// "common.en.yaml").
+func fileLocale(name string) (string, bool) {
+	ext := filepath.Ext(name)
+	if !localeExts[strings.ToLower(ext)] {
+		return "", false
+	}
+
+	name = strings.TrimSuffix(name, ext)
+	if isLocale(name) {
+		return name, true
+	}
+
+	if i := strings.LastIndex(name, "."); i > 0 && isLocale(name[i+1:]) {
+		return name[i+1:], true
+	}
+
+	return "", false
+}
+
+// isLocale reports whether name is the tag of a known language.
+func isLocale(name string) bool {
+	l, err := lang.Parse(name)
+	return err == nil && strings.EqualFold(l.Tag, strings.ReplaceAll(name, "_", "-"))
+}
+
+// otherLocales returns the locales other than the source locale, sorted.
+func otherLocales(locales map[string][]string, source string) []string {
+	var out []string
+	for lang := range locales {
+		if lang != source {
+			out = append(out, lang)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sourceLocale(locales map[string][]string, from string) (string, error) {
+	if from != "" {
+		if _, ok := locales[from]; !ok {
+			return "", fmt.Errorf("source locale %q not found", from)
+		}
+		return from, nil
+	}
+
+	if _, ok := locales["en"]; ok {
+		return "en", nil
+	}
+
+	if len(locales) == 1 {
+		for lang := range locales {
+			return lang, nil
+		}
+	}
+
+	return "", errors.New("cannot determine the source locale")
+}
+
+// localePath returns the path of the locale file of the target language that
+// corresponds to the given file of the source language.
+func localePath(dir, path, source, target string) string {
+	rel, _ := filepath.Rel(dir, path)
+
+	if first, rest, ok := strings.Cut(rel, string(filepath.Separator)); ok && first == source {
+		return filepath.Join(dir, target, rest)
+	}
+
+	ext := filepath.Ext(rel)
+	if namespace, ok := strings.CutSuffix(strings.TrimSuffix(rel, ext), "."+source); ok {
+		return filepath.Join(dir, namespace+"."+target+ext)
+	}
+
+	return filepath.Join(dir, target+strings.TrimPrefix(rel, source))
+}
@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/internal/config"
+)
+
+func TestDiscover(t *testing.T) {
+	tests := map[string]struct {
+		files []string
+		from  string
+		to    []string
+		want  []config.Target
+	}{
+		"file per language": {
+			files: []string{"locales/en.json", "locales/de.json"},
+			want: []config.Target{
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "locales/en.json", Out: "locales/fr.json", Update: true},
+			},
+		},
+		"directory per language": {
+			files: []string{"i18n/en/common.json", "i18n/en/errors.json"},
+			want: []config.Target{
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "i18n/en/common.json", Out: "i18n/fr/common.json", Update: true},
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "i18n/en/errors.json", Out: "i18n/fr/errors.json", Update: true},
+			},
+		},
+		"yaml with explicit source": {
+			files: []string{"lang/de.yml", "lang/en.yml"},
+			from:  "de",
+			want: []config.Target{
+				{Defaults: config.Defaults{From: "de", To: "fr"}, Source: "lang/de.yml", Out: "lang/fr.yml"},
+			},
+		},
+		"nested namespaces": {
+			files: []string{"lang/en/common.json", "lang/en/admin/users.json", "lang/README.md"},
+			want: []config.Target{
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "lang/en/admin/users.json", Out: "lang/fr/admin/users.json", Update: true},
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "lang/en/common.json", Out: "lang/fr/common.json", Update: true},
+			},
+		},
+		"file per namespace and language": {
+			files: []string{"i18n/common.en.yaml", "i18n/errors.en.yaml", "i18n/common.de.yaml", "i18n/config.yaml"},
+			want: []config.Target{
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "i18n/common.en.yaml", Out: "i18n/common.fr.yaml"},
+				{Defaults: config.Defaults{From: "en", To: "fr"}, Source: "i18n/errors.en.yaml", Out: "i18n/errors.fr.yaml"},
+			},
+		},
+		"all other locales": {
+			files: []string{"locales/en/common.json", "locales/pt-BR/common.json", "locales/de/common.json", "locales/shared/common.json"},
+			to:    []string{},
+			want: []config.Target{
+				{Defaults: config.Defaults{From: "en", To: "de"}, Source: "locales/en/common.json", Out: "locales/de/common.json", Update: true},
+				{Defaults: config.Defaults{From: "en", To: "pt-BR"}, Source: "locales/en/common.json", Out: "locales/pt-BR/common.json", Update: true},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, file := range tt.files {
+				path := filepath.Join(root, file)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			to := tt.to
+			if to == nil {
+				to = []string{"fr"}
+			}
+
+			targets, err := config.Discover(root, tt.from, to)
+			if err != nil {
+				t.Fatalf("Discover(): %v", err)
+			}
+
+			for i := range tt.want {
+				tt.want[i].Source = filepath.Join(root, tt.want[i].Source)
+				tt.want[i].Out = filepath.Join(root, tt.want[i].Out)
+			}
+
+			if !cmp.Equal(tt.want, targets) {
+				t.Fatalf("Discover() mismatch (-want +got):\n%s", cmp.Diff(tt.want, targets))
+			}
+		})
+	}
+}
+
+func TestDiscover_noLocales(t *testing.T) {
+	if _, err := config.Discover(t.TempDir(), "", []string{"fr"}); !errors.Is(err, config.ErrNoLocales) {
+		t.Fatalf("Discover() should return %v; got %v", config.ErrNoLocales, err)
+	}
+}
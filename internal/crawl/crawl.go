@@ -0,0 +1,346 @@
+// Package crawl discovers the pages of a website and maps them to the files of
+// a mirrored directory tree.
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxSitemapDepth is the maximum nesting of sitemap indexes.
+const maxSitemapDepth = 3
+
+// Page is a page of a website.
+type Page struct {
+	// URL is the normalized URL of the page, without query and fragment.
+	URL *url.URL
+
+	// Body is the HTML of the page if it was already fetched during the
+	// discovery, or nil otherwise.
+	Body []byte
+}
+
+// Crawler discovers and fetches the pages of a website.
+type Crawler struct {
+	// Client is the HTTP client of the requests. Defaults to
+	// [http.DefaultClient].
+	Client *http.Client
+
+	// Limit is the maximum number of discovered pages. Zero means no limit.
+	Limit int
+}
+
+// Discover returns the pages of the website at the given URL. If the URL is a
+// sitemap (a URL that ends with ".xml"), the pages listed in the sitemap are
+// returned. Otherwise, the pages of the "/sitemap.xml" of the site below the
+// path of the URL are returned if the sitemap exists, and the pages are
+// discovered by following the links of the page at the URL if it does not.
+// Only pages on the host of the URL are returned.
+func (c *Crawler) Discover(ctx context.Context, rawURL string) ([]Page, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL %q: expected an http or https URL", rawURL)
+	}
+
+	if strings.HasSuffix(strings.ToLower(base.Path), ".xml") {
+		return c.sitemap(ctx, base, "/", 0)
+	}
+
+	prefix := strings.TrimSuffix(base.Path, "/")
+	if pages, err := c.sitemap(ctx, base.ResolveReference(&url.URL{Path: "/sitemap.xml"}), prefix+"/", 0); err == nil && len(pages) > 0 {
+		return pages, nil
+	}
+
+	return c.follow(ctx, base, prefix)
+}
+
+// sitemap returns the pages of a sitemap whose paths start with the prefix.
+// The sitemaps of sitemap indexes are read recursively.
+func (c *Crawler) sitemap(ctx context.Context, sitemapURL *url.URL, prefix string, depth int) ([]Page, error) {
+	body, _, err := c.Fetch(ctx, sitemapURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		URLs     []string `xml:"url>loc"`
+		Sitemaps []string `xml:"sitemap>loc"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse sitemap %q: %w", sitemapURL, err)
+	}
+
+	var pages []Page
+	seen := make(map[string]bool)
+	add := func(loc string) bool {
+		u, err := url.Parse(strings.TrimSpace(loc))
+		if err != nil || u.Host != sitemapURL.Host || !underPrefix(u.Path, prefix) {
+			return true
+		}
+		u = Normalize(u)
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			pages = append(pages, Page{URL: u})
+		}
+		return c.Limit <= 0 || len(pages) < c.Limit
+	}
+
+	for _, loc := range doc.URLs {
+		if !add(loc) {
+			return pages, nil
+		}
+	}
+
+	if depth >= maxSitemapDepth {
+		return pages, nil
+	}
+
+	for _, loc := range doc.Sitemaps {
+		u, err := sitemapURL.Parse(strings.TrimSpace(loc))
+		if err != nil {
+			continue
+		}
+		nested, err := c.sitemap(ctx, u, prefix, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range nested {
+			if !add(page.URL.String()) {
+				return pages, nil
+			}
+		}
+	}
+
+	return pages, nil
+}
+
+// follow discovers the pages of a website by following the links of the pages,
+// breadth-first, starting at the base URL. Only links on the host of the base
+// URL whose paths start with the prefix are followed.
+func (c *Crawler) follow(ctx context.Context, base *url.URL, prefix string) ([]Page, error) {
+	start := Normalize(base)
+	queue := []*url.URL{start}
+	seen := map[string]bool{start.String(): true}
+
+	var pages []Page
+	for len(queue) > 0 && (c.Limit <= 0 || len(pages) < c.Limit) {
+		u := queue[0]
+		queue = queue[1:]
+
+		body, mediaType, err := c.Fetch(ctx, u.String())
+		if err != nil {
+			if len(pages) == 0 {
+				return nil, err
+			}
+			continue
+		}
+		if mediaType != "text/html" {
+			continue
+		}
+		pages = append(pages, Page{URL: u, Body: body})
+
+		for _, link := range links(body, u) {
+			if link.Host != base.Host || !underPrefix(link.Path, prefix+"/") {
+				continue
+			}
+			if link = Normalize(link); !seen[link.String()] {
+				seen[link.String()] = true
+				queue = append(queue, link)
+			}
+		}
+	}
+
+	return pages, nil
+}
+
+// Fetch fetches the given URL and returns the body and the media type of the
+// response. Responses with a status other than 200 are errors.
+func (c *Crawler) Fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("create request: %w", err)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch %q: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetch %q: %s", rawURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read %q: %w", rawURL, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	return body, mediaType, nil
+}
+
+// Normalize returns a copy of the URL without query, fragment and user info.
+// An empty path is replaced with "/".
+func Normalize(u *url.URL) *url.URL {
+	out := *u
+	out.RawQuery = ""
+	out.Fragment = ""
+	out.RawFragment = ""
+	out.User = nil
+	if out.Path == "" {
+		out.Path = "/"
+		out.RawPath = ""
+	}
+	return &out
+}
+
+// LocalPath returns the slash-separated path of the file of a page in the
+// mirrored directory tree. Pages whose path ends with ".html" or ".htm" keep
+// their path; the files of other pages are named "index.html" in a directory
+// of their path, e.g. "about/index.html" for "/about" and "/about/".
+func LocalPath(u *url.URL) string {
+	p := path.Clean("/" + u.Path)
+	switch strings.ToLower(path.Ext(p)) {
+	case ".html", ".htm":
+		return strings.TrimPrefix(p, "/")
+	}
+	return strings.TrimPrefix(path.Join(p, "index.html"), "/")
+}
+
+// Rewrite rewrites the URLs of a page that was mirrored to the local path of
+// the given URL. Links to other mirrored pages, given by their normalized URL
+// and local path, point to their local files. Other relative URLs of links,
+// stylesheets, scripts, images and forms are made absolute, so that the local
+// page still loads its assets from the website.
+func Rewrite(doc []byte, page *url.URL, pages map[string]string) ([]byte, error) {
+	root, err := html.Parse(bytes.NewReader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("parse HTML: %w", err)
+	}
+
+	from := path.Dir(LocalPath(page))
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for i, attr := range n.Attr {
+				if attr.Namespace != "" || !isURLAttribute(n.Data, attr.Key) {
+					continue
+				}
+				n.Attr[i].Val = rewriteURL(attr.Val, page, from, pages)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, root); err != nil {
+		return nil, fmt.Errorf("render HTML: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func rewriteURL(value string, page *url.URL, from string, pages map[string]string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return value
+	}
+
+	target, err := page.Parse(trimmed)
+	if err != nil || target.Scheme != "http" && target.Scheme != "https" {
+		return value
+	}
+
+	if local, ok := pages[Normalize(target).String()]; ok {
+		rel, err := filepath.Rel(filepath.FromSlash(from), filepath.FromSlash(local))
+		if err == nil {
+			out := filepath.ToSlash(rel)
+			if target.Fragment != "" {
+				out += "#" + target.EscapedFragment()
+			}
+			return out
+		}
+	}
+
+	return target.String()
+}
+
+// isURLAttribute reports whether the attribute of the element holds a URL
+// that is rewritten by [Rewrite].
+func isURLAttribute(element, attr string) bool {
+	switch attr {
+	case "href":
+		return element == "a" || element == "area" || element == "link"
+	case "src":
+		return element != "input"
+	case "action":
+		return element == "form"
+	case "poster":
+		return element == "video"
+	default:
+		return false
+	}
+}
+
+// links returns the absolute URLs of the links of an HTML page.
+func links(body []byte, page *url.URL) []*url.URL {
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var out []*url.URL
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "area") {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				if u, err := page.Parse(strings.TrimSpace(attr.Val)); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+					out = append(out, u)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	return out
+}
+
+// underPrefix reports whether the path is below the prefix, which ends with a
+// slash, or is the prefix without the slash.
+func underPrefix(p, prefix string) bool {
+	if p == "" {
+		p = "/"
+	}
+	return strings.HasPrefix(p, prefix) || p == strings.TrimSuffix(prefix, "/")
+}
@@ -0,0 +1,149 @@
+package crawl_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/internal/crawl"
+)
+
+func TestCrawler_Discover_sitemap(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/pages.xml</loc></sitemap></sitemapindex>`, srv.URL)
+		case "/pages.xml":
+			fmt.Fprintf(w, `<urlset>
+				<url><loc>%[1]s/</loc></url>
+				<url><loc>%[1]s/docs/intro#top</loc></url>
+				<url><loc>%[1]s/docs/setup</loc></url>
+				<url><loc>https://other.example/docs/</loc></url>
+			</urlset>`, srv.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := map[string]struct {
+		url   string
+		limit int
+		want  []string
+	}{
+		"sitemap":      {url: srv.URL + "/sitemap.xml", want: []string{"/", "/docs/intro", "/docs/setup"}},
+		"limit":        {url: srv.URL + "/sitemap.xml", limit: 2, want: []string{"/", "/docs/intro"}},
+		"base URL":     {url: srv.URL, want: []string{"/", "/docs/intro", "/docs/setup"}},
+		"path of base": {url: srv.URL + "/docs/", want: []string{"/docs/intro", "/docs/setup"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := crawl.Crawler{Limit: tt.limit}
+			pages, err := c.Discover(context.Background(), tt.url)
+			if err != nil {
+				t.Fatalf("Discover(): %v", err)
+			}
+
+			if got := paths(pages); !cmp.Equal(tt.want, got) {
+				t.Fatalf("Discover() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestCrawler_Discover_links(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<a href="/about">About</a> <a href="blog/?page=2#latest">Blog</a> <a href="/logo.png">Logo</a> <a href="https://other.example/">Other</a> <a href="mailto:hi@example.com">Mail</a>`)
+		case "/about", "/blog/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<a href="/">Home</a> <a href="/about">About</a>`)
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var c crawl.Crawler
+	pages, err := c.Discover(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Discover(): %v", err)
+	}
+
+	if want, got := []string{"/", "/about", "/blog/"}, paths(pages); !cmp.Equal(want, got) {
+		t.Fatalf("Discover() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	for _, page := range pages {
+		if len(page.Body) == 0 {
+			t.Fatalf("Body of %q should be fetched during the discovery", page.URL)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := map[string]string{
+		"/":                 "index.html",
+		"/about":            "about/index.html",
+		"/about/":           "about/index.html",
+		"/docs/setup.html":  "docs/setup.html",
+		"/shop/item.php":    "shop/item.php/index.html",
+		"/../../etc/passwd": "etc/passwd/index.html",
+	}
+
+	for p, want := range tests {
+		if got := crawl.LocalPath(&url.URL{Path: p}); got != want {
+			t.Errorf("LocalPath(%q) should return %q; got %q", p, want, got)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	page, _ := url.Parse("https://example.com/docs/intro")
+	pages := map[string]string{
+		"https://example.com/":           "index.html",
+		"https://example.com/docs/intro": "docs/intro/index.html",
+		"https://example.com/docs/setup": "docs/setup/index.html",
+	}
+
+	doc := `<html><head><link rel="stylesheet" href="../style.css"></head><body>` +
+		`<a href="/">Home</a> <a href="setup#install">Setup</a> <a href="#top">Top</a> ` +
+		`<a href="mailto:hi@example.com">Mail</a> <img src="/img/logo.png"></body></html>`
+
+	out, err := crawl.Rewrite([]byte(doc), page, pages)
+	if err != nil {
+		t.Fatalf("Rewrite(): %v", err)
+	}
+
+	for _, want := range []string{
+		`href="https://example.com/style.css"`,
+		`href="../../index.html"`,
+		`href="../setup/index.html#install"`,
+		`href="#top"`,
+		`href="mailto:hi@example.com"`,
+		`src="https://example.com/img/logo.png"`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Rewrite() should contain %s; got\n\n%s", want, out)
+		}
+	}
+}
+
+func paths(pages []crawl.Page) []string {
+	var out []string
+	for _, page := range pages {
+		out = append(out, page.URL.Path)
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+// Package jobstate records the progress of multi-chunk translations, so that
+// an interrupted translation can be resumed at its first untranslated chunk
+// instead of translating the completed chunks again.
+package jobstate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/modernice/dragoman/internal/atomicfile"
+)
+
+// DefaultFile is the name of the state file that is created next to the
+// output files of translations.
+const DefaultFile = ".dragoman-state.json"
+
+// State records the unfinished translations of a directory.
+type State struct {
+	// Jobs maps the names of the output files to their unfinished
+	// translations.
+	Jobs map[string]Job `json:"jobs"`
+}
+
+// Job is the progress of a single translation.
+type Job struct {
+	// Fingerprint identifies the source document and the settings of the
+	// translation. A job can only be resumed by a translation with the same
+	// fingerprint.
+	Fingerprint string `json:"fingerprint"`
+
+	// Chunks is the number of chunks of the document.
+	Chunks int `json:"chunks"`
+
+	// Translations maps the 1-based numbers of the translated chunks to their
+	// translations.
+	Translations map[int]string `json:"translations"`
+
+	// Updated is the time at which the last chunk was translated.
+	Updated time.Time `json:"updated"`
+}
+
+// Load reads the state at the given path. A missing state file is not an
+// error; an empty state is returned instead.
+func Load(path string) (*State, error) {
+	s := &State{Jobs: make(map[string]Job)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("parse state %q: %w", path, err)
+	}
+
+	if s.Jobs == nil {
+		s.Jobs = make(map[string]Job)
+	}
+
+	return s, nil
+}
+
+// Save writes the state to the given path. The file is replaced atomically, so
+// that a crash while saving does not lose the recorded progress. If the state
+// has no jobs, the file is removed instead.
+func (s *State) Save(path string) error {
+	if len(s.Jobs) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove state: %w", err)
+		}
+		return nil
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+
+	return nil
+}
+
+// Update loads the state at the given path, applies fn to it and saves it,
+// while it holds the lock of the state file, so that concurrent translations
+// to the same directory do not lose each other's progress.
+func Update(ctx context.Context, path string, fn func(*State)) error {
+	unlock, err := atomicfile.Lock(ctx, path, nil)
+	if err != nil {
+		return fmt.Errorf("lock state: %w", err)
+	}
+	defer unlock()
+
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	fn(s)
+
+	return s.Save(path)
+}
+
+// Fingerprint returns the fingerprint of a translation. v must contain the
+// source document and every setting that affects the translation.
+func Fingerprint(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal fingerprint: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Resume returns the translated chunks of the job of the given output file. ok
+// is false if there is no job for the output file or if the job was started
+// with a different fingerprint.
+func (s *State) Resume(out, fingerprint string) (translations map[int]string, ok bool) {
+	job, exists := s.Jobs[out]
+	if !exists || job.Fingerprint != fingerprint {
+		return nil, false
+	}
+	return job.Translations, true
+}
+
+// ChunkDone records the translation of a chunk of the job of the given output
+// file. A job with a different fingerprint is replaced.
+func (s *State) ChunkDone(out, fingerprint string, chunk, chunks int, translation string, at time.Time) {
+	job, ok := s.Jobs[out]
+	if !ok || job.Fingerprint != fingerprint {
+		job = Job{Fingerprint: fingerprint, Translations: make(map[int]string)}
+	}
+
+	job.Chunks = chunks
+	job.Translations[chunk] = translation
+	job.Updated = at
+	s.Jobs[out] = job
+}
+
+// Done removes the job of the given output file because its translation is
+// complete.
+func (s *State) Done(out string) {
+	delete(s.Jobs, out)
+}
@@ -0,0 +1,94 @@
+package jobstate_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/internal/jobstate"
+)
+
+func TestState_Resume(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	s := &jobstate.State{Jobs: map[string]jobstate.Job{}}
+	s.ChunkDone("de.md", "abc", 1, 3, "Erster", now)
+	s.ChunkDone("de.md", "abc", 2, 3, "Zweiter", now)
+
+	got, ok := s.Resume("de.md", "abc")
+	if !ok {
+		t.Fatalf("Resume() should find the job")
+	}
+	if want := map[int]string{1: "Erster", 2: "Zweiter"}; !cmp.Equal(want, got) {
+		t.Fatalf("Resume() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if _, ok := s.Resume("de.md", "changed"); ok {
+		t.Fatalf("Resume() should not resume a job with a different fingerprint")
+	}
+	if _, ok := s.Resume("fr.md", "abc"); ok {
+		t.Fatalf("Resume() should not resume the job of another output file")
+	}
+
+	// A translation with a different fingerprint starts over.
+	s.ChunkDone("de.md", "changed", 1, 2, "Neu", now)
+	got, _ = s.Resume("de.md", "changed")
+	if want := map[int]string{1: "Neu"}; !cmp.Equal(want, got) {
+		t.Fatalf("Resume() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestLoad_roundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), jobstate.DefaultFile)
+
+	s, err := jobstate.Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing state failed: %v", err)
+	}
+	if len(s.Jobs) != 0 {
+		t.Fatalf("missing state should be empty; got %d jobs", len(s.Jobs))
+	}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	s.ChunkDone("de.md", "abc", 1, 2, "Erster", now)
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := jobstate.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cmp.Equal(s, loaded) {
+		t.Fatalf("loaded state mismatch (-want +got):\n%s", cmp.Diff(s, loaded))
+	}
+
+	// Saving a state without jobs removes the file.
+	loaded.Done("de.md")
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("state file should be removed; got %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := jobstate.Fingerprint(map[string]any{"document": "Hello", "target": "German"})
+	if err != nil {
+		t.Fatalf("Fingerprint() failed: %v", err)
+	}
+	b, _ := jobstate.Fingerprint(map[string]any{"document": "Hello", "target": "German"})
+	c, _ := jobstate.Fingerprint(map[string]any{"document": "Hello", "target": "French"})
+
+	if a != b {
+		t.Fatalf("fingerprints of equal translations should be equal")
+	}
+	if a == c {
+		t.Fatalf("fingerprints of different translations should differ")
+	}
+}
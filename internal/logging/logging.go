@@ -0,0 +1,89 @@
+// Package logging provides the [slog.Handler]s that are used by the clients of
+// the providers if no logger is configured.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Discard returns a logger that discards all records.
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Handler writes records as human-readable lines, like
+// "[OpenAI] retry request attempt=1 delay=2s". String attributes that span
+// multiple lines, like prompts, are written below the line. It is used for the
+// verbose output of the CLI.
+type Handler struct {
+	mux    *sync.Mutex
+	w      io.Writer
+	prefix string
+	attrs  []slog.Attr
+}
+
+// NewHandler returns a [Handler] that writes all records, including debug
+// records, to w and prefixes them with the given prefix in square brackets.
+func NewHandler(w io.Writer, prefix string) *Handler {
+	return &Handler{mux: &sync.Mutex{}, w: w, prefix: prefix}
+}
+
+// Enabled implements [slog.Handler]. All levels are enabled.
+func (h *Handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements [slog.Handler].
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	var line, blocks strings.Builder
+	fmt.Fprintf(&line, "[%s] ", h.prefix)
+	if r.Level >= slog.LevelWarn {
+		fmt.Fprintf(&line, "%s: ", r.Level)
+	}
+	line.WriteString(r.Message)
+
+	write := func(a slog.Attr) bool {
+		value := a.Value.Resolve().String()
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(&blocks, "%s:\n\n%s\n", a.Key, value)
+			return true
+		}
+		fmt.Fprintf(&line, " %s=%s", a.Key, value)
+		return true
+	}
+	for _, a := range h.attrs {
+		write(a)
+	}
+	r.Attrs(write)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	_, err := fmt.Fprintf(h.w, "%s\n%s", line.String(), blocks.String())
+	return err
+}
+
+// WithAttrs implements [slog.Handler].
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &c
+}
+
+// WithGroup implements [slog.Handler]. Groups are not supported; the
+// attributes of groups are written without the group name.
+func (h *Handler) WithGroup(string) slog.Handler {
+	return h
+}
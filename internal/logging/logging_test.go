@@ -0,0 +1,33 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/modernice/dragoman/internal/logging"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(logging.NewHandler(&buf, "OpenAI")).With("model", "gpt-4o")
+
+	logger.Debug("create chat completion", "timeout", 3*time.Minute, "prompt", "Translate:\nHello")
+	logger.Warn("retry request", "attempt", 1)
+
+	want := "[OpenAI] create chat completion model=gpt-4o timeout=3m0s\n" +
+		"prompt:\n\nTranslate:\nHello\n" +
+		"[OpenAI] WARN: retry request model=gpt-4o attempt=1\n"
+
+	if got := buf.String(); got != want {
+		t.Fatalf("Handler wrote\n\n%s\n\nwant\n\n%s", got, want)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	if logging.Discard().Enabled(context.Background(), slog.LevelError) {
+		t.Fatalf("Discard() should not enable any level")
+	}
+}
@@ -0,0 +1,134 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/modernice/dragoman/internal/atomicfile"
+)
+
+// DefaultFile is the name of the manifest file that is created in the
+// improved directory.
+const DefaultFile = ".dragoman-improve.json"
+
+// Manifest records when the documents of a directory were last improved and
+// what their content was after the improvement, so that large knowledge bases
+// can be improved gradually over many runs.
+type Manifest struct {
+	// Documents maps the slash-separated paths of the documents, relative to
+	// the improved directory, to their state.
+	Documents map[string]Document `json:"documents"`
+}
+
+// Document is the state of a single document after its last improvement.
+type Document struct {
+	// Hash is the hash of the improved content. A document whose content no
+	// longer matches the hash was changed since it was improved.
+	Hash string `json:"hash"`
+
+	// Improved is the time of the last improvement.
+	Improved time.Time `json:"improved"`
+}
+
+// Load reads the manifest at the given path. A missing manifest is not an
+// error; an empty manifest is returned instead.
+func Load(path string) (*Manifest, error) {
+	m := &Manifest{Documents: make(map[string]Document)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("parse manifest %q: %w", path, err)
+	}
+
+	if m.Documents == nil {
+		m.Documents = make(map[string]Document)
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest to the given path.
+func (m *Manifest) Save(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Hash returns the hash of the given document content.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Improved records that the document at the given path was improved at the
+// given time and now has the given content.
+func (m *Manifest) Improved(path string, content []byte, at time.Time) {
+	m.Documents[path] = Document{Hash: Hash(content), Improved: at}
+}
+
+// Prune removes the documents that are not in the given list of paths, for
+// example because they were deleted.
+func (m *Manifest) Prune(paths []string) {
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+
+	for path := range m.Documents {
+		if !keep[path] {
+			delete(m.Documents, path)
+		}
+	}
+}
+
+// Queue returns the paths of the documents that are due for an improvement, in
+// the order in which they should be improved. hashes maps the paths of all
+// documents to the hashes of their current content.
+//
+// Documents that were never improved or that changed since their last
+// improvement come first, sorted by path. They are followed by the unchanged
+// documents that were last improved before now minus minAge, oldest first.
+func (m *Manifest) Queue(hashes map[string]string, now time.Time, minAge time.Duration) []string {
+	var changed, stale []string
+	for path, hash := range hashes {
+		doc, ok := m.Documents[path]
+		switch {
+		case !ok || doc.Hash != hash:
+			changed = append(changed, path)
+		case !doc.Improved.After(now.Add(-minAge)):
+			stale = append(stale, path)
+		}
+	}
+
+	sort.Strings(changed)
+	sort.Slice(stale, func(i, j int) bool {
+		a, b := m.Documents[stale[i]].Improved, m.Documents[stale[j]].Improved
+		if a.Equal(b) {
+			return stale[i] < stale[j]
+		}
+		return a.Before(b)
+	})
+
+	return append(changed, stale...)
+}
@@ -0,0 +1,81 @@
+package manifest_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/internal/manifest"
+)
+
+func TestManifest_Queue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	m := &manifest.Manifest{Documents: map[string]manifest.Document{
+		"a.md": {Hash: manifest.Hash([]byte("a")), Improved: now.Add(-48 * time.Hour)},
+		"b.md": {Hash: manifest.Hash([]byte("b")), Improved: now.Add(-72 * time.Hour)},
+		"c.md": {Hash: manifest.Hash([]byte("c")), Improved: now.Add(-time.Hour)},
+		"d.md": {Hash: manifest.Hash([]byte("d")), Improved: now.Add(-time.Hour)},
+	}}
+
+	hashes := map[string]string{
+		"a.md": manifest.Hash([]byte("a")),
+		"b.md": manifest.Hash([]byte("b")),
+		"c.md": manifest.Hash([]byte("c")),
+		"d.md": manifest.Hash([]byte("d changed")),
+		"e.md": manifest.Hash([]byte("e")),
+	}
+
+	tests := map[string]struct {
+		minAge time.Duration
+		want   []string
+	}{
+		"no minimum age": {
+			want: []string{"d.md", "e.md", "b.md", "a.md", "c.md"},
+		},
+		"minimum age": {
+			minAge: 24 * time.Hour,
+			want:   []string{"d.md", "e.md", "b.md", "a.md"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := m.Queue(hashes, now, tt.minAge)
+			if !cmp.Equal(tt.want, got) {
+				t.Fatalf("Queue() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestLoad_roundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), manifest.DefaultFile)
+
+	m, err := manifest.Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing manifest failed: %v", err)
+	}
+
+	at := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	m.Improved("docs/a.md", []byte("improved"), at)
+	m.Improved("docs/b.md", []byte("improved"), at)
+	m.Prune([]string{"docs/a.md"})
+
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := manifest.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	want := map[string]manifest.Document{
+		"docs/a.md": {Hash: manifest.Hash([]byte("improved")), Improved: at},
+	}
+	if !cmp.Equal(want, loaded.Documents) {
+		t.Fatalf("manifest mismatch (-want +got):\n%s", cmp.Diff(want, loaded.Documents))
+	}
+}
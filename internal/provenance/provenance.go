@@ -0,0 +1,134 @@
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/modernice/dragoman/internal/atomicfile"
+)
+
+// Suffix is appended to the path of a translated file to get the path of its
+// sidecar file.
+const Suffix = ".dragoman"
+
+// Path returns the path of the sidecar file of the translated file at the
+// given path, e.g. "de.json.dragoman" for "de.json".
+func Path(path string) string {
+	return path + Suffix
+}
+
+// Sidecar records the provenance of the machine-translated keys of a
+// translated file: which model translated them, with which prompt, when, and
+// what their source and translation were at that time. Keys that are not in
+// the sidecar were not translated by dragoman.
+type Sidecar struct {
+	// Keys maps the dot-separated key paths of the translated file to their
+	// provenance.
+	Keys map[string]Entry `json:"keys"`
+}
+
+// Entry is the provenance of a single machine-translated key.
+type Entry struct {
+	// Model is the model or provider that translated the key.
+	Model string `json:"model"`
+
+	// Prompt is the hash of the prompt settings the key was translated with,
+	// like the languages, instructions and context.
+	Prompt string `json:"prompt"`
+
+	// Source is the hash of the source value at the time of the translation.
+	Source string `json:"source"`
+
+	// Translation is the hash of the translated value.
+	Translation string `json:"translation"`
+
+	// Translated is the time of the translation.
+	Translated time.Time `json:"translated"`
+}
+
+// Load reads the sidecar file at the given path. A missing file is not an
+// error; an empty sidecar is returned instead.
+func Load(path string) (*Sidecar, error) {
+	s := &Sidecar{Keys: make(map[string]Entry)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sidecar: %w", err)
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("parse sidecar %q: %w", path, err)
+	}
+
+	if s.Keys == nil {
+		s.Keys = make(map[string]Entry)
+	}
+
+	return s, nil
+}
+
+// Save writes the sidecar to the given path.
+func (s *Sidecar) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sidecar: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("write sidecar: %w", err)
+	}
+
+	return nil
+}
+
+// Hash returns the hash of the given text.
+func Hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record records the provenance of a key that was translated from source to
+// translation.
+func (s *Sidecar) Record(key, source, translation string, entry Entry) {
+	entry.Source = Hash(source)
+	entry.Translation = Hash(translation)
+	s.Keys[key] = entry
+}
+
+// Stale reports whether the key was machine-translated from a source that
+// differs from the given source.
+func (s *Sidecar) Stale(key, source string) bool {
+	entry, ok := s.Keys[key]
+	return ok && entry.Source != Hash(source)
+}
+
+// Edited reports whether the key was machine-translated, but its translation
+// was changed since, for example by a human reviewer.
+func (s *Sidecar) Edited(key, translation string) bool {
+	entry, ok := s.Keys[key]
+	return ok && entry.Translation != Hash(translation)
+}
+
+// Prune removes the keys that are not in the given list of keys, for example
+// because they were removed from the translated file.
+func (s *Sidecar) Prune(keys []string) {
+	keep := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		keep[key] = true
+	}
+
+	for key := range s.Keys {
+		if !keep[key] {
+			delete(s.Keys, key)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package provenance_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman/internal/provenance"
+)
+
+func TestSidecar(t *testing.T) {
+	s := &provenance.Sidecar{Keys: map[string]provenance.Entry{}}
+	s.Record("title", "Hello", "Hallo", provenance.Entry{Model: "gpt-4o"})
+	s.Record("body", "World", "Welt", provenance.Entry{Model: "gpt-4o"})
+
+	if s.Stale("title", "Hello") {
+		t.Errorf("Stale() should report false for an unchanged source")
+	}
+	if !s.Stale("title", "Hello!") {
+		t.Errorf("Stale() should report true for a changed source")
+	}
+	if s.Stale("unknown", "Hello") {
+		t.Errorf("Stale() should report false for keys that were not machine-translated")
+	}
+
+	if s.Edited("title", "Hallo") {
+		t.Errorf("Edited() should report false for an unchanged translation")
+	}
+	if !s.Edited("title", "Servus") {
+		t.Errorf("Edited() should report true for a changed translation")
+	}
+
+	s.Prune([]string{"title"})
+	if _, ok := s.Keys["body"]; ok || len(s.Keys) != 1 {
+		t.Errorf("Prune() should remove the keys that are not in the list; got %v", s.Keys)
+	}
+}
+
+func TestLoad_roundTrip(t *testing.T) {
+	path := provenance.Path(filepath.Join(t.TempDir(), "de.json"))
+	if filepath.Base(path) != "de.json.dragoman" {
+		t.Fatalf("Path() should return %q; got %q", "de.json.dragoman", filepath.Base(path))
+	}
+
+	s, err := provenance.Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing sidecar failed: %v", err)
+	}
+	if len(s.Keys) != 0 {
+		t.Fatalf("Load() of a missing sidecar should return an empty sidecar; got %v", s.Keys)
+	}
+
+	s.Record("title", "Hello", "Hallo", provenance.Entry{
+		Model:      "gpt-4o",
+		Prompt:     provenance.Hash("prompt"),
+		Translated: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := provenance.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cmp.Equal(s, loaded) {
+		t.Fatalf("Load() mismatch (-want +got):\n%s", cmp.Diff(s, loaded))
+	}
+}
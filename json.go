@@ -3,12 +3,19 @@ package dragoman
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // JSONPath represents a sequence of keys that specify a unique path through a
 // JSON object hierarchy, similar to an address for locating a specific value
 // within a nested JSON structure. It is used to traverse and extract data from
-// complex JSON documents.
+// complex JSON documents. Elements of arrays are addressed by their index, so
+// that the path {"items", "2"} refers to the third element of the "items"
+// array.
 type JSONPath []string
 
 // JSONDiff identifies the differences between two JSON objects or two raw JSON
@@ -38,40 +45,58 @@ func JSONDiff[TInput []byte | map[string]any](source, target TInput) ([]JSONPath
 
 func jsonDiffPaths(source, target map[string]any) (paths []JSONPath, _ error) {
 	for k, v := range source {
-		switch v := v.(type) {
-		case map[string]any:
-			targetValue, ok := target[k]
-			if ok {
-				targetMap, ok := targetValue.(map[string]any)
-				if !ok {
-					return paths, fmt.Errorf("target value at %q is not a map", k)
-				}
-
-				subPaths, err := jsonDiffPaths(v, targetMap)
-				if err != nil {
-					return paths, err
-				}
-
-				subPaths = mapSlice(subPaths, func(p JSONPath) JSONPath {
-					return append(JSONPath{k}, p...)
-				})
-
-				paths = append(paths, subPaths...)
-			} else {
-				subKeys := allKeys(v)
-				subKeys = mapSlice(subKeys, func(p JSONPath) JSONPath {
-					return append(JSONPath{k}, p...)
-				})
-
-				paths = append(paths, subKeys...)
+		targetValue, ok := target[k]
+		if !ok {
+			paths = append(paths, prefixPaths(k, jsonLeaves(v))...)
+			continue
+		}
+
+		subPaths, err := jsonDiffValues(k, v, targetValue)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, subPaths...)
+	}
+	return
+}
+
+func jsonDiffValues(key string, source, target any) ([]JSONPath, error) {
+	switch source := source.(type) {
+	case map[string]any:
+		targetMap, ok := target.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("target value at %q is not a map", key)
+		}
+
+		subPaths, err := jsonDiffPaths(source, targetMap)
+		if err != nil {
+			return nil, err
+		}
+		return prefixPaths(key, subPaths), nil
+	case []any:
+		targetSlice, ok := target.([]any)
+		if !ok {
+			return nil, fmt.Errorf("target value at %q is not an array", key)
+		}
+
+		var paths []JSONPath
+		for i, v := range source {
+			index := strconv.Itoa(i)
+			if i >= len(targetSlice) || targetSlice[i] == nil && v != nil {
+				paths = append(paths, prefixPaths(key, prefixPaths(index, jsonLeaves(v)))...)
+				continue
 			}
-		default:
-			if _, ok := target[k]; !ok {
-				paths = append(paths, JSONPath{k})
+
+			subPaths, err := jsonDiffValues(index, v, targetSlice[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
 			}
+			paths = append(paths, prefixPaths(key, subPaths)...)
 		}
+		return paths, nil
+	default:
+		return nil, nil
 	}
-	return
 }
 
 // JSONExtract extracts values from a JSON document according to specified paths
@@ -109,52 +134,617 @@ func jsonExtract(data map[string]any, path JSONPath, out map[string]any) error {
 		return fmt.Errorf("key %q not found", key)
 	}
 
-	if len(path) == 1 {
-		out[key] = value
-		return nil
+	extracted, err := jsonExtractValue(value, path[1:], out[key])
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
 	}
+	out[key] = extracted
 
-	subPath := path[1:]
-	subMap, ok := value.(map[string]any)
-	if !ok {
-		return fmt.Errorf("value at %q is not a map", key)
-	}
+	return nil
+}
 
-	if _, ok := out[key]; !ok {
-		outSubMap := make(map[string]any)
-		out[key] = outSubMap
+// jsonExtractValue extracts the value at the path below value into out, which
+// is the previously extracted value at the same location, and returns the
+// result. Extracted array elements keep their index; elements that are not
+// extracted are set to nil.
+func jsonExtractValue(value any, path JSONPath, out any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
 	}
 
-	outSubMap := out[key].(map[string]any)
+	switch value := value.(type) {
+	case map[string]any:
+		outMap, ok := out.(map[string]any)
+		if !ok {
+			outMap = make(map[string]any)
+		}
+		return outMap, jsonExtract(value, path, outMap)
+	case []any:
+		index, err := jsonIndex(path[0], len(value))
+		if err != nil {
+			return nil, err
+		}
+
+		outSlice, _ := out.([]any)
+		for len(outSlice) <= index {
+			outSlice = append(outSlice, nil)
+		}
+
+		extracted, err := jsonExtractValue(value[index], path[1:], outSlice[index])
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", index, err)
+		}
+		outSlice[index] = extracted
 
-	return jsonExtract(subMap, subPath, outSubMap)
+		return outSlice, nil
+	default:
+		return nil, fmt.Errorf("value at %q is not a map or array", path[0])
+	}
 }
 
 // JSONMerge combines the contents of two JSON object maps, where 'from' is
 // merged into 'into'. If there are matching keys, the values from 'from' will
 // overwrite those in 'into'. For nested maps, merging is performed recursively.
-// This function modifies the 'into' map directly and does not return a new map.
+// Arrays are merged element-wise; nil elements of arrays in 'from' leave the
+// corresponding elements of 'into' unchanged. This function modifies the
+// 'into' map directly and does not return a new map.
 func JSONMerge(into map[string]any, from map[string]any) {
 	for k, v := range from {
-		switch v := v.(type) {
+		into[k] = jsonMergeValue(into[k], v)
+	}
+}
+
+func jsonMergeValue(into, from any) any {
+	switch from := from.(type) {
+	case map[string]any:
+		intoMap, ok := into.(map[string]any)
+		if !ok {
+			return from
+		}
+		JSONMerge(intoMap, from)
+		return intoMap
+	case []any:
+		intoSlice, ok := into.([]any)
+		if !ok {
+			return from
+		}
+		for i, v := range from {
+			if i >= len(intoSlice) {
+				intoSlice = append(intoSlice, v)
+				continue
+			}
+			if v != nil {
+				intoSlice[i] = jsonMergeValue(intoSlice[i], v)
+			}
+		}
+		return intoSlice
+	default:
+		return from
+	}
+}
+
+// JSONPrune removes the keys of the target document that do not exist in the
+// source document, so that keys that were removed from the source of a
+// translation do not linger in the translation. Arrays of the target that are
+// longer than their counterpart in the source are truncated. It returns the
+// paths of the removed values in sorted order. This function modifies the
+// target map directly.
+func JSONPrune(target, source map[string]any) []JSONPath {
+	var pruned []JSONPath
+	for k, v := range target {
+		sourceValue, ok := source[k]
+		if !ok {
+			delete(target, k)
+			pruned = append(pruned, JSONPath{k})
+			continue
+		}
+
+		var paths []JSONPath
+		target[k], paths = jsonPruneValue(v, sourceValue)
+		pruned = append(pruned, prefixPaths(k, paths)...)
+	}
+
+	sort.Slice(pruned, func(i, j int) bool {
+		return lessPath(pruned[i], pruned[j])
+	})
+
+	return pruned
+}
+
+// JSONDelete removes the values at the given paths from the document, like
+// the keys of chunks that were left untranslated. Objects that become empty
+// are removed as well, so that no empty objects are merged into a
+// translation. This function modifies the document directly.
+func JSONDelete(data map[string]any, paths []JSONPath) {
+	for _, path := range paths {
+		if len(path) == 0 || !jsonDelete(data, path) {
+			continue
+		}
+		for i := len(path) - 1; i > 0; i-- {
+			parent, ok := jsonValue(data, path[:i]).(map[string]any)
+			if !ok || len(parent) > 0 {
+				break
+			}
+			jsonDelete(data, path[:i])
+		}
+	}
+}
+
+func jsonPruneValue(target, source any) (any, []JSONPath) {
+	switch target := target.(type) {
+	case map[string]any:
+		sourceMap, ok := source.(map[string]any)
+		if !ok {
+			return target, nil
+		}
+		return target, JSONPrune(target, sourceMap)
+	case []any:
+		sourceSlice, ok := source.([]any)
+		if !ok {
+			return target, nil
+		}
+
+		var pruned []JSONPath
+		for i := len(sourceSlice); i < len(target); i++ {
+			pruned = append(pruned, JSONPath{strconv.Itoa(i)})
+		}
+		if len(target) > len(sourceSlice) {
+			target = target[:len(sourceSlice)]
+		}
+
+		for i, v := range target {
+			var paths []JSONPath
+			target[i], paths = jsonPruneValue(v, sourceSlice[i])
+			pruned = append(pruned, prefixPaths(strconv.Itoa(i), paths)...)
+		}
+		return target, pruned
+	default:
+		return target, nil
+	}
+}
+
+// JSONChanges returns the paths of the values of the current version of a
+// JSON document that were added or changed since the previous version, in
+// sorted order. Values that were removed are not returned.
+func JSONChanges(previous, current map[string]any) []JSONPath {
+	var changed []JSONPath
+	for _, path := range allKeys(current) {
+		if !reflect.DeepEqual(jsonValue(previous, path), jsonValue(current, path)) {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return lessPath(changed[i], changed[j])
+	})
+
+	return changed
+}
+
+// JSONFlatten returns a copy of the JSON object whose nested objects are
+// replaced with dot-separated keys, like the flat locale files of some i18n
+// libraries: {"home": {"title": "Home"}} becomes {"home.title": "Home"}.
+// Arrays and empty objects are kept as values. The provided object is not
+// modified.
+func JSONFlatten(doc map[string]any) map[string]any {
+	out := make(map[string]any)
+	jsonFlatten("", doc, out)
+	return out
+}
+
+func jsonFlatten(prefix string, doc map[string]any, out map[string]any) {
+	for k, v := range doc {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			jsonFlatten(key, m, out)
+			continue
+		}
+		out[key] = jsonCopyValue(v)
+	}
+}
+
+// JSONUnflatten returns a copy of the JSON object whose dot-separated keys are
+// replaced with nested objects, which reverses [JSONFlatten]:
+// {"home.title": "Home"} becomes {"home": {"title": "Home"}}. Keys of nested
+// objects are unflattened as well, and objects that are addressed by flat and
+// nested keys are merged. It returns an error if a key addresses a value that
+// is not an object, like "home.title" if "home" is a string. The provided
+// object is not modified.
+func JSONUnflatten(doc map[string]any) (map[string]any, error) {
+	out := make(map[string]any)
+	for k, v := range doc {
+		if m, ok := v.(map[string]any); ok {
+			nested, err := JSONUnflatten(m)
+			if err != nil {
+				return nil, fmt.Errorf("unflatten key %q: %w", k, err)
+			}
+			v = nested
+		} else {
+			v = jsonCopyValue(v)
+		}
+
+		if err := jsonUnflattenSet(out, strings.Split(k, "."), v); err != nil {
+			return nil, fmt.Errorf("unflatten key %q: %w", k, err)
+		}
+	}
+	return out, nil
+}
+
+func jsonUnflattenSet(out map[string]any, path []string, value any) error {
+	key := path[0]
+	if len(path) > 1 {
+		next, ok := out[key]
+		if !ok {
+			next = make(map[string]any)
+			out[key] = next
+		}
+		m, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%q is not an object", key)
+		}
+		return jsonUnflattenSet(m, path[1:], value)
+	}
+
+	existing, ok := out[key]
+	if !ok {
+		out[key] = value
+		return nil
+	}
+
+	existingMap, ok1 := existing.(map[string]any)
+	valueMap, ok2 := value.(map[string]any)
+	if !ok1 || !ok2 {
+		return fmt.Errorf("conflicting values of %q", key)
+	}
+	for k, v := range valueMap {
+		if err := jsonUnflattenSet(existingMap, []string{k}, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONDuplicate describes a string value of a JSON object that is identical to
+// the value at another path of the object. Path is the location of the removed
+// duplicate and Original is the location of the value that is kept.
+type JSONDuplicate struct {
+	Path     JSONPath
+	Original JSONPath
+}
+
+// JSONDeduplicate removes repeated string values from a JSON object, so that
+// each unique value is sent to the model only once. The first occurrence of a
+// value (in lexical order of the paths) is kept and every later occurrence is
+// removed from the returned copy of the object. The removed occurrences are
+// returned as [JSONDuplicate]s and can be restored into the translated object
+// using [JSONRestoreDuplicates]. The provided object is not modified.
+func JSONDeduplicate(doc map[string]any) (map[string]any, []JSONDuplicate) {
+	paths := allKeys(doc)
+	sort.Slice(paths, func(i, j int) bool {
+		return lessPath(paths[i], paths[j])
+	})
+
+	out := jsonCopy(doc)
+	seen := make(map[string]JSONPath)
+	var dups []JSONDuplicate
+	for _, path := range paths {
+		value, ok := jsonValue(doc, path).(string)
+		if !ok || value == "" {
+			continue
+		}
+
+		original, ok := seen[value]
+		if !ok {
+			seen[value] = path
+			continue
+		}
+
+		if jsonDelete(out, path) {
+			dups = append(dups, JSONDuplicate{Path: path, Original: original})
+		}
+	}
+
+	return out, dups
+}
+
+// JSONRestoreDuplicates fans the translated values of deduplicated strings back
+// out to all of their occurrences. It sets the value at the path of each
+// duplicate to the value at its original path. An error is returned if the
+// value at an original path is missing from the translated object.
+func JSONRestoreDuplicates(translated map[string]any, dups []JSONDuplicate) error {
+	for _, dup := range dups {
+		value := jsonValue(translated, dup.Original)
+		if value == nil {
+			return fmt.Errorf("missing translation of %v for duplicate %v", dup.Original, dup.Path)
+		}
+		jsonSet(translated, dup.Path, value)
+	}
+	return nil
+}
+
+// JSONOverrides maps the key paths of JSON string values, with keys separated
+// by dots (e.g. "legal.disclaimer"), to fixed, human-provided translations.
+type JSONOverrides map[string]string
+
+// Strip returns a copy of the JSON object without the overridden string values,
+// so that they are not sent to the model. Elements of arrays are kept, because
+// removing them would change the indices of the following elements. The
+// provided object is not modified.
+func (o JSONOverrides) Strip(doc map[string]any) map[string]any {
+	out := jsonCopy(doc)
+	for _, path := range o.paths(doc) {
+		jsonDelete(out, path)
+	}
+	return out
+}
+
+// Apply sets the overridden values of the translated JSON object to their fixed
+// translations. Only paths that are string values of the source object are
+// set.
+func (o JSONOverrides) Apply(source, translated map[string]any) {
+	for _, path := range o.paths(source) {
+		// Array elements cannot be stripped, so they are translated by the
+		// model and replaced in place.
+		jsonSetValue(translated, path, o[strings.Join(path, ".")])
+	}
+}
+
+func (o JSONOverrides) paths(doc map[string]any) []JSONPath {
+	var paths []JSONPath
+	for key := range o {
+		path := JSONPath(strings.Split(key, "."))
+		if _, ok := jsonValue(doc, path).(string); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// JSONFilter returns the values of a JSON document whose key paths match the
+// given patterns, so that only these values are translated. Keys of patterns
+// are separated by dots; "*" matches any part of a single key (e.g. "errors.*"
+// or "title_*") and "**" matches any number of keys (e.g. "**.title"). A
+// pattern also matches all values below a matching key, so "errors" selects
+// the whole "errors" object. Patterns that start with "!" exclude matching
+// values. A value is selected if it matches one of the other patterns, or if
+// there are none, and matches no excluding pattern. Elements of arrays are
+// matched by their index; elements that are not selected are set to nil, like
+// in [JSONExtract].
+func JSONFilter[TData []byte | map[string]any](data TData, patterns []string) (map[string]any, error) {
+	var dataMap map[string]any
+	switch data := any(data).(type) {
+	case []byte:
+		if err := json.Unmarshal(data, &dataMap); err != nil {
+			return nil, fmt.Errorf("unmarshal data: %w", err)
+		}
+	case map[string]any:
+		dataMap = data
+	}
+
+	var selected []JSONPath
+	for _, path := range allKeys(dataMap) {
+		if MatchJSONPath(path, patterns) {
+			selected = append(selected, path)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return lessPath(selected[i], selected[j]) })
+
+	return JSONExtract(dataMap, selected)
+}
+
+// MatchJSONPath reports whether the path is selected by the patterns. See
+// [JSONFilter] for the syntax of the patterns.
+func MatchJSONPath(path JSONPath, patterns []string) bool {
+	included, hasIncludes := false, false
+	for _, pattern := range patterns {
+		if exclude, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchJSONPattern(strings.Split(exclude, "."), path) {
+				return false
+			}
+			continue
+		}
+
+		hasIncludes = true
+		if matchJSONPattern(strings.Split(pattern, "."), path) {
+			included = true
+		}
+	}
+	return included || !hasIncludes
+}
+
+// matchJSONPattern reports whether the pattern matches the path or one of its
+// parents.
+func matchJSONPattern(pattern []string, path JSONPath) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+
+	if pattern[0] == "**" {
+		return matchJSONPattern(pattern[1:], path) || len(path) > 0 && matchJSONPattern(pattern, path[1:])
+	}
+
+	return len(path) > 0 && matchGlob(pattern[0], path[0]) && matchJSONPattern(pattern[1:], path[1:])
+}
+
+// matchGlob reports whether the key matches the pattern, in which "*" matches
+// any sequence of characters.
+func matchGlob(pattern, key string) bool {
+	before, after, ok := strings.Cut(pattern, "*")
+	if !ok {
+		return pattern == key
+	}
+
+	if !strings.HasPrefix(key, before) {
+		return false
+	}
+
+	rest := key[len(before):]
+	for i := 0; i <= len(rest); i++ {
+		if matchGlob(after, rest[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONSchema returns a JSON schema that describes the key tree of a JSON
+// document. Objects require exactly the keys of the document and arrays
+// require exactly as many elements, so that a translation that conforms to the
+// schema cannot add, remove or rename keys. It can be passed to models that
+// support structured output, like the StructuredOutput option of the openai
+// package. The root of the document may also be an array or a single value.
+func JSONSchema[TData []byte | map[string]any](data TData) (map[string]any, error) {
+	var value any
+	switch data := any(data).(type) {
+	case []byte:
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("unmarshal data: %w", err)
+		}
+	case map[string]any:
+		value = data
+	}
+
+	return jsonSchema(value), nil
+}
+
+func jsonSchema(value any) map[string]any {
+	switch value := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		properties := make(map[string]any, len(value))
+		for k, v := range value {
+			keys = append(keys, k)
+			properties[k] = jsonSchema(v)
+		}
+		sort.Strings(keys)
+
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             keys,
+			"additionalProperties": false,
+		}
+	case []any:
+		var items []any
+		for _, v := range value {
+			schema := jsonSchema(v)
+			if !slices.ContainsFunc(items, func(item any) bool { return reflect.DeepEqual(item, schema) }) {
+				items = append(items, schema)
+			}
+		}
+
+		schema := map[string]any{
+			"type":     "array",
+			"minItems": len(value),
+			"maxItems": len(value),
+		}
+		switch len(items) {
+		case 0:
+		case 1:
+			schema["items"] = items[0]
+		default:
+			schema["items"] = map[string]any{"anyOf": items}
+		}
+		return schema
+	case string:
+		return map[string]any{"type": "string"}
+	case float64, json.Number:
+		return map[string]any{"type": "number"}
+	case bool:
+		return map[string]any{"type": "boolean"}
+	default:
+		return map[string]any{"type": "null"}
+	}
+}
+
+func jsonValue(data map[string]any, path JSONPath) any {
+	var value any = data
+	for _, key := range path {
+		switch v := value.(type) {
 		case map[string]any:
-			intoValue, ok := into[k]
-			if ok {
-				intoMap, ok := intoValue.(map[string]any)
-				if !ok {
-					intoMap = make(map[string]any)
-					into[k] = intoMap
-				}
-				JSONMerge(intoMap, v)
-			} else {
-				into[k] = v
+			value = v[key]
+		case []any:
+			index, err := jsonIndex(key, len(v))
+			if err != nil {
+				return nil
 			}
+			value = v[index]
 		default:
-			into[k] = v
+			return nil
+		}
+	}
+	return value
+}
+
+func jsonSet(data map[string]any, path JSONPath, value any) {
+	parent, ok := jsonValue(data, path[:len(path)-1]).(map[string]any)
+	if !ok {
+		parent = make(map[string]any)
+		jsonSet(data, path[:len(path)-1], parent)
+	}
+	parent[path[len(path)-1]] = value
+}
+
+// jsonSetValue sets the value at the given path like jsonSet, but replaces
+// elements of existing arrays in place.
+func jsonSetValue(data map[string]any, path JSONPath, value any) {
+	if arr, ok := jsonValue(data, path[:len(path)-1]).([]any); ok {
+		if index, err := jsonIndex(path[len(path)-1], len(arr)); err == nil {
+			arr[index] = value
+		}
+		return
+	}
+	jsonSet(data, path, value)
+}
+
+// jsonDelete removes the value at the given path and reports whether it was
+// removed. Array elements are never removed, because that would change the
+// indices of the following elements.
+func jsonDelete(data map[string]any, path JSONPath) bool {
+	parent, ok := jsonValue(data, path[:len(path)-1]).(map[string]any)
+	if !ok {
+		return false
+	}
+	delete(parent, path[len(path)-1])
+	return true
+}
+
+func jsonCopy(data map[string]any) map[string]any {
+	out := make(map[string]any, len(data))
+	for k, v := range data {
+		out[k] = jsonCopyValue(v)
+	}
+	return out
+}
+
+func jsonCopyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return jsonCopy(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = jsonCopyValue(elem)
 		}
+		return out
+	default:
+		return v
 	}
 }
 
+func lessPath(a, b JSONPath) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
 func mapSlice[V, O any](s []V, fn func(V) O) []O {
 	out := make([]O, len(s))
 	for i, v := range s {
@@ -166,16 +756,38 @@ func mapSlice[V, O any](s []V, fn func(V) O) []O {
 func allKeys(m map[string]any) []JSONPath {
 	var keys []JSONPath
 	for k, v := range m {
-		switch v := v.(type) {
-		case map[string]any:
-			subKeys := allKeys(v)
-			subKeys = mapSlice(subKeys, func(p JSONPath) JSONPath {
-				return append(JSONPath{k}, p...)
-			})
-			keys = append(keys, subKeys...)
-		default:
-			keys = append(keys, JSONPath{k})
-		}
+		keys = append(keys, prefixPaths(k, jsonLeaves(v))...)
 	}
 	return keys
 }
+
+// jsonLeaves returns the paths of all leaf values below the given value,
+// relative to the value. A leaf value itself has a single, empty path.
+func jsonLeaves(v any) []JSONPath {
+	switch v := v.(type) {
+	case map[string]any:
+		return allKeys(v)
+	case []any:
+		var keys []JSONPath
+		for i, elem := range v {
+			keys = append(keys, prefixPaths(strconv.Itoa(i), jsonLeaves(elem))...)
+		}
+		return keys
+	default:
+		return []JSONPath{{}}
+	}
+}
+
+func prefixPaths(key string, paths []JSONPath) []JSONPath {
+	return mapSlice(paths, func(p JSONPath) JSONPath {
+		return append(JSONPath{key}, p...)
+	})
+}
+
+func jsonIndex(key string, length int) (int, error) {
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 0 || index >= length {
+		return 0, fmt.Errorf("invalid array index %q", key)
+	}
+	return index, nil
+}
@@ -81,6 +81,532 @@ func TestJSONExtract(t *testing.T) {
 	}
 }
 
+func TestJSONDiff_arrays(t *testing.T) {
+	source := map[string]any{
+		"features": []any{"Fast", "Simple", "Free"},
+		"steps": []any{
+			map[string]any{"title": "Install", "body": "Run the installer."},
+			map[string]any{"title": "Configure", "body": "Edit the config."},
+		},
+	}
+	target := map[string]any{
+		"features": []any{"Schnell", nil},
+		"steps": []any{
+			map[string]any{"title": "Installieren"},
+		},
+	}
+	want := []dragoman.JSONPath{
+		{"features", "1"},
+		{"features", "2"},
+		{"steps", "0", "body"},
+		{"steps", "1", "title"},
+		{"steps", "1", "body"},
+	}
+
+	paths, err := dragoman.JSONDiff(source, target)
+	if err != nil {
+		t.Fatalf("JSONDiff(): %v", err)
+	}
+
+	if !equalPaths(want, paths) {
+		t.Fatalf("JSONDiff(): got %v; want %v", paths, want)
+	}
+
+	extracted, err := dragoman.JSONExtract(source, paths)
+	if err != nil {
+		t.Fatalf("JSONExtract(): %v", err)
+	}
+
+	wantExtracted := map[string]any{
+		"features": []any{nil, "Simple", "Free"},
+		"steps": []any{
+			map[string]any{"body": "Run the installer."},
+			map[string]any{"title": "Configure", "body": "Edit the config."},
+		},
+	}
+
+	if !tcmp.Equal(wantExtracted, extracted) {
+		t.Fatalf("JSONExtract() mismatch (-want +got):\n%s", tcmp.Diff(wantExtracted, extracted))
+	}
+
+	dragoman.JSONMerge(target, map[string]any{
+		"features": []any{nil, "Einfach", "Kostenlos"},
+		"steps": []any{
+			map[string]any{"body": "Führe den Installer aus."},
+			map[string]any{"title": "Konfigurieren", "body": "Bearbeite die Konfiguration."},
+		},
+	})
+
+	wantMerged := map[string]any{
+		"features": []any{"Schnell", "Einfach", "Kostenlos"},
+		"steps": []any{
+			map[string]any{"title": "Installieren", "body": "Führe den Installer aus."},
+			map[string]any{"title": "Konfigurieren", "body": "Bearbeite die Konfiguration."},
+		},
+	}
+
+	if !tcmp.Equal(wantMerged, target) {
+		t.Fatalf("JSONMerge() mismatch (-want +got):\n%s", tcmp.Diff(wantMerged, target))
+	}
+}
+
+func TestJSONDelete(t *testing.T) {
+	doc := map[string]any{
+		"nav": map[string]any{
+			"home":  "Home",
+			"about": "About",
+		},
+		"footer": map[string]any{
+			"links": map[string]any{"imprint": "Imprint"},
+		},
+		"title": "Title",
+	}
+
+	dragoman.JSONDelete(doc, []dragoman.JSONPath{
+		{"nav", "home"},
+		{"footer", "links", "imprint"},
+		{"missing", "key"},
+	})
+
+	want := map[string]any{
+		"nav":   map[string]any{"about": "About"},
+		"title": "Title",
+	}
+	if diff := tcmp.Diff(want, doc); diff != "" {
+		t.Fatalf("JSONDelete() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONPrune(t *testing.T) {
+	source := map[string]any{
+		"hello": "Hello, World!",
+		"contact": map[string]any{
+			"email": "hello@example.com",
+		},
+		"steps": []any{
+			map[string]any{"title": "Install"},
+		},
+		"note": "Plain text",
+	}
+	target := map[string]any{
+		"hello": "Hallo, Welt!",
+		"bye":   "Tschüss!",
+		"contact": map[string]any{
+			"email": "hallo@example.com",
+			"phone": "123-456-7890",
+		},
+		"steps": []any{
+			map[string]any{"title": "Installieren", "body": "Führe den Installer aus."},
+			map[string]any{"title": "Konfigurieren"},
+		},
+		"note": map[string]any{"text": "Nur Text"},
+	}
+
+	pruned := dragoman.JSONPrune(target, source)
+
+	wantPruned := []dragoman.JSONPath{
+		{"bye"},
+		{"contact", "phone"},
+		{"steps", "0", "body"},
+		{"steps", "1"},
+	}
+	if !tcmp.Equal(wantPruned, pruned) {
+		t.Fatalf("JSONPrune() mismatch (-want +got):\n%s", tcmp.Diff(wantPruned, pruned))
+	}
+
+	want := map[string]any{
+		"hello": "Hallo, Welt!",
+		"contact": map[string]any{
+			"email": "hallo@example.com",
+		},
+		"steps": []any{
+			map[string]any{"title": "Installieren"},
+		},
+		"note": map[string]any{"text": "Nur Text"},
+	}
+	if !tcmp.Equal(want, target) {
+		t.Fatalf("target mismatch (-want +got):\n%s", tcmp.Diff(want, target))
+	}
+}
+
+func TestJSONChanges(t *testing.T) {
+	previous := map[string]any{
+		"hello": "Hello, World!",
+		"bye":   "Bye!",
+		"contact": map[string]any{
+			"email": "hello@example.com",
+			"phone": "Call us",
+		},
+		"steps": []any{"Install"},
+		"count": float64(3),
+	}
+	current := map[string]any{
+		"hello": "Hello, world!",
+		"contact": map[string]any{
+			"email": "hello@example.com",
+			"phone": "Call us",
+			"fax":   "Fax us",
+		},
+		"steps": []any{"Install", "Configure"},
+		"count": float64(3),
+	}
+
+	changed := dragoman.JSONChanges(previous, current)
+
+	want := []dragoman.JSONPath{
+		{"contact", "fax"},
+		{"hello"},
+		{"steps", "1"},
+	}
+	if !tcmp.Equal(want, changed) {
+		t.Fatalf("JSONChanges() mismatch (-want +got):\n%s", tcmp.Diff(want, changed))
+	}
+}
+
+func TestJSONFlatten(t *testing.T) {
+	nested := map[string]any{
+		"home": map[string]any{
+			"title": "Home",
+			"nav":   map[string]any{"about": "About"},
+		},
+		"tags":  []any{"New", "Sale"},
+		"empty": map[string]any{},
+	}
+	flat := map[string]any{
+		"home.title":     "Home",
+		"home.nav.about": "About",
+		"tags":           []any{"New", "Sale"},
+		"empty":          map[string]any{},
+	}
+
+	if got := dragoman.JSONFlatten(nested); !tcmp.Equal(flat, got) {
+		t.Fatalf("JSONFlatten() mismatch (-want +got):\n%s", tcmp.Diff(flat, got))
+	}
+
+	got, err := dragoman.JSONUnflatten(flat)
+	if err != nil {
+		t.Fatalf("JSONUnflatten(): %v", err)
+	}
+	if !tcmp.Equal(nested, got) {
+		t.Fatalf("JSONUnflatten() mismatch (-want +got):\n%s", tcmp.Diff(nested, got))
+	}
+}
+
+func TestJSONUnflatten_mixed(t *testing.T) {
+	got, err := dragoman.JSONUnflatten(map[string]any{
+		"home.title": "Home",
+		"home":       map[string]any{"nav.about": "About"},
+	})
+	if err != nil {
+		t.Fatalf("JSONUnflatten(): %v", err)
+	}
+
+	want := map[string]any{
+		"home": map[string]any{
+			"title": "Home",
+			"nav":   map[string]any{"about": "About"},
+		},
+	}
+	if !tcmp.Equal(want, got) {
+		t.Fatalf("JSONUnflatten() mismatch (-want +got):\n%s", tcmp.Diff(want, got))
+	}
+}
+
+func TestJSONUnflatten_conflict(t *testing.T) {
+	if _, err := dragoman.JSONUnflatten(map[string]any{"home": "Home", "home.title": "Title"}); err == nil {
+		t.Fatalf("JSONUnflatten() should fail for a key below a string value")
+	}
+}
+
+func TestJSONDeduplicate(t *testing.T) {
+	doc := map[string]any{
+		"cancel": "Cancel",
+		"dialog": map[string]any{
+			"cancel":  "Cancel",
+			"confirm": "OK",
+		},
+		"form": map[string]any{
+			"abort":  "Cancel",
+			"submit": "OK",
+		},
+	}
+
+	wantDeduped := map[string]any{
+		"cancel": "Cancel",
+		"dialog": map[string]any{
+			"confirm": "OK",
+		},
+		"form": map[string]any{},
+	}
+
+	wantDups := []dragoman.JSONDuplicate{
+		{Path: dragoman.JSONPath{"dialog", "cancel"}, Original: dragoman.JSONPath{"cancel"}},
+		{Path: dragoman.JSONPath{"form", "abort"}, Original: dragoman.JSONPath{"cancel"}},
+		{Path: dragoman.JSONPath{"form", "submit"}, Original: dragoman.JSONPath{"dialog", "confirm"}},
+	}
+
+	deduped, dups := dragoman.JSONDeduplicate(doc)
+
+	if !tcmp.Equal(wantDeduped, deduped) {
+		t.Fatalf("JSONDeduplicate() mismatch (-want +got):\n%s", tcmp.Diff(wantDeduped, deduped))
+	}
+
+	if !tcmp.Equal(wantDups, dups) {
+		t.Fatalf("JSONDeduplicate() duplicates mismatch (-want +got):\n%s", tcmp.Diff(wantDups, dups))
+	}
+
+	if _, ok := doc["dialog"].(map[string]any)["cancel"]; !ok {
+		t.Fatalf("JSONDeduplicate() must not modify the provided document")
+	}
+
+	translated := map[string]any{
+		"cancel": "Abbrechen",
+		"dialog": map[string]any{
+			"confirm": "OK!",
+		},
+		"form": map[string]any{},
+	}
+
+	if err := dragoman.JSONRestoreDuplicates(translated, dups); err != nil {
+		t.Fatalf("JSONRestoreDuplicates() failed: %v", err)
+	}
+
+	want := map[string]any{
+		"cancel": "Abbrechen",
+		"dialog": map[string]any{
+			"cancel":  "Abbrechen",
+			"confirm": "OK!",
+		},
+		"form": map[string]any{
+			"abort":  "Abbrechen",
+			"submit": "OK!",
+		},
+	}
+
+	if !tcmp.Equal(want, translated) {
+		t.Fatalf("JSONRestoreDuplicates() mismatch (-want +got):\n%s", tcmp.Diff(want, translated))
+	}
+}
+
+func TestJSONRestoreDuplicates_missingOriginal(t *testing.T) {
+	dups := []dragoman.JSONDuplicate{{Path: dragoman.JSONPath{"b"}, Original: dragoman.JSONPath{"a"}}}
+	if err := dragoman.JSONRestoreDuplicates(map[string]any{}, dups); err == nil {
+		t.Fatalf("JSONRestoreDuplicates() should fail if the original value is missing")
+	}
+}
+
+func TestJSONOverrides(t *testing.T) {
+	source := map[string]any{
+		"tagline": "Translate anything.",
+		"legal": map[string]any{
+			"disclaimer": "No warranty.",
+			"contact":    "Write to us.",
+		},
+		"steps": []any{"First", "Second"},
+	}
+
+	overrides := dragoman.JSONOverrides{
+		"tagline":          "Übersetze alles.",
+		"legal.disclaimer": "Keine Gewähr.",
+		"steps.1":          "Zweitens",
+		"missing":          "Fehlt",
+	}
+
+	stripped := overrides.Strip(source)
+
+	wantStripped := map[string]any{
+		"legal": map[string]any{
+			"contact": "Write to us.",
+		},
+		"steps": []any{"First", "Second"},
+	}
+	if !tcmp.Equal(wantStripped, stripped) {
+		t.Fatalf("Strip() mismatch (-want +got):\n%s", tcmp.Diff(wantStripped, stripped))
+	}
+
+	if _, ok := source["tagline"]; !ok {
+		t.Fatalf("Strip() must not modify the provided document")
+	}
+
+	translated := map[string]any{
+		"legal": map[string]any{
+			"contact": "Schreib uns.",
+		},
+		"steps": []any{"Erstens", "Second"},
+	}
+	overrides.Apply(source, translated)
+
+	want := map[string]any{
+		"tagline": "Übersetze alles.",
+		"legal": map[string]any{
+			"disclaimer": "Keine Gewähr.",
+			"contact":    "Schreib uns.",
+		},
+		"steps": []any{"Erstens", "Zweitens"},
+	}
+	if !tcmp.Equal(want, translated) {
+		t.Fatalf("Apply() mismatch (-want +got):\n%s", tcmp.Diff(want, translated))
+	}
+}
+
+func TestJSONFilter(t *testing.T) {
+	doc := map[string]any{
+		"title": "Home",
+		"errors": map[string]any{
+			"required": "Required",
+			"internal": map[string]any{"code": "E_INTERNAL"},
+		},
+		"pages": map[string]any{
+			"about": map[string]any{"title": "About", "slug": "about"},
+		},
+		"items": []any{
+			map[string]any{"title": "First", "id": "a"},
+			map[string]any{"title": "Second", "id": "b"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     map[string]any
+	}{
+		{
+			name:     "subtree",
+			patterns: []string{"errors"},
+			want: map[string]any{
+				"errors": map[string]any{
+					"required": "Required",
+					"internal": map[string]any{"code": "E_INTERNAL"},
+				},
+			},
+		},
+		{
+			name:     "wildcard and exclude",
+			patterns: []string{"errors.*", "!errors.internal"},
+			want: map[string]any{
+				"errors": map[string]any{"required": "Required"},
+			},
+		},
+		{
+			name:     "any depth",
+			patterns: []string{"**.title"},
+			want: map[string]any{
+				"title": "Home",
+				"pages": map[string]any{
+					"about": map[string]any{"title": "About"},
+				},
+				"items": []any{
+					map[string]any{"title": "First"},
+					map[string]any{"title": "Second"},
+				},
+			},
+		},
+		{
+			name:     "only excludes",
+			patterns: []string{"!errors", "!**.slug", "!items.*.id", "!items.1"},
+			want: map[string]any{
+				"title": "Home",
+				"pages": map[string]any{
+					"about": map[string]any{"title": "About"},
+				},
+				"items": []any{
+					map[string]any{"title": "First"},
+				},
+			},
+		},
+		{
+			name:     "glob within key",
+			patterns: []string{"pages.ab*.t*"},
+			want: map[string]any{
+				"pages": map[string]any{
+					"about": map[string]any{"title": "About"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dragoman.JSONFilter(doc, tt.patterns)
+			if err != nil {
+				t.Fatalf("JSONFilter() failed: %v", err)
+			}
+
+			if !tcmp.Equal(tt.want, got) {
+				t.Fatalf("JSONFilter() mismatch (-want +got):\n%s", tcmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	source := []byte(`{
+		"title": "Hello",
+		"count": 3,
+		"nav": {"home": "Home", "enabled": true},
+		"items": ["One", {"label": "Two"}]
+	}`)
+
+	schema, err := dragoman.JSONSchema(source)
+	if err != nil {
+		t.Fatalf("JSONSchema() failed: %v", err)
+	}
+
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"title": map[string]any{"type": "string"},
+			"count": map[string]any{"type": "number"},
+			"nav": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"home":    map[string]any{"type": "string"},
+					"enabled": map[string]any{"type": "boolean"},
+				},
+				"required":             []string{"enabled", "home"},
+				"additionalProperties": false,
+			},
+			"items": map[string]any{
+				"type":     "array",
+				"minItems": 2,
+				"maxItems": 2,
+				"items": map[string]any{"anyOf": []any{
+					map[string]any{"type": "string"},
+					map[string]any{
+						"type":                 "object",
+						"properties":           map[string]any{"label": map[string]any{"type": "string"}},
+						"required":             []string{"label"},
+						"additionalProperties": false,
+					},
+				}},
+			},
+		},
+		"required":             []string{"count", "items", "nav", "title"},
+		"additionalProperties": false,
+	}
+
+	if !tcmp.Equal(want, schema) {
+		t.Fatalf("JSONSchema() mismatch (-want +got):\n%s", tcmp.Diff(want, schema))
+	}
+}
+
+func TestJSONSchema_array(t *testing.T) {
+	schema, err := dragoman.JSONSchema([]byte(`["Hello", "World"]`))
+	if err != nil {
+		t.Fatalf("JSONSchema() failed: %v", err)
+	}
+
+	want := map[string]any{
+		"type":     "array",
+		"minItems": 2,
+		"maxItems": 2,
+		"items":    map[string]any{"type": "string"},
+	}
+	if !tcmp.Equal(want, schema) {
+		t.Fatalf("JSONSchema() mismatch (-want +got):\n%s", tcmp.Diff(want, schema))
+	}
+}
+
 func equalPaths(a, b []dragoman.JSONPath) bool {
 	if len(a) != len(b) {
 		return false
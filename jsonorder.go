@@ -0,0 +1,248 @@
+package dragoman
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONOrder returns the JSON document with the keys of its objects in the
+// order of the given layout documents and indented like them, so that
+// rewriting a file, for example after merging new translations into it, does
+// not reorder it. Keys are ordered like in the first layout that has them;
+// keys of later layouts are inserted after the key that precedes them there,
+// and keys of none of the layouts are appended in alphabetical order. The
+// indentation is taken from the first layout that is indented, and defaults
+// to two spaces. Layouts that are not valid JSON are ignored.
+func JSONOrder(doc []byte, layouts ...[]byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("decode document: %w", err)
+	}
+
+	order := &jsonKeyOrder{}
+	indent := ""
+	for _, layout := range layouts {
+		o, err := parseJSONKeyOrder(layout)
+		if err != nil {
+			continue
+		}
+		order.merge(o)
+		if indent == "" {
+			indent = jsonIndentation(layout)
+		}
+	}
+	if indent == "" {
+		indent = "  "
+	}
+
+	var buf bytes.Buffer
+	if err := writeOrderedJSON(&buf, value, order, indent, 0); err != nil {
+		return nil, err
+	}
+	if bytes.HasSuffix(bytes.TrimRight(doc, " \t\r"), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// jsonKeyOrder is the order of the keys of a JSON value and of its nested
+// values. Array elements are keyed by their index.
+type jsonKeyOrder struct {
+	keys     []string
+	children map[string]*jsonKeyOrder
+}
+
+func (o *jsonKeyOrder) child(key string) *jsonKeyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.children[key]
+}
+
+// merge adds the keys of from that o does not have yet. Each key is inserted
+// after the key that precedes it in from.
+func (o *jsonKeyOrder) merge(from *jsonKeyOrder) {
+	for i, key := range from.keys {
+		if slices.Contains(o.keys, key) {
+			continue
+		}
+		pos := 0
+		if i > 0 {
+			pos = slices.Index(o.keys, from.keys[i-1]) + 1
+		}
+		o.keys = slices.Insert(o.keys, pos, key)
+	}
+
+	for key, child := range from.children {
+		if o.children == nil {
+			o.children = make(map[string]*jsonKeyOrder)
+		}
+		if o.children[key] == nil {
+			o.children[key] = &jsonKeyOrder{}
+		}
+		o.children[key].merge(child)
+	}
+}
+
+func parseJSONKeyOrder(doc []byte) (*jsonKeyOrder, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	order, err := decodeJSONKeyOrder(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return order, nil
+}
+
+func decodeJSONKeyOrder(dec *json.Decoder) (*jsonKeyOrder, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil, nil
+	}
+
+	order := &jsonKeyOrder{children: make(map[string]*jsonKeyOrder)}
+	for i := 0; dec.More(); i++ {
+		key := strconv.Itoa(i)
+		if delim == '{' {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key = tok.(string)
+			order.keys = append(order.keys, key)
+		}
+
+		child, err := decodeJSONKeyOrder(dec)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			order.children[key] = child
+		}
+	}
+
+	// Closing delimiter.
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// jsonIndentation returns the indentation of the first indented line of a JSON
+// document, or an empty string if the document is not indented.
+func jsonIndentation(doc []byte) string {
+	for _, line := range strings.Split(string(doc), "\n")[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return ""
+}
+
+func writeOrderedJSON(buf *bytes.Buffer, value any, order *jsonKeyOrder, indent string, depth int) error {
+	newline := func(depth int) {
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(indent, depth))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+
+		buf.WriteByte('{')
+		for i, key := range orderedKeys(v, order) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := writeJSONScalar(buf, key); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := writeOrderedJSON(buf, v[key], order.child(key), indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buf.WriteByte('}')
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := writeOrderedJSON(buf, elem, order.child(strconv.Itoa(i)), indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buf.WriteByte(']')
+	default:
+		return writeJSONScalar(buf, v)
+	}
+
+	return nil
+}
+
+// orderedKeys returns the keys of the object in the given order, followed by
+// the keys that are not in the order in alphabetical order.
+func orderedKeys(obj map[string]any, order *jsonKeyOrder) []string {
+	keys := make([]string, 0, len(obj))
+	seen := make(map[string]bool, len(obj))
+	if order != nil {
+		for _, key := range order.keys {
+			if _, ok := obj[key]; ok {
+				keys = append(keys, key)
+				seen[key] = true
+			}
+		}
+	}
+
+	var rest []string
+	for key := range obj {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+func writeJSONScalar(buf *bytes.Buffer, v any) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return nil
+}
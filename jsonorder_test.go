@@ -0,0 +1,88 @@
+package dragoman_test
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+func TestJSONOrder(t *testing.T) {
+	target := heredoc.Doc(`
+		{
+		    "title": "Titel",
+		    "nav": {
+		        "home": "Startseite"
+		    },
+		    "footer": "Fußzeile"
+		}
+	`)
+
+	source := heredoc.Doc(`
+		{
+		  "title": "Title",
+		  "intro": "Intro",
+		  "nav": {
+		    "about": "About",
+		    "home": "Home"
+		  },
+		  "footer": "Footer"
+		}
+	`)
+
+	merged := heredoc.Doc(`
+		{
+		  "footer": "Fußzeile",
+		  "intro": "Einleitung",
+		  "items": [{"z": 1, "a": 2.50}],
+		  "nav": {
+		    "about": "Über uns",
+		    "home": "Startseite"
+		  },
+		  "title": "Titel",
+		  "zzz": "<b>",
+		  "empty": {}
+		}
+	`)
+
+	want := heredoc.Doc(`
+		{
+		    "title": "Titel",
+		    "intro": "Einleitung",
+		    "nav": {
+		        "about": "Über uns",
+		        "home": "Startseite"
+		    },
+		    "footer": "Fußzeile",
+		    "empty": {},
+		    "items": [
+		        {
+		            "a": 2.50,
+		            "z": 1
+		        }
+		    ],
+		    "zzz": "<b>"
+		}
+	`)
+
+	got, err := dragoman.JSONOrder([]byte(merged), []byte(target), []byte(source))
+	if err != nil {
+		t.Fatalf("JSONOrder() failed: %v", err)
+	}
+
+	if string(got) != want {
+		t.Fatalf("JSONOrder() should return\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestJSONOrder_defaultIndentation(t *testing.T) {
+	got, err := dragoman.JSONOrder([]byte(`{"b": 1, "a": {"d": true, "c": null}}`), []byte("{}"), []byte(`{"b": 0, "a": {"d": 0, "c": 0}}`), []byte("not json"))
+	if err != nil {
+		t.Fatalf("JSONOrder() failed: %v", err)
+	}
+
+	want := "{\n  \"b\": 1,\n  \"a\": {\n    \"d\": true,\n    \"c\": null\n  }\n}"
+	if string(got) != want {
+		t.Fatalf("JSONOrder() should return\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,150 @@
+package dragoman
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// jsonPackOverhead is the estimated number of tokens of the quotes, colons,
+// commas and indentation around a value of a packed chunk.
+const jsonPackOverhead = 4
+
+// JSONPacker returns a [Chunker] that packs the values of a JSON object into
+// as few chunks of at most maxTokens tokens as possible, so that files with
+// thousands of short strings are neither sent to the model in one prompt nor
+// value by value. Each chunk is a JSON object with the nested keys of its
+// values, in the order of their key paths, so that related keys stay
+// together. The plural forms of a key, like "item_one" and "item_other", are
+// always packed into the same chunk. Arrays are packed as a whole. A value
+// that alone exceeds maxTokens gets a chunk of its own. Documents that are not
+// JSON objects are returned as a single chunk. The translations of the chunks
+// are merged back into one document by [JoinJSON].
+//
+// The tokens of the values are counted using the given counter, or estimated
+// from their length if the counter is nil or fails.
+func JSONPacker(maxTokens int, tokens TokenCounter) Chunker {
+	return func(document string) []string {
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(document), &doc); err != nil || maxTokens <= 0 {
+			return []string{document}
+		}
+
+		leaves := packLeaves(nil, doc)
+		sort.Slice(leaves, func(i, j int) bool {
+			if gi, gj := packGroup(leaves[i].path), packGroup(leaves[j].path); !slices.Equal(gi, gj) {
+				return lessPath(gi, gj)
+			}
+			return lessPath(leaves[i].path, leaves[j].path)
+		})
+
+		var (
+			chunks []string
+			chunk  = map[string]any{}
+			size   int
+		)
+
+		flush := func() {
+			if size == 0 {
+				return
+			}
+			b, _ := json.MarshalIndent(chunk, "", "  ")
+			chunks = append(chunks, string(b))
+			chunk, size = map[string]any{}, 0
+		}
+
+		for start := 0; start < len(leaves); {
+			group := packGroup(leaves[start].path)
+			end := start + 1
+			for end < len(leaves) && slices.Equal(packGroup(leaves[end].path), group) {
+				end++
+			}
+
+			var cost int
+			for _, leaf := range leaves[start:end] {
+				value, _ := json.Marshal(leaf.value)
+				cost += countTokens(tokens, strings.Join(leaf.path, ".")) + countTokens(tokens, string(value)) + jsonPackOverhead
+			}
+			if size > 0 && size+cost > maxTokens {
+				flush()
+			}
+			for _, leaf := range leaves[start:end] {
+				jsonSet(chunk, leaf.path, leaf.value)
+			}
+			size += cost
+			start = end
+		}
+		flush()
+
+		if len(chunks) == 0 {
+			return []string{document}
+		}
+
+		return chunks
+	}
+}
+
+// JoinJSON merges the translated chunks of a [JSONPacker] into a single JSON
+// object. It can be used as the Join function of the [TranslateParams].
+func JoinJSON(translations []string) (string, error) {
+	out := make(map[string]any)
+	for i, translation := range translations {
+		var chunk map[string]any
+		if err := json.Unmarshal([]byte(translation), &chunk); err != nil {
+			return "", fmt.Errorf("unmarshal translation of chunk %d: %w", i+1, err)
+		}
+		JSONMerge(out, chunk)
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal joined translation: %w", err)
+	}
+
+	return string(b), nil
+}
+
+type packLeaf struct {
+	path  JSONPath
+	value any
+}
+
+// packLeaves returns the values of the object that are not objects
+// themselves, together with their paths.
+func packLeaves(prefix JSONPath, doc map[string]any) []packLeaf {
+	var leaves []packLeaf
+	for key, value := range doc {
+		path := append(append(JSONPath{}, prefix...), key)
+		if obj, ok := value.(map[string]any); ok && len(obj) > 0 {
+			leaves = append(leaves, packLeaves(path, obj)...)
+			continue
+		}
+		leaves = append(leaves, packLeaf{path: path, value: value})
+	}
+	return leaves
+}
+
+// packGroup returns the path of the key whose plural forms the value at the
+// given path is, like "cart.item" for "cart.item_one", so that the plural
+// forms of i18next resources end up in the same chunk. Other values are in a
+// group of their own.
+func packGroup(path JSONPath) JSONPath {
+	last := path[len(path)-1]
+	if m := i18nextPluralKey.FindStringSubmatch(last); m != nil {
+		last = m[1]
+	} else if base, ok := strings.CutSuffix(last, "_plural"); ok && base != "" {
+		last = base
+	}
+	return append(slices.Clone(path[:len(path)-1]), last)
+}
+
+func countTokens(tokens TokenCounter, text string) int {
+	if tokens != nil {
+		if n, err := tokens(text); err == nil {
+			return n
+		}
+	}
+	return len(text)/4 + 1
+}
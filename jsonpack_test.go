@@ -0,0 +1,139 @@
+package dragoman_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestJSONPacker(t *testing.T) {
+	doc := `{
+		"checkout": {"pay": "Pay now", "back": "Back"},
+		"home": {"title": "Welcome", "intro": "A long introduction that does not fit with others"},
+		"tags": ["a", "b"],
+		"version": 2
+	}`
+
+	chars := func(text string) (int, error) { return len(text), nil }
+	chunks := dragoman.JSONPacker(55, chars)(doc)
+
+	var got []map[string]any
+	for _, chunk := range chunks {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(chunk), &m); err != nil {
+			t.Fatalf("chunk is not a JSON object: %v\n%s", err, chunk)
+		}
+		got = append(got, m)
+	}
+
+	want := []map[string]any{
+		{"checkout": map[string]any{"back": "Back", "pay": "Pay now"}},
+		{"home": map[string]any{"intro": "A long introduction that does not fit with others"}},
+		{"home": map[string]any{"title": "Welcome"}, "tags": []any{"a", "b"}, "version": float64(2)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected chunks (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONPacker_pluralForms(t *testing.T) {
+	doc := `{"item_one": "item", "item_other": "items", "item_title": "Items"}`
+
+	chars := func(text string) (int, error) { return len(text), nil }
+	chunks := dragoman.JSONPacker(1, chars)(doc)
+
+	var got []map[string]any
+	for _, chunk := range chunks {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(chunk), &m); err != nil {
+			t.Fatalf("chunk is not a JSON object: %v\n%s", err, chunk)
+		}
+		got = append(got, m)
+	}
+
+	want := []map[string]any{
+		{"item_one": "item", "item_other": "items"},
+		{"item_title": "Items"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected chunks (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONPacker_notAnObject(t *testing.T) {
+	chunks := dragoman.JSONPacker(10, nil)("Hello, world!")
+	if diff := cmp.Diff([]string{"Hello, world!"}, chunks); diff != "" {
+		t.Fatalf("unexpected chunks (-want +got):\n%s", diff)
+	}
+}
+
+func TestTranslator_Translate_packedJSON(t *testing.T) {
+	var requests int
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		requests++
+		doc := strings.Split(strings.Split(prompt, "---<DOC_BEGIN>---\n")[1], "\n---<DOC_END>---")[0]
+
+		var m map[string]any
+		if err := json.Unmarshal([]byte(doc), &m); err != nil {
+			t.Fatalf("chunk is not a JSON object: %v\n%s", err, doc)
+		}
+		upper(m)
+		b, _ := json.Marshal(m)
+		return string(b), nil
+	})
+
+	var source strings.Builder
+	source.WriteString(`{"a": {`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			source.WriteString(",")
+		}
+		source.WriteString(`"k` + strings.Repeat("x", i) + `": "value"`)
+	}
+	source.WriteString(`}}`)
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: source.String(),
+		Target:   "German",
+		Chunker:  dragoman.JSONPacker(500, nil),
+		Join:     dragoman.JoinJSON,
+		Validate: dragoman.ValidateJSON,
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if requests < 2 {
+		t.Fatalf("expected the document to be packed into several chunks; got %d requests", requests)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(result), &got); err != nil {
+		t.Fatalf("result is not a JSON object: %v", err)
+	}
+
+	values := got["a"].(map[string]any)
+	if len(values) != 100 {
+		t.Fatalf("expected 100 values; got %d", len(values))
+	}
+	for key, value := range values {
+		if value != "VALUE" {
+			t.Fatalf("expected %q to be translated; got %q", key, value)
+		}
+	}
+}
+
+func upper(m map[string]any) {
+	for k, v := range m {
+		switch v := v.(type) {
+		case string:
+			m[k] = strings.ToUpper(v)
+		case map[string]any:
+			upper(v)
+		}
+	}
+}
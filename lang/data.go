@@ -0,0 +1,249 @@
+package lang
+
+import "strings"
+
+// languages maps ISO 639 codes to the English names of the languages.
+var languages = map[string]string{
+	"af":  "Afrikaans",
+	"am":  "Amharic",
+	"ar":  "Arabic",
+	"az":  "Azerbaijani",
+	"be":  "Belarusian",
+	"bg":  "Bulgarian",
+	"bn":  "Bengali",
+	"bs":  "Bosnian",
+	"ca":  "Catalan",
+	"cs":  "Czech",
+	"cy":  "Welsh",
+	"da":  "Danish",
+	"de":  "German",
+	"el":  "Greek",
+	"en":  "English",
+	"eo":  "Esperanto",
+	"es":  "Spanish",
+	"et":  "Estonian",
+	"eu":  "Basque",
+	"fa":  "Persian",
+	"fi":  "Finnish",
+	"fil": "Filipino",
+	"fr":  "French",
+	"ga":  "Irish",
+	"gl":  "Galician",
+	"gu":  "Gujarati",
+	"ha":  "Hausa",
+	"he":  "Hebrew",
+	"hi":  "Hindi",
+	"hr":  "Croatian",
+	"hu":  "Hungarian",
+	"hy":  "Armenian",
+	"id":  "Indonesian",
+	"ig":  "Igbo",
+	"is":  "Icelandic",
+	"it":  "Italian",
+	"ja":  "Japanese",
+	"jv":  "Javanese",
+	"ka":  "Georgian",
+	"kk":  "Kazakh",
+	"km":  "Khmer",
+	"kn":  "Kannada",
+	"ko":  "Korean",
+	"ku":  "Kurdish",
+	"ky":  "Kyrgyz",
+	"la":  "Latin",
+	"lb":  "Luxembourgish",
+	"lo":  "Lao",
+	"lt":  "Lithuanian",
+	"lv":  "Latvian",
+	"mk":  "Macedonian",
+	"ml":  "Malayalam",
+	"mn":  "Mongolian",
+	"mr":  "Marathi",
+	"ms":  "Malay",
+	"mt":  "Maltese",
+	"my":  "Burmese",
+	"nb":  "Norwegian Bokmål",
+	"ne":  "Nepali",
+	"nl":  "Dutch",
+	"nn":  "Norwegian Nynorsk",
+	"no":  "Norwegian",
+	"pa":  "Punjabi",
+	"pl":  "Polish",
+	"ps":  "Pashto",
+	"pt":  "Portuguese",
+	"ro":  "Romanian",
+	"ru":  "Russian",
+	"si":  "Sinhala",
+	"sk":  "Slovak",
+	"sl":  "Slovenian",
+	"so":  "Somali",
+	"sq":  "Albanian",
+	"sr":  "Serbian",
+	"sv":  "Swedish",
+	"sw":  "Swahili",
+	"ta":  "Tamil",
+	"te":  "Telugu",
+	"tg":  "Tajik",
+	"th":  "Thai",
+	"tl":  "Tagalog",
+	"tr":  "Turkish",
+	"uk":  "Ukrainian",
+	"ur":  "Urdu",
+	"uz":  "Uzbek",
+	"vi":  "Vietnamese",
+	"xh":  "Xhosa",
+	"yi":  "Yiddish",
+	"yo":  "Yoruba",
+	"zh":  "Chinese",
+	"zu":  "Zulu",
+}
+
+// scripts maps ISO 15924 codes to the English names of the scripts.
+var scripts = map[string]string{
+	"Arab": "Arabic",
+	"Cyrl": "Cyrillic",
+	"Hans": "Simplified",
+	"Hant": "Traditional",
+	"Latn": "Latin",
+}
+
+// regions maps ISO 3166 codes and UN M.49 area codes to the English names of
+// the regions.
+var regions = map[string]string{
+	"001": "World",
+	"150": "Europe",
+	"419": "Latin America",
+	"AE":  "United Arab Emirates",
+	"AR":  "Argentina",
+	"AT":  "Austria",
+	"AU":  "Australia",
+	"BE":  "Belgium",
+	"BO":  "Bolivia",
+	"BR":  "Brazil",
+	"CA":  "Canada",
+	"CH":  "Switzerland",
+	"CL":  "Chile",
+	"CN":  "China",
+	"CO":  "Colombia",
+	"CR":  "Costa Rica",
+	"CU":  "Cuba",
+	"CY":  "Cyprus",
+	"CZ":  "Czechia",
+	"DE":  "Germany",
+	"DK":  "Denmark",
+	"DO":  "Dominican Republic",
+	"DZ":  "Algeria",
+	"EC":  "Ecuador",
+	"EG":  "Egypt",
+	"ES":  "Spain",
+	"FI":  "Finland",
+	"FR":  "France",
+	"GB":  "United Kingdom",
+	"GR":  "Greece",
+	"GT":  "Guatemala",
+	"HK":  "Hong Kong",
+	"HN":  "Honduras",
+	"ID":  "Indonesia",
+	"IE":  "Ireland",
+	"IL":  "Israel",
+	"IN":  "India",
+	"IQ":  "Iraq",
+	"IR":  "Iran",
+	"IT":  "Italy",
+	"JO":  "Jordan",
+	"JP":  "Japan",
+	"KE":  "Kenya",
+	"KR":  "South Korea",
+	"LI":  "Liechtenstein",
+	"LU":  "Luxembourg",
+	"MA":  "Morocco",
+	"MO":  "Macao",
+	"MX":  "Mexico",
+	"MY":  "Malaysia",
+	"NG":  "Nigeria",
+	"NI":  "Nicaragua",
+	"NL":  "Netherlands",
+	"NO":  "Norway",
+	"NZ":  "New Zealand",
+	"PA":  "Panama",
+	"PE":  "Peru",
+	"PH":  "Philippines",
+	"PK":  "Pakistan",
+	"PL":  "Poland",
+	"PR":  "Puerto Rico",
+	"PT":  "Portugal",
+	"PY":  "Paraguay",
+	"RO":  "Romania",
+	"RS":  "Serbia",
+	"RU":  "Russia",
+	"SA":  "Saudi Arabia",
+	"SE":  "Sweden",
+	"SG":  "Singapore",
+	"SV":  "El Salvador",
+	"TH":  "Thailand",
+	"TN":  "Tunisia",
+	"TR":  "Türkiye",
+	"TW":  "Taiwan",
+	"UA":  "Ukraine",
+	"US":  "United States",
+	"UY":  "Uruguay",
+	"VE":  "Venezuela",
+	"VN":  "Vietnam",
+	"ZA":  "South Africa",
+}
+
+// variants are the English names of regional and script variants that are
+// commonly named differently from "Language (Region)".
+var variants = map[string]string{
+	"de-AT":   "Austrian German",
+	"de-CH":   "Swiss German",
+	"en-AU":   "Australian English",
+	"en-CA":   "Canadian English",
+	"en-GB":   "British English",
+	"en-US":   "American English",
+	"es-419":  "Latin American Spanish",
+	"es-ES":   "European Spanish",
+	"es-MX":   "Mexican Spanish",
+	"fr-CA":   "Canadian French",
+	"fr-CH":   "Swiss French",
+	"pt-BR":   "Brazilian Portuguese",
+	"pt-PT":   "European Portuguese",
+	"zh-Hans": "Simplified Chinese",
+	"zh-Hant": "Traditional Chinese",
+}
+
+// aliases are other English names of languages.
+var aliases = map[string]string{
+	"farsi":     "fa",
+	"mandarin":  "zh",
+	"norsk":     "no",
+	"bokmål":    "nb",
+	"bokmal":    "nb",
+	"nynorsk":   "nn",
+	"castilian": "es",
+	"flemish":   "nl-BE",
+}
+
+// names maps the lowercase English names of languages, variants and aliases
+// to their tags.
+var names = func() map[string]string {
+	out := make(map[string]string, len(languages)+len(variants)+len(aliases))
+	for tag, name := range languages {
+		out[strings.ToLower(name)] = tag
+	}
+	for tag, name := range variants {
+		out[strings.ToLower(name)] = tag
+	}
+	for name, tag := range aliases {
+		out[name] = tag
+	}
+	return out
+}()
+
+// regionNames maps the lowercase English names of regions to their codes.
+var regionNames = func() map[string]string {
+	out := make(map[string]string, len(regions))
+	for code, name := range regions {
+		out[strings.ToLower(name)] = code
+	}
+	return out
+}()
@@ -0,0 +1,231 @@
+// Package lang normalizes the languages that are passed to translators, like
+// "de", "German", "de-DE" or "pt-BR", into canonical BCP 47 tags and the
+// English names that are used in prompts.
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrUnknown is returned by [Parse] for languages that are neither a known
+// language name nor a well-formed tag of a known language.
+var ErrUnknown = errors.New("unknown language")
+
+// Language is a normalized language.
+type Language struct {
+	// Tag is the canonical BCP 47 tag of the language, like "de", "pt-BR" or
+	// "zh-Hant".
+	Tag string
+
+	// Name is the English name of the language, like "German", "Brazilian
+	// Portuguese" or "German (Switzerland)".
+	Name string
+}
+
+// String returns the name of the language.
+func (l Language) String() string {
+	return l.Name
+}
+
+var tagPattern = regexp.MustCompile(`^([A-Za-z]{2,3})(?:[-_]([A-Za-z]{4}))?(?:[-_]([A-Za-z]{2}|[0-9]{3}))?$`)
+
+// Parse normalizes a language that is given as a BCP 47 tag, like "de",
+// "de_DE" or "zh-Hant", or as an English name, like "German" or "Brazilian
+// Portuguese". Names may be followed by a region in parentheses, like "German
+// (Switzerland)". Other parentheses, like in "German (formal)", are kept in
+// the name. Unknown languages fail with an error that wraps [ErrUnknown] and
+// suggests the closest known languages.
+func Parse(s string) (Language, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return Language{}, fmt.Errorf("%w: empty language", ErrUnknown)
+	}
+
+	if l, ok, err := parseTag(s); ok || err != nil {
+		return l, err
+	}
+
+	if l, ok := parseName(s); ok {
+		return l, nil
+	}
+
+	if suggestions := Suggest(s); len(suggestions) > 0 {
+		return Language{}, fmt.Errorf("%w %q (did you mean %s?)", ErrUnknown, s, quoteList(suggestions))
+	}
+	return Language{}, fmt.Errorf("%w %q", ErrUnknown, s)
+}
+
+// parseTag parses a BCP 47 tag. It reports false if s is not a tag of a known
+// language, so that it can be parsed as a name, like "Lao".
+func parseTag(s string) (Language, bool, error) {
+	m := tagPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Language{}, false, nil
+	}
+
+	base := strings.ToLower(m[1])
+	if _, ok := languages[base]; !ok {
+		return Language{}, false, nil
+	}
+
+	parts := []string{base}
+	if script := m[2]; script != "" {
+		script = strings.ToUpper(script[:1]) + strings.ToLower(script[1:])
+		if _, ok := scripts[script]; !ok {
+			return Language{}, false, fmt.Errorf("%w %q: unknown script %q", ErrUnknown, s, script)
+		}
+		parts = append(parts, script)
+	}
+	if region := strings.ToUpper(m[3]); region != "" {
+		if _, ok := regions[region]; !ok {
+			return Language{}, false, fmt.Errorf("%w %q: unknown region %q", ErrUnknown, s, region)
+		}
+		parts = append(parts, region)
+	}
+
+	tag := strings.Join(parts, "-")
+	return Language{Tag: tag, Name: tagName(tag)}, true, nil
+}
+
+// tagName returns the English name of a canonical tag.
+func tagName(tag string) string {
+	if name, ok := variants[tag]; ok {
+		return name
+	}
+
+	parts := strings.Split(tag, "-")
+	var details []string
+	for _, part := range parts[1:] {
+		if name, ok := scripts[part]; ok {
+			details = append(details, name)
+		} else {
+			details = append(details, regions[part])
+		}
+	}
+
+	name := languages[parts[0]]
+	if len(details) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(details, ", ") + ")"
+}
+
+// parseName parses the English name of a language.
+func parseName(s string) (Language, bool) {
+	if tag, ok := names[strings.ToLower(s)]; ok {
+		return Language{Tag: tag, Name: tagName(tag)}, true
+	}
+
+	// "German (Switzerland)" or "German (formal)"
+	open := strings.Index(s, "(")
+	if open <= 0 || !strings.HasSuffix(s, ")") {
+		return Language{}, false
+	}
+	base, ok := parseName(strings.TrimSpace(s[:open]))
+	if !ok {
+		return Language{}, false
+	}
+
+	detail := strings.TrimSpace(s[open+1 : len(s)-1])
+	if region, ok := regionNames[strings.ToLower(detail)]; ok && !strings.Contains(base.Tag, "-") {
+		tag := base.Tag + "-" + region
+		return Language{Tag: tag, Name: tagName(tag)}, true
+	}
+
+	return Language{Tag: base.Tag, Name: base.Name + " (" + detail + ")"}, true
+}
+
+// Suggest returns up to three known language names and tags that are closest
+// to s, for example "German" for "Germann".
+func Suggest(s string) []string {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	type candidate struct {
+		text     string
+		distance int
+	}
+
+	limit := len(s) / 3
+	if limit < 1 {
+		limit = 1
+	}
+
+	seen := make(map[string]bool)
+	var candidates []candidate
+	add := func(text string) {
+		if seen[text] {
+			return
+		}
+		seen[text] = true
+		if d := distance(s, strings.ToLower(text)); d <= limit {
+			candidates = append(candidates, candidate{text: text, distance: d})
+		}
+	}
+	for _, tag := range names {
+		add(tagName(tag))
+	}
+	for code := range languages {
+		add(code)
+	}
+	for tag := range variants {
+		add(tag)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].text < candidates[j].text
+	})
+
+	var out []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		out = append(out, candidates[i].text)
+	}
+	return out
+}
+
+// distance returns the Levenshtein distance of a and b.
+func distance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < curr[j] {
+				curr[j] = d
+			}
+			if d := curr[j-1] + 1; d < curr[j] {
+				curr[j] = d
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(br)]
+}
+
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,63 @@
+package lang_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modernice/dragoman/lang"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]lang.Language{
+		"de":                   {Tag: "de", Name: "German"},
+		"German":               {Tag: "de", Name: "German"},
+		" german ":             {Tag: "de", Name: "German"},
+		"de-DE":                {Tag: "de-DE", Name: "German (Germany)"},
+		"de_de":                {Tag: "de-DE", Name: "German (Germany)"},
+		"pt-BR":                {Tag: "pt-BR", Name: "Brazilian Portuguese"},
+		"Brazilian Portuguese": {Tag: "pt-BR", Name: "Brazilian Portuguese"},
+		"zh-hant-tw":           {Tag: "zh-Hant-TW", Name: "Chinese (Traditional, Taiwan)"},
+		"es-419":               {Tag: "es-419", Name: "Latin American Spanish"},
+		"fil":                  {Tag: "fil", Name: "Filipino"},
+		"Lao":                  {Tag: "lo", Name: "Lao"},
+		"Farsi":                {Tag: "fa", Name: "Persian"},
+		"German (Switzerland)": {Tag: "de-CH", Name: "Swiss German"},
+		"French (Belgium)":     {Tag: "fr-BE", Name: "French (Belgium)"},
+		"German (formal)":      {Tag: "de", Name: "German (formal)"},
+	}
+
+	for input, want := range tests {
+		got, err := lang.Parse(input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Parse(%q) should return %+v; got %+v", input, want, got)
+		}
+	}
+}
+
+func TestParse_unknown(t *testing.T) {
+	tests := map[string]string{
+		"Germann":   `did you mean "German"?`,
+		"Portugese": `did you mean "Portuguese"?`,
+		"dee":       `"de"`,
+		"de-XX":     `unknown region "XX"`,
+		"sr-Abcd":   `unknown script "Abcd"`,
+		"Klingon":   `unknown language "Klingon"`,
+		"":          "empty language",
+	}
+
+	for input, want := range tests {
+		_, err := lang.Parse(input)
+		if !errors.Is(err, lang.ErrUnknown) {
+			t.Errorf("Parse(%q) should fail with %v; got %v", input, lang.ErrUnknown, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error of Parse(%q) should contain %q; got %q", input, want, err)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package dragoman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LengthConstraint limits the length of translations relative to the length
+// of their source, for example for UI strings that must fit into the space of
+// the source text. Lengths are counted in characters. The zero value does not
+// limit the length.
+type LengthConstraint struct {
+	// MaxRatio is the maximum length of a translation as a multiple of the
+	// length of its source, e.g. 1.2 for translations that are at most 20%
+	// longer than their source.
+	MaxRatio float64
+
+	// Retries is the number of times the texts whose translation exceeds the
+	// limit are translated again, with an instruction that asks the model for
+	// a shorter translation. Translations that still exceed the limit are
+	// kept and reported to [TranslateParams.OnOverlong].
+	Retries int
+}
+
+// OverlongText is a text whose translation exceeds the limit of the
+// [LengthConstraint] of the [TranslateParams] after all retries.
+type OverlongText struct {
+	// Key is the key path of the text if the chunk is a JSON document, or an
+	// empty string if the text is the whole chunk.
+	Key string
+
+	// Source is the source text.
+	Source string
+
+	// Translation is the shortest translation of the text.
+	Translation string
+
+	// Limit is the maximum number of characters of the translation.
+	Limit int
+}
+
+func (c LengthConstraint) enabled() bool {
+	return c.MaxRatio > 0
+}
+
+// limit returns the maximum number of characters of the translation of the
+// given source text.
+func (c LengthConstraint) limit(source string) int {
+	return int(math.Ceil(c.MaxRatio * float64(utf8.RuneCountInString(source))))
+}
+
+// instruction returns the prompt rule of the constraint.
+func (c LengthConstraint) instruction() string {
+	return fmt.Sprintf("Keep the translation of every text at most %g times as long as the source text.", c.MaxRatio)
+}
+
+// constrainLength translates the texts of a translated chunk again whose
+// translation exceeds the limit of the length constraint of the params, and
+// keeps the new translations that are shorter. If the chunk is a JSON
+// document, the texts are its string values, which are translated again as a
+// document of only the overlong keys; otherwise the text is the whole chunk.
+func (t *Translator) constrainLength(ctx context.Context, logger *slog.Logger, chunk, translated string, params TranslateParams) (string, error) {
+	constraint := params.LengthConstraint
+	if !constraint.enabled() {
+		return translated, nil
+	}
+
+	strict := params
+	strict.LengthConstraint = LengthConstraint{}
+	strict.ValidationRetries = 0
+
+	var sourceMap, translatedMap map[string]any
+	if json.Unmarshal([]byte(chunk), &sourceMap) != nil || json.Unmarshal([]byte(translated), &translatedMap) != nil {
+		limit := constraint.limit(chunk)
+		for attempt := 0; attempt < constraint.Retries && t.engine == nil && textLength(translated) > limit; attempt++ {
+			logger.WarnContext(ctx, "retry overlong translation", "length", textLength(translated), "limit", limit, "attempt", attempt+1)
+
+			strict.Instructions = append(slices.Clone(params.Instructions), fmt.Sprintf(
+				"The translation must not be longer than %d characters. Use shorter wording or common abbreviations if necessary.",
+				limit,
+			))
+
+			shorter, err := t.translateValidChunk(ctx, logger, chunk, strict)
+			if err != nil {
+				if ctx.Err() != nil {
+					return "", err
+				}
+				logger.WarnContext(ctx, "failed to shorten translation", "error", err)
+				break
+			}
+			if textLength(shorter) < textLength(translated) {
+				translated = shorter
+			}
+		}
+
+		if textLength(translated) > limit && params.OnOverlong != nil {
+			params.OnOverlong(OverlongText{Source: chunk, Translation: translated, Limit: limit})
+		}
+		return translated, nil
+	}
+
+	overlong := overlongPaths(sourceMap, translatedMap, constraint)
+	changed := false
+	for attempt := 0; attempt < constraint.Retries && t.engine == nil && len(overlong) > 0; attempt++ {
+		logger.WarnContext(ctx, "retry overlong translations", "keys", formatPaths(overlong), "attempt", attempt+1)
+
+		doc, err := JSONExtract(sourceMap, overlong)
+		if err != nil {
+			return "", fmt.Errorf("extract overlong keys: %w", err)
+		}
+
+		overlongDoc, err := indentJSON(doc)
+		if err != nil {
+			return "", fmt.Errorf("marshal overlong keys: %w", err)
+		}
+
+		limits := mapSlice(overlong, func(path JSONPath) string {
+			return fmt.Sprintf("%s: %d characters", quote(strings.Join(path, ".")), constraint.limit(jsonValue(sourceMap, path).(string)))
+		})
+		strict.Instructions = append(slices.Clone(params.Instructions), fmt.Sprintf(
+			"The translations of the following keys must not be longer than the given number of characters. Use shorter wording or common abbreviations if necessary: %s.",
+			strings.Join(limits, ", "),
+		))
+
+		shorter, err := t.translateValidChunk(ctx, logger, overlongDoc, strict)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", err
+			}
+			logger.WarnContext(ctx, "failed to shorten translations", "error", err)
+			break
+		}
+
+		var shorterMap map[string]any
+		if err := json.Unmarshal([]byte(shorter), &shorterMap); err != nil {
+			logger.WarnContext(ctx, "failed to shorten translations", "error", err)
+			break
+		}
+
+		for _, path := range overlong {
+			text, ok := jsonValue(shorterMap, path).(string)
+			if ok && textLength(text) < textLength(jsonValue(translatedMap, path).(string)) {
+				jsonSetValue(translatedMap, path, text)
+				changed = true
+			}
+		}
+
+		overlong = overlongPaths(sourceMap, translatedMap, constraint)
+	}
+
+	if params.OnOverlong != nil {
+		for _, path := range overlong {
+			source := jsonValue(sourceMap, path).(string)
+			params.OnOverlong(OverlongText{
+				Key:         strings.Join(path, "."),
+				Source:      source,
+				Translation: jsonValue(translatedMap, path).(string),
+				Limit:       constraint.limit(source),
+			})
+		}
+	}
+
+	if !changed {
+		return translated, nil
+	}
+
+	out, err := indentJSON(translatedMap)
+	if err != nil {
+		return "", fmt.Errorf("marshal translated JSON: %w", err)
+	}
+
+	return out, nil
+}
+
+// overlongPaths returns the sorted key paths of the string values of the
+// translated JSON document that exceed the limit of the constraint.
+func overlongPaths(source, translated map[string]any, constraint LengthConstraint) []JSONPath {
+	var paths []JSONPath
+	for _, path := range allKeys(source) {
+		text, ok := jsonValue(source, path).(string)
+		if !ok {
+			continue
+		}
+		translatedText, ok := jsonValue(translated, path).(string)
+		if ok && textLength(translatedText) > constraint.limit(text) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return lessPath(paths[i], paths[j])
+	})
+	return paths
+}
+
+// indentJSON encodes a JSON document with indentation and without escaping
+// HTML characters.
+func indentJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func textLength(text string) int {
+	return utf8.RuneCountInString(text)
+}
@@ -0,0 +1,95 @@
+package dragoman_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestTranslator_Translate_lengthConstraint(t *testing.T) {
+	source := `{"save": "Speichern", "cancel": "Abbrechen", "count": 3}`
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "must not be longer than") {
+			return `{"save": "Save"}`, nil
+		}
+		return `{"save": "Save all changes now", "cancel": "Cancel", "count": 3}`, nil
+	})
+
+	var overlong []dragoman.OverlongText
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:         source,
+		LengthConstraint: dragoman.LengthConstraint{MaxRatio: 1.2, Retries: 2},
+		OnOverlong: func(text dragoman.OverlongText) {
+			overlong = append(overlong, text)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(result), &got); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+
+	want := map[string]any{"save": "Save", "cancel": "Cancel", "count": float64(3)}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Translate() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("model should be called %d times; got %d", 2, len(prompts))
+	}
+
+	if !strings.Contains(prompts[0], "at most 1.2 times as long") {
+		t.Fatalf("prompt should contain the length rule; got\n\n%s", prompts[0])
+	}
+
+	if !strings.Contains(prompts[1], `"save": 11 characters`) || strings.Contains(prompts[1], "Abbrechen") {
+		t.Fatalf("retry should only translate the overlong keys with their limits; got\n\n%s", prompts[1])
+	}
+
+	if len(overlong) != 0 {
+		t.Fatalf("no texts should be reported as overlong; got %+v", overlong)
+	}
+}
+
+func TestTranslator_Translate_lengthConstraint_exhausted(t *testing.T) {
+	var calls int
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		calls++
+		return "Speichern Sie alle Änderungen", nil
+	})
+
+	var overlong []dragoman.OverlongText
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:         "Save changes",
+		LengthConstraint: dragoman.LengthConstraint{MaxRatio: 1.5, Retries: 1},
+		OnOverlong: func(text dragoman.OverlongText) {
+			overlong = append(overlong, text)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	if strings.TrimSpace(result) != "Speichern Sie alle Änderungen" {
+		t.Fatalf("Translate() should keep the overlong translation; got %q", result)
+	}
+
+	if calls != 2 {
+		t.Fatalf("model should be called %d times; got %d", 2, calls)
+	}
+
+	want := []dragoman.OverlongText{{Source: "Save changes", Translation: "Speichern Sie alle Änderungen", Limit: 18}}
+	if !cmp.Equal(want, overlong) {
+		t.Fatalf("overlong texts mismatch (-want +got):\n%s", cmp.Diff(want, overlong))
+	}
+}
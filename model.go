@@ -1,6 +1,9 @@
 package dragoman
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 // Model is an interface that represents a chat-based translation model. It
 // provides a method called Chat, which takes a context and a prompt string as
@@ -24,3 +27,61 @@ type ModelFunc func(context.Context, string) (string, error)
 func (chat ModelFunc) Chat(ctx context.Context, prompt string) (string, error) {
 	return chat(ctx, prompt)
 }
+
+// Role is the role of the author of a [Message].
+type Role string
+
+// The roles of the authors of messages.
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a message of a conversation with a chat model.
+type Message struct {
+	Role    Role
+	Content string
+}
+
+// MessageModel is a [Model] that accepts a conversation of messages instead of
+// a single prompt. The [Translator] and the [Improver] send the instructions
+// of a task to a MessageModel as a system message and the document as a user
+// message. The system prompt of the [TranslateParams] is prepended to the
+// system message, and its examples are sent as pairs of user and assistant
+// messages. Other models receive all of it as a single prompt.
+type MessageModel interface {
+	Model
+
+	// ChatMessages returns the response of the model to the conversation.
+	ChatMessages(context.Context, []Message) (string, error)
+}
+
+// AsMessageModel returns the model as a [MessageModel]. If the model does not
+// implement MessageModel, the returned model sends the messages of a
+// conversation to its Chat method as a single prompt, joined by
+// [JoinMessages].
+func AsMessageModel(model Model) MessageModel {
+	if m, ok := model.(MessageModel); ok {
+		return m
+	}
+	return promptModel{model}
+}
+
+type promptModel struct {
+	Model
+}
+
+func (m promptModel) ChatMessages(ctx context.Context, msgs []Message) (string, error) {
+	return m.Chat(ctx, JoinMessages(msgs))
+}
+
+// JoinMessages joins the contents of the messages into a single prompt,
+// separated by blank lines.
+func JoinMessages(msgs []Message) string {
+	contents := make([]string, len(msgs))
+	for i, msg := range msgs {
+		contents[i] = msg.Content
+	}
+	return strings.Join(contents, "\n\n")
+}
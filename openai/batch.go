@@ -0,0 +1,152 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/modernice/dragoman"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrBatchNotCompleted is returned by [*Client.CollectBatch] if the batch job
+// has not completed yet.
+var ErrBatchNotCompleted = errors.New("batch not completed")
+
+// SubmitBatch submits the prompts as a job to the OpenAI Batch API, which
+// processes them asynchronously within 24 hours at a lower price than the chat
+// API. Identical prompts are submitted only once. The returned id can be
+// passed to [*Client.CollectBatch] to retrieve the responses once the job has
+// completed. Only chat models are supported.
+func (c *Client) SubmitBatch(ctx context.Context, prompts []string) (string, error) {
+	if !c.isChat() {
+		return "", fmt.Errorf("model %q does not support the batch API", c.model)
+	}
+
+	var req openai.CreateBatchWithUploadFileRequest
+	req.Endpoint = openai.BatchEndpointChatCompletions
+
+	seen := make(map[string]bool)
+	for _, prompt := range prompts {
+		id := promptID(prompt)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		req.AddChatCompletion(id, c.chatRequest([]dragoman.Message{{Role: dragoman.RoleUser, Content: prompt}}))
+	}
+
+	if len(req.Lines) == 0 {
+		return "", errors.New("no prompts to submit")
+	}
+
+	c.logger.DebugContext(ctx, "submit batch", "requests", len(req.Lines))
+
+	resp, err := c.client.CreateBatchWithUploadFile(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("create batch: %w", err)
+	}
+
+	return resp.ID, nil
+}
+
+// CollectBatch retrieves the responses of a completed batch job. It returns an
+// error that wraps [ErrBatchNotCompleted] if the job is still in progress. The
+// returned [*BatchResults] answer the prompts of the job and can be used as a
+// model to replay the translations that were submitted.
+func (c *Client) CollectBatch(ctx context.Context, id string) (*BatchResults, error) {
+	batch, err := c.client.RetrieveBatch(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("retrieve batch: %w", err)
+	}
+
+	if batch.Status != "completed" {
+		return nil, fmt.Errorf("%w: batch %q is %s (%d/%d requests completed)", ErrBatchNotCompleted, id, batch.Status, batch.RequestCounts.Completed, batch.RequestCounts.Total)
+	}
+
+	if batch.OutputFileID == nil {
+		return nil, fmt.Errorf("batch %q has no output file", id)
+	}
+
+	content, err := c.client.GetFileContent(ctx, *batch.OutputFileID)
+	if err != nil {
+		return nil, fmt.Errorf("download batch output: %w", err)
+	}
+	defer content.Close()
+
+	return parseBatchOutput(content)
+}
+
+// BatchResults are the responses of a completed batch job, keyed by their
+// prompt. BatchResults implements the Model interface of dragoman, so that
+// the submitted prompts can be replayed through a translator.
+type BatchResults struct {
+	responses map[string]string
+}
+
+// Chat returns the response to the prompt from the batch results. It returns
+// an error if the prompt was not part of the batch job or if its request
+// failed.
+func (r *BatchResults) Chat(_ context.Context, prompt string) (string, error) {
+	resp, ok := r.responses[promptID(prompt)]
+	if !ok {
+		return "", errors.New("prompt not found in batch results")
+	}
+	return resp, nil
+}
+
+// Len returns the number of successful responses of the batch job.
+func (r *BatchResults) Len() int {
+	return len(r.responses)
+}
+
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response struct {
+		StatusCode int                           `json:"status_code"`
+		Body       openai.ChatCompletionResponse `json:"body"`
+	} `json:"response"`
+}
+
+func parseBatchOutput(r io.Reader) (*BatchResults, error) {
+	results := BatchResults{responses: make(map[string]string)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var out batchOutputLine
+		if err := json.Unmarshal(line, &out); err != nil {
+			return nil, fmt.Errorf("decode batch output: %w", err)
+		}
+
+		if out.Response.StatusCode != 200 || len(out.Response.Body.Choices) == 0 {
+			continue
+		}
+
+		results.responses[out.CustomID] = strings.TrimSpace(out.Response.Body.Choices[0].Message.Content)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read batch output: %w", err)
+	}
+
+	return &results, nil
+}
+
+// promptID returns the custom id of the batch request for the given prompt.
+func promptID(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
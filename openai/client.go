@@ -2,12 +2,20 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/backoff"
+	"github.com/modernice/dragoman/internal/logging"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -34,6 +42,20 @@ const (
 	// adjusted to control how long the system will wait for a chunk before
 	// considering the operation timed out.
 	DefaultChunkTimeout = 5 * time.Second
+
+	// DefaultMaxRetries is the default number of times a failed request is
+	// retried if the OpenAI API responds with a rate limit or server error.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryBackoff is the default base delay between retries. The delay
+	// doubles with every attempt and is randomized with jitter. A Retry-After
+	// header sent by the API takes precedence over the computed delay.
+	DefaultRetryBackoff = time.Second
+
+	// MaxRetryBackoff is the maximum delay between retries that is computed
+	// from the base delay, so that many retries do not wait for minutes. A
+	// Retry-After header sent by the API is not limited.
+	MaxRetryBackoff = backoff.Max
 )
 
 // Client is a configurable interface to the OpenAI API. It allows for the
@@ -42,14 +64,32 @@ const (
 // set for API requests.
 type Client struct {
 	model          string
+	baseURL        string
 	responseFormat openai.ChatCompletionResponseFormatType
+	schema         any
 	maxTokens      int
+	contextWindow  int
 	temperature    float32
 	topP           float32
+	seed           *int
+	stop           []string
+	frequency      float32
+	presence       float32
 	timeout        time.Duration
 	chunkTimeout   time.Duration
+	maxRetries     int
+	retryBackoff   time.Duration
+	limiter        *rateLimiter
 	verbose        bool
+	logger         *slog.Logger
 	stream         io.Writer
+	onChunk        func(string)
+	recordDir      string
+	replayDir      string
+	organization   string
+	headers        http.Header
+	httpClient     *http.Client
+	embeddingModel string
 	client         *openai.Client
 }
 
@@ -68,6 +108,16 @@ func Model(model string) Option {
 	}
 }
 
+// BaseURL sends the requests of the Client to an OpenAI-compatible API at the
+// given base URL instead of the OpenAI API, for example to OpenRouter
+// ("https://openrouter.ai/api/v1"), Together, Groq, vLLM or LM Studio. All
+// models of such APIs are used as chat models.
+func BaseURL(url string) Option {
+	return func(m *Client) {
+		m.baseURL = url
+	}
+}
+
 // ResponseFormat configures the format of the response received from the OpenAI
 // API when generating text completions. It specifies how the response should be
 // structured, which can be either plain text or a structured format that
@@ -81,6 +131,21 @@ func ResponseFormat[Format string | openai.ChatCompletionResponseFormatType](for
 	}
 }
 
+// StructuredOutput asks chat models to respond with JSON that follows the
+// given schema, for example one that is generated by dragoman.JSONSchema from
+// the document that is translated. The model is forced to call a function
+// whose parameters are described by the schema, which makes changed keys and
+// invalid JSON much less likely, but the API does not enforce the schema, so
+// responses should still be validated. The arguments of the function call are
+// returned as the response, or the content of the response if a compatible
+// API ignores the function. StructuredOutput takes precedence over
+// ResponseFormat and has no effect on completion models.
+func StructuredOutput(schema any) Option {
+	return func(m *Client) {
+		m.schema = schema
+	}
+}
+
 // MaxTokens configures the maximum number of tokens that the Client can use for
 // generating text completions. It accepts an integer value and returns an
 // [Option] to modify a [Client] instance.
@@ -105,6 +170,42 @@ func TopP(topP float32) Option {
 	}
 }
 
+// Seed makes the sampling of chat models deterministic on a best-effort
+// basis: repeated requests with the same seed and parameters should return the
+// same result. Completion models ignore the seed.
+func Seed(seed int) Option {
+	return func(m *Client) {
+		m.seed = &seed
+	}
+}
+
+// Stop sets up to 4 sequences where the model stops generating further
+// tokens, for example to cut off trailing commentary of the model. The stop
+// sequences are not part of the response.
+func Stop(sequences []string) Option {
+	return func(m *Client) {
+		m.stop = sequences
+	}
+}
+
+// FrequencyPenalty sets the frequency penalty between -2 and 2. Positive values
+// penalize tokens by how often they already appeared in the response, which
+// makes the model less likely to repeat the same line verbatim.
+func FrequencyPenalty(penalty float32) Option {
+	return func(m *Client) {
+		m.frequency = penalty
+	}
+}
+
+// PresencePenalty sets the presence penalty between -2 and 2. Positive values
+// penalize tokens that already appeared in the response, which makes the
+// model more likely to talk about new topics.
+func PresencePenalty(penalty float32) Option {
+	return func(m *Client) {
+		m.presence = penalty
+	}
+}
+
 // ChunkTimeout sets the maximum duration a Client should wait for a chunk of
 // data during streaming operations before timing out. This is configured as an
 // Option that modifies the chunkTimeout field of a Client instance.
@@ -125,14 +226,44 @@ func Timeout(timeout time.Duration) Option {
 	}
 }
 
+// MaxRetries sets the maximum number of times a failed request is retried if
+// the OpenAI API responds with a rate limit (429) or server error (5xx). A
+// value of 0 disables retries.
+func MaxRetries(maxRetries int) Option {
+	return func(m *Client) {
+		m.maxRetries = maxRetries
+	}
+}
+
+// RetryBackoff sets the base delay between retries of failed requests. The
+// delay doubles with every attempt up to [MaxRetryBackoff] and is randomized
+// with jitter. If the API responds with a Retry-After header, the delay from
+// the header is used instead.
+func RetryBackoff(backoff time.Duration) Option {
+	return func(m *Client) {
+		m.retryBackoff = backoff
+	}
+}
+
 // Verbose sets the verbosity level of the Client instance. If set to true,
-// debug logs will be printed during API requests.
+// debug logs will be printed during API requests. The logs are written to the
+// output of the standard logger unless a [Logger] is configured.
 func Verbose(verbose bool) Option {
 	return func(m *Client) {
 		m.verbose = verbose
 	}
 }
 
+// Logger sets the logger that receives structured records of the requests of
+// the Client: debug records for the configuration, the prompts, rate limit
+// waits and the used tokens, and warnings for retried requests. By default,
+// nothing is logged.
+func Logger(logger *slog.Logger) Option {
+	return func(m *Client) {
+		m.logger = logger
+	}
+}
+
 // Stream is an option function that sets the writer to which the generated text
 // completions will be streamed. This allows for real-time processing and
 // display of the generated text.
@@ -142,6 +273,18 @@ func Stream(stream io.Writer) Option {
 	}
 }
 
+// OnChunk sets a function that is called with every chunk of text that is
+// streamed from the model, for example to display the response in a user
+// interface while it is generated. Unlike [Stream], it does not add line
+// breaks between responses. Independently of OnChunk, the Client reports the
+// response received so far through [dragoman.ReportPartial], so that
+// [dragoman.TranslateParams.OnProgress] receives the partial translations.
+func OnChunk(fn func(text string)) Option {
+	return func(m *Client) {
+		m.onChunk = fn
+	}
+}
+
 // New creates a new Client instance with the specified API token and optional
 // configuration options. The Client allows for the generation of text
 // completions using various models, with adjustable parameters for token count,
@@ -154,113 +297,222 @@ func New(apiToken string, opts ...Option) *Client {
 		topP:         DefaultTopP,
 		timeout:      DefaultTimeout,
 		chunkTimeout: DefaultChunkTimeout,
-		client:       openai.NewClient(apiToken),
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
 	}
 	for _, opt := range opts {
 		opt(&c)
 	}
 
+	config := openai.DefaultConfig(apiToken)
+	config.OrgID = c.organization
+	config.HTTPClient = c.newHTTPClient()
+	if c.baseURL != "" {
+		config.BaseURL = strings.TrimSuffix(c.baseURL, "/")
+	}
+	c.client = openai.NewClientWithConfig(config)
+
 	if c.model == "" {
 		c.model = DefaultModel
 	}
 
-	c.debug("Model: %s", c.model)
-	c.debug("Temperature: %f", c.temperature)
-	c.debug("TopP: %f", c.topP)
+	switch {
+	case c.logger != nil:
+	case c.verbose:
+		c.logger = slog.New(logging.NewHandler(log.Writer(), "OpenAI"))
+	default:
+		c.logger = logging.Discard()
+	}
 
+	attrs := []any{"model", c.model, "temperature", formatFloat(c.temperature), "top_p", formatFloat(c.topP)}
+	if c.baseURL != "" {
+		attrs = append(attrs, "base_url", c.baseURL)
+	}
 	if c.maxTokens > 0 {
-		c.debug("Max tokens: %d", c.maxTokens)
+		attrs = append(attrs, "max_tokens", c.maxTokens)
+	}
+	if c.contextWindow > 0 {
+		attrs = append(attrs, "context_window", c.contextWindow)
+	}
+	if c.seed != nil {
+		attrs = append(attrs, "seed", *c.seed)
 	}
+	if len(c.stop) > 0 {
+		attrs = append(attrs, "stop", c.stop)
+	}
+	if c.frequency != 0 {
+		attrs = append(attrs, "frequency_penalty", formatFloat(c.frequency))
+	}
+	if c.presence != 0 {
+		attrs = append(attrs, "presence_penalty", formatFloat(c.presence))
+	}
+	c.logger.Debug("create client", attrs...)
 
 	return &c
 }
 
 // Chat is a method of the Client type that generates a text completion based on
 // the provided prompt. The generated text completion is returned as a string.
+// Requests that fail because of rate limits or server errors are retried with
+// exponential backoff, up to the configured number of retries. Prompts that are
+// refused by the content filter return a [*RefusalError].
 func (c *Client) Chat(ctx context.Context, prompt string) (string, error) {
-	resp, err := c.createCompletion(ctx, prompt)
+	return c.ChatMessages(ctx, []dragoman.Message{{Role: dragoman.RoleUser, Content: prompt}})
+}
+
+// ChatMessages is like Chat, but sends a conversation of messages, like a
+// system message and few-shot examples, instead of a single prompt. The
+// messages of completion models are joined into a single prompt.
+func (c *Client) ChatMessages(ctx context.Context, msgs []dragoman.Message) (string, error) {
+	if c.replayDir != "" {
+		return c.replay(ctx, msgs)
+	}
+
+	resp, err := c.withRetries(ctx, func(ctx context.Context) (string, error) {
+		if err := c.waitForRateLimit(ctx, dragoman.JoinMessages(msgs)); err != nil {
+			return "", err
+		}
+		return c.createCompletion(ctx, msgs)
+	})
 	if err != nil {
-		return "", err
+		return "", asRefusal(err)
+	}
+	resp = strings.TrimSpace(resp)
+
+	if c.recordDir != "" {
+		if err := c.record(msgs, resp); err != nil {
+			return "", fmt.Errorf("record response: %w", err)
+		}
 	}
 
-	return strings.TrimSpace(resp), nil
+	return resp, nil
 }
 
-func (c *Client) createCompletion(ctx context.Context, prompt string) (string, error) {
-	if c.timeout > 0 {
-		c.debug("Setting timeout to %s", c.timeout)
+// reportUsage reports the usage of a request using [dragoman.ReportUsage] and
+// logs it. If the API did not return the usage, the tokens are counted with the
+// tokenizer of the model, so the counts are estimates.
+func (c *Client) reportUsage(ctx context.Context, msgs []dragoman.Message, resp string, usage *openai.Usage) {
+	u := dragoman.Usage{Requests: 1}
+	if usage != nil {
+		u.PromptTokens = usage.PromptTokens
+		u.CompletionTokens = usage.CompletionTokens
+	} else {
+		// Token counts are only estimates, so a failed count does not fail the
+		// request.
+		u.PromptTokens, _ = PromptTokens(c.model, dragoman.JoinMessages(msgs))
+		u.CompletionTokens, _ = PromptTokens(c.model, resp)
+	}
+
+	dragoman.ReportUsage(ctx, u)
+
+	c.logger.DebugContext(ctx, "tokens used", "model", c.model, "prompt_tokens", u.PromptTokens, "completion_tokens", u.CompletionTokens, "estimated", usage == nil)
+}
+
+// waitForRateLimit blocks until the prompt can be sent without exceeding the
+// rate limit of the Client.
+func (c *Client) waitForRateLimit(ctx context.Context, prompt string) error {
+	if c.limiter == nil {
+		return nil
+	}
 
+	// Token counts are only estimates, so a failed count does not fail the
+	// request.
+	tokens, _ := PromptTokens(c.model, prompt)
+
+	waited, err := c.limiter.wait(ctx, 2*tokens)
+	if waited > 0 {
+		c.logger.DebugContext(ctx, "wait for rate limit", "duration", waited.Round(time.Millisecond))
+	}
+	return err
+}
+
+func (c *Client) createCompletion(ctx context.Context, msgs []dragoman.Message) (string, error) {
+	if c.timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, c.timeout)
 		defer cancel()
 	}
 
-	if isChatModel(c.model) {
-		c.debug("Creating chat completion with prompt:\n\n%s", prompt)
+	if c.isChat() {
+		c.logger.DebugContext(ctx, "create chat completion", "model", c.model, "timeout", c.timeout, "prompt", dragoman.JoinMessages(msgs))
 
-		msgs := []openai.ChatCompletionMessage{{
-			Role:    openai.ChatMessageRoleUser,
-			Content: prompt,
-		}}
+		req := c.chatRequest(msgs)
 
-		if c.responseFormat == "json_object" {
-			msgs = append([]openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are a translator for JSON files. You only translate text fields, preserving the JSON structure and keys.",
-				},
-			}, msgs...)
+		// The completion tokens must fit into the context window together
+		// with the prompt.
+		if req.MaxTokens > 0 {
+			if promptTokens, err := ChatTokens(c.model, req.Messages); err == nil {
+				if limit, ok := c.completionLimit(promptTokens); ok && limit < req.MaxTokens {
+					req.MaxTokens = limit
+				}
+			}
 		}
 
-		var responseFormat *openai.ChatCompletionResponseFormat
-		if c.responseFormat != "" {
-			responseFormat = &openai.ChatCompletionResponseFormat{Type: c.responseFormat}
+		// Compatible APIs may reject the stream options, so only the OpenAI
+		// API is asked for the usage of the request.
+		includeUsage := c.baseURL == ""
+		if includeUsage {
+			req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
 		}
 
-		stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-			Model:          c.model,
-			MaxTokens:      c.maxTokens,
-			Temperature:    c.temperature,
-			TopP:           c.topP,
-			Messages:       msgs,
-			ResponseFormat: responseFormat,
-		})
+		stream, err := c.client.CreateChatCompletionStream(ctx, req)
 		if err != nil {
 			return "", err
 		}
-		return streamReader(c, stream, c.chunkTimeout).read(ctx, func(stream *openai.ChatCompletionStream) (chunk, error) {
+		resp, usage, err := streamReader(c, stream, c.chunkTimeout, includeUsage).read(ctx, func(stream *openai.ChatCompletionStream) (chunk, error) {
 			resp, err := stream.Recv()
 			if err != nil {
 				return chunk{}, err
 			}
+			if len(resp.Choices) == 0 {
+				return chunk{usage: resp.Usage}, nil
+			}
+			// Compatible APIs that do not support function calling respond
+			// with plain content, which is used as is.
+			text := resp.Choices[0].Delta.Content
+			if calls := resp.Choices[0].Delta.ToolCalls; c.schema != nil && len(calls) > 0 {
+				text = toolArguments(calls)
+			}
 			return chunk{
-				text:         resp.Choices[0].Delta.Content,
+				text:         text,
 				finishReason: string(resp.Choices[0].FinishReason),
+				usage:        resp.Usage,
 			}, nil
 		})
+		if err != nil {
+			return resp, err
+		}
+		c.reportUsage(ctx, msgs, resp, usage)
+		return resp, nil
 	}
 
-	c.debug("Creating completion with prompt:\n\n%s", prompt)
+	prompt := dragoman.JoinMessages(msgs)
+	c.logger.DebugContext(ctx, "create completion", "model", c.model, "timeout", c.timeout, "prompt", prompt)
 
 	promptTokens, err := PromptTokens(c.model, prompt)
 	if err != nil {
 		return "", fmt.Errorf("compute prompt tokens: %w", err)
 	}
 
-	// -1 because "This model's maximum context length is 8192 tokens. However, you requested 8192 tokens" ???
-	maxTokens := c.maxTokens - promptTokens - 1
+	maxTokens, ok := c.completionLimit(promptTokens)
+	if !ok {
+		return "", fmt.Errorf("prompt of %d tokens exceeds the context window of %d tokens of %q", promptTokens, c.ContextWindow(), c.model)
+	}
 
 	stream, err := c.client.CreateCompletionStream(ctx, openai.CompletionRequest{
-		Model:       c.model,
-		MaxTokens:   maxTokens,
-		Temperature: c.temperature,
-		TopP:        c.topP,
-		Prompt:      prompt,
+		Model:            c.model,
+		MaxTokens:        maxTokens,
+		Temperature:      c.temperature,
+		TopP:             c.topP,
+		Stop:             c.stop,
+		FrequencyPenalty: c.frequency,
+		PresencePenalty:  c.presence,
+		Prompt:           prompt,
 	})
 	if err != nil {
 		return "", err
 	}
-	return streamReader(c, stream, c.chunkTimeout).read(ctx, func(stream *openai.CompletionStream) (chunk, error) {
+	resp, _, err := streamReader(c, stream, c.chunkTimeout, false).read(ctx, func(stream *openai.CompletionStream) (chunk, error) {
 		resp, err := stream.Recv()
 		if err != nil {
 			return chunk{}, err
@@ -270,39 +522,126 @@ func (c *Client) createCompletion(ctx context.Context, prompt string) (string, e
 			finishReason: resp.Choices[0].FinishReason,
 		}, nil
 	})
+	if err != nil {
+		return resp, err
+	}
+	c.reportUsage(ctx, msgs, resp, nil)
+	return resp, nil
+}
+
+// chatRequest returns the chat completion request for the given messages.
+func (c *Client) chatRequest(messages []dragoman.Message) openai.ChatCompletionRequest {
+	msgs := make([]openai.ChatCompletionMessage, len(messages))
+	for i, msg := range messages {
+		msgs[i] = openai.ChatCompletionMessage{Role: string(msg.Role), Content: msg.Content}
+	}
+
+	if c.responseFormat == "json_object" || c.schema != nil {
+		msgs = append([]openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a translator for JSON files. You only translate text fields, preserving the JSON structure and keys.",
+			},
+		}, msgs...)
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:            c.model,
+		MaxTokens:        c.maxTokens,
+		Temperature:      c.temperature,
+		TopP:             c.topP,
+		Seed:             c.seed,
+		Stop:             c.stop,
+		FrequencyPenalty: c.frequency,
+		PresencePenalty:  c.presence,
+		Messages:         msgs,
+	}
+
+	switch {
+	case c.schema != nil:
+		req.Tools = []openai.Tool{{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        translationFunction,
+				Description: "Returns the translated JSON document.",
+				Parameters:  c.schema,
+			},
+		}}
+		req.ToolChoice = openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: translationFunction},
+		}
+	case c.responseFormat != "":
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: c.responseFormat}
+	}
+
+	return req
+}
+
+// formatFloat formats a float32 without the rounding errors of its float64
+// representation.
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// translationFunction is the name of the function that chat models are forced
+// to call if the client uses structured output.
+const translationFunction = "translation"
+
+// toolArguments returns the streamed arguments of the forced function call.
+func toolArguments(calls []openai.ToolCall) string {
+	var args strings.Builder
+	for _, call := range calls {
+		args.WriteString(call.Function.Arguments)
+	}
+	return args.String()
 }
 
 type chunk struct {
 	text         string
 	finishReason string
+	usage        *openai.Usage
 }
 
-func (m *Client) debug(format string, args ...interface{}) {
-	if m.verbose {
-		log.Printf("[OpenAI] %s", fmt.Sprintf(format, args...))
-	}
+// isChat reports whether the model of the Client is a chat model.
+func (c *Client) isChat() bool {
+	return c.baseURL != "" || isChatModel(c.model)
 }
 
+// reasoningModel matches the reasoning models of OpenAI, like "o1" or
+// "o4-mini", which are chat models.
+var reasoningModel = regexp.MustCompile(`^o[0-9]`)
+
 func isChatModel(model string) bool {
-	return strings.HasPrefix(model, "gpt-")
+	model = baseModel(model)
+	return strings.HasPrefix(model, "gpt-") || strings.HasPrefix(model, "chatgpt-") || reasoningModel.MatchString(model)
 }
 
 type chunkReader[Stream any] struct {
-	client  *Client
-	stream  Stream
-	timeout time.Duration
+	client       *Client
+	stream       Stream
+	timeout      time.Duration
+	includeUsage bool
 }
 
-func streamReader[Stream any](client *Client, stream Stream, timeout time.Duration) *chunkReader[Stream] {
+// streamReader returns a reader for the chunks of the stream. If includeUsage
+// is true, the stream is read until the chunk with the usage of the request
+// after the last text chunk.
+func streamReader[Stream any](client *Client, stream Stream, timeout time.Duration, includeUsage bool) *chunkReader[Stream] {
 	return &chunkReader[Stream]{
-		client:  client,
-		stream:  stream,
-		timeout: timeout,
+		client:       client,
+		stream:       stream,
+		timeout:      timeout,
+		includeUsage: includeUsage,
 	}
 }
 
-func (r *chunkReader[Stream]) read(ctx context.Context, getChunk func(Stream) (chunk, error)) (string, error) {
-	var text strings.Builder
+func (r *chunkReader[Stream]) read(ctx context.Context, getChunk func(Stream) (chunk, error)) (string, *openai.Usage, error) {
+	var (
+		text     strings.Builder
+		usage    *openai.Usage
+		finished bool
+	)
 
 	if r.client.stream != nil {
 		fmt.Fprint(r.client.stream, "\n")
@@ -339,26 +678,49 @@ func (r *chunkReader[Stream]) read(ctx context.Context, getChunk func(Stream) (c
 		select {
 		case <-ctx.Done():
 			timeout.Stop()
-			return text.String(), ctx.Err()
+			return text.String(), usage, ctx.Err()
 		case <-timeout.C:
-			return text.String(), fmt.Errorf("token-chunk timeout")
+			return text.String(), usage, fmt.Errorf("token-chunk timeout")
 		case err := <-errC:
 			timeout.Stop()
-			return text.String(), err
+			// The usage chunk is optional, so a stream that ends after
+			// the last text chunk is complete.
+			if finished && errors.Is(err, io.EOF) {
+				return text.String(), usage, nil
+			}
+			return text.String(), usage, err
 		case chunk := <-chunkC:
 			timeout.Stop()
 			text.WriteString(chunk.text)
 
-			if chunk.text != "" && r.client.stream != nil {
-				fmt.Fprint(r.client.stream, chunk.text)
+			if chunk.usage != nil {
+				usage = chunk.usage
+			}
+
+			if chunk.text != "" {
+				if r.client.stream != nil {
+					fmt.Fprint(r.client.stream, chunk.text)
+				}
+				if r.client.onChunk != nil {
+					r.client.onChunk(chunk.text)
+				}
+				dragoman.ReportPartial(ctx, text.String())
 			}
 
-			if chunk.finishReason == string(openai.FinishReasonStop) {
-				return text.String(), nil
+			if chunk.finishReason == string(openai.FinishReasonStop) || chunk.finishReason == string(openai.FinishReasonToolCalls) {
+				finished = true
+			}
+
+			if finished && (!r.includeUsage || usage != nil) {
+				return text.String(), usage, nil
 			}
 
 			if chunk.finishReason == string(openai.FinishReasonLength) {
-				return text.String(), fmt.Errorf("max tokens exceeded")
+				return text.String(), usage, fmt.Errorf("max tokens exceeded")
+			}
+
+			if chunk.finishReason == string(openai.FinishReasonContentFilter) {
+				return text.String(), usage, &RefusalError{Reason: "the response was stopped by the content filter"}
 			}
 		}
 	}
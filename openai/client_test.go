@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Chat_structuredOutput(t *testing.T) {
+	var req map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\": [{\"index\": 0, \"delta\": {\"tool_calls\": [{\"index\": 0, \"function\": {\"arguments\": \"{\\\"a\\\": \\\"Hallo\\\"}\"}}]}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\": [{\"index\": 0, \"delta\": {}, \"finish_reason\": \"tool_calls\"}]}\n\ndata: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"type": "string"}}}
+	client := New("key", BaseURL(srv.URL), Model("m"), StructuredOutput(schema))
+
+	resp, err := client.Chat(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+	if want := `{"a": "Hallo"}`; resp != want {
+		t.Fatalf("expected the arguments of the function call %q; got %q", want, resp)
+	}
+	if _, ok := req["tools"]; !ok {
+		t.Fatalf("expected the request to define the function of the schema; got %v", req)
+	}
+}
+
+func TestClient_Chat_structuredOutput_content(t *testing.T) {
+	srv := chatServer(t, func(http.ResponseWriter) bool { return true })
+
+	client := New("key", BaseURL(srv.URL), Model("m"), StructuredOutput(map[string]any{"type": "object"}))
+
+	resp, err := client.Chat(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+	if resp != "Hallo" {
+		t.Fatalf("expected the content of a response without function call; got %q", resp)
+	}
+}
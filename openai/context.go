@@ -0,0 +1,109 @@
+package openai
+
+import "strings"
+
+// DefaultContextWindow is the context window of models whose context window
+// is unknown and was not configured using [ContextWindow].
+const DefaultContextWindow = 4096
+
+// modelContextWindows contains the context windows of OpenAI models in tokens.
+// Model names are matched by their longest prefix, so that dated model
+// versions use the context window of their family.
+var modelContextWindows = map[string]int{
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-instruct": 4096,
+	"gpt-3.5-turbo-0613":     4096,
+	"gpt-3.5-turbo-16k":      16385,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-4-turbo":            128000,
+	"gpt-4-1106":             128000,
+	"gpt-4-0125":             128000,
+	"gpt-4o":                 128000,
+	"gpt-4.1":                1047576,
+	"gpt-4.5":                128000,
+	"gpt-5":                  400000,
+	"chatgpt-4o":             128000,
+	"o1":                     200000,
+	"o1-mini":                128000,
+	"o1-preview":             128000,
+	"o3":                     200000,
+	"o4-mini":                200000,
+	"davinci-002":            16384,
+	"babbage-002":            16384,
+}
+
+// ModelContextWindow returns the context window of the given model in tokens,
+// which is the maximum number of prompt and completion tokens of a request.
+// Fine-tuned models, like "ft:gpt-4o-mini-2024-07-18:org::id", have the
+// context window of their base model. It reports false if the context window
+// of the model is unknown.
+func ModelContextWindow(model string) (int, bool) {
+	model = baseModel(model)
+
+	var match string
+	for prefix := range modelContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+
+	if match == "" {
+		return 0, false
+	}
+	return modelContextWindows[match], true
+}
+
+// ContextWindow sets the context window of the model of the Client in tokens,
+// for models whose context window is unknown, like models of
+// OpenAI-compatible APIs, or to override the known context window. The
+// completion tokens of requests are limited to the context window minus the
+// prompt tokens.
+func ContextWindow(tokens int) Option {
+	return func(m *Client) {
+		m.contextWindow = tokens
+	}
+}
+
+// ContextWindow returns the context window of the model of the Client: the
+// context window that was configured using [ContextWindow], the known context
+// window of the model, or [DefaultContextWindow].
+func (c *Client) ContextWindow() int {
+	if c.contextWindow > 0 {
+		return c.contextWindow
+	}
+	if tokens, ok := ModelContextWindow(c.model); ok {
+		return tokens
+	}
+	return DefaultContextWindow
+}
+
+// completionLimit returns the maximum number of completion tokens of a
+// request with the given number of prompt tokens, which is the configured
+// maximum, capped to the rest of the context window. It reports false if the
+// prompt does not leave room for a completion.
+func (c *Client) completionLimit(promptTokens int) (int, bool) {
+	// -1 because "This model's maximum context length is 8192 tokens. However, you requested 8192 tokens" ???
+	available := c.ContextWindow() - promptTokens - 1
+	if available <= 0 {
+		return 0, false
+	}
+	if c.maxTokens > 0 && c.maxTokens < available {
+		return c.maxTokens, true
+	}
+	return available, true
+}
+
+// baseModel returns the base model of a fine-tuned model, like
+// "gpt-4o-mini-2024-07-18" for "ft:gpt-4o-mini-2024-07-18:org::id", or the
+// model itself.
+func baseModel(model string) string {
+	if !strings.HasPrefix(model, "ft:") {
+		return model
+	}
+	model = strings.TrimPrefix(model, "ft:")
+	if i := strings.Index(model, ":"); i >= 0 {
+		model = model[:i]
+	}
+	return model
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultEmbeddingModel is the model that embeds texts if no model was set
+// using the EmbeddingModel option.
+const DefaultEmbeddingModel = string(openai.SmallEmbedding3)
+
+// EmbeddingModel sets the model that embeds texts in [Client.Embed], like
+// "text-embedding-3-large" or the embedding model of an OpenAI-compatible API.
+func EmbeddingModel(model string) Option {
+	return func(c *Client) {
+		c.embeddingModel = model
+	}
+}
+
+var _ dragoman.Embedder = (*Client)(nil)
+
+// Embed returns the embeddings of the texts, in the order of the texts.
+// Failed requests are retried like chat completions, and the usage of the
+// request is reported using [dragoman.ReportUsage].
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	model := c.embeddingModel
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+
+	if err := c.waitForRateLimit(ctx, strings.Join(texts, "\n")); err != nil {
+		return nil, err
+	}
+
+	var out [][]float32
+	_, err := c.withRetries(ctx, func(ctx context.Context) (string, error) {
+		if c.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+		}
+
+		c.logger.DebugContext(ctx, "create embeddings", "model", model, "texts", len(texts))
+		start := time.Now()
+
+		resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Input: texts,
+			Model: openai.EmbeddingModel(model),
+		})
+		if err != nil {
+			return "", fmt.Errorf("create embeddings: %w", err)
+		}
+		if len(resp.Data) != len(texts) {
+			return "", fmt.Errorf("create embeddings: got %d embeddings for %d texts", len(resp.Data), len(texts))
+		}
+
+		out = make([][]float32, len(texts))
+		for _, e := range resp.Data {
+			if e.Index < 0 || e.Index >= len(texts) {
+				return "", fmt.Errorf("create embeddings: invalid index %d", e.Index)
+			}
+			out[e.Index] = e.Embedding
+		}
+
+		dragoman.ReportUsage(ctx, dragoman.Usage{Requests: 1, PromptTokens: resp.Usage.PromptTokens})
+		c.logger.DebugContext(ctx, "embeddings created", "model", model, "tokens", resp.Usage.PromptTokens, "duration", time.Since(start).Round(time.Millisecond))
+
+		return "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
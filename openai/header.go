@@ -0,0 +1,75 @@
+package openai
+
+import "net/http"
+
+// Organization sets the ID of the OpenAI organization whose quota and billing
+// are used for the requests of the Client.
+func Organization(id string) Option {
+	return func(m *Client) {
+		m.organization = id
+	}
+}
+
+// Project sets the ID of the OpenAI project whose quota and billing are used
+// for the requests of the Client.
+func Project(id string) Option {
+	return Header("OpenAI-Project", id)
+}
+
+// Header adds a custom HTTP header to the requests of the Client, for example
+// one that is required by an API gateway. Custom headers replace the headers
+// that the Client sets itself. Header can be used multiple times.
+func Header(key, value string) Option {
+	return func(m *Client) {
+		if m.headers == nil {
+			m.headers = make(http.Header)
+		}
+		m.headers.Set(key, value)
+	}
+}
+
+// HTTPClient sets the HTTP client that sends the requests of the Client, for
+// example one that uses a corporate proxy or client certificates for mutual
+// TLS. The transport of the HTTP client is wrapped to retry failed requests
+// and add custom headers; the HTTP client itself is not modified.
+func HTTPClient(client *http.Client) Option {
+	return func(m *Client) {
+		m.httpClient = client
+	}
+}
+
+// newHTTPClient returns a copy of the configured HTTP client, or of a new one,
+// whose transport captures Retry-After headers and adds the custom headers of
+// the Client.
+func (c *Client) newHTTPClient() *http.Client {
+	var client http.Client
+	if c.httpClient != nil {
+		client = *c.httpClient
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(c.headers) > 0 {
+		base = &headerTransport{base: base, headers: c.headers}
+	}
+	client.Transport = &retryAfterTransport{base: base}
+
+	return &client
+}
+
+// headerTransport is an [http.RoundTripper] that sets custom headers on every
+// request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+	return t.base.RoundTrip(req)
+}
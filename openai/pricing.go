@@ -0,0 +1,44 @@
+package openai
+
+import "strings"
+
+// modelPricing contains the approximate prices of OpenAI models in USD per one
+// million prompt and completion tokens. Model names are matched by their
+// longest prefix, so that dated model versions use the price of their family.
+var modelPricing = map[string][2]float64{
+	"gpt-3.5-turbo": {0.5, 1.5},
+	"gpt-4":         {30, 60},
+	"gpt-4-32k":     {60, 120},
+	"gpt-4-turbo":   {10, 30},
+	"gpt-4-1106":    {10, 30},
+	"gpt-4-0125":    {10, 30},
+	"gpt-4o":        {2.5, 10},
+	"gpt-4o-mini":   {0.15, 0.6},
+	"gpt-4.1":       {2, 8},
+	"gpt-4.1-mini":  {0.4, 1.6},
+	"gpt-4.1-nano":  {0.1, 0.4},
+	"o1":            {15, 60},
+	"o1-mini":       {1.1, 4.4},
+	"o3":            {2, 8},
+	"o3-mini":       {1.1, 4.4},
+	"o4-mini":       {1.1, 4.4},
+}
+
+// ModelPricing returns the approximate price of the given model in USD per one
+// million prompt and completion tokens. It reports false if the price of the
+// model is unknown.
+func ModelPricing(model string) (prompt, completion float64, ok bool) {
+	var match string
+	for prefix := range modelPricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+
+	if match == "" {
+		return 0, 0, false
+	}
+
+	price := modelPricing[match]
+	return price[0], price[1], true
+}
@@ -0,0 +1,28 @@
+package openai
+
+import (
+	"errors"
+
+	"github.com/modernice/dragoman/provider"
+)
+
+func init() {
+	provider.Register("openai", func(cfg provider.Config) (provider.Backend, error) {
+		return provider.Backend{Model: New(cfg.Key, providerOptions(cfg)...)}, nil
+	})
+
+	provider.Register("compat", func(cfg provider.Config) (provider.Backend, error) {
+		if cfg.BaseURL == "" {
+			return provider.Backend{}, errors.New("missing base URL")
+		}
+		return provider.Backend{Model: New(cfg.Key, append(providerOptions(cfg), BaseURL(cfg.BaseURL))...)}, nil
+	})
+}
+
+func providerOptions(cfg provider.Config) []Option {
+	opts := []Option{Timeout(cfg.Timeout), MaxRetries(cfg.MaxRetries), Verbose(cfg.Verbose)}
+	if cfg.Model != "" {
+		opts = append(opts, Model(cfg.Model))
+	}
+	return opts
+}
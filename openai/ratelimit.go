@@ -0,0 +1,125 @@
+package openai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit limits the requests of the Client to the given number of requests
+// and tokens per minute, so that concurrent translations do not exceed the rate
+// limits of the account and end up waiting in retries. A limit of 0 disables
+// the respective limit. The limits are enforced by token buckets that are
+// shared by all goroutines that use the Client. Clients that are created with
+// the same returned Option share the same buckets.
+//
+// The tokens of a request are estimated as the tokens of the prompt plus the
+// same number of tokens for the response, because a translation is about as
+// long as its source.
+func RateLimit(requestsPerMinute, tokensPerMinute int) Option {
+	limiter := &rateLimiter{
+		requests: newBucket(requestsPerMinute),
+		tokens:   newBucket(tokensPerMinute),
+	}
+	return func(m *Client) {
+		m.limiter = limiter
+	}
+}
+
+type rateLimiter struct {
+	mux      sync.Mutex
+	requests bucket
+	tokens   bucket
+}
+
+// wait blocks until a request that uses the given number of tokens can be
+// sent without exceeding the rate limits, and takes the request and tokens
+// from the buckets. It returns the time it waited.
+func (l *rateLimiter) wait(ctx context.Context, tokens int) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	var waited time.Duration
+	for {
+		l.mux.Lock()
+		now := time.Now()
+		l.requests.refill(now)
+		l.tokens.refill(now)
+
+		delay := l.requests.delay(1)
+		if d := l.tokens.delay(float64(tokens)); d > delay {
+			delay = d
+		}
+
+		if delay <= 0 {
+			l.requests.take(1)
+			l.tokens.take(float64(tokens))
+			l.mux.Unlock()
+			return waited, nil
+		}
+		l.mux.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return waited, ctx.Err()
+		case <-timer.C:
+			waited += delay
+		}
+	}
+}
+
+// bucket is a token bucket that holds up to the allowed amount per minute and
+// is refilled continuously at the same rate.
+type bucket struct {
+	capacity  float64
+	available float64
+	updated   time.Time
+}
+
+func newBucket(perMinute int) bucket {
+	return bucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		updated:   time.Now(),
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.capacity <= 0 {
+		return
+	}
+
+	b.available += now.Sub(b.updated).Minutes() * b.capacity
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+	b.updated = now
+}
+
+// delay returns the time until n can be taken from the bucket. Amounts larger
+// than the capacity can be taken once the bucket is full; the bucket then
+// goes into debt, which delays the following requests.
+func (b *bucket) delay(n float64) time.Duration {
+	if b.capacity <= 0 {
+		return 0
+	}
+
+	if n > b.capacity {
+		n = b.capacity
+	}
+
+	missing := n - b.available
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.capacity * float64(time.Minute))
+}
+
+func (b *bucket) take(n float64) {
+	if b.capacity > 0 {
+		b.available -= n
+	}
+}
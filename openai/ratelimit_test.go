@@ -0,0 +1,131 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucket_refill(t *testing.T) {
+	start := time.Now()
+	b := bucket{capacity: 60, updated: start}
+
+	b.refill(start.Add(30 * time.Second))
+	if b.available != 30 {
+		t.Fatalf("bucket should be refilled by half its capacity in 30s; got %v", b.available)
+	}
+
+	b.refill(start.Add(5 * time.Minute))
+	if b.available != 60 {
+		t.Fatalf("bucket should not be refilled beyond its capacity; got %v", b.available)
+	}
+}
+
+func TestBucket_delay(t *testing.T) {
+	b := bucket{capacity: 60, available: 30, updated: time.Now()}
+
+	if d := b.delay(10); d != 0 {
+		t.Errorf("delay() of an available amount should be 0; got %v", d)
+	}
+	if d := b.delay(40); d != 10*time.Second {
+		t.Errorf("delay() should be the time until the missing amount is refilled (10s); got %v", d)
+	}
+}
+
+func TestBucket_delay_debt(t *testing.T) {
+	b := bucket{capacity: 60, available: 60, updated: time.Now()}
+
+	if d := b.delay(120); d != 0 {
+		t.Fatalf("amounts larger than the capacity should be taken from a full bucket; got delay %v", d)
+	}
+	b.take(120)
+
+	if d := b.delay(1); d != 61*time.Second {
+		t.Fatalf("the debt of a large amount should delay the following request by 61s; got %v", d)
+	}
+}
+
+func TestBucket_disabled(t *testing.T) {
+	b := newBucket(0)
+	b.take(1000)
+	b.refill(time.Now().Add(time.Minute))
+
+	if d := b.delay(1000); d != 0 {
+		t.Fatalf("a disabled bucket should never delay; got %v", d)
+	}
+}
+
+func TestRateLimit_shared(t *testing.T) {
+	opt := RateLimit(60, 1000)
+	a, b := New("key", opt), New("key", opt)
+	if a.limiter == nil || a.limiter != b.limiter {
+		t.Fatalf("clients created with the same Option should share the limiter")
+	}
+
+	if c := New("key", RateLimit(60, 1000)); c.limiter == a.limiter {
+		t.Fatalf("clients created with different Options should not share the limiter")
+	}
+}
+
+func TestRateLimiter_wait_concurrent(t *testing.T) {
+	// 6000 requests per minute are one request every 10ms.
+	l := &rateLimiter{requests: newBucket(6000), tokens: newBucket(0)}
+	l.requests.available = 0
+
+	const n = 5
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := l.wait(context.Background(), 100)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("wait() failed: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Fatalf("%d requests should take at least 50ms at one request per 10ms; took %v", n, elapsed)
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.requests.available < -0.5 {
+		t.Fatalf("concurrent requests should not take more than the bucket holds; available %v", l.requests.available)
+	}
+}
+
+func TestRateLimiter_wait_canceled(t *testing.T) {
+	l := &rateLimiter{requests: newBucket(1), tokens: newBucket(0)}
+	l.requests.available = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := l.wait(ctx, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait() should return the error of the context; got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("wait() should return when the context is canceled; took %v", elapsed)
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.requests.available > 0.01 || l.requests.available < -0.01 {
+		t.Fatalf("a canceled wait should not take from the bucket; available %v", l.requests.available)
+	}
+}
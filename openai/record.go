@@ -0,0 +1,117 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/atomicfile"
+)
+
+// ErrNotRecorded is returned by a Client that replays a recording if a request
+// was not recorded.
+var ErrNotRecorded = errors.New("request was not recorded")
+
+// Record writes every successful request of the Client and its response to a
+// JSON file in the given directory, so that the run can later be replayed
+// without calling the API using [Replay], for example in tests, to debug a bad
+// translation or for offline demos. The files are named after the SHA-256 hash
+// of the model and the messages of the request.
+func Record(dir string) Option {
+	return func(m *Client) {
+		m.recordDir = dir
+	}
+}
+
+// Replay answers the requests of the Client with the responses that were
+// recorded in the given directory using [Record] instead of calling the API.
+// Requests that were not recorded fail with an error that wraps
+// [ErrNotRecorded]. Replayed requests do not report usage.
+func Replay(dir string) Option {
+	return func(m *Client) {
+		m.replayDir = dir
+	}
+}
+
+// recording is the file of a recorded request.
+type recording struct {
+	Hash     string            `json:"hash"`
+	Model    string            `json:"model"`
+	Messages []recordedMessage `json:"messages"`
+	Response string            `json:"response"`
+}
+
+type recordedMessage struct {
+	Role    dragoman.Role `json:"role"`
+	Content string        `json:"content"`
+}
+
+// newRecording returns the recording of a request of the Client.
+func (c *Client) newRecording(msgs []dragoman.Message) recording {
+	r := recording{Model: c.model, Messages: make([]recordedMessage, len(msgs))}
+	for i, msg := range msgs {
+		r.Messages[i] = recordedMessage{Role: msg.Role, Content: msg.Content}
+	}
+
+	// The request is marshaled before the hash and the response are set, so
+	// that the hash only depends on the model and the messages.
+	b, _ := json.Marshal(r)
+	sum := sha256.Sum256(b)
+	r.Hash = hex.EncodeToString(sum[:])
+
+	return r
+}
+
+// record writes the recording of a request and its response.
+func (c *Client) record(msgs []dragoman.Message, resp string) error {
+	r := c.newRecording(msgs)
+	r.Response = resp
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("marshal recording: %w", err)
+	}
+
+	if err := os.MkdirAll(c.recordDir, 0755); err != nil {
+		return fmt.Errorf("create recording directory: %w", err)
+	}
+
+	return atomicfile.WriteFile(filepath.Join(c.recordDir, r.Hash+".json"), buf.Bytes(), 0644)
+}
+
+// replay returns the recorded response of a request.
+func (c *Client) replay(ctx context.Context, msgs []dragoman.Message) (string, error) {
+	r := c.newRecording(msgs)
+	c.logger.DebugContext(ctx, "replay recorded response", "model", c.model, "hash", r.Hash)
+
+	b, err := os.ReadFile(filepath.Join(c.replayDir, r.Hash+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: no response for request %s of model %q in %q", ErrNotRecorded, r.Hash, c.model, c.replayDir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read recording: %w", err)
+	}
+
+	var recorded recording
+	if err := json.Unmarshal(b, &recorded); err != nil {
+		return "", fmt.Errorf("unmarshal recording %q: %w", r.Hash, err)
+	}
+
+	if c.stream != nil {
+		io.WriteString(c.stream, recorded.Response)
+	}
+
+	return recorded.Response, nil
+}
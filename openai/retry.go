@@ -0,0 +1,175 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/modernice/dragoman/internal/backoff"
+	"github.com/sashabaranov/go-openai"
+)
+
+type retryAfterKey struct{}
+
+// retryAfter records the Retry-After header of the last failed response of a
+// request.
+type retryAfter struct {
+	mux   sync.Mutex
+	delay time.Duration
+}
+
+func (r *retryAfter) set(delay time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.delay = delay
+}
+
+func (r *retryAfter) get() time.Duration {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.delay
+}
+
+// retryAfterTransport is an [http.RoundTripper] that captures the Retry-After
+// header of failed responses, because the OpenAI client library does not
+// expose response headers in its errors.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if ra, ok := req.Context().Value(retryAfterKey{}).(*retryAfter); ok {
+		ra.set(parseRetryAfter(resp.Header.Get("Retry-After")))
+	}
+
+	return resp, nil
+}
+
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at)
+	}
+
+	return 0
+}
+
+func (c *Client) withRetries(ctx context.Context, fn func(context.Context) (string, error)) (string, error) {
+	ra := &retryAfter{}
+	ctx = context.WithValue(ctx, retryAfterKey{}, ra)
+
+	for attempt := 0; ; attempt++ {
+		ra.set(0)
+
+		resp, err := fn(ctx)
+		if err == nil || attempt >= c.maxRetries || !isRetryable(err) {
+			return resp, err
+		}
+
+		delay := ra.get()
+		if delay <= 0 {
+			delay = backoff.Delay(c.retryBackoff, attempt)
+		}
+
+		c.logger.WarnContext(ctx, "retry request", "error", err, "delay", delay, "attempt", attempt+1, "max_retries", c.maxRetries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+func isRetryable(err error) bool {
+	var status int
+
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	default:
+		return false
+	}
+
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// RefusalError is returned by [Client.Chat] if OpenAI refused a prompt because
+// of its content filter, either by rejecting the request or by stopping the
+// response.
+type RefusalError struct {
+	Reason string
+}
+
+func (err *RefusalError) Error() string {
+	return "openai: content filter: " + err.Reason
+}
+
+// Refused reports that the prompt was refused, so that the translator can
+// skip or retry the chunk.
+func (err *RefusalError) Refused() bool {
+	return true
+}
+
+// contentFilterCodes are the error codes of requests that were rejected by
+// the content filter of OpenAI or Azure OpenAI.
+var contentFilterCodes = map[string]bool{
+	"content_filter":           true,
+	"content_policy_violation": true,
+	// Azure OpenAI
+	"ResponsibleAIPolicyViolation": true,
+}
+
+// asRefusal returns a [*RefusalError] if the error was caused by a request
+// that was rejected by the content filter, or the error itself otherwise.
+func asRefusal(err error) error {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	code, _ := apiErr.Code.(string)
+	if apiErr.InnerError != nil && apiErr.InnerError.Code != "" {
+		code = apiErr.InnerError.Code
+	}
+
+	if contentFilterCodes[code] {
+		return &RefusalError{Reason: apiErr.Message}
+	}
+
+	return err
+}
+
+// IsAPIError reports whether the error was caused by the OpenAI API, for
+// example because of an invalid API key, an exceeded quota or a failed
+// connection to the API.
+func IsAPIError(err error) bool {
+	var (
+		apiErr *openai.APIError
+		reqErr *openai.RequestError
+		urlErr *url.Error
+	)
+	return errors.As(err, &apiErr) || errors.As(err, &reqErr) || errors.As(err, &urlErr)
+}
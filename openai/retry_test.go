@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("3"); got != 3*time.Second {
+		t.Errorf("parseRetryAfter(%q) should return %v; got %v", "3", 3*time.Second, got)
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) should return about an hour; got %v", date, got)
+	}
+
+	for _, header := range []string{"", "soon"} {
+		if got := parseRetryAfter(header); got != 0 {
+			t.Errorf("parseRetryAfter(%q) should return 0; got %v", header, got)
+		}
+	}
+}
+
+func TestClient_Chat_retryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header func() string
+	}{
+		{name: "seconds", header: func() string { return "1" }},
+		{name: "HTTP-date", header: func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := chatServer(t, func(w http.ResponseWriter) bool {
+				if requests.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.header())
+					writeError(w, http.StatusTooManyRequests)
+					return false
+				}
+				return true
+			})
+
+			// The backoff would exceed the deadline, so only the delay of the
+			// Retry-After header lets the retry succeed.
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			client := New("key", BaseURL(srv.URL), Model("m"), RetryBackoff(time.Hour))
+			resp, err := client.Chat(ctx, "Hello")
+			if err != nil {
+				t.Fatalf("Chat() failed: %v", err)
+			}
+			if resp != "Hallo" {
+				t.Fatalf("expected response %q; got %q", "Hallo", resp)
+			}
+			if n := requests.Load(); n != 2 {
+				t.Fatalf("expected 2 requests; got %d", n)
+			}
+		})
+	}
+}
+
+func TestClient_Chat_retryable(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var requests atomic.Int32
+			srv := chatServer(t, func(w http.ResponseWriter) bool {
+				if requests.Add(1) < 3 {
+					writeError(w, status)
+					return false
+				}
+				return true
+			})
+
+			client := New("key", BaseURL(srv.URL), Model("m"), RetryBackoff(time.Millisecond))
+			if _, err := client.Chat(context.Background(), "Hello"); err != nil {
+				t.Fatalf("Chat() failed: %v", err)
+			}
+			if n := requests.Load(); n != 3 {
+				t.Fatalf("expected 3 requests; got %d", n)
+			}
+		})
+	}
+}
+
+func TestClient_Chat_notRetryable(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var requests atomic.Int32
+			srv := chatServer(t, func(w http.ResponseWriter) bool {
+				requests.Add(1)
+				writeError(w, status)
+				return false
+			})
+
+			client := New("key", BaseURL(srv.URL), Model("m"), RetryBackoff(time.Millisecond))
+			if _, err := client.Chat(context.Background(), "Hello"); err == nil {
+				t.Fatalf("Chat() should fail")
+			}
+			if n := requests.Load(); n != 1 {
+				t.Fatalf("expected the request not to be retried; got %d requests", n)
+			}
+		})
+	}
+}
+
+func TestClient_Chat_maxRetries(t *testing.T) {
+	var requests atomic.Int32
+	srv := chatServer(t, func(w http.ResponseWriter) bool {
+		requests.Add(1)
+		writeError(w, http.StatusBadGateway)
+		return false
+	})
+
+	client := New("key", BaseURL(srv.URL), Model("m"), MaxRetries(2), RetryBackoff(time.Millisecond))
+	_, err := client.Chat(context.Background(), "Hello")
+	if !IsAPIError(err) {
+		t.Fatalf("expected an API error; got %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("expected 1 request and 2 retries; got %d requests", n)
+	}
+}
+
+// chatServer starts an OpenAI-compatible server that responds with "Hallo"
+// to every request for which respond returns true. Otherwise, respond must
+// write the response itself.
+func chatServer(t *testing.T, respond func(http.ResponseWriter) bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !respond(w) {
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\": [{\"index\": 0, \"delta\": {\"content\": \"Hallo\"}, \"finish_reason\": \"stop\"}]}\n\ndata: [DONE]\n\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func writeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"error": {"message": "failed", "type": "server_error"}}`))
+}
@@ -0,0 +1,24 @@
+package dragoman
+
+import "context"
+
+type partialContextKey struct{}
+
+// WithPartial returns a context that passes the partial responses that
+// streaming models report through [ReportPartial] to fn, for example to render
+// a translation while it is generated. A nested WithPartial replaces the
+// function of its parent context.
+func WithPartial(ctx context.Context, fn func(text string)) context.Context {
+	return context.WithValue(ctx, partialContextKey{}, fn)
+}
+
+// ReportPartial reports the text of the response that a streaming model has
+// received so far. Models call ReportPartial whenever a chunk of the response
+// arrives, with the whole text of the response up to that chunk, so that a
+// request that is retried starts over with a shorter text. Calls with
+// contexts without a function of [WithPartial] are ignored.
+func ReportPartial(ctx context.Context, text string) {
+	if fn, ok := ctx.Value(partialContextKey{}).(func(string)); ok && fn != nil {
+		fn(text)
+	}
+}
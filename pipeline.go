@@ -0,0 +1,257 @@
+package dragoman
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/modernice/dragoman/internal/atomicfile"
+	"github.com/modernice/dragoman/internal/chunks"
+)
+
+// Chunker splits a document into the chunks that are translated separately.
+type Chunker func(document string) []string
+
+// HeadingChunker returns a [Chunker] that splits Markdown documents before the
+// headings of the given levels, like [TranslateParams.SplitHeadingLevels].
+func HeadingChunker(levels ...int) Chunker {
+	return func(document string) []string {
+		return chunks.Headings(document, levels)
+	}
+}
+
+// PrefixChunker returns a [Chunker] that splits documents at the lines that
+// start with one of the prefixes, like [TranslateParams.SplitChunks].
+func PrefixChunker(prefixes ...string) Chunker {
+	return func(document string) []string {
+		return chunks.Chunks(document, prefixes)
+	}
+}
+
+// chunks returns the chunks of the document of the params.
+func (p TranslateParams) chunks() []string {
+	if p.Chunker != nil {
+		return p.Chunker(p.Document)
+	}
+	if len(p.SplitHeadingLevels) > 0 {
+		return chunks.Headings(p.Document, p.SplitHeadingLevels)
+	}
+	return chunks.Chunks(p.Document, p.SplitChunks)
+}
+
+// Reader reads the source document of a [Pipeline].
+type Reader interface {
+	Read(context.Context) ([]byte, error)
+}
+
+// ReaderFunc allows a function to be used as a [Reader].
+type ReaderFunc func(context.Context) ([]byte, error)
+
+// Read calls fn.
+func (fn ReaderFunc) Read(ctx context.Context) ([]byte, error) {
+	return fn(ctx)
+}
+
+// FileReader returns a [Reader] that reads the file at the given path.
+func FileReader(path string) Reader {
+	return ReaderFunc(func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// Writer writes the translated document of a [Pipeline].
+type Writer interface {
+	Write(ctx context.Context, document []byte) error
+}
+
+// WriterFunc allows a function to be used as a [Writer].
+type WriterFunc func(context.Context, []byte) error
+
+// Write calls fn.
+func (fn WriterFunc) Write(ctx context.Context, document []byte) error {
+	return fn(ctx, document)
+}
+
+// FileWriter returns a [Writer] that writes the document to the file at the
+// given path. The directory of the file is created if it does not exist, and
+// the file is replaced atomically, so that it is never left half-written.
+func FileWriter(path string) Writer {
+	return WriterFunc(func(_ context.Context, document []byte) error {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return atomicfile.WriteFile(path, document, 0644)
+	})
+}
+
+// PostProcessor transforms the translated document of a [Pipeline] before it
+// is written, for example to normalize line endings. The source is the
+// complete source document.
+type PostProcessor func(ctx context.Context, source, translated string) (string, error)
+
+// Pipeline composes the stages of a translation like the dragoman CLI does:
+// the source document is read, split into chunks, translated and validated,
+// merged into the existing translation, post-processed and written.
+//
+// Sources that are JSON objects are validated using [ValidateJSON]. If an
+// Existing reader is set, only the keys of the source that are missing in the
+// existing translation are translated and merged into it, like the --update
+// flag of the CLI. Keys of chunks that were skipped because of the
+// [ErrorPolicy] or a refusal stay missing, so that the next run translates
+// them again.
+type Pipeline struct {
+	// Reader reads the source document. It is required.
+	Reader Reader
+
+	// Translator translates the chunks of the document. It is required.
+	Translator *Translator
+
+	// Params are the parameters of the translation. Their Document is replaced
+	// with the document of the Reader, or with the missing keys of the
+	// document if an Existing reader is set.
+	Params TranslateParams
+
+	// Chunker splits the document into chunks. If it is nil, the document is
+	// split like the SplitHeadingLevels or SplitChunks of the Params.
+	Chunker Chunker
+
+	// Existing reads the existing translation of a JSON document, which is
+	// updated with the translations of the missing keys. An existing
+	// translation that does not exist (an error that wraps [fs.ErrNotExist])
+	// is treated as an empty document.
+	Existing Reader
+
+	// PostProcessors transform the translated document in order before it is
+	// written.
+	PostProcessors []PostProcessor
+
+	// Writer writes the translated document. If it is nil, the document is
+	// only returned by Run.
+	Writer Writer
+}
+
+// Run runs the pipeline and returns the translated document.
+func (p *Pipeline) Run(ctx context.Context) (string, error) {
+	if p.Reader == nil {
+		return "", errors.New("missing reader")
+	}
+	if p.Translator == nil {
+		return "", errors.New("missing translator")
+	}
+
+	source, err := p.Reader.Read(ctx)
+	if err != nil {
+		return "", fmt.Errorf("read source: %w", err)
+	}
+
+	params := p.Params
+	params.Document = string(source)
+	if p.Chunker != nil {
+		params.Chunker = p.Chunker
+	}
+
+	var sourceMap map[string]any
+	isJSON := json.Unmarshal(source, &sourceMap) == nil
+	if isJSON {
+		params.Validate = ValidateAll(ValidateJSON, params.Validate)
+	}
+
+	var result string
+	if p.Existing != nil {
+		if !isJSON {
+			return "", errors.New("update existing translation: source is not a JSON object")
+		}
+		if result, err = p.update(ctx, sourceMap, params); err != nil {
+			return "", err
+		}
+	} else if result, err = p.Translator.Translate(ctx, params); err != nil {
+		return "", err
+	}
+
+	for _, process := range p.PostProcessors {
+		if result, err = process(ctx, string(source), result); err != nil {
+			return "", fmt.Errorf("post-process translation: %w", err)
+		}
+	}
+
+	if p.Writer != nil {
+		if err := p.Writer.Write(ctx, []byte(result)); err != nil {
+			return "", fmt.Errorf("write translation: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// update translates the keys of the source that are missing in the existing
+// translation and returns the merged translation.
+func (p *Pipeline) update(ctx context.Context, source map[string]any, params TranslateParams) (string, error) {
+	existing := make(map[string]any)
+	data, err := p.Existing.Read(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return "", fmt.Errorf("read existing translation: %w", err)
+	default:
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return "", fmt.Errorf("unmarshal existing translation: %w", err)
+		}
+	}
+
+	missing, err := JSONDiff(source, existing)
+	if err != nil {
+		return "", fmt.Errorf("diff source and existing translation: %w", err)
+	}
+
+	if len(missing) > 0 {
+		extracted, err := JSONExtract(source, missing)
+		if err != nil {
+			return "", fmt.Errorf("extract missing keys: %w", err)
+		}
+
+		if params.Document, err = indentJSON(extracted); err != nil {
+			return "", fmt.Errorf("marshal missing keys: %w", err)
+		}
+
+		var skipped []string
+		onSkip := params.OnSkip
+		params.OnSkip = func(chunk SkippedChunk) {
+			var doc map[string]any
+			if json.Unmarshal([]byte(chunk.Source), &doc) == nil {
+				for key := range doc {
+					skipped = append(skipped, key)
+				}
+			}
+			if onSkip != nil {
+				onSkip(chunk)
+			}
+		}
+
+		result, err := p.Translator.Translate(ctx, params)
+		if err != nil {
+			return "", err
+		}
+
+		var translated map[string]any
+		if err := json.Unmarshal([]byte(result), &translated); err != nil {
+			return "", fmt.Errorf("unmarshal translation: %w", err)
+		}
+
+		for _, key := range skipped {
+			delete(translated, key)
+		}
+
+		JSONMerge(existing, translated)
+	}
+
+	out, err := indentJSON(existing)
+	if err != nil {
+		return "", fmt.Errorf("marshal translation: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package dragoman_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "de", "doc.md")
+
+	var chunks []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		doc := strings.Split(strings.Split(prompt, "---<DOC_BEGIN>---\n")[1], "\n---<DOC_END>---")[0]
+		chunks = append(chunks, doc)
+		return strings.ToUpper(doc), nil
+	})
+
+	p := dragoman.Pipeline{
+		Reader:     dragoman.ReaderFunc(func(context.Context) ([]byte, error) { return []byte("# a\n\none\n\n# b\n\ntwo"), nil }),
+		Translator: dragoman.NewTranslator(model),
+		Params:     dragoman.TranslateParams{Target: "German"},
+		Chunker:    dragoman.PrefixChunker("# "),
+		PostProcessors: []dragoman.PostProcessor{
+			func(_ context.Context, source, translated string) (string, error) {
+				return strings.ReplaceAll(translated, "\n", "\r\n"), nil
+			},
+		},
+		Writer: dragoman.FileWriter(out),
+	}
+
+	result, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+
+	if want := []string{"# a\n\none", "# b\n\ntwo"}; !cmp.Equal(want, chunks) {
+		t.Fatalf("chunks mismatch (-want +got):\n%s", cmp.Diff(want, chunks))
+	}
+
+	want := "# A\r\n\r\nONE\r\n\r\n# B\r\n\r\nTWO\r\n"
+	if result != want {
+		t.Fatalf("Run() should return %q; got %q", want, result)
+	}
+
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if string(written) != want {
+		t.Fatalf("Writer should write %q; got %q", want, written)
+	}
+}
+
+func TestPipeline_Run_update(t *testing.T) {
+	source := `{"title": "Hallo", "nav": {"home": "Startseite", "about": "Über uns"}, "footer": "Tschüss"}`
+	existing := `{"title": "Hello", "nav": {"home": "Home"}}`
+
+	tests := map[string]struct {
+		err  error
+		want map[string]any
+	}{
+		"translated": {
+			want: map[string]any{
+				"title":  "Hello",
+				"nav":    map[string]any{"home": "Home", "about": "About us"},
+				"footer": "Bye",
+			},
+		},
+		"skipped": {
+			err: errors.New("service unavailable"),
+			want: map[string]any{
+				"title": "Hello",
+				"nav":   map[string]any{"home": "Home"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+				if strings.Contains(prompt, "Startseite") || strings.Contains(prompt, `"title"`) {
+					t.Fatalf("only missing keys should be translated; got prompt\n\n%s", prompt)
+				}
+				if tt.err != nil {
+					return "", tt.err
+				}
+				return `{"nav": {"about": "About us"}, "footer": "Bye"}`, nil
+			})
+
+			p := dragoman.Pipeline{
+				Reader:     dragoman.ReaderFunc(func(context.Context) ([]byte, error) { return []byte(source), nil }),
+				Existing:   dragoman.ReaderFunc(func(context.Context) ([]byte, error) { return []byte(existing), nil }),
+				Translator: dragoman.NewTranslator(model),
+				Params: dragoman.TranslateParams{
+					ErrorPolicy: dragoman.ErrorPolicy{Action: dragoman.ErrorSkip},
+				},
+			}
+
+			result, err := p.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run(): %v", err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal([]byte(result), &got); err != nil {
+				t.Fatalf("unmarshal result: %v", err)
+			}
+
+			if !cmp.Equal(tt.want, got) {
+				t.Fatalf("Run() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestPipeline_Run_updateRequiresJSON(t *testing.T) {
+	p := dragoman.Pipeline{
+		Reader:     dragoman.ReaderFunc(func(context.Context) ([]byte, error) { return []byte("# Hallo"), nil }),
+		Existing:   dragoman.FileReader(filepath.Join(t.TempDir(), "missing.md")),
+		Translator: dragoman.NewTranslator(dragoman.ModelFunc(func(context.Context, string) (string, error) { return "# Hello", nil })),
+	}
+
+	if _, err := p.Run(context.Background()); err == nil {
+		t.Fatalf("Run() should fail for an existing translation of a document that is not JSON")
+	}
+}
@@ -0,0 +1,102 @@
+package dragoman
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maskedText is a chunk whose matches of the preserve patterns of the
+// [TranslateParams] were replaced with opaque tokens.
+type maskedText struct {
+	text   string
+	values []string
+}
+
+// maskToken returns the opaque token of the i-th preserved value.
+func maskToken(i int) string {
+	return fmt.Sprintf("⟦%d⟧", i)
+}
+
+// mask replaces every match of the patterns in the text with an opaque token
+// like "⟦0⟧", so that the model never sees the matched text and cannot
+// translate it. Identical matches share a token. Where matches of different
+// patterns overlap, the match that starts first wins, and of matches that
+// start at the same position, the longest.
+func mask(text string, patterns []*regexp.Regexp) maskedText {
+	if len(patterns) == 0 {
+		return maskedText{text: text}
+	}
+
+	var matches [][]int
+	for _, re := range patterns {
+		for _, m := range re.FindAllStringIndex(text, -1) {
+			if m[1] > m[0] {
+				matches = append(matches, m)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return maskedText{text: text}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i][0] != matches[j][0] {
+			return matches[i][0] < matches[j][0]
+		}
+		return matches[i][1] > matches[j][1]
+	})
+
+	var (
+		b      strings.Builder
+		values []string
+		tokens = make(map[string]string)
+		pos    int
+	)
+	for _, m := range matches {
+		if m[0] < pos {
+			continue
+		}
+
+		value := text[m[0]:m[1]]
+		token, ok := tokens[value]
+		if !ok {
+			token = maskToken(len(values))
+			tokens[value] = token
+			values = append(values, value)
+		}
+
+		b.WriteString(text[pos:m[0]])
+		b.WriteString(token)
+		pos = m[1]
+	}
+	b.WriteString(text[pos:])
+
+	return maskedText{text: b.String(), values: values}
+}
+
+// restore replaces the tokens in the translation of the masked text with the
+// values they stand for. It returns an error that wraps
+// [ErrInvalidTranslation] if the translation lost a token.
+func (m maskedText) restore(translated string) (string, error) {
+	if len(m.values) == 0 {
+		return translated, nil
+	}
+
+	var lost []string
+	replacements := make([]string, 0, 2*len(m.values))
+	for i, value := range m.values {
+		token := maskToken(i)
+		if !strings.Contains(translated, token) {
+			lost = append(lost, value)
+		}
+		replacements = append(replacements, token, value)
+	}
+
+	if len(lost) > 0 {
+		return "", fmt.Errorf("%w: lost preserved text %s", ErrInvalidTranslation, strings.Join(lost, ", "))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(translated), nil
+}
@@ -0,0 +1,177 @@
+package dragoman
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/MakeNowJust/heredoc/v2"
+)
+
+// PromptData is the data that a [PromptTemplate] is executed with for every
+// chunk of a translated document.
+type PromptData struct {
+	// Document is the chunk of the document to translate.
+	Document string
+
+	// Source is the language of the document. It is empty if the language
+	// should be detected by the model.
+	Source string
+
+	// Target is the language to translate the document to.
+	Target string
+
+	// Preserve are the terms that must not be translated.
+	Preserve []string
+
+	// Instructions are the additional instructions of the [TranslateParams].
+	Instructions []string
+
+	// Formality is the formality of the [TranslateParams].
+	Formality Formality
+
+	// Rules are the rules of the built-in prompt: the default formatting rules,
+	// followed by the Instructions, a rule for the formality, a rule for the
+	// preserved terms, a rule for the tokens of the preserve patterns and a rule
+	// for the length constraint.
+	Rules []string
+
+	// Context is the section of the built-in prompt that contains the reference
+	// documents, or an empty string if there are none.
+	Context string
+
+	// Previous is the section of the built-in prompt that contains the
+	// previously translated chunks or their summary if the carry-over of the
+	// [TranslateParams] is enabled, or an empty string otherwise.
+	Previous string
+}
+
+// Example is a source text together with its desired translation. Examples
+// show the model the expected style and terminology of a translation.
+type Example struct {
+	Source string
+	Target string
+}
+
+// examplesSection returns the section of a single prompt that contains the
+// examples of the [TranslateParams].
+func examplesSection(examples []Example) string {
+	var b strings.Builder
+	b.WriteString("Translate in the style of the following examples:\n")
+	for _, example := range examples {
+		b.WriteString("---<EXAMPLE_SOURCE>---\n")
+		b.WriteString(strings.TrimSpace(example.Source))
+		b.WriteString("\n---<EXAMPLE_TRANSLATION>---\n")
+		b.WriteString(strings.TrimSpace(example.Target))
+		b.WriteString("\n---<EXAMPLE_END>---\n")
+	}
+	return b.String()
+}
+
+// ParsePromptTemplate parses the text of a translation prompt template. See
+// [PromptData] for the data that is available to the template. The template is
+// executed once with empty data, so that references to unknown fields are
+// reported before any document is translated.
+func ParsePromptTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt template: %w", err)
+	}
+
+	if err := tmpl.Execute(io.Discard, PromptData{}); err != nil {
+		return nil, fmt.Errorf("execute prompt template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+func newPromptData(chunk string, params TranslateParams) PromptData {
+	rules := append([]string{
+		"Preserve the original document structure and formatting.",
+		"Preserve code blocks, placeholders, HTML tags and other structures.",
+	}, params.Instructions...)
+
+	if params.Formality.IsSpecified() {
+		rules = append(rules, params.Formality.instruction())
+	}
+
+	if len(params.Preserve) > 0 {
+		rules = append(rules, fmt.Sprintf("Do not translate the following terms: %s", strings.Join(params.Preserve, ", ")))
+	}
+
+	if len(params.PreservePatterns) > 0 {
+		rules = append(rules, "Keep tokens like ⟦0⟧ exactly as they are.")
+	}
+
+	if params.LengthConstraint.enabled() {
+		rules = append(rules, params.LengthConstraint.instruction())
+	}
+
+	return PromptData{
+		Document:     chunk,
+		Source:       params.Source,
+		Target:       params.Target,
+		Preserve:     params.Preserve,
+		Instructions: params.Instructions,
+		Formality:    params.Formality,
+		Rules:        rules,
+		Context:      contextSection(params.Context),
+		Previous:     params.previous,
+	}
+}
+
+func defaultPrompt(data PromptData) string {
+	var from string
+	if data.Source != "" {
+		from = fmt.Sprintf("from %s ", data.Source)
+	}
+
+	return heredoc.Docf(`
+		Translate the following document %sto %s:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		%s
+
+		%s%sOutput only the translated document, no chat.
+	`,
+		from,
+		data.Target,
+		data.Document,
+		strings.Join(data.Rules, "\n"),
+		withNewline(data.Context),
+		withNewline(data.Previous),
+	)
+}
+
+// defaultInstructions returns the system message of the built-in prompt that
+// is sent to a [MessageModel]: the task and the rules of the prompt.
+func defaultInstructions(data PromptData) string {
+	var from string
+	if data.Source != "" {
+		from = fmt.Sprintf("from %s ", data.Source)
+	}
+
+	return heredoc.Docf(`
+		Translate the documents of the user %sto %s. Each document is enclosed by ---<DOC_BEGIN>--- and ---<DOC_END>---.
+
+		%s
+
+		Output only the translated document, no chat.
+	`,
+		from,
+		data.Target,
+		strings.Join(data.Rules, "\n"),
+	)
+}
+
+// documentMessage returns the user message of the built-in prompt that is
+// sent to a [MessageModel]: the reference documents, the previously
+// translated chunks and the document to translate.
+func documentMessage(data PromptData) string {
+	return fmt.Sprintf("%s%s---<DOC_BEGIN>---\n%s\n---<DOC_END>---", withNewline(data.Context), withNewline(data.Previous), data.Document)
+}
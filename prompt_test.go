@@ -0,0 +1,51 @@
+package dragoman_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modernice/dragoman"
+)
+
+func TestPromptTemplate(t *testing.T) {
+	tmpl, err := dragoman.ParsePromptTemplate(`Übersetze von {{or .Source "auto"}} nach {{.Target}}. {{join .Preserve ", "}}
+{{range .Rules}}- {{.}}
+{{end}}{{.Document}}`)
+	if err != nil {
+		t.Fatalf("ParsePromptTemplate() failed: %v", err)
+	}
+
+	var provided string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		provided = prompt
+		return "", nil
+	})
+
+	_, err = dragoman.NewTranslator(model, dragoman.PromptTemplate(tmpl)).Translate(context.Background(), dragoman.TranslateParams{
+		Document:     "Hello, Dragoman.",
+		Target:       "Deutsch",
+		Preserve:     []string{"Dragoman"},
+		Instructions: []string{"Be brief."},
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	want := `Übersetze von auto nach Deutsch. Dragoman
+- Preserve the original document structure and formatting.
+- Preserve code blocks, placeholders, HTML tags and other structures.
+- Be brief.
+- Do not translate the following terms: Dragoman
+Hello, Dragoman.`
+	if provided != want {
+		t.Errorf("expected prompt to be\n\n%s\n\nbut prompt was\n\n%s", want, provided)
+	}
+}
+
+func TestParsePromptTemplate_invalid(t *testing.T) {
+	for _, text := range []string{"{{.Document", "{{.Unknown}}"} {
+		if _, err := dragoman.ParsePromptTemplate(text); err == nil {
+			t.Errorf("ParsePromptTemplate(%q) should fail", text)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package provider is a registry of translation providers, like OpenAI or
+// DeepL. Providers register a [Factory] under their name, usually in an init
+// function of their package, so that importing the package of a provider
+// makes it available to the `--provider` flag of the dragoman CLI:
+//
+//	package main
+//
+//	import (
+//		"github.com/modernice/dragoman/cli"
+//		_ "example.com/dragoman-gemini"
+//	)
+//
+//	func main() {
+//		cli.Main()
+//	}
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/modernice/dragoman"
+)
+
+// ErrUnknown is returned by [New] for providers that are not registered.
+var ErrUnknown = errors.New("unknown provider")
+
+// Config configures the backend that is created by a [Factory].
+type Config struct {
+	// Model is the model of the provider, if the provider has several models.
+	Model string
+
+	// Key is the API key of the provider.
+	Key string
+
+	// BaseURL is the base URL of the API of the provider, if it is not fixed.
+	BaseURL string
+
+	// Timeout is the timeout of requests to the provider.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times failed requests are retried.
+	MaxRetries int
+
+	// Verbose enables the debug logs of the provider.
+	Verbose bool
+}
+
+// Backend is a backend that was created by a [Factory]. Providers of language
+// models set Model, providers of machine translation services, like DeepL,
+// set Engine. If both are set, translations use the Engine and all other
+// features, like improvements, use the Model.
+type Backend struct {
+	Model  dragoman.Model
+	Engine dragoman.Engine
+}
+
+// Factory creates the backend of a provider from the given configuration.
+type Factory func(Config) (Backend, error)
+
+var (
+	mux       sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a provider available under the given name. Register panics
+// if the name is empty, the factory is nil, or a provider with the same name
+// is already registered.
+func Register(name string, factory Factory) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if name == "" {
+		panic("provider: empty provider name")
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("provider: nil factory of provider %q", name))
+	}
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("provider: provider %q is already registered", name))
+	}
+
+	factories[name] = factory
+}
+
+// Registered reports whether a provider with the given name is registered.
+func Registered(name string) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+	_, ok := factories[name]
+	return ok
+}
+
+// Names returns the names of the registered providers, sorted.
+func Names() []string {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New creates the backend of the provider with the given name. It fails with
+// an error that wraps [ErrUnknown] if the provider is not registered.
+func New(name string, cfg Config) (Backend, error) {
+	mux.RLock()
+	factory, ok := factories[name]
+	mux.RUnlock()
+
+	if !ok {
+		return Backend{}, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+
+	backend, err := factory(cfg)
+	if err != nil {
+		return Backend{}, fmt.Errorf("create %q backend: %w", name, err)
+	}
+	if backend.Model == nil && backend.Engine == nil {
+		return Backend{}, fmt.Errorf("create %q backend: neither a model nor an engine", name)
+	}
+
+	return backend, nil
+}
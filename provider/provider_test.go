@@ -0,0 +1,75 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/provider"
+)
+
+func TestRegister(t *testing.T) {
+	provider.Register("test-echo", func(cfg provider.Config) (provider.Backend, error) {
+		return provider.Backend{
+			Model: dragoman.ModelFunc(func(context.Context, string) (string, error) {
+				return cfg.Model, nil
+			}),
+		}, nil
+	})
+
+	if !provider.Registered("test-echo") {
+		t.Fatalf("Registered() should report %q as registered", "test-echo")
+	}
+
+	backend, err := provider.New("test-echo", provider.Config{Model: "echo-1"})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+
+	got, err := backend.Model.Chat(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("Chat(): %v", err)
+	}
+	if got != "echo-1" {
+		t.Fatalf("the backend should be created with the config; got model %q", got)
+	}
+
+	var names []string
+	for _, name := range provider.Names() {
+		if name == "test-echo" {
+			names = append(names, name)
+		}
+	}
+	if len(names) != 1 {
+		t.Fatalf("Names() should contain %q once; got %v", "test-echo", provider.Names())
+	}
+}
+
+func TestRegister_duplicate(t *testing.T) {
+	factory := func(provider.Config) (provider.Backend, error) { return provider.Backend{}, nil }
+	provider.Register("test-duplicate", factory)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Register() should panic for a provider that is already registered")
+		}
+	}()
+	provider.Register("test-duplicate", factory)
+}
+
+func TestNew_unknown(t *testing.T) {
+	if _, err := provider.New("test-unknown", provider.Config{}); !errors.Is(err, provider.ErrUnknown) {
+		t.Fatalf("New() should fail with %v; got %v", provider.ErrUnknown, err)
+	}
+}
+
+func TestNew_emptyBackend(t *testing.T) {
+	provider.Register("test-empty", func(provider.Config) (provider.Backend, error) {
+		return provider.Backend{}, nil
+	})
+
+	if _, err := provider.New("test-empty", provider.Config{}); err == nil {
+		t.Fatalf("New() should fail for a backend without a model and engine")
+	}
+}
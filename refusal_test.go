@@ -0,0 +1,109 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+type refusalError struct{}
+
+func (refusalError) Error() string { return "content filter" }
+func (refusalError) Refused() bool { return true }
+
+func TestIsRefusal(t *testing.T) {
+	tests := map[string]bool{
+		"I'm sorry, but I can't assist with that.":         true,
+		"I’m sorry, I cannot help with this request.":      true,
+		"---<DOC_BEGIN>---\nI cannot assist with that.":    true,
+		"As an AI language model, I can't translate this.": true,
+		"Hello world!":                           false,
+		"# Sorry\n\nWe are sorry for the delay.": false,
+	}
+
+	for response, want := range tests {
+		if got := dragoman.IsRefusal(response); got != want {
+			t.Errorf("IsRefusal(%q) should return %v; got %v", response, want, got)
+		}
+	}
+}
+
+func TestTranslator_Translate_refused(t *testing.T) {
+	source := heredoc.Doc(`
+		# Intro
+
+		Hallo Welt!
+
+		# Crime
+
+		Ein Kapitel über Verbrechen.
+	`)
+
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		if strings.Contains(prompt, "Verbrechen") {
+			return "I'm sorry, but I can't assist with that.", nil
+		}
+		return "# Intro\n\nHello world!", nil
+	})
+
+	translator := dragoman.NewTranslator(model)
+	params := dragoman.TranslateParams{Document: source, SplitChunks: []string{"# "}}
+
+	if _, err := translator.Translate(context.Background(), params); !errors.Is(err, dragoman.ErrRefused) {
+		t.Fatalf("Translate() should fail with %q; got %v", dragoman.ErrRefused, err)
+	}
+
+	var skipped []dragoman.SkippedChunk
+	params.SkipRefused = true
+	params.OnSkip = func(chunk dragoman.SkippedChunk) {
+		skipped = append(skipped, chunk)
+	}
+
+	result, err := translator.Translate(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if want := "# Intro\n\nHello world!\n\n# Crime\n\nEin Kapitel über Verbrechen.\n"; result != want {
+		t.Fatalf("refused chunks should be left untranslated; got\n\n%s", result)
+	}
+
+	if len(skipped) != 1 || skipped[0].Chunk != 2 || skipped[0].Reason == "" {
+		t.Fatalf("OnSkip should be called for the second chunk; got %+v", skipped)
+	}
+}
+
+func TestTranslator_Translate_refusalRetries(t *testing.T) {
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if len(prompts) == 1 {
+			return "", refusalError{}
+		}
+		return "A chapter about crime.", nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:       "Ein Kapitel über Verbrechen.",
+		RefusalRetries: 1,
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if want := "A chapter about crime.\n"; result != want {
+		t.Fatalf("expected result to be %q; got %q", want, result)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("refused chunk should be translated again; got %d prompts", len(prompts))
+	}
+
+	if !strings.Contains(prompts[1], "localized faithfully") || strings.Contains(prompts[0], "localized faithfully") {
+		t.Fatalf("only the retry should ask for a faithful localization; got prompts\n\n%s", strings.Join(prompts, "\n\n"))
+	}
+}
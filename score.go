@@ -0,0 +1,224 @@
+package dragoman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+)
+
+// DefaultScoreBatchSize is the default number of strings that are rated in a
+// single request by [ScoreTranslations].
+const DefaultScoreBatchSize = 40
+
+// Score is the quality rating of a translated string. Accuracy, Fluency and
+// Terminology are rated from 1 (unusable) to 5 (flawless).
+type Score struct {
+	// Key identifies the string, like the key path of a JSON document.
+	Key string `json:"key"`
+
+	// Source is the original string.
+	Source string `json:"source"`
+
+	// Translation is the translated string.
+	Translation string `json:"translation"`
+
+	// Accuracy rates whether the translation conveys the meaning of the source.
+	Accuracy int `json:"accuracy"`
+
+	// Fluency rates whether the translation reads naturally in the target
+	// language.
+	Fluency int `json:"fluency"`
+
+	// Terminology rates whether the translation uses the right and consistent
+	// terms.
+	Terminology int `json:"terminology"`
+
+	// Comment explains the rating, if the translation is not flawless.
+	Comment string `json:"comment,omitempty"`
+}
+
+// Overall returns the mean of the accuracy, fluency and terminology ratings.
+func (s Score) Overall() float64 {
+	return float64(s.Accuracy+s.Fluency+s.Terminology) / 3
+}
+
+// ScoreParams configure the rating of translations by [ScoreTranslations].
+type ScoreParams struct {
+	// Source maps the keys of the strings to their original text.
+	Source map[string]string
+
+	// Target maps the keys of the strings to their translation. Strings that
+	// are missing in Target are not rated.
+	Target map[string]string
+
+	// SourceLang is the language of the source strings. If empty, the model
+	// infers the language.
+	SourceLang string
+
+	// TargetLang is the language of the translations. If empty, the model
+	// infers the language.
+	TargetLang string
+
+	// Instructions are additional instructions for the model, like required
+	// terminology.
+	Instructions []string
+
+	// BatchSize is the maximum number of strings that are rated in a single
+	// request. Defaults to [DefaultScoreBatchSize].
+	BatchSize int
+}
+
+// scoreRating is a rating of a single string in the response of the model.
+type scoreRating struct {
+	Accuracy    int    `json:"accuracy"`
+	Fluency     int    `json:"fluency"`
+	Terminology int    `json:"terminology"`
+	Comment     string `json:"comment"`
+}
+
+// ScoreTranslations asks the model to rate the accuracy, fluency and
+// terminology of each translated string, so that human reviewers can focus on
+// the strings with the lowest scores. The scores are returned in the order of
+// their keys.
+func ScoreTranslations(ctx context.Context, model Model, params ScoreParams) ([]Score, error) {
+	batchSize := params.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultScoreBatchSize
+	}
+
+	keys := make([]string, 0, len(params.Source))
+	for key, source := range params.Source {
+		if translation, ok := params.Target[key]; ok && strings.TrimSpace(source) != "" && strings.TrimSpace(translation) != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	scores := make([]Score, 0, len(keys))
+	for start := 0; start < len(keys); start += batchSize {
+		end := start + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		batch, err := scoreBatch(ctx, model, keys[start:end], params)
+		if err != nil {
+			return scores, err
+		}
+		scores = append(scores, batch...)
+	}
+
+	return scores, nil
+}
+
+func scoreBatch(ctx context.Context, model Model, keys []string, params ScoreParams) ([]Score, error) {
+	pairs := make(map[string]map[string]string, len(keys))
+	for _, key := range keys {
+		pairs[key] = map[string]string{
+			"source":      params.Source[key],
+			"translation": params.Target[key],
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pairs); err != nil {
+		return nil, fmt.Errorf("marshal strings: %w", err)
+	}
+
+	response, err := model.Chat(ctx, scorePrompt(strings.TrimSpace(buf.String()), params))
+	if err != nil {
+		return nil, fmt.Errorf("llm error: %w", err)
+	}
+
+	ratings, err := parseRatings(response)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]Score, len(keys))
+	for i, key := range keys {
+		rating, ok := ratings[key]
+		if !ok {
+			return nil, fmt.Errorf("the model did not rate %q", key)
+		}
+
+		scores[i] = Score{
+			Key:         key,
+			Source:      params.Source[key],
+			Translation: params.Target[key],
+			Accuracy:    clampRating(rating.Accuracy),
+			Fluency:     clampRating(rating.Fluency),
+			Terminology: clampRating(rating.Terminology),
+			Comment:     strings.TrimSpace(rating.Comment),
+		}
+	}
+
+	return scores, nil
+}
+
+func scorePrompt(doc string, params ScoreParams) string {
+	languages := "from its source language"
+	switch {
+	case params.SourceLang != "" && params.TargetLang != "":
+		languages = fmt.Sprintf("from %s to %s", params.SourceLang, params.TargetLang)
+	case params.TargetLang != "":
+		languages = fmt.Sprintf("to %s", params.TargetLang)
+	case params.SourceLang != "":
+		languages = fmt.Sprintf("from %s", params.SourceLang)
+	}
+
+	var instructions string
+	if len(params.Instructions) > 0 {
+		instructions = "\nAdditional instructions:\n- " + strings.Join(params.Instructions, "\n- ") + "\n"
+	}
+
+	return heredoc.Docf(`
+		You are a professional translation reviewer. Rate the translation of each of the following strings %s.
+		The JSON object maps the key of each string to its source text and its translation:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+		%s
+		Rate each translation from 1 (unusable) to 5 (flawless) for:
+		- "accuracy": the translation conveys the complete meaning of the source, without additions or omissions
+		- "fluency": the translation reads naturally and is grammatically correct
+		- "terminology": the translation uses correct and consistent terms and keeps placeholders intact
+
+		Respond with a JSON object that maps every key to its ratings and a short "comment" in English that explains any rating below 5, e.g. {"greeting": {"accuracy": 5, "fluency": 4, "terminology": 5, "comment": "Slightly stiff wording."}}.
+		Leave the comment empty for flawless translations.
+
+		Output only the JSON object, no chat.
+	`, languages, doc, instructions)
+}
+
+func parseRatings(response string) (map[string]scoreRating, error) {
+	text := trimDividers(response)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.Trim(text, "`\n ")
+
+	var ratings map[string]scoreRating
+	if err := json.Unmarshal([]byte(text), &ratings); err != nil {
+		return nil, fmt.Errorf("parse ratings %q: %w", firstLine(text), err)
+	}
+
+	return ratings, nil
+}
+
+func clampRating(rating int) int {
+	switch {
+	case rating < 1:
+		return 1
+	case rating > 5:
+		return 5
+	default:
+		return rating
+	}
+}
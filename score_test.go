@@ -0,0 +1,78 @@
+package dragoman_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestScoreTranslations(t *testing.T) {
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "Tschüss") {
+			return "```json\n" + `{
+				"farewell": {"accuracy": 5, "fluency": 5, "terminology": 5, "comment": ""},
+				"greeting": {"accuracy": 2, "fluency": 7, "terminology": 0, "comment": " Wrong meaning. "}
+			}` + "\n```", nil
+		}
+		return `{"title": {"accuracy": 4, "fluency": 4, "terminology": 5, "comment": "Stiff."}}`, nil
+	})
+
+	scores, err := dragoman.ScoreTranslations(context.Background(), model, dragoman.ScoreParams{
+		Source:       map[string]string{"greeting": "Hello", "farewell": "Goodbye", "title": "Welcome", "missing": "Untranslated"},
+		Target:       map[string]string{"greeting": "Tschüss", "farewell": "Auf Wiedersehen", "title": "Willkommen"},
+		SourceLang:   "English",
+		TargetLang:   "German",
+		Instructions: []string{"Use informal speech"},
+		BatchSize:    2,
+	})
+	if err != nil {
+		t.Fatalf("ScoreTranslations(): %v", err)
+	}
+
+	want := []dragoman.Score{
+		{Key: "farewell", Source: "Goodbye", Translation: "Auf Wiedersehen", Accuracy: 5, Fluency: 5, Terminology: 5},
+		{Key: "greeting", Source: "Hello", Translation: "Tschüss", Accuracy: 2, Fluency: 5, Terminology: 1, Comment: "Wrong meaning."},
+		{Key: "title", Source: "Welcome", Translation: "Willkommen", Accuracy: 4, Fluency: 4, Terminology: 5, Comment: "Stiff."},
+	}
+
+	if !cmp.Equal(want, scores) {
+		t.Fatalf("ScoreTranslations() mismatch (-want +got):\n%s", cmp.Diff(want, scores))
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("strings should be rated in 2 batches; got %d", len(prompts))
+	}
+
+	for _, s := range []string{"from English to German", "Use informal speech", `"translation": "Tschüss"`} {
+		if !strings.Contains(prompts[0], s) {
+			t.Errorf("prompt should contain %q; got\n\n%s", s, prompts[0])
+		}
+	}
+
+	if strings.Contains(prompts[0], "missing") || strings.Contains(prompts[1], "missing") {
+		t.Errorf("untranslated strings should not be rated")
+	}
+
+	if got := want[1].Overall(); got != 8.0/3 {
+		t.Errorf("Overall() should return %v; got %v", 8.0/3, got)
+	}
+}
+
+func TestScoreTranslations_missingRating(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		return `{"greeting": {"accuracy": 5, "fluency": 5, "terminology": 5}}`, nil
+	})
+
+	_, err := dragoman.ScoreTranslations(context.Background(), model, dragoman.ScoreParams{
+		Source: map[string]string{"greeting": "Hello", "farewell": "Goodbye"},
+		Target: map[string]string{"greeting": "Hallo", "farewell": "Tschüss"},
+	})
+	if err == nil {
+		t.Fatalf("ScoreTranslations() should fail if the model does not rate a string")
+	}
+}
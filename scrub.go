@@ -0,0 +1,112 @@
+package dragoman
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Scrub kinds name groups of sensitive texts that can be kept away from the
+// model. See [ScrubPatterns].
+const (
+	// ScrubSecrets are API keys, access tokens, JSON Web Tokens, bearer tokens
+	// and private keys.
+	ScrubSecrets = "secrets"
+
+	// ScrubEmails are email addresses.
+	ScrubEmails = "emails"
+)
+
+var scrubPatterns = map[string][]*regexp.Regexp{
+	ScrubSecrets: {
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+		regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}`),
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`),
+		regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`),
+		regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+		regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}`),
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+		regexp.MustCompile(`(?i)\bbearer [A-Za-z0-9._~+/-]{20,}=*`),
+	},
+	ScrubEmails: {
+		regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	},
+}
+
+// ScrubPatterns returns the regular expressions of the sensitive texts of the
+// given kinds, like [ScrubSecrets] and [ScrubEmails]. Pass them as
+// [TranslateParams.PreservePatterns] to mask the texts before they are sent to
+// the model and restore them in the translation.
+func ScrubPatterns(kinds ...string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, kind := range kinds {
+		p, ok := scrubPatterns[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown scrub kind %q (expected %q or %q)", kind, ScrubSecrets, ScrubEmails)
+		}
+		patterns = append(patterns, p...)
+	}
+	return patterns, nil
+}
+
+// Secret is a sensitive text that was found by [FindSecrets].
+type Secret struct {
+	// Kind is the scrub kind of the text, like [ScrubSecrets].
+	Kind string
+
+	// Line is the 1-based line of the document where the text starts.
+	Line int
+
+	// Text is the sensitive text.
+	Text string
+}
+
+// Redacted returns the text of the secret with all but its first four
+// characters replaced, so that it can be reported without leaking it.
+func (s Secret) Redacted() string {
+	runes := []rune(s.Text)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", len(runes)-4)
+}
+
+// FindSecrets returns the sensitive texts of the given kinds in the document,
+// in the order of the document.
+func FindSecrets(doc string, kinds ...string) ([]Secret, error) {
+	type match struct {
+		start  int
+		secret Secret
+	}
+
+	var matches []match
+	for _, kind := range kinds {
+		patterns, err := ScrubPatterns(kind)
+		if err != nil {
+			return nil, err
+		}
+		for _, re := range patterns {
+			for _, m := range re.FindAllStringIndex(doc, -1) {
+				matches = append(matches, match{start: m[0], secret: Secret{
+					Kind: kind,
+					Line: strings.Count(doc[:m[0]], "\n") + 1,
+					Text: doc[m[0]:m[1]],
+				}})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	secrets := make([]Secret, len(matches))
+	for i, m := range matches {
+		secrets[i] = m.secret
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,52 @@
+package dragoman
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStopped is returned by Translate if the translation was stopped using
+// the [TranslateParams.Stop] channel before all chunks were translated.
+var ErrStopped = errors.New("translation stopped")
+
+// StoppedError is the error of a translation that was stopped using the
+// [TranslateParams.Stop] channel or by its [Budget]. It matches [ErrStopped].
+type StoppedError struct {
+	// Chunk is the 1-based number of the first chunk that was not translated.
+	Chunk int
+
+	// Chunks is the number of chunks of the document.
+	Chunks int
+
+	// Cause is the reason why the translation was stopped, like
+	// [ErrBudgetExceeded]. It is nil if the Stop channel was closed.
+	Cause error
+}
+
+func (err *StoppedError) Error() string {
+	msg := fmt.Sprintf("%v before chunk %d of %d", ErrStopped, err.Chunk, err.Chunks)
+	if err.Cause != nil {
+		msg += ": " + err.Cause.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is [ErrStopped].
+func (err *StoppedError) Is(target error) bool {
+	return target == ErrStopped
+}
+
+// Unwrap returns the cause of the stop.
+func (err *StoppedError) Unwrap() error {
+	return err.Cause
+}
+
+// stopped reports whether the Stop channel of the params is closed.
+func (p TranslateParams) stopped() bool {
+	select {
+	case <-p.Stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+func TestTranslator_Translate_stop(t *testing.T) {
+	source := heredoc.Doc(`
+		# One
+
+		Eins
+
+		# Two
+
+		Zwei
+
+		# Three
+
+		Drei
+	`)
+
+	stop := make(chan struct{})
+	var prompts int
+	model := dragoman.ModelFunc(func(ctx context.Context, prompt string) (string, error) {
+		prompts++
+		if strings.Contains(prompt, "Zwei") {
+			// The chunk that is being translated when the translation is
+			// stopped is finished.
+			close(stop)
+			return "# Two\n\nTwo", nil
+		}
+		return "# One\n\nOne", nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:    source,
+		SplitChunks: []string{"# "},
+		Stop:        stop,
+	})
+	if !errors.Is(err, dragoman.ErrStopped) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrStopped, err)
+	}
+
+	var stopped *dragoman.StoppedError
+	if !errors.As(err, &stopped) || stopped.Chunk != 3 || stopped.Chunks != 3 {
+		t.Fatalf("Translate() should stop before chunk 3 of 3; got %v", err)
+	}
+
+	if want := "# One\n\nOne\n\n# Two\n\nTwo\n"; result != want {
+		t.Fatalf("Translate() should return the translated chunks %q; got %q", want, result)
+	}
+
+	if prompts != 2 {
+		t.Fatalf("the model should be prompted 2 times; got %d", prompts)
+	}
+}
+
+func TestTranslator_Translate_stopBeforeStart(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		t.Fatal("the model should not be prompted")
+		return "", nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: "Hallo Welt!",
+		Stop:     stop,
+	})
+	if !errors.Is(err, dragoman.ErrStopped) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrStopped, err)
+	}
+	if result != "" {
+		t.Fatalf("Translate() should return an empty result; got %q", result)
+	}
+}
@@ -0,0 +1,74 @@
+package dragoman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+)
+
+// termsSampleSize is the maximum number of characters of a text that are sent
+// to the model to detect the terms to preserve.
+const termsSampleSize = 12000
+
+// DetectTerms asks the model for the product names, brand names, trademarks
+// and other proper nouns of the text that should not be translated, so that
+// they can be added to [TranslateParams.Preserve]. Only the beginning of long
+// texts is sent to the model. Terms that do not occur verbatim in the text are
+// discarded, and every term is returned once, in the order of the response.
+func DetectTerms(ctx context.Context, model Model, text string) ([]string, error) {
+	sample := strings.TrimSpace(text)
+	if runes := []rune(sample); len(runes) > termsSampleSize {
+		sample = string(runes[:termsSampleSize])
+	}
+	if sample == "" {
+		return nil, nil
+	}
+
+	prompt := heredoc.Docf(`
+		List the product names, brand names, trademarks, company names and other proper nouns in the following text that must be kept verbatim when the text is translated:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		Do not list common words, people's titles, or names that are usually translated, like the names of countries or cities.
+		Write every term exactly as it appears in the text.
+
+		Respond with a JSON array of strings, e.g. ["Dragoman", "GitHub Actions"]. If there are no such terms, respond with [].
+
+		Output only the JSON array, no chat.
+	`, sample)
+
+	response, err := model.Chat(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("llm error: %w", err)
+	}
+
+	return parseTerms(response, sample)
+}
+
+func parseTerms(response, text string) ([]string, error) {
+	raw := trimDividers(response)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.Trim(raw, "`\n ")
+
+	var terms []string
+	if err := json.Unmarshal([]byte(raw), &terms); err != nil {
+		return nil, fmt.Errorf("parse terms %q: %w", firstLine(raw), err)
+	}
+
+	out := make([]string, 0, len(terms))
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" || seen[term] || !strings.Contains(text, term) {
+			continue
+		}
+		seen[term] = true
+		out = append(out, term)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package dragoman_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+)
+
+func TestDetectTerms(t *testing.T) {
+	text := "Deploy Dragoman with GitHub Actions. Dragoman translates your docs."
+
+	tests := map[string]struct {
+		response string
+		want     []string
+	}{
+		"terms": {
+			response: `["Dragoman", "GitHub Actions"]`,
+			want:     []string{"Dragoman", "GitHub Actions"},
+		},
+		"code fence": {
+			response: "```json\n[\"GitHub Actions\"]\n```",
+			want:     []string{"GitHub Actions"},
+		},
+		"duplicates and unknown terms": {
+			response: `["Dragoman", " Dragoman ", "Kubernetes", ""]`,
+			want:     []string{"Dragoman"},
+		},
+		"no terms": {
+			response: `[]`,
+			want:     []string{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+				if !strings.Contains(prompt, text) {
+					t.Errorf("prompt should contain the text; got\n\n%s", prompt)
+				}
+				return tt.response, nil
+			})
+
+			got, err := dragoman.DetectTerms(context.Background(), model, text)
+			if err != nil {
+				t.Fatalf("DetectTerms(): %v", err)
+			}
+
+			if !cmp.Equal(tt.want, got) {
+				t.Fatalf("DetectTerms() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestDetectTerms_invalidResponse(t *testing.T) {
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		return "Dragoman", nil
+	})
+
+	if _, err := dragoman.DetectTerms(context.Background(), model, "Dragoman"); err == nil {
+		t.Fatalf("DetectTerms() should fail for a response that is not a JSON array")
+	}
+}
@@ -0,0 +1,51 @@
+package dragoman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrChunkTimeout is returned by Translate if a chunk was not translated
+// within the [TranslateParams.ChunkTimeout].
+var ErrChunkTimeout = errors.New("chunk timeout exceeded")
+
+// ErrRunTimeout is returned by Translate if the document was not translated
+// within the [TranslateParams.RunTimeout].
+var ErrRunTimeout = errors.New("run timeout exceeded")
+
+// runContext returns the context of a translation, whose deadline is the
+// RunTimeout of the params.
+func (p TranslateParams) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.RunTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, p.RunTimeout, ErrRunTimeout)
+}
+
+// translateTimedChunk translates a chunk like translatePolicyChunk within the
+// ChunkTimeout of the params. If the chunk or the run timed out, the returned
+// error wraps [ErrChunkTimeout] or [ErrRunTimeout] and names the chunk.
+func (t *Translator) translateTimedChunk(ctx context.Context, logger *slog.Logger, chunk string, params TranslateParams, progress ChunkProgress) (string, error) {
+	chunkCtx := ctx
+	if params.ChunkTimeout > 0 {
+		var cancel context.CancelFunc
+		chunkCtx, cancel = context.WithTimeoutCause(ctx, params.ChunkTimeout, ErrChunkTimeout)
+		defer cancel()
+	}
+
+	translated, err := t.translatePolicyChunk(chunkCtx, logger, chunk, params)
+	if err == nil {
+		return translated, nil
+	}
+
+	switch cause := context.Cause(chunkCtx); {
+	case errors.Is(cause, ErrRunTimeout):
+		return "", fmt.Errorf("%w: the document was not translated within %s (stopped at chunk %d of %d)", ErrRunTimeout, params.RunTimeout, progress.Chunk, progress.Chunks)
+	case errors.Is(cause, ErrChunkTimeout):
+		return "", fmt.Errorf("%w: chunk %d of %d was not translated within %s", ErrChunkTimeout, progress.Chunk, progress.Chunks, params.ChunkTimeout)
+	default:
+		return "", err
+	}
+}
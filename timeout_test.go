@@ -0,0 +1,86 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/dragoman"
+)
+
+func TestTranslator_Translate_chunkTimeout(t *testing.T) {
+	source := heredoc.Doc(`
+		# Intro
+
+		Hallo Welt!
+
+		# Outro
+
+		Tschüss!
+	`)
+
+	model := dragoman.ModelFunc(func(ctx context.Context, prompt string) (string, error) {
+		if !strings.Contains(prompt, "Tschüss") {
+			return "# Intro\n\nHello world!", nil
+		}
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	_, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:     source,
+		SplitChunks:  []string{"# "},
+		ChunkTimeout: 20 * time.Millisecond,
+	})
+	if !errors.Is(err, dragoman.ErrChunkTimeout) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrChunkTimeout, err)
+	}
+	if !strings.Contains(err.Error(), "chunk 2 of 2") {
+		t.Fatalf("error should name the chunk; got %q", err)
+	}
+
+	var skipped []dragoman.SkippedChunk
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:     source,
+		SplitChunks:  []string{"# "},
+		ChunkTimeout: 20 * time.Millisecond,
+		ErrorPolicy:  dragoman.ErrorPolicy{Action: dragoman.ErrorSkip},
+		OnSkip: func(chunk dragoman.SkippedChunk) {
+			skipped = append(skipped, chunk)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if want := "# Intro\n\nHello world!\n\n# Outro\n\nTschüss!\n"; result != want {
+		t.Fatalf("Translate() should return %q; got %q", want, result)
+	}
+
+	if len(skipped) != 1 || skipped[0].Chunk != 2 {
+		t.Fatalf("chunk 2 should be skipped; got %+v", skipped)
+	}
+}
+
+func TestTranslator_Translate_runTimeout(t *testing.T) {
+	model := dragoman.ModelFunc(func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	_, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:     "Hallo Welt!",
+		RunTimeout:   20 * time.Millisecond,
+		ChunkTimeout: time.Minute,
+		ErrorPolicy:  dragoman.ErrorPolicy{Action: dragoman.ErrorSkip},
+	})
+	if !errors.Is(err, dragoman.ErrRunTimeout) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrRunTimeout, err)
+	}
+	if !strings.Contains(err.Error(), "chunk 1 of 1") {
+		t.Fatalf("error should name the chunk; got %q", err)
+	}
+}
@@ -2,12 +2,16 @@ package dragoman
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"regexp"
 	"slices"
 	"strings"
+	"text/template"
+	"time"
 
-	"github.com/MakeNowJust/heredoc/v2"
-	"github.com/modernice/dragoman/internal/chunks"
+	"github.com/modernice/dragoman/internal/logging"
 )
 
 // Translator provides facilities for converting text from one language to
@@ -20,7 +24,34 @@ import (
 // gracefully, providing detailed error messages that facilitate
 // troubleshooting.
 type Translator struct {
-	model Model
+	model  Model
+	engine Engine
+	prompt *template.Template
+	logger *slog.Logger
+}
+
+// TranslatorOption configures a [Translator].
+type TranslatorOption func(*Translator)
+
+// PromptTemplate replaces the built-in translation prompt with the given
+// template. The template is executed for every chunk of a document with a
+// [PromptData] as its data. Use [ParsePromptTemplate] to parse a template from
+// text.
+func PromptTemplate(tmpl *template.Template) TranslatorOption {
+	return func(t *Translator) {
+		t.prompt = tmpl
+	}
+}
+
+// TranslatorLogger makes the [Translator] log structured records of its
+// progress to the given logger: debug records when a chunk is started and done,
+// and warnings when a chunk is translated again or skipped. The records carry
+// the number of the chunk as "chunk" and the number of chunks as "chunks". By
+// default, nothing is logged.
+func TranslatorLogger(logger *slog.Logger) TranslatorOption {
+	return func(t *Translator) {
+		t.logger = logger
+	}
 }
 
 // TranslateParams specifies the parameters for translating text from one
@@ -40,22 +71,193 @@ type TranslateParams struct {
 	// preserving brand names.
 	Preserve []string
 
+	// PreservePatterns are regular expressions of texts, like placeholders or
+	// URLs, that must survive the translation verbatim. Unlike the Preserve
+	// terms, the matches are cut out of the document and replaced with opaque
+	// tokens like "⟦0⟧" before the document is sent to the model, and put back
+	// into the translation afterwards. A translation that lost a token is
+	// rejected with [ErrInvalidTranslation] and translated again up to
+	// ValidationRetries times.
+	PreservePatterns []*regexp.Regexp
+
 	// Instructions are raw instructions that should be included in the prompt.
 	Instructions []string
 
+	// SystemPrompt is an optional system message that steers the style of the
+	// translation, like the tone of voice of a brand.
+	SystemPrompt string
+
+	// Examples are pairs of source texts and their desired translations that
+	// are shown to the model before each chunk (few-shot prompting).
+	Examples []Example
+
+	// Formality specifies the formality (formal address) to use in the
+	// translation, e.g. "Sie" or "du" in German. Translation engines must be
+	// configured with the formality themselves.
+	Formality Formality
+
+	// Context are read-only reference documents, like brand guides or existing
+	// translations, that are included in the prompt so that the translation
+	// matches their terminology, tone and style.
+	Context []string
+
 	// SplitChunks is a list of strings that should be used to split the document
 	// into chunks. If the document is split into chunks, each chunk will be
 	// translated separately, allowing to fit large documents into the model's
 
 	SplitChunks []string
+
+	// SplitHeadingLevels are the levels of the Markdown headings (e.g. 2 for
+	// "## ") before which the document is split into chunks. Unlike
+	// SplitChunks, headings in fenced code blocks are ignored. If it is set, it
+	// takes precedence over SplitChunks.
+	SplitHeadingLevels []int
+
+	// Chunker splits the document into chunks. If it is set, it takes
+	// precedence over SplitHeadingLevels and SplitChunks.
+	Chunker Chunker
+
+	// I18next treats JSON documents as i18next resources. The plural forms of
+	// a key, like "item_one" and "item_other", are translated together, and
+	// the plural forms that the target language needs, like "item_few", are
+	// added. Interpolations like "{{count}}" and nesting references like
+	// "$t(common.save)" are never translated. The pairs of "key" and
+	// "key_plural" of i18next v3 are translated together but not expanded.
+	I18next bool
+
+	// Join joins the translations of the chunks into the translated document,
+	// like [JoinJSON] for the chunks of a [JSONPacker]. By default, the
+	// translations are separated by blank lines.
+	Join func(translations []string) (string, error)
+
+	// Validate is an optional [Validator] that checks the translation of each
+	// chunk, for example [ValidateJSON]. If a translated chunk is invalid, it is
+	// translated again up to ValidationRetries times before Translate fails.
+	Validate Validator
+
+	// ValidationRetries is the number of times a chunk is translated again if
+	// its translation is rejected by the Validate function.
+	ValidationRetries int
+
+	// LengthConstraint limits the length of translations relative to their
+	// source. Texts whose translation exceeds the limit are translated again
+	// with a stricter instruction. For JSON documents, the limit applies to
+	// every string value.
+	LengthConstraint LengthConstraint
+
+	// OnOverlong is called for every text whose translation still exceeds the
+	// limit of the LengthConstraint after all retries.
+	OnOverlong func(OverlongText)
+
+	// Overrides maps the 1-based numbers of chunks to fixed, human-provided
+	// translations. Overridden chunks are not sent to the model; their
+	// translations are used verbatim.
+	Overrides map[int]string
+
+	// RefusalRetries is the number of times a chunk that the model refused to
+	// translate is translated again, with an instruction that asks the model to
+	// faithfully localize the text as existing content.
+	RefusalRetries int
+
+	// SkipRefused leaves chunks that the model refused to translate in the
+	// source language instead of failing with [ErrRefused]. Skipped chunks are
+	// passed to OnSkip.
+	SkipRefused bool
+
+	// ErrorPolicy decides what happens when a chunk fails to translate for
+	// another reason than a refusal. By default, the translation aborts.
+	// Chunks that are skipped because of the policy are passed to OnSkip.
+	ErrorPolicy ErrorPolicy
+
+	// OnSkip is called for every chunk that was skipped because of SkipRefused
+	// or the ErrorPolicy.
+	OnSkip func(SkippedChunk)
+
+	// ChunkTimeout limits the time of the translation of each chunk, including
+	// its retries. A chunk that exceeds it fails with [ErrChunkTimeout], which
+	// the ErrorPolicy may skip. Zero means no limit.
+	ChunkTimeout time.Duration
+
+	// RunTimeout limits the time of the translation of the whole document. If
+	// it is exceeded, the translation fails with [ErrRunTimeout]. Zero means no
+	// limit.
+	RunTimeout time.Duration
+
+	// Stop stops the translation gracefully when it is closed: the chunk that
+	// is being translated is finished, but no further chunks are started. The
+	// translation then fails with a [*StoppedError], and TranslateChunks and
+	// Translate return what was translated so far.
+	Stop <-chan struct{}
+
+	// Budget limits the usage of the translation. If the next chunk would
+	// exceed it, the translation stops like it would using Stop. A Budget can
+	// be shared by several translations.
+	Budget *Budget
+
+	// OnChunkStart is called before a chunk is translated, for example to
+	// report the progress of long translations.
+	OnChunkStart func(ChunkProgress)
+
+	// OnChunkDone is called after a chunk was translated, overridden or
+	// skipped.
+	OnChunkDone func(ChunkProgress)
+
+	// OnProgress is called while a chunk is translated by a streaming model,
+	// whenever a part of the response arrives, with the response received so
+	// far as the Translation of the progress, for example to render partial
+	// translations in a user interface. The response is passed as generated
+	// by the model, before it is validated or post-processed. Models report
+	// partial responses through [ReportPartial].
+	OnProgress func(ChunkProgress)
+
+	// CarryOver is the number of previously translated chunks that are
+	// included in the prompt of each chunk, so that terminology, pronouns and
+	// tone stay consistent across chunk boundaries.
+	CarryOver int
+
+	// CarrySummary includes a rolling summary of the previously translated
+	// chunks in the prompt of each chunk instead of the chunks themselves. The
+	// model updates the summary after every chunk, which costs an additional
+	// request per chunk. CarryOver is ignored if CarrySummary is set.
+	//
+	// The carry-over is not supported by [Translator.Prompts] and by
+	// translation engines.
+	CarrySummary bool
+
+	// previous is the carry-over section of the prompt of the current chunk.
+	previous string
+}
+
+// ChunkProgress describes a chunk of a document that is being translated.
+type ChunkProgress struct {
+	// Chunk is the 1-based number of the chunk.
+	Chunk int
+
+	// Chunks is the number of chunks of the document.
+	Chunks int
+
+	// Source is the text of the chunk.
+	Source string
+
+	// Prompt is the prompt that is sent to the model for the chunk. It is empty
+	// for overridden chunks and for translation engines that are not prompted.
+	Prompt string
+
+	// Translation is the translation of the chunk. It is only set when the
+	// chunk is done, or to the partial response for
+	// [TranslateParams.OnProgress].
+	Translation string
 }
 
 // NewTranslator creates a new instance of a translator, initializing it with a
-// provided model for language translation tasks. It returns a [*Translator].
-func NewTranslator(svc Model) *Translator {
-	return &Translator{
-		model: svc,
+// provided model for language translation tasks and the given options. It
+// returns a [*Translator].
+func NewTranslator(svc Model, opts ...TranslatorOption) *Translator {
+	t := &Translator{model: svc, logger: logging.Discard()}
+	for _, opt := range opts {
+		opt(t)
 	}
+	return t
 }
 
 // Translate converts the content of a document from one language to another
@@ -65,63 +267,479 @@ func NewTranslator(svc Model) *Translator {
 // fails. Input parameters and context are provided by a [TranslateParams] and
 // [context.Context], respectively.
 func (t *Translator) Translate(ctx context.Context, params TranslateParams) (string, error) {
+	result, err := t.TranslateWithUsage(ctx, params)
+	return result.Text, err
+}
+
+// TranslateResult is a translated document together with the usage of the
+// model that translated it.
+type TranslateResult struct {
+	Text  string
+	Usage Usage
+}
+
+// TranslateWithUsage translates a document like Translate, but also returns
+// the number of requests and tokens that the model used for the translation.
+// The usage is zero for models that do not report their usage using
+// [ReportUsage], like a [ModelFunc].
+func (t *Translator) TranslateWithUsage(ctx context.Context, params TranslateParams) (TranslateResult, error) {
+	var usage Usage
+	pairs, err := t.TranslateChunks(TrackUsage(ctx, &usage), params)
+	if err != nil && !errors.Is(err, ErrStopped) {
+		return TranslateResult{}, err
+	}
+
+	translations := mapSlice(pairs, func(p ChunkPair) string {
+		return p.Translation
+	})
+
+	text := strings.Join(translations, "\n\n")
+	if params.Join != nil {
+		joined, joinErr := params.Join(translations)
+		if joinErr != nil {
+			return TranslateResult{}, fmt.Errorf("join chunks: %w", joinErr)
+		}
+		text = joined
+	}
+
+	// A stopped translation returns the chunks that were translated so far.
+	return TranslateResult{Text: addNewline(text), Usage: usage}, err
+}
+
+// ChunkPair is a chunk of a document together with its translation.
+type ChunkPair struct {
+	Source      string
+	Translation string
+}
+
+// TranslateChunks translates a document like Translate, but returns the chunks
+// of the document paired with their translations instead of the joined
+// translation. The pairs can be rendered using [RenderBilingual].
+func (t *Translator) TranslateChunks(ctx context.Context, params TranslateParams) ([]ChunkPair, error) {
 	if params.Target == "" {
 		params.Target = "English"
 	}
 
-	docChunks := chunks.Chunks(params.Document, params.SplitChunks)
+	ctx, cancel := params.runContext(ctx)
+	defer cancel()
+
+	docChunks := params.chunks()
+	carry := newCarryOver(params)
+
+	pairs := make([]ChunkPair, 0, len(docChunks))
+	for i, chunk := range docChunks {
+		if params.stopped() {
+			t.logger.DebugContext(ctx, "stop translation", "chunk", i+1, "chunks", len(docChunks))
+			return pairs, &StoppedError{Chunk: i + 1, Chunks: len(docChunks)}
+		}
+
+		progress := ChunkProgress{Chunk: i + 1, Chunks: len(docChunks), Source: chunk}
+		params.previous = carry.section()
+		logger := t.logger.With("chunk", i+1, "chunks", len(docChunks))
+
+		if translated, ok := params.Overrides[i+1]; ok {
+			logger.DebugContext(ctx, "use override")
+			pairs = append(pairs, ChunkPair{Source: chunk, Translation: translated})
+			progress.Translation = translated
+			notify(params.OnChunkDone, progress)
+			if err := t.carry(ctx, carry, translated); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if t.engine == nil {
+			prompt, err := t.sourcePrompt(chunk, params)
+			if err != nil {
+				return nil, err
+			}
+			progress.Prompt = prompt
+		}
+
+		if err := params.Budget.check(chunk, progress.Prompt); err != nil {
+			if !errors.Is(err, ErrBudgetExceeded) {
+				return nil, err
+			}
+			logger.DebugContext(ctx, "stop translation", "error", err)
+			return pairs, &StoppedError{Chunk: i + 1, Chunks: len(docChunks), Cause: err}
+		}
 
-	result := make([]string, 0, len(docChunks))
-	for _, chunk := range docChunks {
-		translated, err := t.translateChunk(ctx, chunk, params)
+		notify(params.OnChunkStart, progress)
+		logger.DebugContext(ctx, "translate chunk")
+
+		var chunkUsage Usage
+		chunkCtx := ctx
+		if params.Budget != nil {
+			chunkCtx = TrackUsage(ctx, &chunkUsage)
+		}
+		if params.OnProgress != nil {
+			chunkCtx = WithPartial(chunkCtx, func(text string) {
+				partial := progress
+				partial.Translation = text
+				params.OnProgress(partial)
+			})
+		}
+
+		translated, err := t.translateTimedChunk(chunkCtx, logger, chunk, params, progress)
+		params.Budget.add(chunkUsage)
+		skipped := skipChunk(ctx, err, params)
+		if skipped {
+			logger.WarnContext(ctx, "skip chunk", "error", err)
+			if params.OnSkip != nil {
+				params.OnSkip(SkippedChunk{Chunk: i + 1, Source: chunk, Reason: err.Error()})
+			}
+			translated = chunk
+		} else if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, ChunkPair{Source: chunk, Translation: translated})
+		logger.DebugContext(ctx, "chunk done")
+
+		progress.Translation = translated
+		notify(params.OnChunkDone, progress)
+
+		// Skipped chunks are not carried over because they are untranslated.
+		if !skipped {
+			if err := t.carry(ctx, carry, translated); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pairs, nil
+}
+
+// carry carries the translation of a chunk over into the prompts of the
+// following chunks. Translation engines are not prompted, so nothing is
+// carried over for them.
+func (t *Translator) carry(ctx context.Context, carry *carryOver, translation string) error {
+	if !carry.enabled() || t.engine != nil {
+		return nil
+	}
+	return carry.add(ctx, t.model, translation)
+}
+
+func notify(fn func(ChunkProgress), progress ChunkProgress) {
+	if fn != nil {
+		fn(progress)
+	}
+}
+
+// Prompts returns the prompts that Translate would send to the model for the
+// chunks of the document, in order, without calling the model. Overridden
+// chunks are skipped. The prompts can
+// be used to translate a document asynchronously, for example using a batch
+// API, and then replayed through Translate using a [Model] that returns the
+// collected responses.
+func (t *Translator) Prompts(params TranslateParams) ([]string, error) {
+	if params.Target == "" {
+		params.Target = "English"
+	}
+
+	var prompts []string
+	for i, chunk := range params.chunks() {
+		if _, ok := params.Overrides[i+1]; ok {
+			continue
+		}
+
+		prompt, err := t.sourcePrompt(chunk, params)
 		if err != nil {
-			return "", fmt.Errorf("translate chunk: %w", err)
+			return nil, err
 		}
-		result = append(result, translated)
+		prompts = append(prompts, prompt)
 	}
 
-	return addNewline(strings.Join(result, "\n\n")), nil
+	return prompts, nil
 }
 
-func (t *Translator) translateChunk(ctx context.Context, chunk string, params TranslateParams) (string, error) {
-	var from string
-	if params.Source != "" {
-		from = fmt.Sprintf("from %s ", params.Source)
+// sourcePrompt returns the prompt of a chunk of the source document.
+func (t *Translator) sourcePrompt(chunk string, params TranslateParams) (string, error) {
+	if split, ok := splitJSONChunk(chunk, params); ok {
+		chunk, params = split.source(), split.params(params)
 	}
+	return t.requestPrompt(mask(chunk, params.PreservePatterns).text, params)
+}
 
-	instructions := append([]string{
-		"Preserve the original document structure and formatting.",
-		"Preserve code blocks, placeholders, HTML tags and other structures.",
-	}, params.Instructions...)
+// PromptMessages returns the conversations that Translate would send to the
+// model for the chunks of the document, like [Translator.Prompts], for
+// example to inspect the prompts before paying for a translation. Models that
+// implement [MessageModel] receive the conversations as they are returned;
+// other models receive a single user message with the prompt of the chunk.
+func (t *Translator) PromptMessages(params TranslateParams) ([][]Message, error) {
+	if params.Target == "" {
+		params.Target = "English"
+	}
+
+	var out [][]Message
+	for i, chunk := range params.chunks() {
+		if _, ok := params.Overrides[i+1]; ok {
+			continue
+		}
 
-	if len(params.Preserve) > 0 {
-		instructions = append(instructions, fmt.Sprintf("Do not translate the following terms: %s", strings.Join(params.Preserve, ", ")))
+		msgs, err := t.sourceMessages(chunk, params)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msgs)
 	}
 
-	prompt := heredoc.Docf(`
-		Translate the following document %sto %s:
-		---<DOC_BEGIN>---
-		%s
-		---<DOC_END>---
+	return out, nil
+}
 
-		%s
+// sourceMessages returns the conversation of a chunk of the source document.
+func (t *Translator) sourceMessages(chunk string, params TranslateParams) ([]Message, error) {
+	if _, ok := t.model.(MessageModel); !ok {
+		prompt, err := t.sourcePrompt(chunk, params)
+		if err != nil {
+			return nil, err
+		}
+		return []Message{{Role: RoleUser, Content: prompt}}, nil
+	}
 
-		Output only the translated document, no chat.
-	`,
-		from,
-		params.Target,
-		chunk,
-		strings.Join(instructions, "\n"),
-	)
+	if split, ok := splitJSONChunk(chunk, params); ok {
+		chunk, params = split.source(), split.params(params)
+	}
+	return t.messages(mask(chunk, params.PreservePatterns).text, params)
+}
 
-	response, err := t.model.Chat(ctx, prompt)
+// translatePolicyChunk translates a chunk and translates it again if it failed
+// and the error policy of the params asks for retries. Refused and canceled
+// chunks are never translated again.
+func (t *Translator) translatePolicyChunk(ctx context.Context, logger *slog.Logger, chunk string, params TranslateParams) (string, error) {
+	split, isSplit := splitJSONChunk(chunk, params)
+	for failures := 1; ; failures++ {
+		var translated string
+		var err error
+		if isSplit {
+			translated, err = t.translateSplitChunk(ctx, logger, split, params)
+		} else {
+			translated, err = t.translateValidChunk(ctx, logger, chunk, params)
+		}
+		if err == nil || errors.Is(err, ErrRefused) || ctx.Err() != nil || !params.ErrorPolicy.retry(failures) {
+			return translated, err
+		}
+		logger.WarnContext(ctx, "retry failed chunk", "error", err, "attempt", failures)
+	}
+}
+
+// splitChunk is a JSON chunk whose values were rewritten before their
+// translation, like the ICU MessageFormat messages of an [icuChunk] or the
+// plural forms of an [i18nextChunk].
+type splitChunk interface {
+	// source returns the rewritten chunk.
+	source() string
+
+	// params returns the params of the translation of the rewritten chunk.
+	params(TranslateParams) TranslateParams
+
+	// join restores the original structure of the translated chunk.
+	join(translated string) (string, error)
+}
+
+// splitJSONChunk rewrites the values of a JSON chunk for its translation: the
+// plural forms of i18next resources if the params ask for i18next, or the ICU
+// MessageFormat messages otherwise. It reports false if there is nothing to
+// rewrite.
+func splitJSONChunk(chunk string, params TranslateParams) (splitChunk, bool) {
+	if params.I18next {
+		split, ok := splitI18next(chunk, params.Target)
+		return split, ok
+	}
+	split, ok := splitICU(chunk, params.Target)
+	return split, ok
+}
+
+// translateSplitChunk translates a rewritten JSON chunk and restores the
+// structure of the translation.
+func (t *Translator) translateSplitChunk(ctx context.Context, logger *slog.Logger, chunk splitChunk, params TranslateParams) (string, error) {
+	params = chunk.params(params)
+
+	translated, err := t.translateValidChunk(ctx, logger, chunk.source(), params)
+	if err != nil {
+		return "", err
+	}
+
+	return chunk.join(translated)
+}
+
+// skipChunk reports whether a chunk that failed with the given error is left
+// untranslated instead of aborting the translation.
+func skipChunk(ctx context.Context, err error, params TranslateParams) bool {
+	switch {
+	case err == nil || ctx.Err() != nil:
+		return false
+	case errors.Is(err, ErrRefused):
+		return params.SkipRefused
+	default:
+		return params.ErrorPolicy.Action == ErrorSkip
+	}
+}
+
+func (t *Translator) translateValidChunk(ctx context.Context, logger *slog.Logger, chunk string, params TranslateParams) (string, error) {
+	masked := mask(chunk, params.PreservePatterns)
+	for attempt, refusals := 0, 0; ; attempt++ {
+		translated, err := t.translateChunk(ctx, masked.text, params)
+		if errors.Is(err, ErrRefused) && refusals < params.RefusalRetries {
+			logger.WarnContext(ctx, "retry refused chunk", "error", err, "attempt", refusals+1)
+			if refusals == 0 {
+				params.Instructions = append(slices.Clone(params.Instructions), refusalInstruction)
+			}
+			refusals++
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("translate chunk: %w", err)
+		}
+
+		if translated, err = masked.restore(translated); err != nil {
+			if attempt < params.ValidationRetries {
+				logger.WarnContext(ctx, "retry invalid translation", "error", err, "attempt", attempt+1)
+				continue
+			}
+			return "", fmt.Errorf("validate chunk: %w", err)
+		}
+
+		if params.Validate != nil {
+			if err := params.Validate(chunk, translated); err != nil {
+				if attempt < params.ValidationRetries && errors.Is(err, ErrInvalidTranslation) {
+					logger.WarnContext(ctx, "retry invalid translation", "error", err, "attempt", attempt+1)
+					continue
+				}
+				return "", fmt.Errorf("validate chunk: %w", err)
+			}
+		}
+
+		return t.constrainLength(ctx, logger, chunk, translated, params)
+	}
+}
+
+func (t *Translator) translateChunk(ctx context.Context, chunk string, params TranslateParams) (string, error) {
+	if t.engine != nil {
+		return t.translateWithEngine(ctx, chunk, params)
+	}
+
+	response, err := t.chat(ctx, chunk, params)
+	if isRefusalError(err) {
+		return "", fmt.Errorf("%w: %v", ErrRefused, err)
+	}
 	if err != nil {
 		return "", err
 	}
 
+	if IsRefusal(response) && !IsRefusal(chunk) {
+		return "", fmt.Errorf("%w: %q", ErrRefused, firstLine(trimDividers(response)))
+	}
+
 	return trimDividers(response), nil
 }
 
+// chat sends the prompt of a chunk to the model. The instructions, the system
+// prompt and the examples of the params are sent as separate messages if the
+// model is a [MessageModel], and as part of the prompt otherwise.
+func (t *Translator) chat(ctx context.Context, chunk string, params TranslateParams) (string, error) {
+	model, ok := t.model.(MessageModel)
+	if !ok {
+		prompt, err := t.requestPrompt(chunk, params)
+		if err != nil {
+			return "", err
+		}
+		return t.model.Chat(ctx, prompt)
+	}
+
+	msgs, err := t.messages(chunk, params)
+	if err != nil {
+		return "", err
+	}
+	return model.ChatMessages(ctx, msgs)
+}
+
+// messages returns the conversation for translating a chunk of a document: a
+// system message with the system prompt and the instructions of the built-in
+// prompt, a user and an assistant message for each example and a user message
+// with the chunk. The messages of the examples do not contain the context and
+// the carry-over of the params. If the Translator uses a prompt template, the
+// system message only contains the system prompt and the user messages are
+// rendered by the template.
+func (t *Translator) messages(chunk string, params TranslateParams) ([]Message, error) {
+	system := []string{strings.TrimSpace(params.SystemPrompt)}
+	if t.prompt == nil {
+		system = append(system, strings.TrimSpace(defaultInstructions(newPromptData("", params))))
+	}
+
+	var msgs []Message
+	if content := strings.TrimSpace(strings.Join(system, "\n\n")); content != "" {
+		msgs = append(msgs, Message{Role: RoleSystem, Content: content})
+	}
+
+	exampleParams := params
+	exampleParams.Context = nil
+	exampleParams.previous = ""
+	for _, example := range params.Examples {
+		prompt, err := t.userMessage(example.Source, exampleParams)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs,
+			Message{Role: RoleUser, Content: prompt},
+			Message{Role: RoleAssistant, Content: example.Target},
+		)
+	}
+
+	prompt, err := t.userMessage(chunk, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(msgs, Message{Role: RoleUser, Content: prompt}), nil
+}
+
+// userMessage returns the user message for translating a chunk of a document,
+// using the prompt template of the Translator if one was provided.
+func (t *Translator) userMessage(chunk string, params TranslateParams) (string, error) {
+	if t.prompt == nil {
+		return documentMessage(newPromptData(chunk, params)), nil
+	}
+	return t.chunkPrompt(chunk, params)
+}
+
+// requestPrompt returns the prompt of a chunk as it is sent to models that
+// accept a single prompt: the system prompt and the examples of the params,
+// followed by the prompt of the chunk.
+func (t *Translator) requestPrompt(chunk string, params TranslateParams) (string, error) {
+	prompt, err := t.chunkPrompt(chunk, params)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if params.SystemPrompt != "" {
+		b.WriteString(strings.TrimSpace(params.SystemPrompt))
+		b.WriteString("\n\n")
+	}
+	if len(params.Examples) > 0 {
+		b.WriteString(examplesSection(params.Examples))
+		b.WriteString("\n")
+	}
+	b.WriteString(prompt)
+
+	return b.String(), nil
+}
+
+// chunkPrompt returns the prompt for translating a chunk of a document, using
+// the prompt template of the Translator if one was provided.
+func (t *Translator) chunkPrompt(chunk string, params TranslateParams) (string, error) {
+	data := newPromptData(chunk, params)
+	if t.prompt == nil {
+		return defaultPrompt(data), nil
+	}
+
+	var b strings.Builder
+	if err := t.prompt.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("execute prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
 func trimDividers(text string) string {
 	lines := strings.Split(text, "\n")
 
@@ -142,6 +760,18 @@ func trimDividers(text string) string {
 	return strings.TrimSpace(strings.Join(out, "\n"))
 }
 
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
+
+func withNewline(text string) string {
+	if text == "" {
+		return text
+	}
+	return text + "\n"
+}
+
 func addNewline(text string) string {
 	if text == "" {
 		return text
@@ -2,9 +2,14 @@ package dragoman_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
 	"github.com/modernice/dragoman"
 )
 
@@ -110,6 +115,329 @@ func TestPreserve_multiple(t *testing.T) {
 	prompt(wantPrompt).expect(t, dragoman.TranslateParams{Document: source, Preserve: []string{"HalloWeltBot", "WeltFabrik"}})
 }
 
+func TestPreservePatterns(t *testing.T) {
+	source := `{"greeting": "Hallo {name}, du hast {count} neue Nachrichten. Hallo {name}!"}`
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return `{"greeting": "Hello ⟦0⟧, you have ⟦1⟧ new messages. Hello ⟦0⟧!"}`, nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:         source,
+		PreservePatterns: []*regexp.Regexp{regexp.MustCompile(`\{[a-z]+\}`)},
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if want := `{"greeting": "Hello {name}, you have {count} new messages. Hello {name}!"}` + "\n"; result != want {
+		t.Fatalf("Translate() should return %q; got %q", want, result)
+	}
+
+	if strings.Contains(prompts[0], "{name}") || !strings.Contains(prompts[0], "Hallo ⟦0⟧, du hast ⟦1⟧ neue Nachrichten. Hallo ⟦0⟧!") {
+		t.Fatalf("the matches should be masked in the prompt; got\n\n%s", prompts[0])
+	}
+
+	if !strings.Contains(prompts[0], "Keep tokens like ⟦0⟧ exactly as they are.") {
+		t.Fatalf("the prompt should tell the model to keep the tokens; got\n\n%s", prompts[0])
+	}
+}
+
+func TestPreservePatterns_overlapping(t *testing.T) {
+	var prompt string
+	model := dragoman.ModelFunc(func(_ context.Context, p string) (string, error) {
+		prompt = p
+		return "Visit ⟦0⟧ or call ⟦1⟧.", nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: "Besuche https://example.com/42 oder ruf 42 an.",
+		PreservePatterns: []*regexp.Regexp{
+			regexp.MustCompile(`\d+`),
+			regexp.MustCompile(`https://\S+`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if !strings.Contains(prompt, "Besuche ⟦0⟧ oder ruf ⟦1⟧ an.") {
+		t.Fatalf("the URL should be masked as a whole; got\n\n%s", prompt)
+	}
+
+	if want := "Visit https://example.com/42 or call 42.\n"; result != want {
+		t.Fatalf("Translate() should return %q; got %q", want, result)
+	}
+}
+
+func TestPreservePatterns_lostToken(t *testing.T) {
+	var calls int
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		calls++
+		if calls == 1 {
+			return "Hello name!", nil
+		}
+		return "Hello ⟦0⟧!", nil
+	})
+
+	params := dragoman.TranslateParams{
+		Document:         "Hallo {name}!",
+		PreservePatterns: []*regexp.Regexp{regexp.MustCompile(`\{[a-z]+\}`)},
+	}
+
+	if _, err := dragoman.NewTranslator(model).Translate(context.Background(), params); !errors.Is(err, dragoman.ErrInvalidTranslation) {
+		t.Fatalf("Translate() should fail with %v; got %v", dragoman.ErrInvalidTranslation, err)
+	}
+
+	calls = 0
+	params.ValidationRetries = 1
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if want := "Hello {name}!\n"; result != want || calls != 2 {
+		t.Fatalf("Translate() should return %q after 2 requests; got %q after %d", want, result, calls)
+	}
+}
+
+func TestFormality(t *testing.T) {
+	source := heredoc.Docf(`{
+		"hello": "Hello, how are you?"
+	}`)
+
+	wantPrompt := heredoc.Docf(`
+		Translate the following document to German:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		Preserve the original document structure and formatting.
+		Preserve code blocks, placeholders, HTML tags and other structures.
+		Use informal language and address forms, applicable across all languages where such distinctions exist.
+
+		Output only the translated document, no chat.
+	`, source)
+
+	prompt(wantPrompt).expect(t, dragoman.TranslateParams{Document: source, Target: "German", Formality: dragoman.FormalityInformal})
+}
+
+func TestContext(t *testing.T) {
+	source := heredoc.Docf(`{
+		"hallo": "Hallo Welt!"
+	}`)
+
+	wantPrompt := heredoc.Docf(`
+		Translate the following document to English:
+		---<DOC_BEGIN>---
+		%s
+		---<DOC_END>---
+
+		Preserve the original document structure and formatting.
+		Preserve code blocks, placeholders, HTML tags and other structures.
+
+		Use the following reference material to match terminology, tone and style. Do not include it in the output:
+		---<CONTEXT_BEGIN>---
+		Always address the reader as "you".
+		---<CONTEXT_END>---
+
+		Output only the translated document, no chat.
+	`, source)
+
+	prompt(wantPrompt).expect(t, dragoman.TranslateParams{Document: source, Context: []string{`Always address the reader as "you".`}})
+}
+
+func TestSystemPromptAndExamples(t *testing.T) {
+	params := dragoman.TranslateParams{
+		Document:     "Sign in",
+		Target:       "German",
+		SystemPrompt: "You localize a playful mobile game.",
+		Examples:     []dragoman.Example{{Source: "Log out", Target: "Tschüss!"}},
+	}
+
+	wantPrompt := heredoc.Doc(`
+		You localize a playful mobile game.
+
+		Translate in the style of the following examples:
+		---<EXAMPLE_SOURCE>---
+		Log out
+		---<EXAMPLE_TRANSLATION>---
+		Tschüss!
+		---<EXAMPLE_END>---
+
+		Translate the following document to German:
+		---<DOC_BEGIN>---
+		Sign in
+		---<DOC_END>---
+
+		Preserve the original document structure and formatting.
+		Preserve code blocks, placeholders, HTML tags and other structures.
+
+		Output only the translated document, no chat.
+	`)
+
+	prompt(wantPrompt).expect(t, params)
+}
+
+type messageModel struct {
+	dragoman.Model
+	messages []dragoman.Message
+}
+
+func (m *messageModel) ChatMessages(_ context.Context, msgs []dragoman.Message) (string, error) {
+	m.messages = msgs
+	return "Anmelden", nil
+}
+
+func TestSystemPromptAndExamples_messageModel(t *testing.T) {
+	model := &messageModel{Model: dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		t.Fatal("Chat() should not be called for a MessageModel")
+		return "", nil
+	})}
+
+	params := dragoman.TranslateParams{
+		Document:     "Sign in",
+		Target:       "German",
+		SystemPrompt: "You localize a playful mobile game.",
+		Examples:     []dragoman.Example{{Source: "Log out", Target: "Tschüss!"}},
+		Context:      []string{"Glossary"},
+	}
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+	if result != "Anmelden\n" {
+		t.Fatalf("Translate() should return %q; got %q", "Anmelden\n", result)
+	}
+
+	roles := make([]dragoman.Role, len(model.messages))
+	for i, msg := range model.messages {
+		roles[i] = msg.Role
+	}
+	wantRoles := []dragoman.Role{dragoman.RoleSystem, dragoman.RoleUser, dragoman.RoleAssistant, dragoman.RoleUser}
+	if fmt.Sprint(roles) != fmt.Sprint(wantRoles) {
+		t.Fatalf("messages should have the roles %v; got %v", wantRoles, roles)
+	}
+
+	if system := model.messages[0].Content; !strings.HasPrefix(system, params.SystemPrompt) || !strings.Contains(system, "to German") || strings.Contains(system, "Sign in") {
+		t.Errorf("system message should contain the system prompt and the instructions; got\n\n%s", system)
+	}
+	if !strings.Contains(model.messages[1].Content, "Log out") || strings.Contains(model.messages[1].Content, "Glossary") {
+		t.Errorf("example prompt should contain the example source but not the context; got\n\n%s", model.messages[1].Content)
+	}
+	if model.messages[2].Content != "Tschüss!" {
+		t.Errorf("example answer should be %q; got %q", "Tschüss!", model.messages[2].Content)
+	}
+	if last := model.messages[3].Content; !strings.Contains(last, "Sign in") || !strings.Contains(last, "Glossary") || strings.Contains(last, "Log out") || strings.Contains(last, "Output only") {
+		t.Errorf("last message should be the prompt of the document; got\n\n%s", last)
+	}
+}
+
+func TestTranslator_Translate_messageModel(t *testing.T) {
+	model := &messageModel{}
+
+	if _, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: "Sign in",
+		Source:   "English",
+		Target:   "German",
+	}); err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	want := []dragoman.Message{
+		{Role: dragoman.RoleSystem, Content: strings.TrimSpace(heredoc.Doc(`
+			Translate the documents of the user from English to German. Each document is enclosed by ---<DOC_BEGIN>--- and ---<DOC_END>---.
+
+			Preserve the original document structure and formatting.
+			Preserve code blocks, placeholders, HTML tags and other structures.
+
+			Output only the translated document, no chat.
+		`))},
+		{Role: dragoman.RoleUser, Content: "---<DOC_BEGIN>---\nSign in\n---<DOC_END>---"},
+	}
+
+	if fmt.Sprint(model.messages) != fmt.Sprint(want) {
+		t.Fatalf("ChatMessages() should be called with\n\n%v\n\ngot\n\n%v", want, model.messages)
+	}
+}
+
+type recordingMessageModel struct {
+	dragoman.Model
+	conversations [][]dragoman.Message
+}
+
+func (m *recordingMessageModel) ChatMessages(_ context.Context, msgs []dragoman.Message) (string, error) {
+	m.conversations = append(m.conversations, msgs)
+	return strings.Split(strings.Split(msgs[len(msgs)-1].Content, "---<DOC_BEGIN>---\n")[1], "\n---<DOC_END>---")[0], nil
+}
+
+func TestTranslator_PromptMessages(t *testing.T) {
+	params := dragoman.TranslateParams{
+		Document:         "# One\n\nVisit https://example.com\n\n# Two\n\nBye",
+		Target:           "German",
+		SplitChunks:      []string{"# "},
+		PreservePatterns: []*regexp.Regexp{regexp.MustCompile(`https://\S+`)},
+		Examples:         []dragoman.Example{{Source: "Hi", Target: "Hallo"}},
+	}
+
+	model := &recordingMessageModel{}
+	translator := dragoman.NewTranslator(model)
+
+	got, err := translator.PromptMessages(params)
+	if err != nil {
+		t.Fatalf("PromptMessages() failed: %v", err)
+	}
+
+	if _, err := translator.Translate(context.Background(), params); err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if diff := cmp.Diff(model.conversations, got); diff != "" {
+		t.Fatalf("PromptMessages() should return the sent conversations (-sent +got):\n%s", diff)
+	}
+
+	if strings.Contains(got[0][len(got[0])-1].Content, "https://example.com") {
+		t.Fatalf("prompt should contain the masked document; got\n\n%s", got[0][len(got[0])-1].Content)
+	}
+
+	prompts, err := dragoman.NewTranslator(dragoman.ModelFunc(func(context.Context, string) (string, error) { return "", nil })).PromptMessages(params)
+	if err != nil {
+		t.Fatalf("PromptMessages() failed: %v", err)
+	}
+	if len(prompts) != 2 || len(prompts[0]) != 1 || prompts[0][0].Role != dragoman.RoleUser {
+		t.Fatalf("PromptMessages() should return a single user message per chunk for other models; got %v", prompts)
+	}
+}
+
+func TestAsMessageModel(t *testing.T) {
+	var got string
+	model := dragoman.AsMessageModel(dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		got = prompt
+		return "Anmelden", nil
+	}))
+
+	resp, err := model.ChatMessages(context.Background(), []dragoman.Message{
+		{Role: dragoman.RoleSystem, Content: "Translate to German."},
+		{Role: dragoman.RoleUser, Content: "Sign in"},
+	})
+	if err != nil {
+		t.Fatalf("ChatMessages() failed: %v", err)
+	}
+	if resp != "Anmelden" {
+		t.Fatalf("ChatMessages() should return %q; got %q", "Anmelden", resp)
+	}
+	if want := "Translate to German.\n\nSign in"; got != want {
+		t.Fatalf("Chat() should be called with %q; got %q", want, got)
+	}
+
+	if m := (&messageModel{}); dragoman.AsMessageModel(m) != m {
+		t.Fatalf("AsMessageModel() should return a MessageModel as is")
+	}
+}
+
 type prompt string
 
 func (p prompt) expect(t *testing.T, params dragoman.TranslateParams) {
@@ -131,3 +459,112 @@ func (p prompt) expect(t *testing.T, params dragoman.TranslateParams) {
 		t.Errorf("expected prompt to be\n\n%s\n\nbut prompt was\n\n%s", p, providedPrompt)
 	}
 }
+
+func TestTranslator_Translate_overrides(t *testing.T) {
+	source := heredoc.Doc(`
+		# Intro
+
+		Hallo Welt!
+
+		# Legal
+
+		Keine Gewähr.
+	`)
+
+	var prompts []string
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "# Intro\n\nHello world!", nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:    source,
+		SplitChunks: []string{"# "},
+		Overrides:   map[int]string{2: "# Legal\n\nNo warranty."},
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if len(prompts) != 1 {
+		t.Fatalf("overridden chunks must not be sent to the model; got %d prompts", len(prompts))
+	}
+
+	if want := "# Intro\n\nHello world!\n\n# Legal\n\nNo warranty.\n"; result != want {
+		t.Fatalf("expected result to be\n\n%s\n\nbut result was\n\n%s", want, result)
+	}
+}
+
+func TestTranslator_Translate_progress(t *testing.T) {
+	source := heredoc.Doc(`
+		# Intro
+
+		Hallo Welt!
+
+		# Legal
+
+		Keine Gewähr.
+	`)
+
+	model := dragoman.ModelFunc(func(_ context.Context, prompt string) (string, error) {
+		return "# Intro\n\nHello world!", nil
+	})
+
+	var events []string
+	record := func(event string) func(dragoman.ChunkProgress) {
+		return func(p dragoman.ChunkProgress) {
+			events = append(events, fmt.Sprintf("%s %d/%d prompt=%t translation=%q", event, p.Chunk, p.Chunks, p.Prompt != "", p.Translation))
+		}
+	}
+
+	_, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:     source,
+		SplitChunks:  []string{"# "},
+		Overrides:    map[int]string{2: "# Legal\n\nNo warranty."},
+		OnChunkStart: record("start"),
+		OnChunkDone:  record("done"),
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	want := []string{
+		`start 1/2 prompt=true translation=""`,
+		`done 1/2 prompt=true translation="# Intro\n\nHello world!"`,
+		`done 2/2 prompt=false translation="# Legal\n\nNo warranty."`,
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected progress events:\n\n%s", strings.Join(events, "\n"))
+	}
+}
+
+func TestTranslator_Translate_partialProgress(t *testing.T) {
+	model := dragoman.ModelFunc(func(ctx context.Context, prompt string) (string, error) {
+		var text string
+		for _, part := range []string{"Hello", " world", "!"} {
+			text += part
+			dragoman.ReportPartial(ctx, text)
+		}
+		return text, nil
+	})
+
+	var partials []string
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document: "Hallo Welt!",
+		OnProgress: func(p dragoman.ChunkProgress) {
+			partials = append(partials, fmt.Sprintf("%d/%d %q", p.Chunk, p.Chunks, p.Translation))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Translate() failed: %v", err)
+	}
+
+	if want := "Hello world!\n"; result != want {
+		t.Fatalf("Translate() should return %q; got %q", want, result)
+	}
+
+	want := []string{`1/1 "Hello"`, `1/1 "Hello world"`, `1/1 "Hello world!"`}
+	if strings.Join(partials, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected partial progress:\n\n%s", strings.Join(partials, "\n"))
+	}
+}
@@ -0,0 +1,65 @@
+package dragoman
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage is the number of requests and tokens that a model used.
+type Usage struct {
+	// Requests is the number of successful requests to the model.
+	Requests int
+
+	// PromptTokens is the number of tokens of the prompts.
+	PromptTokens int
+
+	// CompletionTokens is the number of tokens of the responses.
+	CompletionTokens int
+}
+
+// TotalTokens returns the sum of the prompt and completion tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add returns the sum of both usages.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Requests:         u.Requests + other.Requests,
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+type usageContextKey struct{}
+
+type usageTracker struct {
+	mux    *sync.Mutex
+	usage  *Usage
+	parent *usageTracker
+}
+
+// TrackUsage returns a context that adds the usage that models report through
+// [ReportUsage] to usage. Tracked contexts can be nested; the usage is then
+// added to the usage of every tracking context. usage must not be read before
+// the models that use the context have returned.
+func TrackUsage(ctx context.Context, usage *Usage) context.Context {
+	parent, _ := ctx.Value(usageContextKey{}).(*usageTracker)
+	return context.WithValue(ctx, usageContextKey{}, &usageTracker{
+		mux:    &sync.Mutex{},
+		usage:  usage,
+		parent: parent,
+	})
+}
+
+// ReportUsage adds the usage of a request to the usage that is tracked by the
+// context. Models call ReportUsage after every successful request. Calls with
+// contexts that are not tracked using [TrackUsage] are ignored.
+func ReportUsage(ctx context.Context, usage Usage) {
+	t, _ := ctx.Value(usageContextKey{}).(*usageTracker)
+	for ; t != nil; t = t.parent {
+		t.mux.Lock()
+		*t.usage = t.usage.Add(usage)
+		t.mux.Unlock()
+	}
+}
@@ -0,0 +1,64 @@
+package dragoman_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/modernice/dragoman"
+)
+
+func TestTrackUsage(t *testing.T) {
+	var outer, inner dragoman.Usage
+	ctx := dragoman.TrackUsage(context.Background(), &outer)
+	innerCtx := dragoman.TrackUsage(ctx, &inner)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dragoman.ReportUsage(innerCtx, dragoman.Usage{Requests: 1, PromptTokens: 10, CompletionTokens: 5})
+		}()
+	}
+	wg.Wait()
+
+	dragoman.ReportUsage(ctx, dragoman.Usage{Requests: 1, PromptTokens: 1, CompletionTokens: 1})
+	dragoman.ReportUsage(context.Background(), dragoman.Usage{Requests: 1})
+
+	if want := (dragoman.Usage{Requests: 10, PromptTokens: 100, CompletionTokens: 50}); inner != want {
+		t.Fatalf("inner usage should be %+v; got %+v", want, inner)
+	}
+	if want := (dragoman.Usage{Requests: 11, PromptTokens: 101, CompletionTokens: 51}); outer != want {
+		t.Fatalf("outer usage should be %+v; got %+v", want, outer)
+	}
+	if outer.TotalTokens() != 152 {
+		t.Fatalf("TotalTokens() should return %d; got %d", 152, outer.TotalTokens())
+	}
+}
+
+func TestTranslator_TranslateWithUsage(t *testing.T) {
+	model := dragoman.ModelFunc(func(ctx context.Context, prompt string) (string, error) {
+		dragoman.ReportUsage(ctx, dragoman.Usage{Requests: 1, PromptTokens: 20, CompletionTokens: 3})
+		return "# Hallo", nil
+	})
+
+	var total dragoman.Usage
+	ctx := dragoman.TrackUsage(context.Background(), &total)
+
+	result, err := dragoman.NewTranslator(model).TranslateWithUsage(ctx, dragoman.TranslateParams{
+		Document:    "# Hello\n\n# Hello",
+		Target:      "German",
+		SplitChunks: []string{"# "},
+	})
+	if err != nil {
+		t.Fatalf("TranslateWithUsage() failed: %v", err)
+	}
+
+	if want := "# Hallo\n\n# Hallo\n"; result.Text != want {
+		t.Fatalf("Text should be %q; got %q", want, result.Text)
+	}
+	if want := (dragoman.Usage{Requests: 2, PromptTokens: 40, CompletionTokens: 6}); result.Usage != want || total != want {
+		t.Fatalf("Usage should be %+v; got %+v (tracked %+v)", want, result.Usage, total)
+	}
+}
@@ -0,0 +1,206 @@
+package dragoman
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidTranslation is returned by a [Validator] if a translation does not
+// preserve the structure of its source.
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// DefaultPlaceholderPatterns are the regular expressions of the placeholders
+// that are verified by [VerifyPlaceholders] if no patterns are given: template
+// actions like "{{.Var}}", brace placeholders like "{name}" and printf-style
+// format specifiers like "%s" or "%1$s".
+var DefaultPlaceholderPatterns = []string{
+	`\{\{[^{}]*\}\}`,
+	`\{[^{}\s]+\}`,
+	`%(\d+\$)?[-+#0]*\d*(\.\d+)?[sdfiuxXoeEgGcqvp@]`,
+}
+
+// Validator checks the translation of a chunk against its source and returns
+// an error if the translation is invalid. Validators should wrap
+// [ErrInvalidTranslation] for translations that may succeed when translated
+// again.
+type Validator func(source, translated string) error
+
+// ValidateJSON is a [Validator] for JSON documents. It parses the translation
+// and compares its key tree against the source using [JSONDiff]. The
+// translation is invalid if it is not valid JSON, if keys or array elements
+// are missing or were added, or if an object or array was replaced by a
+// different type. Sources that are not JSON objects or arrays cannot be
+// validated and always pass.
+func ValidateJSON(source, translated string) error {
+	var sourceValue any
+	if err := json.Unmarshal([]byte(source), &sourceValue); err != nil {
+		return nil
+	}
+
+	var translatedValue any
+	if err := json.Unmarshal([]byte(translated), &translatedValue); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+
+	switch sourceValue.(type) {
+	case map[string]any:
+		translatedMap, ok := translatedValue.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: expected a JSON object", ErrInvalidTranslation)
+		}
+		return validateJSONKeys(sourceValue.(map[string]any), translatedMap, 0)
+	case []any:
+		if _, ok := translatedValue.([]any); !ok {
+			return fmt.Errorf("%w: expected a JSON array", ErrInvalidTranslation)
+		}
+		// Arrays are compared as the value of a key, whose name is removed
+		// from the reported paths.
+		return validateJSONKeys(map[string]any{"": sourceValue}, map[string]any{"": translatedValue}, 1)
+	default:
+		return nil
+	}
+}
+
+// validateJSONKeys compares the key trees of the source and the translation.
+// The first trim elements of the paths of missing or added keys are removed.
+func validateJSONKeys(source, translated map[string]any, trim int) error {
+	missing, err := JSONDiff(source, translated)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing keys %s", ErrInvalidTranslation, formatPaths(trimPaths(missing, trim)))
+	}
+
+	added, err := JSONDiff(translated, source)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+
+	if len(added) > 0 {
+		return fmt.Errorf("%w: unexpected keys %s", ErrInvalidTranslation, formatPaths(trimPaths(added, trim)))
+	}
+
+	return nil
+}
+
+func trimPaths(paths []JSONPath, n int) []JSONPath {
+	return mapSlice(paths, func(p JSONPath) JSONPath {
+		return p[n:]
+	})
+}
+
+// ValidateAll returns a [Validator] that runs the given validators in order and
+// returns the first error. Nil validators are skipped.
+func ValidateAll(validators ...Validator) Validator {
+	return func(source, translated string) error {
+		for _, validate := range validators {
+			if validate == nil {
+				continue
+			}
+			if err := validate(source, translated); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// VerifyPlaceholders checks that every placeholder of the source that matches
+// one of the regular expression patterns appears in the translation at least
+// as often as in the source. If patterns is empty, the
+// [DefaultPlaceholderPatterns] are used. If the source and the translation are
+// JSON objects, the placeholders are compared value by value and the error
+// names the keys whose values lost placeholders. The error wraps
+// [ErrInvalidTranslation].
+func VerifyPlaceholders(source, translated string, patterns []string) error {
+	validate, err := ValidatePlaceholders(patterns)
+	if err != nil {
+		return err
+	}
+	return validate(source, translated)
+}
+
+// ValidatePlaceholders returns a [Validator] that verifies the placeholders of
+// translations like [VerifyPlaceholders]. It returns an error if one of the
+// patterns is not a valid regular expression.
+func ValidatePlaceholders(patterns []string) (Validator, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultPlaceholderPatterns
+	}
+
+	// Patterns are tried in order, so that "{{.Var}}" is matched as a whole
+	// before "{.Var}" could match.
+	alternatives := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid placeholder pattern %q: %w", pattern, err)
+		}
+		alternatives[i] = "(?:" + pattern + ")"
+	}
+	re := regexp.MustCompile(strings.Join(alternatives, "|"))
+
+	return func(source, translated string) error {
+		var sourceMap, translatedMap map[string]any
+		if json.Unmarshal([]byte(source), &sourceMap) != nil || json.Unmarshal([]byte(translated), &translatedMap) != nil {
+			if lost := lostPlaceholders(re, source, translated); len(lost) > 0 {
+				return fmt.Errorf("%w: lost placeholders %s", ErrInvalidTranslation, strings.Join(lost, ", "))
+			}
+			return nil
+		}
+
+		var keys []string
+		for _, path := range allKeys(sourceMap) {
+			text, ok := jsonValue(sourceMap, path).(string)
+			if !ok {
+				continue
+			}
+			translatedText, _ := jsonValue(translatedMap, path).(string)
+			if lost := lostPlaceholders(re, text, translatedText); len(lost) > 0 {
+				keys = append(keys, fmt.Sprintf("%s (%s)", quote(strings.Join(path, ".")), strings.Join(lost, ", ")))
+			}
+		}
+
+		if len(keys) > 0 {
+			slices.Sort(keys)
+			return fmt.Errorf("%w: lost placeholders in %s", ErrInvalidTranslation, strings.Join(keys, ", "))
+		}
+
+		return nil
+	}, nil
+}
+
+// lostPlaceholders returns the placeholders of the source that occur less
+// often in the translation.
+func lostPlaceholders(re *regexp.Regexp, source, translated string) []string {
+	counts := make(map[string]int)
+	for _, p := range re.FindAllString(translated, -1) {
+		counts[p]++
+	}
+
+	var lost []string
+	for _, p := range re.FindAllString(source, -1) {
+		if counts[p] > 0 {
+			counts[p]--
+			continue
+		}
+		if !slices.Contains(lost, p) {
+			lost = append(lost, p)
+		}
+	}
+
+	return lost
+}
+
+func formatPaths(paths []JSONPath) string {
+	formatted := mapSlice(paths, func(p JSONPath) string {
+		return quote(strings.Join(p, "."))
+	})
+	slices.Sort(formatted)
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,169 @@
+package dragoman_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modernice/dragoman"
+)
+
+func TestValidateJSON(t *testing.T) {
+	source := `{"hello": "Hello", "contact": {"email": "Email"}}`
+
+	tests := []struct {
+		name       string
+		translated string
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			translated: `{"hello": "Hallo", "contact": {"email": "E-Mail"}}`,
+		},
+		{
+			name:       "invalid JSON",
+			translated: `{"hello": "Hallo", "contact": {"email": "E-Mail"}`,
+			wantErr:    true,
+		},
+		{
+			name:       "missing key",
+			translated: `{"hello": "Hallo", "contact": {}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "unexpected key",
+			translated: `{"hallo": "Hallo", "hello": "Hallo", "contact": {"email": "E-Mail"}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "changed structure",
+			translated: `{"hello": "Hallo", "contact": "E-Mail"}`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dragoman.ValidateJSON(source, tt.translated)
+			if tt.wantErr && !errors.Is(err, dragoman.ErrInvalidTranslation) {
+				t.Fatalf("ValidateJSON() should fail with %q; got %v", dragoman.ErrInvalidTranslation, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("ValidateJSON(): %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateJSON_array(t *testing.T) {
+	source := `["Hello", {"title": "World"}]`
+
+	if err := dragoman.ValidateJSON(source, `["Hallo", {"title": "Welt"}]`); err != nil {
+		t.Fatalf("ValidateJSON(): %v", err)
+	}
+
+	for _, translated := range []string{
+		`["Hallo"]`,
+		`["Hallo", {"title": "Welt"}, "Extra"]`,
+		`["Hallo", {"titel": "Welt"}]`,
+		`{"0": "Hallo"}`,
+	} {
+		err := dragoman.ValidateJSON(source, translated)
+		if !errors.Is(err, dragoman.ErrInvalidTranslation) {
+			t.Fatalf("ValidateJSON() should reject %s; got %v", translated, err)
+		}
+	}
+}
+
+func TestValidateJSON_invalidSource(t *testing.T) {
+	if err := dragoman.ValidateJSON("Hello, World!", "Hallo, Welt!"); err != nil {
+		t.Fatalf("sources that are not JSON should not be validated; got %v", err)
+	}
+}
+
+func TestTranslator_Translate_validationRetries(t *testing.T) {
+	responses := []string{`{"hallo": "Hello"}`, `{"hello": "Hello"}`}
+	model := dragoman.ModelFunc(func(context.Context, string) (string, error) {
+		resp := responses[0]
+		responses = responses[1:]
+		return resp, nil
+	})
+
+	result, err := dragoman.NewTranslator(model).Translate(context.Background(), dragoman.TranslateParams{
+		Document:          `{"hello": "Hallo"}`,
+		Validate:          dragoman.ValidateJSON,
+		ValidationRetries: 1,
+	})
+	if err != nil {
+		t.Fatalf("Translate(): %v", err)
+	}
+
+	if want := "{\"hello\": \"Hello\"}\n"; result != want {
+		t.Fatalf("Translate(): got %q; want %q", result, want)
+	}
+}
+
+func TestVerifyPlaceholders(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		translated string
+		patterns   []string
+		wantErr    string
+	}{
+		{
+			name:       "kept",
+			source:     "Hello {name}, you have %d new {{.Kind}} messages (%1$s).",
+			translated: "Hallo {name}, du hast %d neue {{.Kind}}-Nachrichten (%1$s).",
+		},
+		{
+			name:       "lost",
+			source:     "Hello {name}, you have %d messages.",
+			translated: "Hallo, du hast %d Nachrichten.",
+			wantErr:    "lost placeholders {name}",
+		},
+		{
+			name:       "repeated",
+			source:     "{name} and {name}",
+			translated: "{name} und er",
+			wantErr:    "lost placeholders {name}",
+		},
+		{
+			name:       "JSON",
+			source:     `{"greeting": "Hello {name}", "nested": {"count": "%d items"}, "ok": "{x}"}`,
+			translated: `{"greeting": "Hallo", "nested": {"count": "Elemente"}, "ok": "{x}"}`,
+			wantErr:    `lost placeholders in "greeting" ({name}), "nested.count" (%d)`,
+		},
+		{
+			name:       "custom patterns",
+			source:     "Hello :name, {ignored}",
+			translated: "Hallo",
+			patterns:   []string{`:[a-z]+`},
+			wantErr:    "lost placeholders :name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dragoman.VerifyPlaceholders(tt.source, tt.translated, tt.patterns)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyPlaceholders(): %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, dragoman.ErrInvalidTranslation) {
+				t.Fatalf("VerifyPlaceholders() should fail with %q; got %v", dragoman.ErrInvalidTranslation, err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error should contain %q; got %q", tt.wantErr, err)
+			}
+		})
+	}
+
+	if err := dragoman.VerifyPlaceholders("a", "b", []string{"("}); err == nil || errors.Is(err, dragoman.ErrInvalidTranslation) {
+		t.Fatalf("VerifyPlaceholders() should fail for invalid patterns; got %v", err)
+	}
+}
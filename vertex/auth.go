@@ -0,0 +1,320 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Scope is the OAuth scope of the access tokens of the Vertex AI API.
+	Scope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// CredentialsEnv is the environment variable that contains the path to
+	// the credentials file of Application Default Credentials.
+	CredentialsEnv = "GOOGLE_APPLICATION_CREDENTIALS"
+
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	metadataHost    = "metadata.google.internal"
+
+	// tokenExpiryDelta is the time before their expiry at which access tokens
+	// are refreshed, so that requests do not fail with expired tokens.
+	tokenExpiryDelta = time.Minute
+)
+
+// credentialsFile is a credentials file of a service account or of the
+// Application Default Credentials of a gcloud user.
+type credentialsFile struct {
+	Type string `json:"type"`
+
+	// Service accounts
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+
+	// Users
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// credentials create the access tokens of requests.
+type credentials struct {
+	file   *credentialsFile // nil for the metadata server
+	key    *rsa.PrivateKey
+	client *http.Client
+
+	mux    sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// findCredentials returns the Application Default Credentials: the
+// credentials file at path, the file of the GOOGLE_APPLICATION_CREDENTIALS
+// environment variable, the file of `gcloud auth application-default login`,
+// or the service account of the metadata server of Google Cloud, in this
+// order.
+func findCredentials(path string, client *http.Client) (*credentials, error) {
+	if path == "" {
+		path = os.Getenv(CredentialsEnv)
+	}
+	if path == "" {
+		if wellKnown := wellKnownFile(); wellKnown != "" {
+			if _, err := os.Stat(wellKnown); err == nil {
+				path = wellKnown
+			}
+		}
+	}
+	if path == "" {
+		return &credentials{client: client}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+
+	return parseCredentials(data, client)
+}
+
+func parseCredentials(data []byte, client *http.Client) (*credentials, error) {
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	if f.TokenURI == "" {
+		f.TokenURI = defaultTokenURI
+	}
+
+	creds := credentials{file: &f, client: client}
+
+	switch f.Type {
+	case "service_account":
+		key, err := parsePrivateKey(f.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key of %q: %w", f.ClientEmail, err)
+		}
+		creds.key = key
+	case "authorized_user":
+		if f.RefreshToken == "" {
+			return nil, errors.New("parse credentials: missing refresh token")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported credentials type %q", f.Type)
+	}
+
+	return &creds, nil
+}
+
+// wellKnownFile returns the path of the credentials file that is written by
+// `gcloud auth application-default login`.
+func wellKnownFile() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, "gcloud", "application_default_credentials.json")
+		}
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+func parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA key; got %T", parsed)
+	}
+	return key, nil
+}
+
+// project returns the project of the credentials, if known.
+func (c *credentials) project() string {
+	if c.file == nil {
+		return ""
+	}
+	if c.file.ProjectID != "" {
+		return c.file.ProjectID
+	}
+	return c.file.QuotaProjectID
+}
+
+// accessToken returns a valid access token, which is cached until shortly
+// before it expires.
+func (c *credentials) accessToken(ctx context.Context) (string, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenExpiryDelta).Before(c.expiry) {
+		return c.token, nil
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+	switch {
+	case c.file == nil:
+		req, err = c.metadataRequest(ctx)
+	case c.key != nil:
+		req, err = c.serviceAccountRequest(ctx)
+	default:
+		req, err = c.refreshRequest(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token, err := c.fetchToken(req)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token.AccessToken
+	c.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return c.token, nil
+}
+
+// serviceAccountRequest exchanges a JWT that is signed with the key of the
+// service account for an access token.
+func (c *credentials) serviceAccountRequest(ctx context.Context) (*http.Request, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": c.file.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   c.file.ClientEmail,
+		"scope": Scope,
+		"aud":   c.file.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign token request: %w", err)
+	}
+
+	return formRequest(ctx, c.file.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	})
+}
+
+// refreshRequest exchanges the refresh token of a gcloud user for an access
+// token.
+func (c *credentials) refreshRequest(ctx context.Context) (*http.Request, error) {
+	return formRequest(ctx, c.file.TokenURI, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.file.ClientID},
+		"client_secret": {c.file.ClientSecret},
+		"refresh_token": {c.file.RefreshToken},
+	})
+}
+
+// metadataRequest requests an access token of the service account that is
+// attached to the Google Cloud resource, like a Compute Engine instance or a
+// Cloud Run service, from the metadata server.
+func (c *credentials) metadataRequest(ctx context.Context) (*http.Request, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = metadataHost
+	}
+
+	u := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token?scopes=" + url.QueryEscape(Scope)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	return req, nil
+}
+
+func formRequest(ctx context.Context, uri string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c *credentials) fetchToken(req *http.Request) (tokenResponse, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if c.file == nil {
+			return tokenResponse{}, fmt.Errorf("no Application Default Credentials found (set %s or run `gcloud auth application-default login`): %w", CredentialsEnv, err)
+		}
+		return tokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("read token response: %w", err)
+	}
+
+	var token tokenResponse
+	json.Unmarshal(data, &token)
+
+	if resp.StatusCode != http.StatusOK {
+		msg := token.ErrorDescription
+		if msg == "" {
+			msg = token.Error
+		}
+		if msg == "" {
+			msg = strings.TrimSpace(string(data))
+		}
+		return tokenResponse{}, &APIError{StatusCode: resp.StatusCode, Message: "fetch access token: " + msg}
+	}
+
+	if token.AccessToken == "" {
+		return tokenResponse{}, errors.New("fetch access token: empty access token")
+	}
+
+	return token, nil
+}
@@ -0,0 +1,445 @@
+// Package vertex provides a dragoman.Model for the Gemini models of Vertex AI
+// on Google Cloud. Unlike the public Gemini API, Vertex AI authenticates with
+// Application Default Credentials, like the key file of a service account,
+// the credentials of `gcloud auth application-default login`, or the service
+// account of the Google Cloud resource that dragoman runs on.
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/internal/backoff"
+	"github.com/modernice/dragoman/internal/logging"
+)
+
+const (
+	// DefaultModel is the default Gemini model.
+	DefaultModel = "gemini-2.5-flash"
+
+	// DefaultLocation is the default Google Cloud region of requests.
+	DefaultLocation = "us-central1"
+
+	// DefaultTimeout is the default timeout of a request to Vertex AI.
+	DefaultTimeout = 3 * time.Minute
+
+	// DefaultMaxRetries is the default number of times a failed request is
+	// retried if Vertex AI responds with a rate limit or server error.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryBackoff is the default base delay between retries. The delay
+	// doubles with every attempt up to 30 seconds and is randomized with
+	// jitter.
+	DefaultRetryBackoff = time.Second
+)
+
+// Client generates responses using a Gemini model of Vertex AI. It implements
+// the dragoman.Model and dragoman.MessageModel interfaces.
+type Client struct {
+	model        string
+	project      string
+	location     string
+	endpoint     string
+	credsFile    string
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+	verbose      bool
+	logger       *slog.Logger
+	client       *http.Client
+
+	credsOnce sync.Once
+	creds     *credentials
+	credsErr  error
+}
+
+// Option configures a [Client].
+type Option func(*Client)
+
+// Model sets the Gemini model, like "gemini-2.5-pro".
+func Model(model string) Option {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// Project sets the Google Cloud project of requests. By default, the project
+// of the GOOGLE_CLOUD_PROJECT environment variable or of the credentials is
+// used.
+func Project(project string) Option {
+	return func(c *Client) {
+		c.project = project
+	}
+}
+
+// Location sets the Google Cloud region of requests, like "europe-west4" or
+// "global". By default, the region of the GOOGLE_CLOUD_LOCATION environment
+// variable or [DefaultLocation] is used.
+func Location(location string) Option {
+	return func(c *Client) {
+		c.location = location
+	}
+}
+
+// Endpoint sets the base URL of the Vertex AI API, for example for Private
+// Service Connect. By default, the regional endpoint of the location is used,
+// like "https://europe-west4-aiplatform.googleapis.com".
+func Endpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// CredentialsFile sets the path of the credentials file, like the key file of
+// a service account, instead of looking up the Application Default
+// Credentials.
+func CredentialsFile(path string) Option {
+	return func(c *Client) {
+		c.credsFile = path
+	}
+}
+
+// Timeout sets the timeout of a request to Vertex AI.
+func Timeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// MaxRetries sets the maximum number of times a failed request is retried if
+// Vertex AI responds with a rate limit (429) or server error (5xx). A value of
+// 0 disables retries.
+func MaxRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// RetryBackoff sets the base delay between retries of failed requests.
+func RetryBackoff(backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retryBackoff = backoff
+	}
+}
+
+// Verbose enables debug logs of API requests. The logs are written to the
+// output of the standard logger unless a [Logger] is configured.
+func Verbose(verbose bool) Option {
+	return func(c *Client) {
+		c.verbose = verbose
+	}
+}
+
+// Logger sets the logger that receives the debug records of API requests and
+// the warnings about retried requests. By default, nothing is logged.
+func Logger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// HTTPClient sets the HTTP client that is used for requests to Vertex AI and
+// for the access tokens of the credentials.
+func HTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// New creates a new [*Client] with the given options. The credentials are
+// looked up on the first request.
+func New(opts ...Option) *Client {
+	c := Client{
+		model:        DefaultModel,
+		timeout:      DefaultTimeout,
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+		client:       http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.project == "" {
+		c.project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+
+	if c.location == "" {
+		c.location = os.Getenv("GOOGLE_CLOUD_LOCATION")
+	}
+	if c.location == "" {
+		c.location = DefaultLocation
+	}
+
+	if c.endpoint == "" {
+		c.endpoint = "https://" + c.location + "-aiplatform.googleapis.com"
+		if c.location == "global" {
+			c.endpoint = "https://aiplatform.googleapis.com"
+		}
+	}
+
+	switch {
+	case c.logger != nil:
+	case c.verbose:
+		c.logger = slog.New(logging.NewHandler(log.Writer(), "Vertex AI"))
+	default:
+		c.logger = logging.Discard()
+	}
+
+	return &c
+}
+
+// APIError is returned if Vertex AI or the token endpoint of the credentials
+// respond with an error status, for example because of missing permissions or
+// an exceeded quota.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *APIError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("vertex: %d %s", err.StatusCode, http.StatusText(err.StatusCode))
+	}
+	return fmt.Sprintf("vertex: %d %s: %s", err.StatusCode, http.StatusText(err.StatusCode), err.Message)
+}
+
+// IsAPIError reports whether the error was caused by Vertex AI, for example
+// because of invalid credentials, an exceeded quota or a failed connection to
+// the API.
+func IsAPIError(err error) bool {
+	var (
+		apiErr *APIError
+		urlErr *url.Error
+	)
+	return errors.As(err, &apiErr) || errors.As(err, &urlErr)
+}
+
+// RefusalError is returned by [Client.Chat] if Vertex AI blocked a prompt or
+// a response because of its safety filters.
+type RefusalError struct {
+	Reason string
+}
+
+func (err *RefusalError) Error() string {
+	return "vertex: blocked: " + err.Reason
+}
+
+// Refused reports that the prompt was refused, so that the translator can
+// skip or retry the chunk.
+func (err *RefusalError) Refused() bool {
+	return true
+}
+
+// Chat sends the prompt to the model and returns its response. Requests that
+// fail because of rate limits or server errors are retried with exponential
+// backoff, up to the configured number of retries. Prompts that are blocked
+// by the safety filters return a [*RefusalError].
+func (c *Client) Chat(ctx context.Context, prompt string) (string, error) {
+	return c.ChatMessages(ctx, []dragoman.Message{{Role: dragoman.RoleUser, Content: prompt}})
+}
+
+// ChatMessages is like Chat, but sends a conversation of messages. System
+// messages are sent as the system instruction of the request.
+func (c *Client) ChatMessages(ctx context.Context, msgs []dragoman.Message) (string, error) {
+	req := generateRequest{GenerationConfig: generationConfig{CandidateCount: 1}}
+
+	var system []string
+	for _, msg := range msgs {
+		switch msg.Role {
+		case dragoman.RoleSystem:
+			system = append(system, msg.Content)
+		case dragoman.RoleAssistant:
+			req.Contents = append(req.Contents, content{Role: "model", Parts: []part{{Text: msg.Content}}})
+		default:
+			req.Contents = append(req.Contents, content{Role: "user", Parts: []part{{Text: msg.Content}}})
+		}
+	}
+	if len(system) > 0 {
+		req.SystemInstruction = &content{Parts: []part{{Text: strings.Join(system, "\n\n")}}}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.generate(ctx, req)
+		if err == nil || attempt >= c.maxRetries || !isRetryable(err) {
+			return resp, err
+		}
+
+		delay := backoff.Delay(c.retryBackoff, attempt)
+		c.logger.WarnContext(ctx, "retry request", "error", err, "delay", delay, "attempt", attempt+1, "max_retries", c.maxRetries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+type generateRequest struct {
+	Contents          []content        `json:"contents"`
+	SystemInstruction *content         `json:"systemInstruction,omitempty"`
+	GenerationConfig  generationConfig `json:"generationConfig"`
+}
+
+type generationConfig struct {
+	CandidateCount int `json:"candidateCount"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type part struct {
+	Text string `json:"text"`
+}
+
+type generateResponse struct {
+	Candidates []struct {
+		Content      content `json:"content"`
+		FinishReason string  `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// blockedFinishReasons are the finish reasons of responses that were stopped
+// by the safety filters.
+var blockedFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+func (c *Client) credentials() (*credentials, error) {
+	c.credsOnce.Do(func() {
+		c.creds, c.credsErr = findCredentials(c.credsFile, c.client)
+		if c.credsErr == nil && c.project == "" {
+			c.project = c.creds.project()
+		}
+	})
+	return c.creds, c.credsErr
+}
+
+func (c *Client) generate(ctx context.Context, req generateRequest) (string, error) {
+	creds, err := c.credentials()
+	if err != nil {
+		return "", err
+	}
+	if c.project == "" {
+		return "", errors.New("vertex: missing Google Cloud project (set GOOGLE_CLOUD_PROJECT)")
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	token, err := creds.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	u := fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models/%s:generateContent", c.endpoint, url.PathEscape(c.project), url.PathEscape(c.location), url.PathEscape(c.model))
+
+	c.logger.DebugContext(ctx, "generate content", "model", c.model, "project", c.project, "location", c.location, "messages", len(req.Contents))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var msg struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &msg)
+		return "", &APIError{StatusCode: resp.StatusCode, Message: msg.Error.Message}
+	}
+
+	var result generateResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	if reason := result.PromptFeedback.BlockReason; reason != "" {
+		return "", &RefusalError{Reason: "the prompt was blocked (" + reason + ")"}
+	}
+
+	if len(result.Candidates) == 0 {
+		return "", errors.New("vertex: no candidates in response")
+	}
+
+	dragoman.ReportUsage(ctx, dragoman.Usage{
+		Requests:         1,
+		PromptTokens:     result.UsageMetadata.PromptTokenCount,
+		CompletionTokens: result.UsageMetadata.CandidatesTokenCount,
+	})
+
+	candidate := result.Candidates[0]
+	switch reason := candidate.FinishReason; {
+	case blockedFinishReasons[reason]:
+		return "", &RefusalError{Reason: "the response was blocked (" + reason + ")"}
+	case reason == "MAX_TOKENS":
+		return "", fmt.Errorf("max tokens exceeded")
+	}
+
+	var text strings.Builder
+	for _, p := range candidate.Content.Parts {
+		text.WriteString(p.Text)
+	}
+
+	return text.String(), nil
+}
+
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,248 @@
+package vertex_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/dragoman"
+	"github.com/modernice/dragoman/vertex"
+)
+
+func TestClient_ChatMessages(t *testing.T) {
+	var tokenRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		r.ParseForm()
+		if got := r.Form.Get("grant_type"); got != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("unexpected grant type %q", got)
+		}
+		if parts := strings.Split(r.Form.Get("assertion"), "."); len(parts) != 3 {
+			t.Errorf("expected a signed JWT; got %q", r.Form.Get("assertion"))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+	})
+
+	var got map[string]any
+	mux.HandleFunc("/v1/projects/my-project/locations/europe-west4/publishers/google/models/gemini-test:generateContent", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("unexpected Authorization header %q", auth)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [{"text": "Hallo "}, {"text": "Welt"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 3}
+		}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+
+	client := vertex.New(
+		vertex.Model("gemini-test"),
+		vertex.Location("europe-west4"),
+		vertex.Endpoint(srv.URL),
+		vertex.CredentialsFile(serviceAccount(t, srv.URL+"/token")),
+	)
+
+	var usage dragoman.Usage
+	ctx := dragoman.TrackUsage(context.Background(), &usage)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.ChatMessages(ctx, []dragoman.Message{
+			{Role: dragoman.RoleSystem, Content: "Translate to German."},
+			{Role: dragoman.RoleUser, Content: "Hello"},
+			{Role: dragoman.RoleAssistant, Content: "Hallo"},
+			{Role: dragoman.RoleUser, Content: "Hello world"},
+		})
+		if err != nil {
+			t.Fatalf("ChatMessages() failed: %v", err)
+		}
+		if resp != "Hallo Welt" {
+			t.Fatalf("expected response %q; got %q", "Hallo Welt", resp)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("expected the access token to be cached; got %d token requests", tokenRequests)
+	}
+
+	if want := (dragoman.Usage{Requests: 2, PromptTokens: 20, CompletionTokens: 6}); usage != want {
+		t.Errorf("expected usage %+v; got %+v", want, usage)
+	}
+
+	want := map[string]any{
+		"contents": []any{
+			map[string]any{"role": "user", "parts": []any{map[string]any{"text": "Hello"}}},
+			map[string]any{"role": "model", "parts": []any{map[string]any{"text": "Hallo"}}},
+			map[string]any{"role": "user", "parts": []any{map[string]any{"text": "Hello world"}}},
+		},
+		"systemInstruction": map[string]any{"parts": []any{map[string]any{"text": "Translate to German."}}},
+		"generationConfig":  map[string]any{"candidateCount": float64(1)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected request (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Chat_authorizedUser(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "refresh" {
+				t.Errorf("unexpected token request %v", r.Form)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "user-token", "expires_in": 3600})
+			return
+		}
+
+		if !strings.Contains(r.URL.Path, "/projects/quota-project/") {
+			t.Errorf("expected the quota project of the credentials; got path %q", r.URL.Path)
+		}
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}, "finishReason": "STOP"}]}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeJSON(t, path, map[string]string{
+		"type":             "authorized_user",
+		"client_id":        "id",
+		"client_secret":    "secret",
+		"refresh_token":    "refresh",
+		"quota_project_id": "quota-project",
+		"token_uri":        srv.URL + "/token",
+	})
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv(vertex.CredentialsEnv, path)
+
+	resp, err := vertex.New(vertex.Endpoint(srv.URL)).Chat(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+	if resp != "ok" {
+		t.Fatalf("expected response %q; got %q", "ok", resp)
+	}
+}
+
+func TestClient_Chat_blocked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		w.Write([]byte(`{"promptFeedback": {"blockReason": "SAFETY"}}`))
+	}))
+	defer srv.Close()
+
+	client := vertex.New(vertex.Project("p"), vertex.Endpoint(srv.URL), vertex.CredentialsFile(serviceAccount(t, srv.URL+"/token")))
+
+	_, err := client.Chat(context.Background(), "Hello")
+
+	var refusal *vertex.RefusalError
+	if !errors.As(err, &refusal) {
+		t.Fatalf("expected a RefusalError; got %v", err)
+	}
+}
+
+func TestClient_Chat_retry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+			return
+		}
+
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"code": 429, "message": "Resource exhausted"}}`))
+			return
+		}
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}, "finishReason": "STOP"}]}`))
+	}))
+	defer srv.Close()
+
+	client := vertex.New(
+		vertex.Project("p"),
+		vertex.Endpoint(srv.URL),
+		vertex.CredentialsFile(serviceAccount(t, srv.URL+"/token")),
+		vertex.RetryBackoff(0),
+	)
+
+	if _, err := client.Chat(context.Background(), "Hello"); err != nil {
+		t.Fatalf("Chat() failed: %v", err)
+	}
+
+	if requests != 2 {
+		t.Fatalf("expected 2 requests; got %d", requests)
+	}
+}
+
+func TestClient_Chat_tokenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "Invalid JWT Signature."}`))
+	}))
+	defer srv.Close()
+
+	client := vertex.New(vertex.Project("p"), vertex.Endpoint(srv.URL), vertex.CredentialsFile(serviceAccount(t, srv.URL+"/token")))
+
+	_, err := client.Chat(context.Background(), "Hello")
+	if !vertex.IsAPIError(err) {
+		t.Fatalf("expected an API error; got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Invalid JWT Signature.") {
+		t.Fatalf("expected the error description in the error; got %v", err)
+	}
+}
+
+func serviceAccount(t *testing.T, tokenURI string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	writeJSON(t, path, map[string]string{
+		"type":           "service_account",
+		"project_id":     "my-project",
+		"private_key_id": "key-id",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "dragoman@my-project.iam.gserviceaccount.com",
+		"token_uri":      tokenURI,
+	})
+
+	return path
+}
+
+func writeJSON(t *testing.T, path string, v any) {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+package vertex
+
+import "github.com/modernice/dragoman/provider"
+
+func init() {
+	provider.Register("vertex", func(cfg provider.Config) (provider.Backend, error) {
+		opts := []Option{Timeout(cfg.Timeout), MaxRetries(cfg.MaxRetries), Verbose(cfg.Verbose)}
+		if cfg.Model != "" {
+			opts = append(opts, Model(cfg.Model))
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, Endpoint(cfg.BaseURL))
+		}
+		return provider.Backend{Model: New(opts...)}, nil
+	})
+}